@@ -150,13 +150,14 @@ func (cf *rpcClientFactory) NewMatchingClientWithTimeout(
 	}
 
 	peerResolver := matching.NewPeerResolver(cf.resolver, namedPort)
+	partitionConfigProvider := matching.NewPartitionConfigProvider(rawClient, peerResolver, cf.dynConfig, cf.logger)
 
 	client := matching.NewClient(
 		timeout,
 		longPollTimeout,
 		rawClient,
 		peerResolver,
-		matching.NewLoadBalancer(domainIDToName, cf.dynConfig),
+		matching.NewLoadBalancer(domainIDToName, cf.dynConfig, partitionConfigProvider),
 	)
 	if errorRate := cf.dynConfig.GetFloat64Property(dynamicconfig.MatchingErrorInjectionRate)(); errorRate != 0 {
 		client = errorinjectors.NewMatchingClient(client, errorRate, cf.logger)
@@ -60,9 +60,10 @@ type (
 	}
 
 	defaultLoadBalancer struct {
-		nReadPartitions  dynamicconfig.IntPropertyFnWithTaskListInfoFilters
-		nWritePartitions dynamicconfig.IntPropertyFnWithTaskListInfoFilters
-		domainIDToName   func(string) (string, error)
+		nReadPartitions         dynamicconfig.IntPropertyFnWithTaskListInfoFilters
+		nWritePartitions        dynamicconfig.IntPropertyFnWithTaskListInfoFilters
+		domainIDToName          func(string) (string, error)
+		partitionConfigProvider PartitionConfigProvider
 	}
 )
 
@@ -71,11 +72,13 @@ type (
 func NewLoadBalancer(
 	domainIDToName func(string) (string, error),
 	dc *dynamicconfig.Collection,
+	partitionConfigProvider PartitionConfigProvider,
 ) LoadBalancer {
 	return &defaultLoadBalancer{
-		domainIDToName:   domainIDToName,
-		nReadPartitions:  dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingNumTasklistReadPartitions),
-		nWritePartitions: dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingNumTasklistWritePartitions),
+		domainIDToName:          domainIDToName,
+		nReadPartitions:         dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingNumTasklistReadPartitions),
+		nWritePartitions:        dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingNumTasklistWritePartitions),
+		partitionConfigProvider: partitionConfigProvider,
 	}
 }
 
@@ -89,6 +92,9 @@ func (lb *defaultLoadBalancer) PickWritePartition(
 	if err != nil {
 		return taskList.GetName()
 	}
+	if n, ok := lb.getAdaptiveNumPartitions(domainName, taskList, taskListType, forwardedFrom); ok {
+		return lb.pickPartition(taskList, forwardedFrom, n)
+	}
 	nPartitions := lb.nWritePartitions(domainName, taskList.GetName(), taskListType)
 
 	// checks to make sure number of writes never exceeds number of reads
@@ -109,11 +115,29 @@ func (lb *defaultLoadBalancer) PickReadPartition(
 	if err != nil {
 		return taskList.GetName()
 	}
+	if n, ok := lb.getAdaptiveNumPartitions(domainName, taskList, taskListType, forwardedFrom); ok {
+		return lb.pickPartition(taskList, forwardedFrom, n)
+	}
 	n := lb.nReadPartitions(domainName, taskList.GetName(), taskListType)
 	return lb.pickPartition(taskList, forwardedFrom, n)
 
 }
 
+// getAdaptiveNumPartitions returns the number of partitions of the task lists scaled by
+// matching, which is the same for adds and polls since matching drains the removed partitions
+func (lb *defaultLoadBalancer) getAdaptiveNumPartitions(
+	domainName string,
+	taskList types.TaskList,
+	taskListType int,
+	forwardedFrom string,
+) (int, bool) {
+	if lb.partitionConfigProvider == nil || forwardedFrom != "" || taskList.GetKind() == types.TaskListKindSticky ||
+		strings.HasPrefix(taskList.GetName(), common.ReservedTaskListPrefix) {
+		return 0, false
+	}
+	return lb.partitionConfigProvider.GetNumberOfPartitions(domainName, taskList, taskListType)
+}
+
 func (lb *defaultLoadBalancer) pickPartition(
 	taskList types.TaskList,
 	forwardedFrom string,
@@ -251,3 +251,44 @@ func Test_defaultLoadBalancer_pickPartition(t *testing.T) {
 		})
 	}
 }
+
+type testPartitionConfigProvider struct {
+	numPartitions int
+	ok            bool
+}
+
+func (p *testPartitionConfigProvider) GetNumberOfPartitions(string, types.TaskList, int) (int, bool) {
+	return p.numPartitions, p.ok
+}
+
+func Test_defaultLoadBalancer_PartitionConfigProvider(t *testing.T) {
+	provider := &testPartitionConfigProvider{numPartitions: 3, ok: true}
+	lb := &defaultLoadBalancer{
+		nReadPartitions:         func(string, string, int) int { return 1 },
+		nWritePartitions:        func(string, string, int) int { return 1 },
+		domainIDToName:          func(string) (string, error) { return "domainName", nil },
+		partitionConfigProvider: provider,
+	}
+	taskList := types.TaskList{Name: "taskListName"}
+	expected := []string{"taskListName", "/__cadence_sys/taskListName/1", "/__cadence_sys/taskListName/2"}
+	picked := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		got := lb.PickWritePartition("domainID", taskList, 0, "")
+		assert.Contains(t, expected, got)
+		picked[got] = struct{}{}
+		got = lb.PickReadPartition("domainID", taskList, 0, "")
+		assert.Contains(t, expected, got)
+		picked[got] = struct{}{}
+	}
+	assert.Len(t, picked, 3)
+
+	assert.Equal(t, "taskListName", lb.PickWritePartition("domainID", taskList, 0, "/__cadence_sys/taskListName/1"))
+	assert.Equal(t, "taskListName", lb.PickReadPartition("domainID", types.TaskList{Name: "taskListName", Kind: types.TaskListKindSticky.Ptr()}, 0, ""))
+
+	// the dynamic config is used when the provider doesn't know the number of partitions
+	provider.ok = false
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "taskListName", lb.PickWritePartition("domainID", taskList, 0, ""))
+		assert.Equal(t, "taskListName", lb.PickReadPartition("domainID", taskList, 0, ""))
+	}
+}
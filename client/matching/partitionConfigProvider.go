@@ -0,0 +1,167 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// PartitionConfigProvider provides the number of partitions of the task lists
+	// which are scaled by the adaptive scaler of matching
+	PartitionConfigProvider interface {
+		// GetNumberOfPartitions returns the number of partitions used for both adds and polls,
+		// or false when the number of partitions from dynamic config has to be used instead
+		GetNumberOfPartitions(domainName string, taskList types.TaskList, taskListType int) (int, bool)
+	}
+
+	partitionConfigProviderImpl struct {
+		client               Client
+		peerResolver         PeerResolver
+		enableAdaptiveScaler dynamicconfig.BoolPropertyFnWithTaskListInfoFilters
+		refreshInterval      dynamicconfig.DurationPropertyFn
+		timeSource           clock.TimeSource
+		logger               log.Logger
+		cache                cache.Cache
+	}
+
+	partitionConfigKey struct {
+		domainName   string
+		taskListName string
+	}
+
+	partitionConfigEntry struct {
+		sync.Mutex
+		// number of partitions by task list type, as returned by ListTaskListPartitions
+		numPartitions   map[int]int
+		lastRefreshTime time.Time
+		refreshing      bool
+	}
+)
+
+const (
+	partitionConfigCacheInitialSize = 1000
+	partitionConfigCacheMaxSize     = 100000
+	partitionConfigRefreshTimeout   = 5 * time.Second
+)
+
+// NewPartitionConfigProvider returns a PartitionConfigProvider which caches the number of
+// partitions returned by ListTaskListPartitions and refreshes it in the background
+func NewPartitionConfigProvider(
+	client Client,
+	peerResolver PeerResolver,
+	dc *dynamicconfig.Collection,
+	logger log.Logger,
+) PartitionConfigProvider {
+	return &partitionConfigProviderImpl{
+		client:               client,
+		peerResolver:         peerResolver,
+		enableAdaptiveScaler: dc.GetBoolPropertyFilteredByTaskListInfo(dynamicconfig.MatchingEnableAdaptiveScaler),
+		refreshInterval:      dc.GetDurationProperty(dynamicconfig.MatchingPartitionConfigRefreshInterval),
+		timeSource:           clock.NewRealTimeSource(),
+		logger:               logger,
+		cache: cache.New(&cache.Options{
+			InitialCapacity: partitionConfigCacheInitialSize,
+			MaxCount:        partitionConfigCacheMaxSize,
+		}),
+	}
+}
+
+func (p *partitionConfigProviderImpl) GetNumberOfPartitions(
+	domainName string,
+	taskList types.TaskList,
+	taskListType int,
+) (int, bool) {
+	if !p.enableAdaptiveScaler(domainName, taskList.GetName(), taskListType) {
+		return 0, false
+	}
+
+	key := partitionConfigKey{domainName: domainName, taskListName: taskList.GetName()}
+	entry, ok := p.cache.Get(key).(*partitionConfigEntry)
+	if !ok {
+		value, err := p.cache.PutIfNotExist(key, &partitionConfigEntry{})
+		if err != nil {
+			return 0, false
+		}
+		entry = value.(*partitionConfigEntry)
+	}
+
+	entry.Lock()
+	defer entry.Unlock()
+	if !entry.refreshing && p.timeSource.Now().Sub(entry.lastRefreshTime) >= p.refreshInterval() {
+		entry.refreshing = true
+		go p.refresh(domainName, taskList.GetName(), entry)
+	}
+	n, ok := entry.numPartitions[taskListType]
+	return n, ok && n > 0
+}
+
+func (p *partitionConfigProviderImpl) refresh(domainName string, taskListName string, entry *partitionConfigEntry) {
+	numPartitions, err := p.listTaskListPartitions(domainName, taskListName)
+
+	entry.Lock()
+	defer entry.Unlock()
+	entry.refreshing = false
+	entry.lastRefreshTime = p.timeSource.Now()
+	if err != nil {
+		p.logger.Warn("Failed to refresh the number of task list partitions",
+			tag.WorkflowDomainName(domainName),
+			tag.WorkflowTaskListName(taskListName),
+			tag.Error(err),
+		)
+		return
+	}
+	entry.numPartitions = numPartitions
+}
+
+func (p *partitionConfigProviderImpl) listTaskListPartitions(domainName string, taskListName string) (map[int]int, error) {
+	peer, err := p.peerResolver.FromTaskList(taskListName)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), partitionConfigRefreshTimeout)
+	defer cancel()
+	resp, err := p.client.ListTaskListPartitions(ctx, &types.MatchingListTaskListPartitionsRequest{
+		Domain:   domainName,
+		TaskList: &types.TaskList{Name: taskListName},
+	}, yarpc.WithShardKey(peer))
+	if err != nil {
+		return nil, err
+	}
+	return map[int]int{
+		persistence.TaskListTypeDecision: len(resp.DecisionTaskListPartitions),
+		persistence.TaskListTypeActivity: len(resp.ActivityTaskListPartitions),
+	}, nil
+}
@@ -0,0 +1,117 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestPartitionConfigProvider(t *testing.T) {
+	controller := gomock.NewController(t)
+	client := NewMockClient(controller)
+	resolver := membership.NewMockResolver(controller)
+	resolver.EXPECT().Lookup(service.Matching, "tl").Return(membership.NewDetailedHostInfo(
+		"host:123",
+		"host_123",
+		membership.PortMap{membership.PortTchannel: 1234},
+	), nil).AnyTimes()
+	timeSource := clock.NewMockedTimeSource()
+	enabled := true
+	p := &partitionConfigProviderImpl{
+		client:       client,
+		peerResolver: NewPeerResolver(resolver, membership.PortTchannel),
+		enableAdaptiveScaler: func(domain string, taskList string, taskType int) bool {
+			return enabled && taskList == "tl"
+		},
+		refreshInterval: dynamicconfig.GetDurationPropertyFn(10 * time.Second),
+		timeSource:      timeSource,
+		logger:          log.NewNoop(),
+		cache:           cache.New(&cache.Options{InitialCapacity: 1, MaxCount: 10}),
+	}
+	request := &types.MatchingListTaskListPartitionsRequest{
+		Domain:   "domain",
+		TaskList: &types.TaskList{Name: "tl"},
+	}
+	taskList := types.TaskList{Name: "tl"}
+
+	// the dynamic config is used until the first refresh is done
+	client.EXPECT().ListTaskListPartitions(gomock.Any(), request, gomock.Any()).Return(&types.ListTaskListPartitionsResponse{
+		DecisionTaskListPartitions: make([]*types.TaskListPartitionMetadata, 3),
+		ActivityTaskListPartitions: make([]*types.TaskListPartitionMetadata, 1),
+	}, nil).Times(1)
+	_, ok := p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+	assert.False(t, ok)
+	assert.Eventually(t, func() bool {
+		n, ok := p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+		return ok && n == 3
+	}, time.Second, time.Millisecond)
+	n, ok := p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeActivity)
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+
+	// a failed refresh keeps the last number of partitions
+	timeSource.Advance(10 * time.Second)
+	client.EXPECT().ListTaskListPartitions(gomock.Any(), request, gomock.Any()).Return(nil, assert.AnError).Times(1)
+	n, ok = p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+	assert.Eventually(t, func() bool {
+		entry := p.cache.Get(partitionConfigKey{domainName: "domain", taskListName: "tl"}).(*partitionConfigEntry)
+		entry.Lock()
+		defer entry.Unlock()
+		return !entry.refreshing
+	}, time.Second, time.Millisecond)
+	n, ok = p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+
+	timeSource.Advance(10 * time.Second)
+	client.EXPECT().ListTaskListPartitions(gomock.Any(), request, gomock.Any()).Return(&types.ListTaskListPartitionsResponse{
+		DecisionTaskListPartitions: make([]*types.TaskListPartitionMetadata, 5),
+		ActivityTaskListPartitions: make([]*types.TaskListPartitionMetadata, 1),
+	}, nil).Times(1)
+	assert.Eventually(t, func() bool {
+		n, ok := p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+		return ok && n == 5
+	}, time.Second, time.Millisecond)
+
+	// task lists which aren't scaled by matching use the dynamic config
+	_, ok = p.GetNumberOfPartitions("domain", types.TaskList{Name: "other"}, persistence.TaskListTypeDecision)
+	assert.False(t, ok)
+	enabled = false
+	_, ok = p.GetNumberOfPartitions("domain", taskList, persistence.TaskListTypeDecision)
+	assert.False(t, ok)
+}
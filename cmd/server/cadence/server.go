@@ -33,18 +33,23 @@ import (
 	"github.com/uber/cadence/common/archiver"
 	"github.com/uber/cadence/common/archiver/provider"
 	"github.com/uber/cadence/common/asyncworkflow/queue"
+	"github.com/uber/cadence/common/blobstore"
 	"github.com/uber/cadence/common/blobstore/filestore"
+	"github.com/uber/cadence/common/blobstore/s3store"
 	"github.com/uber/cadence/common/cluster"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/dynamicconfig/configstore"
+	"github.com/uber/cadence/common/dynamicconfig/etcd"
 	"github.com/uber/cadence/common/elasticsearch"
 	"github.com/uber/cadence/common/log/loggerimpl"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/membership"
 	"github.com/uber/cadence/common/messaging/kafka"
 	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/peerprovider/dnsprovider"
 	"github.com/uber/cadence/common/peerprovider/ringpopprovider"
+	"github.com/uber/cadence/common/peerprovider/staticprovider"
 	"github.com/uber/cadence/common/persistence"
 	pnt "github.com/uber/cadence/common/pinot"
 	"github.com/uber/cadence/common/resource"
@@ -134,6 +139,9 @@ func (s *server) startService() common.Daemon {
 		case dynamicconfig.FileBasedClient:
 			params.Logger.Info("initialising File Based dynamic config client")
 			params.DynamicConfig, err = dynamicconfig.NewFileBasedClient(&s.cfg.DynamicConfig.FileBased, params.Logger, s.doneC)
+		case dynamicconfig.EtcdClient:
+			params.Logger.Info("initialising Etcd dynamic config client")
+			params.DynamicConfig, err = etcd.NewEtcdClient(&s.cfg.DynamicConfig.Etcd, params.Logger, s.doneC)
 		default:
 			params.Logger.Info("initialising NOP dynamic config client")
 			params.DynamicConfig = dynamicconfig.NewNopClient()
@@ -165,19 +173,9 @@ func (s *server) startService() common.Daemon {
 	rpcFactory := rpc.NewFactory(params.Logger, rpcParams)
 	params.RPCFactory = rpcFactory
 
-	peerProvider, err := ringpopprovider.New(
-		params.Name,
-		&s.cfg.Ringpop,
-		rpcFactory.GetChannel(),
-		membership.PortMap{
-			membership.PortGRPC:     svcCfg.RPC.GRPCPort,
-			membership.PortTchannel: svcCfg.RPC.Port,
-		},
-		params.Logger,
-	)
-
+	peerProvider, err := s.newPeerProvider(&params, rpcFactory, rpcParams.TChannelAddress)
 	if err != nil {
-		log.Fatalf("ringpop provider failed: %v", err)
+		log.Fatalf("%v peer provider failed: %v", s.cfg.PeerProvider.Type, err)
 	}
 
 	params.MetricsClient = metrics.NewClient(params.MetricScope, service.GetMetricsServiceIdx(params.Name, params.Logger))
@@ -279,9 +277,9 @@ func (s *server) startService() common.Daemon {
 	params.PersistenceConfig.TransactionSizeLimit = dc.GetIntProperty(dynamicconfig.TransactionSizeLimit)
 	params.PersistenceConfig.ErrorInjectionRate = dc.GetFloat64Property(dynamicconfig.PersistenceErrorInjectionRate)
 	params.AuthorizationConfig = s.cfg.Authorization
-	params.BlobstoreClient, err = filestore.NewFilestoreClient(s.cfg.Blobstore.Filestore)
+	params.BlobstoreClient, err = newBlobstoreClient(&s.cfg.Blobstore)
 	if err != nil {
-		log.Printf("failed to create file blobstore client, will continue startup without it: %v", err)
+		log.Printf("failed to create blobstore client, will continue startup without it: %v", err)
 		params.BlobstoreClient = nil
 	}
 
@@ -313,6 +311,48 @@ func (s *server) startService() common.Daemon {
 	return daemon
 }
 
+// newPeerProvider creates the peer provider of the configured type, ringpop is used if the type is not set
+func (s *server) newPeerProvider(
+	params *resource.Params,
+	rpcFactory *rpc.Factory,
+	selfAddress string,
+) (membership.PeerProvider, error) {
+	portMaps := make(map[string]membership.PortMap, len(service.List))
+	for _, name := range service.List {
+		svcCfg, err := s.cfg.GetServiceConfig(name)
+		if err != nil {
+			continue
+		}
+		portMaps[name] = membership.PortMap{
+			membership.PortGRPC:     svcCfg.RPC.GRPCPort,
+			membership.PortTchannel: svcCfg.RPC.Port,
+		}
+	}
+
+	switch s.cfg.PeerProvider.Type {
+	case config.PeerProviderTypeStatic:
+		params.Logger.Info("initialising static peer provider")
+		return staticprovider.New(params.Name, &s.cfg.PeerProvider.Static, selfAddress, portMaps, params.Logger)
+	case config.PeerProviderTypeDNS:
+		params.Logger.Info("initialising DNS peer provider")
+		return dnsprovider.New(params.Name, &s.cfg.PeerProvider.DNS, selfAddress, portMaps, params.Logger)
+	default:
+		return ringpopprovider.New(params.Name, &s.cfg.Ringpop, rpcFactory.GetChannel(), portMaps[params.Name], params.Logger)
+	}
+}
+
+// newBlobstoreClient creates the S3 blobstore client if it's configured, and falls back to the file blobstore otherwise
+func newBlobstoreClient(cfg *config.Blobstore) (blobstore.Client, error) {
+	if cfg.S3 != nil {
+		client, err := s3store.NewS3Client(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return blobstore.NewRetryableClient(client, common.CreateBlobstoreClientRetryPolicy()), nil
+	}
+	return filestore.NewFilestoreClient(cfg.Filestore)
+}
+
 // execute runs the daemon in a separate go routine
 func execute(d common.Daemon, doneC chan struct{}) {
 	d.Start()
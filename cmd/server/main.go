@@ -26,12 +26,17 @@ import (
 	"github.com/uber/cadence/cmd/server/cadence"
 	"github.com/uber/cadence/common/metrics"
 
+	_ "github.com/uber/cadence/common/archiver/azureblob"                                   // needed to load the optional azure blob storage archiver plugin
 	_ "github.com/uber/cadence/common/archiver/gcloud"                                      // needed to load the optional gcloud archiver plugin
 	_ "github.com/uber/cadence/common/asyncworkflow/queue/kafka"                            // needed to load kafka asyncworkflow queue
+	_ "github.com/uber/cadence/common/asyncworkflow/queue/persistence"                      // needed to load persistence asyncworkflow queue
 	_ "github.com/uber/cadence/common/persistence/nosql/nosqlplugin/cassandra"              // needed to load cassandra plugin
 	_ "github.com/uber/cadence/common/persistence/nosql/nosqlplugin/cassandra/gocql/public" // needed to load the default gocql client
+	_ "github.com/uber/cadence/common/persistence/nosql/nosqlplugin/dynamodb"               // needed to load dynamodb plugin
+	_ "github.com/uber/cadence/common/persistence/nosql/nosqlplugin/mongodb"                // needed to load mongodb plugin
 	_ "github.com/uber/cadence/common/persistence/sql/sqlplugin/mysql"                      // needed to load mysql plugin
 	_ "github.com/uber/cadence/common/persistence/sql/sqlplugin/postgres"                   // needed to load postgres plugin
+	_ "github.com/uber/cadence/common/persistence/sql/sqlplugin/sqlite"                     // needed to load sqlite plugin
 )
 
 // main entry point for the cadence server
@@ -0,0 +1,200 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build azuriteintegration
+
+package azureblob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/types"
+)
+
+// The well known account of the Azurite emulator, see https://github.com/Azure/Azurite#default-storage-account
+const (
+	azuriteAccountName     = "devstoreaccount1"
+	azuriteAccountKey      = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azuriteDefaultEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
+)
+
+// TestAzurite runs the archivers against Azurite, start it with docker/dev/azurite.yml and run
+// go test -tags azuriteintegration ./...
+func TestAzurite(t *testing.T) {
+	endpoint := os.Getenv("AZURITE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = azuriteDefaultEndpoint
+	}
+	config := &Config{
+		AccountName: azuriteAccountName,
+		AccountKey:  azuriteAccountKey,
+		Endpoint:    endpoint,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	containerName := "cadence-" + uuid.New()
+	cred, err := azblob.NewSharedKeyCredential(azuriteAccountName, azuriteAccountKey)
+	require.NoError(t, err)
+	adminClient, err := azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+	require.NoError(t, err)
+	_, err = adminClient.CreateContainer(ctx, containerName, nil)
+	require.NoError(t, err)
+	defer adminClient.DeleteContainer(context.Background(), containerName, nil)
+
+	URI, err := archiver.NewURI(fmt.Sprintf("%s://%s/archival", URIScheme, containerName))
+	require.NoError(t, err)
+
+	t.Run("history", func(t *testing.T) {
+		container := &archiver.HistoryBootstrapContainer{
+			Logger:        testlogger.New(t),
+			MetricsClient: metrics.NewClient(tally.NoopScope, metrics.History),
+		}
+		client, err := newBlobClient(config)
+		require.NoError(t, err)
+		historyArchiver := newHistoryArchiver(container, client, nil)
+		require.NoError(t, historyArchiver.ValidateURI(URI))
+
+		nonExistentURI, err := archiver.NewURI(URIScheme + "://non-existent-container")
+		require.NoError(t, err)
+		require.Equal(t, errContainerNotExists, historyArchiver.ValidateURI(nonExistentURI))
+
+		historyBlob := &archiver.HistoryBlob{
+			Header: &archiver.HistoryBlobHeader{
+				IsLast: common.BoolPtr(true),
+			},
+			Body: []*types.History{
+				{
+					Events: []*types.HistoryEvent{
+						{
+							ID:        testNextEventID - 1,
+							Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+							Version:   testCloseFailoverVersion,
+						},
+					},
+				},
+			},
+		}
+		mockCtrl := gomock.NewController(t)
+		historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+		gomock.InOrder(
+			historyIterator.EXPECT().HasNext().Return(true),
+			historyIterator.EXPECT().Next().Return(historyBlob, nil),
+			historyIterator.EXPECT().HasNext().Return(false),
+		)
+		historyArchiver.historyIterator = historyIterator
+
+		err = historyArchiver.Archive(ctx, URI, &archiver.ArchiveHistoryRequest{
+			DomainID:             testDomainID,
+			DomainName:           testDomainName,
+			WorkflowID:           testWorkflowID,
+			RunID:                testRunID,
+			BranchToken:          testBranchToken,
+			NextEventID:          testNextEventID,
+			CloseFailoverVersion: testCloseFailoverVersion,
+		})
+		require.NoError(t, err)
+
+		response, err := historyArchiver.Get(ctx, URI, &archiver.GetHistoryRequest{
+			DomainID:   testDomainID,
+			WorkflowID: testWorkflowID,
+			RunID:      testRunID,
+			PageSize:   testPageSize,
+		})
+		require.NoError(t, err)
+		require.Nil(t, response.NextPageToken)
+		require.Equal(t, historyBlob.Body, response.HistoryBatches)
+
+		_, err = historyArchiver.Get(ctx, URI, &archiver.GetHistoryRequest{
+			DomainID:             testDomainID,
+			WorkflowID:           testWorkflowID,
+			RunID:                "non-existent-run-id",
+			PageSize:             testPageSize,
+			CloseFailoverVersion: common.Int64Ptr(testCloseFailoverVersion),
+		})
+		require.IsType(t, &types.EntityNotExistsError{}, err)
+	})
+
+	t.Run("visibility", func(t *testing.T) {
+		container := &archiver.VisibilityBootstrapContainer{
+			Logger:        testlogger.New(t),
+			MetricsClient: metrics.NewClient(tally.NoopScope, metrics.History),
+		}
+		visibilityArchiver, err := NewVisibilityArchiver(container, config)
+		require.NoError(t, err)
+		require.NoError(t, visibilityArchiver.ValidateURI(URI))
+
+		closeTime := time.Date(2024, 1, 21, 10, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			err := visibilityArchiver.Archive(ctx, URI, &archiver.ArchiveVisibilityRequest{
+				DomainID:         testDomainID,
+				DomainName:       testDomainName,
+				WorkflowID:       testWorkflowID,
+				RunID:            fmt.Sprintf("%s-%d", testRunID, i),
+				WorkflowTypeName: testWorkflowTypeName,
+				StartTimestamp:   closeTime.Add(-time.Hour).UnixNano(),
+				CloseTimestamp:   closeTime.Add(time.Duration(i) * time.Minute).UnixNano(),
+				CloseStatus:      types.WorkflowExecutionCloseStatusCompleted,
+				HistoryLength:    10,
+			})
+			require.NoError(t, err)
+		}
+
+		request := &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 2,
+			Query:    fmt.Sprintf("WorkflowID = '%s' and CloseTime = '%s' and SearchPrecision = 'Day'", testWorkflowID, closeTime.Format(time.RFC3339)),
+		}
+		var executions []*types.WorkflowExecutionInfo
+		for first := true; first || request.NextPageToken != nil; first = false {
+			response, err := visibilityArchiver.Query(ctx, URI, request)
+			require.NoError(t, err)
+			executions = append(executions, response.Executions...)
+			request.NextPageToken = response.NextPageToken
+		}
+		require.Len(t, executions, 3)
+
+		request = &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 10,
+			Query:    fmt.Sprintf("WorkflowTypeName = '%s' and CloseTime = '%s' and SearchPrecision = 'Minute'", testWorkflowTypeName, closeTime.Format(time.RFC3339)),
+		}
+		response, err := visibilityArchiver.Query(ctx, URI, request)
+		require.NoError(t, err)
+		require.Len(t, response.Executions, 1)
+		require.Equal(t, testRunID+"-0", response.Executions[0].Execution.RunID)
+	})
+}
@@ -0,0 +1,175 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"go.uber.org/multierr"
+)
+
+type (
+	// Config is the config of the Azure Blob Storage archivers.
+	// The storage account is authenticated with the first of ConnectionString, AccountKey
+	// or the default Azure credential chain (environment, managed identity, Azure CLI) which is set.
+	Config struct {
+		// AccountName is the name of the storage account, required unless ConnectionString is set
+		AccountName string `yaml:"accountName"`
+		// AccountKey is the shared key of the storage account (optional)
+		AccountKey string `yaml:"accountKey"`
+		// ConnectionString is the connection string of the storage account (optional)
+		ConnectionString string `yaml:"connectionString"`
+		// Endpoint overrides the blob service URL, e.g. http://127.0.0.1:10000/devstoreaccount1 for Azurite
+		// (optional, default to https://<accountName>.blob.core.windows.net/)
+		Endpoint string `yaml:"endpoint"`
+	}
+
+	// blobClient is the subset of the Azure Blob Storage API used by the archivers
+	blobClient interface {
+		GetContainerProperties(ctx context.Context, containerName string) error
+		UploadBlob(ctx context.Context, containerName, blobName string, data []byte) error
+		DownloadBlob(ctx context.Context, containerName, blobName string) ([]byte, error)
+		GetBlobProperties(ctx context.Context, containerName, blobName string) error
+		// ListBlobPrefixes returns the virtual directories right below the prefix
+		ListBlobPrefixes(ctx context.Context, containerName, prefix string) ([]string, error)
+		// ListBlobs returns a single page of the blobs starting with the prefix, and the marker of the next page
+		ListBlobs(ctx context.Context, containerName, prefix string, marker *string, maxResults int32) ([]string, *string, error)
+	}
+
+	azureBlobClient struct {
+		client *azblob.Client
+	}
+)
+
+var errEmptyAccountName = errors.New("empty azure storage account name")
+
+func newBlobClient(config *Config) (blobClient, error) {
+	if len(config.ConnectionString) != 0 {
+		client, err := azblob.NewClientFromConnectionString(config.ConnectionString, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &azureBlobClient{client: client}, nil
+	}
+
+	if len(config.AccountName) == 0 {
+		return nil, errEmptyAccountName
+	}
+	serviceURL := config.Endpoint
+	if len(serviceURL) == 0 {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", config.AccountName)
+	}
+
+	var client *azblob.Client
+	if len(config.AccountKey) != 0 {
+		cred, err := azblob.NewSharedKeyCredential(config.AccountName, config.AccountKey)
+		if err != nil {
+			return nil, err
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, err
+		}
+		client, err = azblob.NewClient(serviceURL, cred, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &azureBlobClient{client: client}, nil
+}
+
+func (c *azureBlobClient) GetContainerProperties(ctx context.Context, containerName string) error {
+	_, err := c.client.ServiceClient().NewContainerClient(containerName).GetProperties(ctx, nil)
+	return err
+}
+
+func (c *azureBlobClient) UploadBlob(ctx context.Context, containerName, blobName string, data []byte) error {
+	_, err := c.client.UploadBuffer(ctx, containerName, blobName, data, nil)
+	return err
+}
+
+func (c *azureBlobClient) DownloadBlob(ctx context.Context, containerName, blobName string) (data []byte, err error) {
+	resp, err := c.client.DownloadStream(ctx, containerName, blobName, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if ierr := resp.Body.Close(); ierr != nil {
+			err = multierr.Append(err, ierr)
+		}
+	}()
+	return io.ReadAll(resp.Body)
+}
+
+func (c *azureBlobClient) GetBlobProperties(ctx context.Context, containerName, blobName string) error {
+	_, err := c.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName).GetProperties(ctx, nil)
+	return err
+}
+
+func (c *azureBlobClient) ListBlobPrefixes(ctx context.Context, containerName, prefix string) ([]string, error) {
+	pager := c.client.ServiceClient().NewContainerClient(containerName).NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{
+		Prefix: &prefix,
+	})
+	var prefixes []string
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, blobPrefix := range page.Segment.BlobPrefixes {
+			prefixes = append(prefixes, *blobPrefix.Name)
+		}
+	}
+	return prefixes, nil
+}
+
+func (c *azureBlobClient) ListBlobs(ctx context.Context, containerName, prefix string, marker *string, maxResults int32) ([]string, *string, error) {
+	pager := c.client.NewListBlobsFlatPager(containerName, &azblob.ListBlobsFlatOptions{
+		Prefix:     &prefix,
+		Marker:     marker,
+		MaxResults: &maxResults,
+	})
+	page, err := pager.NextPage(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for _, item := range page.Segment.BlobItems {
+		names = append(names, *item.Name)
+	}
+	if page.NextMarker == nil || len(*page.NextMarker) == 0 {
+		return names, nil, nil
+	}
+	return names, page.NextMarker, nil
+}
@@ -0,0 +1,669 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	testDomainID             = "test-domain-id"
+	testDomainName           = "test-domain-name"
+	testWorkflowID           = "test-workflow-id"
+	testRunID                = "test-run-id"
+	testNextEventID          = 1800
+	testCloseFailoverVersion = 100
+	testPageSize             = 100
+	testContainer            = "test-container"
+	testContainerURI         = "azblob://test-container"
+)
+
+var (
+	testBranchToken = []byte{1, 2, 3}
+)
+
+// fakeBlobClient emulates the Azure Blob Storage containers in memory
+type fakeBlobClient struct {
+	sync.Mutex
+	containers map[string]map[string][]byte
+}
+
+func newFakeBlobClient(containers ...string) *fakeBlobClient {
+	c := &fakeBlobClient{containers: make(map[string]map[string][]byte)}
+	for _, container := range containers {
+		c.containers[container] = make(map[string][]byte)
+	}
+	return c
+}
+
+func newResponseError(statusCode int, code bloberror.Code) error {
+	return &azcore.ResponseError{StatusCode: statusCode, ErrorCode: string(code)}
+}
+
+func (c *fakeBlobClient) getContainer(containerName string) (map[string][]byte, error) {
+	blobs, ok := c.containers[containerName]
+	if !ok {
+		return nil, newResponseError(http.StatusNotFound, bloberror.ContainerNotFound)
+	}
+	return blobs, nil
+}
+
+func (c *fakeBlobClient) GetContainerProperties(_ context.Context, containerName string) error {
+	c.Lock()
+	defer c.Unlock()
+	_, err := c.getContainer(containerName)
+	return err
+}
+
+func (c *fakeBlobClient) UploadBlob(_ context.Context, containerName, blobName string, data []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	blobs, err := c.getContainer(containerName)
+	if err != nil {
+		return err
+	}
+	blobs[blobName] = data
+	return nil
+}
+
+func (c *fakeBlobClient) DownloadBlob(_ context.Context, containerName, blobName string) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+	blobs, err := c.getContainer(containerName)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := blobs[blobName]
+	if !ok {
+		return nil, newResponseError(http.StatusNotFound, bloberror.BlobNotFound)
+	}
+	return data, nil
+}
+
+func (c *fakeBlobClient) GetBlobProperties(_ context.Context, containerName, blobName string) error {
+	c.Lock()
+	defer c.Unlock()
+	blobs, err := c.getContainer(containerName)
+	if err != nil {
+		return err
+	}
+	if _, ok := blobs[blobName]; !ok {
+		// HEAD responses have no body, so the error code is not known
+		return newResponseError(http.StatusNotFound, "")
+	}
+	return nil
+}
+
+func (c *fakeBlobClient) ListBlobPrefixes(_ context.Context, containerName, prefix string) ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+	blobs, err := c.getContainer(containerName)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make(map[string]struct{})
+	for name := range blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if index := strings.Index(name[len(prefix):], "/"); index != -1 {
+			prefixes[name[:len(prefix)+index+1]] = struct{}{}
+		}
+	}
+	var result []string
+	for p := range prefixes {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (c *fakeBlobClient) ListBlobs(_ context.Context, containerName, prefix string, marker *string, maxResults int32) ([]string, *string, error) {
+	c.Lock()
+	defer c.Unlock()
+	blobs, err := c.getContainer(containerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for name := range blobs {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	start := 0
+	if marker != nil {
+		start, _ = strconv.Atoi(*marker)
+	}
+	if len(names) > start+int(maxResults) {
+		return names[start : start+int(maxResults)], common.StringPtr(fmt.Sprintf("%d", start+int(maxResults))), nil
+	}
+	return names[start:], nil, nil
+}
+
+type historyArchiverSuite struct {
+	*require.Assertions
+	suite.Suite
+	client             *fakeBlobClient
+	container          *archiver.HistoryBootstrapContainer
+	testArchivalURI    archiver.URI
+	historyBatchesV1   []*archiver.HistoryBlob
+	historyBatchesV100 []*archiver.HistoryBlob
+}
+
+func TestHistoryArchiverSuite(t *testing.T) {
+	suite.Run(t, new(historyArchiverSuite))
+}
+
+func (s *historyArchiverSuite) SetupSuite() {
+	var err error
+	s.client = newFakeBlobClient(testContainer)
+	s.setupHistoryDirectory()
+	s.testArchivalURI, err = archiver.NewURI(testContainerURI)
+
+	s.Require().NoError(err)
+}
+
+func (s *historyArchiverSuite) SetupTest() {
+	scope := tally.NewTestScope("test", nil)
+	s.Assertions = require.New(s.T())
+	s.container = &archiver.HistoryBootstrapContainer{
+		Logger:        testlogger.New(s.T()),
+		MetricsClient: metrics.NewClient(scope, metrics.HistoryArchiverScope),
+	}
+}
+
+func (s *historyArchiverSuite) TestValidateURI() {
+	testCases := []struct {
+		URI         string
+		expectedErr error
+	}{
+		{
+			URI:         "wrongscheme:///a/b/c",
+			expectedErr: archiver.ErrURISchemeMismatch,
+		},
+		{
+			URI:         "azblob://",
+			expectedErr: errNoContainerSpecified,
+		},
+		{
+			URI:         "azblob://container/a/b/c",
+			expectedErr: errContainerNotExists,
+		},
+		{
+			URI:         testContainerURI,
+			expectedErr: nil,
+		},
+	}
+
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	for _, tc := range testCases {
+		URI, err := archiver.NewURI(tc.URI)
+		s.NoError(err)
+		s.Equal(tc.expectedErr, historyArchiver.ValidateURI(URI))
+	}
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_InvalidURI() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	URI, err := archiver.NewURI("wrongscheme://")
+	s.NoError(err)
+	err = historyArchiver.Archive(context.Background(), URI, s.newArchiveRequest())
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_InvalidRequest() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := s.newArchiveRequest()
+	request.WorkflowID = "" // an invalid request
+	err := historyArchiver.Archive(context.Background(), s.testArchivalURI, request)
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_ErrorOnReadHistory() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(nil, errors.New("some random error")),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	err := historyArchiver.Archive(context.Background(), s.testArchivalURI, s.newArchiveRequest())
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_TimeoutWhenReadingHistory() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(nil, &types.ServiceBusyError{}),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	err := historyArchiver.Archive(getCanceledContext(), s.testArchivalURI, s.newArchiveRequest())
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_HistoryMutated() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	historyBlob := &archiver.HistoryBlob{
+		Header: &archiver.HistoryBlobHeader{
+			IsLast: common.BoolPtr(true),
+		},
+		Body: []*types.History{
+			{
+				Events: []*types.HistoryEvent{
+					{
+						ID:        common.FirstEventID + 1,
+						Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+						Version:   testCloseFailoverVersion + 1,
+					},
+				},
+			},
+		},
+	}
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(historyBlob, nil),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	err := historyArchiver.Archive(context.Background(), s.testArchivalURI, s.newArchiveRequest())
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_NonRetriableErrorOption() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(nil, errors.New("some random error")),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	nonRetryableErr := errors.New("some non-retryable error")
+	err := historyArchiver.Archive(context.Background(), s.testArchivalURI, s.newArchiveRequest(), archiver.GetNonRetriableErrorOption(nonRetryableErr))
+	s.Equal(nonRetryableErr, err)
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_ContainerNotExists() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(s.historyBatchesV100[1], nil),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	URI, err := archiver.NewURI("azblob://non-existent-container")
+	s.NoError(err)
+	err = historyArchiver.Archive(context.Background(), URI, s.newArchiveRequest())
+	s.Error(err)
+	s.False(isRetryableError(err))
+}
+
+func (s *historyArchiverSuite) TestArchive_Skip() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	historyBlob := &archiver.HistoryBlob{
+		Header: &archiver.HistoryBlobHeader{
+			IsLast: common.BoolPtr(false),
+		},
+		Body: []*types.History{
+			{
+				Events: []*types.HistoryEvent{
+					{
+						ID:        common.FirstEventID,
+						Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+						Version:   testCloseFailoverVersion,
+					},
+				},
+			},
+		},
+	}
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(historyBlob, nil),
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(nil, &types.EntityNotExistsError{Message: "workflow not found"}),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	URI, err := archiver.NewURI(testContainerURI + "/TestArchive_Skip")
+	s.NoError(err)
+	err = historyArchiver.Archive(context.Background(), URI, s.newArchiveRequest())
+	s.NoError(err)
+
+	expectedBlobName := constructHistoryBlobName(URI.Path(), testDomainID, testWorkflowID, testRunID, testCloseFailoverVersion, 0)
+	s.assertBlobExists(expectedBlobName)
+}
+
+func (s *historyArchiverSuite) TestArchive_Success() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(s.historyBatchesV100[1], nil),
+		historyIterator.EXPECT().HasNext().Return(false),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	URI, err := archiver.NewURI(testContainerURI + "/TestArchive_Success")
+	s.NoError(err)
+	err = historyArchiver.Archive(context.Background(), URI, s.newArchiveRequest())
+	s.NoError(err)
+
+	expectedBlobName := constructHistoryBlobName(URI.Path(), testDomainID, testWorkflowID, testRunID, testCloseFailoverVersion, 0)
+	s.assertBlobExists(expectedBlobName)
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_InvalidURI() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   100,
+	}
+	URI, err := archiver.NewURI("wrongscheme://")
+	s.NoError(err)
+	response, err := historyArchiver.Get(context.Background(), URI, request)
+	s.Nil(response)
+	s.Error(err)
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_InvalidRequest() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   0, // pageSize should be greater than 0
+	}
+	response, err := historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.Nil(response)
+	s.Error(err)
+	s.IsType(&types.BadRequestError{}, err)
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_InvalidToken() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:      testDomainID,
+		WorkflowID:    testWorkflowID,
+		RunID:         testRunID,
+		PageSize:      testPageSize,
+		NextPageToken: []byte{'r', 'a', 'n', 'd', 'o', 'm'},
+	}
+	response, err := historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.Nil(response)
+	s.Error(err)
+	s.IsType(&types.BadRequestError{}, err)
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_BlobNotExist() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:             testDomainID,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		PageSize:             testPageSize,
+		CloseFailoverVersion: common.Int64Ptr(testCloseFailoverVersion),
+	}
+	URI, err := archiver.NewURI(testContainerURI + "/non-existent")
+	s.NoError(err)
+	response, err := historyArchiver.Get(context.Background(), URI, request)
+	s.Nil(response)
+	s.Error(err)
+	s.IsType(&types.EntityNotExistsError{}, err)
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_ContainerNotExist() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   testPageSize,
+	}
+	URI, err := archiver.NewURI("azblob://non-existent-container")
+	s.NoError(err)
+	response, err := historyArchiver.Get(context.Background(), URI, request)
+	s.Nil(response)
+	s.Error(err)
+	s.IsType(&types.BadRequestError{}, err)
+}
+
+func (s *historyArchiverSuite) TestGet_Success_PickHighestVersion() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   testPageSize,
+	}
+	response, err := historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal(append(s.historyBatchesV100[0].Body, s.historyBatchesV100[1].Body...), response.HistoryBatches)
+}
+
+func (s *historyArchiverSuite) TestGet_Success_UseProvidedVersion() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:             testDomainID,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		PageSize:             testPageSize,
+		CloseFailoverVersion: common.Int64Ptr(1),
+	}
+	response, err := historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal(s.historyBatchesV1[0].Body, response.HistoryBatches)
+}
+
+func (s *historyArchiverSuite) TestGet_Success_SmallPageSize() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:             testDomainID,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		PageSize:             1,
+		CloseFailoverVersion: common.Int64Ptr(testCloseFailoverVersion),
+	}
+	combinedHistory := []*types.History{}
+
+	response, err := historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.NotNil(response.NextPageToken)
+	s.Len(response.HistoryBatches, 1)
+	combinedHistory = append(combinedHistory, response.HistoryBatches...)
+
+	request.NextPageToken = response.NextPageToken
+	response, err = historyArchiver.Get(context.Background(), s.testArchivalURI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.Nil(response.NextPageToken)
+	s.Len(response.HistoryBatches, 1)
+	combinedHistory = append(combinedHistory, response.HistoryBatches...)
+
+	s.Equal(append(s.historyBatchesV100[0].Body, s.historyBatchesV100[1].Body...), combinedHistory)
+}
+
+func (s *historyArchiverSuite) TestArchiveAndGet() {
+	mockCtrl := gomock.NewController(s.T())
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(s.historyBatchesV100[0], nil),
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(s.historyBatchesV100[1], nil),
+		historyIterator.EXPECT().HasNext().Return(false),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	URI, err := archiver.NewURI(testContainerURI + "/TestArchiveAndGet")
+	s.NoError(err)
+	err = historyArchiver.Archive(context.Background(), URI, s.newArchiveRequest())
+	s.NoError(err)
+
+	getRequest := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   testPageSize,
+	}
+	response, err := historyArchiver.Get(context.Background(), URI, getRequest)
+	s.NoError(err)
+	s.NotNil(response)
+	s.Nil(response.NextPageToken)
+	s.Equal(append(s.historyBatchesV100[0].Body, s.historyBatchesV100[1].Body...), response.HistoryBatches)
+}
+
+func (s *historyArchiverSuite) newTestHistoryArchiver(historyIterator archiver.HistoryIterator) *historyArchiver {
+	return newHistoryArchiver(s.container, s.client, historyIterator)
+}
+
+func (s *historyArchiverSuite) newArchiveRequest() *archiver.ArchiveHistoryRequest {
+	return &archiver.ArchiveHistoryRequest{
+		DomainID:             testDomainID,
+		DomainName:           testDomainName,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		BranchToken:          testBranchToken,
+		NextEventID:          testNextEventID,
+		CloseFailoverVersion: testCloseFailoverVersion,
+	}
+}
+
+func (s *historyArchiverSuite) setupHistoryDirectory() {
+	s.historyBatchesV1 = []*archiver.HistoryBlob{
+		{
+			Header: &archiver.HistoryBlobHeader{
+				IsLast: common.BoolPtr(true),
+			},
+			Body: []*types.History{
+				{
+					Events: []*types.HistoryEvent{
+						{
+							ID:        testNextEventID - 1,
+							Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+							Version:   1,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	s.historyBatchesV100 = []*archiver.HistoryBlob{
+		{
+			Header: &archiver.HistoryBlobHeader{
+				IsLast: common.BoolPtr(false),
+			},
+			Body: []*types.History{
+				{
+					Events: []*types.HistoryEvent{
+						{
+							ID:        common.FirstEventID + 1,
+							Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+							Version:   testCloseFailoverVersion,
+						},
+						{
+							ID:        common.FirstEventID + 1,
+							Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+							Version:   testCloseFailoverVersion,
+						},
+					},
+				},
+			},
+		},
+		{
+			Header: &archiver.HistoryBlobHeader{
+				IsLast: common.BoolPtr(true),
+			},
+			Body: []*types.History{
+				{
+					Events: []*types.HistoryEvent{
+						{
+							ID:        testNextEventID - 1,
+							Timestamp: common.Int64Ptr(time.Now().UnixNano()),
+							Version:   testCloseFailoverVersion,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	s.writeHistoryBatchesForGetTest(s.historyBatchesV1, int64(1))
+	s.writeHistoryBatchesForGetTest(s.historyBatchesV100, testCloseFailoverVersion)
+}
+
+func (s *historyArchiverSuite) writeHistoryBatchesForGetTest(historyBatches []*archiver.HistoryBlob, version int64) {
+	for i, batch := range historyBatches {
+		data, err := encode(batch)
+		s.Require().NoError(err)
+		blobName := constructHistoryBlobName("", testDomainID, testWorkflowID, testRunID, version, i)
+		s.Require().NoError(s.client.UploadBlob(context.Background(), testContainer, blobName, data))
+	}
+}
+
+func (s *historyArchiverSuite) assertBlobExists(blobName string) {
+	_, err := s.client.DownloadBlob(context.Background(), testContainer, blobName)
+	s.NoError(err)
+}
+
+func getCanceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
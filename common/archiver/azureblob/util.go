@@ -0,0 +1,256 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/prefixquery"
+	"github.com/uber/cadence/common/types"
+)
+
+// encoding & decoding util
+
+func encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func decodeHistoryBlob(data []byte) (*archiver.HistoryBlob, error) {
+	historyBlob := &archiver.HistoryBlob{}
+	err := json.Unmarshal(data, historyBlob)
+	if err != nil {
+		return nil, err
+	}
+	return historyBlob, nil
+}
+
+func decodeVisibilityRecord(data []byte) (*visibilityRecord, error) {
+	record := &visibilityRecord{}
+	err := json.Unmarshal(data, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func serializeToken(token interface{}) ([]byte, error) {
+	if token == nil {
+		return nil, nil
+	}
+	return json.Marshal(token)
+}
+
+func deserializeGetHistoryToken(bytes []byte) (*getHistoryToken, error) {
+	token := &getHistoryToken{}
+	err := json.Unmarshal(bytes, token)
+	return token, err
+}
+
+func deserializeQueryVisibilityToken(bytes []byte) *string {
+	var ret = string(bytes)
+	return &ret
+}
+
+func serializeQueryVisibilityToken(token string) []byte {
+	return []byte(token)
+}
+
+// Only validates the scheme and container are passed
+func softValidateURI(URI archiver.URI) error {
+	if URI.Scheme() != URIScheme {
+		return archiver.ErrURISchemeMismatch
+	}
+	if len(URI.Hostname()) == 0 {
+		return errNoContainerSpecified
+	}
+	return nil
+}
+
+func containerExists(ctx context.Context, client blobClient, URI archiver.URI) error {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+	err := client.GetContainerProperties(ctx, URI.Hostname())
+	if err == nil {
+		return nil
+	}
+	if isNotFoundError(err) {
+		return errContainerNotExists
+	}
+	return err
+}
+
+func blobExists(ctx context.Context, client blobClient, URI archiver.URI, blobName string) (bool, error) {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+	err := client.GetBlobProperties(ctx, URI.Hostname(), blobName)
+	if err != nil {
+		if isNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func upload(ctx context.Context, client blobClient, URI archiver.URI, blobName string, data []byte) error {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+	err := client.UploadBlob(ctx, URI.Hostname(), blobName, data)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+			return &types.BadRequestError{Message: errContainerNotExists.Error()}
+		}
+		return err
+	}
+	return nil
+}
+
+func download(ctx context.Context, client blobClient, URI archiver.URI, blobName string) ([]byte, error) {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+	data, err := client.DownloadBlob(ctx, URI.Hostname(), blobName)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+			return nil, &types.BadRequestError{Message: errContainerNotExists.Error()}
+		}
+		if isNotFoundError(err) {
+			return nil, &types.EntityNotExistsError{Message: archiver.ErrHistoryNotExist.Error()}
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// isNotFoundError also checks the status code, as the responses of HEAD requests have no body with the error code
+func isNotFoundError(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		if respErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return respErr.StatusCode >= http.StatusInternalServerError && respErr.StatusCode != http.StatusNotImplemented
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Blob name construction
+func constructHistoryBlobName(path, domainID, workflowID, runID string, version int64, batchIdx int) string {
+	prefix := constructHistoryBlobNamePrefixWithVersion(path, domainID, workflowID, runID, version)
+	return fmt.Sprintf("%s%d", prefix, batchIdx)
+}
+
+func constructHistoryBlobNamePrefixWithVersion(path, domainID, workflowID, runID string, version int64) string {
+	prefix := constructHistoryBlobNamePrefix(path, domainID, workflowID, runID)
+	return fmt.Sprintf("%s/%v/", prefix, version)
+}
+
+func constructHistoryBlobNamePrefix(path, domainID, workflowID, runID string) string {
+	return strings.TrimLeft(strings.Join([]string{path, domainID, "history", workflowID, runID}, "/"), "/")
+}
+
+func constructTimeBasedSearchPrefix(path, domainID, primaryIndexKey, primaryIndexValue, secondaryIndexKey string, timestamp int64, precision string) string {
+	t := time.Unix(0, timestamp).In(time.UTC)
+	var timeFormat = ""
+	switch precision {
+	case prefixquery.PrecisionSecond:
+		timeFormat = ":05"
+		fallthrough
+	case prefixquery.PrecisionMinute:
+		timeFormat = ":04" + timeFormat
+		fallthrough
+	case prefixquery.PrecisionHour:
+		timeFormat = "15" + timeFormat
+		fallthrough
+	case prefixquery.PrecisionDay:
+		timeFormat = "2006-01-02T" + timeFormat
+	}
+
+	return fmt.Sprintf("%s/%s", constructVisibilitySearchPrefix(path, domainID, primaryIndexKey, primaryIndexValue, secondaryIndexKey), t.Format(timeFormat))
+}
+
+func constructTimestampIndex(path, domainID, primaryIndexKey, primaryIndexValue, secondaryIndexKey string, timestamp int64, runID string) string {
+	t := time.Unix(0, timestamp).In(time.UTC)
+	return fmt.Sprintf("%s/%s/%s", constructVisibilitySearchPrefix(path, domainID, primaryIndexKey, primaryIndexValue, secondaryIndexKey), t.Format(time.RFC3339), runID)
+}
+
+func constructVisibilitySearchPrefix(path, domainID, primaryIndexKey, primaryIndexValue, secondaryIndexType string) string {
+	return strings.TrimLeft(strings.Join([]string{path, domainID, "visibility", primaryIndexKey, primaryIndexValue, secondaryIndexType}, "/"), "/")
+}
+
+func ensureContextTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, defaultBlobstoreTimeout)
+}
+
+func contextExpired(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func convertToExecutionInfo(record *visibilityRecord) *types.WorkflowExecutionInfo {
+	return &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{
+			WorkflowID: record.WorkflowID,
+			RunID:      record.RunID,
+		},
+		Type: &types.WorkflowType{
+			Name: record.WorkflowTypeName,
+		},
+		StartTime:     common.Int64Ptr(record.StartTimestamp),
+		ExecutionTime: common.Int64Ptr(record.ExecutionTimestamp),
+		CloseTime:     common.Int64Ptr(record.CloseTimestamp),
+		CloseStatus:   record.CloseStatus.Ptr(),
+		HistoryLength: record.HistoryLength,
+		Memo:          record.Memo,
+		SearchAttributes: &types.SearchAttributes{
+			IndexedFields: archiver.ConvertSearchAttrToBytes(record.SearchAttributes),
+		},
+	}
+}
@@ -0,0 +1,89 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil":                   {err: nil, expected: false},
+		"random error":          {err: errors.New("some random error"), expected: false},
+		"too many requests":     {err: newResponseError(http.StatusTooManyRequests, ""), expected: true},
+		"internal server error": {err: newResponseError(http.StatusInternalServerError, bloberror.InternalError), expected: true},
+		"server busy":           {err: newResponseError(http.StatusServiceUnavailable, bloberror.ServerBusy), expected: true},
+		"not implemented":       {err: newResponseError(http.StatusNotImplemented, ""), expected: false},
+		"blob not found":        {err: newResponseError(http.StatusNotFound, bloberror.BlobNotFound), expected: false},
+		"network error":         {err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isRetryableError(tc.err))
+		})
+	}
+}
+
+func TestDownload_ErrorMapping(t *testing.T) {
+	client := newFakeBlobClient(testContainer)
+	URI, err := archiver.NewURI(testContainerURI)
+	require.NoError(t, err)
+
+	_, err = download(context.Background(), client, URI, "non-existent-blob")
+	assert.IsType(t, &types.EntityNotExistsError{}, err)
+
+	URI, err = archiver.NewURI("azblob://non-existent-container")
+	require.NoError(t, err)
+	_, err = download(context.Background(), client, URI, "non-existent-blob")
+	assert.IsType(t, &types.BadRequestError{}, err)
+}
+
+func TestNewBlobClient(t *testing.T) {
+	_, err := newBlobClient(&Config{})
+	assert.Equal(t, errEmptyAccountName, err)
+
+	client, err := newBlobClient(&Config{
+		AccountName: "account",
+		AccountKey:  "a2V5",
+		Endpoint:    "http://127.0.0.1:10000/account",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:10000/account", client.(*azureBlobClient).client.URL())
+
+	_, err = newBlobClient(&Config{ConnectionString: "not a connection string"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/prefixquery"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	visibilityArchiver struct {
+		container   *archiver.VisibilityBootstrapContainer
+		client      blobClient
+		queryParser prefixquery.Parser
+	}
+
+	visibilityRecord archiver.ArchiveVisibilityRequest
+
+	queryVisibilityRequest struct {
+		domainID      string
+		pageSize      int
+		nextPageToken []byte
+		parsedQuery   *prefixquery.Query
+	}
+
+	indexToArchive struct {
+		primaryIndex            string
+		primaryIndexValue       string
+		secondaryIndex          string
+		secondaryIndexTimestamp int64
+	}
+)
+
+const (
+	errEncodeVisibilityRecord       = "failed to encode visibility record"
+	secondaryIndexKeyStartTimeout   = "startTimeout"
+	secondaryIndexKeyCloseTimeout   = "closeTimeout"
+	primaryIndexKeyWorkflowTypeName = "workflowTypeName"
+	primaryIndexKeyWorkflowID       = "workflowID"
+)
+
+// NewVisibilityArchiver creates a new archiver.VisibilityArchiver based on Azure Blob Storage
+func NewVisibilityArchiver(
+	container *archiver.VisibilityBootstrapContainer,
+	config *Config,
+) (archiver.VisibilityArchiver, error) {
+	client, err := newBlobClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return newVisibilityArchiver(container, client), nil
+}
+
+func newVisibilityArchiver(
+	container *archiver.VisibilityBootstrapContainer,
+	client blobClient,
+) *visibilityArchiver {
+	return &visibilityArchiver{
+		container:   container,
+		client:      client,
+		queryParser: prefixquery.NewParser("Azure Blob Storage"),
+	}
+}
+
+func (v *visibilityArchiver) Archive(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.ArchiveVisibilityRequest,
+	opts ...archiver.ArchiveOption,
+) (err error) {
+	scope := v.container.MetricsClient.Scope(metrics.VisibilityArchiverScope, metrics.DomainTag(request.DomainName))
+	featureCatalog := archiver.GetFeatureCatalog(opts...)
+	sw := scope.StartTimer(metrics.CadenceLatency)
+	logger := archiver.TagLoggerWithArchiveVisibilityRequestAndURI(v.container.Logger, request, URI.String())
+	archiveFailReason := ""
+	defer func() {
+		sw.Stop()
+		if err != nil {
+			if isRetryableError(err) {
+				scope.IncCounter(metrics.VisibilityArchiverArchiveTransientErrorCount)
+				logger.Error(archiver.ArchiveTransientErrorMsg, tag.ArchivalArchiveFailReason(archiveFailReason), tag.Error(err))
+			} else {
+				scope.IncCounter(metrics.VisibilityArchiverArchiveNonRetryableErrorCount)
+				logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(archiveFailReason), tag.Error(err))
+				if featureCatalog.NonRetriableError != nil {
+					err = featureCatalog.NonRetriableError()
+				}
+			}
+		}
+	}()
+
+	if err := softValidateURI(URI); err != nil {
+		archiveFailReason = archiver.ErrReasonInvalidURI
+		return err
+	}
+
+	if err := archiver.ValidateVisibilityArchivalRequest(request); err != nil {
+		archiveFailReason = archiver.ErrReasonInvalidArchiveRequest
+		return err
+	}
+
+	encodedVisibilityRecord, err := encode(request)
+	if err != nil {
+		archiveFailReason = errEncodeVisibilityRecord
+		return err
+	}
+	indexes := createIndexesToArchive(request)
+	// Upload archive to all indexes
+	for _, element := range indexes {
+		blobName := constructTimestampIndex(URI.Path(), request.DomainID, element.primaryIndex, element.primaryIndexValue, element.secondaryIndex, element.secondaryIndexTimestamp, request.RunID)
+		if err := upload(ctx, v.client, URI, blobName, encodedVisibilityRecord); err != nil {
+			archiveFailReason = errWriteBlob
+			return err
+		}
+	}
+	scope.IncCounter(metrics.VisibilityArchiveSuccessCount)
+	return nil
+}
+
+func createIndexesToArchive(request *archiver.ArchiveVisibilityRequest) []indexToArchive {
+	return []indexToArchive{
+		{primaryIndexKeyWorkflowTypeName, request.WorkflowTypeName, secondaryIndexKeyCloseTimeout, request.CloseTimestamp},
+		{primaryIndexKeyWorkflowTypeName, request.WorkflowTypeName, secondaryIndexKeyStartTimeout, request.StartTimestamp},
+		{primaryIndexKeyWorkflowID, request.WorkflowID, secondaryIndexKeyCloseTimeout, request.CloseTimestamp},
+		{primaryIndexKeyWorkflowID, request.WorkflowID, secondaryIndexKeyStartTimeout, request.StartTimestamp},
+	}
+}
+
+func (v *visibilityArchiver) Query(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.QueryVisibilityRequest,
+) (*archiver.QueryVisibilityResponse, error) {
+	if err := softValidateURI(URI); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidURI.Error()}
+	}
+
+	if err := archiver.ValidateQueryRequest(request); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidQueryVisibilityRequest.Error()}
+	}
+
+	parsedQuery, err := v.queryParser.Parse(request.Query)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+
+	return v.query(ctx, URI, &queryVisibilityRequest{
+		domainID:      request.DomainID,
+		pageSize:      request.PageSize,
+		nextPageToken: request.NextPageToken,
+		parsedQuery:   parsedQuery,
+	})
+}
+
+func (v *visibilityArchiver) query(
+	ctx context.Context,
+	URI archiver.URI,
+	request *queryVisibilityRequest,
+) (*archiver.QueryVisibilityResponse, error) {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+	var marker *string
+	if request.nextPageToken != nil {
+		marker = deserializeQueryVisibilityToken(request.nextPageToken)
+	}
+	primaryIndex := primaryIndexKeyWorkflowTypeName
+	primaryIndexValue := request.parsedQuery.WorkflowTypeName
+	if request.parsedQuery.WorkflowID != nil {
+		primaryIndex = primaryIndexKeyWorkflowID
+		primaryIndexValue = request.parsedQuery.WorkflowID
+	}
+	var prefix = constructVisibilitySearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout) + "/"
+	if request.parsedQuery.CloseTime != nil {
+		prefix = constructTimeBasedSearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout, *request.parsedQuery.CloseTime, *request.parsedQuery.SearchPrecision)
+	}
+	if request.parsedQuery.StartTime != nil {
+		prefix = constructTimeBasedSearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyStartTimeout, *request.parsedQuery.StartTime, *request.parsedQuery.SearchPrecision)
+	}
+
+	blobNames, nextMarker, err := v.client.ListBlobs(ctx, URI.Hostname(), prefix, marker, int32(request.pageSize))
+	if err != nil {
+		if isRetryableError(err) {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+	if len(blobNames) == 0 {
+		return &archiver.QueryVisibilityResponse{}, nil
+	}
+
+	response := &archiver.QueryVisibilityResponse{}
+	if nextMarker != nil {
+		response.NextPageToken = serializeQueryVisibilityToken(*nextMarker)
+	}
+	for _, blobName := range blobNames {
+		encodedRecord, err := download(ctx, v.client, URI, blobName)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+
+		record, err := decodeVisibilityRecord(encodedRecord)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		response.Executions = append(response.Executions, convertToExecutionInfo(record))
+	}
+	return response, nil
+}
+
+func (v *visibilityArchiver) ValidateURI(URI archiver.URI) error {
+	err := softValidateURI(URI)
+	if err != nil {
+		return err
+	}
+	return containerExists(context.TODO(), v.client, URI)
+}
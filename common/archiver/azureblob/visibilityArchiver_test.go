@@ -0,0 +1,586 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package azureblob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/prefixquery"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/types"
+)
+
+type visibilityArchiverSuite struct {
+	*require.Assertions
+	suite.Suite
+	client *fakeBlobClient
+
+	container         *archiver.VisibilityBootstrapContainer
+	visibilityRecords []*visibilityRecord
+
+	controller      *gomock.Controller
+	testArchivalURI archiver.URI
+}
+
+func TestVisibilityArchiverSuite(t *testing.T) {
+	suite.Run(t, new(visibilityArchiverSuite))
+}
+
+func (s *visibilityArchiverSuite) TestValidateURI() {
+	testCases := []struct {
+		URI         string
+		expectedErr error
+	}{
+		{
+			URI:         "wrongscheme:///a/b/c",
+			expectedErr: archiver.ErrURISchemeMismatch,
+		},
+		{
+			URI:         "azblob://",
+			expectedErr: errNoContainerSpecified,
+		},
+		{
+			URI:         "azblob:///test",
+			expectedErr: errNoContainerSpecified,
+		},
+		{
+			URI:         "azblob://container/a/b/c",
+			expectedErr: errContainerNotExists,
+		},
+		{
+			URI:         testContainerURI,
+			expectedErr: nil,
+		},
+	}
+
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	for _, tc := range testCases {
+		URI, err := archiver.NewURI(tc.URI)
+		s.NoError(err)
+		s.Equal(tc.expectedErr, visibilityArchiver.ValidateURI(URI))
+	}
+}
+
+func (s *visibilityArchiverSuite) newTestVisibilityArchiver() *visibilityArchiver {
+	return newVisibilityArchiver(s.container, s.client)
+}
+
+const (
+	testWorkflowTypeName = "test-workflow-type"
+)
+
+func (s *visibilityArchiverSuite) SetupSuite() {
+	var err error
+	scope := tally.NewTestScope("test", nil)
+	s.client = newFakeBlobClient(testContainer)
+
+	s.testArchivalURI, err = archiver.NewURI(testContainerURI)
+	s.Require().NoError(err)
+
+	s.container = &archiver.VisibilityBootstrapContainer{
+		Logger:        testlogger.New(s.T()),
+		MetricsClient: metrics.NewClient(scope, metrics.VisibilityArchiverScope),
+	}
+	s.setupVisibilityDirectory()
+}
+
+func (s *visibilityArchiverSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.controller = gomock.NewController(s.T())
+}
+
+func (s *visibilityArchiverSuite) TearDownTest() {
+	s.controller.Finish()
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Fail_InvalidURI() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI("wrongscheme://")
+	s.NoError(err)
+	request := &archiver.ArchiveVisibilityRequest{
+		DomainName:         testDomainName,
+		DomainID:           testDomainID,
+		WorkflowID:         testWorkflowID,
+		RunID:              testRunID,
+		WorkflowTypeName:   testWorkflowTypeName,
+		StartTimestamp:     time.Now().UnixNano(),
+		ExecutionTimestamp: 0, // workflow without backoff
+		CloseTimestamp:     time.Now().UnixNano(),
+		CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+		HistoryLength:      int64(101),
+	}
+	err = visibilityArchiver.Archive(context.Background(), URI, request)
+	s.Error(err)
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Fail_InvalidRequest() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	err := visibilityArchiver.Archive(context.Background(), s.testArchivalURI, &archiver.ArchiveVisibilityRequest{})
+	s.Error(err)
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Fail_NonRetriableErrorOption() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	nonRetriableErr := errors.New("some non-retryable error")
+	err := visibilityArchiver.Archive(
+		context.Background(),
+		s.testArchivalURI,
+		&archiver.ArchiveVisibilityRequest{
+			DomainID: testDomainID,
+		},
+		archiver.GetNonRetriableErrorOption(nonRetriableErr),
+	)
+	s.Equal(nonRetriableErr, err)
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Success() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	closeTimestamp := time.Now()
+	request := &archiver.ArchiveVisibilityRequest{
+		DomainID:           testDomainID,
+		DomainName:         testDomainName,
+		WorkflowID:         testWorkflowID,
+		RunID:              testRunID,
+		WorkflowTypeName:   testWorkflowTypeName,
+		StartTimestamp:     closeTimestamp.Add(-time.Hour).UnixNano(),
+		ExecutionTimestamp: 0, // workflow without backoff
+		CloseTimestamp:     closeTimestamp.UnixNano(),
+		CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+		HistoryLength:      int64(101),
+		Memo: &types.Memo{
+			Fields: map[string][]byte{
+				"testFields": {1, 2, 3},
+			},
+		},
+		SearchAttributes: map[string]string{
+			"testAttribute": "456",
+		},
+	}
+	URI, err := archiver.NewURI(testContainerURI + "/test-archive-success")
+	s.NoError(err)
+	err = visibilityArchiver.Archive(context.Background(), URI, request)
+	s.NoError(err)
+
+	expectedBlobName := constructTimestampIndex(URI.Path(), testDomainID, primaryIndexKeyWorkflowID, testWorkflowID, secondaryIndexKeyCloseTimeout, closeTimestamp.UnixNano(), testRunID)
+	data, err := download(context.Background(), visibilityArchiver.client, URI, expectedBlobName)
+	s.NoError(err, expectedBlobName)
+
+	archivedRecord := &archiver.ArchiveVisibilityRequest{}
+	err = json.Unmarshal(data, archivedRecord)
+	s.NoError(err)
+	s.Equal(request, archivedRecord)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidURI() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI("wrongscheme://")
+	s.NoError(err)
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+	}
+	response, err := visibilityArchiver.Query(context.Background(), URI, request)
+	s.Error(err)
+	s.Nil(response)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidRequest() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	response, err := visibilityArchiver.Query(context.Background(), s.testArchivalURI, &archiver.QueryVisibilityRequest{})
+	s.Error(err)
+	s.Nil(response)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidQuery() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(nil, errors.New("invalid query"))
+	visibilityArchiver.queryParser = mockParser
+	response, err := visibilityArchiver.Query(context.Background(), s.testArchivalURI, &archiver.QueryVisibilityRequest{
+		DomainID: "some random domainID",
+		PageSize: 10,
+		Query:    "some invalid query",
+	})
+	s.Error(err)
+	s.Nil(response)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Success_DirectoryNotExist() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowID:      common.StringPtr(testWorkflowID),
+		CloseTime:       common.Int64Ptr(0),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionSecond),
+	}, nil)
+	visibilityArchiver.queryParser = mockParser
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		Query:    "parsed by mockParser",
+		PageSize: 1,
+	}
+	response, err := visibilityArchiver.Query(context.Background(), s.testArchivalURI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.Empty(response.Executions)
+	s.Empty(response.NextPageToken)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Success_NoNextPageToken() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		CloseTime:       common.Int64Ptr(int64(1 * time.Hour)),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionHour),
+		WorkflowID:      common.StringPtr(testWorkflowID),
+	}, nil)
+	visibilityArchiver.queryParser = mockParser
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 10,
+		Query:    "parsed by mockParser",
+	}
+	URI, err := archiver.NewURI(testContainerURI)
+	s.NoError(err)
+	response, err := visibilityArchiver.Query(context.Background(), URI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.Nil(response.NextPageToken)
+	s.Len(response.Executions, 2)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), response.Executions[0])
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Success_SmallPageSize() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		CloseTime:       common.Int64Ptr(0),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionDay),
+		WorkflowID:      common.StringPtr(testWorkflowID),
+	}, nil).AnyTimes()
+	visibilityArchiver.queryParser = mockParser
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 2,
+		Query:    "parsed by mockParser",
+	}
+	URI, err := archiver.NewURI(testContainerURI)
+	s.NoError(err)
+	response, err := visibilityArchiver.Query(context.Background(), URI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.NotNil(response.NextPageToken)
+	s.Len(response.Executions, 2)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), response.Executions[0])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), response.Executions[1])
+
+	request.NextPageToken = response.NextPageToken
+	response, err = visibilityArchiver.Query(context.Background(), URI, request)
+	s.NoError(err)
+	s.NotNil(response)
+	s.Nil(response.NextPageToken)
+	s.Len(response.Executions, 1)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), response.Executions[0])
+}
+
+type precisionTest struct {
+	day       int64
+	hour      int64
+	minute    int64
+	second    int64
+	precision string
+}
+
+func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
+	precisionTests := []*precisionTest{
+		{
+			day:       1,
+			hour:      0,
+			minute:    0,
+			second:    0,
+			precision: prefixquery.PrecisionDay,
+		},
+		{
+			day:       1,
+			hour:      1,
+			minute:    0,
+			second:    0,
+			precision: prefixquery.PrecisionDay,
+		},
+		{
+			day:       2,
+			hour:      1,
+			minute:    0,
+			second:    0,
+			precision: prefixquery.PrecisionHour,
+		},
+		{
+			day:       2,
+			hour:      1,
+			minute:    30,
+			second:    0,
+			precision: prefixquery.PrecisionHour,
+		},
+		{
+			day:       3,
+			hour:      2,
+			minute:    1,
+			second:    0,
+			precision: prefixquery.PrecisionMinute,
+		},
+		{
+			day:       3,
+			hour:      2,
+			minute:    1,
+			second:    30,
+			precision: prefixquery.PrecisionMinute,
+		},
+		{
+			day:       4,
+			hour:      3,
+			minute:    2,
+			second:    1,
+			precision: prefixquery.PrecisionSecond,
+		},
+		{
+			day:       4,
+			hour:      3,
+			minute:    2,
+			second:    1,
+			precision: prefixquery.PrecisionSecond,
+		},
+		{
+			day:       4,
+			hour:      3,
+			minute:    2,
+			second:    2,
+			precision: prefixquery.PrecisionSecond,
+		},
+		{
+			day:       4,
+			hour:      3,
+			minute:    2,
+			second:    2,
+			precision: prefixquery.PrecisionSecond,
+		},
+	}
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI(testContainerURI + "/archive-and-query-precision")
+	s.NoError(err)
+
+	for i, testData := range precisionTests {
+		record := archiver.ArchiveVisibilityRequest{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       testWorkflowID,
+			RunID:            fmt.Sprintf("%s-%d", testRunID, i),
+			WorkflowTypeName: testWorkflowTypeName,
+			StartTimestamp:   testData.day*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second),
+			CloseTimestamp:   (testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second),
+			CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:    101,
+		}
+		err := visibilityArchiver.Archive(context.Background(), URI, &record)
+		s.NoError(err)
+	}
+
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "parsed by mockParser",
+	}
+
+	for i, testData := range precisionTests {
+		mockParser := prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			CloseTime:       common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision: common.StringPtr(testData.precision),
+			WorkflowID:      common.StringPtr(testWorkflowID),
+		}, nil).AnyTimes()
+		visibilityArchiver.queryParser = mockParser
+
+		response, err := visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		s.Len(response.Executions, 2, "Iteration ", i)
+
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			StartTime:       common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision: common.StringPtr(testData.precision),
+			WorkflowID:      common.StringPtr(testWorkflowID),
+		}, nil).AnyTimes()
+		visibilityArchiver.queryParser = mockParser
+
+		response, err = visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		s.Len(response.Executions, 2, "Iteration ", i)
+
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			CloseTime:        common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision:  common.StringPtr(testData.precision),
+			WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
+		}, nil).AnyTimes()
+		visibilityArchiver.queryParser = mockParser
+
+		response, err = visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		s.Len(response.Executions, 2, "Iteration ", i)
+
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			StartTime:        common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision:  common.StringPtr(testData.precision),
+			WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
+		}, nil).AnyTimes()
+		visibilityArchiver.queryParser = mockParser
+
+		response, err = visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		s.Len(response.Executions, 2, "Iteration ", i)
+	}
+}
+
+func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI(testContainerURI + "/archive-and-query")
+	s.NoError(err)
+	for _, record := range s.visibilityRecords {
+		err := visibilityArchiver.Archive(context.Background(), URI, (*archiver.ArchiveVisibilityRequest)(record))
+		s.NoError(err)
+	}
+
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowID: common.StringPtr(testWorkflowID),
+	}, nil).AnyTimes()
+	visibilityArchiver.queryParser = mockParser
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+		Query:    "parsed by mockParser",
+	}
+	executions := []*types.WorkflowExecutionInfo{}
+	var first = true
+	for first || request.NextPageToken != nil {
+		response, err := visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		executions = append(executions, response.Executions...)
+		request.NextPageToken = response.NextPageToken
+		first = false
+	}
+	s.Len(executions, 3)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), executions[0])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), executions[2])
+
+	mockParser = prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
+	}, nil).AnyTimes()
+	visibilityArchiver.queryParser = mockParser
+	request = &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+		Query:    "parsed by mockParser",
+	}
+	executions = []*types.WorkflowExecutionInfo{}
+	first = true
+	for first || request.NextPageToken != nil {
+		response, err := visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		executions = append(executions, response.Executions...)
+		request.NextPageToken = response.NextPageToken
+		first = false
+	}
+	s.Len(executions, 3)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), executions[0])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), executions[2])
+}
+
+func (s *visibilityArchiverSuite) setupVisibilityDirectory() {
+	s.visibilityRecords = []*visibilityRecord{
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       testWorkflowID,
+			RunID:            testRunID,
+			WorkflowTypeName: testWorkflowTypeName,
+			StartTimestamp:   1,
+			CloseTimestamp:   int64(1 * time.Hour),
+			CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:    101,
+		},
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       testWorkflowID,
+			RunID:            testRunID + "1",
+			WorkflowTypeName: testWorkflowTypeName,
+			StartTimestamp:   1,
+			CloseTimestamp:   int64(1*time.Hour + 30*time.Minute),
+			CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:    101,
+		},
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       testWorkflowID,
+			RunID:            testRunID + "1",
+			WorkflowTypeName: testWorkflowTypeName,
+			StartTimestamp:   1,
+			CloseTimestamp:   int64(3 * time.Hour),
+			CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:    101,
+		},
+	}
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	for _, record := range s.visibilityRecords {
+		s.writeVisibilityRecordForQueryTest(visibilityArchiver, record)
+	}
+}
+
+func (s *visibilityArchiverSuite) writeVisibilityRecordForQueryTest(visibilityArchiver *visibilityArchiver, record *visibilityRecord) {
+	err := visibilityArchiver.Archive(context.Background(), s.testArchivalURI, (*archiver.ArchiveVisibilityRequest)(record))
+	s.Require().NoError(err)
+}
@@ -0,0 +1,290 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// Archiver archives visibility records in the columnar format with a Store, and queries them
+	Archiver struct {
+		store       Store
+		batcher     *batcher
+		queryParser QueryParser
+	}
+
+	queryVisibilityToken struct {
+		LastCloseTime int64
+		LastRunID     string
+	}
+)
+
+var (
+	errStagedFileCompacted = errors.New("staged file was compacted while reading its partition")
+	errUnboundedCloseTime  = errors.New("the query must have both a lower and an upper bound on CloseTime, " +
+		"for example CloseTime BETWEEN '2024-03-01T00:00:00Z' AND '2024-03-02T00:00:00Z', which are not under OR or NOT")
+)
+
+const (
+	defaultFlushInterval = time.Second
+	defaultMaxBatchSize  = 1000
+
+	maxReadPartitionAttempts = 3
+)
+
+// NewArchiver creates a new columnar visibility archiver writing its files with the given store
+func NewArchiver(store Store, config *config.ColumnarVisibilityArchiver, logger log.Logger) *Archiver {
+	return newArchiver(store, config, clock.NewRealTimeSource(), logger)
+}
+
+func newArchiver(
+	store Store,
+	config *config.ColumnarVisibilityArchiver,
+	timeSource clock.TimeSource,
+	logger log.Logger,
+) *Archiver {
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	maxBatchSize := config.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+	return &Archiver{
+		store:       store,
+		batcher:     newBatcher(store, timeSource, logger, flushInterval, maxBatchSize),
+		queryParser: NewQueryParser(),
+	}
+}
+
+// Archive durably writes the record, which is later compacted with the other records of its partition archived
+// at about the same time. The request is expected to be validated by the caller.
+func (a *Archiver) Archive(ctx context.Context, URI archiver.URI, request *archiver.ArchiveVisibilityRequest) error {
+	return a.batcher.add(ctx, URI, request)
+}
+
+// Query returns the records matching the query, ordered by close time and run ID, both descending.
+// The query must have a bounded close time range.
+// The request is expected to be validated by the caller.
+func (a *Archiver) Query(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.QueryVisibilityRequest,
+) (*archiver.QueryVisibilityResponse, error) {
+	parsedQuery, err := a.queryParser.Parse(request.Query)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+	// every day of the close time range is listed and read, so an unbounded range would read the whole domain
+	if !parsedQuery.hasCloseTimeRange() {
+		return nil, &types.BadRequestError{Message: errUnboundedCloseTime.Error()}
+	}
+
+	var token *queryVisibilityToken
+	if request.NextPageToken != nil {
+		token, err = deserializeQueryVisibilityToken(request.NextPageToken)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: archiver.ErrNextPageTokenCorrupted.Error()}
+		}
+		parsedQuery.latestCloseTime = common.MinInt64(parsedQuery.latestCloseTime, token.LastCloseTime)
+	}
+
+	partitions, err := a.listPartitions(ctx, URI, request.DomainID, parsedQuery)
+	if err != nil {
+		return nil, &types.InternalServiceError{Message: err.Error()}
+	}
+
+	response := &archiver.QueryVisibilityResponse{}
+	seenRunIDs := make(map[string]struct{})
+	for _, partition := range partitions {
+		records, err := a.readPartition(ctx, URI, request.DomainID, partition)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+
+		var matches []*archiver.ArchiveVisibilityRequest
+		for _, record := range records {
+			if _, ok := seenRunIDs[record.RunID]; ok {
+				// a record is written again when its archival is retried
+				continue
+			}
+			if token != nil && !isAfterToken(record, token) {
+				continue
+			}
+			if !parsedQuery.filter(record) {
+				continue
+			}
+			seenRunIDs[record.RunID] = struct{}{}
+			matches = append(matches, record)
+		}
+		sort.Slice(matches, func(i, j int) bool {
+			if matches[i].CloseTimestamp != matches[j].CloseTimestamp {
+				return matches[i].CloseTimestamp > matches[j].CloseTimestamp
+			}
+			return matches[i].RunID > matches[j].RunID
+		})
+
+		for _, record := range matches {
+			response.Executions = append(response.Executions, convertToExecutionInfo(record))
+			if len(response.Executions) == request.PageSize {
+				response.NextPageToken, err = serializeQueryVisibilityToken(&queryVisibilityToken{
+					LastCloseTime: record.CloseTimestamp,
+					LastRunID:     record.RunID,
+				})
+				if err != nil {
+					return nil, &types.InternalServiceError{Message: err.Error()}
+				}
+				return response, nil
+			}
+		}
+	}
+	return response, nil
+}
+
+// listPartitions returns the partitions overlapping the close time range of the query, latest first
+func (a *Archiver) listPartitions(
+	ctx context.Context,
+	URI archiver.URI,
+	domainID string,
+	parsedQuery *parsedQuery,
+) ([]string, error) {
+	partitions, err := a.store.ListPartitions(ctx, URI, domainID)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, partition := range partitions {
+		start, end, err := partitionTimeRange(partition)
+		if err != nil {
+			// not a partition of the columnar format
+			continue
+		}
+		if end < parsedQuery.earliestCloseTime || start > parsedQuery.latestCloseTime {
+			continue
+		}
+		result = append(result, partition)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(result)))
+	return result, nil
+}
+
+// readPartition reads the records of the compacted and the staged files of a partition.
+// A staged file can be compacted and deleted after the files are listed, in which case the partition is listed
+// again to read the compacted file instead.
+func (a *Archiver) readPartition(
+	ctx context.Context,
+	URI archiver.URI,
+	domainID string,
+	partition string,
+) ([]*archiver.ArchiveVisibilityRequest, error) {
+	for attempt := 1; ; attempt++ {
+		records, err := a.readPartitionFiles(ctx, URI, domainID, partition)
+		if err != errStagedFileCompacted || attempt == maxReadPartitionAttempts {
+			return records, err
+		}
+	}
+}
+
+func (a *Archiver) readPartitionFiles(
+	ctx context.Context,
+	URI archiver.URI,
+	domainID string,
+	partition string,
+) ([]*archiver.ArchiveVisibilityRequest, error) {
+	filenames, err := a.store.ListFiles(ctx, URI, domainID, partition)
+	if err != nil {
+		return nil, err
+	}
+	var records []*archiver.ArchiveVisibilityRequest
+	for _, filename := range filenames {
+		if !isColumnarFile(filename) {
+			continue
+		}
+		data, err := a.store.ReadFile(ctx, URI, domainID, partition, filename)
+		if err != nil {
+			if isStagedFile(filename) {
+				if exists, listErr := fileExists(ctx, a.store, URI, domainID, partition, filename); listErr == nil && !exists {
+					return nil, errStagedFileCompacted
+				}
+			}
+			return nil, err
+		}
+		fileRecords, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+func isAfterToken(record *archiver.ArchiveVisibilityRequest, token *queryVisibilityToken) bool {
+	if record.CloseTimestamp != token.LastCloseTime {
+		return record.CloseTimestamp < token.LastCloseTime
+	}
+	return record.RunID < token.LastRunID
+}
+
+func serializeQueryVisibilityToken(token *queryVisibilityToken) ([]byte, error) {
+	return json.Marshal(token)
+}
+
+func deserializeQueryVisibilityToken(bytes []byte) (*queryVisibilityToken, error) {
+	token := &queryVisibilityToken{}
+	err := json.Unmarshal(bytes, token)
+	return token, err
+}
+
+func convertToExecutionInfo(record *archiver.ArchiveVisibilityRequest) *types.WorkflowExecutionInfo {
+	return &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{
+			WorkflowID: record.WorkflowID,
+			RunID:      record.RunID,
+		},
+		Type: &types.WorkflowType{
+			Name: record.WorkflowTypeName,
+		},
+		StartTime:     common.Int64Ptr(record.StartTimestamp),
+		ExecutionTime: common.Int64Ptr(record.ExecutionTimestamp),
+		CloseTime:     common.Int64Ptr(record.CloseTimestamp),
+		CloseStatus:   record.CloseStatus.Ptr(),
+		HistoryLength: record.HistoryLength,
+		Memo:          record.Memo,
+		SearchAttributes: &types.SearchAttributes{
+			IndexedFields: archiver.ConvertSearchAttrToBytes(record.SearchAttributes),
+		},
+	}
+}
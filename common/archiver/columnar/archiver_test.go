@@ -0,0 +1,238 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/types"
+)
+
+type archiverSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	store      *memoryStore
+	timeSource clock.MockedTimeSource
+	archiver   *Archiver
+	URI        archiver.URI
+	records    []*archiver.ArchiveVisibilityRequest
+}
+
+const testCloseTimeRange = "CloseTime BETWEEN '2024-03-01T00:00:00Z' AND '2024-03-31T00:00:00Z'"
+
+func TestArchiverSuite(t *testing.T) {
+	suite.Run(t, new(archiverSuite))
+}
+
+func (s *archiverSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.store = newMemoryStore()
+	// the records stay in their staged files until the time source is advanced
+	s.timeSource = clock.NewMockedTimeSource()
+	s.archiver = newArchiver(s.store, &config.ColumnarVisibilityArchiver{}, s.timeSource, testlogger.New(s.T()))
+	URI, err := archiver.NewURI(testURI)
+	s.NoError(err)
+	s.URI = URI
+
+	// two records per day over five days, the latest closed first
+	closeTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	s.records = nil
+	for i := 0; i < 10; i++ {
+		status := types.WorkflowExecutionCloseStatusCompleted
+		if i%2 == 1 {
+			status = types.WorkflowExecutionCloseStatusFailed
+		}
+		s.records = append(s.records, &archiver.ArchiveVisibilityRequest{
+			DomainID:         testDomainID,
+			WorkflowID:       fmt.Sprintf("workflowID-%d", i),
+			RunID:            fmt.Sprintf("runID-%d", i),
+			WorkflowTypeName: "workflowType",
+			CloseTimestamp:   closeTime.Add(-time.Duration(i) * 12 * time.Hour).UnixNano(),
+			CloseStatus:      status,
+			SearchAttributes: map[string]string{"CustomIntField": fmt.Sprintf("%d", i)},
+		})
+	}
+	for _, record := range s.records {
+		s.NoError(s.archiver.Archive(context.Background(), s.URI, record))
+	}
+}
+
+func (s *archiverSuite) TestQuery() {
+	response, err := s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "CloseStatus = 'failed' and CustomIntField < 7 and " + testCloseTimeRange,
+	})
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal([]string{"runID-1", "runID-3", "runID-5"}, runIDs(response))
+	s.Equal(convertToExecutionInfo(s.records[1]), response.Executions[0])
+}
+
+func (s *archiverSuite) TestQueryPagination() {
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 3,
+		Query:    "CloseTime BETWEEN '2024-03-01T00:00:00Z' AND '2024-03-09T12:00:00Z'",
+	}
+	var pages [][]string
+	for {
+		response, err := s.archiver.Query(context.Background(), s.URI, request)
+		s.NoError(err)
+		pages = append(pages, runIDs(response))
+		if response.NextPageToken == nil {
+			break
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+	s.Equal([][]string{
+		{"runID-2", "runID-3", "runID-4"},
+		{"runID-5", "runID-6", "runID-7"},
+		{"runID-8", "runID-9"},
+	}, pages)
+}
+
+func (s *archiverSuite) TestQuerySameCloseTime() {
+	closeTime := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	for _, runID := range []string{"runID-a", "runID-c", "runID-b"} {
+		s.NoError(s.archiver.Archive(context.Background(), s.URI, &archiver.ArchiveVisibilityRequest{
+			DomainID:       testDomainID,
+			RunID:          runID,
+			CloseTimestamp: closeTime,
+		}))
+	}
+
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 2,
+		Query:    fmt.Sprintf("CloseTime = %d", closeTime),
+	}
+	response, err := s.archiver.Query(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Equal([]string{"runID-c", "runID-b"}, runIDs(response))
+
+	request.NextPageToken = response.NextPageToken
+	response, err = s.archiver.Query(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Equal([]string{"runID-a"}, runIDs(response))
+	s.Nil(response.NextPageToken)
+}
+
+func (s *archiverSuite) TestQueryDeduplicatesRetries() {
+	s.NoError(s.archiver.Archive(context.Background(), s.URI, s.records[0]))
+	response, err := s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "WorkflowID = 'workflowID-0' and " + testCloseTimeRange,
+	})
+	s.NoError(err)
+	s.Equal([]string{"runID-0"}, runIDs(response))
+}
+
+func (s *archiverSuite) TestQueryOnlyReadsMatchingPartitions() {
+	// a corrupted file in a partition outside of the close time range of the query isn't read
+	s.NoError(s.store.WriteFile(context.Background(), s.URI, testDomainID, "2024-03-06", newFilename(time.Now()), []byte("corrupted")))
+
+	response, err := s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "CloseTime >= '2024-03-10T00:00:00Z' and CloseTime < '2024-03-11T00:00:00Z'",
+	})
+	s.NoError(err)
+	s.Equal([]string{"runID-0", "runID-1"}, runIDs(response))
+
+	_, err = s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "CloseTime >= '2024-03-05T00:00:00Z' and CloseTime < '2024-03-11T00:00:00Z'",
+	})
+	s.IsType(&types.InternalServiceError{}, err)
+}
+
+func (s *archiverSuite) TestQueryInvalidRequest() {
+	_, err := s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    "WorkflowID like 'workflowID'",
+	})
+	s.IsType(&types.BadRequestError{}, err)
+
+	_, err = s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID:      testDomainID,
+		PageSize:      100,
+		NextPageToken: []byte("invalid token"),
+		Query:         "WorkflowID = 'workflowID' and " + testCloseTimeRange,
+	})
+	s.IsType(&types.BadRequestError{}, err)
+
+	for _, query := range []string{
+		"WorkflowID = 'workflowID'",
+		"CloseTime >= '2024-03-10T00:00:00Z'",
+		"CloseTime <= '2024-03-10T00:00:00Z'",
+		"CloseTime >= '2024-03-10T00:00:00Z' or CloseTime <= '2024-03-11T00:00:00Z'",
+	} {
+		_, err = s.archiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 100,
+			Query:    query,
+		})
+		s.IsType(&types.BadRequestError{}, err, query)
+	}
+}
+
+func (s *archiverSuite) TestQueryAfterCompaction() {
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 100,
+		Query:    testCloseTimeRange,
+	}
+	response, err := s.archiver.Query(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Len(response.Executions, len(s.records))
+
+	s.timeSource.Advance(time.Second)
+	requireFileCount(s.T(), s.store, 0, 5)
+
+	compactedResponse, err := s.archiver.Query(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Equal(response, compactedResponse)
+}
+
+func runIDs(response *archiver.QueryVisibilityResponse) []string {
+	var result []string
+	for _, execution := range response.Executions {
+		result = append(result, execution.Execution.RunID)
+	}
+	return result
+}
@@ -0,0 +1,239 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+)
+
+type (
+	// Store reads and writes the files of the columnar format in a storage backend.
+	// Files are grouped by the URI, the domain and the partition they belong to.
+	Store interface {
+		ListPartitions(ctx context.Context, URI archiver.URI, domainID string) ([]string, error)
+		ListFiles(ctx context.Context, URI archiver.URI, domainID string, partition string) ([]string, error)
+		ReadFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) ([]byte, error)
+		WriteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string, data []byte) error
+		// DeleteFile deletes a file, and doesn't fail if the file doesn't exist
+		DeleteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) error
+	}
+
+	// batcher groups the records of the same partition which are archived at about the same time into a single file.
+	// Each record is first written to its own staged file, which is what makes its archival durable, so callers
+	// don't wait for the rest of the batch. The first record of a batch starts a timer, and the staged files of
+	// the partition are compacted into a single file in the background when the timer fires or when the batch
+	// reaches its maximum size, whichever comes first. Queries read the staged files until they are compacted.
+	batcher struct {
+		store         Store
+		timeSource    clock.TimeSource
+		logger        log.Logger
+		flushInterval time.Duration
+		maxBatchSize  int
+
+		sync.Mutex
+		batches map[batchKey]*batch
+	}
+
+	batchKey struct {
+		URI       string
+		domainID  string
+		partition string
+	}
+
+	batch struct {
+		key   batchKey
+		URI   archiver.URI
+		size  int
+		timer clock.Timer
+	}
+)
+
+// flushTimeout is the timeout of compacting a batch, which doesn't depend on the context of any of its callers
+const flushTimeout = time.Minute
+
+func newBatcher(
+	store Store,
+	timeSource clock.TimeSource,
+	logger log.Logger,
+	flushInterval time.Duration,
+	maxBatchSize int,
+) *batcher {
+	return &batcher{
+		store:         store,
+		timeSource:    timeSource,
+		logger:        logger,
+		flushInterval: flushInterval,
+		maxBatchSize:  maxBatchSize,
+		batches:       make(map[batchKey]*batch),
+	}
+}
+
+// add writes the record to a staged file, and schedules the compaction of its partition.
+// The record is durably archived once add returns without error.
+func (b *batcher) add(ctx context.Context, URI archiver.URI, record *archiver.ArchiveVisibilityRequest) error {
+	key := batchKey{
+		URI:       URI.String(),
+		domainID:  record.DomainID,
+		partition: PartitionName(record.CloseTimestamp),
+	}
+
+	data, err := encode([]*archiver.ArchiveVisibilityRequest{record})
+	if err != nil {
+		return err
+	}
+	if err := b.store.WriteFile(ctx, URI, key.domainID, key.partition, newStagedFilename(b.timeSource.Now()), data); err != nil {
+		return err
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	current, ok := b.batches[key]
+	if !ok {
+		current = &batch{
+			key: key,
+			URI: URI,
+		}
+		current.timer = b.timeSource.AfterFunc(b.flushInterval, func() {
+			if b.remove(current) {
+				b.flush(current)
+			}
+		})
+		b.batches[key] = current
+	}
+	current.size++
+	if current.size >= b.maxBatchSize {
+		delete(b.batches, key)
+		current.timer.Stop()
+		go b.flush(current)
+	}
+	return nil
+}
+
+// remove removes the batch from the pending batches, and returns false if it is already removed
+func (b *batcher) remove(current *batch) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.batches[current.key] != current {
+		return false
+	}
+	delete(b.batches, current.key)
+	return true
+}
+
+// flush compacts the staged files of the partition of the batch, including the ones left by previous
+// failed compactions or by other hosts, into files of at most maxBatchSize records.
+// A compacted file is written before its staged files are deleted, so a record is never lost, and a record
+// compacted more than once, for example by two hosts at the same time, is removed by run ID when querying.
+func (b *batcher) flush(current *batch) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	logger := b.logger.WithTags(
+		tag.ArchivalURI(current.key.URI),
+		tag.WorkflowDomainID(current.key.domainID),
+		tag.Value(current.key.partition),
+	)
+	filenames, err := b.store.ListFiles(ctx, current.URI, current.key.domainID, current.key.partition)
+	if err != nil {
+		logger.Error("failed to list the staged visibility records", tag.Error(err))
+		return
+	}
+	var staged []string
+	for _, filename := range filenames {
+		if isStagedFile(filename) {
+			staged = append(staged, filename)
+		}
+	}
+	for len(staged) > 0 {
+		size := b.maxBatchSize
+		if size > len(staged) {
+			size = len(staged)
+		}
+		if err := b.compact(ctx, current, staged[:size]); err != nil {
+			logger.Error("failed to compact the staged visibility records", tag.Error(err))
+			return
+		}
+		staged = staged[size:]
+	}
+}
+
+func (b *batcher) compact(ctx context.Context, current *batch, staged []string) error {
+	var records []*archiver.ArchiveVisibilityRequest
+	for _, filename := range staged {
+		data, err := b.store.ReadFile(ctx, current.URI, current.key.domainID, current.key.partition, filename)
+		if err != nil {
+			if exists, listErr := fileExists(ctx, b.store, current.URI, current.key.domainID, current.key.partition, filename); listErr == nil && !exists {
+				// compacted by another host at the same time
+				continue
+			}
+			return err
+		}
+		fileRecords, err := decode(data)
+		if err != nil {
+			return err
+		}
+		records = append(records, fileRecords...)
+	}
+	data, err := encode(records)
+	if err != nil {
+		return err
+	}
+	if err := b.store.WriteFile(ctx, current.URI, current.key.domainID, current.key.partition, newFilename(b.timeSource.Now()), data); err != nil {
+		return err
+	}
+	for _, filename := range staged {
+		if err := b.store.DeleteFile(ctx, current.URI, current.key.domainID, current.key.partition, filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fileExists(
+	ctx context.Context,
+	store Store,
+	URI archiver.URI,
+	domainID string,
+	partition string,
+	filename string,
+) (bool, error) {
+	filenames, err := store.ListFiles(ctx, URI, domainID, partition)
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range filenames {
+		if existing == filename {
+			return true, nil
+		}
+	}
+	return false, nil
+}
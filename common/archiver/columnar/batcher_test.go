@@ -0,0 +1,314 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log/testlogger"
+)
+
+const (
+	testDomainID = "test-domain-id"
+	testURI      = "file:///tmp/test"
+)
+
+type memoryStore struct {
+	sync.Mutex
+	// files are indexed by URI, domain ID, partition and filename
+	files    map[string]map[string]map[string]map[string][]byte
+	writeErr error
+	// compactErr fails the writes of compacted files
+	compactErr error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		files: make(map[string]map[string]map[string]map[string][]byte),
+	}
+}
+
+func (s *memoryStore) ListPartitions(ctx context.Context, URI archiver.URI, domainID string) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var partitions []string
+	for partition := range s.files[URI.String()][domainID] {
+		partitions = append(partitions, partition)
+	}
+	sort.Strings(partitions)
+	return partitions, nil
+}
+
+func (s *memoryStore) ListFiles(ctx context.Context, URI archiver.URI, domainID string, partition string) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var filenames []string
+	for filename := range s.files[URI.String()][domainID][partition] {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
+
+func (s *memoryStore) ReadFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	data, ok := s.files[URI.String()][domainID][partition][filename]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found", filename)
+	}
+	return data, nil
+}
+
+func (s *memoryStore) WriteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string, data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	if s.compactErr != nil && !isStagedFile(filename) {
+		return s.compactErr
+	}
+	if s.files[URI.String()] == nil {
+		s.files[URI.String()] = make(map[string]map[string]map[string][]byte)
+	}
+	if s.files[URI.String()][domainID] == nil {
+		s.files[URI.String()][domainID] = make(map[string]map[string][]byte)
+	}
+	if s.files[URI.String()][domainID][partition] == nil {
+		s.files[URI.String()][domainID][partition] = make(map[string][]byte)
+	}
+	s.files[URI.String()][domainID][partition][filename] = data
+	return nil
+}
+
+func (s *memoryStore) DeleteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.files[URI.String()][domainID][partition], filename)
+	return nil
+}
+
+// fileCount returns the number of staged and compacted files
+func (s *memoryStore) fileCount() (int, int) {
+	s.Lock()
+	defer s.Unlock()
+
+	staged, compacted := 0, 0
+	for _, domains := range s.files {
+		for _, partitions := range domains {
+			for _, files := range partitions {
+				for filename := range files {
+					if isStagedFile(filename) {
+						staged++
+					} else {
+						compacted++
+					}
+				}
+			}
+		}
+	}
+	return staged, compacted
+}
+
+func requireFileCount(t *testing.T, store *memoryStore, expectedStaged int, expectedCompacted int) {
+	require.Eventually(t, func() bool {
+		staged, compacted := store.fileCount()
+		return staged == expectedStaged && compacted == expectedCompacted
+	}, time.Second, time.Millisecond)
+}
+
+func newTestBatcher(t *testing.T, store Store, timeSource clock.TimeSource, maxBatchSize int) *batcher {
+	return newBatcher(store, timeSource, testlogger.New(t), time.Second, maxBatchSize)
+}
+
+func TestBatcherStagesRecords(t *testing.T) {
+	store := newMemoryStore()
+	b := newTestBatcher(t, store, clock.NewMockedTimeSource(), 10)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{
+			DomainID: testDomainID,
+			RunID:    fmt.Sprintf("runID-%d", i),
+		}))
+	}
+	requireFileCount(t, store, 3, 0)
+	require.Equal(t, 3, b.batches[batchKey{URI: testURI, domainID: testDomainID, partition: PartitionName(0)}].size)
+}
+
+func TestBatcherCompactsOnInterval(t *testing.T) {
+	store := newMemoryStore()
+	timeSource := clock.NewMockedTimeSource()
+	b := newTestBatcher(t, store, timeSource, 10)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	closeTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{
+			DomainID:       testDomainID,
+			RunID:          fmt.Sprintf("runID-%d", i),
+			CloseTimestamp: closeTime.UnixNano(),
+		}))
+	}
+
+	timeSource.Advance(time.Second)
+	requireFileCount(t, store, 0, 1)
+	require.Empty(t, b.batches)
+
+	filenames, err := store.ListFiles(context.Background(), URI, testDomainID, "2024-03-10")
+	require.NoError(t, err)
+	require.Len(t, filenames, 1)
+	data, err := store.ReadFile(context.Background(), URI, testDomainID, "2024-03-10", filenames[0])
+	require.NoError(t, err)
+	records, err := decode(data)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+}
+
+func TestBatcherCompactsFullBatch(t *testing.T) {
+	store := newMemoryStore()
+	b := newTestBatcher(t, store, clock.NewMockedTimeSource(), 2)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{
+			DomainID: testDomainID,
+			RunID:    fmt.Sprintf("runID-%d", i),
+		}))
+	}
+	require.Empty(t, b.batches)
+
+	// the two compactions may both pick up the staged files written while the other one runs
+	require.Eventually(t, func() bool {
+		staged, _ := store.fileCount()
+		return staged == 0
+	}, time.Second, time.Millisecond)
+	filenames, err := store.ListFiles(context.Background(), URI, testDomainID, PartitionName(0))
+	require.NoError(t, err)
+	runIDs := make(map[string]struct{})
+	for _, filename := range filenames {
+		data, err := store.ReadFile(context.Background(), URI, testDomainID, PartitionName(0), filename)
+		require.NoError(t, err)
+		records, err := decode(data)
+		require.NoError(t, err)
+		for _, record := range records {
+			runIDs[record.RunID] = struct{}{}
+		}
+	}
+	require.Len(t, runIDs, 4)
+}
+
+func TestBatcherCompactsLeftoverStagedFiles(t *testing.T) {
+	store := newMemoryStore()
+	timeSource := clock.NewMockedTimeSource()
+	b := newTestBatcher(t, store, timeSource, 10)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	// a staged file left by a host which stopped before compacting it
+	data, err := encode([]*archiver.ArchiveVisibilityRequest{{DomainID: testDomainID, RunID: "runID-0"}})
+	require.NoError(t, err)
+	require.NoError(t, store.WriteFile(context.Background(), URI, testDomainID, PartitionName(0), newStagedFilename(time.Now()), data))
+
+	require.NoError(t, b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{DomainID: testDomainID, RunID: "runID-1"}))
+	timeSource.Advance(time.Second)
+
+	requireFileCount(t, store, 0, 1)
+}
+
+func TestBatcherSeparatesPartitions(t *testing.T) {
+	store := newMemoryStore()
+	timeSource := clock.NewMockedTimeSource()
+	b := newTestBatcher(t, store, timeSource, 10)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	closeTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	records := []*archiver.ArchiveVisibilityRequest{
+		{DomainID: testDomainID, RunID: "runID-1", CloseTimestamp: closeTime.UnixNano()},
+		{DomainID: testDomainID, RunID: "runID-2", CloseTimestamp: closeTime.Add(24 * time.Hour).UnixNano()},
+		{DomainID: "other-domain-id", RunID: "runID-3", CloseTimestamp: closeTime.UnixNano()},
+	}
+	for _, record := range records {
+		require.NoError(t, b.add(context.Background(), URI, record))
+	}
+	timeSource.Advance(time.Second)
+	requireFileCount(t, store, 0, 3)
+
+	partitions, err := store.ListPartitions(context.Background(), URI, testDomainID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-03-10", "2024-03-11"}, partitions)
+	partitions, err = store.ListPartitions(context.Background(), URI, "other-domain-id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-03-10"}, partitions)
+}
+
+func TestBatcherWriteError(t *testing.T) {
+	store := newMemoryStore()
+	store.writeErr = fmt.Errorf("write failed")
+	b := newTestBatcher(t, store, clock.NewMockedTimeSource(), 1)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	err = b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{DomainID: testDomainID})
+	require.EqualError(t, err, "write failed")
+	require.Empty(t, b.batches)
+}
+
+func TestBatcherCompactionError(t *testing.T) {
+	store := newMemoryStore()
+	store.compactErr = fmt.Errorf("write failed")
+	timeSource := clock.NewMockedTimeSource()
+	b := newTestBatcher(t, store, timeSource, 10)
+	URI, err := archiver.NewURI(testURI)
+	require.NoError(t, err)
+
+	require.NoError(t, b.add(context.Background(), URI, &archiver.ArchiveVisibilityRequest{DomainID: testDomainID}))
+	timeSource.Advance(time.Second)
+	require.Eventually(t, func() bool {
+		b.Lock()
+		defer b.Unlock()
+		return len(b.batches) == 0
+	}, time.Second, time.Millisecond)
+
+	// the record stays in its staged file until the next compaction of its partition
+	requireFileCount(t, store, 1, 0)
+}
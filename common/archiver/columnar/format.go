@@ -0,0 +1,200 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package columnar archives visibility records in batches of Parquet files, partitioned by domain and close day,
+// so that queries only read the partitions of the requested time range.
+package columnar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// FileExtension is the extension of the columnar visibility files
+	FileExtension = ".parquet"
+	// stagedFileExtension is the extension of the files holding a single record until it is compacted into a batch
+	stagedFileExtension = ".staged" + FileExtension
+
+	partitionFormat = "2006-01-02"
+)
+
+// names of the columns of the visibility files.
+// Timestamps are in nanoseconds, the memo and the search attributes are JSON encoded.
+const (
+	columnDomainID           = "DomainID"
+	columnWorkflowID         = "WorkflowID"
+	columnRunID              = "RunID"
+	columnWorkflowTypeName   = "WorkflowTypeName"
+	columnStartTime          = "StartTime"
+	columnExecutionTime      = "ExecutionTime"
+	columnCloseTime          = "CloseTime"
+	columnCloseStatus        = "CloseStatus"
+	columnHistoryLength      = "HistoryLength"
+	columnMemo               = "Memo"
+	columnSearchAttributes   = "SearchAttributes"
+	columnHistoryArchivalURI = "HistoryArchivalURI"
+)
+
+// PartitionName returns the name of the partition of a record closed at the given time, which is its UTC day
+func PartitionName(closeTimestamp int64) string {
+	return time.Unix(0, closeTimestamp).UTC().Format(partitionFormat)
+}
+
+// partitionTimeRange returns the first and the last timestamp of a partition
+func partitionTimeRange(partition string) (int64, int64, error) {
+	start, err := time.Parse(partitionFormat, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid partition name %s: %w", partition, err)
+	}
+	return start.UnixNano(), start.Add(24*time.Hour).UnixNano() - 1, nil
+}
+
+func newFilename(now time.Time) string {
+	return fmt.Sprintf("%d_%s%s", now.UnixNano(), uuid.New(), FileExtension)
+}
+
+func newStagedFilename(now time.Time) string {
+	return fmt.Sprintf("%d_%s%s", now.UnixNano(), uuid.New(), stagedFileExtension)
+}
+
+// isColumnarFile returns true for both the batches and the staged records
+func isColumnarFile(filename string) bool {
+	return strings.HasSuffix(filename, FileExtension)
+}
+
+func isStagedFile(filename string) bool {
+	return strings.HasSuffix(filename, stagedFileExtension)
+}
+
+func encode(records []*archiver.ArchiveVisibilityRequest) ([]byte, error) {
+	writer := newParquetWriter()
+	domainID := writer.addColumn(columnDomainID, parquetByteArray, true)
+	workflowID := writer.addColumn(columnWorkflowID, parquetByteArray, true)
+	runID := writer.addColumn(columnRunID, parquetByteArray, true)
+	workflowTypeName := writer.addColumn(columnWorkflowTypeName, parquetByteArray, true)
+	startTime := writer.addColumn(columnStartTime, parquetInt64, false)
+	executionTime := writer.addColumn(columnExecutionTime, parquetInt64, false)
+	closeTime := writer.addColumn(columnCloseTime, parquetInt64, false)
+	closeStatus := writer.addColumn(columnCloseStatus, parquetInt32, false)
+	historyLength := writer.addColumn(columnHistoryLength, parquetInt64, false)
+	memo := writer.addColumn(columnMemo, parquetByteArray, true)
+	searchAttributes := writer.addColumn(columnSearchAttributes, parquetByteArray, true)
+	historyArchivalURI := writer.addColumn(columnHistoryArchivalURI, parquetByteArray, true)
+
+	for _, record := range records {
+		encodedMemo, err := json.Marshal(record.Memo)
+		if err != nil {
+			return nil, err
+		}
+		encodedSearchAttributes, err := json.Marshal(record.SearchAttributes)
+		if err != nil {
+			return nil, err
+		}
+		domainID.appendString(record.DomainID)
+		workflowID.appendString(record.WorkflowID)
+		runID.appendString(record.RunID)
+		workflowTypeName.appendString(record.WorkflowTypeName)
+		startTime.appendInt64(record.StartTimestamp)
+		executionTime.appendInt64(record.ExecutionTimestamp)
+		closeTime.appendInt64(record.CloseTimestamp)
+		closeStatus.appendInt32(int32(record.CloseStatus))
+		historyLength.appendInt64(record.HistoryLength)
+		memo.appendByteArray(encodedMemo)
+		searchAttributes.appendByteArray(encodedSearchAttributes)
+		historyArchivalURI.appendString(record.HistoryArchivalURI)
+		writer.addRow()
+	}
+	return writer.write()
+}
+
+func decode(data []byte) ([]*archiver.ArchiveVisibilityRequest, error) {
+	file, err := readParquet(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var columnErr error
+	byteArrays := func(name string) [][]byte {
+		values, err := file.byteArrayColumn(name)
+		if columnErr == nil {
+			columnErr = err
+		}
+		return values
+	}
+	int64s := func(name string) []int64 {
+		values, err := file.int64Column(name)
+		if columnErr == nil {
+			columnErr = err
+		}
+		return values
+	}
+	domainID := byteArrays(columnDomainID)
+	workflowID := byteArrays(columnWorkflowID)
+	runID := byteArrays(columnRunID)
+	workflowTypeName := byteArrays(columnWorkflowTypeName)
+	startTime := int64s(columnStartTime)
+	executionTime := int64s(columnExecutionTime)
+	closeTime := int64s(columnCloseTime)
+	historyLength := int64s(columnHistoryLength)
+	memo := byteArrays(columnMemo)
+	searchAttributes := byteArrays(columnSearchAttributes)
+	historyArchivalURI := byteArrays(columnHistoryArchivalURI)
+	closeStatus, err := file.int32Column(columnCloseStatus)
+	if columnErr == nil {
+		columnErr = err
+	}
+	if columnErr != nil {
+		return nil, columnErr
+	}
+
+	records := make([]*archiver.ArchiveVisibilityRequest, 0, file.numRows)
+	for i := 0; i < int(file.numRows); i++ {
+		record := &archiver.ArchiveVisibilityRequest{
+			DomainID:           string(domainID[i]),
+			WorkflowID:         string(workflowID[i]),
+			RunID:              string(runID[i]),
+			WorkflowTypeName:   string(workflowTypeName[i]),
+			StartTimestamp:     startTime[i],
+			ExecutionTimestamp: executionTime[i],
+			CloseTimestamp:     closeTime[i],
+			CloseStatus:        types.WorkflowExecutionCloseStatus(closeStatus[i]),
+			HistoryLength:      historyLength[i],
+			HistoryArchivalURI: string(historyArchivalURI[i]),
+		}
+		if err := json.Unmarshal(memo[i], &record.Memo); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(searchAttributes[i], &record.SearchAttributes); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
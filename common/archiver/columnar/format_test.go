@@ -0,0 +1,151 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+var updateGoldenFiles = flag.Bool("update", false, "rewrite testdata/encoded.parquet with the current encoder")
+
+// goldenRecords must match goldenRows in testdata/reference, which writes testdata/reference.parquet
+// with github.com/xitongsys/parquet-go and checks that it reads testdata/encoded.parquet.
+var goldenRecords = []*archiver.ArchiveVisibilityRequest{
+	{
+		DomainID:           "3d3bb3b5-8ac4-4d7c-a4c7-0e0e44ec0a9b",
+		WorkflowID:         "workflowID-1",
+		RunID:              "runID-1",
+		WorkflowTypeName:   "workflowType",
+		StartTimestamp:     1710028800000000000,
+		ExecutionTimestamp: 1710028800000000001,
+		CloseTimestamp:     1710115199000000000,
+		CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+		HistoryLength:      10,
+		Memo: &types.Memo{
+			Fields: map[string][]byte{"memoKey": []byte("memoValue")},
+		},
+		SearchAttributes:   map[string]string{"CustomKeywordField": `"keyword"`},
+		HistoryArchivalURI: "file:///history",
+	},
+	{
+		DomainID:         "3d3bb3b5-8ac4-4d7c-a4c7-0e0e44ec0a9b",
+		WorkflowID:       "workflowID-2",
+		RunID:            "runID-2",
+		WorkflowTypeName: "workflowType",
+		CloseTimestamp:   1710115199000000001,
+		CloseStatus:      types.WorkflowExecutionCloseStatusCompleted,
+	},
+}
+
+func TestPartitionName(t *testing.T) {
+	closeTime := time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)
+	require.Equal(t, "2024-03-10", PartitionName(closeTime.UnixNano()))
+	require.Equal(t, "2024-03-11", PartitionName(closeTime.Add(time.Second).UnixNano()))
+
+	start, end, err := partitionTimeRange("2024-03-10")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC).UnixNano(), start)
+	require.Equal(t, closeTime.Add(time.Second).UnixNano()-1, end)
+
+	_, _, err = partitionTimeRange("not a partition")
+	require.Error(t, err)
+}
+
+func TestFilename(t *testing.T) {
+	filename := newFilename(time.Now())
+	require.True(t, isColumnarFile(filename))
+	require.NotEqual(t, filename, newFilename(time.Now()))
+	require.False(t, isColumnarFile("1234.visibility"))
+	require.False(t, isStagedFile(filename))
+
+	stagedFilename := newStagedFilename(time.Now())
+	require.True(t, isColumnarFile(stagedFilename))
+	require.True(t, isStagedFile(stagedFilename))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	records := []*archiver.ArchiveVisibilityRequest{
+		{
+			DomainID:           testDomainID,
+			WorkflowID:         "workflowID-1",
+			RunID:              "runID-1",
+			WorkflowTypeName:   "workflowType",
+			StartTimestamp:     1,
+			ExecutionTimestamp: 2,
+			CloseTimestamp:     3,
+			CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:      10,
+			Memo: &types.Memo{
+				Fields: map[string][]byte{"memoKey": []byte("memoValue")},
+			},
+			SearchAttributes:   map[string]string{"CustomKeywordField": `"keyword"`},
+			HistoryArchivalURI: "file:///history",
+		},
+		{
+			DomainID:         testDomainID,
+			WorkflowID:       "workflowID-2",
+			RunID:            "runID-2",
+			WorkflowTypeName: "workflowType",
+			CloseTimestamp:   4,
+			CloseStatus:      types.WorkflowExecutionCloseStatusCompleted,
+		},
+	}
+
+	data, err := encode(records)
+	require.NoError(t, err)
+	decoded, err := decode(data)
+	require.NoError(t, err)
+	require.Equal(t, records, decoded)
+
+	_, err = decode([]byte("not parquet"))
+	require.Error(t, err)
+}
+
+func TestDecodeReferenceFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "reference.parquet"))
+	require.NoError(t, err)
+	decoded, err := decode(data)
+	require.NoError(t, err)
+	require.Equal(t, goldenRecords, decoded)
+}
+
+func TestEncodeGoldenFile(t *testing.T) {
+	filename := filepath.Join("testdata", "encoded.parquet")
+	data, err := encode(goldenRecords)
+	require.NoError(t, err)
+	if *updateGoldenFiles {
+		require.NoError(t, os.WriteFile(filename, data, 0644))
+	}
+	expected, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, expected, data, "the encoder changed, run the test with -update and check the new file with testdata/reference")
+}
@@ -0,0 +1,696 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package columnar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// This file writes and reads the subset of Apache Parquet used by the columnar visibility files: a flat schema
+// of required columns, each stored as a single gzip compressed data page (v1) with plain encoded values.
+// The file metadata and the page headers are thrift structs encoded with the compact protocol, as defined in
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift, so the files can be read
+// by any Parquet reader. The other Parquet features, such as dictionaries or nested columns, are rejected when reading.
+
+const (
+	parquetMagic = "PAR1"
+
+	// physical types
+	parquetInt32     int32 = 1
+	parquetInt64     int32 = 2
+	parquetByteArray int32 = 6
+
+	parquetRepetitionRequired int32 = 0
+	parquetConvertedTypeUTF8  int32 = 0
+	parquetEncodingPlain      int32 = 0
+	parquetEncodingRLE        int32 = 3
+	parquetCodecUncompressed  int32 = 0
+	parquetCodecGzip          int32 = 2
+	parquetPageTypeData       int32 = 0
+
+	parquetCreatedBy = "cadence columnar visibility archiver"
+)
+
+var errUnsupportedParquet = errors.New("unsupported parquet file")
+
+type (
+	parquetWriter struct {
+		columns []*parquetColumnWriter
+		numRows int64
+	}
+
+	parquetColumnWriter struct {
+		name         string
+		physicalType int32
+		utf8         bool
+		numValues    int64
+		values       bytes.Buffer
+	}
+
+	// parquetFile holds the decoded values of the columns of a parquet file, indexed by column name
+	parquetFile struct {
+		numRows int64
+		columns map[string]*parquetColumn
+	}
+
+	parquetColumn struct {
+		physicalType int32
+		int32s       []int32
+		int64s       []int64
+		byteArrays   [][]byte
+	}
+
+	parquetSchemaElement struct {
+		physicalType   int32
+		repetitionType int32
+		name           string
+		numChildren    int32
+	}
+
+	parquetColumnMetadata struct {
+		physicalType          int32
+		path                  []string
+		codec                 int32
+		numValues             int64
+		totalUncompressedSize int64
+		totalCompressedSize   int64
+		dataPageOffset        int64
+	}
+
+	parquetPageHeader struct {
+		pageType           int32
+		uncompressedSize   int32
+		compressedSize     int32
+		numValues          int32
+		encoding           int32
+		hasDataPageHeaders bool
+	}
+
+	// thriftWriter writes thrift structs with the compact protocol, and keeps the first error
+	thriftWriter struct {
+		protocol *thrift.TCompactProtocol
+		buffer   *thrift.TMemoryBuffer
+		err      error
+	}
+)
+
+func newParquetWriter() *parquetWriter {
+	return &parquetWriter{}
+}
+
+func (w *parquetWriter) addColumn(name string, physicalType int32, utf8 bool) *parquetColumnWriter {
+	column := &parquetColumnWriter{
+		name:         name,
+		physicalType: physicalType,
+		utf8:         utf8,
+	}
+	w.columns = append(w.columns, column)
+	return column
+}
+
+// addRow must be called after the values of a row are appended to every column
+func (w *parquetWriter) addRow() {
+	w.numRows++
+}
+
+func (c *parquetColumnWriter) appendInt32(value int32) {
+	_ = binary.Write(&c.values, binary.LittleEndian, value)
+	c.numValues++
+}
+
+func (c *parquetColumnWriter) appendInt64(value int64) {
+	_ = binary.Write(&c.values, binary.LittleEndian, value)
+	c.numValues++
+}
+
+func (c *parquetColumnWriter) appendByteArray(value []byte) {
+	_ = binary.Write(&c.values, binary.LittleEndian, uint32(len(value)))
+	c.values.Write(value)
+	c.numValues++
+}
+
+func (c *parquetColumnWriter) appendString(value string) {
+	c.appendByteArray([]byte(value))
+}
+
+func (w *parquetWriter) write() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	metadata := make([]*parquetColumnMetadata, 0, len(w.columns))
+	var totalByteSize int64
+	for _, column := range w.columns {
+		if column.numValues != w.numRows {
+			return nil, fmt.Errorf("column %s has %d values instead of %d", column.name, column.numValues, w.numRows)
+		}
+		compressed, err := gzipCompress(column.values.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		header := newThriftWriter()
+		header.writePageHeader(column.values.Len(), len(compressed), column.numValues)
+		headerBytes, err := header.bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		offset := int64(buf.Len())
+		buf.Write(headerBytes)
+		buf.Write(compressed)
+		totalByteSize += int64(len(headerBytes) + column.values.Len())
+		metadata = append(metadata, &parquetColumnMetadata{
+			physicalType:          column.physicalType,
+			path:                  []string{column.name},
+			codec:                 parquetCodecGzip,
+			numValues:             column.numValues,
+			totalUncompressedSize: int64(len(headerBytes) + column.values.Len()),
+			totalCompressedSize:   int64(len(headerBytes) + len(compressed)),
+			dataPageOffset:        offset,
+		})
+	}
+
+	footer := newThriftWriter()
+	footer.writeFileMetadata(w, metadata, totalByteSize)
+	footerBytes, err := footer.bytes()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(footerBytes)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(footerBytes)))
+	buf.WriteString(parquetMagic)
+	return buf.Bytes(), nil
+}
+
+func newThriftWriter() *thriftWriter {
+	buffer := thrift.NewTMemoryBuffer()
+	return &thriftWriter{
+		protocol: thrift.NewTCompactProtocol(buffer),
+		buffer:   buffer,
+	}
+}
+
+func (w *thriftWriter) bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := w.protocol.Flush(); err != nil {
+		return nil, err
+	}
+	return w.buffer.Bytes(), nil
+}
+
+func (w *thriftWriter) check(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *thriftWriter) structBegin() {
+	w.check(w.protocol.WriteStructBegin(""))
+}
+
+func (w *thriftWriter) structEnd() {
+	w.check(w.protocol.WriteFieldStop())
+	w.check(w.protocol.WriteStructEnd())
+}
+
+func (w *thriftWriter) fieldBegin(id int16, fieldType thrift.TType) {
+	w.check(w.protocol.WriteFieldBegin("", fieldType, id))
+}
+
+func (w *thriftWriter) fieldEnd() {
+	w.check(w.protocol.WriteFieldEnd())
+}
+
+func (w *thriftWriter) i32Field(id int16, value int32) {
+	w.fieldBegin(id, thrift.I32)
+	w.check(w.protocol.WriteI32(value))
+	w.fieldEnd()
+}
+
+func (w *thriftWriter) i64Field(id int16, value int64) {
+	w.fieldBegin(id, thrift.I64)
+	w.check(w.protocol.WriteI64(value))
+	w.fieldEnd()
+}
+
+func (w *thriftWriter) stringField(id int16, value string) {
+	w.fieldBegin(id, thrift.STRING)
+	w.check(w.protocol.WriteString(value))
+	w.fieldEnd()
+}
+
+func (w *thriftWriter) listField(id int16, elemType thrift.TType, size int, writeElem func(i int)) {
+	w.fieldBegin(id, thrift.LIST)
+	w.check(w.protocol.WriteListBegin(elemType, size))
+	for i := 0; i < size; i++ {
+		writeElem(i)
+	}
+	w.check(w.protocol.WriteListEnd())
+	w.fieldEnd()
+}
+
+func (w *thriftWriter) writePageHeader(uncompressedSize int, compressedSize int, numValues int64) {
+	w.structBegin()
+	w.i32Field(1, parquetPageTypeData)
+	w.i32Field(2, int32(uncompressedSize))
+	w.i32Field(3, int32(compressedSize))
+	w.fieldBegin(5, thrift.STRUCT)
+	w.structBegin()
+	w.i32Field(1, int32(numValues))
+	w.i32Field(2, parquetEncodingPlain)
+	w.i32Field(3, parquetEncodingRLE)
+	w.i32Field(4, parquetEncodingRLE)
+	w.structEnd()
+	w.fieldEnd()
+	w.structEnd()
+}
+
+func (w *thriftWriter) writeFileMetadata(file *parquetWriter, columns []*parquetColumnMetadata, totalByteSize int64) {
+	w.structBegin()
+	w.i32Field(1, 1)
+	w.listField(2, thrift.STRUCT, len(file.columns)+1, func(i int) {
+		w.structBegin()
+		if i == 0 {
+			w.stringField(4, "schema")
+			w.i32Field(5, int32(len(file.columns)))
+		} else {
+			column := file.columns[i-1]
+			w.i32Field(1, column.physicalType)
+			w.i32Field(3, parquetRepetitionRequired)
+			w.stringField(4, column.name)
+			if column.utf8 {
+				w.i32Field(6, parquetConvertedTypeUTF8)
+			}
+		}
+		w.structEnd()
+	})
+	w.i64Field(3, file.numRows)
+	w.listField(4, thrift.STRUCT, 1, func(int) {
+		w.structBegin()
+		w.listField(1, thrift.STRUCT, len(columns), func(i int) {
+			column := columns[i]
+			w.structBegin()
+			w.i64Field(2, column.dataPageOffset)
+			w.fieldBegin(3, thrift.STRUCT)
+			w.structBegin()
+			w.i32Field(1, column.physicalType)
+			w.listField(2, thrift.I32, 1, func(int) {
+				w.check(w.protocol.WriteI32(parquetEncodingPlain))
+			})
+			w.listField(3, thrift.STRING, len(column.path), func(i int) {
+				w.check(w.protocol.WriteString(column.path[i]))
+			})
+			w.i32Field(4, column.codec)
+			w.i64Field(5, column.numValues)
+			w.i64Field(6, column.totalUncompressedSize)
+			w.i64Field(7, column.totalCompressedSize)
+			w.i64Field(9, column.dataPageOffset)
+			w.structEnd()
+			w.fieldEnd()
+			w.structEnd()
+		})
+		w.i64Field(2, totalByteSize)
+		w.i64Field(3, file.numRows)
+		w.structEnd()
+	})
+	w.stringField(6, parquetCreatedBy)
+	w.structEnd()
+}
+
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readParquet(data []byte) (*parquetFile, error) {
+	if len(data) < 2*len(parquetMagic)+4 ||
+		string(data[:len(parquetMagic)]) != parquetMagic ||
+		string(data[len(data)-len(parquetMagic):]) != parquetMagic {
+		return nil, errors.New("not a parquet file")
+	}
+	footerEnd := len(data) - len(parquetMagic) - 4
+	footerLength := int(binary.LittleEndian.Uint32(data[footerEnd:]))
+	if footerLength > footerEnd-len(parquetMagic) {
+		return nil, errors.New("invalid parquet footer length")
+	}
+
+	protocol, _ := newThriftReader(data, footerEnd-footerLength)
+	var schema []*parquetSchemaElement
+	var chunks []*parquetColumnMetadata
+	file := &parquetFile{
+		columns: make(map[string]*parquetColumn),
+	}
+	err := readThriftStruct(protocol, func(id int16, fieldType thrift.TType) error {
+		switch {
+		case id == 2 && fieldType == thrift.LIST:
+			return readThriftList(protocol, func() error {
+				element, err := readSchemaElement(protocol)
+				schema = append(schema, element)
+				return err
+			})
+		case id == 3 && fieldType == thrift.I64:
+			var err error
+			file.numRows, err = protocol.ReadI64()
+			return err
+		case id == 4 && fieldType == thrift.LIST:
+			return readThriftList(protocol, func() error {
+				rowGroupChunks, err := readRowGroup(protocol)
+				chunks = append(chunks, rowGroupChunks...)
+				return err
+			})
+		default:
+			return protocol.Skip(fieldType)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid parquet footer: %w", err)
+	}
+
+	if len(schema) == 0 || int(schema[0].numChildren) != len(schema)-1 {
+		return nil, fmt.Errorf("%w: only flat schemas are supported", errUnsupportedParquet)
+	}
+	for _, element := range schema[1:] {
+		if element.numChildren != 0 || element.repetitionType != parquetRepetitionRequired {
+			return nil, fmt.Errorf("%w: column %s is not a required primitive column", errUnsupportedParquet, element.name)
+		}
+		file.columns[element.name] = &parquetColumn{physicalType: element.physicalType}
+	}
+
+	for _, chunk := range chunks {
+		if len(chunk.path) != 1 {
+			return nil, fmt.Errorf("%w: nested column %v", errUnsupportedParquet, chunk.path)
+		}
+		column, ok := file.columns[chunk.path[0]]
+		if !ok || column.physicalType != chunk.physicalType {
+			return nil, fmt.Errorf("column chunk %s doesn't match the schema", chunk.path[0])
+		}
+		if err := readColumnChunk(data[:footerEnd-footerLength], chunk, column); err != nil {
+			return nil, fmt.Errorf("invalid column chunk %s: %w", chunk.path[0], err)
+		}
+	}
+	return file, nil
+}
+
+func readColumnChunk(data []byte, chunk *parquetColumnMetadata, column *parquetColumn) error {
+	offset := chunk.dataPageOffset
+	for values := int64(0); values < chunk.numValues; {
+		if offset < 0 || offset >= int64(len(data)) {
+			return errors.New("page offset out of range")
+		}
+		protocol, buffer := newThriftReader(data, int(offset))
+		header, err := readPageHeader(protocol)
+		if err != nil {
+			return err
+		}
+		if !header.hasDataPageHeaders || header.pageType != parquetPageTypeData || header.encoding != parquetEncodingPlain {
+			return fmt.Errorf("%w: only plain encoded data pages are supported", errUnsupportedParquet)
+		}
+		pageStart := int64(len(data)) - int64(buffer.Len())
+		pageEnd := pageStart + int64(header.compressedSize)
+		if header.compressedSize < 0 || pageEnd > int64(len(data)) {
+			return errors.New("page size out of range")
+		}
+		page, err := decompress(chunk.codec, data[pageStart:pageEnd])
+		if err != nil {
+			return err
+		}
+		if err := column.decodePlain(page, int(header.numValues)); err != nil {
+			return err
+		}
+		values += int64(header.numValues)
+		offset = pageEnd
+	}
+	return nil
+}
+
+func (c *parquetColumn) decodePlain(page []byte, numValues int) error {
+	reader := bytes.NewReader(page)
+	for i := 0; i < numValues; i++ {
+		switch c.physicalType {
+		case parquetInt32:
+			var value int32
+			if err := binary.Read(reader, binary.LittleEndian, &value); err != nil {
+				return err
+			}
+			c.int32s = append(c.int32s, value)
+		case parquetInt64:
+			var value int64
+			if err := binary.Read(reader, binary.LittleEndian, &value); err != nil {
+				return err
+			}
+			c.int64s = append(c.int64s, value)
+		case parquetByteArray:
+			var length uint32
+			if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+				return err
+			}
+			if int64(length) > int64(reader.Len()) {
+				return io.ErrUnexpectedEOF
+			}
+			value := make([]byte, length)
+			if _, err := io.ReadFull(reader, value); err != nil {
+				return err
+			}
+			c.byteArrays = append(c.byteArrays, value)
+		default:
+			return fmt.Errorf("%w: physical type %d", errUnsupportedParquet, c.physicalType)
+		}
+	}
+	return nil
+}
+
+func decompress(codec int32, data []byte) ([]byte, error) {
+	switch codec {
+	case parquetCodecUncompressed:
+		return data, nil
+	case parquetCodecGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return nil, fmt.Errorf("%w: compression codec %d", errUnsupportedParquet, codec)
+	}
+}
+
+func newThriftReader(data []byte, offset int) (*thrift.TCompactProtocol, *thrift.TMemoryBuffer) {
+	buffer := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(data[offset:])}
+	return thrift.NewTCompactProtocol(buffer), buffer
+}
+
+// readThriftStruct reads a struct, calling readField for each of its fields, which must read or skip the field value
+func readThriftStruct(protocol thrift.TProtocol, readField func(id int16, fieldType thrift.TType) error) error {
+	if _, err := protocol.ReadStructBegin(); err != nil {
+		return err
+	}
+	for {
+		_, fieldType, id, err := protocol.ReadFieldBegin()
+		if err != nil {
+			return err
+		}
+		if fieldType == thrift.STOP {
+			break
+		}
+		if err := readField(id, fieldType); err != nil {
+			return err
+		}
+		if err := protocol.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	return protocol.ReadStructEnd()
+}
+
+func readThriftList(protocol thrift.TProtocol, readElem func() error) error {
+	_, size, err := protocol.ReadListBegin()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < size; i++ {
+		if err := readElem(); err != nil {
+			return err
+		}
+	}
+	return protocol.ReadListEnd()
+}
+
+func readSchemaElement(protocol thrift.TProtocol) (*parquetSchemaElement, error) {
+	element := &parquetSchemaElement{}
+	err := readThriftStruct(protocol, func(id int16, fieldType thrift.TType) (err error) {
+		switch {
+		case id == 1 && fieldType == thrift.I32:
+			element.physicalType, err = protocol.ReadI32()
+		case id == 3 && fieldType == thrift.I32:
+			element.repetitionType, err = protocol.ReadI32()
+		case id == 4 && fieldType == thrift.STRING:
+			element.name, err = protocol.ReadString()
+		case id == 5 && fieldType == thrift.I32:
+			element.numChildren, err = protocol.ReadI32()
+		default:
+			err = protocol.Skip(fieldType)
+		}
+		return err
+	})
+	return element, err
+}
+
+func readRowGroup(protocol thrift.TProtocol) ([]*parquetColumnMetadata, error) {
+	var chunks []*parquetColumnMetadata
+	err := readThriftStruct(protocol, func(id int16, fieldType thrift.TType) error {
+		if id != 1 || fieldType != thrift.LIST {
+			return protocol.Skip(fieldType)
+		}
+		return readThriftList(protocol, func() error {
+			return readThriftStruct(protocol, func(id int16, fieldType thrift.TType) error {
+				if id != 3 || fieldType != thrift.STRUCT {
+					return protocol.Skip(fieldType)
+				}
+				chunk, err := readColumnMetadata(protocol)
+				chunks = append(chunks, chunk)
+				return err
+			})
+		})
+	})
+	return chunks, err
+}
+
+func readColumnMetadata(protocol thrift.TProtocol) (*parquetColumnMetadata, error) {
+	metadata := &parquetColumnMetadata{}
+	err := readThriftStruct(protocol, func(id int16, fieldType thrift.TType) (err error) {
+		switch {
+		case id == 1 && fieldType == thrift.I32:
+			metadata.physicalType, err = protocol.ReadI32()
+		case id == 3 && fieldType == thrift.LIST:
+			err = readThriftList(protocol, func() error {
+				element, err := protocol.ReadString()
+				metadata.path = append(metadata.path, element)
+				return err
+			})
+		case id == 4 && fieldType == thrift.I32:
+			metadata.codec, err = protocol.ReadI32()
+		case id == 5 && fieldType == thrift.I64:
+			metadata.numValues, err = protocol.ReadI64()
+		case id == 7 && fieldType == thrift.I64:
+			metadata.totalCompressedSize, err = protocol.ReadI64()
+		case id == 9 && fieldType == thrift.I64:
+			metadata.dataPageOffset, err = protocol.ReadI64()
+		case id == 11:
+			err = fmt.Errorf("%w: dictionary pages", errUnsupportedParquet)
+		default:
+			err = protocol.Skip(fieldType)
+		}
+		return err
+	})
+	return metadata, err
+}
+
+func readPageHeader(protocol thrift.TProtocol) (*parquetPageHeader, error) {
+	header := &parquetPageHeader{}
+	err := readThriftStruct(protocol, func(id int16, fieldType thrift.TType) (err error) {
+		switch {
+		case id == 1 && fieldType == thrift.I32:
+			header.pageType, err = protocol.ReadI32()
+		case id == 2 && fieldType == thrift.I32:
+			header.uncompressedSize, err = protocol.ReadI32()
+		case id == 3 && fieldType == thrift.I32:
+			header.compressedSize, err = protocol.ReadI32()
+		case id == 5 && fieldType == thrift.STRUCT:
+			header.hasDataPageHeaders = true
+			err = readThriftStruct(protocol, func(id int16, fieldType thrift.TType) (err error) {
+				switch {
+				case id == 1 && fieldType == thrift.I32:
+					header.numValues, err = protocol.ReadI32()
+				case id == 2 && fieldType == thrift.I32:
+					header.encoding, err = protocol.ReadI32()
+				default:
+					err = protocol.Skip(fieldType)
+				}
+				return err
+			})
+		default:
+			err = protocol.Skip(fieldType)
+		}
+		return err
+	})
+	return header, err
+}
+
+func (f *parquetFile) column(name string, physicalType int32) (*parquetColumn, error) {
+	column, ok := f.columns[name]
+	if !ok {
+		return nil, fmt.Errorf("column %s not found", name)
+	}
+	if column.physicalType != physicalType {
+		return nil, fmt.Errorf("column %s has physical type %d instead of %d", name, column.physicalType, physicalType)
+	}
+	return column, nil
+}
+
+func (f *parquetFile) int32Column(name string) ([]int32, error) {
+	column, err := f.column(name, parquetInt32)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(column.int32s)) != f.numRows {
+		return nil, fmt.Errorf("column %s has %d values instead of %d", name, len(column.int32s), f.numRows)
+	}
+	return column.int32s, nil
+}
+
+func (f *parquetFile) int64Column(name string) ([]int64, error) {
+	column, err := f.column(name, parquetInt64)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(column.int64s)) != f.numRows {
+		return nil, fmt.Errorf("column %s has %d values instead of %d", name, len(column.int64s), f.numRows)
+	}
+	return column.int64s, nil
+}
+
+func (f *parquetFile) byteArrayColumn(name string) ([][]byte, error) {
+	column, err := f.column(name, parquetByteArray)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(column.byteArrays)) != f.numRows {
+		return nil, fmt.Errorf("column %s has %d values instead of %d", name, len(column.byteArrays), f.numRows)
+	}
+	return column.byteArrays, nil
+}
@@ -0,0 +1,94 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetWriteRead(t *testing.T) {
+	writer := newParquetWriter()
+	name := writer.addColumn("name", parquetByteArray, true)
+	count := writer.addColumn("count", parquetInt32, false)
+	timestamp := writer.addColumn("timestamp", parquetInt64, false)
+	for i, value := range []string{"a", "", "ccc"} {
+		name.appendString(value)
+		count.appendInt32(int32(i))
+		timestamp.appendInt64(int64(i) << 40)
+		writer.addRow()
+	}
+	data, err := writer.write()
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte(parquetMagic)))
+	require.True(t, bytes.HasSuffix(data, []byte(parquetMagic)))
+
+	file, err := readParquet(data)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), file.numRows)
+	names, err := file.byteArrayColumn("name")
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("a"), []byte(""), []byte("ccc")}, names)
+	counts, err := file.int32Column("count")
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 1, 2}, counts)
+	timestamps, err := file.int64Column("timestamp")
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 1 << 40, 2 << 40}, timestamps)
+
+	_, err = file.int64Column("name")
+	require.Error(t, err)
+	_, err = file.int64Column("missing")
+	require.Error(t, err)
+}
+
+func TestParquetWriteMissingValue(t *testing.T) {
+	writer := newParquetWriter()
+	writer.addColumn("name", parquetByteArray, true).appendString("a")
+	writer.addColumn("count", parquetInt32, false)
+	writer.addRow()
+	_, err := writer.write()
+	require.Error(t, err)
+}
+
+func TestParquetReadCorrupted(t *testing.T) {
+	writer := newParquetWriter()
+	writer.addColumn("name", parquetByteArray, true).appendString("a")
+	writer.addRow()
+	data, err := writer.write()
+	require.NoError(t, err)
+
+	_, err = readParquet(data[:len(data)-1])
+	require.Error(t, err)
+
+	invalidFooterLength := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(invalidFooterLength[len(data)-8:], uint32(len(data)))
+	_, err = readParquet(invalidFooterLength)
+	require.Error(t, err)
+
+	truncatedPage := append([]byte(parquetMagic), data[len(parquetMagic)+10:]...)
+	_, err = readParquet(truncatedPage)
+	require.Error(t, err)
+}
@@ -0,0 +1,527 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// QueryParser parses the where clause of a query into a filter of visibility records
+	QueryParser interface {
+		Parse(query string) (*parsedQuery, error)
+	}
+
+	queryParser struct{}
+
+	parsedQuery struct {
+		// earliestCloseTime and latestCloseTime are only used to select the partitions to read,
+		// the records are always checked against the whole filter
+		earliestCloseTime int64
+		latestCloseTime   int64
+		filter            filter
+	}
+
+	filter func(record *archiver.ArchiveVisibilityRequest) bool
+
+	// fieldValue returns the value of a field of a record, and false if the record doesn't have it
+	fieldValue func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool)
+)
+
+// All the fields of the visibility records which can be used in a query.
+// Any other name is the name of a search attribute.
+const (
+	WorkflowID       = "WorkflowID"
+	RunID            = "RunID"
+	WorkflowType     = "WorkflowType"
+	WorkflowTypeName = "WorkflowTypeName"
+	CloseStatus      = "CloseStatus"
+	StartTime        = "StartTime"
+	ExecutionTime    = "ExecutionTime"
+	CloseTime        = "CloseTime"
+	HistoryLength    = "HistoryLength"
+)
+
+const (
+	queryTemplate = "select * from dummy where %s"
+
+	defaultDateTimeFormat = time.RFC3339
+)
+
+// NewQueryParser creates a new query parser for the columnar visibility format
+func NewQueryParser() QueryParser {
+	return &queryParser{}
+}
+
+func (p *queryParser) Parse(query string) (*parsedQuery, error) {
+	stmt, err := sqlparser.Parse(fmt.Sprintf(queryTemplate, query))
+	if err != nil {
+		return nil, err
+	}
+	whereExpr := stmt.(*sqlparser.Select).Where.Expr
+	filter, err := p.convertWhereExpr(whereExpr)
+	if err != nil {
+		return nil, err
+	}
+	parsedQuery := &parsedQuery{
+		earliestCloseTime: math.MinInt64,
+		latestCloseTime:   math.MaxInt64,
+		filter:            filter,
+	}
+	if err := p.narrowCloseTimeRange(whereExpr, parsedQuery); err != nil {
+		return nil, err
+	}
+	return parsedQuery, nil
+}
+
+// hasCloseTimeRange returns true if the query has both a lower and an upper bound on the close time
+func (q *parsedQuery) hasCloseTimeRange() bool {
+	return q.earliestCloseTime != math.MinInt64 && q.latestCloseTime != math.MaxInt64
+}
+
+func (p *queryParser) convertWhereExpr(expr sqlparser.Expr) (filter, error) {
+	if expr == nil {
+		return nil, errors.New("where expression is nil")
+	}
+
+	switch expr := expr.(type) {
+	case *sqlparser.ComparisonExpr:
+		return p.convertComparisonExpr(expr)
+	case *sqlparser.RangeCond:
+		return p.convertRangeCond(expr)
+	case *sqlparser.AndExpr:
+		left, err := p.convertWhereExpr(expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.convertWhereExpr(expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *archiver.ArchiveVisibilityRequest) bool {
+			return left(record) && right(record)
+		}, nil
+	case *sqlparser.OrExpr:
+		left, err := p.convertWhereExpr(expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.convertWhereExpr(expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *archiver.ArchiveVisibilityRequest) bool {
+			return left(record) || right(record)
+		}, nil
+	case *sqlparser.NotExpr:
+		inner, err := p.convertWhereExpr(expr.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *archiver.ArchiveVisibilityRequest) bool {
+			return !inner(record)
+		}, nil
+	case *sqlparser.ParenExpr:
+		return p.convertWhereExpr(expr.Expr)
+	default:
+		return nil, fmt.Errorf("expression %s is not supported", sqlparser.String(expr))
+	}
+}
+
+func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr) (filter, error) {
+	colName, ok := compExpr.Left.(*sqlparser.ColName)
+	if !ok {
+		return nil, fmt.Errorf("invalid filter name: %s", sqlparser.String(compExpr.Left))
+	}
+	name := colName.Name.String()
+	getValue, convertValue := p.getField(name)
+
+	switch compExpr.Operator {
+	case sqlparser.InStr, sqlparser.NotInStr:
+		tuple, ok := compExpr.Right.(sqlparser.ValTuple)
+		if !ok {
+			return nil, fmt.Errorf("invalid value: %s", sqlparser.String(compExpr.Right))
+		}
+		var values []interface{}
+		for _, valExpr := range tuple {
+			value, err := convertValue(valExpr)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		isIn := compExpr.Operator == sqlparser.InStr
+		return func(record *archiver.ArchiveVisibilityRequest) bool {
+			recordValue, ok := getValue(record)
+			if !ok {
+				return false
+			}
+			for _, value := range values {
+				if matchValue(recordValue, value, sqlparser.EqualStr) {
+					return isIn
+				}
+			}
+			return !isIn
+		}, nil
+	case sqlparser.EqualStr, sqlparser.NotEqualStr, sqlparser.LessThanStr, sqlparser.LessEqualStr,
+		sqlparser.GreaterThanStr, sqlparser.GreaterEqualStr:
+		value, err := convertValue(compExpr.Right)
+		if err != nil {
+			return nil, err
+		}
+		op := compExpr.Operator
+		return func(record *archiver.ArchiveVisibilityRequest) bool {
+			recordValue, ok := getValue(record)
+			if !ok {
+				return false
+			}
+			return matchValue(recordValue, value, op)
+		}, nil
+	default:
+		return nil, fmt.Errorf("operator %s is not supported", compExpr.Operator)
+	}
+}
+
+func (p *queryParser) convertRangeCond(rangeCond *sqlparser.RangeCond) (filter, error) {
+	colName, ok := rangeCond.Left.(*sqlparser.ColName)
+	if !ok {
+		return nil, fmt.Errorf("invalid filter name: %s", sqlparser.String(rangeCond.Left))
+	}
+	getValue, convertValue := p.getField(colName.Name.String())
+	from, err := convertValue(rangeCond.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := convertValue(rangeCond.To)
+	if err != nil {
+		return nil, err
+	}
+	isBetween := rangeCond.Operator == sqlparser.BetweenStr
+	return func(record *archiver.ArchiveVisibilityRequest) bool {
+		recordValue, ok := getValue(record)
+		if !ok {
+			return false
+		}
+		between := matchValue(recordValue, from, sqlparser.GreaterEqualStr) && matchValue(recordValue, to, sqlparser.LessEqualStr)
+		return between == isBetween
+	}, nil
+}
+
+// getField returns the function reading a field from the records, and the function converting the query values
+// of this field to the type of the field
+func (p *queryParser) getField(name string) (fieldValue, func(sqlparser.Expr) (interface{}, error)) {
+	switch name {
+	case WorkflowID:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return record.WorkflowID, true
+		}, convertStringValue
+	case RunID:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return record.RunID, true
+		}, convertStringValue
+	case WorkflowType, WorkflowTypeName:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return record.WorkflowTypeName, true
+		}, convertStringValue
+	case CloseStatus:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return float64(record.CloseStatus), true
+		}, convertCloseStatusValue
+	case StartTime:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return float64(record.StartTimestamp), true
+		}, convertTimeValue
+	case ExecutionTime:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return float64(record.ExecutionTimestamp), true
+		}, convertTimeValue
+	case CloseTime:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return float64(record.CloseTimestamp), true
+		}, convertTimeValue
+	case HistoryLength:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			return float64(record.HistoryLength), true
+		}, convertLiteralValue
+	default:
+		return func(record *archiver.ArchiveVisibilityRequest) (interface{}, bool) {
+			encodedValue, ok := record.SearchAttributes[name]
+			if !ok {
+				return nil, false
+			}
+			var value interface{}
+			if err := json.Unmarshal([]byte(encodedValue), &value); err != nil {
+				return encodedValue, true
+			}
+			return value, true
+		}, convertLiteralValue
+	}
+}
+
+// narrowCloseTimeRange narrows the close time range of the query with the close time conditions
+// which all the matching records must satisfy, which are the ones which are not under an OR or a NOT
+func (p *queryParser) narrowCloseTimeRange(expr sqlparser.Expr, parsedQuery *parsedQuery) error {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		if err := p.narrowCloseTimeRange(expr.Left, parsedQuery); err != nil {
+			return err
+		}
+		return p.narrowCloseTimeRange(expr.Right, parsedQuery)
+	case *sqlparser.ParenExpr:
+		return p.narrowCloseTimeRange(expr.Expr, parsedQuery)
+	case *sqlparser.ComparisonExpr:
+		if !isCloseTime(expr.Left) {
+			return nil
+		}
+		switch expr.Operator {
+		case sqlparser.EqualStr, sqlparser.LessThanStr, sqlparser.LessEqualStr, sqlparser.GreaterThanStr, sqlparser.GreaterEqualStr:
+			timestamp, err := convertTimeValue(expr.Right)
+			if err != nil {
+				return err
+			}
+			narrowCloseTime(int64(timestamp.(float64)), expr.Operator, parsedQuery)
+		}
+	case *sqlparser.RangeCond:
+		if !isCloseTime(expr.Left) || expr.Operator != sqlparser.BetweenStr {
+			return nil
+		}
+		from, err := convertTimeValue(expr.From)
+		if err != nil {
+			return err
+		}
+		to, err := convertTimeValue(expr.To)
+		if err != nil {
+			return err
+		}
+		narrowCloseTime(int64(from.(float64)), sqlparser.GreaterEqualStr, parsedQuery)
+		narrowCloseTime(int64(to.(float64)), sqlparser.LessEqualStr, parsedQuery)
+	}
+	return nil
+}
+
+func isCloseTime(expr sqlparser.Expr) bool {
+	colName, ok := expr.(*sqlparser.ColName)
+	return ok && colName.Name.String() == CloseTime
+}
+
+func narrowCloseTime(timestamp int64, op string, parsedQuery *parsedQuery) {
+	switch op {
+	case sqlparser.EqualStr:
+		narrowCloseTime(timestamp, sqlparser.GreaterEqualStr, parsedQuery)
+		narrowCloseTime(timestamp, sqlparser.LessEqualStr, parsedQuery)
+	case sqlparser.LessThanStr:
+		parsedQuery.latestCloseTime = common.MinInt64(parsedQuery.latestCloseTime, timestamp-1)
+	case sqlparser.LessEqualStr:
+		parsedQuery.latestCloseTime = common.MinInt64(parsedQuery.latestCloseTime, timestamp)
+	case sqlparser.GreaterThanStr:
+		parsedQuery.earliestCloseTime = common.MaxInt64(parsedQuery.earliestCloseTime, timestamp+1)
+	case sqlparser.GreaterEqualStr:
+		parsedQuery.earliestCloseTime = common.MaxInt64(parsedQuery.earliestCloseTime, timestamp)
+	}
+}
+
+// matchValue compares the value of a record with the value of a query. Numbers are compared with numbers,
+// and strings with strings, as times if both are RFC3339 times. A list value, such as the value of a
+// keyword list search attribute, matches if any of its elements matches, except for != which requires all of them to.
+func matchValue(recordValue, queryValue interface{}, op string) bool {
+	if list, ok := recordValue.([]interface{}); ok {
+		if op == sqlparser.NotEqualStr {
+			for _, element := range list {
+				if !matchValue(element, queryValue, op) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, element := range list {
+			if matchValue(element, queryValue, op) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cmp, ok := compareValues(recordValue, queryValue)
+	if !ok {
+		return op == sqlparser.NotEqualStr
+	}
+	switch op {
+	case sqlparser.EqualStr:
+		return cmp == 0
+	case sqlparser.NotEqualStr:
+		return cmp != 0
+	case sqlparser.LessThanStr:
+		return cmp < 0
+	case sqlparser.LessEqualStr:
+		return cmp <= 0
+	case sqlparser.GreaterThanStr:
+		return cmp > 0
+	case sqlparser.GreaterEqualStr:
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// compareValues returns the comparison of two values, and false if they are not comparable
+func compareValues(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		aTime, aErr := time.Parse(defaultDateTimeFormat, a)
+		bTime, bErr := time.Parse(defaultDateTimeFormat, b)
+		if aErr == nil && bErr == nil {
+			return aTime.Compare(bTime), true
+		}
+		return strings.Compare(a, b), true
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, true
+			}
+			// only equality is meaningful for booleans
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func convertStringValue(expr sqlparser.Expr) (interface{}, error) {
+	valExpr, ok := expr.(*sqlparser.SQLVal)
+	if !ok || valExpr.Type != sqlparser.StrVal {
+		return nil, fmt.Errorf("value %s is not a string value", sqlparser.String(expr))
+	}
+	return string(valExpr.Val), nil
+}
+
+// convertLiteralValue converts the value of a query to a string, a float64 or a bool
+func convertLiteralValue(expr sqlparser.Expr) (interface{}, error) {
+	switch expr := expr.(type) {
+	case sqlparser.BoolVal:
+		return bool(expr), nil
+	case *sqlparser.SQLVal:
+		switch expr.Type {
+		case sqlparser.StrVal:
+			return string(expr.Val), nil
+		case sqlparser.IntVal, sqlparser.FloatVal:
+			value, err := strconv.ParseFloat(string(expr.Val), 64)
+			if err != nil {
+				return nil, err
+			}
+			return value, nil
+		}
+	case *sqlparser.UnaryExpr:
+		if expr.Operator == sqlparser.UMinusStr {
+			value, err := convertLiteralValue(expr.Expr)
+			if err != nil {
+				return nil, err
+			}
+			if number, ok := value.(float64); ok {
+				return -number, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid value: %s", sqlparser.String(expr))
+}
+
+// convertTimeValue converts a timestamp in nanoseconds or a RFC3339 time to a float64 timestamp
+func convertTimeValue(expr sqlparser.Expr) (interface{}, error) {
+	valExpr, ok := expr.(*sqlparser.SQLVal)
+	if !ok {
+		return nil, fmt.Errorf("invalid time value: %s", sqlparser.String(expr))
+	}
+	switch valExpr.Type {
+	case sqlparser.IntVal:
+		timestamp, err := strconv.ParseInt(string(valExpr.Val), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return float64(timestamp), nil
+	case sqlparser.StrVal:
+		parsedTime, err := time.Parse(defaultDateTimeFormat, string(valExpr.Val))
+		if err != nil {
+			return nil, err
+		}
+		return float64(parsedTime.UnixNano()), nil
+	default:
+		return nil, fmt.Errorf("invalid time value: %s", sqlparser.String(expr))
+	}
+}
+
+func convertCloseStatusValue(expr sqlparser.Expr) (interface{}, error) {
+	valExpr, ok := expr.(*sqlparser.SQLVal)
+	if !ok {
+		return nil, fmt.Errorf("invalid close status: %s", sqlparser.String(expr))
+	}
+	status, err := convertStatusStr(string(valExpr.Val))
+	if err != nil {
+		return nil, err
+	}
+	return float64(status), nil
+}
+
+func convertStatusStr(statusStr string) (types.WorkflowExecutionCloseStatus, error) {
+	statusStr = strings.ToLower(strings.TrimSpace(statusStr))
+	switch statusStr {
+	case "completed", strconv.Itoa(int(types.WorkflowExecutionCloseStatusCompleted)):
+		return types.WorkflowExecutionCloseStatusCompleted, nil
+	case "failed", strconv.Itoa(int(types.WorkflowExecutionCloseStatusFailed)):
+		return types.WorkflowExecutionCloseStatusFailed, nil
+	case "canceled", strconv.Itoa(int(types.WorkflowExecutionCloseStatusCanceled)):
+		return types.WorkflowExecutionCloseStatusCanceled, nil
+	case "terminated", strconv.Itoa(int(types.WorkflowExecutionCloseStatusTerminated)):
+		return types.WorkflowExecutionCloseStatusTerminated, nil
+	case "continuedasnew", "continued_as_new", strconv.Itoa(int(types.WorkflowExecutionCloseStatusContinuedAsNew)):
+		return types.WorkflowExecutionCloseStatusContinuedAsNew, nil
+	case "timedout", "timed_out", strconv.Itoa(int(types.WorkflowExecutionCloseStatusTimedOut)):
+		return types.WorkflowExecutionCloseStatusTimedOut, nil
+	default:
+		return 0, fmt.Errorf("unknown workflow close status: %s", statusStr)
+	}
+}
@@ -0,0 +1,202 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package columnar
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+type queryParserSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	parser QueryParser
+	record *archiver.ArchiveVisibilityRequest
+}
+
+func TestQueryParserSuite(t *testing.T) {
+	suite.Run(t, new(queryParserSuite))
+}
+
+func (s *queryParserSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.parser = NewQueryParser()
+	s.record = &archiver.ArchiveVisibilityRequest{
+		DomainID:           testDomainID,
+		WorkflowID:         "workflowID",
+		RunID:              "runID",
+		WorkflowTypeName:   "workflowType",
+		StartTimestamp:     time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC).UnixNano(),
+		ExecutionTimestamp: time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC).UnixNano(),
+		CloseTimestamp:     time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC).UnixNano(),
+		CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+		HistoryLength:      20,
+		SearchAttributes: map[string]string{
+			"CustomKeywordField":  `"keyword"`,
+			"CustomStringField":   `["first","second"]`,
+			"CustomIntField":      `123`,
+			"CustomDoubleField":   `1.5`,
+			"CustomBoolField":     `true`,
+			"CustomDatetimeField": `"2024-03-01T00:00:00Z"`,
+		},
+	}
+}
+
+func (s *queryParserSuite) TestParseMatch() {
+	testCases := []struct {
+		query string
+		match bool
+	}{
+		{query: "WorkflowID = 'workflowID'", match: true},
+		{query: "WorkflowID = 'other'", match: false},
+		{query: "WorkflowID != 'other'", match: true},
+		{query: "RunID = 'runID'", match: true},
+		{query: "WorkflowType = 'workflowType'", match: true},
+		{query: "WorkflowTypeName = 'workflowType'", match: true},
+		{query: "WorkflowID in ('other', 'workflowID')", match: true},
+		{query: "WorkflowID not in ('other', 'workflowID')", match: false},
+		{query: "CloseStatus = 'failed'", match: true},
+		{query: "CloseStatus = 1", match: true},
+		{query: "CloseStatus in ('completed', 'timedout')", match: false},
+		{query: "CloseStatus != 'completed'", match: true},
+		{query: "CloseTime > '2024-03-10T11:00:00Z'", match: true},
+		{query: "CloseTime between '2024-03-10T00:00:00Z' and '2024-03-10T11:00:00Z'", match: false},
+		{query: "CloseTime not between '2024-03-10T00:00:00Z' and '2024-03-10T11:00:00Z'", match: true},
+		{query: "StartTime >= 1710064800000000000", match: true},
+		{query: "StartTime > 1710064800000000000", match: false},
+		{query: "ExecutionTime < '2024-03-10T10:00:01Z'", match: true},
+		{query: "HistoryLength >= 20", match: true},
+		{query: "HistoryLength > 20", match: false},
+		{query: "CustomKeywordField = 'keyword'", match: true},
+		{query: "CustomKeywordField != 'keyword'", match: false},
+		{query: "CustomKeywordField = 123", match: false},
+		{query: "CustomStringField = 'second'", match: true},
+		{query: "CustomStringField = 'third'", match: false},
+		{query: "CustomStringField != 'third'", match: true},
+		{query: "CustomStringField in ('third', 'first')", match: true},
+		{query: "CustomIntField = 123", match: true},
+		{query: "CustomIntField between 100 and 200", match: true},
+		{query: "CustomIntField > -1", match: true},
+		{query: "CustomDoubleField < 2.5", match: true},
+		{query: "CustomBoolField = true", match: true},
+		{query: "CustomBoolField = false", match: false},
+		{query: "CustomDatetimeField < '2024-03-01T01:00:00+01:00'", match: false},
+		{query: "CustomDatetimeField <= '2024-03-01T01:00:00+01:00'", match: true},
+		{query: "MissingField = 'value'", match: false},
+		{query: "MissingField != 'value'", match: false},
+		{query: "WorkflowID = 'other' or CloseStatus = 'failed'", match: true},
+		{query: "WorkflowID = 'other' or (CloseStatus = 'failed' and CustomIntField > 200)", match: false},
+		{query: "not (WorkflowID = 'other')", match: true},
+		{query: "WorkflowType = 'workflowType' and CloseTime >= 1710072000000000000 and CustomKeywordField = 'keyword'", match: true},
+	}
+
+	for _, tc := range testCases {
+		parsedQuery, err := s.parser.Parse(tc.query)
+		s.NoError(err, tc.query)
+		s.Equal(tc.match, parsedQuery.filter(s.record), tc.query)
+	}
+}
+
+func (s *queryParserSuite) TestParseCloseTimeRange() {
+	testCases := []struct {
+		query    string
+		earliest int64
+		latest   int64
+	}{
+		{
+			query:    "WorkflowID = 'workflowID'",
+			earliest: math.MinInt64,
+			latest:   math.MaxInt64,
+		},
+		{
+			query:    "CloseTime >= 1000 and CloseTime < 2000",
+			earliest: 1000,
+			latest:   1999,
+		},
+		{
+			query:    "CloseTime > 1000 and (CloseTime <= 2000 and CloseTime <= 3000)",
+			earliest: 1001,
+			latest:   2000,
+		},
+		{
+			query:    "CloseTime between 1000 and 2000 and CloseTime >= 1500",
+			earliest: 1500,
+			latest:   2000,
+		},
+		{
+			query:    "CloseTime = 1000",
+			earliest: 1000,
+			latest:   1000,
+		},
+		{
+			query:    "CloseTime < 1000 or CloseTime > 2000",
+			earliest: math.MinInt64,
+			latest:   math.MaxInt64,
+		},
+		{
+			query:    "not CloseTime < 1000",
+			earliest: math.MinInt64,
+			latest:   math.MaxInt64,
+		},
+		{
+			query:    "CloseTime != 1000",
+			earliest: math.MinInt64,
+			latest:   math.MaxInt64,
+		},
+	}
+
+	for _, tc := range testCases {
+		parsedQuery, err := s.parser.Parse(tc.query)
+		s.NoError(err, tc.query)
+		s.Equal(tc.earliest, parsedQuery.earliestCloseTime, tc.query)
+		s.Equal(tc.latest, parsedQuery.latestCloseTime, tc.query)
+	}
+}
+
+func (s *queryParserSuite) TestParseError() {
+	queries := []string{
+		"",
+		"WorkflowID",
+		"WorkflowID = 123",
+		"RunID like 'runID'",
+		"CloseStatus = 'unknown'",
+		"CloseTime > 'not a time'",
+		"StartTime between 1 and 'not a time'",
+		"HistoryLength = HistoryLength",
+		"'workflowID' = WorkflowID",
+		"CustomKeywordField in (CustomKeywordField)",
+	}
+
+	for _, query := range queries {
+		_, err := s.parser.Parse(query)
+		s.Error(err, query)
+	}
+}
@@ -0,0 +1,145 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Command reference checks the columnar visibility format against github.com/xitongsys/parquet-go, which
+// the main module cannot depend on as it requires a newer Apache Thrift than the server is pinned to.
+//
+// It writes ../reference.parquet, which the columnar tests decode, and reads ../encoded.parquet, which the
+// columnar tests keep in sync with the encoder:
+//
+//	go run . write
+//	go run . check
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	referenceFile = "../reference.parquet"
+	encodedFile   = "../encoded.parquet"
+)
+
+type visibilityRow struct {
+	DomainID           string `parquet:"name=DomainID, type=BYTE_ARRAY, convertedtype=UTF8"`
+	WorkflowID         string `parquet:"name=WorkflowID, type=BYTE_ARRAY, convertedtype=UTF8"`
+	RunID              string `parquet:"name=RunID, type=BYTE_ARRAY, convertedtype=UTF8"`
+	WorkflowTypeName   string `parquet:"name=WorkflowTypeName, type=BYTE_ARRAY, convertedtype=UTF8"`
+	StartTime          int64  `parquet:"name=StartTime, type=INT64"`
+	ExecutionTime      int64  `parquet:"name=ExecutionTime, type=INT64"`
+	CloseTime          int64  `parquet:"name=CloseTime, type=INT64"`
+	CloseStatus        int32  `parquet:"name=CloseStatus, type=INT32"`
+	HistoryLength      int64  `parquet:"name=HistoryLength, type=INT64"`
+	Memo               string `parquet:"name=Memo, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SearchAttributes   string `parquet:"name=SearchAttributes, type=BYTE_ARRAY, convertedtype=UTF8"`
+	HistoryArchivalURI string `parquet:"name=HistoryArchivalURI, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// goldenRows must match goldenRecords in the columnar tests
+var goldenRows = []visibilityRow{
+	{
+		DomainID:           "3d3bb3b5-8ac4-4d7c-a4c7-0e0e44ec0a9b",
+		WorkflowID:         "workflowID-1",
+		RunID:              "runID-1",
+		WorkflowTypeName:   "workflowType",
+		StartTime:          1710028800000000000,
+		ExecutionTime:      1710028800000000001,
+		CloseTime:          1710115199000000000,
+		CloseStatus:        1,
+		HistoryLength:      10,
+		Memo:               `{"fields":{"memoKey":"bWVtb1ZhbHVl"}}`,
+		SearchAttributes:   `{"CustomKeywordField":"\"keyword\""}`,
+		HistoryArchivalURI: "file:///history",
+	},
+	{
+		DomainID:         "3d3bb3b5-8ac4-4d7c-a4c7-0e0e44ec0a9b",
+		WorkflowID:       "workflowID-2",
+		RunID:            "runID-2",
+		WorkflowTypeName: "workflowType",
+		CloseTime:        1710115199000000001,
+		Memo:             "null",
+		SearchAttributes: "null",
+	},
+}
+
+func main() {
+	var err error
+	switch {
+	case len(os.Args) == 2 && os.Args[1] == "write":
+		err = write(referenceFile)
+	case len(os.Args) == 2 && os.Args[1] == "check":
+		err = check(encodedFile)
+	default:
+		err = fmt.Errorf("usage: %s write|check", os.Args[0])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func write(filename string) error {
+	file, err := local.NewLocalFileWriter(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	parquetWriter, err := writer.NewParquetWriter(file, new(visibilityRow), 1)
+	if err != nil {
+		return err
+	}
+	parquetWriter.CompressionType = parquet.CompressionCodec_GZIP
+	for _, row := range goldenRows {
+		if err := parquetWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	return parquetWriter.WriteStop()
+}
+
+func check(filename string) error {
+	file, err := local.NewLocalFileReader(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	parquetReader, err := reader.NewParquetReader(file, new(visibilityRow), 1)
+	if err != nil {
+		return err
+	}
+	defer parquetReader.ReadStop()
+	rows := make([]visibilityRow, parquetReader.GetNumRows())
+	if err := parquetReader.Read(&rows); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(goldenRows, rows) {
+		return fmt.Errorf("%s does not contain the golden rows:\nexpected %+v\nactual   %+v", filename, goldenRows, rows)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package filestore
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
+	"github.com/uber/cadence/common/util"
+)
+
+const columnarDirName = "columnar"
+
+type (
+	// columnarStore stores the files of the columnar visibility format under <path>/columnar/<domainID>/<partition>.
+	// They are kept out of the domain directories, which only contain the records of the default format.
+	columnarStore struct {
+		fileMode os.FileMode
+		dirMode  os.FileMode
+	}
+)
+
+var _ columnar.Store = (*columnarStore)(nil)
+
+func (s *columnarStore) ListPartitions(ctx context.Context, URI archiver.URI, domainID string) ([]string, error) {
+	return listDirectory(path.Join(URI.Path(), columnarDirName, domainID))
+}
+
+func (s *columnarStore) ListFiles(ctx context.Context, URI archiver.URI, domainID string, partition string) ([]string, error) {
+	return listDirectory(path.Join(URI.Path(), columnarDirName, domainID, partition))
+}
+
+func (s *columnarStore) ReadFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) ([]byte, error) {
+	return util.ReadFile(path.Join(URI.Path(), columnarDirName, domainID, partition, filename))
+}
+
+func (s *columnarStore) WriteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string, data []byte) error {
+	dirPath := path.Join(URI.Path(), columnarDirName, domainID, partition)
+	if err := util.MkdirAll(dirPath, s.dirMode); err != nil {
+		return err
+	}
+	return util.WriteFile(path.Join(dirPath, filename), data, s.fileMode)
+}
+
+func (s *columnarStore) DeleteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) error {
+	err := os.Remove(path.Join(URI.Path(), columnarDirName, domainID, partition, filename))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func listDirectory(dirPath string) ([]string, error) {
+	exists, err := util.DirectoryExists(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	return util.ListFiles(dirPath)
+}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/types"
@@ -47,6 +48,8 @@ type (
 		fileMode    os.FileMode
 		dirMode     os.FileMode
 		queryParser QueryParser
+		// columnar is set when the visibility records are archived in the columnar format
+		columnar *columnar.Archiver
 	}
 
 	queryVisibilityToken struct {
@@ -77,12 +80,19 @@ func NewVisibilityArchiver(
 	if err != nil {
 		return nil, errInvalidDirMode
 	}
-	return &visibilityArchiver{
+	visibilityArchiver := &visibilityArchiver{
 		container:   container,
 		fileMode:    os.FileMode(fileMode),
 		dirMode:     os.FileMode(dirMode),
 		queryParser: NewQueryParser(),
-	}, nil
+	}
+	if config.ColumnarVisibility != nil {
+		visibilityArchiver.columnar = columnar.NewArchiver(&columnarStore{
+			fileMode: visibilityArchiver.fileMode,
+			dirMode:  visibilityArchiver.dirMode,
+		}, config.ColumnarVisibility, container.Logger)
+	}
+	return visibilityArchiver, nil
 }
 
 func (v *visibilityArchiver) Archive(
@@ -110,6 +120,14 @@ func (v *visibilityArchiver) Archive(
 		return err
 	}
 
+	if v.columnar != nil {
+		if err := v.columnar.Archive(ctx, URI, request); err != nil {
+			logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errWriteFile), tag.Error(err))
+			return err
+		}
+		return nil
+	}
+
 	dirPath := path.Join(URI.Path(), request.DomainID)
 	if err = util.MkdirAll(dirPath, v.dirMode); err != nil {
 		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errMakeDirectory), tag.Error(err))
@@ -146,6 +164,10 @@ func (v *visibilityArchiver) Query(
 		return nil, &types.BadRequestError{Message: archiver.ErrInvalidQueryVisibilityRequest.Error()}
 	}
 
+	if v.columnar != nil {
+		return v.columnar.Query(ctx, URI, request)
+	}
+
 	parsedQuery, err := v.queryParser.Parse(request.Query)
 	if err != nil {
 		return nil, &types.BadRequestError{Message: err.Error()}
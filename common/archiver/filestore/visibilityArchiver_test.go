@@ -26,6 +26,7 @@ import (
 	"errors"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log/testlogger"
 	"github.com/uber/cadence/common/types"
@@ -479,6 +481,60 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
 }
 
+func (s *visibilityArchiverSuite) TestArchiveAndQuery_Columnar() {
+	dir := s.T().TempDir()
+
+	columnarArchiver, err := NewVisibilityArchiver(s.container, &config.FilestoreArchiver{
+		FileMode: testFileModeStr,
+		DirMode:  testDirModeStr,
+		ColumnarVisibility: &config.ColumnarVisibilityArchiver{
+			FlushInterval: time.Millisecond,
+		},
+	})
+	s.NoError(err)
+	visibilityArchiver := columnarArchiver.(*visibilityArchiver)
+	URI, err := archiver.NewURI("file://" + dir)
+	s.NoError(err)
+	for _, record := range s.visibilityRecords {
+		err := visibilityArchiver.Archive(context.Background(), URI, (*archiver.ArchiveVisibilityRequest)(record))
+		s.NoError(err)
+	}
+	exists, err := util.DirectoryExists(path.Join(dir, testDomainID))
+	s.NoError(err)
+	s.False(exists)
+	exists, err = util.DirectoryExists(path.Join(dir, columnarDirName, testDomainID))
+	s.NoError(err)
+	s.True(exists)
+	// the staged records are compacted in the background
+	s.Eventually(func() bool {
+		filenames, err := util.ListFiles(path.Join(dir, columnarDirName, testDomainID, columnar.PartitionName(0)))
+		s.NoError(err)
+		for _, filename := range filenames {
+			if strings.HasSuffix(filename, ".staged"+columnar.FileExtension) {
+				return false
+			}
+		}
+		return len(filenames) > 0
+	}, time.Second, time.Millisecond)
+
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+		Query:    "CloseStatus = 'failed' and CloseTime between 10 and 10001 and HistoryLength > 100",
+	}
+	executions := []*types.WorkflowExecutionInfo{}
+	for len(executions) == 0 || request.NextPageToken != nil {
+		response, err := visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		executions = append(executions, response.Executions...)
+		request.NextPageToken = response.NextPageToken
+	}
+	s.Len(executions, 2)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), executions[0])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
+}
+
 func (s *visibilityArchiverSuite) newTestVisibilityArchiver() *visibilityArchiver {
 	config := &config.FilestoreArchiver{
 		FileMode: testFileModeStr,
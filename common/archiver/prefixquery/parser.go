@@ -1,4 +1,6 @@
-// Copyright (c) 2020 Uber Technologies, Inc.
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -7,20 +9,23 @@
 // copies of the Software, and to permit persons to whom the Software is
 // furnished to do so, subject to the following conditions:
 //
-// The above copyright notice and this permission notice shall be included in
-// all copies or substantial portions of the Software.
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
 //
 // THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
 // IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
 // FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
 // AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
-// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
-// THE SOFTWARE.
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
 
-//go:generate mockgen -package $GOPACKAGE -source queryParser.go -destination queryParser_mock.go -mock_names Interface=MockQueryParser
+//go:generate mockgen -package $GOPACKAGE -source parser.go -destination parser_mock.go -mock_names Parser=MockParser
 
-package s3store
+// Package prefixquery parses the visibility queries of the archivers which index the visibility records by
+// key prefixes, like s3store and azureblob. Only equality filters on the workflow ID or the workflow type name,
+// and optionally on the start or close time with a search precision, are supported.
+package prefixquery
 
 import (
 	"errors"
@@ -34,19 +39,24 @@ import (
 )
 
 type (
-	// QueryParser parses a limited SQL where clause into a struct
-	QueryParser interface {
-		Parse(query string) (*parsedQuery, error)
+	// Parser parses a limited SQL where clause into a Query
+	Parser interface {
+		Parse(query string) (*Query, error)
 	}
 
-	queryParser struct{}
+	// Query is a parsed query. Exactly one of WorkflowID and WorkflowTypeName is set, and at most one of
+	// StartTime and CloseTime, which are set together with SearchPrecision.
+	Query struct {
+		WorkflowTypeName *string
+		WorkflowID       *string
+		StartTime        *int64
+		CloseTime        *int64
+		SearchPrecision  *string
+	}
 
-	parsedQuery struct {
-		workflowTypeName *string
-		workflowID       *string
-		startTime        *int64
-		closeTime        *int64
-		searchPrecision  *string
+	parser struct {
+		// storeName is the name of the archival store in the error messages
+		storeName string
 	}
 )
 
@@ -71,41 +81,41 @@ const (
 	defaultDateTimeFormat = time.RFC3339
 )
 
-// NewQueryParser creates a new query parser for filestore
-func NewQueryParser() QueryParser {
-	return &queryParser{}
+// NewParser creates a new query parser for the archival store with the given name
+func NewParser(storeName string) Parser {
+	return &parser{storeName: storeName}
 }
 
-func (p *queryParser) Parse(query string) (*parsedQuery, error) {
+func (p *parser) Parse(query string) (*Query, error) {
 	stmt, err := sqlparser.Parse(fmt.Sprintf(queryTemplate, query))
 	if err != nil {
 		return nil, err
 	}
 	whereExpr := stmt.(*sqlparser.Select).Where.Expr
-	parsedQuery := &parsedQuery{}
+	parsedQuery := &Query{}
 	if err := p.convertWhereExpr(whereExpr, parsedQuery); err != nil {
 		return nil, err
 	}
-	if parsedQuery.workflowID == nil && parsedQuery.workflowTypeName == nil {
+	if parsedQuery.WorkflowID == nil && parsedQuery.WorkflowTypeName == nil {
 		return nil, errors.New("WorkflowID or WorkflowTypeName is required in query")
 	}
-	if parsedQuery.workflowID != nil && parsedQuery.workflowTypeName != nil {
+	if parsedQuery.WorkflowID != nil && parsedQuery.WorkflowTypeName != nil {
 		return nil, errors.New("only one of WorkflowID or WorkflowTypeName can be specified in a query")
 	}
-	if parsedQuery.closeTime != nil && parsedQuery.startTime != nil {
+	if parsedQuery.CloseTime != nil && parsedQuery.StartTime != nil {
 		return nil, errors.New("only one of StartTime or CloseTime can be specified in a query")
 	}
-	if (parsedQuery.closeTime != nil || parsedQuery.startTime != nil) && parsedQuery.searchPrecision == nil {
+	if (parsedQuery.CloseTime != nil || parsedQuery.StartTime != nil) && parsedQuery.SearchPrecision == nil {
 		return nil, errors.New("SearchPrecision is required when searching for a StartTime or CloseTime")
 	}
 
-	if parsedQuery.closeTime == nil && parsedQuery.startTime == nil && parsedQuery.searchPrecision != nil {
+	if parsedQuery.CloseTime == nil && parsedQuery.StartTime == nil && parsedQuery.SearchPrecision != nil {
 		return nil, errors.New("SearchPrecision requires a StartTime or CloseTime")
 	}
 	return parsedQuery, nil
 }
 
-func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQuery) error {
+func (p *parser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *Query) error {
 	if expr == nil {
 		return errors.New("where expression is nil")
 	}
@@ -122,18 +132,18 @@ func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQ
 	}
 }
 
-func (p *queryParser) convertParenExpr(parenExpr *sqlparser.ParenExpr, parsedQuery *parsedQuery) error {
+func (p *parser) convertParenExpr(parenExpr *sqlparser.ParenExpr, parsedQuery *Query) error {
 	return p.convertWhereExpr(parenExpr.Expr, parsedQuery)
 }
 
-func (p *queryParser) convertAndExpr(andExpr *sqlparser.AndExpr, parsedQuery *parsedQuery) error {
+func (p *parser) convertAndExpr(andExpr *sqlparser.AndExpr, parsedQuery *Query) error {
 	if err := p.convertWhereExpr(andExpr.Left, parsedQuery); err != nil {
 		return err
 	}
 	return p.convertWhereExpr(andExpr.Right, parsedQuery)
 }
 
-func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *parsedQuery) error {
+func (p *parser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *Query) error {
 	colName, ok := compExpr.Left.(*sqlparser.ColName)
 	if !ok {
 		return fmt.Errorf("invalid filter name: %s", sqlparser.String(compExpr.Left))
@@ -153,51 +163,51 @@ func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr,
 			return err
 		}
 		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", WorkflowTypeName)
+			return fmt.Errorf("only operator = is supported for %s with %s", WorkflowTypeName, p.storeName)
 		}
-		if parsedQuery.workflowTypeName != nil {
+		if parsedQuery.WorkflowTypeName != nil {
 			return fmt.Errorf("can not query %s multiple times", WorkflowTypeName)
 		}
-		parsedQuery.workflowTypeName = common.StringPtr(val)
+		parsedQuery.WorkflowTypeName = common.StringPtr(val)
 	case WorkflowID:
 		val, err := extractStringValue(valStr)
 		if err != nil {
 			return err
 		}
 		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", WorkflowID)
+			return fmt.Errorf("only operator = is supported for %s with %s", WorkflowID, p.storeName)
 		}
-		if parsedQuery.workflowID != nil {
+		if parsedQuery.WorkflowID != nil {
 			return fmt.Errorf("can not query %s multiple times", WorkflowID)
 		}
-		parsedQuery.workflowID = common.StringPtr(val)
+		parsedQuery.WorkflowID = common.StringPtr(val)
 	case CloseTime:
 		timestamp, err := convertToTimestamp(valStr)
 		if err != nil {
 			return err
 		}
 		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", CloseTime)
+			return fmt.Errorf("only operator = is supported for %s with %s", CloseTime, p.storeName)
 		}
-		parsedQuery.closeTime = &timestamp
+		parsedQuery.CloseTime = &timestamp
 	case StartTime:
 		timestamp, err := convertToTimestamp(valStr)
 		if err != nil {
 			return err
 		}
 		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", StartTime)
+			return fmt.Errorf("only operator = is supported for %s with %s", StartTime, p.storeName)
 		}
-		parsedQuery.startTime = &timestamp
+		parsedQuery.StartTime = &timestamp
 	case SearchPrecision:
 		val, err := extractStringValue(valStr)
 		if err != nil {
 			return err
 		}
 		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", SearchPrecision)
+			return fmt.Errorf("only operator = is supported for %s with %s", SearchPrecision, p.storeName)
 		}
-		if parsedQuery.searchPrecision != nil && *parsedQuery.searchPrecision != val {
+		if parsedQuery.SearchPrecision != nil && *parsedQuery.SearchPrecision != val {
 			return fmt.Errorf("only one expression is allowed for %s", SearchPrecision)
 		}
 		switch val {
@@ -208,7 +218,7 @@ func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr,
 		default:
 			return fmt.Errorf("invalid value for %s: %s", SearchPrecision, val)
 		}
-		parsedQuery.searchPrecision = common.StringPtr(val)
+		parsedQuery.SearchPrecision = common.StringPtr(val)
 
 	default:
 		return fmt.Errorf("unknown filter name: %s", colNameStr)
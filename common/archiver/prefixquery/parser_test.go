@@ -1,4 +1,6 @@
-// Copyright (c) 2020 Uber Technologies, Inc.
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -7,18 +9,18 @@
 // copies of the Software, and to permit persons to whom the Software is
 // furnished to do so, subject to the following conditions:
 //
-// The above copyright notice and this permission notice shall be included in
-// all copies or substantial portions of the Software.
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
 //
 // THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
 // IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
 // FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
 // AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
-// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
-// THE SOFTWARE.
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
 
-package s3store
+package prefixquery
 
 import (
 	"testing"
@@ -29,40 +31,40 @@ import (
 	"github.com/uber/cadence/common"
 )
 
-type queryParserSuite struct {
+type parserSuite struct {
 	*require.Assertions
 	suite.Suite
 
-	parser QueryParser
+	parser Parser
 }
 
-func TestQueryParserSuite(t *testing.T) {
-	suite.Run(t, new(queryParserSuite))
+func TestParserSuite(t *testing.T) {
+	suite.Run(t, new(parserSuite))
 }
 
-func (s *queryParserSuite) SetupTest() {
+func (s *parserSuite) SetupTest() {
 	s.Assertions = require.New(s.T())
-	s.parser = NewQueryParser()
+	s.parser = NewParser("test store")
 }
 
-func (s *queryParserSuite) TestParseWorkflowIDAndWorkflowTypeName() {
+func (s *parserSuite) TestParseWorkflowIDAndWorkflowTypeName() {
 	testCases := []struct {
 		query       string
 		expectErr   bool
-		parsedQuery *parsedQuery
+		parsedQuery *Query
 	}{
 		{
 			query:     "WorkflowID = \"random workflowID\"",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				workflowID: common.StringPtr("random workflowID"),
+			parsedQuery: &Query{
+				WorkflowID: common.StringPtr("random workflowID"),
 			},
 		},
 		{
 			query:     "WorkflowTypeName = \"random workflowTypeName\"",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				workflowTypeName: common.StringPtr("random workflowTypeName"),
+			parsedQuery: &Query{
+				WorkflowTypeName: common.StringPtr("random workflowTypeName"),
 			},
 		},
 		{
@@ -80,15 +82,15 @@ func (s *queryParserSuite) TestParseWorkflowIDAndWorkflowTypeName() {
 		{
 			query:     "WorkflowID = 'random workflowID'",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				workflowID: common.StringPtr("random workflowID"),
+			parsedQuery: &Query{
+				WorkflowID: common.StringPtr("random workflowID"),
 			},
 		},
 		{
 			query:     "(WorkflowID = \"random workflowID\")",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				workflowID: common.StringPtr("random workflowID"),
+			parsedQuery: &Query{
+				WorkflowID: common.StringPtr("random workflowID"),
 			},
 		},
 		{
@@ -120,45 +122,45 @@ func (s *queryParserSuite) TestParseWorkflowIDAndWorkflowTypeName() {
 			continue
 		}
 		s.NoError(err)
-		s.Equal(tc.parsedQuery.workflowID, parsedQuery.workflowID)
-		s.Equal(tc.parsedQuery.workflowTypeName, parsedQuery.workflowTypeName)
+		s.Equal(tc.parsedQuery.WorkflowID, parsedQuery.WorkflowID)
+		s.Equal(tc.parsedQuery.WorkflowTypeName, parsedQuery.WorkflowTypeName)
 
 	}
 }
 
-func (s *queryParserSuite) TestParsePrecision() {
+func (s *parserSuite) TestParsePrecision() {
 	commonQueryPart := "WorkflowID = \"random workflowID\" AND "
 	testCases := []struct {
 		query       string
 		expectErr   bool
-		parsedQuery *parsedQuery
+		parsedQuery *Query
 	}{
 		{
 			query:     commonQueryPart + "CloseTime = 1000 and SearchPrecision = 'Day'",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				searchPrecision: common.StringPtr(PrecisionDay),
+			parsedQuery: &Query{
+				SearchPrecision: common.StringPtr(PrecisionDay),
 			},
 		},
 		{
 			query:     commonQueryPart + "CloseTime = 1000 and SearchPrecision = 'Hour'",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				searchPrecision: common.StringPtr(PrecisionHour),
+			parsedQuery: &Query{
+				SearchPrecision: common.StringPtr(PrecisionHour),
 			},
 		},
 		{
 			query:     commonQueryPart + "CloseTime = 1000 and SearchPrecision = 'Minute'",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				searchPrecision: common.StringPtr(PrecisionMinute),
+			parsedQuery: &Query{
+				SearchPrecision: common.StringPtr(PrecisionMinute),
 			},
 		},
 		{
 			query:     commonQueryPart + "StartTime = 1000 and SearchPrecision = 'Second'",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				searchPrecision: common.StringPtr(PrecisionSecond),
+			parsedQuery: &Query{
+				SearchPrecision: common.StringPtr(PrecisionSecond),
 			},
 		},
 		{
@@ -178,30 +180,30 @@ func (s *queryParserSuite) TestParsePrecision() {
 			continue
 		}
 		s.NoError(err)
-		s.Equal(tc.parsedQuery.searchPrecision, parsedQuery.searchPrecision)
+		s.Equal(tc.parsedQuery.SearchPrecision, parsedQuery.SearchPrecision)
 	}
 }
 
-func (s *queryParserSuite) TestParseCloseTime() {
+func (s *parserSuite) TestParseCloseTime() {
 	commonQueryPart := "WorkflowID = \"random workflowID\" AND SearchPrecision = 'Day' AND "
 
 	testCases := []struct {
 		query       string
 		expectErr   bool
-		parsedQuery *parsedQuery
+		parsedQuery *Query
 	}{
 		{
 			query:     commonQueryPart + "CloseTime = 1000",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				closeTime: common.Int64Ptr(1000),
+			parsedQuery: &Query{
+				CloseTime: common.Int64Ptr(1000),
 			},
 		},
 		{
 			query:     commonQueryPart + "CloseTime = \"2019-01-01T11:11:11Z\"",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				closeTime: common.Int64Ptr(1546341071000000000),
+			parsedQuery: &Query{
+				CloseTime: common.Int64Ptr(1546341071000000000),
 			},
 		},
 		{
@@ -221,31 +223,31 @@ func (s *queryParserSuite) TestParseCloseTime() {
 			continue
 		}
 		s.NoError(err)
-		s.Equal(tc.parsedQuery.closeTime, parsedQuery.closeTime)
+		s.Equal(tc.parsedQuery.CloseTime, parsedQuery.CloseTime)
 
 	}
 }
 
-func (s *queryParserSuite) TestParseStartTime() {
+func (s *parserSuite) TestParseStartTime() {
 	commonQueryPart := "WorkflowID = \"random workflowID\" AND SearchPrecision = 'Day' AND "
 
 	testCases := []struct {
 		query       string
 		expectErr   bool
-		parsedQuery *parsedQuery
+		parsedQuery *Query
 	}{
 		{
 			query:     commonQueryPart + "StartTime = 1000",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				startTime: common.Int64Ptr(1000),
+			parsedQuery: &Query{
+				StartTime: common.Int64Ptr(1000),
 			},
 		},
 		{
 			query:     commonQueryPart + "StartTime = \"2019-01-01T11:11:11Z\"",
 			expectErr: false,
-			parsedQuery: &parsedQuery{
-				startTime: common.Int64Ptr(1546341071000000000),
+			parsedQuery: &Query{
+				StartTime: common.Int64Ptr(1546341071000000000),
 			},
 		},
 		{
@@ -265,6 +267,6 @@ func (s *queryParserSuite) TestParseStartTime() {
 			continue
 		}
 		s.NoError(err)
-		s.Equal(tc.parsedQuery.closeTime, parsedQuery.closeTime)
+		s.Equal(tc.parsedQuery.CloseTime, parsedQuery.CloseTime)
 	}
 }
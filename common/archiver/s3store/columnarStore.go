@@ -0,0 +1,113 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package s3store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
+)
+
+type (
+	// columnarStore stores the files of the columnar visibility format
+	// under <path>/<domainID>/visibility/columnar/<partition>
+	columnarStore struct {
+		s3cli s3iface.S3API
+	}
+)
+
+var _ columnar.Store = (*columnarStore)(nil)
+
+func (s *columnarStore) ListPartitions(ctx context.Context, URI archiver.URI, domainID string) ([]string, error) {
+	prefix := constructColumnarPrefix(URI.Path(), domainID) + "/"
+	var partitions []string
+	err := s.list(ctx, URI, prefix, func(page *s3.ListObjectsV2Output) {
+		for _, commonPrefix := range page.CommonPrefixes {
+			partitions = append(partitions, strings.TrimSuffix(strings.TrimPrefix(*commonPrefix.Prefix, prefix), "/"))
+		}
+	})
+	return partitions, err
+}
+
+func (s *columnarStore) ListFiles(ctx context.Context, URI archiver.URI, domainID string, partition string) ([]string, error) {
+	prefix := constructColumnarPrefix(URI.Path(), domainID, partition) + "/"
+	var filenames []string
+	err := s.list(ctx, URI, prefix, func(page *s3.ListObjectsV2Output) {
+		for _, object := range page.Contents {
+			filenames = append(filenames, strings.TrimPrefix(*object.Key, prefix))
+		}
+	})
+	return filenames, err
+}
+
+func (s *columnarStore) ReadFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) ([]byte, error) {
+	return download(ctx, s.s3cli, URI, constructColumnarPrefix(URI.Path(), domainID, partition, filename))
+}
+
+func (s *columnarStore) WriteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string, data []byte) error {
+	return upload(ctx, s.s3cli, URI, constructColumnarPrefix(URI.Path(), domainID, partition, filename), data)
+}
+
+func (s *columnarStore) DeleteFile(ctx context.Context, URI archiver.URI, domainID string, partition string, filename string) error {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+
+	_, err := s.s3cli.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(URI.Hostname()),
+		Key:    aws.String(constructColumnarPrefix(URI.Path(), domainID, partition, filename)),
+	})
+	return err
+}
+
+func (s *columnarStore) list(ctx context.Context, URI archiver.URI, prefix string, handlePage func(*s3.ListObjectsV2Output)) error {
+	ctx, cancel := ensureContextTimeout(ctx)
+	defer cancel()
+
+	var token *string
+	for {
+		page, err := s.s3cli.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(URI.Hostname()),
+			Prefix:            aws.String(prefix),
+			Delimiter:         aws.String("/"),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return err
+		}
+		handlePage(page)
+		if !aws.BoolValue(page.IsTruncated) {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func constructColumnarPrefix(path, domainID string, elements ...string) string {
+	return strings.TrimLeft(strings.Join(append([]string{path, domainID, "visibility", "columnar"}, elements...), "/"), "/")
+}
@@ -37,6 +37,7 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/prefixquery"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -150,16 +151,16 @@ func constructTimeBasedSearchKey(path, domainID, primaryIndexKey, primaryIndexVa
 	t := time.Unix(0, timestamp).In(time.UTC)
 	var timeFormat = ""
 	switch precision {
-	case PrecisionSecond:
+	case prefixquery.PrecisionSecond:
 		timeFormat = ":05"
 		fallthrough
-	case PrecisionMinute:
+	case prefixquery.PrecisionMinute:
 		timeFormat = ":04" + timeFormat
 		fallthrough
-	case PrecisionHour:
+	case prefixquery.PrecisionHour:
 		timeFormat = "15" + timeFormat
 		fallthrough
-	case PrecisionDay:
+	case prefixquery.PrecisionDay:
 		timeFormat = "2006-01-02T" + timeFormat
 	}
 
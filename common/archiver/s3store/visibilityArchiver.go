@@ -29,6 +29,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 
 	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
+	"github.com/uber/cadence/common/archiver/prefixquery"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
@@ -39,7 +41,9 @@ type (
 	visibilityArchiver struct {
 		container   *archiver.VisibilityBootstrapContainer
 		s3cli       s3iface.S3API
-		queryParser QueryParser
+		queryParser prefixquery.Parser
+		// columnar is set when the visibility records are archived in the columnar format
+		columnar *columnar.Archiver
 	}
 
 	visibilityRecord archiver.ArchiveVisibilityRequest
@@ -48,7 +52,7 @@ type (
 		domainID      string
 		pageSize      int
 		nextPageToken []byte
-		parsedQuery   *parsedQuery
+		parsedQuery   *prefixquery.Query
 	}
 
 	indexToArchive struct {
@@ -87,11 +91,15 @@ func newVisibilityArchiver(
 	if err != nil {
 		return nil, err
 	}
-	return &visibilityArchiver{
+	visibilityArchiver := &visibilityArchiver{
 		container:   container,
 		s3cli:       s3.New(sess),
-		queryParser: NewQueryParser(),
-	}, nil
+		queryParser: prefixquery.NewParser("Amazon S3"),
+	}
+	if config.ColumnarVisibility != nil {
+		visibilityArchiver.columnar = columnar.NewArchiver(&columnarStore{s3cli: visibilityArchiver.s3cli}, config.ColumnarVisibility, container.Logger)
+	}
+	return visibilityArchiver, nil
 }
 
 func (v *visibilityArchiver) Archive(
@@ -131,6 +139,15 @@ func (v *visibilityArchiver) Archive(
 		return err
 	}
 
+	if v.columnar != nil {
+		if err := v.columnar.Archive(ctx, URI, request); err != nil {
+			archiveFailReason = errWriteKey
+			return err
+		}
+		scope.IncCounter(metrics.VisibilityArchiveSuccessCount)
+		return nil
+	}
+
 	encodedVisibilityRecord, err := encode(request)
 	if err != nil {
 		archiveFailReason = errEncodeVisibilityRecord
@@ -170,6 +187,10 @@ func (v *visibilityArchiver) Query(
 		return nil, &types.BadRequestError{Message: archiver.ErrInvalidQueryVisibilityRequest.Error()}
 	}
 
+	if v.columnar != nil {
+		return v.columnar.Query(ctx, URI, request)
+	}
+
 	parsedQuery, err := v.queryParser.Parse(request.Query)
 	if err != nil {
 		return nil, &types.BadRequestError{Message: err.Error()}
@@ -195,17 +216,17 @@ func (v *visibilityArchiver) query(
 		token = deserializeQueryVisibilityToken(request.nextPageToken)
 	}
 	primaryIndex := primaryIndexKeyWorkflowTypeName
-	primaryIndexValue := request.parsedQuery.workflowTypeName
-	if request.parsedQuery.workflowID != nil {
+	primaryIndexValue := request.parsedQuery.WorkflowTypeName
+	if request.parsedQuery.WorkflowID != nil {
 		primaryIndex = primaryIndexKeyWorkflowID
-		primaryIndexValue = request.parsedQuery.workflowID
+		primaryIndexValue = request.parsedQuery.WorkflowID
 	}
 	var prefix = constructVisibilitySearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout) + "/"
-	if request.parsedQuery.closeTime != nil {
-		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout, *request.parsedQuery.closeTime, *request.parsedQuery.searchPrecision)
+	if request.parsedQuery.CloseTime != nil {
+		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout, *request.parsedQuery.CloseTime, *request.parsedQuery.SearchPrecision)
 	}
-	if request.parsedQuery.startTime != nil {
-		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyStartTimeout, *request.parsedQuery.startTime, *request.parsedQuery.searchPrecision)
+	if request.parsedQuery.StartTime != nil {
+		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyStartTimeout, *request.parsedQuery.StartTime, *request.parsedQuery.SearchPrecision)
 	}
 
 	results, err := v.s3cli.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
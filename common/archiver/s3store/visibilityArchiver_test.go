@@ -38,7 +38,10 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/archiver/columnar"
+	"github.com/uber/cadence/common/archiver/prefixquery"
 	"github.com/uber/cadence/common/archiver/s3store/mocks"
+	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log/testlogger"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
@@ -104,7 +107,7 @@ func (s *visibilityArchiverSuite) newTestVisibilityArchiver() *visibilityArchive
 	archiver := &visibilityArchiver{
 		container:   s.container,
 		s3cli:       s.s3cli,
-		queryParser: NewQueryParser(),
+		queryParser: prefixquery.NewParser("Amazon S3"),
 	}
 	return archiver
 }
@@ -241,7 +244,7 @@ func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidRequest() {
 
 func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidQuery() {
 	visibilityArchiver := s.newTestVisibilityArchiver()
-	mockParser := NewMockQueryParser(s.controller)
+	mockParser := prefixquery.NewMockParser(s.controller)
 	mockParser.EXPECT().Parse(gomock.Any()).Return(nil, errors.New("invalid query"))
 	visibilityArchiver.queryParser = mockParser
 	response, err := visibilityArchiver.Query(context.Background(), s.testArchivalURI, &archiver.QueryVisibilityRequest{
@@ -254,11 +257,11 @@ func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidQuery() {
 }
 func (s *visibilityArchiverSuite) TestQuery_Success_DirectoryNotExist() {
 	visibilityArchiver := s.newTestVisibilityArchiver()
-	mockParser := NewMockQueryParser(s.controller)
-	mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-		workflowID:      common.StringPtr(testWorkflowID),
-		closeTime:       common.Int64Ptr(0),
-		searchPrecision: common.StringPtr(PrecisionSecond),
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowID:      common.StringPtr(testWorkflowID),
+		CloseTime:       common.Int64Ptr(0),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionSecond),
 	}, nil)
 	visibilityArchiver.queryParser = mockParser
 	request := &archiver.QueryVisibilityRequest{
@@ -275,11 +278,11 @@ func (s *visibilityArchiverSuite) TestQuery_Success_DirectoryNotExist() {
 
 func (s *visibilityArchiverSuite) TestQuery_Success_NoNextPageToken() {
 	visibilityArchiver := s.newTestVisibilityArchiver()
-	mockParser := NewMockQueryParser(s.controller)
-	mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-		closeTime:       common.Int64Ptr(int64(1 * time.Hour)),
-		searchPrecision: common.StringPtr(PrecisionHour),
-		workflowID:      common.StringPtr(testWorkflowID),
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		CloseTime:       common.Int64Ptr(int64(1 * time.Hour)),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionHour),
+		WorkflowID:      common.StringPtr(testWorkflowID),
 	}, nil)
 	visibilityArchiver.queryParser = mockParser
 	request := &archiver.QueryVisibilityRequest{
@@ -299,11 +302,11 @@ func (s *visibilityArchiverSuite) TestQuery_Success_NoNextPageToken() {
 
 func (s *visibilityArchiverSuite) TestQuery_Success_SmallPageSize() {
 	visibilityArchiver := s.newTestVisibilityArchiver()
-	mockParser := NewMockQueryParser(s.controller)
-	mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-		closeTime:       common.Int64Ptr(0),
-		searchPrecision: common.StringPtr(PrecisionDay),
-		workflowID:      common.StringPtr(testWorkflowID),
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		CloseTime:       common.Int64Ptr(0),
+		SearchPrecision: common.StringPtr(prefixquery.PrecisionDay),
+		WorkflowID:      common.StringPtr(testWorkflowID),
 	}, nil).AnyTimes()
 	visibilityArchiver.queryParser = mockParser
 	request := &archiver.QueryVisibilityRequest{
@@ -345,70 +348,70 @@ func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
 			hour:      0,
 			minute:    0,
 			second:    0,
-			precision: PrecisionDay,
+			precision: prefixquery.PrecisionDay,
 		},
 		{
 			day:       1,
 			hour:      1,
 			minute:    0,
 			second:    0,
-			precision: PrecisionDay,
+			precision: prefixquery.PrecisionDay,
 		},
 		{
 			day:       2,
 			hour:      1,
 			minute:    0,
 			second:    0,
-			precision: PrecisionHour,
+			precision: prefixquery.PrecisionHour,
 		},
 		{
 			day:       2,
 			hour:      1,
 			minute:    30,
 			second:    0,
-			precision: PrecisionHour,
+			precision: prefixquery.PrecisionHour,
 		},
 		{
 			day:       3,
 			hour:      2,
 			minute:    1,
 			second:    0,
-			precision: PrecisionMinute,
+			precision: prefixquery.PrecisionMinute,
 		},
 		{
 			day:       3,
 			hour:      2,
 			minute:    1,
 			second:    30,
-			precision: PrecisionMinute,
+			precision: prefixquery.PrecisionMinute,
 		},
 		{
 			day:       4,
 			hour:      3,
 			minute:    2,
 			second:    1,
-			precision: PrecisionSecond,
+			precision: prefixquery.PrecisionSecond,
 		},
 		{
 			day:       4,
 			hour:      3,
 			minute:    2,
 			second:    1,
-			precision: PrecisionSecond,
+			precision: prefixquery.PrecisionSecond,
 		},
 		{
 			day:       4,
 			hour:      3,
 			minute:    2,
 			second:    2,
-			precision: PrecisionSecond,
+			precision: prefixquery.PrecisionSecond,
 		},
 		{
 			day:       4,
 			hour:      3,
 			minute:    2,
 			second:    2,
-			precision: PrecisionSecond,
+			precision: prefixquery.PrecisionSecond,
 		},
 	}
 	visibilityArchiver := s.newTestVisibilityArchiver()
@@ -438,11 +441,11 @@ func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
 	}
 
 	for i, testData := range precisionTests {
-		mockParser := NewMockQueryParser(s.controller)
-		mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-			closeTime:       common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
-			searchPrecision: common.StringPtr(testData.precision),
-			workflowID:      common.StringPtr(testWorkflowID),
+		mockParser := prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			CloseTime:       common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision: common.StringPtr(testData.precision),
+			WorkflowID:      common.StringPtr(testWorkflowID),
 		}, nil).AnyTimes()
 		visibilityArchiver.queryParser = mockParser
 
@@ -451,11 +454,11 @@ func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
 		s.NotNil(response)
 		s.Len(response.Executions, 2, "Iteration ", i)
 
-		mockParser = NewMockQueryParser(s.controller)
-		mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-			startTime:       common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
-			searchPrecision: common.StringPtr(testData.precision),
-			workflowID:      common.StringPtr(testWorkflowID),
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			StartTime:       common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision: common.StringPtr(testData.precision),
+			WorkflowID:      common.StringPtr(testWorkflowID),
 		}, nil).AnyTimes()
 		visibilityArchiver.queryParser = mockParser
 
@@ -464,11 +467,11 @@ func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
 		s.NotNil(response)
 		s.Len(response.Executions, 2, "Iteration ", i)
 
-		mockParser = NewMockQueryParser(s.controller)
-		mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-			closeTime:        common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
-			searchPrecision:  common.StringPtr(testData.precision),
-			workflowTypeName: common.StringPtr(testWorkflowTypeName),
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			CloseTime:        common.Int64Ptr((testData.day+30)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision:  common.StringPtr(testData.precision),
+			WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
 		}, nil).AnyTimes()
 		visibilityArchiver.queryParser = mockParser
 
@@ -477,11 +480,11 @@ func (s *visibilityArchiverSuite) TestArchiveAndQueryPrecisions() {
 		s.NotNil(response)
 		s.Len(response.Executions, 2, "Iteration ", i)
 
-		mockParser = NewMockQueryParser(s.controller)
-		mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-			startTime:        common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
-			searchPrecision:  common.StringPtr(testData.precision),
-			workflowTypeName: common.StringPtr(testWorkflowTypeName),
+		mockParser = prefixquery.NewMockParser(s.controller)
+		mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+			StartTime:        common.Int64Ptr((testData.day)*int64(time.Hour)*24 + testData.hour*int64(time.Hour) + testData.minute*int64(time.Minute) + testData.second*int64(time.Second)),
+			SearchPrecision:  common.StringPtr(testData.precision),
+			WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
 		}, nil).AnyTimes()
 		visibilityArchiver.queryParser = mockParser
 
@@ -500,9 +503,9 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 		s.NoError(err)
 	}
 
-	mockParser := NewMockQueryParser(s.controller)
-	mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-		workflowID: common.StringPtr(testWorkflowID),
+	mockParser := prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowID: common.StringPtr(testWorkflowID),
 	}, nil).AnyTimes()
 	visibilityArchiver.queryParser = mockParser
 	request := &archiver.QueryVisibilityRequest{
@@ -525,9 +528,9 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), executions[2])
 
-	mockParser = NewMockQueryParser(s.controller)
-	mockParser.EXPECT().Parse(gomock.Any()).Return(&parsedQuery{
-		workflowTypeName: common.StringPtr(testWorkflowTypeName),
+	mockParser = prefixquery.NewMockParser(s.controller)
+	mockParser.EXPECT().Parse(gomock.Any()).Return(&prefixquery.Query{
+		WorkflowTypeName: common.StringPtr(testWorkflowTypeName),
 	}, nil).AnyTimes()
 	visibilityArchiver.queryParser = mockParser
 	request = &archiver.QueryVisibilityRequest{
@@ -551,6 +554,39 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), executions[2])
 }
 
+func (s *visibilityArchiverSuite) TestArchiveAndQuery_Columnar() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	// the records stay in their staged files, as the emulated s3 client can't be used concurrently
+	visibilityArchiver.columnar = columnar.NewArchiver(&columnarStore{s3cli: s.s3cli}, &config.ColumnarVisibilityArchiver{
+		FlushInterval: time.Hour,
+	}, s.container.Logger)
+	URI, err := archiver.NewURI(testBucketURI + "/archive-and-query-columnar")
+	s.NoError(err)
+	for _, record := range s.visibilityRecords {
+		err := visibilityArchiver.Archive(context.Background(), URI, (*archiver.ArchiveVisibilityRequest)(record))
+		s.NoError(err)
+	}
+
+	request := &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+		Query:    fmt.Sprintf("WorkflowID = '%s' and CloseStatus = 'failed' and CloseTime between 0 and %d", testWorkflowID, int64(2*time.Hour)),
+	}
+	executions := []*types.WorkflowExecutionInfo{}
+	var first = true
+	for first || request.NextPageToken != nil {
+		response, err := visibilityArchiver.Query(context.Background(), URI, request)
+		s.NoError(err)
+		s.NotNil(response)
+		executions = append(executions, response.Executions...)
+		request.NextPageToken = response.NextPageToken
+		first = false
+	}
+	s.Len(executions, 2)
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[0])
+	s.Equal(convertToExecutionInfo(s.visibilityRecords[0]), executions[1])
+}
+
 func (s *visibilityArchiverSuite) setupVisibilityDirectory() {
 	s.visibilityRecords = []*visibilityRecord{
 		{
@@ -0,0 +1,83 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultQueueName         = "default"
+	defaultBatchSize         = 100
+	defaultPollInterval      = time.Second
+	defaultVisibilityTimeout = time.Minute
+)
+
+type (
+	queueConfig struct {
+		// Name identifies the queue among the persistence backed queues.
+		// Each queue is stored under its own queue type in the database, see persistence.AsyncWorkflowQueueTypeOf.
+		Name string `yaml:"name"`
+		// BatchSize is the max number of messages that are read from the database at once,
+		// and also the max number of messages that are being processed by a consumer at the same time
+		BatchSize int `yaml:"batchSize"`
+		// PollIntervalInMilliseconds is the interval of polling the database for new messages
+		PollIntervalInMilliseconds int `yaml:"pollIntervalInMilliseconds"`
+		// VisibilityTimeoutInSeconds is the time after which a message that is neither acked nor nacked is delivered again
+		VisibilityTimeoutInSeconds int `yaml:"visibilityTimeoutInSeconds"`
+	}
+)
+
+// ID returns the ID of the queue, which is unique among the persistence backed queues
+func (c *queueConfig) ID() string {
+	return fmt.Sprintf("%s::%s", QueueType, c.name())
+}
+
+func (c *queueConfig) name() string {
+	if c.Name == "" {
+		return defaultQueueName
+	}
+	return c.Name
+}
+
+func (c *queueConfig) batchSize() int {
+	if c.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return c.BatchSize
+}
+
+func (c *queueConfig) pollInterval() time.Duration {
+	if c.PollIntervalInMilliseconds <= 0 {
+		return defaultPollInterval
+	}
+	return time.Duration(c.PollIntervalInMilliseconds) * time.Millisecond
+}
+
+func (c *queueConfig) visibilityTimeout() time.Duration {
+	if c.VisibilityTimeoutInSeconds <= 0 {
+		return defaultVisibilityTimeout
+	}
+	return time.Duration(c.VisibilityTimeoutInSeconds) * time.Second
+}
@@ -0,0 +1,72 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name                  string
+		config                *queueConfig
+		wantBatchSize         int
+		wantPollInterval      time.Duration
+		wantVisibilityTimeout time.Duration
+	}{
+		{
+			name:                  "empty config",
+			config:                &queueConfig{},
+			wantBatchSize:         defaultBatchSize,
+			wantPollInterval:      defaultPollInterval,
+			wantVisibilityTimeout: defaultVisibilityTimeout,
+		},
+		{
+			name: "custom config",
+			config: &queueConfig{
+				BatchSize:                  10,
+				PollIntervalInMilliseconds: 200,
+				VisibilityTimeoutInSeconds: 30,
+			},
+			wantBatchSize:         10,
+			wantPollInterval:      200 * time.Millisecond,
+			wantVisibilityTimeout: 30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantBatchSize, tt.config.batchSize())
+			assert.Equal(t, tt.wantPollInterval, tt.config.pollInterval())
+			assert.Equal(t, tt.wantVisibilityTimeout, tt.config.visibilityTimeout())
+		})
+	}
+}
+
+func TestQueueConfigID(t *testing.T) {
+	assert.Equal(t, "persistence::default", (&queueConfig{}).ID())
+	assert.Equal(t, "persistence::queue1", (&queueConfig{Name: "queue1"}).ID())
+}
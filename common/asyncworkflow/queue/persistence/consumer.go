@@ -0,0 +1,437 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/messaging"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+)
+
+const (
+	// ackLevelKeyPrefix is the prefix of the keys of the ack levels of the queues
+	ackLevelKeyPrefix = "asyncWorkflowConsumer::"
+
+	persistenceTimeout = 10 * time.Second
+	dlqPublishTimeout  = time.Minute
+	purgeInterval      = 5 * time.Minute
+)
+
+type (
+	// consumerImpl reads the messages from the queue in the database in the order of the message IDs,
+	// and keeps track of the messages that are delivered but not completed yet.
+	// Messages are delivered at least once:
+	// - the ack level is only moved forward when all the messages before it are acked or nacked,
+	//   so the messages that are not completed are read again after the consumer is restarted
+	// - a message that is not completed within the visibility timeout is delivered again
+	// - a nacked message is enqueued to the DLQ of the queue
+	// Each persistence backed queue is stored under its own queue type in the database. The queues whose
+	// names hash to the same queue type share it, so the consumer skips the messages of the other queues
+	// and keeps its own ack level. Only the worker host owning the queue in the membership ring consumes it.
+	consumerImpl struct {
+		queueName          string
+		queueID            string
+		queueManager       p.QueueManager
+		membershipResolver membership.Resolver
+		hostInfo           membership.HostInfo
+		batchSize          int
+		pollInterval       time.Duration
+		visibilityTimeout  time.Duration
+		timeSource         clock.TimeSource
+		logger             log.Logger
+		throttleRetry      *backoff.ThrottleRetry
+
+		msgChan chan messaging.Message
+
+		sync.Mutex
+		// ackManager is replaced when the ownership of the queue is acquired again
+		ackManager messaging.AckManager
+		// inflightMessages are the messages that are delivered but not completed yet
+		inflightMessages map[int64]*messageImpl
+
+		// the fields below are only accessed by the polling loop
+		owned             bool
+		persistedAckLevel int64
+		lastPurgeTime     time.Time
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+		wg       sync.WaitGroup
+	}
+
+	messageImpl struct {
+		id int64
+		// payload is the message published to the queue, and rawPayload is the payload stored in the database
+		payload    []byte
+		rawPayload []byte
+		deadline   time.Time
+		consumer   *consumerImpl
+		ackManager messaging.AckManager
+	}
+)
+
+var _ messaging.Message = (*messageImpl)(nil)
+var _ messaging.Consumer = (*consumerImpl)(nil)
+
+func newConsumer(
+	queueName string,
+	queueID string,
+	queueManager p.QueueManager,
+	membershipResolver membership.Resolver,
+	hostInfo membership.HostInfo,
+	batchSize int,
+	pollInterval time.Duration,
+	visibilityTimeout time.Duration,
+	timeSource clock.TimeSource,
+	logger log.Logger,
+) *consumerImpl {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	logger = logger.WithTags(tag.AsyncWFQueueID(queueID))
+	return &consumerImpl{
+		queueName:          queueName,
+		queueID:            queueID,
+		queueManager:       queueManager,
+		membershipResolver: membershipResolver,
+		hostInfo:           hostInfo,
+		batchSize:          batchSize,
+		pollInterval:       pollInterval,
+		visibilityTimeout:  visibilityTimeout,
+		timeSource:         timeSource,
+		logger:             logger,
+		throttleRetry: backoff.NewThrottleRetry(
+			backoff.WithRetryPolicy(common.CreateDlqPublishRetryPolicy()),
+			backoff.WithRetryableError(func(_ error) bool { return true }),
+		),
+		ackManager:        messaging.NewAckManager(logger),
+		msgChan:           make(chan messaging.Message, batchSize),
+		inflightMessages:  make(map[int64]*messageImpl),
+		persistedAckLevel: common.EmptyMessageID,
+		lastPurgeTime:     timeSource.Now(),
+		ctx:               ctx,
+		cancelFn:          cancelFn,
+	}
+}
+
+// Start loads the ack level of the consumer and starts polling the queue
+func (c *consumerImpl) Start() error {
+	if err := c.loadAckLevel(); err != nil {
+		return err
+	}
+
+	c.wg.Add(1)
+	go c.run()
+	c.logger.Info("Started persistence queue consumer", tag.AckLevel(c.persistedAckLevel))
+	return nil
+}
+
+// Stop stops polling the queue and persists the ack level
+func (c *consumerImpl) Stop() {
+	c.logger.Info("Stopping persistence queue consumer")
+	c.cancelFn()
+	c.wg.Wait()
+	if c.owned {
+		c.updateAckLevel(context.Background())
+	}
+	close(c.msgChan)
+	c.logger.Info("Stopped persistence queue consumer")
+}
+
+// Messages return the message channel for this consumer
+func (c *consumerImpl) Messages() <-chan messaging.Message {
+	return c.msgChan
+}
+
+func (c *consumerImpl) run() {
+	defer c.wg.Done()
+
+	ticker := c.timeSource.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if c.refreshOwnership() {
+			c.redeliverExpiredMessages()
+			c.readMessages()
+			c.updateAckLevel(c.ctx)
+			c.purgeAckedMessages()
+		}
+
+		select {
+		case <-ticker.Chan():
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshOwnership returns whether the queue is owned by this host.
+// The following is a best effort to make sure only one worker host is consuming the queue. When the ring is
+// under reconfiguration, it is possible that for a small period of time two hosts think they are the owner
+// and deliver the same messages, which is allowed as the messages are delivered at least once.
+func (c *consumerImpl) refreshOwnership() bool {
+	info, err := c.membershipResolver.Lookup(service.Worker, c.queueID)
+	if err != nil {
+		c.logger.Warn("Failed to lookup the owner of persistence queue", tag.Error(err))
+		return c.owned
+	}
+
+	owned := info.Identity() == c.hostInfo.Identity()
+	if owned == c.owned {
+		return owned
+	}
+	if owned {
+		// the queue may have been consumed by another host since this host owned it last time
+		if err := c.loadAckLevel(); err != nil {
+			c.logger.Warn("Failed to load ack level of persistence queue", tag.Error(err))
+			return false
+		}
+		c.logger.Info("Acquired ownership of persistence queue", tag.AckLevel(c.persistedAckLevel))
+	} else {
+		c.updateAckLevel(c.ctx)
+		c.logger.Info("Lost ownership of persistence queue", tag.Dynamic("owner", info.Identity()))
+	}
+	c.owned = owned
+	return owned
+}
+
+// loadAckLevel resets the consumer to the persisted ack level of the queue,
+// the messages that are delivered but not completed yet are read again
+func (c *consumerImpl) loadAckLevel() error {
+	ctx, cancel := context.WithTimeout(c.ctx, persistenceTimeout)
+	defer cancel()
+	ackLevels, err := c.queueManager.GetAckLevels(ctx)
+	if err != nil {
+		return err
+	}
+
+	ackManager := messaging.NewAckManager(c.logger)
+	persistedAckLevel := int64(common.EmptyMessageID)
+	if ackLevel, ok := ackLevels[c.ackLevelKey()]; ok {
+		ackManager.SetAckLevel(ackLevel)
+		persistedAckLevel = ackLevel
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.ackManager = ackManager
+	c.inflightMessages = make(map[int64]*messageImpl)
+	c.persistedAckLevel = persistedAckLevel
+	return nil
+}
+
+func (c *consumerImpl) ackLevelKey() string {
+	return ackLevelKeyPrefix + c.queueName
+}
+
+// readMessages reads the messages after the read level until the number of inflight messages reaches the batch size
+func (c *consumerImpl) readMessages() {
+	for {
+		c.Lock()
+		count := c.batchSize - len(c.inflightMessages)
+		ackManager := c.ackManager
+		c.Unlock()
+		if count <= 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.ctx, persistenceTimeout)
+		messages, err := c.queueManager.ReadMessages(ctx, ackManager.GetReadLevel(), count)
+		cancel()
+		if err != nil {
+			c.logger.Warn("Failed to read messages from persistence queue", tag.Error(err))
+			return
+		}
+
+		for _, message := range messages {
+			if err := ackManager.ReadItem(message.ID); err != nil {
+				c.logger.Error("Failed to add message to ack manager", tag.TaskID(message.ID), tag.Error(err))
+				continue
+			}
+			queueName, payload, err := decodePayload(message.Payload)
+			if err != nil {
+				c.logger.Error("Failed to decode message of persistence queue, skipping it", tag.TaskID(message.ID), tag.Error(err))
+			}
+			if err != nil || queueName != c.queueName {
+				ackManager.AckItem(message.ID)
+				continue
+			}
+			msg := &messageImpl{
+				id:         message.ID,
+				payload:    payload,
+				rawPayload: message.Payload,
+				consumer:   c,
+				ackManager: ackManager,
+			}
+			c.Lock()
+			msg.deadline = c.timeSource.Now().Add(c.visibilityTimeout)
+			c.inflightMessages[msg.id] = msg
+			c.Unlock()
+			if !c.deliver(msg) {
+				return
+			}
+		}
+
+		if len(messages) < count {
+			return
+		}
+	}
+}
+
+// redeliverExpiredMessages delivers the messages that are not completed within the visibility timeout again
+func (c *consumerImpl) redeliverExpiredMessages() {
+	now := c.timeSource.Now()
+	var expired []*messageImpl
+	c.Lock()
+	for _, msg := range c.inflightMessages {
+		if !now.Before(msg.deadline) {
+			msg.deadline = now.Add(c.visibilityTimeout)
+			expired = append(expired, msg)
+		}
+	}
+	c.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].id < expired[j].id
+	})
+	for _, msg := range expired {
+		c.logger.Warn("Message is not completed within visibility timeout, delivering it again", tag.TaskID(msg.id))
+		if !c.deliver(msg) {
+			return
+		}
+	}
+}
+
+func (c *consumerImpl) deliver(msg *messageImpl) bool {
+	select {
+	case c.msgChan <- msg:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+func (c *consumerImpl) updateAckLevel(ctx context.Context) {
+	c.Lock()
+	ackLevel := c.ackManager.GetAckLevel()
+	c.Unlock()
+	if ackLevel <= c.persistedAckLevel {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, persistenceTimeout)
+	defer cancel()
+	if err := c.queueManager.UpdateAckLevel(ctx, ackLevel, c.ackLevelKey()); err != nil {
+		c.logger.Warn("Failed to update ack level of persistence queue", tag.AckLevel(ackLevel), tag.Error(err))
+		return
+	}
+	c.persistedAckLevel = ackLevel
+}
+
+// purgeAckedMessages deletes the messages that are acked by all the consumers
+func (c *consumerImpl) purgeAckedMessages() {
+	if c.timeSource.Since(c.lastPurgeTime) < purgeInterval {
+		return
+	}
+	c.lastPurgeTime = c.timeSource.Now()
+
+	ctx, cancel := context.WithTimeout(c.ctx, persistenceTimeout)
+	defer cancel()
+	ackLevels, err := c.queueManager.GetAckLevels(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to purge acked messages of persistence queue", tag.Error(err))
+		return
+	}
+	if len(ackLevels) == 0 {
+		return
+	}
+
+	minAckLevel := int64(math.MaxInt64)
+	for _, ackLevel := range ackLevels {
+		if ackLevel < minAckLevel {
+			minAckLevel = ackLevel
+		}
+	}
+	if err := c.queueManager.DeleteMessagesBefore(ctx, minAckLevel); err != nil {
+		c.logger.Warn("Failed to purge acked messages of persistence queue", tag.Error(err))
+	}
+}
+
+func (c *consumerImpl) completeMessage(msg *messageImpl, isAck bool) error {
+	c.Lock()
+	inflight, ok := c.inflightMessages[msg.id]
+	if ok && inflight == msg {
+		delete(c.inflightMessages, msg.id)
+	}
+	c.Unlock()
+	if !ok || inflight != msg {
+		// the message was delivered more than once and is already completed,
+		// or the consumer has been reset since the message was delivered
+		return nil
+	}
+
+	if !isAck {
+		op := func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), dlqPublishTimeout)
+			defer cancel()
+			return c.queueManager.EnqueueMessageToDLQ(ctx, msg.rawPayload)
+		}
+		if err := c.throttleRetry.Do(context.Background(), op); err != nil {
+			c.logger.Error("Fail to enqueue message to DLQ when nacking message, please take action!!", tag.TaskID(msg.id), tag.Error(err))
+		} else {
+			c.logger.Warn("nack message and enqueue to DLQ", tag.TaskID(msg.id))
+		}
+	}
+	msg.ackManager.AckItem(msg.id)
+	return nil
+}
+
+func (m *messageImpl) Value() []byte {
+	return m.payload
+}
+
+func (m *messageImpl) Partition() int32 {
+	return 0
+}
+
+func (m *messageImpl) Offset() int64 {
+	return m.id
+}
+
+func (m *messageImpl) Ack() error {
+	return m.consumer.completeMessage(m, true)
+}
+
+func (m *messageImpl) Nack() error {
+	return m.consumer.completeMessage(m, false)
+}
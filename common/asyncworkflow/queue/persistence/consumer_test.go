@@ -0,0 +1,263 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/messaging"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+)
+
+const (
+	testQueueName         = "test-queue"
+	testQueueID           = "persistence::test-queue"
+	testAckLevelKey       = "asyncWorkflowConsumer::test-queue"
+	testBatchSize         = 3
+	testPollInterval      = time.Second
+	testVisibilityTimeout = time.Minute
+)
+
+var (
+	testHost  = membership.NewHostInfo("127.0.0.1:7939")
+	otherHost = membership.NewHostInfo("127.0.0.2:7939")
+)
+
+func setupConsumer(t *testing.T) (*consumerImpl, *p.MockQueueManager, *membership.MockResolver, clock.MockedTimeSource) {
+	ctrl := gomock.NewController(t)
+	mockQueueManager := p.NewMockQueueManager(ctrl)
+	mockResolver := membership.NewMockResolver(ctrl)
+	timeSource := clock.NewMockedTimeSource()
+	c := newConsumer(testQueueName, testQueueID, mockQueueManager, mockResolver, testHost, testBatchSize, testPollInterval, testVisibilityTimeout, timeSource, testlogger.New(t))
+	return c, mockQueueManager, mockResolver, timeSource
+}
+
+func queueMessages(ids ...int64) p.QueueMessageList {
+	var messages p.QueueMessageList
+	for _, id := range ids {
+		messages = append(messages, &p.QueueMessage{ID: id, QueueType: p.AsyncWorkflowQueueTypeOf(testQueueName), Payload: encodePayload(testQueueName, []byte{byte(id)})})
+	}
+	return messages
+}
+
+func receiveMessages(t *testing.T, c *consumerImpl, count int) []messaging.Message {
+	var messages []messaging.Message
+	for i := 0; i < count; i++ {
+		select {
+		case msg := <-c.Messages():
+			messages = append(messages, msg)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %v messages, got %v", count, len(messages))
+		}
+	}
+	return messages
+}
+
+func TestConsumerStartAndStop(t *testing.T) {
+	c, mockQueueManager, mockResolver, _ := setupConsumer(t)
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(testHost, nil).AnyTimes()
+	mockQueueManager.EXPECT().GetAckLevels(gomock.Any()).Return(map[string]int64{testAckLevelKey: 10}, nil).Times(2)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(10), testBatchSize).Return(queueMessages(11, 12), nil)
+	// the ack level can be updated by the polling loop before all the messages are acked
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), gomock.Any(), testAckLevelKey).Return(nil).MinTimes(1)
+
+	assert.NoError(t, c.Start())
+	messages := receiveMessages(t, c, 2)
+	assert.Equal(t, int64(11), messages[0].Offset())
+	assert.Equal(t, []byte{11}, messages[0].Value())
+	assert.Equal(t, int64(12), messages[1].Offset())
+	for _, msg := range messages {
+		assert.NoError(t, msg.Ack())
+	}
+	c.Stop()
+	assert.Equal(t, int64(12), c.persistedAckLevel)
+
+	_, ok := <-c.Messages()
+	assert.False(t, ok)
+}
+
+func TestConsumerStartFailure(t *testing.T) {
+	c, mockQueueManager, _, _ := setupConsumer(t)
+	mockQueueManager.EXPECT().GetAckLevels(gomock.Any()).Return(nil, errors.New("get ack levels error"))
+
+	assert.Error(t, c.Start())
+}
+
+func TestConsumerReadMessages(t *testing.T) {
+	c, mockQueueManager, _, _ := setupConsumer(t)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), testBatchSize).Return(queueMessages(1, 2, 3), nil)
+
+	c.readMessages()
+	messages := receiveMessages(t, c, 3)
+	for i, msg := range messages {
+		assert.Equal(t, int64(i+1), msg.Offset())
+	}
+
+	// no more messages are read until the inflight messages are completed
+	c.readMessages()
+
+	assert.NoError(t, messages[1].Ack())
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(3), 1).Return(nil, nil)
+	c.readMessages()
+}
+
+func TestConsumerAckLevel(t *testing.T) {
+	c, mockQueueManager, _, _ := setupConsumer(t)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), testBatchSize).Return(queueMessages(1, 2, 3), nil)
+	c.readMessages()
+	messages := receiveMessages(t, c, 3)
+
+	// the ack level is right before the first message read
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), int64(0), testAckLevelKey).Return(nil)
+	c.updateAckLevel(c.ctx)
+
+	// the ack level is not moved until all the messages before it are completed
+	assert.NoError(t, messages[1].Ack())
+	assert.NoError(t, messages[2].Ack())
+	c.updateAckLevel(c.ctx)
+
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), int64(3), testAckLevelKey).Return(nil)
+	assert.NoError(t, messages[0].Ack())
+	c.updateAckLevel(c.ctx)
+
+	// the ack level is only updated when it's moved
+	c.updateAckLevel(c.ctx)
+}
+
+func TestConsumerNack(t *testing.T) {
+	c, mockQueueManager, _, _ := setupConsumer(t)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), testBatchSize).Return(queueMessages(1), nil)
+	c.readMessages()
+	messages := receiveMessages(t, c, 1)
+
+	mockQueueManager.EXPECT().EnqueueMessageToDLQ(gomock.Any(), encodePayload(testQueueName, []byte{1})).Return(nil)
+	assert.NoError(t, messages[0].Nack())
+
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), int64(1), testAckLevelKey).Return(nil)
+	c.updateAckLevel(c.ctx)
+}
+
+func TestConsumerVisibilityTimeout(t *testing.T) {
+	c, mockQueueManager, _, timeSource := setupConsumer(t)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), testBatchSize).Return(queueMessages(1, 2), nil)
+	c.readMessages()
+	messages := receiveMessages(t, c, 2)
+	assert.NoError(t, messages[1].Ack())
+
+	c.redeliverExpiredMessages()
+	assert.Len(t, c.Messages(), 0)
+
+	// only the message that is not completed is delivered again
+	timeSource.Advance(testVisibilityTimeout)
+	c.redeliverExpiredMessages()
+	redelivered := receiveMessages(t, c, 1)
+	assert.Equal(t, int64(1), redelivered[0].Offset())
+
+	// completing the message more than once is a no-op
+	assert.NoError(t, redelivered[0].Ack())
+	assert.NoError(t, messages[0].Nack())
+
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), int64(2), testAckLevelKey).Return(nil)
+	c.updateAckLevel(c.ctx)
+}
+
+func TestConsumerPurgeAckedMessages(t *testing.T) {
+	c, mockQueueManager, _, timeSource := setupConsumer(t)
+
+	// nothing is purged before the purge interval
+	c.purgeAckedMessages()
+
+	timeSource.Advance(purgeInterval)
+	mockQueueManager.EXPECT().GetAckLevels(gomock.Any()).Return(map[string]int64{testAckLevelKey: 5, "other": 3}, nil)
+	mockQueueManager.EXPECT().DeleteMessagesBefore(gomock.Any(), int64(3)).Return(nil)
+	c.purgeAckedMessages()
+}
+
+func TestConsumerSkipsMessagesOfOtherQueues(t *testing.T) {
+	c, mockQueueManager, _, _ := setupConsumer(t)
+	messages := queueMessages(1, 3)
+	messages = append(messages[:1], append(p.QueueMessageList{
+		{ID: 2, QueueType: p.AsyncWorkflowQueueTypeOf(testQueueName), Payload: encodePayload("other-queue", []byte{2})},
+	}, messages[1:]...)...)
+	messages = append(messages, &p.QueueMessage{ID: 4, QueueType: p.AsyncWorkflowQueueTypeOf(testQueueName), Payload: []byte{0xff}})
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), testBatchSize).Return(messages, nil)
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(4), 1).Return(nil, nil)
+
+	c.readMessages()
+	delivered := receiveMessages(t, c, 2)
+	assert.Equal(t, int64(1), delivered[0].Offset())
+	assert.Equal(t, int64(3), delivered[1].Offset())
+	assert.Equal(t, []byte{3}, delivered[1].Value())
+
+	// the skipped messages don't hold back the ack level
+	assert.NoError(t, delivered[0].Ack())
+	assert.NoError(t, delivered[1].Ack())
+	mockQueueManager.EXPECT().UpdateAckLevel(gomock.Any(), int64(4), testAckLevelKey).Return(nil)
+	c.updateAckLevel(c.ctx)
+}
+
+func TestConsumerOwnership(t *testing.T) {
+	c, mockQueueManager, mockResolver, _ := setupConsumer(t)
+
+	// the queue is not consumed when it's owned by another host
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(otherHost, nil)
+	assert.False(t, c.refreshOwnership())
+
+	// the ack level is loaded when the ownership is acquired
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(testHost, nil)
+	mockQueueManager.EXPECT().GetAckLevels(gomock.Any()).Return(map[string]int64{testAckLevelKey: 5}, nil)
+	assert.True(t, c.refreshOwnership())
+	assert.Equal(t, int64(5), c.persistedAckLevel)
+
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(5), testBatchSize).Return(queueMessages(6), nil)
+	c.readMessages()
+	messages := receiveMessages(t, c, 1)
+
+	// the ownership is kept when the lookup fails
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(membership.HostInfo{}, errors.New("lookup error"))
+	assert.True(t, c.refreshOwnership())
+
+	// the queue is not consumed after the ownership is lost
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(otherHost, nil)
+	assert.False(t, c.refreshOwnership())
+
+	// the messages delivered before the ownership is acquired again are read again
+	mockResolver.EXPECT().Lookup(service.Worker, testQueueID).Return(testHost, nil)
+	mockQueueManager.EXPECT().GetAckLevels(gomock.Any()).Return(map[string]int64{testAckLevelKey: 5}, nil)
+	assert.True(t, c.refreshOwnership())
+	assert.NoError(t, messages[0].Ack())
+	mockQueueManager.EXPECT().ReadMessages(gomock.Any(), int64(5), testBatchSize).Return(queueMessages(6), nil)
+	c.readMessages()
+	redelivered := receiveMessages(t, c, 1)
+	assert.Equal(t, int64(6), redelivered[0].Offset())
+}
@@ -0,0 +1,49 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedPayload = errors.New("malformed persistence queue payload")
+
+// encodePayload prefixes the message with the name of the queue it is published to,
+// since the persistence backed queues whose names collide share the same queue type in the database
+func encodePayload(queueName string, message []byte) []byte {
+	payload := make([]byte, 0, binary.MaxVarintLen64+len(queueName)+len(message))
+	payload = binary.AppendUvarint(payload, uint64(len(queueName)))
+	payload = append(payload, queueName...)
+	return append(payload, message...)
+}
+
+// decodePayload returns the name of the queue and the message encoded by encodePayload
+func decodePayload(payload []byte) (string, []byte, error) {
+	nameLen, n := binary.Uvarint(payload)
+	if n <= 0 || uint64(len(payload)-n) < nameLen {
+		return "", nil, errMalformedPayload
+	}
+	payload = payload[n:]
+	return string(payload[:nameLen]), payload[nameLen:], nil
+}
@@ -0,0 +1,91 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/.gen/go/sqlblobs"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/log/testlogger"
+	p "github.com/uber/cadence/common/persistence"
+)
+
+func TestProducerPublish(t *testing.T) {
+	message := &sqlblobs.AsyncRequestMessage{
+		PartitionKey: common.StringPtr("test-partition-key"),
+	}
+	payload, err := codec.NewThriftRWEncoder().Encode(message)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		msg       interface{}
+		mockSetup func(*p.MockQueueManager)
+		wantErr   bool
+	}{
+		{
+			name: "success",
+			msg:  message,
+			mockSetup: func(m *p.MockQueueManager) {
+				m.EXPECT().EnqueueMessage(gomock.Any(), encodePayload("test-queue", payload)).Return(nil)
+			},
+		},
+		{
+			name:      "unknown message type",
+			msg:       "test",
+			mockSetup: func(m *p.MockQueueManager) {},
+			wantErr:   true,
+		},
+		{
+			name: "enqueue failure",
+			msg:  message,
+			mockSetup: func(m *p.MockQueueManager) {
+				m.EXPECT().EnqueueMessage(gomock.Any(), encodePayload("test-queue", payload)).Return(errors.New("enqueue error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockQueueManager := p.NewMockQueueManager(ctrl)
+			tt.mockSetup(mockQueueManager)
+
+			producer := newProducer("test-queue", mockQueueManager, testlogger.New(t))
+			err := producer.Publish(context.Background(), tt.msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uber/cadence/common/asyncworkflow/queue/consumer"
+	"github.com/uber/cadence/common/asyncworkflow/queue/provider"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/messaging"
+)
+
+type (
+	queueImpl struct {
+		config *queueConfig
+	}
+)
+
+var (
+	errNoQueueManager       = errors.New("persistence queue manager is not provided")
+	errNoMembershipResolver = errors.New("membership resolver is not provided")
+)
+
+func newQueue(decoder provider.Decoder) (provider.Queue, error) {
+	var out queueConfig
+	if err := decoder.Decode(&out); err != nil {
+		return nil, fmt.Errorf("bad config: %w", err)
+	}
+	return &queueImpl{
+		config: &out,
+	}, nil
+}
+
+func (q *queueImpl) ID() string {
+	return q.config.ID()
+}
+
+func (q *queueImpl) CreateConsumer(p *provider.Params) (provider.Consumer, error) {
+	if p.QueueManagerFn == nil {
+		return nil, errNoQueueManager
+	}
+	if p.MembershipResolver == nil {
+		return nil, errNoMembershipResolver
+	}
+	hostInfo, err := p.MembershipResolver.WhoAmI()
+	if err != nil {
+		return nil, err
+	}
+	queueManager, err := p.QueueManagerFn(q.config.name())
+	if err != nil {
+		return nil, err
+	}
+	persistenceConsumer := newConsumer(
+		q.config.name(),
+		q.ID(),
+		queueManager,
+		p.MembershipResolver,
+		hostInfo,
+		q.config.batchSize(),
+		q.config.pollInterval(),
+		q.config.visibilityTimeout(),
+		clock.NewRealTimeSource(),
+		p.Logger,
+	)
+	return consumer.New(q.ID(), persistenceConsumer, p.Logger, p.MetricsClient, p.FrontendClient), nil
+}
+
+func (q *queueImpl) CreateProducer(p *provider.Params) (messaging.Producer, error) {
+	if p.QueueManagerFn == nil {
+		return nil, errNoQueueManager
+	}
+	queueManager, err := p.QueueManagerFn(q.config.name())
+	if err != nil {
+		return nil, err
+	}
+	return messaging.NewMetricProducer(newProducer(q.config.name(), queueManager, p.Logger), p.MetricsClient), nil
+}
@@ -0,0 +1,200 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common/asyncworkflow/queue/provider"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/metrics"
+	p "github.com/uber/cadence/common/persistence"
+)
+
+type MockDecoder struct {
+	DecodeFunc func(v any) error
+}
+
+func (m *MockDecoder) Decode(v any) error {
+	return m.DecodeFunc(v)
+}
+
+func TestNewQueue(t *testing.T) {
+	tests := []struct {
+		name      string
+		decoder   *MockDecoder
+		want      *queueImpl
+		wantErr   bool
+		errString string
+	}{
+		{
+			name: "successful decoding",
+			decoder: &MockDecoder{
+				DecodeFunc: func(v any) error {
+					out := v.(*queueConfig)
+					out.BatchSize = 10
+					return nil
+				},
+			},
+			want: &queueImpl{
+				config: &queueConfig{
+					BatchSize: 10,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "decoding failure",
+			decoder: &MockDecoder{
+				DecodeFunc: func(v any) error {
+					return errors.New("decoding error")
+				},
+			},
+			want:      nil,
+			wantErr:   true,
+			errString: "bad config: decoding error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newQueue(tt.decoder)
+			if tt.wantErr {
+				assert.EqualError(t, err, tt.errString)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, "persistence::default", got.ID())
+			}
+		})
+	}
+}
+
+func TestCreateConsumer(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		hasQueueManager       bool
+		hasMembershipResolver bool
+		wantErr               error
+	}{
+		{
+			name:                  "Success case",
+			hasQueueManager:       true,
+			hasMembershipResolver: true,
+		},
+		{
+			name:                  "No queue manager",
+			hasQueueManager:       false,
+			hasMembershipResolver: true,
+			wantErr:               errNoQueueManager,
+		},
+		{
+			name:                  "No membership resolver",
+			hasQueueManager:       true,
+			hasMembershipResolver: false,
+			wantErr:               errNoMembershipResolver,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			q := &queueImpl{
+				config: &queueConfig{Name: "test-queue"},
+			}
+			params := &provider.Params{
+				Logger:        testlogger.New(t),
+				MetricsClient: metrics.NewNoopMetricsClient(),
+			}
+			if tc.hasQueueManager {
+				params.QueueManagerFn = func(queueName string) (p.QueueManager, error) {
+					// each queue is stored apart from the others
+					assert.Equal(t, "test-queue", queueName)
+					return p.NewMockQueueManager(ctrl), nil
+				}
+			}
+			if tc.hasMembershipResolver {
+				mockResolver := membership.NewMockResolver(ctrl)
+				mockResolver.EXPECT().WhoAmI().Return(membership.NewHostInfo("127.0.0.1:7939"), nil).MaxTimes(1)
+				params.MembershipResolver = mockResolver
+			}
+			consumer, err := q.CreateConsumer(params)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, consumer)
+			}
+		})
+	}
+}
+
+func TestCreateProducer(t *testing.T) {
+	testCases := []struct {
+		name            string
+		hasQueueManager bool
+		wantErr         bool
+	}{
+		{
+			name:            "Success case",
+			hasQueueManager: true,
+		},
+		{
+			name:            "No queue manager",
+			hasQueueManager: false,
+			wantErr:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			q := &queueImpl{
+				config: &queueConfig{Name: "test-queue"},
+			}
+			params := &provider.Params{
+				Logger:        testlogger.New(t),
+				MetricsClient: metrics.NewNoopMetricsClient(),
+			}
+			if tc.hasQueueManager {
+				params.QueueManagerFn = func(queueName string) (p.QueueManager, error) {
+					// each queue is stored apart from the others
+					assert.Equal(t, "test-queue", queueName)
+					return p.NewMockQueueManager(ctrl), nil
+				}
+			}
+			producer, err := q.CreateProducer(params)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, errNoQueueManager)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, producer)
+			}
+		})
+	}
+}
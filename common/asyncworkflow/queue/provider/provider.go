@@ -29,8 +29,10 @@ import (
 
 	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/membership"
 	"github.com/uber/cadence/common/messaging"
 	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/syncmap"
 	"github.com/uber/cadence/common/types"
 )
@@ -40,8 +42,15 @@ type (
 		Logger         log.Logger
 		MetricsClient  metrics.Client
 		FrontendClient frontend.Client
+		// QueueManagerFn returns the queue in the persistence store, used by the queues that are backed by the database
+		QueueManagerFn QueueManagerFn
+		// MembershipResolver is used by the queues whose consumers must run on a single host
+		MembershipResolver membership.Resolver
 	}
 
+	// QueueManagerFn returns the queue in the persistence store of a queue name
+	QueueManagerFn func(queueName string) (persistence.QueueManager, error)
+
 	Decoder interface {
 		Decode(any) error
 	}
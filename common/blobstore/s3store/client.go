@@ -0,0 +1,220 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package s3store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/multierr"
+
+	"github.com/uber/cadence/common/blobstore"
+	"github.com/uber/cadence/common/config"
+)
+
+const (
+	// tagsMetadataKey is the key of the object metadata that the tags of the blob are stored in.
+	// The tags are encoded as base64 JSON, since the metadata is sent as HTTP headers.
+	tagsMetadataKey = "Cadence-Tags"
+)
+
+type (
+	client struct {
+		s3cli     s3iface.S3API
+		bucket    string
+		keyPrefix string
+	}
+)
+
+// NewS3Client constructs a blobstore backed by an S3 compatible API
+func NewS3Client(cfg *config.S3Blobstore) (blobstore.Client, error) {
+	if cfg == nil {
+		return nil, errors.New("s3 blobstore config is nil")
+	}
+	if len(cfg.Bucket) == 0 {
+		return nil, errors.New("bucket not given for s3 blobstore")
+	}
+	if len(cfg.Region) == 0 {
+		return nil, errors.New("region not given for s3 blobstore")
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         cfg.Endpoint,
+		Region:           aws.String(cfg.Region),
+		S3ForcePathStyle: aws.Bool(cfg.S3ForcePathStyle),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newClient(s3.New(sess), cfg.Bucket, cfg.KeyPrefix), nil
+}
+
+func newClient(s3cli s3iface.S3API, bucket string, keyPrefix string) *client {
+	return &client{
+		s3cli:     s3cli,
+		bucket:    bucket,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// Put stores a blob
+func (c *client) Put(ctx context.Context, request *blobstore.PutRequest) (*blobstore.PutResponse, error) {
+	tagsData, err := json.Marshal(request.Blob.Tags)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.s3cli.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.objectKey(request.Key)),
+		Body:   bytes.NewReader(request.Blob.Body),
+		Metadata: map[string]*string{
+			tagsMetadataKey: aws.String(base64.StdEncoding.EncodeToString(tagsData)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &blobstore.PutResponse{}, nil
+}
+
+// Get fetches a blob
+func (c *client) Get(ctx context.Context, request *blobstore.GetRequest) (resp *blobstore.GetResponse, err error) {
+	result, err := c.s3cli.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.objectKey(request.Key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if ierr := result.Body.Close(); ierr != nil {
+			err = multierr.Append(err, ierr)
+		}
+	}()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := decodeTags(result.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &blobstore.GetResponse{
+		Blob: blobstore.Blob{
+			Body: data,
+			Tags: tags,
+		},
+	}, nil
+}
+
+// Exists determines if a blob exists
+func (c *client) Exists(ctx context.Context, request *blobstore.ExistsRequest) (*blobstore.ExistsResponse, error) {
+	_, err := c.s3cli.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.objectKey(request.Key)),
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return &blobstore.ExistsResponse{
+				Exists: false,
+			}, nil
+		}
+		return nil, err
+	}
+	return &blobstore.ExistsResponse{
+		Exists: true,
+	}, nil
+}
+
+// Delete deletes a blob
+func (c *client) Delete(ctx context.Context, request *blobstore.DeleteRequest) (*blobstore.DeleteResponse, error) {
+	_, err := c.s3cli.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.objectKey(request.Key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &blobstore.DeleteResponse{}, nil
+}
+
+// IsRetryableError returns true if the error is retryable false otherwise
+func (c *client) IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return isStatusCodeRetryable(aerr) || request.IsErrorRetryable(aerr) || request.IsErrorThrottle(aerr)
+	}
+	return false
+}
+
+func (c *client) objectKey(key string) string {
+	return c.keyPrefix + key
+}
+
+func decodeTags(metadata map[string]*string) (map[string]string, error) {
+	tags := make(map[string]string)
+	encodedTags := aws.StringValue(metadata[tagsMetadataKey])
+	if len(encodedTags) == 0 {
+		return tags, nil
+	}
+	tagsData, err := base64.StdEncoding.DecodeString(encodedTags)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(tagsData, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func isNotFoundError(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey)
+}
+
+func isStatusCodeRetryable(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		if rerr, ok := err.(awserr.RequestFailure); ok {
+			if rerr.StatusCode() == 429 {
+				return true
+			}
+			if rerr.StatusCode() >= 500 && rerr.StatusCode() != 501 {
+				return true
+			}
+		}
+		return isStatusCodeRetryable(aerr.OrigErr())
+	}
+	return false
+}
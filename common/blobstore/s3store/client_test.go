@@ -0,0 +1,235 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package s3store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/blobstore"
+	"github.com/uber/cadence/common/config"
+)
+
+const (
+	testBucket    = "test-bucket"
+	testKeyPrefix = "scanner/"
+)
+
+type (
+	ClientSuite struct {
+		*require.Assertions
+		suite.Suite
+
+		s3cli  *fakeS3
+		client *client
+	}
+
+	// fakeS3 is an in memory fake of the subset of the S3 API used by the client
+	fakeS3 struct {
+		s3iface.S3API
+
+		objects map[string]*fakeObject
+		// errs are returned by the next calls before the calls are handled
+		errs []error
+	}
+
+	fakeObject struct {
+		body     []byte
+		metadata map[string]*string
+	}
+)
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(ClientSuite))
+}
+
+func (s *ClientSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.s3cli = &fakeS3{objects: make(map[string]*fakeObject)}
+	s.client = newClient(s.s3cli, testBucket, testKeyPrefix)
+}
+
+func (s *ClientSuite) TestNewS3Client_InvalidConfig() {
+	_, err := NewS3Client(nil)
+	s.Error(err)
+	_, err = NewS3Client(&config.S3Blobstore{Region: "us-east-1"})
+	s.Error(err)
+	_, err = NewS3Client(&config.S3Blobstore{Bucket: testBucket})
+	s.Error(err)
+}
+
+func (s *ClientSuite) TestNewS3Client() {
+	c, err := NewS3Client(&config.S3Blobstore{
+		Bucket:           testBucket,
+		KeyPrefix:        testKeyPrefix,
+		Region:           "us-east-1",
+		Endpoint:         aws.String("http://127.0.0.1:9000"),
+		S3ForcePathStyle: true,
+	})
+	s.NoError(err)
+	s.Equal(testBucket, c.(*client).bucket)
+	s.Equal(testKeyPrefix, c.(*client).keyPrefix)
+}
+
+func (s *ClientSuite) TestCrudOperations() {
+	ctx := context.Background()
+	key := "test-key"
+
+	existsResp, err := s.client.Exists(ctx, &blobstore.ExistsRequest{Key: key})
+	s.NoError(err)
+	s.False(existsResp.Exists)
+	_, err = s.client.Get(ctx, &blobstore.GetRequest{Key: key})
+	s.Error(err)
+
+	blob := blobstore.Blob{
+		Tags: map[string]string{"key1": "value1", "Key2": "välue2"},
+		Body: []byte("test body"),
+	}
+	_, err = s.client.Put(ctx, &blobstore.PutRequest{Key: key, Blob: blob})
+	s.NoError(err)
+	s.Contains(s.s3cli.objects, testKeyPrefix+key)
+
+	existsResp, err = s.client.Exists(ctx, &blobstore.ExistsRequest{Key: key})
+	s.NoError(err)
+	s.True(existsResp.Exists)
+	getResp, err := s.client.Get(ctx, &blobstore.GetRequest{Key: key})
+	s.NoError(err)
+	s.Equal(blob, getResp.Blob)
+
+	_, err = s.client.Delete(ctx, &blobstore.DeleteRequest{Key: key})
+	s.NoError(err)
+	existsResp, err = s.client.Exists(ctx, &blobstore.ExistsRequest{Key: key})
+	s.NoError(err)
+	s.False(existsResp.Exists)
+}
+
+func (s *ClientSuite) TestGet_NoTags() {
+	s.s3cli.objects[testKeyPrefix+"test-key"] = &fakeObject{body: []byte("test body")}
+
+	getResp, err := s.client.Get(context.Background(), &blobstore.GetRequest{Key: "test-key"})
+	s.NoError(err)
+	s.Equal([]byte("test body"), getResp.Blob.Body)
+	s.Empty(getResp.Blob.Tags)
+}
+
+func (s *ClientSuite) TestRetryableClient() {
+	ctx := context.Background()
+	policy := backoff.NewExponentialRetryPolicy(time.Millisecond)
+	policy.SetMaximumAttempts(3)
+	retryableClient := blobstore.NewRetryableClient(s.client, policy)
+
+	s.s3cli.errs = []error{newRequestFailure(503), newRequestFailure(500)}
+	_, err := retryableClient.Put(ctx, &blobstore.PutRequest{Key: "test-key", Blob: blobstore.Blob{Body: []byte("test body")}})
+	s.NoError(err)
+	s.Empty(s.s3cli.errs)
+
+	s.s3cli.errs = []error{newRequestFailure(403)}
+	_, err = retryableClient.Get(ctx, &blobstore.GetRequest{Key: "test-key"})
+	s.Error(err)
+	s.Empty(s.s3cli.errs)
+}
+
+func (s *ClientSuite) TestIsRetryableError() {
+	s.False(s.client.IsRetryableError(nil))
+	s.False(s.client.IsRetryableError(errors.New("some error")))
+	s.False(s.client.IsRetryableError(awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)))
+	s.False(s.client.IsRetryableError(newRequestFailure(403)))
+	s.False(s.client.IsRetryableError(newRequestFailure(501)))
+	s.True(s.client.IsRetryableError(newRequestFailure(429)))
+	s.True(s.client.IsRetryableError(newRequestFailure(500)))
+	s.True(s.client.IsRetryableError(newRequestFailure(503)))
+	s.True(s.client.IsRetryableError(awserr.New(request.ErrCodeRequestError, "connection reset", nil)))
+}
+
+func newRequestFailure(statusCode int) error {
+	return awserr.NewRequestFailure(awserr.New("TestError", "test error", nil), statusCode, "request-id")
+}
+
+func (f *fakeS3) nextError() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeS3) PutObjectWithContext(_ aws.Context, input *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	if err := f.nextError(); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[aws.StringValue(input.Key)] = &fakeObject{body: body, metadata: input.Metadata}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(_ aws.Context, input *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	if err := f.nextError(); err != nil {
+		return nil, err
+	}
+	object, ok := f.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+	return &s3.GetObjectOutput{
+		Body:     io.NopCloser(bytes.NewReader(object.body)),
+		Metadata: object.metadata,
+	}, nil
+}
+
+func (f *fakeS3) HeadObjectWithContext(_ aws.Context, input *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	if err := f.nextError(); err != nil {
+		return nil, err
+	}
+	object, ok := f.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NotFound", "not found", nil)
+	}
+	return &s3.HeadObjectOutput{
+		Metadata: object.metadata,
+	}, nil
+}
+
+func (f *fakeS3) DeleteObjectWithContext(_ aws.Context, input *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+	if err := f.nextError(); err != nil {
+		return nil, err
+	}
+	delete(f.objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
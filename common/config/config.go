@@ -34,7 +34,10 @@ import (
 
 	"github.com/uber/cadence/common/dynamicconfig"
 	c "github.com/uber/cadence/common/dynamicconfig/configstore/config"
+	ec "github.com/uber/cadence/common/dynamicconfig/etcdconfig"
+	"github.com/uber/cadence/common/peerprovider/dnsprovider"
 	"github.com/uber/cadence/common/peerprovider/ringpopprovider"
+	"github.com/uber/cadence/common/peerprovider/staticprovider"
 	"github.com/uber/cadence/common/service"
 )
 
@@ -43,6 +46,8 @@ type (
 	Config struct {
 		// Ringpop is the ringpop related configuration
 		Ringpop ringpopprovider.Config `yaml:"ringpop"`
+		// PeerProvider is the config for membership discovery, ringpop is used if the type is not set
+		PeerProvider PeerProvider `yaml:"peerProvider"`
 		// Persistence contains the configuration for cadence datastores
 		Persistence Persistence `yaml:"persistence"`
 		// Log is the logging config
@@ -84,6 +89,16 @@ type (
 		AsyncWorkflowQueues map[string]AsyncWorkflowQueueProvider `yaml:"asyncWorkflowQueues"`
 	}
 
+	// PeerProvider contains the config of the peer provider used for membership discovery
+	PeerProvider struct {
+		// Type is the type of the peer provider, one of ringpop (default), static or dns
+		Type string `yaml:"type"`
+		// Static is the config of the static peer provider
+		Static staticprovider.Config `yaml:"static"`
+		// DNS is the config of the DNS peer provider
+		DNS dnsprovider.Config `yaml:"dns"`
+	}
+
 	HeaderRule struct {
 		Add   bool // if false, matching headers are removed if previously matched.
 		Match *regexp.Regexp
@@ -93,6 +108,7 @@ type (
 		Client      string                              `yaml:"client"`
 		ConfigStore c.ClientConfig                      `yaml:"configstore"`
 		FileBased   dynamicconfig.FileBasedClientConfig `yaml:"filebased"`
+		Etcd        ec.ClientConfig                     `yaml:"etcd"`
 	}
 
 	// Service contains the service specific config items
@@ -153,6 +169,8 @@ type (
 	// Blobstore contains the config for blobstore
 	Blobstore struct {
 		Filestore *FileBlobstore `yaml:"filestore"`
+		// S3 takes precedence over Filestore if both are configured
+		S3 *S3Blobstore `yaml:"s3"`
 	}
 
 	// FileBlobstore contains the config for a file backed blobstore
@@ -160,6 +178,18 @@ type (
 		OutputDirectory string `yaml:"outputDirectory"`
 	}
 
+	// S3Blobstore contains the config for a blobstore backed by an S3 compatible API
+	S3Blobstore struct {
+		// Bucket is the name of the bucket the blobs are stored in, it must exist already
+		Bucket string `yaml:"bucket"`
+		// KeyPrefix is prepended to the keys of all the blobs, so that a bucket can be shared (optional)
+		KeyPrefix string `yaml:"keyPrefix"`
+		Region    string `yaml:"region"`
+		// Endpoint overrides the default AWS endpoint, e.g. for MinIO (optional)
+		Endpoint         *string `yaml:"endpoint"`
+		S3ForcePathStyle bool    `yaml:"s3ForcePathStyle"`
+	}
+
 	// Persistence contains the configuration for data store / persistence layer
 	Persistence struct {
 		// DefaultStore is the name of the default data store to use
@@ -181,6 +211,8 @@ type (
 		NumHistoryShards int `yaml:"numHistoryShards" validate:"nonzero"`
 		// DataStores contains the configuration for all datastores
 		DataStores map[string]DataStore `yaml:"datastores"`
+		// Encryption contains the configuration of the encryption at rest of workflow payloads (optional)
+		Encryption *Encryption `yaml:"encryption"`
 		// TODO: move dynamic config out of static config
 		// TransactionSizeLimit is the largest allowed transaction size
 		TransactionSizeLimit dynamicconfig.IntPropertyFn `yaml:"-" json:"-"`
@@ -189,6 +221,18 @@ type (
 		ErrorInjectionRate dynamicconfig.FloatPropertyFn `yaml:"-" json:"-"`
 	}
 
+	// Encryption is the configuration of the envelope encryption of workflow payloads, such as inputs, results,
+	// signal inputs, heartbeat details and memos, in history and mutable state
+	Encryption struct {
+		// Enabled is to encrypt the payloads which are written. Payloads which are already encrypted are
+		// decrypted regardless, so that encryption can be disabled without losing access to them
+		Enabled bool `yaml:"enabled"`
+		// KeyFile is the path of the file containing the master keys, see encryption.KeyFile for its format
+		KeyFile string `yaml:"keyFile"`
+		// DataKeyRotationInterval is the age after which the data key of a domain is replaced (optional, default to 24h)
+		DataKeyRotationInterval time.Duration `yaml:"dataKeyRotationInterval"`
+	}
+
 	// DataStore is the configuration for a single datastore
 	DataStore struct {
 		// Cassandra contains the config for a cassandra datastore
@@ -300,7 +344,7 @@ type (
 		DatabaseName string `yaml:"databaseName"`
 		// ConnectAddr is the remote addr of the database
 		// If useMultipleDatabases, must be empty and provide it via multipleDatabasesConfig instead
-		// Required if not useMultipleDatabases
+		// Required if not useMultipleDatabases, except for sqlite which is an embedded database
 		ConnectAddr string `yaml:"connectAddr"`
 		// ConnectProtocol is the protocol that goes with the ConnectAddr ex - tcp, unix
 		ConnectProtocol string `yaml:"connectProtocol" validate:"nonzero"`
@@ -485,6 +529,7 @@ type (
 	//  - FilestoreConfig: [*FilestoreArchiver], used with provider scheme [github.com/uber/cadence/common/archiver/filestore.URIScheme]
 	//  - S3storeConfig: [*S3Archiver], used with provider scheme [github.com/uber/cadence/common/archiver/s3store.URIScheme]
 	//  - "gstorage" via [github.com/uber/cadence/common/archiver/gcloud.ConfigKey]: [github.com/uber/cadence/common/archiver/gcloud.Config], used with provider scheme "gs" [github.com/uber/cadence/common/archiver/gcloud.URIScheme]
+	//  - "azblob" via [github.com/uber/cadence/common/archiver/azureblob.ConfigKey]: [github.com/uber/cadence/common/archiver/azureblob.Config], used with provider scheme "azblob" [github.com/uber/cadence/common/archiver/azureblob.URIScheme]
 	//
 	// For handling hardcoded config, see ToYamlNode.
 	HistoryArchiverProvider map[string]*YamlNode
@@ -509,6 +554,7 @@ type (
 	//  - FilestoreConfig: [*FilestoreArchiver], used with provider scheme [github.com/uber/cadence/common/archiver/filestore.URIScheme]
 	//  - S3storeConfig: [*S3Archiver], used with provider scheme [github.com/uber/cadence/common/archiver/s3store.URIScheme]
 	//  - "gstorage" via [github.com/uber/cadence/common/archiver/gcloud.ConfigKey]: [github.com/uber/cadence/common/archiver/gcloud.Config], used with provider scheme "gs" [github.com/uber/cadence/common/archiver/gcloud.URIScheme]
+	//  - "azblob" via [github.com/uber/cadence/common/archiver/azureblob.ConfigKey]: [github.com/uber/cadence/common/archiver/azureblob.Config], used with provider scheme "azblob" [github.com/uber/cadence/common/archiver/azureblob.URIScheme]
 	//
 	// For handling hardcoded config, see ToYamlNode.
 	VisibilityArchiverProvider map[string]*YamlNode
@@ -517,6 +563,8 @@ type (
 	FilestoreArchiver struct {
 		FileMode string `yaml:"fileMode"`
 		DirMode  string `yaml:"dirMode"`
+		// ColumnarVisibility enables the columnar format for the visibility archiver (optional)
+		ColumnarVisibility *ColumnarVisibilityArchiver `yaml:"columnarVisibility"`
 	}
 
 	// S3Archiver contains the config for S3 archiver
@@ -524,6 +572,19 @@ type (
 		Region           string  `yaml:"region"`
 		Endpoint         *string `yaml:"endpoint"`
 		S3ForcePathStyle bool    `yaml:"s3ForcePathStyle"`
+		// ColumnarVisibility enables the columnar format for the visibility archiver (optional)
+		ColumnarVisibility *ColumnarVisibilityArchiver `yaml:"columnarVisibility"`
+	}
+
+	// ColumnarVisibilityArchiver contains the config for archiving visibility records in the columnar format.
+	// Records are batched into compressed files with one column per field, partitioned by domain and close day,
+	// instead of being written to one file per record. Records archived with the default format are not
+	// visible to the queries of the columnar format, so it should be enabled with a new archival URI.
+	ColumnarVisibilityArchiver struct {
+		// FlushInterval is the time after which the staged records of a batch are compacted into a single file (optional, default to 1s)
+		FlushInterval time.Duration `yaml:"flushInterval"`
+		// MaxBatchSize is the number of staged records after which a batch is compacted right away (optional, default to 1000)
+		MaxBatchSize int `yaml:"maxBatchSize"`
 	}
 
 	// PublicClient is config for connecting to cadence frontend
@@ -593,6 +654,13 @@ const (
 
 	FilestoreConfig = "filestore"
 	S3storeConfig   = "s3store"
+
+	// PeerProviderTypeRingpop is the peer provider type of ringpop, which is the default
+	PeerProviderTypeRingpop = "ringpop"
+	// PeerProviderTypeStatic is the peer provider type of a static host list
+	PeerProviderTypeStatic = "static"
+	// PeerProviderTypeDNS is the peer provider type of hosts resolved from DNS
+	PeerProviderTypeDNS = "dns"
 )
 
 var _ yaml.Unmarshaler = (*YamlNode)(nil)
@@ -651,10 +719,22 @@ func (c *Config) validate() error {
 	if err := c.Archival.Validate(&c.DomainDefaults.Archival); err != nil {
 		return err
 	}
+	if err := c.PeerProvider.Validate(); err != nil {
+		return err
+	}
 
 	return c.Authorization.Validate()
 }
 
+// Validate validates the peer provider type
+func (p *PeerProvider) Validate() error {
+	switch p.Type {
+	case "", PeerProviderTypeRingpop, PeerProviderTypeStatic, PeerProviderTypeDNS:
+		return nil
+	}
+	return fmt.Errorf("unknown peer provider type %q", p.Type)
+}
+
 func (c *Config) fillDefaults() {
 	c.Persistence.FillDefaults()
 
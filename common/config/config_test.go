@@ -159,6 +159,32 @@ func TestInvalidMultipleDatabaseConfig_nonEmptySQLConnAddr(t *testing.T) {
 	require.EqualError(t, err, "sql persistence config: connectAddr can only be configured in multipleDatabasesConfig when UseMultipleDatabases is true")
 }
 
+func TestValidSQLiteConfigWithoutConnAddr(t *testing.T) {
+	cfg := &Config{
+		ClusterGroupMetadata: validClusterGroupMetadata(),
+		Persistence: Persistence{
+			DefaultStore:    "default",
+			VisibilityStore: "default",
+			DataStores: map[string]DataStore{
+				"default": {
+					SQL: &SQL{
+						PluginName:   "sqlite",
+						DatabaseName: ":memory:",
+					},
+				},
+			},
+		},
+	}
+	err := cfg.ValidateAndFillDefaults()
+	require.NoError(t, err)
+
+	sqlds := cfg.Persistence.DataStores["default"]
+	sqlds.SQL.PluginName = "mysql"
+	cfg.Persistence.DataStores["default"] = sqlds
+	err = cfg.ValidateAndFillDefaults()
+	require.EqualError(t, err, "sql persistence config: connectAddr can not be empty")
+}
+
 func TestConfigFallbacks(t *testing.T) {
 	metadata := validClusterGroupMetadata()
 	cfg := &Config{
@@ -387,3 +413,10 @@ func TestInvalidShardedNoSQLConfig_TasklistShardingRefersToUnknownConnection(t *
 	err := cfg.ValidateAndFillDefaults()
 	require.ErrorContains(t, err, "Unknown tasklist shard name")
 }
+
+func TestPeerProviderValidate(t *testing.T) {
+	for _, providerType := range []string{"", PeerProviderTypeRingpop, PeerProviderTypeStatic, PeerProviderTypeDNS} {
+		assert.NoError(t, (&PeerProvider{Type: providerType}).Validate())
+	}
+	assert.EqualError(t, (&PeerProvider{Type: "zookeeper"}).Validate(), `unknown peer provider type "zookeeper"`)
+}
@@ -85,6 +85,10 @@ func (c *Persistence) Validate() error {
 		useAdvancedVisibilityOnly = true
 	}
 
+	if c.Encryption != nil && c.Encryption.KeyFile == "" {
+		return fmt.Errorf("persistence config: encryption: keyFile can not be empty")
+	}
+
 	for _, st := range dbStoreKeys {
 		ds, ok := c.DataStores[st]
 		if !ok {
@@ -138,7 +142,8 @@ func (c *Persistence) Validate() error {
 				if ds.SQL.DatabaseName == "" {
 					return fmt.Errorf("sql persistence config: databaseName can not be empty")
 				}
-				if ds.SQL.ConnectAddr == "" {
+				// sqlite is an embedded database, so it doesn't have a remote address to connect to
+				if ds.SQL.ConnectAddr == "" && ds.SQL.PluginName != "sqlite" {
 					return fmt.Errorf("sql persistence config: connectAddr can not be empty")
 				}
 			}
@@ -66,6 +66,11 @@ const (
 	EncodingTypeUnknown  EncodingType = "unknow"
 	EncodingTypeEmpty    EncodingType = ""
 	EncodingTypeProto    EncodingType = "proto3"
+
+	// EncodingTypeThriftRWSnappy and EncodingTypeThriftRWZstd are ThriftRW payloads compressed with snappy and zstd.
+	// They are only used to store the blobs, the blobs returned by the persistence layer are uncompressed ThriftRW.
+	EncodingTypeThriftRWSnappy EncodingType = "thriftrw+snappy"
+	EncodingTypeThriftRWZstd   EncodingType = "thriftrw+zstd"
 )
 
 type (
@@ -113,6 +118,13 @@ const (
 	// BatcherLocalDomainName is domain name for batcher workflows running in local cluster
 	// Batcher cannot use SystemLocalDomain because auth
 	BatcherLocalDomainName = "cadence-batcher"
+	// SchedulerDomainID is domain id for scheduler local domain
+	SchedulerDomainID = "5a4dd0b4-ad3f-43c6-b0c9-3e5a9aad2c4e"
+	// SchedulerLocalDomainName is domain name for schedule workflows running in local cluster
+	SchedulerLocalDomainName = "cadence-scheduler"
+	// SchedulerWorkflowIDSeparator separates the target domain name from the schedule ID in the workflow IDs of
+	// the scheduler domain. Domain names can't contain it, so the target domain is the part before the first one.
+	SchedulerWorkflowIDSeparator = "/"
 	// ShadowerDomainID is domain id for workflow shadower local domain
 	ShadowerDomainID = "59c51119-1b41-4a28-986d-d6e377716f82"
 	// ShadowerLocalDomainName
@@ -31,6 +31,7 @@ import (
 const (
 	ConfigStoreClient = "configstore"
 	FileBasedClient   = "filebased"
+	EtcdClient        = "etcd"
 	InMemoryClient    = "memory"
 	NopClient         = "nop"
 )
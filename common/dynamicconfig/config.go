@@ -161,6 +161,9 @@ type BoolPropertyFnWithDomainIDFilter func(domainID string) bool
 // BoolPropertyFnWithDomainIDAndWorkflowIDFilter is a wrapper to get bool property from dynamic config with domainID and workflowID as filter
 type BoolPropertyFnWithDomainIDAndWorkflowIDFilter func(domainID string, workflowID string) bool
 
+// BoolPropertyFnWithDomainAndWorkflowIDFilter is a wrapper to get bool property from dynamic config with domain and workflowID as filter
+type BoolPropertyFnWithDomainAndWorkflowIDFilter func(domain string, workflowID string) bool
+
 // BoolPropertyFnWithTaskListInfoFilters is a wrapper to get bool property from dynamic config with three filters: domain, taskList, taskType
 type BoolPropertyFnWithTaskListInfoFilters func(domain string, taskList string, taskType int) bool
 
@@ -170,6 +173,9 @@ type IntPropertyFnWithWorkflowTypeFilter func(domainName string, workflowType st
 // DurationPropertyFnWithDomainFilter is a wrapper to get duration property from dynamic config with domain as filter
 type DurationPropertyFnWithWorkflowTypeFilter func(domainName string, workflowType string) time.Duration
 
+// IntPropertyFnWithActivityTypeFilter is a wrapper to get int property from dynamic config with domain and activity type as filters
+type IntPropertyFnWithActivityTypeFilter func(domainName string, activityType string) int
+
 // ListPropertyFn is a wrapper to get a list property from dynamic config
 type ListPropertyFn func(opts ...FilterOption) []interface{}
 
@@ -240,6 +246,26 @@ func (c *Collection) GetIntPropertyFilteredByWorkflowType(key IntKey) IntPropert
 	}
 }
 
+// GetIntPropertyFilteredByActivityType gets property with activity type filter and asserts that it's an integer
+func (c *Collection) GetIntPropertyFilteredByActivityType(key IntKey) IntPropertyFnWithActivityTypeFilter {
+	return func(domainName string, activityType string) int {
+		filters := c.toFilterMap(
+			DomainFilter(domainName),
+			ActivityTypeFilter(activityType),
+		)
+		val, err := c.client.GetIntValue(
+			key,
+			filters,
+		)
+		if err != nil {
+			c.logError(key, filters, err)
+			return key.DefaultInt()
+		}
+		c.logValue(key, filters, val, key.DefaultValue(), intCompareEquals)
+		return val
+	}
+}
+
 // GetDurationPropertyFilteredByWorkflowType gets property with workflow type filter and asserts that it's a duration
 func (c *Collection) GetDurationPropertyFilteredByWorkflowType(key DurationKey) DurationPropertyFnWithWorkflowTypeFilter {
 	return func(domainName string, workflowType string) time.Duration {
@@ -523,6 +549,23 @@ func (c *Collection) GetBoolPropertyFilteredByDomainID(key BoolKey) BoolProperty
 	}
 }
 
+// GetBoolPropertyFilteredByDomainAndWorkflowID gets property with domain and workflowID filters and asserts that it's a bool
+func (c *Collection) GetBoolPropertyFilteredByDomainAndWorkflowID(key BoolKey) BoolPropertyFnWithDomainAndWorkflowIDFilter {
+	return func(domain string, workflowID string) bool {
+		filters := c.toFilterMap(DomainFilter(domain), WorkflowIDFilter(workflowID))
+		val, err := c.client.GetBoolValue(
+			key,
+			filters,
+		)
+		if err != nil {
+			c.logError(key, filters, err)
+			return key.DefaultBool()
+		}
+		c.logValue(key, filters, val, key.DefaultValue(), boolCompareEquals)
+		return val
+	}
+}
+
 // GetBoolPropertyFilteredByDomainIDAndWorkflowID gets property with domainID and workflowID filters and asserts that it's a bool
 func (c *Collection) GetBoolPropertyFilteredByDomainIDAndWorkflowID(key BoolKey) BoolPropertyFnWithDomainIDAndWorkflowIDFilter {
 	return func(domainID string, workflowID string) bool {
@@ -49,6 +49,11 @@ func GetIntPropertyFilteredByWorkflowType(value int) func(domainName string, wor
 	return func(domainName string, workflowType string) int { return value }
 }
 
+// GetIntPropertyFilteredByActivityType returns values as IntPropertyFnWithActivityTypeFilter
+func GetIntPropertyFilteredByActivityType(value int) func(domainName string, activityType string) int {
+	return func(domainName string, activityType string) int { return value }
+}
+
 // GetDurationPropertyFilteredByWorkflowType returns values as IntPropertyFnWithWorkflowTypeFilters
 func GetDurationPropertyFilteredByWorkflowType(value time.Duration) func(domainName string, workflowType string) time.Duration {
 	return func(domainName string, workflowType string) time.Duration { return value }
@@ -74,6 +79,11 @@ func GetBoolPropertyFnFilteredByDomainID(value bool) func(domainID string) bool
 	return func(domainID string) bool { return value }
 }
 
+// GetBoolPropertyFnFilteredByTaskListInfo returns value as BoolPropertyFnWithTaskListInfoFilters
+func GetBoolPropertyFnFilteredByTaskListInfo(value bool) func(domain string, taskList string, taskType int) bool {
+	return func(domain string, taskList string, taskType int) bool { return value }
+}
+
 // GetDurationPropertyFnFilteredByDomain returns value as DurationPropertyFnFilteredByDomain
 func GetDurationPropertyFnFilteredByDomain(value time.Duration) func(domain string) time.Duration {
 	return func(domain string) time.Duration { return value }
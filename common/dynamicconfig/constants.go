@@ -586,6 +586,14 @@ const (
 	// Default value: 0
 	// Allowed filters: DomainName
 	FrontendDecisionResultCountLimit
+	// FrontendWorkflowTypeConcurrencyLimit is the max number of open executions of a workflow type in a domain, starts over the limit are rejected or queued
+	// It applies to StartWorkflowExecution and SignalWithStartWorkflowExecution, including the async ones once they're consumed from the queue.
+	// Child workflows, continue as new and cron runs are started by history, they count towards the limit but aren't limited
+	// KeyName: frontend.workflowTypeConcurrencyLimit
+	// Value type: Int
+	// Default value: 0 (no limit)
+	// Allowed filters: DomainName, WorkflowType
+	FrontendWorkflowTypeConcurrencyLimit
 	// FrontendHistoryMgrNumConns is for persistence cluster.NumConns
 	// KeyName: frontend.historyMgrNumConns
 	// Value type: Int
@@ -739,6 +747,32 @@ const (
 	// Default value: 20
 	// Allowed filters: DomainName,TasklistName,TasklistType
 	MatchingForwarderMaxChildrenPerNode
+	// MatchingDefaultTaskPriority is the priority of the tasks which don't have a valid priority, when task priority is enabled
+	// KeyName: matching.defaultTaskPriority
+	// Value type: Int
+	// Default value: 3
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingDefaultTaskPriority
+	// MatchingPartitionUpscaleRPS is the add or dispatch rate per partition above which the adaptive scaler adds partitions to a task list
+	// KeyName: matching.partitionUpscaleRPS
+	// Value type: Int
+	// Default value: 200
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingPartitionUpscaleRPS
+	// MatchingAdaptiveScalerMaxPartitions is the max number of partitions the adaptive scaler can scale a task list to
+	// KeyName: matching.adaptiveScalerMaxPartitions
+	// Value type: Int
+	// Default value: 20
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingAdaptiveScalerMaxPartitions
+	// MatchingActivityTypeDispatchRPS is the max rate per second activity tasks of an activity type are dispatched in a domain,
+	// the rate is divided equally across the matching hosts. 0 means no limit
+	// KeyName: matching.activityTypeDispatchRPS
+	// Value type: Int
+	// Default value: 0
+	// Allowed filters: DomainName,ActivityType
+	// History only sends the activity types to matching for the domains with history.enableActivityPartitionConfig
+	MatchingActivityTypeDispatchRPS
 
 	// key for history
 
@@ -828,7 +862,7 @@ const (
 	TaskProcessRPS
 	// TaskSchedulerType is the task scheduler type for priority task processor
 	// KeyName: history.taskSchedulerType
-	// Value type: Int enum(1 for SchedulerTypeFIFO, 2 for SchedulerTypeWRR(weighted round robin scheduler implementation))
+	// Value type: Int enum(1 for SchedulerTypeFIFO, 2 for SchedulerTypeWRR(weighted round robin scheduler implementation), 3 for SchedulerTypeDomainWRR(per domain weighted round robin scheduler implementation))
 	// Default value: 2 (task.SchedulerTypeWRR)
 	// Allowed filters: N/A
 	TaskSchedulerType
@@ -862,6 +896,18 @@ const (
 	// Default value: 1
 	// Allowed filters: N/A
 	TaskSchedulerDispatcherCount
+	// TaskSchedulerDomainWeight is the weight of a domain in the per domain weighted round robin task scheduler
+	// KeyName: history.taskSchedulerDomainWeight
+	// Value type: Int
+	// Default value: 1
+	// Allowed filters: DomainName
+	TaskSchedulerDomainWeight
+	// TaskSchedulerDomainRPS is the max rate per second tasks of a domain are dispatched by the per domain weighted round robin task scheduler, 0 means no limit
+	// KeyName: history.taskSchedulerDomainRPS
+	// Value type: Int
+	// Default value: 0
+	// Allowed filters: DomainName
+	TaskSchedulerDomainRPS
 	// TaskCriticalRetryCount is the critical retry count for background tasks
 	// when task attempt exceeds this threshold:
 	// - task attempt metrics and additional error logs will be emitted
@@ -1438,6 +1484,12 @@ const (
 	IsolationGroupStateUpdateRetryAttempts
 
 	LargeShardHistoryBlobMetricThreshold
+	// CassandraVisibilityMaxCountScanSize is the max number of executions read by Cassandra visibility to count the executions
+	// matched by a query, counting a query matching more executions fails
+	// KeyName: system.cassandraVisibilityMaxCountScanSize
+	// Value type: Int
+	// Default value: 10000
+	CassandraVisibilityMaxCountScanSize
 	// LastIntKey must be the last one in this const group
 	LastIntKey
 )
@@ -1564,6 +1616,13 @@ const (
 	// Default value: false
 	// Allowed filters: DomainName
 	FrontendEmitSignalNameMetricsTag
+	// FrontendQueueStartsOverConcurrencyLimit is whether to queue workflow starts over the workflow type concurrency limit to the async workflow queue of the domain instead of rejecting them,
+	// only for callers opting in with the cadence-queue-start-over-concurrency-limit header since the run ID of a queued start is unknown
+	// KeyName: frontend.queueStartsOverConcurrencyLimit
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	FrontendQueueStartsOverConcurrencyLimit
 	// EnableQueryAttributeValidation enables validation of queries' search attributes against the dynamic config whitelist
 	// Keyname: frontend.enableQueryAttributeValidation
 	// Value type: Bool
@@ -1585,6 +1644,24 @@ const (
 	// Default value: false
 	// Allowed filters: DomainID
 	MatchingEnableTaskInfoLogByDomainID
+	// MatchingEnableTaskPriority is to persist the tasks of a task list in a backlog per priority and dispatch them by priority
+	// KeyName: matching.enableTaskPriority
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingEnableTaskPriority
+	// MatchingEnableTaskFairness is to dispatch the buffered tasks of a task list by round robin across their fairness keys
+	// KeyName: matching.enableTaskFairness
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingEnableTaskFairness
+	// MatchingEnableAdaptiveScaler is to scale the partitions of a task list based on its add and dispatch rates instead of MatchingNumTasklistWritePartitions and MatchingNumTasklistReadPartitions
+	// KeyName: matching.enableAdaptiveScaler
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingEnableAdaptiveScaler
 
 	// key for history
 
@@ -1715,6 +1792,20 @@ const (
 	// Default value: true
 	// Allowed filters: DomainID, WorkflowID
 	EnableReplicationTaskGeneration
+	// HistoryWorkflowPaused pauses the workflows, no decision or activity task of a paused workflow is dispatched to workers
+	// and the schedule to start timeouts of its tasks don't fire. Its tasks need to be refreshed after it's resumed.
+	// KeyName: history.workflowPaused
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName, WorkflowID
+	HistoryWorkflowPaused
+	// HistoryEnableActivityPartitionConfig is to dispatch the activity tasks with their activity type, and with the priority and
+	// the fairness key headers of the activity. They are read from the scheduled event of the activity, which may not be cached.
+	// KeyName: history.enableActivityPartitionConfig
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	HistoryEnableActivityPartitionConfig
 	// UseNewInitialFailoverVersion is a switch to issue a failover version based on the minFailoverVersion
 	// rather than the default initialFailoverVersion. USed as a per-domain migration switch
 	// KeyName: history.useNewInitialFailoverVersion
@@ -1874,6 +1965,18 @@ const (
 	// Default value: true
 	// Allowed filters: N/A
 	EnableFailoverManager
+	// EnableScheduler decides whether to start the scheduler of cron schedules in our worker
+	// KeyName: worker.enableScheduler
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	EnableScheduler
+	// EnableVisibilityBackfill decides whether to start the worker of visibility backfill workflows in our worker
+	// KeyName: worker.enableVisibilityBackfill
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	EnableVisibilityBackfill
 	// ConcreteExecutionFixerDomainAllow is which domains are allowed to be fixed by concrete fixer workflow
 	// KeyName: worker.concreteExecutionFixerDomainAllow
 	// Value type: Bool
@@ -2040,6 +2143,12 @@ const (
 	// Default value: 0
 	// Allowed filters: N/A
 	MatchingErrorInjectionRate
+	// MatchingPartitionDownscaleFactor is the fraction of the upscale rate below which the adaptive scaler removes partitions from a task list
+	// KeyName: matching.partitionDownscaleFactor
+	// Value type: Float64
+	// Default value: 0.75
+	// Allowed filters: N/A
+	MatchingPartitionDownscaleFactor
 
 	// key for history
 
@@ -2216,7 +2325,9 @@ const (
 	// Default value: "enabled"
 	// Allowed filters: N/A
 	VisibilityArchivalStatus
-	// DefaultEventEncoding is the encoding type for history events
+	// DefaultEventEncoding is the encoding type for history events.
+	// Besides thriftrw and json, thriftrw+snappy and thriftrw+zstd compress the history events before storing them,
+	// and the blobs written with any of them can still be read after changing it
 	// KeyName: history.defaultEventEncoding
 	// Value type: String
 	// Default value: string(common.EncodingTypeThriftRW)
@@ -2253,6 +2364,13 @@ const (
 	// Value type: string ["test-domain","test-domain2"]
 	// Default value: ""
 	ESAnalyzerWorkflowTypeMetricDomains
+	// MatchingFairnessKeyWorkflowIDDelimiter is the delimiter of the workflow ID prefix used as the fairness key of the tasks without one.
+	// The whole workflow ID is used if it's empty or the workflow ID doesn't contain it
+	// KeyName: matching.fairnessKeyWorkflowIDDelimiter
+	// Value type: String
+	// Default value: ""
+	// Allowed filters: DomainName
+	MatchingFairnessKeyWorkflowIDDelimiter
 
 	// LastStringKey must be the last one in this const group
 	LastStringKey
@@ -2315,6 +2433,30 @@ const (
 	// Default value: 0
 	// Allowed filters: N/A
 	MatchingShutdownDrainDuration
+	// MatchingPartitionUpscaleSustainedDuration is the duration for which the rate of a task list must stay above the upscale rate before partitions are added
+	// KeyName: matching.partitionUpscaleSustainedDuration
+	// Value type: Duration
+	// Default value: 1m (1*time.Minute)
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingPartitionUpscaleSustainedDuration
+	// MatchingPartitionDownscaleSustainedDuration is the duration for which the rate of a task list must stay below the downscale rate before partitions are removed
+	// KeyName: matching.partitionDownscaleSustainedDuration
+	// Value type: Duration
+	// Default value: 2m (2*time.Minute)
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingPartitionDownscaleSustainedDuration
+	// MatchingAdaptiveScalerUpdateInterval is the interval at which the adaptive scaler samples the rates of a task list
+	// KeyName: matching.adaptiveScalerUpdateInterval
+	// Value type: Duration
+	// Default value: 15s (15*time.Second)
+	// Allowed filters: DomainName,TasklistName,TasklistType
+	MatchingAdaptiveScalerUpdateInterval
+	// MatchingPartitionConfigRefreshInterval is the interval at which matching clients refresh the number of partitions of the task lists scaled by the adaptive scaler
+	// KeyName: matching.partitionConfigRefreshInterval
+	// Value type: Duration
+	// Default value: 10s (10*time.Second)
+	// Allowed filters: N/A
+	MatchingPartitionConfigRefreshInterval
 	// MatchingActivityTaskSyncMatchWaitTime is the amount of time activity task will wait to be sync matched
 	// KeyName: matching.activityTaskSyncMatchWaitTime
 	// Value type: Duration
@@ -2382,6 +2524,12 @@ const (
 	// Default value: 30s (30*time.Second)
 	// Allowed filters: N/A
 	StandbyTaskRedispatchInterval
+	// TaskSchedulerNoisyDomainBackoff is how long the per domain weighted round robin task scheduler rejects new tasks of a domain after its queue is full
+	// KeyName: history.taskSchedulerNoisyDomainBackoff
+	// Value type: Duration
+	// Default value: 5s (5*time.Second)
+	// Allowed filters: N/A
+	TaskSchedulerNoisyDomainBackoff
 	// StandbyTaskReReplicationContextTimeout is the context timeout for standby task re-replication
 	// KeyName: history.standbyTaskReReplicationContextTimeout
 	// Value type: Duration
@@ -2728,6 +2876,25 @@ const (
 	// Default value: the default attributes of this release version, see definition.GetDefaultIndexedKeys()
 	// Allowed filters: N/A
 	ValidSearchAttributes
+	// DeprecatedSearchAttributes is the indexed keys that are deprecated, mapped to the reason of deprecation. Writes to these keys are still accepted but logged
+	// KeyName: frontend.deprecatedSearchAttributes
+	// Value type: Map
+	// Default value: empty map
+	// Allowed filters: N/A
+	DeprecatedSearchAttributes
+	// RemovedSearchAttributes is the indexed keys that are removed, mapped to the reason of removal. Writes to them are rejected by the frontend and dropped from workflow decisions
+	// KeyName: frontend.removedSearchAttributes
+	// Value type: Map
+	// Default value: empty map
+	// Allowed filters: N/A
+	RemovedSearchAttributes
+	// MigratedSearchAttributes is the indexed keys that are being migrated, mapped to the key they are migrated to.
+	// The values written to them are also written to the new keys, converted to the type of the new keys
+	// KeyName: frontend.migratedSearchAttributes
+	// Value type: Map
+	// Default value: empty map
+	// Allowed filters: N/A
+	MigratedSearchAttributes
 
 	// key for history
 
@@ -2749,6 +2916,18 @@ const (
 	// Default value: see common.ConvertIntMapToDynamicConfigMapProperty(DefaultStuckTaskSplitThreshold) in code base
 	// Allowed filters: N/A
 	QueueProcessorStuckTaskSplitThreshold
+	// MatchingTaskPriorityWeights is the priority to weight mapping used to dispatch the tasks of a task list, the priorities without weight use the default priority
+	// KeyName: matching.taskPriorityWeights
+	// Value type: Map
+	// Default value: 1: 16, 2: 8, 3: 4, 4: 2, 5: 1
+	// Allowed filters: N/A
+	MatchingTaskPriorityWeights
+	// MatchingTaskFairnessKeyWeights is the fairness key to weight mapping used to dispatch the buffered tasks of a task list
+	// KeyName: matching.taskFairnessKeyWeights
+	// Value type: Map
+	// Default value: empty, every fairness key has a weight of 1
+	// Allowed filters: N/A
+	MatchingTaskFairnessKeyWeights
 
 	// LastMapKey must be the last one in this const group
 	LastMapKey
@@ -2773,6 +2952,13 @@ const (
 	// Default value: N/A
 	// Allowed filters: N/A
 	AllIsolationGroups
+	// CassandraVisibilityIndexedSearchAttributes is the list of custom search attributes indexed by Cassandra visibility,
+	// which can be used in equality predicates of visibility queries. Only executions recorded after a key is added are indexed
+	// KeyName: system.cassandraVisibilityIndexedSearchAttributes
+	// Value type: []string
+	// Default value: empty list
+	// Allowed filters: N/A
+	CassandraVisibilityIndexedSearchAttributes
 
 	LastListKey
 )
@@ -3048,6 +3234,12 @@ var IntKeys = map[IntKey]DynamicInt{
 		Description:  "FrontendDecisionResultCountLimit is max number of decisions per RespondDecisionTaskCompleted request",
 		DefaultValue: 0,
 	},
+	FrontendWorkflowTypeConcurrencyLimit: DynamicInt{
+		KeyName:      "frontend.workflowTypeConcurrencyLimit",
+		Filters:      []Filter{DomainName, WorkflowType},
+		Description:  "FrontendWorkflowTypeConcurrencyLimit is the max number of open executions of a workflow type in a domain, starts over the limit are rejected or queued. It applies to StartWorkflowExecution and SignalWithStartWorkflowExecution, child workflows, continue as new and cron runs aren't limited",
+		DefaultValue: 0,
+	},
 	FrontendHistoryMgrNumConns: DynamicInt{
 		KeyName:      "frontend.historyMgrNumConns",
 		Description:  "FrontendHistoryMgrNumConns is for persistence cluster.NumConns",
@@ -3188,6 +3380,30 @@ var IntKeys = map[IntKey]DynamicInt{
 		Description:  "MatchingForwarderMaxChildrenPerNode is the max number of children per node in the task list partition tree",
 		DefaultValue: 20,
 	},
+	MatchingDefaultTaskPriority: DynamicInt{
+		KeyName:      "matching.defaultTaskPriority",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingDefaultTaskPriority is the priority of the tasks which don't have a valid priority, when task priority is enabled",
+		DefaultValue: 3,
+	},
+	MatchingPartitionUpscaleRPS: DynamicInt{
+		KeyName:      "matching.partitionUpscaleRPS",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingPartitionUpscaleRPS is the add or dispatch rate per partition above which the adaptive scaler adds partitions to a task list",
+		DefaultValue: 200,
+	},
+	MatchingAdaptiveScalerMaxPartitions: DynamicInt{
+		KeyName:      "matching.adaptiveScalerMaxPartitions",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingAdaptiveScalerMaxPartitions is the max number of partitions the adaptive scaler can scale a task list to",
+		DefaultValue: 20,
+	},
+	MatchingActivityTypeDispatchRPS: DynamicInt{
+		KeyName:      "matching.activityTypeDispatchRPS",
+		Filters:      []Filter{DomainName, ActivityType},
+		Description:  "MatchingActivityTypeDispatchRPS is the max rate per second activity tasks of an activity type are dispatched in a domain, the rate is divided equally across the matching hosts. 0 means no limit. Requires history.enableActivityPartitionConfig for the domain",
+		DefaultValue: 0,
+	},
 	HistoryRPS: DynamicInt{
 		KeyName:      "history.rps",
 		Description:  "HistoryRPS is request rate per second for each history host",
@@ -3291,6 +3507,18 @@ var IntKeys = map[IntKey]DynamicInt{
 		Description:  "TaskSchedulerDispatcherCount is the number of task dispatcher in task scheduler (only applies to host level task scheduler)",
 		DefaultValue: 1,
 	},
+	TaskSchedulerDomainWeight: DynamicInt{
+		KeyName:      "history.taskSchedulerDomainWeight",
+		Filters:      []Filter{DomainName},
+		Description:  "TaskSchedulerDomainWeight is the weight of a domain in the per domain weighted round robin task scheduler",
+		DefaultValue: 1,
+	},
+	TaskSchedulerDomainRPS: DynamicInt{
+		KeyName:      "history.taskSchedulerDomainRPS",
+		Filters:      []Filter{DomainName},
+		Description:  "TaskSchedulerDomainRPS is the max rate per second tasks of a domain are dispatched by the per domain weighted round robin task scheduler, 0 means no limit",
+		DefaultValue: 0,
+	},
 	TaskCriticalRetryCount: DynamicInt{
 		KeyName:      "history.taskCriticalRetryCount",
 		Description:  "TaskCriticalRetryCount is the critical retry count for background tasks, when task attempt exceeds this threshold:- task attempt metrics and additional error logs will be emitted- task priority will be lowered",
@@ -3772,6 +4000,11 @@ var IntKeys = map[IntKey]DynamicInt{
 		Description:  "The number of attempts to push Isolation group configuration to the config store",
 		DefaultValue: 2,
 	},
+	CassandraVisibilityMaxCountScanSize: DynamicInt{
+		KeyName:      "system.cassandraVisibilityMaxCountScanSize",
+		Description:  "CassandraVisibilityMaxCountScanSize is the max number of executions read by Cassandra visibility to count the executions matched by a query, counting a query matching more executions fails",
+		DefaultValue: 10000,
+	},
 }
 
 var BoolKeys = map[BoolKey]DynamicBool{
@@ -3894,6 +4127,12 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "FrontendEmitSignalNameMetricsTag enables emitting signal name tag in metrics in frontend client",
 		DefaultValue: false,
 	},
+	FrontendQueueStartsOverConcurrencyLimit: DynamicBool{
+		KeyName:      "frontend.queueStartsOverConcurrencyLimit",
+		Filters:      []Filter{DomainName},
+		Description:  "FrontendQueueStartsOverConcurrencyLimit is whether to queue workflow starts over the workflow type concurrency limit to the async workflow queue of the domain instead of rejecting them, only for callers opting in with the cadence-queue-start-over-concurrency-limit header since the run ID of a queued start is unknown",
+		DefaultValue: false,
+	},
 	EnableQueryAttributeValidation: DynamicBool{
 		KeyName:      "frontend.enableQueryAttributeValidation",
 		Description:  "EnableQueryAttributeValidation enables validation of queries' search attributes against the dynamic config whitelist",
@@ -3911,6 +4150,24 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "MatchingEnableTaskInfoLogByDomainID is enables info level logs for decision/activity task based on the request domainID",
 		DefaultValue: false,
 	},
+	MatchingEnableTaskPriority: DynamicBool{
+		KeyName:      "matching.enableTaskPriority",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingEnableTaskPriority is to persist the tasks of a task list in a backlog per priority and dispatch them by priority",
+		DefaultValue: false,
+	},
+	MatchingEnableTaskFairness: DynamicBool{
+		KeyName:      "matching.enableTaskFairness",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingEnableTaskFairness is to dispatch the buffered tasks of a task list by round robin across their fairness keys",
+		DefaultValue: false,
+	},
+	MatchingEnableAdaptiveScaler: DynamicBool{
+		KeyName:      "matching.enableAdaptiveScaler",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingEnableAdaptiveScaler is to scale the partitions of a task list based on its add and dispatch rates instead of MatchingNumTasklistWritePartitions and MatchingNumTasklistReadPartitions",
+		DefaultValue: false,
+	},
 	EventsCacheGlobalEnable: DynamicBool{
 		KeyName:      "history.eventsCacheGlobalEnable",
 		Description:  "EventsCacheGlobalEnable is enables global cache over all history shards",
@@ -4027,6 +4284,18 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "EnableReplicationTaskGeneration is the flag to control replication generation",
 		DefaultValue: true,
 	},
+	HistoryWorkflowPaused: DynamicBool{
+		KeyName:      "history.workflowPaused",
+		Filters:      []Filter{DomainName, WorkflowID},
+		Description:  "HistoryWorkflowPaused pauses the workflows, no decision or activity task of a paused workflow is dispatched to workers",
+		DefaultValue: false,
+	},
+	HistoryEnableActivityPartitionConfig: DynamicBool{
+		KeyName:      "history.enableActivityPartitionConfig",
+		Filters:      []Filter{DomainName},
+		Description:  "HistoryEnableActivityPartitionConfig is to dispatch the activity tasks with their activity type, and with the priority and the fairness key headers of the activity",
+		DefaultValue: false,
+	},
 	UseNewInitialFailoverVersion: DynamicBool{
 		KeyName:      "history.useNewInitialFailoverVersion",
 		Description:  "use the minInitialFailover version",
@@ -4133,6 +4402,16 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "EnableFailoverManager indicates if failover manager is enabled",
 		DefaultValue: true,
 	},
+	EnableScheduler: DynamicBool{
+		KeyName:      "worker.enableScheduler",
+		Description:  "EnableScheduler decides whether to start the scheduler of cron schedules in our worker",
+		DefaultValue: false,
+	},
+	EnableVisibilityBackfill: DynamicBool{
+		KeyName:      "worker.enableVisibilityBackfill",
+		Description:  "EnableVisibilityBackfill decides whether to start the worker of visibility backfill workflows in our worker",
+		DefaultValue: false,
+	},
 	ConcreteExecutionFixerDomainAllow: DynamicBool{
 		KeyName:      "worker.concreteExecutionFixerDomainAllow",
 		Filters:      []Filter{DomainName},
@@ -4300,6 +4579,11 @@ var FloatKeys = map[FloatKey]DynamicFloat{
 		Description:  "MatchingErrorInjectionRate is rate for injecting random error in matching client",
 		DefaultValue: 0,
 	},
+	MatchingPartitionDownscaleFactor: DynamicFloat{
+		KeyName:      "matching.partitionDownscaleFactor",
+		Description:  "MatchingPartitionDownscaleFactor is the fraction of the upscale rate below which the adaptive scaler removes partitions from a task list",
+		DefaultValue: 0.75,
+	},
 	TaskRedispatchIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.taskRedispatchIntervalJitterCoefficient",
 		Description:  "TaskRedispatchIntervalJitterCoefficient is the task redispatch interval jitter coefficient",
@@ -4481,6 +4765,12 @@ var StringKeys = map[StringKey]DynamicString{
 		Description:  "ESAnalyzerWorkflowDurationWarnThresholds defines the domains we want to emit wf version metrics on",
 		DefaultValue: "",
 	},
+	MatchingFairnessKeyWorkflowIDDelimiter: DynamicString{
+		KeyName:      "matching.fairnessKeyWorkflowIDDelimiter",
+		Filters:      []Filter{DomainName},
+		Description:  "MatchingFairnessKeyWorkflowIDDelimiter is the delimiter of the workflow ID prefix used as the fairness key of the tasks without one",
+		DefaultValue: "",
+	},
 }
 
 var DurationKeys = map[DurationKey]DynamicDuration{
@@ -4544,6 +4834,29 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		Description:  "MatchingShutdownDrainDuration is the duration of traffic drain during shutdown",
 		DefaultValue: 0,
 	},
+	MatchingPartitionUpscaleSustainedDuration: DynamicDuration{
+		KeyName:      "matching.partitionUpscaleSustainedDuration",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingPartitionUpscaleSustainedDuration is the duration for which the rate of a task list must stay above the upscale rate before partitions are added",
+		DefaultValue: time.Minute,
+	},
+	MatchingPartitionDownscaleSustainedDuration: DynamicDuration{
+		KeyName:      "matching.partitionDownscaleSustainedDuration",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingPartitionDownscaleSustainedDuration is the duration for which the rate of a task list must stay below the downscale rate before partitions are removed",
+		DefaultValue: 2 * time.Minute,
+	},
+	MatchingAdaptiveScalerUpdateInterval: DynamicDuration{
+		KeyName:      "matching.adaptiveScalerUpdateInterval",
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
+		Description:  "MatchingAdaptiveScalerUpdateInterval is the interval at which the adaptive scaler samples the rates of a task list",
+		DefaultValue: 15 * time.Second,
+	},
+	MatchingPartitionConfigRefreshInterval: DynamicDuration{
+		KeyName:      "matching.partitionConfigRefreshInterval",
+		Description:  "MatchingPartitionConfigRefreshInterval is the interval at which matching clients refresh the number of partitions of the task lists scaled by the adaptive scaler",
+		DefaultValue: 10 * time.Second,
+	},
 	MatchingActivityTaskSyncMatchWaitTime: DynamicDuration{
 		KeyName:      "matching.activityTaskSyncMatchWaitTime",
 		Filters:      []Filter{DomainName},
@@ -4601,6 +4914,11 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		Description:  "StandbyTaskRedispatchInterval is the standby task redispatch interval",
 		DefaultValue: time.Second * 30,
 	},
+	TaskSchedulerNoisyDomainBackoff: DynamicDuration{
+		KeyName:      "history.taskSchedulerNoisyDomainBackoff",
+		Description:  "TaskSchedulerNoisyDomainBackoff is how long the per domain weighted round robin task scheduler rejects new tasks of a domain after its queue is full",
+		DefaultValue: time.Second * 5,
+	},
 	StandbyTaskReReplicationContextTimeout: DynamicDuration{
 		KeyName:      "history.standbyTaskReReplicationContextTimeout",
 		Filters:      []Filter{DomainID},
@@ -4894,6 +5212,21 @@ var MapKeys = map[MapKey]DynamicMap{
 		Description:  "ValidSearchAttributes is legal indexed keys that can be used in list APIs. When overriding, ensure to include the existing default attributes of the current release",
 		DefaultValue: definition.GetDefaultIndexedKeys(),
 	},
+	DeprecatedSearchAttributes: DynamicMap{
+		KeyName:      "frontend.deprecatedSearchAttributes",
+		Description:  "DeprecatedSearchAttributes is the indexed keys that are deprecated, mapped to the reason of deprecation. Writes to these keys are still accepted but logged",
+		DefaultValue: map[string]interface{}{},
+	},
+	RemovedSearchAttributes: DynamicMap{
+		KeyName:      "frontend.removedSearchAttributes",
+		Description:  "RemovedSearchAttributes is the indexed keys that are removed, mapped to the reason of removal. Writes to them are rejected by the frontend and dropped from workflow decisions",
+		DefaultValue: map[string]interface{}{},
+	},
+	MigratedSearchAttributes: DynamicMap{
+		KeyName:      "frontend.migratedSearchAttributes",
+		Description:  "MigratedSearchAttributes is the indexed keys that are being migrated, mapped to the key they are migrated to. The values written to them are also written to the new keys, converted to the type of the new keys",
+		DefaultValue: map[string]interface{}{},
+	},
 	TaskSchedulerRoundRobinWeights: DynamicMap{
 		KeyName:     "history.taskSchedulerRoundRobinWeight",
 		Description: "TaskSchedulerRoundRobinWeights is the priority weight for weighted round robin task scheduler",
@@ -4913,6 +5246,16 @@ var MapKeys = map[MapKey]DynamicMap{
 		Description:  "QueueProcessorStuckTaskSplitThreshold is the threshold for the number of attempts of a task",
 		DefaultValue: common.ConvertIntMapToDynamicConfigMapProperty(map[int]int{0: 100, 1: 10000}),
 	},
+	MatchingTaskPriorityWeights: DynamicMap{
+		KeyName:      "matching.taskPriorityWeights",
+		Description:  "MatchingTaskPriorityWeights is the priority to weight mapping used to dispatch the tasks of a task list, the priorities without weight use the default priority",
+		DefaultValue: common.ConvertIntMapToDynamicConfigMapProperty(map[int]int{1: 16, 2: 8, 3: 4, 4: 2, 5: 1}),
+	},
+	MatchingTaskFairnessKeyWeights: DynamicMap{
+		KeyName:      "matching.taskFairnessKeyWeights",
+		Description:  "MatchingTaskFairnessKeyWeights is the fairness key to weight mapping used to dispatch the buffered tasks of a task list",
+		DefaultValue: map[string]interface{}{},
+	},
 }
 
 var ListKeys = map[ListKey]DynamicList{
@@ -4920,6 +5263,10 @@ var ListKeys = map[ListKey]DynamicList{
 		KeyName:     "system.allIsolationGroups",
 		Description: "A list of all the isolation groups in a system",
 	},
+	CassandraVisibilityIndexedSearchAttributes: {
+		KeyName:     "system.cassandraVisibilityIndexedSearchAttributes",
+		Description: "CassandraVisibilityIndexedSearchAttributes is the list of custom search attributes indexed by Cassandra visibility, which can be used in equality predicates of visibility queries",
+	},
 	DefaultIsolationGroupConfigStoreManagerGlobalMapping: {
 		KeyName: "system.defaultIsolationGroupConfigStoreManagerGlobalMapping",
 		Description: "A configuration store for global isolation groups - used in isolation-group config only, not normal dynamic config." +
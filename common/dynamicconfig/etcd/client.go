@@ -0,0 +1,690 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	dc "github.com/uber/cadence/common/dynamicconfig"
+	ec "github.com/uber/cadence/common/dynamicconfig/etcdconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/types"
+)
+
+var _ dc.Client = (*etcdClient)(nil)
+
+const (
+	defaultPrefix         = "/cadence/dynamicconfig/"
+	defaultDialTimeout    = 5 * time.Second
+	defaultRequestTimeout = 5 * time.Second
+
+	watchRetryInterval = time.Second
+)
+
+type (
+	etcdClient struct {
+		client *clientv3.Client
+		config *ec.ClientConfig
+		// values is a map from the key name to its *cacheEntry, it's replaced as a whole on every update
+		values atomic.Value
+		// updateLock serializes the updates of the values
+		updateLock sync.Mutex
+		doneCh     chan struct{}
+		logger     log.Logger
+	}
+
+	cacheEntry struct {
+		dcEntry *types.DynamicConfigEntry
+		values  []*constrainedValue
+	}
+
+	constrainedValue struct {
+		value   interface{}
+		filters map[dc.Filter]interface{}
+	}
+
+	// storedValue is how a value of a dynamic config key is stored in etcd. The value of an etcd key is a
+	// JSON list of storedValue, so it can also be edited with etcdctl, e.g.
+	// [{"value": 100}, {"value": 200, "filters": [{"name": "domainName", "value": "samples-domain"}]}]
+	storedValue struct {
+		Value   json.RawMessage `json:"value"`
+		Filters []*storedFilter `json:"filters,omitempty"`
+	}
+
+	storedFilter struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+)
+
+// NewEtcdClient creates a dynamic config client which watches the keys in etcd, and stops watching when doneCh is closed
+func NewEtcdClient(config *ec.ClientConfig, logger log.Logger, doneCh chan struct{}) (dc.Client, error) {
+	config, err := validateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig := clientv3.Config{
+		Endpoints:   config.Endpoints,
+		DialTimeout: config.DialTimeout,
+		Username:    config.Username,
+		Password:    config.Password,
+	}
+	if config.TLS.Enabled {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      config.TLS.CertFile,
+			KeyFile:       config.TLS.KeyFile,
+			TrustedCAFile: config.TLS.CaFile,
+		}
+		clientConfig.TLS, err = tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create etcd TLS config: %v", err)
+		}
+	}
+	client, err := clientv3.New(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %v", err)
+	}
+
+	c := &etcdClient{
+		client: client,
+		config: config,
+		doneCh: doneCh,
+		logger: logger,
+	}
+	revision, err := c.reload()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	go c.watch(revision)
+	return c, nil
+}
+
+func (c *etcdClient) GetValue(name dc.Key) (interface{}, error) {
+	return c.getValueWithFilters(name, nil, name.DefaultValue())
+}
+
+func (c *etcdClient) GetValueWithFilters(name dc.Key, filters map[dc.Filter]interface{}) (interface{}, error) {
+	return c.getValueWithFilters(name, filters, name.DefaultValue())
+}
+
+func (c *etcdClient) GetIntValue(name dc.IntKey, filters map[dc.Filter]interface{}) (int, error) {
+	defaultValue := name.DefaultInt()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	}
+	return defaultValue, fmt.Errorf("value type is not int but is: %T", val)
+}
+
+func (c *etcdClient) GetFloatValue(name dc.FloatKey, filters map[dc.Filter]interface{}) (float64, error) {
+	defaultValue := name.DefaultFloat()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if floatVal, ok := val.(float64); ok {
+		return floatVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not float64 but is: %T", val)
+}
+
+func (c *etcdClient) GetBoolValue(name dc.BoolKey, filters map[dc.Filter]interface{}) (bool, error) {
+	defaultValue := name.DefaultBool()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if boolVal, ok := val.(bool); ok {
+		return boolVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not bool but is: %T", val)
+}
+
+func (c *etcdClient) GetStringValue(name dc.StringKey, filters map[dc.Filter]interface{}) (string, error) {
+	defaultValue := name.DefaultString()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if stringVal, ok := val.(string); ok {
+		return stringVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not string but is: %T", val)
+}
+
+// Note that all number types (ex: ints) will be returned as float64.
+// It is the caller's responsibility to convert based on their context for value type.
+func (c *etcdClient) GetMapValue(name dc.MapKey, filters map[dc.Filter]interface{}) (map[string]interface{}, error) {
+	defaultValue := name.DefaultMap()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+	if mapVal, ok := val.(map[string]interface{}); ok {
+		return mapVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not map but is: %T", val)
+}
+
+func (c *etcdClient) GetDurationValue(name dc.DurationKey, filters map[dc.Filter]interface{}) (time.Duration, error) {
+	defaultValue := name.DefaultDuration()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	switch v := val.(type) {
+	case string:
+		durationVal, err := time.ParseDuration(v)
+		if err != nil {
+			return defaultValue, fmt.Errorf("failed to parse duration: %v", err)
+		}
+		return durationVal, nil
+	case time.Duration:
+		return v, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not duration but is: %T", val)
+}
+
+func (c *etcdClient) GetListValue(name dc.ListKey, filters map[dc.Filter]interface{}) ([]interface{}, error) {
+	defaultValue := name.DefaultList()
+	val, err := c.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+	if listVal, ok := val.([]interface{}); ok {
+		return listVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not list but is: %T", val)
+}
+
+// UpdateValue replaces all the values of the key, or deletes the key if value is empty.
+// The value must be []*types.DynamicConfigValue, as it's sent by the admin API.
+func (c *etcdClient) UpdateValue(name dc.Key, value interface{}) error {
+	dcValues, ok := value.([]*types.DynamicConfigValue)
+	if !ok && value != nil {
+		return errors.New("invalid value")
+	}
+	for _, dcValue := range dcValues {
+		if err := validateKeyValue(name, dcValue); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.RequestTimeout)
+	defer cancel()
+	o, err := c.updateOp(name, dcValues)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(ctx, o)
+	return err
+}
+
+// RestoreValue deletes the fallback value of the key if filters is nil, otherwise the values matching the filters
+func (c *etcdClient) RestoreValue(name dc.Key, filters map[dc.Filter]interface{}) error {
+	for attempt := 0; attempt <= c.config.UpdateRetryAttempts; attempt++ {
+		succeeded, err := c.restoreValue(name, filters)
+		if err != nil || succeeded {
+			return err
+		}
+	}
+	return errors.New("ran out of retry attempts on update")
+}
+
+func (c *etcdClient) ListValue(name dc.Key) ([]*types.DynamicConfigEntry, error) {
+	values := c.loadValues()
+	if name != nil {
+		if entry, ok := values[name.String()]; ok {
+			return []*types.DynamicConfigEntry{entry.dcEntry}, nil
+		}
+	}
+
+	// if key is not known/specified, return all entries
+	entries := make([]*types.DynamicConfigEntry, 0, len(values))
+	for _, entry := range values {
+		entries = append(entries, entry.dcEntry)
+	}
+	return entries, nil
+}
+
+// restoreValue updates the key only if it's not updated since it's read, and returns false if it's updated
+func (c *etcdClient) restoreValue(name dc.Key, filters map[dc.Filter]interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.RequestTimeout)
+	defer cancel()
+
+	key := c.etcdKey(name.String())
+	resp, err := c.client.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return false, dc.NotFoundError
+	}
+	entry, err := parseEntry(name.String(), resp.Kvs[0].Value)
+	if err != nil {
+		return false, err
+	}
+
+	newValues := make([]*types.DynamicConfigValue, 0, len(entry.dcEntry.Values))
+	for i, dcValue := range entry.dcEntry.Values {
+		if len(dcValue.Filters) == 0 {
+			// the fallback value is only deleted if no filter is given
+			if filters != nil {
+				newValues = append(newValues, dcValue)
+			}
+			continue
+		}
+		if filters == nil || !match(entry.values[i], filters) {
+			newValues = append(newValues, dcValue)
+		}
+	}
+
+	o, err := c.updateOp(name, newValues)
+	if err != nil {
+		return false, err
+	}
+	txnResp, err := c.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)).
+		Then(o).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return txnResp.Succeeded, nil
+}
+
+func (c *etcdClient) updateOp(name dc.Key, dcValues []*types.DynamicConfigValue) (clientv3.Op, error) {
+	key := c.etcdKey(name.String())
+	if len(dcValues) == 0 {
+		return clientv3.OpDelete(key), nil
+	}
+	data, err := encodeValues(dcValues)
+	if err != nil {
+		return clientv3.Op{}, err
+	}
+	return clientv3.OpPut(key, string(data)), nil
+}
+
+// reload loads all the values from etcd, and returns the revision they are loaded at
+func (c *etcdClient) reload() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.RequestTimeout)
+	defer cancel()
+
+	resp, err := c.client.Get(ctx, c.config.Prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load dynamic config from etcd: %v", err)
+	}
+
+	newValues := make(map[string]*cacheEntry, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		keyName := c.keyName(kv.Key)
+		entry, err := parseEntry(keyName, kv.Value)
+		if err != nil {
+			c.logger.Error("Failed to parse dynamic config value", tag.Key(keyName), tag.Error(err))
+			continue
+		}
+		newValues[keyName] = entry
+	}
+
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	c.values.Store(newValues)
+	c.logger.Info("Loaded dynamic config from etcd", tag.Counter(len(newValues)))
+	return resp.Header.Revision, nil
+}
+
+// watch applies the updates after the revision until doneCh is closed, and then closes the etcd client
+func (c *etcdClient) watch(revision int64) {
+	defer c.client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		var err error
+		revision, err = c.watchUntilInterrupted(ctx, revision)
+		if ctx.Err() == nil {
+			c.logger.Warn("Dynamic config watch is interrupted", tag.Error(err))
+		}
+
+		select {
+		case <-c.doneCh:
+			return
+		case <-time.After(watchRetryInterval):
+		}
+
+		// the updates might be lost, e.g. the revision is compacted, so reload all the values before watching again
+		newRevision, err := c.reload()
+		if err != nil {
+			c.logger.Error("Failed to reload dynamic config", tag.Error(err))
+			continue
+		}
+		revision = newRevision
+	}
+}
+
+// watchUntilInterrupted applies the updates after the revision until the watch fails, and returns the last applied revision
+func (c *etcdClient) watchUntilInterrupted(ctx context.Context, revision int64) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// RequireLeader interrupts the watch when the member loses its leader, so that it's watched again on another member
+	watchCh := c.client.Watch(clientv3.WithRequireLeader(ctx), c.config.Prefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+	for resp := range watchCh {
+		if err := resp.Err(); err != nil {
+			return revision, err
+		}
+		c.applyEvents(resp.Events)
+		revision = resp.Header.Revision
+	}
+	return revision, ctx.Err()
+}
+
+func (c *etcdClient) applyEvents(events []*clientv3.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+
+	currentValues := c.loadValues()
+	newValues := make(map[string]*cacheEntry, len(currentValues))
+	for keyName, entry := range currentValues {
+		newValues[keyName] = entry
+	}
+	for _, event := range events {
+		keyName := c.keyName(event.Kv.Key)
+		if event.Type == mvccpb.DELETE {
+			delete(newValues, keyName)
+			continue
+		}
+		entry, err := parseEntry(keyName, event.Kv.Value)
+		if err != nil {
+			c.logger.Error("Failed to parse dynamic config value", tag.Key(keyName), tag.Error(err))
+			continue
+		}
+		newValues[keyName] = entry
+	}
+	c.values.Store(newValues)
+	c.logger.Info("Updated dynamic config")
+}
+
+func (c *etcdClient) loadValues() map[string]*cacheEntry {
+	values, _ := c.values.Load().(map[string]*cacheEntry)
+	return values
+}
+
+func (c *etcdClient) getValueWithFilters(key dc.Key, filters map[dc.Filter]interface{}, defaultValue interface{}) (interface{}, error) {
+	entry, ok := c.loadValues()[key.String()]
+	if !ok {
+		return defaultValue, dc.NotFoundError
+	}
+	found := false
+	for _, value := range entry.values {
+		if len(value.filters) == 0 {
+			// special handling for default value (value without any filters)
+			defaultValue = value.value
+			found = true
+			continue
+		}
+		if match(value, filters) {
+			return value.value, nil
+		}
+	}
+	if !found {
+		return defaultValue, dc.NotFoundError
+	}
+	return defaultValue, nil
+}
+
+func (c *etcdClient) etcdKey(keyName string) string {
+	return c.config.Prefix + keyName
+}
+
+func (c *etcdClient) keyName(etcdKey []byte) string {
+	return strings.TrimPrefix(string(etcdKey), c.config.Prefix)
+}
+
+// match will return true if the filters of the value matches the given filters or any subsets
+func match(v *constrainedValue, filters map[dc.Filter]interface{}) bool {
+	if len(v.filters) > len(filters) {
+		return false
+	}
+
+	for filter, filterValue := range v.filters {
+		if filters[filter] == nil || normalizeFilterValue(filters[filter]) != filterValue {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeFilterValue converts the numbers to float64, as the filter values decoded from JSON are,
+// e.g. the shard ID and task type filters are given as int
+func normalizeFilterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return value
+	}
+}
+
+func parseEntry(keyName string, data []byte) (*cacheEntry, error) {
+	var storedValues []*storedValue
+	if err := json.Unmarshal(data, &storedValues); err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		dcEntry: &types.DynamicConfigEntry{
+			Name:   keyName,
+			Values: make([]*types.DynamicConfigValue, 0, len(storedValues)),
+		},
+		values: make([]*constrainedValue, 0, len(storedValues)),
+	}
+	for _, sv := range storedValues {
+		dcValue := &types.DynamicConfigValue{
+			Value: jsonBlob(sv.Value),
+		}
+		value := &constrainedValue{}
+		if err := json.Unmarshal(sv.Value, &value.value); err != nil {
+			return nil, err
+		}
+		if len(sv.Filters) > 0 {
+			value.filters = make(map[dc.Filter]interface{}, len(sv.Filters))
+		}
+		for _, sf := range sv.Filters {
+			var filterValue interface{}
+			if err := json.Unmarshal(sf.Value, &filterValue); err != nil {
+				return nil, err
+			}
+			value.filters[dc.ParseFilter(sf.Name)] = filterValue
+			dcValue.Filters = append(dcValue.Filters, &types.DynamicConfigFilter{
+				Name:  sf.Name,
+				Value: jsonBlob(sf.Value),
+			})
+		}
+		entry.dcEntry.Values = append(entry.dcEntry.Values, dcValue)
+		entry.values = append(entry.values, value)
+	}
+	return entry, nil
+}
+
+func encodeValues(dcValues []*types.DynamicConfigValue) ([]byte, error) {
+	storedValues := make([]*storedValue, 0, len(dcValues))
+	for _, dcValue := range dcValues {
+		value, err := jsonData(dcValue.Value)
+		if err != nil {
+			return nil, err
+		}
+		sv := &storedValue{
+			Value: value,
+		}
+		for _, filter := range dcValue.Filters {
+			filterValue, err := jsonData(filter.Value)
+			if err != nil {
+				return nil, err
+			}
+			sv.Filters = append(sv.Filters, &storedFilter{
+				Name:  filter.Name,
+				Value: filterValue,
+			})
+		}
+		storedValues = append(storedValues, sv)
+	}
+	return json.Marshal(storedValues)
+}
+
+func jsonBlob(data json.RawMessage) *types.DataBlob {
+	return &types.DataBlob{
+		EncodingType: types.EncodingTypeJSON.Ptr(),
+		Data:         []byte(data),
+	}
+}
+
+func jsonData(blob *types.DataBlob) (json.RawMessage, error) {
+	if blob == nil || blob.GetEncodingType() != types.EncodingTypeJSON {
+		return nil, errors.New("unsupported blob encoding")
+	}
+	if !json.Valid(blob.Data) {
+		return nil, errors.New("invalid JSON value")
+	}
+	return json.RawMessage(blob.Data), nil
+}
+
+func validateKeyValue(key dc.Key, dcValue *types.DynamicConfigValue) error {
+	data, err := jsonData(dcValue.Value)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	err = fmt.Errorf("key value pair mismatch, key type: %T, value type: %T", key, value)
+	switch key.(type) {
+	case dc.IntKey:
+		floatVal, ok := value.(float64)
+		if !ok {
+			return err
+		}
+		if floatVal != math.Trunc(floatVal) {
+			return errors.New("value type is not int")
+		}
+	case dc.BoolKey:
+		if _, ok := value.(bool); !ok {
+			return err
+		}
+	case dc.FloatKey:
+		if _, ok := value.(float64); !ok {
+			return err
+		}
+	case dc.StringKey:
+		if _, ok := value.(string); !ok {
+			return err
+		}
+	case dc.DurationKey:
+		durationStr, ok := value.(string)
+		if !ok {
+			return err
+		}
+		if _, err := time.ParseDuration(durationStr); err != nil {
+			return errors.New("value string encoding cannot be parsed into duration")
+		}
+	case dc.MapKey:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return err
+		}
+	case dc.ListKey:
+		if _, ok := value.([]interface{}); !ok {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown key type: %T", key)
+	}
+	return nil
+}
+
+func validateConfig(config *ec.ClientConfig) (*ec.ClientConfig, error) {
+	if config == nil {
+		return nil, errors.New("no config found for etcd based dynamic config client")
+	}
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("no endpoints found for etcd based dynamic config client")
+	}
+	if config.UpdateRetryAttempts < 0 {
+		return nil, errors.New("UpdateRetryAttempts must be non-negative")
+	}
+
+	validated := *config
+	if validated.Prefix == "" {
+		validated.Prefix = defaultPrefix
+	}
+	if validated.DialTimeout <= 0 {
+		validated.DialTimeout = defaultDialTimeout
+	}
+	if validated.RequestTimeout <= 0 {
+		validated.RequestTimeout = defaultRequestTimeout
+	}
+	return &validated, nil
+}
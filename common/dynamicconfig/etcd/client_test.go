@@ -0,0 +1,284 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	dc "github.com/uber/cadence/common/dynamicconfig"
+	ec "github.com/uber/cadence/common/dynamicconfig/etcdconfig"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	testPrefix = "/test/dynamicconfig/"
+)
+
+type etcdClientSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	etcd   *embed.Etcd
+	raw    *clientv3.Client
+	client *etcdClient
+	doneCh chan struct{}
+}
+
+func TestEtcdClientSuite(t *testing.T) {
+	suite.Run(t, new(etcdClientSuite))
+}
+
+func (s *etcdClientSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.etcd = startEtcd(s.T())
+	raw, err := clientv3.New(clientv3.Config{Endpoints: s.endpoints()})
+	s.NoError(err)
+	s.T().Cleanup(func() { raw.Close() })
+	s.raw = raw
+	s.putRaw(dc.TestGetIntPropertyKey, `[{"value": 1000}, {"value": 1001, "filters": [{"name": "domainName", "value": "samples-domain"}]}]`)
+	s.putRaw(dc.TestGetBoolPropertyKey, `[{"value": true}]`)
+
+	s.doneCh = make(chan struct{})
+	client, err := NewEtcdClient(&ec.ClientConfig{
+		Endpoints:           s.endpoints(),
+		Prefix:              testPrefix,
+		UpdateRetryAttempts: 1,
+	}, testlogger.New(s.T()), s.doneCh)
+	s.NoError(err)
+	s.client = client.(*etcdClient)
+}
+
+func (s *etcdClientSuite) TearDownTest() {
+	close(s.doneCh)
+}
+
+func (s *etcdClientSuite) TestNewEtcdClient_InvalidConfig() {
+	_, err := NewEtcdClient(nil, testlogger.New(s.T()), s.doneCh)
+	s.Error(err)
+	_, err = NewEtcdClient(&ec.ClientConfig{}, testlogger.New(s.T()), s.doneCh)
+	s.Error(err)
+	_, err = NewEtcdClient(&ec.ClientConfig{Endpoints: s.endpoints(), UpdateRetryAttempts: -1}, testlogger.New(s.T()), s.doneCh)
+	s.Error(err)
+}
+
+func (s *etcdClientSuite) TestGetValue() {
+	v, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+
+	v, err = s.client.GetIntValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.DomainName: "samples-domain"})
+	s.NoError(err)
+	s.Equal(1001, v)
+
+	v, err = s.client.GetIntValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.DomainName: "other-domain"})
+	s.NoError(err)
+	s.Equal(1000, v)
+
+	b, err := s.client.GetBoolValue(dc.TestGetBoolPropertyKey, nil)
+	s.NoError(err)
+	s.True(b)
+
+	str, err := s.client.GetStringValue(dc.TestGetStringPropertyKey, nil)
+	s.Equal(dc.NotFoundError, err)
+	s.Equal(dc.TestGetStringPropertyKey.DefaultString(), str)
+}
+
+func (s *etcdClientSuite) TestGetValue_WrongType() {
+	s.putRaw(dc.TestGetFloat64PropertyKey, `[{"value": "not a float"}]`)
+	s.Eventually(func() bool {
+		_, ok := s.client.loadValues()[dc.TestGetFloat64PropertyKey.String()]
+		return ok
+	}, time.Second*5, time.Millisecond*10)
+
+	v, err := s.client.GetFloatValue(dc.TestGetFloat64PropertyKey, nil)
+	s.Error(err)
+	s.Equal(dc.TestGetFloat64PropertyKey.DefaultFloat(), v)
+}
+
+func (s *etcdClientSuite) TestWatch() {
+	s.putRaw(dc.TestGetDurationPropertyKey, `[{"value": "10s"}]`)
+	s.Eventually(func() bool {
+		v, err := s.client.GetDurationValue(dc.TestGetDurationPropertyKey, nil)
+		return err == nil && v == 10*time.Second
+	}, time.Second*5, time.Millisecond*10)
+
+	_, err := s.raw.Delete(context.Background(), testPrefix+dc.TestGetBoolPropertyKey.String())
+	s.NoError(err)
+	s.Eventually(func() bool {
+		_, err := s.client.GetBoolValue(dc.TestGetBoolPropertyKey, nil)
+		return err == dc.NotFoundError
+	}, time.Second*5, time.Millisecond*10)
+
+	// invalid values are ignored
+	s.putRaw(dc.TestGetIntPropertyKey, `invalid`)
+	s.putRaw(dc.TestGetStringPropertyKey, `[{"value": "abc"}]`)
+	s.Eventually(func() bool {
+		v, err := s.client.GetStringValue(dc.TestGetStringPropertyKey, nil)
+		return err == nil && v == "abc"
+	}, time.Second*5, time.Millisecond*10)
+	v, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+}
+
+func (s *etcdClientSuite) TestWatch_Compacted() {
+	s.putRaw(dc.TestGetStringPropertyKey, `[{"value": "abc"}]`)
+	resp, err := s.raw.Put(context.Background(), testPrefix+dc.TestGetStringPropertyKey.String(), `[{"value": "def"}]`)
+	s.NoError(err)
+	_, err = s.raw.Compact(context.Background(), resp.Header.Revision)
+	s.NoError(err)
+
+	// the watch is interrupted if the updates after the revision are compacted, so that the values are reloaded
+	revision, err := s.client.watchUntilInterrupted(context.Background(), resp.Header.Revision-2)
+	s.ErrorIs(err, rpctypes.ErrCompacted)
+	s.Equal(resp.Header.Revision-2, revision)
+}
+
+func (s *etcdClientSuite) TestGetValue_NumericFilter() {
+	s.putRaw(dc.TestGetIntPropertyKey, `[{"value": 1000}, {"value": 1001, "filters": [{"name": "shardID", "value": 10}]}]`)
+	s.Eventually(func() bool {
+		v, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.ShardID: 10})
+		return err == nil && v == 1001
+	}, time.Second*5, time.Millisecond*10)
+
+	v, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.ShardID: 11})
+	s.NoError(err)
+	s.Equal(1000, v)
+}
+
+func (s *etcdClientSuite) TestUpdateValue() {
+	values := []*types.DynamicConfigValue{
+		{
+			Value: jsonBlob([]byte(`"abc"`)),
+		},
+		{
+			Value:   jsonBlob([]byte(`"def"`)),
+			Filters: []*types.DynamicConfigFilter{{Name: "domainName", Value: jsonBlob([]byte(`"samples-domain"`))}},
+		},
+	}
+	s.NoError(s.client.UpdateValue(dc.TestGetStringPropertyKey, values))
+	s.Eventually(func() bool {
+		v, err := s.client.GetStringValue(dc.TestGetStringPropertyKey, map[dc.Filter]interface{}{dc.DomainName: "samples-domain"})
+		return err == nil && v == "def"
+	}, time.Second*5, time.Millisecond*10)
+
+	entries, err := s.client.ListValue(dc.TestGetStringPropertyKey)
+	s.NoError(err)
+	s.Equal([]*types.DynamicConfigEntry{{Name: dc.TestGetStringPropertyKey.String(), Values: values}}, entries)
+
+	// deletes the key if no value is given
+	s.NoError(s.client.UpdateValue(dc.TestGetStringPropertyKey, nil))
+	s.Eventually(func() bool {
+		_, err := s.client.GetStringValue(dc.TestGetStringPropertyKey, nil)
+		return err == dc.NotFoundError
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (s *etcdClientSuite) TestUpdateValue_InvalidValue() {
+	s.Error(s.client.UpdateValue(dc.TestGetStringPropertyKey, "abc"))
+	s.Error(s.client.UpdateValue(dc.TestGetStringPropertyKey, []*types.DynamicConfigValue{{Value: jsonBlob([]byte(`123`))}}))
+	s.Error(s.client.UpdateValue(dc.TestGetIntPropertyKey, []*types.DynamicConfigValue{{Value: jsonBlob([]byte(`1.5`))}}))
+	s.Error(s.client.UpdateValue(dc.TestGetDurationPropertyKey, []*types.DynamicConfigValue{{Value: jsonBlob([]byte(`"abc"`))}}))
+	s.Error(s.client.UpdateValue(dc.TestGetMapPropertyKey, []*types.DynamicConfigValue{{Value: &types.DataBlob{Data: []byte(`{}`)}}}))
+}
+
+func (s *etcdClientSuite) TestRestoreValue() {
+	s.Equal(dc.NotFoundError, s.client.RestoreValue(dc.TestGetStringPropertyKey, nil))
+
+	// restores the value with the filters
+	s.NoError(s.client.RestoreValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.DomainName: "samples-domain"}))
+	s.Eventually(func() bool {
+		v, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, map[dc.Filter]interface{}{dc.DomainName: "samples-domain"})
+		return err == nil && v == 1000
+	}, time.Second*5, time.Millisecond*10)
+
+	// restores the fallback value
+	s.NoError(s.client.RestoreValue(dc.TestGetIntPropertyKey, nil))
+	s.Eventually(func() bool {
+		_, err := s.client.GetIntValue(dc.TestGetIntPropertyKey, nil)
+		return err == dc.NotFoundError
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (s *etcdClientSuite) TestListValue() {
+	entries, err := s.client.ListValue(nil)
+	s.NoError(err)
+	s.Len(entries, 2)
+
+	entries, err = s.client.ListValue(dc.TestGetBoolPropertyKey)
+	s.NoError(err)
+	s.Equal([]*types.DynamicConfigEntry{
+		{
+			Name:   dc.TestGetBoolPropertyKey.String(),
+			Values: []*types.DynamicConfigValue{{Value: jsonBlob([]byte(`true`))}},
+		},
+	}, entries)
+}
+
+func (s *etcdClientSuite) putRaw(key dc.Key, value string) {
+	_, err := s.raw.Put(context.Background(), testPrefix+key.String(), value)
+	s.NoError(err)
+}
+
+func (s *etcdClientSuite) endpoints() []string {
+	return []string{s.etcd.Clients[0].Addr().String()}
+}
+
+// startEtcd starts a single member etcd cluster, which is stopped when the test finishes
+func startEtcd(t *testing.T) *embed.Etcd {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL := url.URL{Scheme: "http", Host: freeAddress(t)}
+	peerURL := url.URL{Scheme: "http", Host: freeAddress(t)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(time.Second * 10):
+		t.Fatal("etcd server took too long to start")
+	}
+	return e
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
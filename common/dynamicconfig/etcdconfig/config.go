@@ -0,0 +1,54 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcdconfig
+
+import "time"
+
+// This package is necessary as common/config imports this ClientConfig definition, while the etcd client
+// is in its own go module so that the main module doesn't depend on the etcd client modules
+
+// ClientConfig is the config for the etcd based dynamic config client.
+// Every dynamic config key is stored in its own etcd key under the prefix, and the client watches the prefix
+// so that the updates are applied as soon as they are written to etcd.
+type ClientConfig struct {
+	// Endpoints are the addresses of the etcd cluster members
+	Endpoints []string `yaml:"endpoints"`
+	// Prefix is prepended to the dynamic config key names to form the etcd keys (optional, default to "/cadence/dynamicconfig/")
+	Prefix string `yaml:"prefix"`
+	// DialTimeout is the timeout of establishing a connection to etcd (optional, default to 5s)
+	DialTimeout time.Duration `yaml:"dialTimeout"`
+	// RequestTimeout is the timeout of the requests to etcd (optional, default to 5s)
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// UpdateRetryAttempts is the number of retries of RestoreValue when the key is updated concurrently
+	UpdateRetryAttempts int    `yaml:"updateRetryAttempts"`
+	Username            string `yaml:"username"`
+	Password            string `yaml:"password"`
+	// TLS contains the paths of the certificate files to connect to etcd with TLS (optional)
+	TLS TLS `yaml:"tls"`
+}
+
+// TLS is the TLS config of the etcd client
+type TLS struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	CaFile   string `yaml:"caFile"`
+}
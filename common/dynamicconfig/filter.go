@@ -31,7 +31,7 @@ import (
 type Filter int
 
 func (f Filter) String() string {
-	if f <= UnknownFilter || f > WorkflowID {
+	if f <= UnknownFilter || f >= LastFilterTypeForTest {
 		return filters[UnknownFilter]
 	}
 	return filters[f]
@@ -55,6 +55,8 @@ func ParseFilter(filterName string) Filter {
 		return WorkflowID
 	case "workflowType":
 		return WorkflowType
+	case "activityType":
+		return ActivityType
 	default:
 		return UnknownFilter
 	}
@@ -70,6 +72,7 @@ var filters = []string{
 	"clusterName",
 	"workflowID",
 	"workflowType",
+	"activityType",
 }
 
 const (
@@ -90,6 +93,8 @@ const (
 	WorkflowID
 	// WorkflowType is the workflow type name
 	WorkflowType
+	// ActivityType is the activity type name
+	ActivityType
 
 	// LastFilterTypeForTest must be the last one in this const group for testing purpose
 	LastFilterTypeForTest
@@ -154,6 +159,13 @@ func WorkflowTypeFilter(name string) FilterOption {
 	}
 }
 
+// ActivityTypeFilter filters by activity type name
+func ActivityTypeFilter(name string) FilterOption {
+	return func(filterMap map[Filter]interface{}) {
+		filterMap[ActivityType] = name
+	}
+}
+
 // ToGetDynamicConfigFilterRequest generates a GetDynamicConfigRequest object
 // by converting filters to DynamicConfigFilter objects and setting values
 func ToGetDynamicConfigFilterRequest(configName string, filters []FilterOption) *types.GetDynamicConfigRequest {
@@ -205,6 +205,10 @@ func (c *ESClient) CountByQuery(ctx context.Context, index, query string) (int64
 	return c.Client.Count(ctx, index, query)
 }
 
+func (c *ESClient) UpdateByQuery(ctx context.Context, index, body string) (*client.UpdateByQueryResponse, error) {
+	return c.Client.UpdateByQuery(ctx, index, body)
+}
+
 func (c *ESClient) PutMapping(ctx context.Context, index, root, key, valueType string) error {
 	mapping := buildPutMappingBody(root, key, valueType)
 	body, err := json.Marshal(mapping)
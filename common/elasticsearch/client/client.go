@@ -50,6 +50,8 @@ type Client interface {
 	Scroll(ctx context.Context, index, body, scrollID string) (*Response, error)
 	// Search returns Elasticsearch hit bytes and additional metadata
 	Search(ctx context.Context, index, body string) (*Response, error)
+	// UpdateByQuery runs the script of the body on the documents matching its query, proceeding on version conflicts
+	UpdateByQuery(ctx context.Context, index, body string) (*UpdateByQueryResponse, error)
 }
 
 // Response is used to pass data retrieved from Elasticsearch/OpenSearch to upper layer
@@ -80,3 +82,12 @@ type SearchHit struct {
 	Sort   []interface{}   // sort information
 	Source json.RawMessage // stored document source
 }
+
+// UpdateByQueryResponse is the outcome of an update by query
+type UpdateByQueryResponse struct {
+	Total            int64 // number of documents matching the query
+	Updated          int64 // number of documents updated
+	Noops            int64 // number of documents left unchanged by the script
+	VersionConflicts int64 // number of documents changed while being updated
+	Failures         int64 // number of documents which failed to be updated
+}
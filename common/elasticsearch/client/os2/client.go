@@ -358,6 +358,45 @@ func (c *OS2) Search(ctx context.Context, index, body string) (*client.Response,
 	}, nil
 }
 
+func (c *OS2) UpdateByQuery(ctx context.Context, index, body string) (*client.UpdateByQueryResponse, error) {
+	resp, err := c.client.UpdateByQuery(
+		[]string{index},
+		c.client.UpdateByQuery.WithContext(ctx),
+		c.client.UpdateByQuery.WithBody(strings.NewReader(body)),
+		c.client.UpdateByQuery.WithConflicts("proceed"),
+		c.client.UpdateByQuery.WithRefresh(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("OpenSearch UpdateByQuery: %w", err)
+	}
+
+	defer closeBody(resp)
+	if resp.IsError() {
+		return nil, c.parseError(resp)
+	}
+
+	type UpdateByQueryResponse struct {
+		Total            int64             `json:"total"`
+		Updated          int64             `json:"updated"`
+		Noops            int64             `json:"noops"`
+		VersionConflicts int64             `json:"version_conflicts"`
+		Failures         []json.RawMessage `json:"failures"`
+	}
+
+	var updateResp UpdateByQueryResponse
+	if err := c.decoder.Decode(resp.Body, &updateResp); err != nil {
+		return nil, fmt.Errorf("decoding OpenSearch UpdateByQuery result: %w", err)
+	}
+
+	return &client.UpdateByQueryResponse{
+		Total:            updateResp.Total,
+		Updated:          updateResp.Updated,
+		Noops:            updateResp.Noops,
+		VersionConflicts: updateResp.VersionConflicts,
+		Failures:         int64(len(updateResp.Failures)),
+	}, nil
+}
+
 func (e *osError) Error() string {
 	return fmt.Sprintf("Status code: %d, Type: %s, Reason: %s", e.Status, e.Details.Type, e.Details.Reason)
 }
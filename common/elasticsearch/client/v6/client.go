@@ -99,6 +99,20 @@ func (c *ElasticV6) Count(ctx context.Context, index, query string) (int64, erro
 	return c.client.Count(index).BodyString(query).Do(ctx)
 }
 
+func (c *ElasticV6) UpdateByQuery(ctx context.Context, index, body string) (*client.UpdateByQueryResponse, error) {
+	resp, err := c.client.UpdateByQuery(index).Body(body).ProceedOnVersionConflict().Refresh("true").Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &client.UpdateByQueryResponse{
+		Total:            resp.Total,
+		Updated:          resp.Updated,
+		Noops:            resp.Noops,
+		VersionConflicts: resp.VersionConflicts,
+		Failures:         int64(len(resp.Failures)),
+	}, nil
+}
+
 func (c *ElasticV6) ClearScroll(ctx context.Context, scrollID string) error {
 	return elastic.NewScrollService(c.client).ScrollId(scrollID).Clear(ctx)
 }
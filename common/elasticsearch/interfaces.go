@@ -117,6 +117,8 @@ type (
 		PutMapping(ctx context.Context, index, root, key, valueType string) error
 		// CreateIndex creates a new index
 		CreateIndex(ctx context.Context, index string) error
+		// UpdateByQuery runs a script on the documents matching a query, the body holds both of them
+		UpdateByQuery(ctx context.Context, index, body string) (*esc.UpdateByQueryResponse, error)
 
 		IsNotFoundError(err error) bool
 	}
@@ -29,6 +29,7 @@ import (
 
 	elasticsearch "github.com/uber/cadence/common/elasticsearch"
 	bulk "github.com/uber/cadence/common/elasticsearch/bulk"
+	client "github.com/uber/cadence/common/elasticsearch/client"
 	persistence "github.com/uber/cadence/common/persistence"
 )
 
@@ -236,3 +237,26 @@ func (_m *GenericClient) SearchForOneClosedExecution(ctx context.Context, index
 
 	return r0, r1
 }
+
+// UpdateByQuery provides a mock function with given fields: ctx, index, body
+func (_m *GenericClient) UpdateByQuery(ctx context.Context, index string, body string) (*client.UpdateByQueryResponse, error) {
+	ret := _m.Called(ctx, index, body)
+
+	var r0 *client.UpdateByQueryResponse
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *client.UpdateByQueryResponse); ok {
+		r0 = rf(ctx, index, body)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.UpdateByQueryResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, index, body)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
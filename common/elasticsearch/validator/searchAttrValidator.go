@@ -37,6 +37,8 @@ type SearchAttributesValidator struct {
 
 	enableQueryAttributeValidation    dynamicconfig.BoolPropertyFn
 	validSearchAttributes             dynamicconfig.MapPropertyFn
+	deprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	removedSearchAttributes           dynamicconfig.MapPropertyFn
 	searchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	searchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	searchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -47,6 +49,8 @@ func NewSearchAttributesValidator(
 	logger log.Logger,
 	enableQueryAttributeValidation dynamicconfig.BoolPropertyFn,
 	validSearchAttributes dynamicconfig.MapPropertyFn,
+	deprecatedSearchAttributes dynamicconfig.MapPropertyFn,
+	removedSearchAttributes dynamicconfig.MapPropertyFn,
 	searchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter,
 	searchAttributesSizeOfValueLimit dynamicconfig.IntPropertyFnWithDomainFilter,
 	searchAttributesTotalSizeLimit dynamicconfig.IntPropertyFnWithDomainFilter,
@@ -55,6 +59,8 @@ func NewSearchAttributesValidator(
 		logger:                            logger,
 		enableQueryAttributeValidation:    enableQueryAttributeValidation,
 		validSearchAttributes:             validSearchAttributes,
+		deprecatedSearchAttributes:        deprecatedSearchAttributes,
+		removedSearchAttributes:           removedSearchAttributes,
 		searchAttributesNumberOfKeysLimit: searchAttributesNumberOfKeysLimit,
 		searchAttributesSizeOfValueLimit:  searchAttributesSizeOfValueLimit,
 		searchAttributesTotalSizeLimit:    searchAttributesTotalSizeLimit,
@@ -84,7 +90,19 @@ func (sv *SearchAttributesValidator) ValidateSearchAttributes(input *types.Searc
 		validateAttr = validateAttrFn()
 	}
 	validAttr := sv.validSearchAttributes()
+	deprecatedAttr := sv.getSearchAttributes(sv.deprecatedSearchAttributes)
+	removedAttr := sv.getSearchAttributes(sv.removedSearchAttributes)
 	for key, val := range fields {
+		// verify: key is not removed, regardless of whether attribute validation is enabled
+		if _, isRemoved := removedAttr[key]; isRemoved {
+			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain)).
+				Error("write to removed search attribute")
+			return &types.BadRequestError{Message: fmt.Sprintf("%s is a removed search attribute key", key)}
+		}
+		if reason, isDeprecated := deprecatedAttr[key]; isDeprecated {
+			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain), tag.Value(reason)).
+				Warn("write to deprecated search attribute")
+		}
 		if validateAttr {
 			// verify: key is whitelisted
 			if !sv.isValidSearchAttributesKey(validAttr, key) {
@@ -124,6 +142,37 @@ func (sv *SearchAttributesValidator) ValidateSearchAttributes(input *types.Searc
 	return nil
 }
 
+// DropRemovedSearchAttributes deletes the removed keys from search attributes and returns the deleted keys.
+// It's used instead of rejecting the keys where the search attributes come from a running workflow,
+// as rejecting them would fail its decisions until the workflow code is changed.
+func (sv *SearchAttributesValidator) DropRemovedSearchAttributes(input *types.SearchAttributes, domain string) []string {
+	removedAttr := sv.getSearchAttributes(sv.removedSearchAttributes)
+	if len(removedAttr) == 0 {
+		return nil
+	}
+
+	var dropped []string
+	for key := range input.GetIndexedFields() {
+		if _, isRemoved := removedAttr[key]; isRemoved {
+			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain)).
+				Warn("drop write to removed search attribute")
+			delete(input.IndexedFields, key)
+			dropped = append(dropped, key)
+		}
+	}
+	return dropped
+}
+
+// getSearchAttributes returns the search attributes of the given property, or nil if the property is not set
+func (sv *SearchAttributesValidator) getSearchAttributes(
+	attributesFn dynamicconfig.MapPropertyFn,
+) map[string]interface{} {
+	if attributesFn == nil {
+		return nil
+	}
+	return attributesFn()
+}
+
 // isValidSearchAttributesKey return true if key is registered
 func (sv *SearchAttributesValidator) isValidSearchAttributesKey(
 	validAttr map[string]interface{},
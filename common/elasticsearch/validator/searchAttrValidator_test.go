@@ -48,6 +48,8 @@ func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes() {
 	validator := NewSearchAttributesValidator(log.NewNoop(),
 		dynamicconfig.GetBoolPropertyFn(true),
 		dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys()),
+		dynamicconfig.GetMapPropertyFn(map[string]interface{}{}),
+		dynamicconfig.GetMapPropertyFn(map[string]interface{}{}),
 		dynamicconfig.GetIntPropertyFilteredByDomain(numOfKeysLimit),
 		dynamicconfig.GetIntPropertyFilteredByDomain(sizeOfValueLimit),
 		dynamicconfig.GetIntPropertyFilteredByDomain(sizeOfTotalLimit))
@@ -120,3 +122,45 @@ func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes() {
 	err = validator.ValidateSearchAttributes(attr, domain)
 	s.Equal(`total size 44 exceed limit`, err.Error())
 }
+
+func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes_DeprecatedAndRemovedKeys() {
+	validAttr := map[string]interface{}{}
+	for key, valueType := range definition.GetDefaultIndexedKeys() {
+		validAttr[key] = valueType
+	}
+	validAttr["RemovedKey"] = types.IndexedValueTypeKeyword
+
+	for _, enableValidation := range []bool{true, false} {
+		validator := NewSearchAttributesValidator(log.NewNoop(),
+			dynamicconfig.GetBoolPropertyFn(enableValidation),
+			dynamicconfig.GetMapPropertyFn(validAttr),
+			dynamicconfig.GetMapPropertyFn(map[string]interface{}{"CustomIntField": "use CustomDoubleField"}),
+			dynamicconfig.GetMapPropertyFn(map[string]interface{}{"RemovedKey": "unused"}),
+			dynamicconfig.GetIntPropertyFilteredByDomain(10),
+			dynamicconfig.GetIntPropertyFilteredByDomain(100),
+			dynamicconfig.GetIntPropertyFilteredByDomain(1000))
+
+		domain := "domain"
+		attr := &types.SearchAttributes{
+			IndexedFields: map[string][]byte{
+				"CustomIntField": []byte(`1`),
+			},
+		}
+		err := validator.ValidateSearchAttributes(attr, domain)
+		s.NoError(err)
+
+		attr.IndexedFields = map[string][]byte{
+			"CustomIntField": []byte(`1`),
+			"RemovedKey":     []byte(`"keyword"`),
+		}
+		err = validator.ValidateSearchAttributes(attr, domain)
+		s.Error(err)
+		s.Equal("RemovedKey is a removed search attribute key", err.Error())
+
+		dropped := validator.DropRemovedSearchAttributes(attr, domain)
+		s.Equal([]string{"RemovedKey"}, dropped)
+		s.Equal(map[string][]byte{"CustomIntField": []byte(`1`)}, attr.IndexedFields)
+		s.NoError(validator.ValidateSearchAttributes(attr, domain))
+		s.Empty(validator.DropRemovedSearchAttributes(attr, domain))
+	}
+}
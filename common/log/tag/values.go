@@ -123,6 +123,8 @@ var (
 	ComponentESVisibilityManager        = component("es-visibility-manager")
 	ComponentArchiver                   = component("archiver")
 	ComponentBatcher                    = component("batcher")
+	ComponentScheduler                  = component("scheduler")
+	ComponentVisibilityBackfill         = component("visibility-backfill")
 	ComponentWorker                     = component("worker")
 	ComponentServiceResolver            = component("service-resolver")
 	ComponentFailoverCoordinator        = component("failover-coordinator")
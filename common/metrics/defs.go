@@ -2105,6 +2105,10 @@ const (
 
 	PriorityTaskSubmitRequest
 	PriorityTaskSubmitLatency
+	PriorityTaskDomainQueueSize
+	PriorityTaskDomainQueueLatency
+	PriorityTaskDomainThrottled
+	PriorityTaskDomainBackoff
 
 	KafkaConsumerMessageIn
 	KafkaConsumerMessageAck
@@ -2179,6 +2183,9 @@ const (
 
 	HashringViewIdentifier
 
+	WorkflowConcurrencyLimitExceededCounter
+	WorkflowConcurrencyLimitQueuedCounter
+
 	NumCommonMetrics // Needs to be last on this list for iota numbering
 )
 
@@ -2271,6 +2278,7 @@ const (
 	DecisionTypeContinueAsNewCounter
 	DecisionTypeSignalExternalWorkflowCounter
 	DecisionTypeUpsertWorkflowSearchAttributesCounter
+	RemovedSearchAttributesDroppedCounter
 	EmptyCompletionDecisionsCounter
 	MultipleCompletionDecisionsCounter
 	FailedDecisionsCounter
@@ -2280,6 +2288,7 @@ const (
 	DataInconsistentCounter
 	TimerResurrectionCounter
 	ActivityResurrectionCounter
+	ActivityPartitionConfigFailureCounter
 	AutoResetPointsLimitExceededCounter
 	AutoResetPointCorruptionCounter
 	ConcurrencyUpdateFailureCounter
@@ -2500,6 +2509,13 @@ const (
 	TaskLagPerTaskListGauge
 	TaskBacklogPerTaskListGauge
 	TaskCountPerTaskListGauge
+	BufferedTasksPerPriorityGauge
+	BufferedFairnessKeysPerTaskListGauge
+	TaskListPartitionsGauge
+	TaskListDrainingPartitionsGauge
+	TaskListPartitionUpscaleCounter
+	TaskListPartitionDownscaleCounter
+	ActivityTypeThrottlePerTaskListCounter
 
 	NumMatchingMetrics
 )
@@ -2711,6 +2727,10 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		ParallelTaskTaskProcessingLatency:                            {metricName: "paralleltask_task_processing_latency", metricType: Timer},
 		PriorityTaskSubmitRequest:                                    {metricName: "prioritytask_submit_request", metricType: Counter},
 		PriorityTaskSubmitLatency:                                    {metricName: "prioritytask_submit_latency", metricType: Timer},
+		PriorityTaskDomainQueueSize:                                  {metricName: "prioritytask_domain_queue_size", metricType: Gauge},
+		PriorityTaskDomainQueueLatency:                               {metricName: "prioritytask_domain_queue_latency", metricType: Timer},
+		PriorityTaskDomainThrottled:                                  {metricName: "prioritytask_domain_throttled", metricType: Counter},
+		PriorityTaskDomainBackoff:                                    {metricName: "prioritytask_domain_backoff", metricType: Counter},
 		KafkaConsumerMessageIn:                                       {metricName: "kafka_consumer_message_in", metricType: Counter},
 		KafkaConsumerMessageAck:                                      {metricName: "kafka_consumer_message_ack", metricType: Counter},
 		KafkaConsumerMessageNack:                                     {metricName: "kafka_consumer_message_nack", metricType: Counter},
@@ -2818,6 +2838,9 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		IsolationGroupStateHealthy:           {metricName: "isolation_group_healthy", metricType: Counter},
 		ValidatedWorkflowCount:               {metricName: "task_validator_count", metricType: Counter},
 		HashringViewIdentifier:               {metricName: "hashring_view_identifier", metricType: Counter},
+
+		WorkflowConcurrencyLimitExceededCounter: {metricName: "workflow_concurrency_limit_exceeded", metricType: Counter},
+		WorkflowConcurrencyLimitQueuedCounter:   {metricName: "workflow_concurrency_limit_queued", metricType: Counter},
 	},
 	History: {
 		TaskRequests:             {metricName: "task_requests", metricType: Counter},
@@ -2901,6 +2924,7 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		DecisionTypeContinueAsNewCounter:                             {metricName: "continue_as_new_decision", metricType: Counter},
 		DecisionTypeSignalExternalWorkflowCounter:                    {metricName: "signal_external_workflow_decision", metricType: Counter},
 		DecisionTypeUpsertWorkflowSearchAttributesCounter:            {metricName: "upsert_workflow_search_attributes_decision", metricType: Counter},
+		RemovedSearchAttributesDroppedCounter:                        {metricName: "removed_search_attributes_dropped", metricType: Counter},
 		DecisionTypeChildWorkflowCounter:                             {metricName: "child_workflow_decision", metricType: Counter},
 		EmptyCompletionDecisionsCounter:                              {metricName: "empty_completion_decisions", metricType: Counter},
 		MultipleCompletionDecisionsCounter:                           {metricName: "multiple_completion_decisions", metricType: Counter},
@@ -2911,6 +2935,7 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		DataInconsistentCounter:                                      {metricName: "data_inconsistent", metricType: Counter},
 		TimerResurrectionCounter:                                     {metricName: "timer_resurrection", metricType: Counter},
 		ActivityResurrectionCounter:                                  {metricName: "activity_resurrection", metricType: Counter},
+		ActivityPartitionConfigFailureCounter:                        {metricName: "activity_partition_config_failure", metricType: Counter},
 		AutoResetPointsLimitExceededCounter:                          {metricName: "auto_reset_points_exceed_limit", metricType: Counter},
 		AutoResetPointCorruptionCounter:                              {metricName: "auto_reset_point_corruption", metricType: Counter},
 		ConcurrencyUpdateFailureCounter:                              {metricName: "concurrency_update_failure", metricType: Counter},
@@ -3128,6 +3153,13 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		TaskLagPerTaskListGauge:                     {metricName: "task_lag_per_tl", metricType: Gauge},
 		TaskBacklogPerTaskListGauge:                 {metricName: "task_backlog_per_tl", metricType: Gauge},
 		TaskCountPerTaskListGauge:                   {metricName: "task_count_per_tl", metricType: Gauge},
+		BufferedTasksPerPriorityGauge:               {metricName: "buffered_tasks_per_priority_per_tl", metricType: Gauge},
+		BufferedFairnessKeysPerTaskListGauge:        {metricName: "buffered_fairness_keys_per_tl", metricType: Gauge},
+		TaskListPartitionsGauge:                     {metricName: "task_list_partitions_per_tl", metricType: Gauge},
+		TaskListDrainingPartitionsGauge:             {metricName: "task_list_draining_partitions_per_tl", metricType: Gauge},
+		TaskListPartitionUpscaleCounter:             {metricName: "task_list_partition_upscale", metricType: Counter},
+		TaskListPartitionDownscaleCounter:           {metricName: "task_list_partition_downscale", metricType: Counter},
+		ActivityTypeThrottlePerTaskListCounter:      {metricName: "activity_type_throttle_count_per_tl", metricType: Counter},
 	},
 	Worker: {
 		ReplicatorMessages:                            {metricName: "replicator_messages"},
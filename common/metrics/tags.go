@@ -59,6 +59,7 @@ const (
 	host                   = "host"
 	pollerIsolationGroup   = "poller_isolation_group"
 	asyncWFRequestType     = "async_wf_request_type"
+	taskPriority           = "task_priority"
 
 	allValue     = "all"
 	unknownValue = "_unknown_"
@@ -242,6 +243,11 @@ func AsyncWFRequestTypeTag(value string) Tag {
 	return metricWithUnknown(asyncWFRequestType, value)
 }
 
+// TaskPriorityTag returns a new TaskPriority tag
+func TaskPriorityTag(value int) Tag {
+	return simpleMetric{key: taskPriority, value: strconv.Itoa(value)}
+}
+
 // PartitionConfigTags returns a list of partition config tags
 func PartitionConfigTags(partitionConfig map[string]string) []Tag {
 	tags := make([]Tag, 0, len(partitionConfig))
@@ -39,6 +39,15 @@ import (
 const (
 	IsolationGroupKey = "isolation-group"
 	WorkflowIDKey     = "wf-id"
+	// PriorityKey is the key of the priority of the decision and activity tasks of the workflow.
+	// It's not used for partitioning, but it's persisted and inherited the same way as the other keys.
+	PriorityKey = "priority"
+	// FairnessKey is the key of the fairness key of the decision and activity tasks of the workflow,
+	// which is used to dispatch the tasks of a task list by round robin across the keys
+	FairnessKey = "fairness-key"
+	// ActivityTypeKey is the key of the activity type of an activity task, which is used to limit the dispatch rate
+	// of the activity tasks by activity type. It's only set for activity tasks.
+	ActivityTypeKey = "activity-type"
 )
 
 // ErrNoIsolationGroupsAvailable is returned when there are no available isolation-groups
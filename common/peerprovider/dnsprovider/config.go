@@ -0,0 +1,69 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package dnsprovider
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uber/cadence/common/service"
+)
+
+const (
+	defaultRefreshInterval = 10 * time.Second
+)
+
+// Config contains the DNS peer provider config items
+type Config struct {
+	// Names is the map of service name to the DNS name that resolves to all the hosts of the service,
+	// e.g. frontend: "cadence-frontend-headless.cadence.svc.cluster.local" for a kubernetes headless service.
+	Names map[string]string `yaml:"names"`
+	// SRV makes the provider look up the SRV records of the named ports, i.e. _tchannel._tcp.<name> and
+	// _grpc._tcp.<name>, instead of the A records. The ports of A records are the ones in the services config.
+	SRV bool `yaml:"srv"`
+	// RefreshInterval is the interval to resolve the names again, default to 10s
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+}
+
+func (c *Config) validate() error {
+	if len(c.Names) == 0 {
+		return fmt.Errorf("dns peer provider config missing `names` param")
+	}
+	names := make(map[string]string, len(c.Names))
+	for svc, name := range c.Names {
+		if len(name) == 0 {
+			return fmt.Errorf("dns peer provider config has empty name for service %q", svc)
+		}
+		fullName := service.FullName(svc)
+		if _, ok := names[fullName]; ok {
+			return fmt.Errorf("dns peer provider config has duplicate names for service %q", svc)
+		}
+		names[fullName] = name
+	}
+	c.Names = names
+
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = defaultRefreshInterval
+	}
+	return nil
+}
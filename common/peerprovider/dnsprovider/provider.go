@@ -0,0 +1,347 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package dnsprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
+)
+
+type (
+	// Provider resolves the hosts of every service from DNS periodically,
+	// and announces membership changes when the resolved hosts change
+	Provider struct {
+		status     int32
+		service    string
+		self       membership.HostInfo
+		config     *Config
+		portMaps   map[string]membership.PortMap
+		resolver   dnsResolver
+		logger     log.Logger
+		shutdownCh chan struct{}
+		shutdownWG sync.WaitGroup
+
+		mu          sync.RWMutex
+		members     map[string][]membership.HostInfo
+		evicted     bool
+		subscribers map[string]chan<- *membership.ChangedEvent
+	}
+
+	dnsResolver interface {
+		LookupHost(ctx context.Context, host string) (addrs []string, err error)
+		LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+	}
+)
+
+const (
+	resolveTimeout = 5 * time.Second
+)
+
+// srvPorts are the named ports looked up in SRV mode
+var srvPorts = []string{membership.PortTchannel, membership.PortGRPC}
+
+var _ membership.PeerProvider = (*Provider)(nil)
+
+// New creates a DNS peer provider. selfAddress is the tchannel address this host listens on,
+// and portMaps are the ports of every service, which are used as the ports of A records.
+func New(
+	service string,
+	config *Config,
+	selfAddress string,
+	portMaps map[string]membership.PortMap,
+	logger log.Logger,
+) (*Provider, error) {
+	return newProvider(service, config, selfAddress, portMaps, net.DefaultResolver, logger)
+}
+
+func newProvider(
+	service string,
+	config *Config,
+	selfAddress string,
+	portMaps map[string]membership.PortMap,
+	resolver dnsResolver,
+	logger log.Logger,
+) (*Provider, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if !config.SRV {
+		for svc := range config.Names {
+			if _, ok := portMaps[svc][membership.PortTchannel]; !ok {
+				return nil, fmt.Errorf("dns peer provider: no tchannel port for service %q", svc)
+			}
+		}
+	}
+
+	return &Provider{
+		status:      common.DaemonStatusInitialized,
+		service:     service,
+		self:        membership.NewDetailedHostInfo(selfAddress, selfAddress, portMaps[service]),
+		config:      config,
+		portMaps:    portMaps,
+		resolver:    resolver,
+		logger:      logger,
+		shutdownCh:  make(chan struct{}),
+		members:     map[string][]membership.HostInfo{},
+		subscribers: map[string]chan<- *membership.ChangedEvent{},
+	}, nil
+}
+
+// Start resolves the hosts and starts refreshing them periodically
+func (p *Provider) Start() {
+	if !atomic.CompareAndSwapInt32(
+		&p.status,
+		common.DaemonStatusInitialized,
+		common.DaemonStatusStarted,
+	) {
+		return
+	}
+
+	p.refresh()
+
+	p.shutdownWG.Add(1)
+	go p.refreshLoop()
+}
+
+// Stop stops refreshing the hosts
+func (p *Provider) Stop() {
+	if !atomic.CompareAndSwapInt32(
+		&p.status,
+		common.DaemonStatusStarted,
+		common.DaemonStatusStopped,
+	) {
+		return
+	}
+
+	close(p.shutdownCh)
+	if success := common.AwaitWaitGroup(&p.shutdownWG, time.Minute); !success {
+		p.logger.Warn("dns peer provider timed out on shutdown.")
+	}
+}
+
+// GetMembers returns all hosts of a service
+func (p *Provider) GetMembers(service string) ([]membership.HostInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	res := make([]membership.HostInfo, 0, len(p.members[service]))
+	for _, member := range p.members[service] {
+		if p.evicted && member.GetAddress() == p.self.GetAddress() {
+			continue
+		}
+		res = append(res, member)
+	}
+	return res, nil
+}
+
+// WhoAmI returns address of this instance
+func (p *Provider) WhoAmI() (membership.HostInfo, error) {
+	return p.self, nil
+}
+
+// SelfEvict removes this host from its own view of the ring. Other hosts remove it
+// once it's removed from DNS, e.g. when a kubernetes pod is terminating.
+func (p *Provider) SelfEvict() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.evicted {
+		return nil
+	}
+	p.evicted = true
+	p.notify(&membership.ChangedEvent{HostsRemoved: []string{p.self.GetAddress()}})
+	return nil
+}
+
+// Subscribe allows to be subscribed for ring changes
+func (p *Provider) Subscribe(name string, notifyChannel chan<- *membership.ChangedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok := p.subscribers[name]
+	if ok {
+		return fmt.Errorf("%q already subscribed to dns peer provider", name)
+	}
+
+	p.subscribers[name] = notifyChannel
+	return nil
+}
+
+func (p *Provider) refreshLoop() {
+	defer p.shutdownWG.Done()
+
+	ticker := time.NewTicker(p.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.shutdownCh:
+			return
+		case <-ticker.C:
+			p.refresh()
+		}
+	}
+}
+
+// refresh resolves the hosts of all the services, and notifies the subscribers if they changed.
+// The previous hosts of a service are kept if they can't be resolved, so that a DNS outage doesn't empty the ring.
+func (p *Provider) refresh() {
+	resolved := make(map[string][]membership.HostInfo, len(p.config.Names))
+	for svc, name := range p.config.Names {
+		hosts, err := p.resolve(svc, name)
+		if err != nil {
+			p.logger.Warn("failed to resolve hosts of service, keeping previous hosts", tag.Service(svc), tag.Address(name), tag.Error(err))
+			continue
+		}
+		resolved[svc] = hosts
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	members := make(map[string][]membership.HostInfo, len(p.config.Names))
+	change := &membership.ChangedEvent{}
+	for svc := range p.config.Names {
+		hosts, ok := resolved[svc]
+		if !ok {
+			members[svc] = p.members[svc]
+			continue
+		}
+		members[svc] = hosts
+		diff(p.members[svc], hosts, change)
+	}
+	p.members = members
+
+	if len(change.HostsAdded) == 0 && len(change.HostsUpdated) == 0 && len(change.HostsRemoved) == 0 {
+		return
+	}
+	p.logger.Info("dns peer provider members changed",
+		tag.Dynamic("hosts-added", change.HostsAdded),
+		tag.Dynamic("hosts-updated", change.HostsUpdated),
+		tag.Dynamic("hosts-removed", change.HostsRemoved),
+	)
+	p.notify(change)
+}
+
+func (p *Provider) resolve(service, name string) ([]membership.HostInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	portMaps := map[string]membership.PortMap{}
+	if p.config.SRV {
+		for _, portName := range srvPorts {
+			_, srvs, err := p.resolver.LookupSRV(ctx, portName, "tcp", name)
+			if err != nil {
+				return nil, fmt.Errorf("lookup %v srv records: %w", portName, err)
+			}
+			for _, srv := range srvs {
+				ips, err := p.resolver.LookupHost(ctx, srv.Target)
+				if err != nil {
+					p.logger.Warn("could not resolve srv dns host", tag.Address(srv.Target), tag.Error(err))
+					continue
+				}
+				for _, ip := range ips {
+					if _, ok := portMaps[ip]; !ok {
+						portMaps[ip] = membership.PortMap{}
+					}
+					portMaps[ip][portName] = srv.Port
+				}
+			}
+		}
+	} else {
+		ips, err := p.resolver.LookupHost(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			portMaps[ip] = p.portMaps[service]
+		}
+	}
+
+	hosts := make([]membership.HostInfo, 0, len(portMaps))
+	for ip, portMap := range portMaps {
+		port, ok := portMap[membership.PortTchannel]
+		if !ok {
+			p.logger.Warn("dns host has no tchannel port", tag.Address(ip), tag.Service(service))
+			continue
+		}
+		addr := net.JoinHostPort(ip, strconv.Itoa(int(port)))
+		hosts = append(hosts, membership.NewDetailedHostInfo(addr, addr, portMap))
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].GetAddress() < hosts[j].GetAddress()
+	})
+	return hosts, nil
+}
+
+// notify must be called with the lock held
+func (p *Provider) notify(change *membership.ChangedEvent) {
+	for name, ch := range p.subscribers {
+		select {
+		case ch <- change:
+		default:
+			p.logger.Error("Failed to send listener notification, channel full", tag.Subscriber(name))
+		}
+	}
+}
+
+// diff adds the differences between the previous and current hosts to the change
+func diff(previous, current []membership.HostInfo, change *membership.ChangedEvent) {
+	previousHosts := make(map[string]membership.HostInfo, len(previous))
+	for _, host := range previous {
+		previousHosts[host.GetAddress()] = host
+	}
+	for _, host := range current {
+		previousHost, ok := previousHosts[host.GetAddress()]
+		if !ok {
+			change.HostsAdded = append(change.HostsAdded, host.GetAddress())
+		} else if !samePorts(previousHost, host) {
+			change.HostsUpdated = append(change.HostsUpdated, host.GetAddress())
+		}
+		delete(previousHosts, host.GetAddress())
+	}
+	for addr := range previousHosts {
+		change.HostsRemoved = append(change.HostsRemoved, addr)
+	}
+}
+
+func samePorts(a, b membership.HostInfo) bool {
+	for _, portName := range srvPorts {
+		addrA, errA := a.GetNamedAddress(portName)
+		addrB, errB := b.GetNamedAddress(portName)
+		if addrA != addrB || (errA == nil) != (errB == nil) {
+			return false
+		}
+	}
+	return true
+}
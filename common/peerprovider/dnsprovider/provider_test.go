@@ -0,0 +1,256 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package dnsprovider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/service"
+)
+
+type fakeResolver struct {
+	sync.Mutex
+	hosts map[string][]string
+	srvs  map[string][]*net.SRV
+	err   error
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	key := "_" + service + "._" + proto + "." + name
+	srvs, ok := r.srvs[key]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: key, IsNotFound: true}
+	}
+	return key, srvs, nil
+}
+
+func (r *fakeResolver) setHosts(host string, addrs ...string) {
+	r.Lock()
+	defer r.Unlock()
+	r.hosts[host] = addrs
+}
+
+func (r *fakeResolver) setErr(err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.err = err
+}
+
+var testPortMaps = map[string]membership.PortMap{
+	service.Frontend: {membership.PortTchannel: 7933, membership.PortGRPC: 7833},
+	service.History:  {membership.PortTchannel: 7934, membership.PortGRPC: 7834},
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &Config{}
+	assert.Error(t, cfg.validate())
+
+	cfg = &Config{Names: map[string]string{"frontend": ""}}
+	assert.Error(t, cfg.validate())
+
+	cfg = &Config{Names: map[string]string{"frontend": "a", service.Frontend: "b"}}
+	assert.Error(t, cfg.validate())
+
+	cfg = &Config{Names: map[string]string{"frontend": "frontend.local"}}
+	require.NoError(t, cfg.validate())
+	assert.Equal(t, map[string]string{service.Frontend: "frontend.local"}, cfg.Names)
+	assert.Equal(t, defaultRefreshInterval, cfg.RefreshInterval)
+}
+
+func TestNew_MissingPorts(t *testing.T) {
+	_, err := newProvider(service.Matching, &Config{Names: map[string]string{"matching": "matching.local"}}, "10.0.0.1:7935", testPortMaps, &fakeResolver{}, testlogger.New(t))
+	assert.Error(t, err)
+
+	// ports of srv records are not needed
+	_, err = newProvider(service.Matching, &Config{Names: map[string]string{"matching": "matching.local"}, SRV: true}, "10.0.0.1:7935", testPortMaps, &fakeResolver{}, testlogger.New(t))
+	assert.NoError(t, err)
+}
+
+func TestARecords(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{
+		"frontend.local": {"10.0.0.2", "10.0.0.1"},
+		"history.local":  {"10.0.0.3"},
+	}}
+	p, err := newProvider(
+		service.Frontend,
+		&Config{Names: map[string]string{"frontend": "frontend.local", "history": "history.local"}},
+		"10.0.0.1:7933",
+		testPortMaps,
+		resolver,
+		testlogger.New(t),
+	)
+	require.NoError(t, err)
+
+	ch := make(chan *membership.ChangedEvent, 1)
+	require.NoError(t, p.Subscribe("test", ch))
+	assert.Error(t, p.Subscribe("test", ch))
+
+	p.Start()
+	defer p.Stop()
+	<-ch
+
+	members, err := p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	assert.Equal(t, []membership.HostInfo{
+		membership.NewDetailedHostInfo("10.0.0.1:7933", "10.0.0.1:7933", testPortMaps[service.Frontend]),
+		membership.NewDetailedHostInfo("10.0.0.2:7933", "10.0.0.2:7933", testPortMaps[service.Frontend]),
+	}, members)
+
+	members, err = p.GetMembers(service.History)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	addr, err := members[0].GetNamedAddress(membership.PortGRPC)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3:7834", addr)
+
+	members, err = p.GetMembers(service.Worker)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	self, err := p.WhoAmI()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:7933", self.GetAddress())
+
+	// hosts are changed
+	resolver.setHosts("frontend.local", "10.0.0.1", "10.0.0.4")
+	p.refresh()
+	change := <-ch
+	assert.Equal(t, []string{"10.0.0.4:7933"}, change.HostsAdded)
+	assert.Equal(t, []string{"10.0.0.2:7933"}, change.HostsRemoved)
+
+	// nothing is changed
+	p.refresh()
+	assert.Empty(t, ch)
+
+	// previous hosts are kept if the names can't be resolved
+	resolver.setErr(errors.New("dns is down"))
+	p.refresh()
+	assert.Empty(t, ch)
+	members, err = p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+}
+
+func TestSRVRecords(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]string{
+			"pod-1.frontend.local": {"10.0.0.1"},
+			"pod-2.frontend.local": {"10.0.0.2"},
+		},
+		srvs: map[string][]*net.SRV{
+			"_tchannel._tcp.frontend.local": {
+				{Target: "pod-1.frontend.local", Port: 1000},
+				{Target: "pod-2.frontend.local", Port: 1000},
+			},
+			"_grpc._tcp.frontend.local": {
+				{Target: "pod-1.frontend.local", Port: 2000},
+				{Target: "pod-2.frontend.local", Port: 2000},
+			},
+		},
+	}
+	p, err := newProvider(
+		service.Frontend,
+		&Config{Names: map[string]string{"frontend": "frontend.local"}, SRV: true},
+		"10.0.0.1:1000",
+		map[string]membership.PortMap{},
+		resolver,
+		testlogger.New(t),
+	)
+	require.NoError(t, err)
+
+	ch := make(chan *membership.ChangedEvent, 1)
+	require.NoError(t, p.Subscribe("test", ch))
+	p.Start()
+	defer p.Stop()
+	<-ch
+
+	members, err := p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	portMap := membership.PortMap{membership.PortTchannel: 1000, membership.PortGRPC: 2000}
+	assert.Equal(t, []membership.HostInfo{
+		membership.NewDetailedHostInfo("10.0.0.1:1000", "10.0.0.1:1000", portMap),
+		membership.NewDetailedHostInfo("10.0.0.2:1000", "10.0.0.2:1000", portMap),
+	}, members)
+
+	// grpc port is changed
+	resolver.Lock()
+	resolver.srvs["_grpc._tcp.frontend.local"][1].Port = 3000
+	resolver.Unlock()
+	p.refresh()
+	change := <-ch
+	assert.Equal(t, []string{"10.0.0.2:1000"}, change.HostsUpdated)
+	assert.Empty(t, change.HostsAdded)
+	assert.Empty(t, change.HostsRemoved)
+}
+
+func TestSelfEvict(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{
+		"frontend.local": {"10.0.0.1", "10.0.0.2"},
+	}}
+	p, err := newProvider(service.Frontend, &Config{Names: map[string]string{"frontend": "frontend.local"}}, "10.0.0.1:7933", testPortMaps, resolver, testlogger.New(t))
+	require.NoError(t, err)
+	p.Start()
+	defer p.Stop()
+
+	ch := make(chan *membership.ChangedEvent, 1)
+	require.NoError(t, p.Subscribe("test", ch))
+	require.NoError(t, p.SelfEvict())
+	assert.Equal(t, []string{"10.0.0.1:7933"}, (<-ch).HostsRemoved)
+
+	members, err := p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	assert.Equal(t, []membership.HostInfo{
+		membership.NewDetailedHostInfo("10.0.0.2:7933", "10.0.0.2:7933", testPortMaps[service.Frontend]),
+	}, members)
+
+	// evicting again is a no-op
+	require.NoError(t, p.SelfEvict())
+	assert.Empty(t, ch)
+}
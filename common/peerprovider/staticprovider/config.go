@@ -0,0 +1,59 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package staticprovider
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/uber/cadence/common/service"
+)
+
+// Config contains the static peer provider config items
+type Config struct {
+	// Hosts is the map of service name to the ip addresses of its hosts, e.g. frontend: ["10.0.0.1", "10.0.0.2"].
+	// The ports of the hosts are the ones in the services config, so all the hosts of a service must listen on
+	// the same ports. Addresses must be the ones the hosts bind on, otherwise a host can't find itself in the ring.
+	Hosts map[string][]string `yaml:"hosts"`
+}
+
+func (c *Config) validate() error {
+	if len(c.Hosts) == 0 {
+		return fmt.Errorf("static peer provider config missing `hosts` param")
+	}
+	hosts := make(map[string][]string, len(c.Hosts))
+	for name, addrs := range c.Hosts {
+		fullName := service.FullName(name)
+		if _, ok := hosts[fullName]; ok {
+			return fmt.Errorf("static peer provider config has duplicate hosts for service %q", name)
+		}
+		for _, addr := range addrs {
+			if _, _, err := net.SplitHostPort(addr); err == nil {
+				return fmt.Errorf("static peer provider host %q of service %q must not contain a port", addr, name)
+			}
+		}
+		hosts[fullName] = addrs
+	}
+	c.Hosts = hosts
+	return nil
+}
@@ -0,0 +1,178 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package staticprovider
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
+)
+
+type (
+	// Provider announces the hosts of a static list as members. The members never change,
+	// except this host leaves its own view of the ring after SelfEvict.
+	Provider struct {
+		status      int32
+		service     string
+		self        membership.HostInfo
+		members     map[string][]membership.HostInfo
+		logger      log.Logger
+		mu          sync.RWMutex
+		evicted     bool
+		subscribers map[string]chan<- *membership.ChangedEvent
+	}
+)
+
+var _ membership.PeerProvider = (*Provider)(nil)
+
+// New creates a static peer provider. selfAddress is the tchannel address this host listens on,
+// and portMaps are the ports of every service, which are used to build the addresses of the hosts.
+func New(
+	service string,
+	config *Config,
+	selfAddress string,
+	portMaps map[string]membership.PortMap,
+	logger log.Logger,
+) (*Provider, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	members := make(map[string][]membership.HostInfo, len(config.Hosts))
+	for name, hosts := range config.Hosts {
+		portMap, ok := portMaps[name]
+		if !ok {
+			return nil, fmt.Errorf("static peer provider: no ports for service %q", name)
+		}
+		port, ok := portMap[membership.PortTchannel]
+		if !ok {
+			return nil, fmt.Errorf("static peer provider: no tchannel port for service %q", name)
+		}
+		for _, host := range hosts {
+			addr := net.JoinHostPort(host, strconv.Itoa(int(port)))
+			members[name] = append(members[name], membership.NewDetailedHostInfo(addr, addr, portMap))
+		}
+	}
+
+	return &Provider{
+		status:      common.DaemonStatusInitialized,
+		service:     service,
+		self:        membership.NewDetailedHostInfo(selfAddress, selfAddress, portMaps[service]),
+		members:     members,
+		logger:      logger,
+		subscribers: map[string]chan<- *membership.ChangedEvent{},
+	}, nil
+}
+
+// Start starts the provider
+func (p *Provider) Start() {
+	if !atomic.CompareAndSwapInt32(
+		&p.status,
+		common.DaemonStatusInitialized,
+		common.DaemonStatusStarted,
+	) {
+		return
+	}
+
+	for _, member := range p.members[p.service] {
+		if member.GetAddress() == p.self.GetAddress() {
+			return
+		}
+	}
+	p.logger.Warn("this host is not in the static host list of its service", tag.Address(p.self.GetAddress()), tag.Service(p.service))
+}
+
+// Stop stops the provider
+func (p *Provider) Stop() {
+	atomic.CompareAndSwapInt32(
+		&p.status,
+		common.DaemonStatusStarted,
+		common.DaemonStatusStopped,
+	)
+}
+
+// GetMembers returns all hosts of a service
+func (p *Provider) GetMembers(service string) ([]membership.HostInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	res := make([]membership.HostInfo, 0, len(p.members[service]))
+	for _, member := range p.members[service] {
+		if p.evicted && member.GetAddress() == p.self.GetAddress() {
+			continue
+		}
+		res = append(res, member)
+	}
+	return res, nil
+}
+
+// WhoAmI returns address of this instance
+func (p *Provider) WhoAmI() (membership.HostInfo, error) {
+	return p.self, nil
+}
+
+// SelfEvict removes this host from its own view of the ring. Other hosts keep it
+// in their rings, as there is no way to tell them about it.
+func (p *Provider) SelfEvict() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.evicted {
+		return nil
+	}
+	p.evicted = true
+	p.notify(&membership.ChangedEvent{HostsRemoved: []string{p.self.GetAddress()}})
+	return nil
+}
+
+// Subscribe allows to be subscribed for ring changes
+func (p *Provider) Subscribe(name string, notifyChannel chan<- *membership.ChangedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok := p.subscribers[name]
+	if ok {
+		return fmt.Errorf("%q already subscribed to static peer provider", name)
+	}
+
+	p.subscribers[name] = notifyChannel
+	return nil
+}
+
+// notify must be called with the lock held
+func (p *Provider) notify(change *membership.ChangedEvent) {
+	for name, ch := range p.subscribers {
+		select {
+		case ch <- change:
+		default:
+			p.logger.Error("Failed to send listener notification, channel full", tag.Subscriber(name))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package staticprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/service"
+)
+
+var testPortMaps = map[string]membership.PortMap{
+	service.Frontend: {membership.PortTchannel: 7933, membership.PortGRPC: 7833},
+	service.History:  {membership.PortTchannel: 7934, membership.PortGRPC: 7834},
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := map[string]*Config{
+		"no hosts":           {},
+		"duplicate services": {Hosts: map[string][]string{"frontend": {"10.0.0.1"}, service.Frontend: {"10.0.0.2"}}},
+		"host with port":     {Hosts: map[string][]string{"frontend": {"10.0.0.1:7933"}}},
+		"no ports":           {Hosts: map[string][]string{"matching": {"10.0.0.1"}}},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(service.Frontend, cfg, "10.0.0.1:7933", testPortMaps, testlogger.New(t))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	p, err := New(
+		service.Frontend,
+		&Config{Hosts: map[string][]string{
+			"frontend": {"10.0.0.1", "10.0.0.2"},
+			"history":  {"10.0.0.3"},
+		}},
+		"10.0.0.1:7933",
+		testPortMaps,
+		testlogger.New(t),
+	)
+	require.NoError(t, err)
+	p.Start()
+	defer p.Stop()
+
+	members, err := p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	assert.Equal(t, []membership.HostInfo{
+		membership.NewDetailedHostInfo("10.0.0.1:7933", "10.0.0.1:7933", testPortMaps[service.Frontend]),
+		membership.NewDetailedHostInfo("10.0.0.2:7933", "10.0.0.2:7933", testPortMaps[service.Frontend]),
+	}, members)
+
+	members, err = p.GetMembers(service.History)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	addr, err := members[0].GetNamedAddress(membership.PortGRPC)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3:7834", addr)
+
+	members, err = p.GetMembers(service.Worker)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	self, err := p.WhoAmI()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:7933", self.GetAddress())
+}
+
+func TestSelfEvict(t *testing.T) {
+	p, err := New(
+		service.Frontend,
+		&Config{Hosts: map[string][]string{"frontend": {"10.0.0.1", "10.0.0.2"}}},
+		"10.0.0.1:7933",
+		testPortMaps,
+		testlogger.New(t),
+	)
+	require.NoError(t, err)
+	p.Start()
+	defer p.Stop()
+
+	ch := make(chan *membership.ChangedEvent, 1)
+	require.NoError(t, p.Subscribe("test", ch))
+	assert.Error(t, p.Subscribe("test", ch))
+
+	require.NoError(t, p.SelfEvict())
+	assert.Equal(t, []string{"10.0.0.1:7933"}, (<-ch).HostsRemoved)
+
+	members, err := p.GetMembers(service.Frontend)
+	require.NoError(t, err)
+	assert.Equal(t, []membership.HostInfo{
+		membership.NewDetailedHostInfo("10.0.0.2:7933", "10.0.0.2:7933", testPortMaps[service.Frontend]),
+	}, members)
+
+	// evicting again is a no-op
+	require.NoError(t, p.SelfEvict())
+	assert.Empty(t, ch)
+}
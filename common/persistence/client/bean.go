@@ -51,6 +51,9 @@ type (
 		GetDomainReplicationQueueManager() persistence.QueueManager
 		SetDomainReplicationQueueManager(persistence.QueueManager)
 
+		GetAsyncWorkflowQueueManager(string) (persistence.QueueManager, error)
+		SetAsyncWorkflowQueueManager(string, persistence.QueueManager)
+
 		GetShardManager() persistence.ShardManager
 		SetShardManager(persistence.ShardManager)
 
@@ -73,10 +76,11 @@ type (
 		shardManager                  persistence.ShardManager
 		historyManager                persistence.HistoryManager
 		configStoreManager            persistence.ConfigStoreManager
-		executionManagerFactory       persistence.ExecutionManagerFactory
+		factory                       Factory
 
 		sync.RWMutex
-		shardIDToExecutionManager map[int]persistence.ExecutionManager
+		shardIDToExecutionManager        map[int]persistence.ExecutionManager
+		queueNameToAsyncWorkflowQueueMgr map[string]persistence.QueueManager
 	}
 
 	// Params contains dependencies for persistence
@@ -154,7 +158,7 @@ func NewBean(
 	shardManager persistence.ShardManager,
 	historyManager persistence.HistoryManager,
 	configStoreManager persistence.ConfigStoreManager,
-	executionManagerFactory persistence.ExecutionManagerFactory,
+	factory Factory,
 ) *BeanImpl {
 	return &BeanImpl{
 		domainManager:                 domainManager,
@@ -164,9 +168,10 @@ func NewBean(
 		shardManager:                  shardManager,
 		historyManager:                historyManager,
 		configStoreManager:            configStoreManager,
-		executionManagerFactory:       executionManagerFactory,
+		factory:                       factory,
 
-		shardIDToExecutionManager: make(map[int]persistence.ExecutionManager),
+		shardIDToExecutionManager:        make(map[int]persistence.ExecutionManager),
+		queueNameToAsyncWorkflowQueueMgr: make(map[string]persistence.QueueManager),
 	}
 }
 
@@ -250,6 +255,48 @@ func (s *BeanImpl) SetDomainReplicationQueueManager(
 	s.domainReplicationQueueManager = domainReplicationQueueManager
 }
 
+// GetAsyncWorkflowQueueManager gets the async workflow QueueManager of a persistence backed queue
+func (s *BeanImpl) GetAsyncWorkflowQueueManager(
+	queueName string,
+) (persistence.QueueManager, error) {
+
+	s.RLock()
+	queueManager, ok := s.queueNameToAsyncWorkflowQueueMgr[queueName]
+	if ok {
+		s.RUnlock()
+		return queueManager, nil
+	}
+	s.RUnlock()
+
+	s.Lock()
+	defer s.Unlock()
+
+	queueManager, ok = s.queueNameToAsyncWorkflowQueueMgr[queueName]
+	if ok {
+		return queueManager, nil
+	}
+
+	queueManager, err := s.factory.NewAsyncWorkflowQueueManager(queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.queueNameToAsyncWorkflowQueueMgr[queueName] = queueManager
+	return queueManager, nil
+}
+
+// SetAsyncWorkflowQueueManager sets the async workflow QueueManager of a persistence backed queue
+func (s *BeanImpl) SetAsyncWorkflowQueueManager(
+	queueName string,
+	queueManager persistence.QueueManager,
+) {
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.queueNameToAsyncWorkflowQueueMgr[queueName] = queueManager
+}
+
 // GetShardManager get ShardManager
 func (s *BeanImpl) GetShardManager() persistence.ShardManager {
 
@@ -311,7 +358,7 @@ func (s *BeanImpl) GetExecutionManager(
 		return executionManager, nil
 	}
 
-	executionManager, err := s.executionManagerFactory.NewExecutionManager(shardID)
+	executionManager, err := s.factory.NewExecutionManager(shardID)
 	if err != nil {
 		return nil, err
 	}
@@ -367,8 +414,11 @@ func (s *BeanImpl) Close() {
 	s.domainReplicationQueueManager.Close()
 	s.shardManager.Close()
 	s.historyManager.Close()
-	s.executionManagerFactory.Close()
+	s.factory.Close()
 	for _, executionMgr := range s.shardIDToExecutionManager {
 		executionMgr.Close()
 	}
+	for _, queueMgr := range s.queueNameToAsyncWorkflowQueueMgr {
+		queueMgr.Close()
+	}
 }
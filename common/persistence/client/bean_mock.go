@@ -30,7 +30,6 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
-
 	persistence "github.com/uber/cadence/common/persistence"
 )
 
@@ -69,6 +68,21 @@ func (mr *MockBeanMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockBean)(nil).Close))
 }
 
+// GetAsyncWorkflowQueueManager mocks base method.
+func (m *MockBean) GetAsyncWorkflowQueueManager(arg0 string) (persistence.QueueManager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAsyncWorkflowQueueManager", arg0)
+	ret0, _ := ret[0].(persistence.QueueManager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAsyncWorkflowQueueManager indicates an expected call of GetAsyncWorkflowQueueManager.
+func (mr *MockBeanMockRecorder) GetAsyncWorkflowQueueManager(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAsyncWorkflowQueueManager", reflect.TypeOf((*MockBean)(nil).GetAsyncWorkflowQueueManager), arg0)
+}
+
 // GetConfigStoreManager mocks base method.
 func (m *MockBean) GetConfigStoreManager() persistence.ConfigStoreManager {
 	m.ctrl.T.Helper()
@@ -182,6 +196,18 @@ func (mr *MockBeanMockRecorder) GetVisibilityManager() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVisibilityManager", reflect.TypeOf((*MockBean)(nil).GetVisibilityManager))
 }
 
+// SetAsyncWorkflowQueueManager mocks base method.
+func (m *MockBean) SetAsyncWorkflowQueueManager(arg0 string, arg1 persistence.QueueManager) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetAsyncWorkflowQueueManager", arg0, arg1)
+}
+
+// SetAsyncWorkflowQueueManager indicates an expected call of SetAsyncWorkflowQueueManager.
+func (mr *MockBeanMockRecorder) SetAsyncWorkflowQueueManager(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAsyncWorkflowQueueManager", reflect.TypeOf((*MockBean)(nil).SetAsyncWorkflowQueueManager), arg0, arg1)
+}
+
 // SetConfigStoreManager mocks base method.
 func (m *MockBean) SetConfigStoreManager(arg0 persistence.ConfigStoreManager) {
 	m.ctrl.T.Helper()
@@ -33,10 +33,12 @@ import (
 	"github.com/uber/cadence/common/metrics"
 	p "github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/elasticsearch"
+	"github.com/uber/cadence/common/persistence/encryption"
 	"github.com/uber/cadence/common/persistence/nosql"
 	pinotVisibility "github.com/uber/cadence/common/persistence/pinot"
 	"github.com/uber/cadence/common/persistence/serialization"
 	"github.com/uber/cadence/common/persistence/sql"
+	"github.com/uber/cadence/common/persistence/wrappers/encrypted"
 	"github.com/uber/cadence/common/persistence/wrappers/errorinjectors"
 	"github.com/uber/cadence/common/persistence/wrappers/metered"
 	"github.com/uber/cadence/common/persistence/wrappers/ratelimited"
@@ -67,6 +69,8 @@ type (
 		NewVisibilityManager(params *Params, serviceConfig *service.Config) (p.VisibilityManager, error)
 		// NewDomainReplicationQueueManager returns a new queue for domain replication
 		NewDomainReplicationQueueManager() (p.QueueManager, error)
+		// NewAsyncWorkflowQueueManager returns a new queue for the async workflow requests of a persistence backed queue
+		NewAsyncWorkflowQueueManager(queueName string) (p.QueueManager, error)
 		// NewConfigStoreManager returns a new config store manager
 		NewConfigStoreManager() (p.ConfigStoreManager, error)
 	}
@@ -107,6 +111,10 @@ type (
 		datastores    map[storeType]Datastore
 		clusterName   string
 		dc            *p.DynamicConfiguration
+
+		encryptorOnce sync.Once
+		encryptor     encryption.Encryptor
+		encryptorErr  error
 	}
 
 	storeType int
@@ -209,6 +217,13 @@ func (f *factoryImpl) NewHistoryManager() (p.HistoryManager, error) {
 		return nil, err
 	}
 	result := p.NewHistoryV2ManagerImpl(store, f.logger, f.config.TransactionSizeLimit)
+	if f.config.Encryption != nil {
+		encryptor, err := f.getEncryptor()
+		if err != nil {
+			return nil, err
+		}
+		result = encrypted.NewHistoryManager(result, encryptor, f.config.Encryption.Enabled)
+	}
 	if errorRate := f.config.ErrorInjectionRate(); errorRate != 0 {
 		result = errorinjectors.NewHistoryManager(result, errorRate, f.logger)
 	}
@@ -251,6 +266,13 @@ func (f *factoryImpl) NewExecutionManager(shardID int) (p.ExecutionManager, erro
 		return nil, err
 	}
 	result := p.NewExecutionManagerImpl(store, f.logger)
+	if f.config.Encryption != nil {
+		encryptor, err := f.getEncryptor()
+		if err != nil {
+			return nil, err
+		}
+		result = encrypted.NewExecutionManager(result, encryptor, f.config.Encryption.Enabled)
+	}
 	if errorRate := f.config.ErrorInjectionRate(); errorRate != 0 {
 		result = errorinjectors.NewExecutionManager(result, errorRate, f.logger)
 	}
@@ -267,6 +289,21 @@ func (f *factoryImpl) NewExecutionManager(shardID int) (p.ExecutionManager, erro
 func (f *factoryImpl) NewVisibilityManager(
 	params *Params,
 	resourceConfig *service.Config,
+) (p.VisibilityManager, error) {
+	result, err := f.newVisibilityManager(params, resourceConfig)
+	if err != nil || result == nil {
+		return result, err
+	}
+	if resourceConfig.MigratedSearchAttributes != nil && resourceConfig.ValidSearchAttributes != nil {
+		result = p.NewVisibilitySearchAttributesMigrator(
+			result, resourceConfig.ValidSearchAttributes, resourceConfig.MigratedSearchAttributes, f.logger)
+	}
+	return result, nil
+}
+
+func (f *factoryImpl) newVisibilityManager(
+	params *Params,
+	resourceConfig *service.Config,
 ) (p.VisibilityManager, error) {
 	if resourceConfig.EnableReadVisibilityFromES == nil && resourceConfig.AdvancedVisibilityWritingMode == nil {
 		// No need to create visibility manager as no read/write needed
@@ -422,8 +459,16 @@ func (f *factoryImpl) newDBVisibilityManager(
 }
 
 func (f *factoryImpl) NewDomainReplicationQueueManager() (p.QueueManager, error) {
+	return f.newQueueManager(p.DomainReplicationQueueType)
+}
+
+func (f *factoryImpl) NewAsyncWorkflowQueueManager(queueName string) (p.QueueManager, error) {
+	return f.newQueueManager(p.AsyncWorkflowQueueTypeOf(queueName))
+}
+
+func (f *factoryImpl) newQueueManager(queueType p.QueueType) (p.QueueManager, error) {
 	ds := f.datastores[storeTypeQueue]
-	store, err := ds.factory.NewQueue(p.DomainReplicationQueueType)
+	store, err := ds.factory.NewQueue(queueType)
 	if err != nil {
 		return nil, err
 	}
@@ -467,6 +512,20 @@ func (f *factoryImpl) Close() {
 	ds.factory.Close()
 }
 
+// getEncryptor returns the encryptor shared by the history and execution managers, so that the data keys
+// are not generated for every shard
+func (f *factoryImpl) getEncryptor() (encryption.Encryptor, error) {
+	f.encryptorOnce.Do(func() {
+		kms, err := encryption.NewKeyFileKMS(f.config.Encryption.KeyFile)
+		if err != nil {
+			f.encryptorErr = err
+			return
+		}
+		f.encryptor = encryption.NewEncryptor(kms, f.config.Encryption.DataKeyRotationInterval)
+	})
+	return f.encryptor, f.encryptorErr
+}
+
 func (f *factoryImpl) init(clusterName string, limiters map[string]quotas.Limiter) {
 	f.datastores = make(map[storeType]Datastore, len(storeTypes))
 	defaultCfg := f.config.DataStores[f.config.DefaultStore]
@@ -0,0 +1,105 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/uber/cadence/common"
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// DecompressDataBlob returns the uncompressed ThriftRW blob of a compressed blob, and the blob itself otherwise
+func DecompressDataBlob(blob *DataBlob) (*DataBlob, error) {
+	if blob == nil || !isCompressedEncoding(blob.Encoding) {
+		return blob, nil
+	}
+	data, err := decompress(blob.Data, blob.Encoding)
+	if err != nil {
+		return nil, NewCadenceDeserializationError(fmt.Sprintf("failed to decompress %v blob: %v", blob.Encoding, err))
+	}
+	return NewDataBlob(data, common.EncodingTypeThriftRW), nil
+}
+
+func isCompressedEncoding(encodingType common.EncodingType) bool {
+	switch encodingType {
+	case common.EncodingTypeThriftRWSnappy, common.EncodingTypeThriftRWZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+func compress(data []byte, encodingType common.EncodingType) ([]byte, error) {
+	switch encodingType {
+	case common.EncodingTypeThriftRWSnappy:
+		return snappy.Encode(nil, data), nil
+	case common.EncodingTypeThriftRWZstd:
+		encoder, _, err := getZstd()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, nil), nil
+	default:
+		return nil, NewUnknownEncodingTypeError(encodingType)
+	}
+}
+
+func decompress(data []byte, encodingType common.EncodingType) ([]byte, error) {
+	switch encodingType {
+	case common.EncodingTypeThriftRWSnappy:
+		return snappy.Decode(nil, data)
+	case common.EncodingTypeThriftRWZstd:
+		_, decoder, err := getZstd()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(data, nil)
+	default:
+		return nil, NewUnknownEncodingTypeError(encodingType)
+	}
+}
+
+// getZstd returns the zstd encoder and decoder shared by all the serializers.
+// Both are safe for concurrent use with EncodeAll and DecodeAll, and are only created when zstd is used
+// as they allocate their buffers upfront.
+func getZstd() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
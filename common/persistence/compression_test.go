@@ -0,0 +1,79 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+)
+
+func TestDecompressDataBlob(t *testing.T) {
+	serializer := NewPayloadSerializer()
+	events := generateTestHistoryEventBatch()
+	thriftBlob, err := serializer.SerializeBatchEvents(events, common.EncodingTypeThriftRW)
+	require.NoError(t, err)
+
+	for _, encoding := range []common.EncodingType{common.EncodingTypeThriftRWSnappy, common.EncodingTypeThriftRWZstd} {
+		t.Run(string(encoding), func(t *testing.T) {
+			compressedBlob, err := serializer.SerializeBatchEvents(events, encoding)
+			require.NoError(t, err)
+			assert.Equal(t, encoding, compressedBlob.GetEncoding())
+			assert.NotEqual(t, thriftBlob.Data, compressedBlob.Data)
+
+			blob, err := DecompressDataBlob(compressedBlob)
+			require.NoError(t, err)
+			assert.Equal(t, thriftBlob, blob)
+
+			_, err = DecompressDataBlob(NewDataBlob([]byte("corrupted"), encoding))
+			assert.Error(t, err)
+		})
+	}
+
+	blob, err := DecompressDataBlob(thriftBlob)
+	require.NoError(t, err)
+	assert.Equal(t, thriftBlob, blob)
+
+	blob, err = DecompressDataBlob(nil)
+	require.NoError(t, err)
+	assert.Nil(t, blob)
+}
+
+func TestCompressionRatio(t *testing.T) {
+	serializer := NewPayloadSerializer()
+	var events = generateTestHistoryEventBatch()
+	for i := 0; i < 10; i++ {
+		events = append(events, generateTestHistoryEventBatch()...)
+	}
+	thriftBlob, err := serializer.SerializeBatchEvents(events, common.EncodingTypeThriftRW)
+	require.NoError(t, err)
+
+	for _, encoding := range []common.EncodingType{common.EncodingTypeThriftRWSnappy, common.EncodingTypeThriftRWZstd} {
+		compressedBlob, err := serializer.SerializeBatchEvents(events, encoding)
+		require.NoError(t, err)
+		assert.Less(t, len(compressedBlob.Data), len(thriftBlob.Data)/2, encoding)
+	}
+}
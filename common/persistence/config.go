@@ -25,19 +25,23 @@ import "github.com/uber/cadence/common/dynamicconfig"
 type (
 	// DynamicConfiguration represents dynamic configuration for persistence layer
 	DynamicConfiguration struct {
-		EnableSQLAsyncTransaction                dynamicconfig.BoolPropertyFn
-		EnableCassandraAllConsistencyLevelDelete dynamicconfig.BoolPropertyFn
-		PersistenceSampleLoggingRate             dynamicconfig.IntPropertyFn
-		EnableShardIDMetrics                     dynamicconfig.BoolPropertyFn
+		EnableSQLAsyncTransaction                  dynamicconfig.BoolPropertyFn
+		EnableCassandraAllConsistencyLevelDelete   dynamicconfig.BoolPropertyFn
+		PersistenceSampleLoggingRate               dynamicconfig.IntPropertyFn
+		EnableShardIDMetrics                       dynamicconfig.BoolPropertyFn
+		CassandraVisibilityIndexedSearchAttributes dynamicconfig.ListPropertyFn
+		CassandraVisibilityMaxCountScanSize        dynamicconfig.IntPropertyFn
 	}
 )
 
 // NewDynamicConfiguration returns new config with default values
 func NewDynamicConfiguration(dc *dynamicconfig.Collection) *DynamicConfiguration {
 	return &DynamicConfiguration{
-		EnableSQLAsyncTransaction:                dc.GetBoolProperty(dynamicconfig.EnableSQLAsyncTransaction),
-		EnableCassandraAllConsistencyLevelDelete: dc.GetBoolProperty(dynamicconfig.EnableCassandraAllConsistencyLevelDelete),
-		PersistenceSampleLoggingRate:             dc.GetIntProperty(dynamicconfig.SampleLoggingRate),
-		EnableShardIDMetrics:                     dc.GetBoolProperty(dynamicconfig.EnableShardIDMetrics),
+		EnableSQLAsyncTransaction:                  dc.GetBoolProperty(dynamicconfig.EnableSQLAsyncTransaction),
+		EnableCassandraAllConsistencyLevelDelete:   dc.GetBoolProperty(dynamicconfig.EnableCassandraAllConsistencyLevelDelete),
+		PersistenceSampleLoggingRate:               dc.GetIntProperty(dynamicconfig.SampleLoggingRate),
+		EnableShardIDMetrics:                       dc.GetBoolProperty(dynamicconfig.EnableShardIDMetrics),
+		CassandraVisibilityIndexedSearchAttributes: dc.GetListProperty(dynamicconfig.CassandraVisibilityIndexedSearchAttributes),
+		CassandraVisibilityMaxCountScanSize:        dc.GetIntProperty(dynamicconfig.CassandraVisibilityMaxCountScanSize),
 	}
 }
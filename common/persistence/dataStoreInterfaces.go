@@ -922,7 +922,7 @@ func NewDataBlob(data []byte, encodingType common.EncodingType) *DataBlob {
 	if len(data) == 0 {
 		return nil
 	}
-	if encodingType != "thriftrw" && data[0] == 'Y' {
+	if encodingType != "thriftrw" && !isCompressedEncoding(encodingType) && data[0] == 'Y' {
 		panic(fmt.Sprintf("Invalid incoding: \"%v\"", encodingType))
 	}
 	return &DataBlob{
@@ -973,6 +973,10 @@ func (d *DataBlob) GetEncoding() common.EncodingType {
 		return common.EncodingTypeJSON
 	case common.EncodingTypeThriftRW:
 		return common.EncodingTypeThriftRW
+	case common.EncodingTypeThriftRWSnappy:
+		return common.EncodingTypeThriftRWSnappy
+	case common.EncodingTypeThriftRWZstd:
+		return common.EncodingTypeThriftRWZstd
 	case common.EncodingTypeEmpty:
 		return common.EncodingTypeEmpty
 	default:
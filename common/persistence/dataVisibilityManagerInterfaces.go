@@ -32,8 +32,12 @@ package persistence
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -45,6 +49,10 @@ import (
 // ErrVisibilityOperationNotSupported is an error which indicates that operation is not supported in selected persistence
 var ErrVisibilityOperationNotSupported = &types.BadRequestError{Message: "Operation is not supported. Please use ElasticSearch"}
 
+// MaxWorkflowExecutionCountGroups is the max number of groups returned by CountWorkflowExecutions with GroupBy,
+// workflows in the remaining groups are only included in the total count
+const MaxWorkflowExecutionCountGroups = 1000
+
 type (
 	// RecordWorkflowExecutionStartedRequest is used to add a record of a newly
 	// started execution
@@ -157,11 +165,22 @@ type (
 		DomainUUID string
 		Domain     string // domain name is not persisted, but used as config filter key
 		Query      string
+		// GroupBy is optional, when set the count is also broken down by the values of
+		// WorkflowType, CloseStatus or a keyword search attribute
+		GroupBy string
 	}
 
 	// CountWorkflowExecutionsResponse is response to CountWorkflowExecutions
 	CountWorkflowExecutionsResponse struct {
-		Count int64
+		Count  int64
+		Groups []*WorkflowExecutionCountGroup // only set when GroupBy is requested, ordered by count desc
+	}
+
+	// WorkflowExecutionCountGroup is the number of workflow executions sharing the same GroupBy value.
+	// When grouping by CloseStatus the value is the close status name, open workflows have an empty value.
+	WorkflowExecutionCountGroup struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
 	}
 
 	// ListWorkflowExecutionsByTypeRequest is used to list executions of
@@ -238,3 +257,32 @@ func IsNopUpsertWorkflowRequest(request *InternalUpsertWorkflowExecutionRequest)
 	_, exist := request.SearchAttributes[definition.CadenceChangeVersion]
 	return exist
 }
+
+// ValidateCountGroupBy checks that workflow executions can be counted by the given field,
+// which must be WorkflowType, CloseStatus or a keyword search attribute
+func ValidateCountGroupBy(groupBy string, validSearchAttributes map[string]interface{}, logger log.Logger) error {
+	switch groupBy {
+	case definition.WorkflowType, definition.CloseStatus:
+		return nil
+	}
+	fieldType, ok := validSearchAttributes[groupBy]
+	if !ok || definition.IsSystemIndexedKey(groupBy) {
+		return &types.BadRequestError{Message: fmt.Sprintf("cannot group by %s, only WorkflowType, CloseStatus and keyword search attributes are supported", groupBy)}
+	}
+	if common.ConvertIndexedValueTypeToInternalType(fieldType, logger) != types.IndexedValueTypeKeyword {
+		return &types.BadRequestError{Message: fmt.Sprintf("cannot group by %s, only keyword search attributes are supported", groupBy)}
+	}
+	return nil
+}
+
+// WorkflowExecutionCountGroupValue converts the value of a GroupBy field stored in visibility to a group value
+func WorkflowExecutionCountGroupValue(groupBy string, value interface{}) string {
+	if groupBy != definition.CloseStatus {
+		return fmt.Sprint(value)
+	}
+	status, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil || status < 0 {
+		return "" // open workflow
+	}
+	return types.WorkflowExecutionCloseStatus(status).String()
+}
@@ -54,6 +54,8 @@ package persistence
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"strings"
 	"time"
 
@@ -94,6 +96,18 @@ const (
 	DomainReplicationQueueType QueueType = iota + 1
 )
 
+// asyncWorkflowQueueTypeBase is the lowest queue type of the persistence backed async workflow queues
+const asyncWorkflowQueueTypeBase = 1 << 16
+
+// AsyncWorkflowQueueTypeOf returns the queue type of a persistence backed async workflow queue.
+// Each queue gets its own type, so that its messages and ack levels are stored apart from the other queues.
+// The type is a hash of the queue name, the queues whose names collide share a type.
+func AsyncWorkflowQueueTypeOf(queueName string) QueueType {
+	hash := fnv.New32a()
+	hash.Write([]byte(queueName))
+	return QueueType(asyncWorkflowQueueTypeBase + hash.Sum32()%(math.MaxInt32-asyncWorkflowQueueTypeBase))
+}
+
 // Create Workflow Execution Mode
 const (
 	// Fail if current record exists
@@ -251,6 +265,7 @@ type ConfigType int
 const (
 	DynamicConfig ConfigType = iota
 	GlobalIsolationGroupConfig
+	ScheduleConfig
 )
 
 type (
@@ -67,6 +67,14 @@ func TestIsTimeoutError(t *testing.T) {
 	assert.True(t, IsTimeoutError(&TimeoutError{}))
 }
 
+func TestAsyncWorkflowQueueTypeOf(t *testing.T) {
+	queueType := AsyncWorkflowQueueTypeOf("queue-a")
+	assert.Equal(t, queueType, AsyncWorkflowQueueTypeOf("queue-a"))
+	assert.NotEqual(t, queueType, AsyncWorkflowQueueTypeOf("queue-b"))
+	assert.NotEqual(t, DomainReplicationQueueType, AsyncWorkflowQueueTypeOf(""))
+	assert.GreaterOrEqual(t, int(queueType), asyncWorkflowQueueTypeBase)
+}
+
 func TestTaskCommonMethods(t *testing.T) {
 	timeNow := time.Now()
 	tasks := []interface{}{
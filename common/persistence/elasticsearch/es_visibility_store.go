@@ -21,6 +21,7 @@
 package elasticsearch
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -482,6 +483,10 @@ func (v *esVisibilityStore) CountWorkflowExecutions(
 ) (
 	*p.CountWorkflowExecutionsResponse, error) {
 
+	if request.GroupBy != "" {
+		return v.countWorkflowExecutionsGroupBy(ctx, request)
+	}
+
 	queryDSL, err := getESQueryDSLForCount(request)
 	if err != nil {
 		return nil, &types.BadRequestError{Message: fmt.Sprintf("Error when parse query: %v", err)}
@@ -498,6 +503,53 @@ func (v *esVisibilityStore) CountWorkflowExecutions(
 	return response, nil
 }
 
+func (v *esVisibilityStore) countWorkflowExecutionsGroupBy(
+	ctx context.Context,
+	request *p.CountWorkflowExecutionsRequest,
+) (*p.CountWorkflowExecutionsResponse, error) {
+
+	if err := p.ValidateCountGroupBy(request.GroupBy, v.config.ValidSearchAttributes(), v.logger); err != nil {
+		return nil, err
+	}
+
+	queryDSL, err := getESQueryDSLForCountGroupBy(request)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: fmt.Sprintf("Error when parse query: %v", err)}
+	}
+
+	resp, err := v.esClient.SearchRaw(ctx, v.index, queryDSL)
+	if err != nil {
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("CountWorkflowExecutions failed. Error: %v", err),
+		}
+	}
+
+	var groupBy struct {
+		Buckets []struct {
+			Key      interface{} `json:"key"`
+			DocCount int64       `json:"doc_count"`
+		} `json:"buckets"`
+		SumOtherDocCount int64 `json:"sum_other_doc_count"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(resp.Aggregations[dslFieldGroupBy]))
+	decoder.UseNumber()
+	if err := decoder.Decode(&groupBy); err != nil {
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("CountWorkflowExecutions failed to decode aggregation. Error: %v", err),
+		}
+	}
+
+	response := &p.CountWorkflowExecutionsResponse{Count: groupBy.SumOtherDocCount}
+	for _, bucket := range groupBy.Buckets {
+		response.Count += bucket.DocCount
+		response.Groups = append(response.Groups, &p.WorkflowExecutionCountGroup{
+			Value: p.WorkflowExecutionCountGroupValue(request.GroupBy, bucket.Key),
+			Count: bucket.DocCount,
+		})
+	}
+	return response, nil
+}
+
 const (
 	jsonMissingCloseTime     = `{"missing":{"field":"CloseTime"}}`
 	jsonRangeOnExecutionTime = `{"range":{"ExecutionTime":`
@@ -509,6 +561,8 @@ const (
 	dslFieldSearchAfter = "search_after"
 	dslFieldFrom        = "from"
 	dslFieldSize        = "size"
+	dslFieldAggs        = "aggs"
+	dslFieldGroupBy     = "groupby"
 
 	defaultDateTimeFormat = time.RFC3339 // used for converting UnixNano to string like 2018-02-15T16:16:36-08:00
 )
@@ -558,6 +612,35 @@ func getESQueryDSLForCount(request *p.CountWorkflowExecutionsRequest) (string, e
 	return dsl.String(), nil
 }
 
+// getESQueryDSLForCountGroupBy returns a terms aggregation over the GroupBy field.
+// Workflows missing the field are aggregated as well, so that bucket counts add up to the total count.
+func getESQueryDSLForCountGroupBy(request *p.CountWorkflowExecutionsRequest) (string, error) {
+	sql := getSQLFromCountRequest(request)
+	dsl, err := getCustomizedDSLFromSQL(sql, request.DomainUUID)
+	if err != nil {
+		return "", err
+	}
+
+	field, missing := request.GroupBy, `""`
+	switch request.GroupBy {
+	case definition.WorkflowType:
+	case definition.CloseStatus:
+		missing = "-1" // open workflows
+	default:
+		field = definition.Attr + "." + request.GroupBy
+	}
+
+	dsl.Del(dslFieldFrom)
+	dsl.Del(dslFieldSort)
+	dsl.Set(dslFieldSize, fastjson.MustParse("0"))
+	dsl.Set(dslFieldAggs, fastjson.MustParse(fmt.Sprintf(
+		`{"%s":{"terms":{"field":"%s","missing":%s,"size":%d}}}`,
+		dslFieldGroupBy, field, missing, p.MaxWorkflowExecutionCountGroups,
+	)))
+
+	return dsl.String(), nil
+}
+
 func (v *esVisibilityStore) getESQueryDSL(request *p.ListWorkflowExecutionsByQueryRequest, token *es.ElasticVisibilityPageToken) (string, error) {
 	sql := getSQLFromListRequest(request)
 	dsl, err := getCustomizedDSLFromSQL(sql, request.DomainUUID)
@@ -897,6 +897,69 @@ func (s *ESVisibilitySuite) TestCountWorkflowExecutions() {
 	s.True(strings.Contains(err.Error(), "Error when parse query"))
 }
 
+func (s *ESVisibilitySuite) TestCountWorkflowExecutionsGroupBy() {
+	s.mockESClient.On("SearchRaw", mock.Anything, testIndex, mock.MatchedBy(func(input string) bool {
+		return strings.Contains(input, `"size":0`) &&
+			strings.Contains(input, `"aggs":{"groupby":{"terms":{"field":"CloseStatus","missing":-1,"size":1000}}}`)
+	})).Return(&es.RawResponse{
+		Aggregations: map[string]json.RawMessage{
+			"groupby": json.RawMessage(`{"sum_other_doc_count":0,"buckets":[{"key":-1,"doc_count":3},{"key":1,"doc_count":2}]}`),
+		},
+	}, nil).Once()
+
+	request := &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainID,
+		Domain:     testDomain,
+		Query:      `WorkflowType = 'wt'`,
+		GroupBy:    "CloseStatus",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testContextTimeout)
+	defer cancel()
+
+	resp, err := s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.NoError(err)
+	s.Equal(&p.CountWorkflowExecutionsResponse{
+		Count: 5,
+		Groups: []*p.WorkflowExecutionCountGroup{
+			{Value: "", Count: 3},
+			{Value: "FAILED", Count: 2},
+		},
+	}, resp)
+
+	// keyword search attribute
+	s.mockESClient.On("SearchRaw", mock.Anything, testIndex, mock.MatchedBy(func(input string) bool {
+		return strings.Contains(input, `"aggs":{"groupby":{"terms":{"field":"Attr.CustomKeywordField","missing":"","size":1000}}}`)
+	})).Return(&es.RawResponse{
+		Aggregations: map[string]json.RawMessage{
+			"groupby": json.RawMessage(`{"sum_other_doc_count":4,"buckets":[{"key":"keyword","doc_count":6}]}`),
+		},
+	}, nil).Once()
+
+	request.GroupBy = "CustomKeywordField"
+	resp, err = s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.NoError(err)
+	s.Equal(&p.CountWorkflowExecutionsResponse{
+		Count:  10,
+		Groups: []*p.WorkflowExecutionCountGroup{{Value: "keyword", Count: 6}},
+	}, resp)
+
+	// test not supported field
+	request.GroupBy = "CustomStringField"
+	_, err = s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.Error(err)
+	_, ok := err.(*types.BadRequestError)
+	s.True(ok)
+
+	// test internal error
+	s.mockESClient.On("SearchRaw", mock.Anything, testIndex, mock.Anything).Return(nil, errTestESSearch).Once()
+	request.GroupBy = "WorkflowType"
+	_, err = s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.Error(err)
+	_, ok = err.(*types.InternalServiceError)
+	s.True(ok)
+}
+
 func (s *ESVisibilitySuite) TestTimeProcessFunc() {
 	cases := []struct {
 		key   string
@@ -0,0 +1,259 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+)
+
+const (
+	// plaintextEnvelopeVersion wraps the payloads which aren't encrypted but start with envelopeMagic,
+	// so that they aren't mistaken for encrypted payloads
+	plaintextEnvelopeVersion = 0
+	envelopeVersion          = 1
+
+	// DefaultDataKeyRotationInterval is the default age after which the data key of a domain is replaced
+	DefaultDataKeyRotationInterval = 24 * time.Hour
+
+	dataKeyCacheSize = 10000
+)
+
+// envelopeMagic prefixes encrypted payloads. Payloads without it are returned as is by Decrypt,
+// so that encryption can be enabled on a cluster which already has data. Payloads are always wrapped
+// when they are encrypted, and the plaintext payloads starting with it are wrapped by Escape.
+var envelopeMagic = []byte{0x00, 'c', 'e', 'n', 'c'}
+
+type (
+	// Encryptor encrypts payloads with envelope encryption: every domain has a data key, which is encrypted
+	// by the KMS and stored next to the payloads it has encrypted. Data keys are replaced after the rotation
+	// interval, and payloads keep the key they were encrypted with, so that no re-encryption is needed.
+	//
+	// Encrypted payloads have the following layout:
+	//   magic | version | uvarint length + master key ID | uvarint length + encrypted data key | nonce | ciphertext
+	// and escaped plaintext payloads:
+	//   magic | plaintext version | payload
+	Encryptor interface {
+		// Encrypt encrypts the payload with the current data key of the domain
+		Encrypt(ctx context.Context, domainName string, data []byte) ([]byte, error)
+		// Decrypt decrypts an encrypted payload, unwraps an escaped payload and returns other payloads as is
+		Decrypt(ctx context.Context, data []byte) ([]byte, error)
+	}
+
+	encryptorImpl struct {
+		kms              KMS
+		rotationInterval time.Duration
+		timeSource       clock.TimeSource
+
+		sync.Mutex
+		domainKeys map[string]*domainKey
+		// dataKeys caches the decrypted data keys by encrypted data key
+		dataKeys cache.Cache
+	}
+
+	// plaintextEncryptor escapes the payloads instead of encrypting them, and decrypts them with the wrapped Encryptor
+	plaintextEncryptor struct {
+		Encryptor
+	}
+
+	domainKey struct {
+		header    []byte
+		aead      cipher.AEAD
+		createdAt time.Time
+	}
+)
+
+// NewEncryptor returns a new Encryptor with the given KMS
+func NewEncryptor(kms KMS, rotationInterval time.Duration) Encryptor {
+	return newEncryptor(kms, rotationInterval, clock.NewRealTimeSource())
+}
+
+// NewPlaintextEncryptor returns an Encryptor which only escapes the payloads, so that they are written in plain text,
+// while the payloads encrypted before are still decrypted with the given Encryptor
+func NewPlaintextEncryptor(encryptor Encryptor) Encryptor {
+	return &plaintextEncryptor{Encryptor: encryptor}
+}
+
+func (e *plaintextEncryptor) Encrypt(ctx context.Context, domainName string, data []byte) ([]byte, error) {
+	return Escape(data), nil
+}
+
+func newEncryptor(kms KMS, rotationInterval time.Duration, timeSource clock.TimeSource) *encryptorImpl {
+	if rotationInterval <= 0 {
+		rotationInterval = DefaultDataKeyRotationInterval
+	}
+	return &encryptorImpl{
+		kms:              kms,
+		rotationInterval: rotationInterval,
+		timeSource:       timeSource,
+		domainKeys:       make(map[string]*domainKey),
+		dataKeys:         cache.New(&cache.Options{MaxCount: dataKeyCacheSize}),
+	}
+}
+
+// IsEncrypted returns true if the payload was encrypted by an Encryptor
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// Escape returns the payload as is, unless it starts with the prefix of the encrypted payloads, in which case
+// it is wrapped so that Decrypt returns it unchanged. It is applied to the payloads written without encryption.
+func Escape(data []byte) []byte {
+	if !IsEncrypted(data) {
+		return data
+	}
+	result := make([]byte, 0, len(envelopeMagic)+1+len(data))
+	result = append(result, envelopeMagic...)
+	result = append(result, plaintextEnvelopeVersion)
+	return append(result, data...)
+}
+
+// ContainsEncrypted returns true if the data, such as an uncompressed serialized batch of events,
+// may contain encrypted payloads
+func ContainsEncrypted(data []byte) bool {
+	return bytes.Contains(data, envelopeMagic)
+}
+
+func (e *encryptorImpl) Encrypt(ctx context.Context, domainName string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	key, err := e.getDomainKey(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(key.aead, data)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, len(key.header)+len(ciphertext))
+	result = append(result, key.header...)
+	return append(result, ciphertext...), nil
+}
+
+func (e *encryptorImpl) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	reader := bytes.NewReader(data[len(envelopeMagic):])
+	version, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version == plaintextEnvelopeVersion {
+		return data[len(envelopeMagic)+1:], nil
+	}
+	if version != envelopeVersion {
+		return nil, fmt.Errorf("unknown encryption envelope version %v", version)
+	}
+	masterKeyID, err := readBytes(reader)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := readBytes(reader)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := e.getDataKey(ctx, string(masterKeyID), encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, data[len(data)-reader.Len():])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %v", err)
+	}
+	return plaintext, nil
+}
+
+func (e *encryptorImpl) getDomainKey(ctx context.Context, domainName string) (*domainKey, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	now := e.timeSource.Now()
+	if key, ok := e.domainKeys[domainName]; ok && now.Sub(key.createdAt) < e.rotationInterval {
+		return key, nil
+	}
+	dataKey, err := e.kms.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %v", err)
+	}
+	aead, err := newAEAD(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	header := append([]byte{}, envelopeMagic...)
+	header = append(header, envelopeVersion)
+	header = appendBytes(header, []byte(dataKey.MasterKeyID))
+	header = appendBytes(header, dataKey.Encrypted)
+	key := &domainKey{
+		header:    header,
+		aead:      aead,
+		createdAt: now,
+	}
+	e.domainKeys[domainName] = key
+	e.dataKeys.Put(string(dataKey.Encrypted), aead)
+	return key, nil
+}
+
+func (e *encryptorImpl) getDataKey(ctx context.Context, masterKeyID string, encryptedKey []byte) (cipher.AEAD, error) {
+	if aead, ok := e.dataKeys.Get(string(encryptedKey)).(cipher.AEAD); ok {
+		return aead, nil
+	}
+	plaintext, err := e.kms.DecryptDataKey(ctx, masterKeyID, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %v", err)
+	}
+	aead, err := newAEAD(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	e.dataKeys.Put(string(encryptedKey), aead)
+	return aead, nil
+}
+
+func appendBytes(buf []byte, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func readBytes(reader *bytes.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encryption envelope: %v", err)
+	}
+	if length > uint64(reader.Len()) {
+		return nil, fmt.Errorf("malformed encryption envelope: length %v exceeds the remaining %v bytes", length, reader.Len())
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(reader, data)
+	return data, err
+}
@@ -0,0 +1,193 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/clock"
+)
+
+type countingKMS struct {
+	KMS
+	generated int
+	decrypted int
+}
+
+func (k *countingKMS) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	k.generated++
+	return k.KMS.GenerateDataKey(ctx)
+}
+
+func (k *countingKMS) DecryptDataKey(ctx context.Context, masterKeyID string, encryptedKey []byte) ([]byte, error) {
+	k.decrypted++
+	return k.KMS.DecryptDataKey(ctx, masterKeyID, encryptedKey)
+}
+
+func newTestKMS(t *testing.T) *countingKMS {
+	kms, err := newKeyFileKMS(&KeyFile{
+		PrimaryKey: "key-1",
+		Keys:       map[string]string{"key-1": testKey(1)},
+	})
+	require.NoError(t, err)
+	return &countingKMS{KMS: kms}
+}
+
+func TestEncryptor_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+
+	plaintext := []byte("sensitive input")
+	encrypted, err := encryptor.Encrypt(ctx, "domain", plaintext)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), string(plaintext))
+
+	decrypted, err := encryptor.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// payloads starting with the envelope prefix are encrypted like the others
+	colliding := append(append([]byte{}, envelopeMagic...), "user data"...)
+	encrypted, err = encryptor.Encrypt(ctx, "domain", colliding)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "user data")
+	decrypted, err = encryptor.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, colliding, decrypted)
+
+	// empty payloads are kept as is
+	empty, err := encryptor.Encrypt(ctx, "domain", nil)
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+}
+
+func TestEncryptor_DecryptPlaintext(t *testing.T) {
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	data, err := encryptor.Decrypt(context.Background(), []byte("written before encryption was enabled"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("written before encryption was enabled"), data)
+}
+
+func TestPlaintextEncryptor(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	plaintextEncryptor := NewPlaintextEncryptor(encryptor)
+
+	for _, payload := range [][]byte{
+		[]byte("plain text"),
+		append(append([]byte{}, envelopeMagic...), envelopeVersion, 'x'),
+		append(append([]byte{}, envelopeMagic...), plaintextEnvelopeVersion),
+	} {
+		written, err := plaintextEncryptor.Encrypt(ctx, "domain", payload)
+		require.NoError(t, err)
+		assert.Equal(t, IsEncrypted(payload), !bytes.Equal(payload, written))
+		decrypted, err := plaintextEncryptor.Decrypt(ctx, written)
+		require.NoError(t, err)
+		assert.Equal(t, payload, decrypted)
+	}
+
+	// the payloads encrypted before encryption was disabled are still decrypted
+	encrypted, err := encryptor.Encrypt(ctx, "domain", []byte("sensitive input"))
+	require.NoError(t, err)
+	decrypted, err := plaintextEncryptor.Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sensitive input"), decrypted)
+}
+
+func TestEncryptor_DecryptInvalid(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	encrypted, err := encryptor.Encrypt(ctx, "domain", []byte("payload"))
+	require.NoError(t, err)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = encryptor.Decrypt(ctx, tampered)
+	assert.ErrorContains(t, err, "failed to decrypt payload")
+
+	_, err = encryptor.Decrypt(ctx, encrypted[:len(envelopeMagic)+3])
+	assert.ErrorContains(t, err, "malformed encryption envelope")
+
+	unknownVersion := append([]byte{}, encrypted...)
+	unknownVersion[len(envelopeMagic)] = 2
+	_, err = encryptor.Decrypt(ctx, unknownVersion)
+	assert.ErrorContains(t, err, "unknown encryption envelope version 2")
+}
+
+func TestEncryptor_DataKeys(t *testing.T) {
+	ctx := context.Background()
+	kms := newTestKMS(t)
+	timeSource := clock.NewMockedTimeSource()
+	encryptor := newEncryptor(kms, time.Hour, timeSource)
+
+	first, err := encryptor.Encrypt(ctx, "domain-1", []byte("payload"))
+	require.NoError(t, err)
+	_, err = encryptor.Encrypt(ctx, "domain-1", []byte("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, kms.generated)
+
+	// every domain has its own data key
+	other, err := encryptor.Encrypt(ctx, "domain-2", []byte("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, kms.generated)
+	assert.NotEqual(t, first[:len(encryptor.domainKeys["domain-1"].header)], other[:len(encryptor.domainKeys["domain-2"].header)])
+
+	// data keys are replaced after the rotation interval
+	timeSource.Advance(time.Hour)
+	rotated, err := encryptor.Encrypt(ctx, "domain-1", []byte("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, kms.generated)
+
+	// payloads encrypted with the old data key can still be decrypted, without calling the KMS
+	for _, data := range [][]byte{first, other, rotated} {
+		decrypted, err := encryptor.Decrypt(ctx, data)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("payload"), decrypted)
+	}
+	assert.Equal(t, 0, kms.decrypted)
+
+	// a new encryptor has to decrypt the data key once
+	restarted := newEncryptor(kms, time.Hour, timeSource)
+	for i := 0; i < 2; i++ {
+		decrypted, err := restarted.Decrypt(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("payload"), decrypted)
+	}
+	assert.Equal(t, 1, kms.decrypted)
+}
+
+func TestContainsEncrypted(t *testing.T) {
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	encrypted, err := encryptor.Encrypt(context.Background(), "domain", []byte("payload"))
+	require.NoError(t, err)
+
+	assert.True(t, ContainsEncrypted(append([]byte("prefix"), encrypted...)))
+	assert.False(t, ContainsEncrypted([]byte("payload")))
+}
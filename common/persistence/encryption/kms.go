@@ -0,0 +1,159 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// dataKeySize is the size of the AES-256 keys
+const dataKeySize = 32
+
+type (
+	// KMS manages the master keys which encrypt the data keys of the payloads
+	KMS interface {
+		// GenerateDataKey returns a new data key, both in plain text and encrypted with the current master key
+		GenerateDataKey(ctx context.Context) (*DataKey, error)
+		// DecryptDataKey decrypts a data key encrypted with the given master key
+		DecryptDataKey(ctx context.Context, masterKeyID string, encryptedKey []byte) ([]byte, error)
+	}
+
+	// DataKey is a data key generated by a KMS
+	DataKey struct {
+		MasterKeyID string
+		Plaintext   []byte
+		Encrypted   []byte
+	}
+
+	// KeyFile is the content of the file used by the key file KMS.
+	// Master keys are rotated by adding a new key and changing the primary key. Old keys must be kept
+	// as long as there are payloads encrypted with data keys they have encrypted.
+	KeyFile struct {
+		// PrimaryKey is the ID of the master key used to encrypt new data keys
+		PrimaryKey string `yaml:"primaryKey"`
+		// Keys are the base64 encoded 32 bytes master keys by ID
+		Keys map[string]string `yaml:"keys"`
+	}
+
+	keyFileKMS struct {
+		primaryKey string
+		keys       map[string]cipher.AEAD
+	}
+)
+
+// NewKeyFileKMS returns a KMS which reads its master keys from a local file.
+// It is meant for testing and for deployments without an external KMS, as the master keys are stored in plain text.
+func NewKeyFileKMS(path string) (KMS, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+	var keyFile KeyFile
+	if err := yaml.Unmarshal(content, &keyFile); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %v", err)
+	}
+	return newKeyFileKMS(&keyFile)
+}
+
+func newKeyFileKMS(keyFile *KeyFile) (KMS, error) {
+	if _, ok := keyFile.Keys[keyFile.PrimaryKey]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the key file", keyFile.PrimaryKey)
+	}
+	keys := make(map[string]cipher.AEAD, len(keyFile.Keys))
+	for id, encoded := range keyFile.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %q: %v", id, err)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("key %q must be %v bytes long, got %v", id, dataKeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		keys[id] = aead
+	}
+	return &keyFileKMS{
+		primaryKey: keyFile.PrimaryKey,
+		keys:       keys,
+	}, nil
+}
+
+func (k *keyFileKMS) GenerateDataKey(_ context.Context) (*DataKey, error) {
+	plaintext := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, plaintext); err != nil {
+		return nil, err
+	}
+	encrypted, err := seal(k.keys[k.primaryKey], plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{
+		MasterKeyID: k.primaryKey,
+		Plaintext:   plaintext,
+		Encrypted:   encrypted,
+	}, nil
+}
+
+func (k *keyFileKMS) DecryptDataKey(_ context.Context, masterKeyID string, encryptedKey []byte) ([]byte, error) {
+	aead, ok := k.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not in the key file", masterKeyID)
+	}
+	return open(aead, encryptedKey)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext and prepends the random nonce to the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,114 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	key := make([]byte, dataKeySize)
+	for i := range key {
+		key[i] = b
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestNewKeyFileKMS(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		err     string
+	}{
+		"valid": {
+			content: "primaryKey: key-2\nkeys:\n  key-1: " + testKey(1) + "\n  key-2: " + testKey(2) + "\n",
+		},
+		"missing primary key": {
+			content: "primaryKey: key-3\nkeys:\n  key-1: " + testKey(1) + "\n",
+			err:     `primary key "key-3" is not in the key file`,
+		},
+		"invalid base64": {
+			content: "primaryKey: key-1\nkeys:\n  key-1: '!!!'\n",
+			err:     `failed to decode key "key-1"`,
+		},
+		"invalid key size": {
+			content: "primaryKey: key-1\nkeys:\n  key-1: " + base64.StdEncoding.EncodeToString([]byte("short")) + "\n",
+			err:     `key "key-1" must be 32 bytes long, got 5`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0600))
+			kms, err := NewKeyFileKMS(path)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, kms)
+		})
+	}
+
+	_, err := NewKeyFileKMS(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read key file")
+}
+
+func TestKeyFileKMS_Rotation(t *testing.T) {
+	ctx := context.Background()
+	oldKMS, err := newKeyFileKMS(&KeyFile{
+		PrimaryKey: "key-1",
+		Keys:       map[string]string{"key-1": testKey(1)},
+	})
+	require.NoError(t, err)
+	dataKey, err := oldKMS.GenerateDataKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", dataKey.MasterKeyID)
+	assert.Len(t, dataKey.Plaintext, dataKeySize)
+	assert.NotEqual(t, dataKey.Plaintext, dataKey.Encrypted)
+
+	// the new primary key is used for new data keys, and the old one still decrypts the data keys it has encrypted
+	newKMS, err := newKeyFileKMS(&KeyFile{
+		PrimaryKey: "key-2",
+		Keys:       map[string]string{"key-1": testKey(1), "key-2": testKey(2)},
+	})
+	require.NoError(t, err)
+	plaintext, err := newKMS.DecryptDataKey(ctx, dataKey.MasterKeyID, dataKey.Encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey.Plaintext, plaintext)
+
+	newDataKey, err := newKMS.GenerateDataKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", newDataKey.MasterKeyID)
+
+	_, err = oldKMS.DecryptDataKey(ctx, newDataKey.MasterKeyID, newDataKey.Encrypted)
+	assert.ErrorContains(t, err, `master key "key-2" is not in the key file`)
+	_, err = newKMS.DecryptDataKey(ctx, "key-1", newDataKey.Encrypted)
+	assert.Error(t, err)
+}
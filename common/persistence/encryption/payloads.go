@@ -0,0 +1,377 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"context"
+
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+// The functions below apply the encryptor to the payloads of history events and mutable state:
+// inputs, results, failure details, heartbeat details, signal inputs and memos. Metadata which is read
+// by the server, such as search attributes, is left in plain text.
+// They never modify their arguments, as the callers keep using them after they are persisted.
+
+// EncryptEvents returns copies of the events with encrypted payloads
+func EncryptEvents(
+	ctx context.Context,
+	encryptor Encryptor,
+	domainName string,
+	events []*types.HistoryEvent,
+) ([]*types.HistoryEvent, error) {
+	t := newEncryptTransformer(ctx, encryptor, domainName)
+	result := t.events(events)
+	return result, t.err
+}
+
+// DecryptEvents returns copies of the events with decrypted payloads
+func DecryptEvents(
+	ctx context.Context,
+	encryptor Encryptor,
+	events []*types.HistoryEvent,
+) ([]*types.HistoryEvent, error) {
+	t := newDecryptTransformer(ctx, encryptor)
+	result := t.events(events)
+	return result, t.err
+}
+
+// EncryptWorkflowSnapshot returns a copy of the snapshot with encrypted payloads
+func EncryptWorkflowSnapshot(
+	ctx context.Context,
+	encryptor Encryptor,
+	domainName string,
+	snapshot *persistence.WorkflowSnapshot,
+) (*persistence.WorkflowSnapshot, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	t := newEncryptTransformer(ctx, encryptor, domainName)
+	result := *snapshot
+	result.ExecutionInfo = t.executionInfo(snapshot.ExecutionInfo)
+	result.ActivityInfos = t.activityInfos(snapshot.ActivityInfos)
+	result.ChildExecutionInfos = t.childExecutionInfos(snapshot.ChildExecutionInfos)
+	result.SignalInfos = t.signalInfos(snapshot.SignalInfos)
+	return &result, t.err
+}
+
+// EncryptWorkflowMutation returns a copy of the mutation with encrypted payloads
+func EncryptWorkflowMutation(
+	ctx context.Context,
+	encryptor Encryptor,
+	domainName string,
+	mutation *persistence.WorkflowMutation,
+) (*persistence.WorkflowMutation, error) {
+	if mutation == nil {
+		return nil, nil
+	}
+	t := newEncryptTransformer(ctx, encryptor, domainName)
+	result := *mutation
+	result.ExecutionInfo = t.executionInfo(mutation.ExecutionInfo)
+	result.UpsertActivityInfos = t.activityInfos(mutation.UpsertActivityInfos)
+	result.UpsertChildExecutionInfos = t.childExecutionInfos(mutation.UpsertChildExecutionInfos)
+	result.UpsertSignalInfos = t.signalInfos(mutation.UpsertSignalInfos)
+	result.NewBufferedEvents = t.events(mutation.NewBufferedEvents)
+	return &result, t.err
+}
+
+// DecryptWorkflowMutableState returns a copy of the mutable state with decrypted payloads
+func DecryptWorkflowMutableState(
+	ctx context.Context,
+	encryptor Encryptor,
+	state *persistence.WorkflowMutableState,
+) (*persistence.WorkflowMutableState, error) {
+	if state == nil {
+		return nil, nil
+	}
+	t := newDecryptTransformer(ctx, encryptor)
+	result := *state
+	result.ExecutionInfo = t.executionInfo(state.ExecutionInfo)
+	if state.ActivityInfos != nil {
+		result.ActivityInfos = make(map[int64]*persistence.ActivityInfo, len(state.ActivityInfos))
+		for id, info := range state.ActivityInfos {
+			result.ActivityInfos[id] = t.activityInfo(info)
+		}
+	}
+	if state.ChildExecutionInfos != nil {
+		result.ChildExecutionInfos = make(map[int64]*persistence.ChildExecutionInfo, len(state.ChildExecutionInfos))
+		for id, info := range state.ChildExecutionInfos {
+			result.ChildExecutionInfos[id] = t.childExecutionInfo(info)
+		}
+	}
+	if state.SignalInfos != nil {
+		result.SignalInfos = make(map[int64]*persistence.SignalInfo, len(state.SignalInfos))
+		for id, info := range state.SignalInfos {
+			result.SignalInfos[id] = t.signalInfo(info)
+		}
+	}
+	result.BufferedEvents = t.events(state.BufferedEvents)
+	return &result, t.err
+}
+
+// DecryptWorkflowExecutionInfo returns a copy of the execution info with decrypted payloads
+func DecryptWorkflowExecutionInfo(
+	ctx context.Context,
+	encryptor Encryptor,
+	info *persistence.WorkflowExecutionInfo,
+) (*persistence.WorkflowExecutionInfo, error) {
+	t := newDecryptTransformer(ctx, encryptor)
+	result := t.executionInfo(info)
+	return result, t.err
+}
+
+// transformer applies a function to every payload and keeps the first error it returns
+type transformer struct {
+	fn  func([]byte) ([]byte, error)
+	err error
+}
+
+func newEncryptTransformer(ctx context.Context, encryptor Encryptor, domainName string) *transformer {
+	return &transformer{
+		fn: func(data []byte) ([]byte, error) {
+			return encryptor.Encrypt(ctx, domainName, data)
+		},
+	}
+}
+
+func newDecryptTransformer(ctx context.Context, encryptor Encryptor) *transformer {
+	return &transformer{
+		fn: func(data []byte) ([]byte, error) {
+			return encryptor.Decrypt(ctx, data)
+		},
+	}
+}
+
+func (t *transformer) bytes(data []byte) []byte {
+	if t.err != nil || len(data) == 0 {
+		return data
+	}
+	result, err := t.fn(data)
+	if err != nil {
+		t.err = err
+		return data
+	}
+	return result
+}
+
+func (t *transformer) fields(fields map[string][]byte) map[string][]byte {
+	if fields == nil {
+		return nil
+	}
+	result := make(map[string][]byte, len(fields))
+	for key, value := range fields {
+		result[key] = t.bytes(value)
+	}
+	return result
+}
+
+func (t *transformer) memo(memo *types.Memo) *types.Memo {
+	if memo == nil {
+		return nil
+	}
+	return &types.Memo{Fields: t.fields(memo.Fields)}
+}
+
+func (t *transformer) events(events []*types.HistoryEvent) []*types.HistoryEvent {
+	if events == nil {
+		return nil
+	}
+	result := make([]*types.HistoryEvent, len(events))
+	for i, event := range events {
+		result[i] = t.event(event)
+	}
+	return result
+}
+
+func (t *transformer) event(event *types.HistoryEvent) *types.HistoryEvent {
+	if event == nil {
+		return nil
+	}
+	e := *event
+	switch {
+	case e.WorkflowExecutionStartedEventAttributes != nil:
+		attr := *e.WorkflowExecutionStartedEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		attr.ContinuedFailureDetails = t.bytes(attr.ContinuedFailureDetails)
+		attr.LastCompletionResult = t.bytes(attr.LastCompletionResult)
+		attr.Memo = t.memo(attr.Memo)
+		e.WorkflowExecutionStartedEventAttributes = &attr
+	case e.WorkflowExecutionCompletedEventAttributes != nil:
+		attr := *e.WorkflowExecutionCompletedEventAttributes
+		attr.Result = t.bytes(attr.Result)
+		e.WorkflowExecutionCompletedEventAttributes = &attr
+	case e.WorkflowExecutionFailedEventAttributes != nil:
+		attr := *e.WorkflowExecutionFailedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.WorkflowExecutionFailedEventAttributes = &attr
+	case e.WorkflowExecutionTerminatedEventAttributes != nil:
+		attr := *e.WorkflowExecutionTerminatedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.WorkflowExecutionTerminatedEventAttributes = &attr
+	case e.WorkflowExecutionCanceledEventAttributes != nil:
+		attr := *e.WorkflowExecutionCanceledEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.WorkflowExecutionCanceledEventAttributes = &attr
+	case e.WorkflowExecutionContinuedAsNewEventAttributes != nil:
+		attr := *e.WorkflowExecutionContinuedAsNewEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		attr.FailureDetails = t.bytes(attr.FailureDetails)
+		attr.LastCompletionResult = t.bytes(attr.LastCompletionResult)
+		attr.Memo = t.memo(attr.Memo)
+		e.WorkflowExecutionContinuedAsNewEventAttributes = &attr
+	case e.WorkflowExecutionSignaledEventAttributes != nil:
+		attr := *e.WorkflowExecutionSignaledEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		e.WorkflowExecutionSignaledEventAttributes = &attr
+	case e.DecisionTaskFailedEventAttributes != nil:
+		attr := *e.DecisionTaskFailedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.DecisionTaskFailedEventAttributes = &attr
+	case e.ActivityTaskScheduledEventAttributes != nil:
+		attr := *e.ActivityTaskScheduledEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		e.ActivityTaskScheduledEventAttributes = &attr
+	case e.ActivityTaskStartedEventAttributes != nil:
+		attr := *e.ActivityTaskStartedEventAttributes
+		attr.LastFailureDetails = t.bytes(attr.LastFailureDetails)
+		e.ActivityTaskStartedEventAttributes = &attr
+	case e.ActivityTaskCompletedEventAttributes != nil:
+		attr := *e.ActivityTaskCompletedEventAttributes
+		attr.Result = t.bytes(attr.Result)
+		e.ActivityTaskCompletedEventAttributes = &attr
+	case e.ActivityTaskFailedEventAttributes != nil:
+		attr := *e.ActivityTaskFailedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.ActivityTaskFailedEventAttributes = &attr
+	case e.ActivityTaskTimedOutEventAttributes != nil:
+		attr := *e.ActivityTaskTimedOutEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		attr.LastFailureDetails = t.bytes(attr.LastFailureDetails)
+		e.ActivityTaskTimedOutEventAttributes = &attr
+	case e.ActivityTaskCanceledEventAttributes != nil:
+		attr := *e.ActivityTaskCanceledEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.ActivityTaskCanceledEventAttributes = &attr
+	case e.MarkerRecordedEventAttributes != nil:
+		attr := *e.MarkerRecordedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.MarkerRecordedEventAttributes = &attr
+	case e.SignalExternalWorkflowExecutionInitiatedEventAttributes != nil:
+		attr := *e.SignalExternalWorkflowExecutionInitiatedEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		e.SignalExternalWorkflowExecutionInitiatedEventAttributes = &attr
+	case e.StartChildWorkflowExecutionInitiatedEventAttributes != nil:
+		attr := *e.StartChildWorkflowExecutionInitiatedEventAttributes
+		attr.Input = t.bytes(attr.Input)
+		attr.Memo = t.memo(attr.Memo)
+		e.StartChildWorkflowExecutionInitiatedEventAttributes = &attr
+	case e.ChildWorkflowExecutionCompletedEventAttributes != nil:
+		attr := *e.ChildWorkflowExecutionCompletedEventAttributes
+		attr.Result = t.bytes(attr.Result)
+		e.ChildWorkflowExecutionCompletedEventAttributes = &attr
+	case e.ChildWorkflowExecutionFailedEventAttributes != nil:
+		attr := *e.ChildWorkflowExecutionFailedEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.ChildWorkflowExecutionFailedEventAttributes = &attr
+	case e.ChildWorkflowExecutionCanceledEventAttributes != nil:
+		attr := *e.ChildWorkflowExecutionCanceledEventAttributes
+		attr.Details = t.bytes(attr.Details)
+		e.ChildWorkflowExecutionCanceledEventAttributes = &attr
+	}
+	return &e
+}
+
+func (t *transformer) executionInfo(info *persistence.WorkflowExecutionInfo) *persistence.WorkflowExecutionInfo {
+	if info == nil {
+		return nil
+	}
+	result := *info
+	result.CompletionEvent = t.event(info.CompletionEvent)
+	result.Memo = t.fields(info.Memo)
+	return &result
+}
+
+func (t *transformer) activityInfos(infos []*persistence.ActivityInfo) []*persistence.ActivityInfo {
+	if infos == nil {
+		return nil
+	}
+	result := make([]*persistence.ActivityInfo, len(infos))
+	for i, info := range infos {
+		result[i] = t.activityInfo(info)
+	}
+	return result
+}
+
+func (t *transformer) activityInfo(info *persistence.ActivityInfo) *persistence.ActivityInfo {
+	if info == nil {
+		return nil
+	}
+	result := *info
+	result.ScheduledEvent = t.event(info.ScheduledEvent)
+	result.StartedEvent = t.event(info.StartedEvent)
+	result.Details = t.bytes(info.Details)
+	result.LastFailureDetails = t.bytes(info.LastFailureDetails)
+	return &result
+}
+
+func (t *transformer) childExecutionInfos(infos []*persistence.ChildExecutionInfo) []*persistence.ChildExecutionInfo {
+	if infos == nil {
+		return nil
+	}
+	result := make([]*persistence.ChildExecutionInfo, len(infos))
+	for i, info := range infos {
+		result[i] = t.childExecutionInfo(info)
+	}
+	return result
+}
+
+func (t *transformer) childExecutionInfo(info *persistence.ChildExecutionInfo) *persistence.ChildExecutionInfo {
+	if info == nil {
+		return nil
+	}
+	result := *info
+	result.InitiatedEvent = t.event(info.InitiatedEvent)
+	result.StartedEvent = t.event(info.StartedEvent)
+	return &result
+}
+
+func (t *transformer) signalInfos(infos []*persistence.SignalInfo) []*persistence.SignalInfo {
+	if infos == nil {
+		return nil
+	}
+	result := make([]*persistence.SignalInfo, len(infos))
+	for i, info := range infos {
+		result[i] = t.signalInfo(info)
+	}
+	return result
+}
+
+func (t *transformer) signalInfo(info *persistence.SignalInfo) *persistence.SignalInfo {
+	if info == nil {
+		return nil
+	}
+	result := *info
+	result.Input = t.bytes(info.Input)
+	return &result
+}
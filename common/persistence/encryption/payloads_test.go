@@ -0,0 +1,201 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestEvents_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	events := []*types.HistoryEvent{
+		{
+			ID:        1,
+			EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+			WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+				WorkflowType:         &types.WorkflowType{Name: "workflow"},
+				Input:                []byte("input"),
+				LastCompletionResult: []byte("last result"),
+				Memo:                 &types.Memo{Fields: map[string][]byte{"key": []byte("memo")}},
+				SearchAttributes:     &types.SearchAttributes{IndexedFields: map[string][]byte{"CustomKeywordField": []byte(`"keyword"`)}},
+			},
+		},
+		{
+			ID:        2,
+			EventType: types.EventTypeActivityTaskScheduled.Ptr(),
+			ActivityTaskScheduledEventAttributes: &types.ActivityTaskScheduledEventAttributes{
+				ActivityID: "activity",
+				Input:      []byte("activity input"),
+			},
+		},
+		{
+			ID:        3,
+			EventType: types.EventTypeWorkflowExecutionSignaled.Ptr(),
+			WorkflowExecutionSignaledEventAttributes: &types.WorkflowExecutionSignaledEventAttributes{
+				SignalName: "signal",
+				Input:      []byte("signal input"),
+			},
+		},
+		{
+			ID:        4,
+			EventType: types.EventTypeTimerStarted.Ptr(),
+			TimerStartedEventAttributes: &types.TimerStartedEventAttributes{
+				TimerID: "timer",
+			},
+		},
+		nil,
+	}
+
+	encrypted, err := EncryptEvents(ctx, encryptor, "domain", events)
+	require.NoError(t, err)
+	require.Len(t, encrypted, len(events))
+
+	started := encrypted[0].WorkflowExecutionStartedEventAttributes
+	assert.True(t, IsEncrypted(started.Input))
+	assert.True(t, IsEncrypted(started.LastCompletionResult))
+	assert.True(t, IsEncrypted(started.Memo.Fields["key"]))
+	assert.Equal(t, "workflow", started.WorkflowType.Name)
+	assert.Equal(t, []byte(`"keyword"`), started.SearchAttributes.IndexedFields["CustomKeywordField"])
+	assert.True(t, IsEncrypted(encrypted[1].ActivityTaskScheduledEventAttributes.Input))
+	assert.True(t, IsEncrypted(encrypted[2].WorkflowExecutionSignaledEventAttributes.Input))
+	assert.Equal(t, events[3], encrypted[3])
+	assert.Nil(t, encrypted[4])
+
+	// the original events are not modified
+	assert.Equal(t, []byte("input"), events[0].WorkflowExecutionStartedEventAttributes.Input)
+	assert.Equal(t, []byte("memo"), events[0].WorkflowExecutionStartedEventAttributes.Memo.Fields["key"])
+
+	decrypted, err := DecryptEvents(ctx, encryptor, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, events, decrypted)
+}
+
+func TestMutableState_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+	scheduledEvent := &types.HistoryEvent{
+		ID: 5,
+		ActivityTaskScheduledEventAttributes: &types.ActivityTaskScheduledEventAttributes{
+			Input: []byte("activity input"),
+		},
+	}
+	executionInfo := &persistence.WorkflowExecutionInfo{
+		DomainID:   "domain-id",
+		WorkflowID: "workflow-id",
+		Memo:       map[string][]byte{"key": []byte("memo")},
+	}
+	activityInfo := &persistence.ActivityInfo{
+		ScheduleID:     5,
+		ScheduledEvent: scheduledEvent,
+		Details:        []byte("heartbeat details"),
+	}
+	childInfo := &persistence.ChildExecutionInfo{
+		InitiatedID: 6,
+		InitiatedEvent: &types.HistoryEvent{
+			ID: 6,
+			StartChildWorkflowExecutionInitiatedEventAttributes: &types.StartChildWorkflowExecutionInitiatedEventAttributes{
+				Input: []byte("child input"),
+			},
+		},
+	}
+	signalInfo := &persistence.SignalInfo{
+		InitiatedID: 7,
+		Input:       []byte("signal input"),
+		Control:     []byte("control"),
+	}
+	bufferedEvent := &types.HistoryEvent{
+		ID: common.BufferedEventID,
+		WorkflowExecutionSignaledEventAttributes: &types.WorkflowExecutionSignaledEventAttributes{
+			Input: []byte("buffered signal"),
+		},
+	}
+
+	snapshot, err := EncryptWorkflowSnapshot(ctx, encryptor, "domain", &persistence.WorkflowSnapshot{
+		ExecutionInfo:       executionInfo,
+		ActivityInfos:       []*persistence.ActivityInfo{activityInfo},
+		ChildExecutionInfos: []*persistence.ChildExecutionInfo{childInfo},
+		SignalInfos:         []*persistence.SignalInfo{signalInfo},
+	})
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(snapshot.ExecutionInfo.Memo["key"]))
+	assert.Equal(t, "workflow-id", snapshot.ExecutionInfo.WorkflowID)
+	assert.True(t, IsEncrypted(snapshot.ActivityInfos[0].Details))
+	assert.True(t, IsEncrypted(snapshot.ActivityInfos[0].ScheduledEvent.ActivityTaskScheduledEventAttributes.Input))
+	assert.True(t, IsEncrypted(snapshot.ChildExecutionInfos[0].InitiatedEvent.StartChildWorkflowExecutionInitiatedEventAttributes.Input))
+	assert.True(t, IsEncrypted(snapshot.SignalInfos[0].Input))
+	assert.Equal(t, []byte("control"), snapshot.SignalInfos[0].Control)
+	assert.Equal(t, []byte("heartbeat details"), activityInfo.Details)
+
+	mutation, err := EncryptWorkflowMutation(ctx, encryptor, "domain", &persistence.WorkflowMutation{
+		ExecutionInfo:       executionInfo,
+		UpsertActivityInfos: []*persistence.ActivityInfo{activityInfo},
+		UpsertSignalInfos:   []*persistence.SignalInfo{signalInfo},
+		NewBufferedEvents:   []*types.HistoryEvent{bufferedEvent},
+	})
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(mutation.UpsertActivityInfos[0].Details))
+	assert.True(t, IsEncrypted(mutation.UpsertSignalInfos[0].Input))
+	assert.True(t, IsEncrypted(mutation.NewBufferedEvents[0].WorkflowExecutionSignaledEventAttributes.Input))
+
+	state, err := DecryptWorkflowMutableState(ctx, encryptor, &persistence.WorkflowMutableState{
+		ExecutionInfo:       snapshot.ExecutionInfo,
+		ActivityInfos:       map[int64]*persistence.ActivityInfo{5: snapshot.ActivityInfos[0]},
+		ChildExecutionInfos: map[int64]*persistence.ChildExecutionInfo{6: snapshot.ChildExecutionInfos[0]},
+		SignalInfos:         map[int64]*persistence.SignalInfo{7: snapshot.SignalInfos[0]},
+		BufferedEvents:      mutation.NewBufferedEvents,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, executionInfo, state.ExecutionInfo)
+	assert.Equal(t, activityInfo, state.ActivityInfos[5])
+	assert.Equal(t, childInfo, state.ChildExecutionInfos[6])
+	assert.Equal(t, signalInfo, state.SignalInfos[7])
+	assert.Equal(t, []*types.HistoryEvent{bufferedEvent}, state.BufferedEvents)
+
+	info, err := DecryptWorkflowExecutionInfo(ctx, encryptor, snapshot.ExecutionInfo)
+	require.NoError(t, err)
+	assert.Equal(t, executionInfo, info)
+}
+
+func TestMutableState_Nil(t *testing.T) {
+	ctx := context.Background()
+	encryptor := NewEncryptor(newTestKMS(t), 0)
+
+	snapshot, err := EncryptWorkflowSnapshot(ctx, encryptor, "domain", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+	mutation, err := EncryptWorkflowMutation(ctx, encryptor, "domain", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, mutation)
+	state, err := DecryptWorkflowMutableState(ctx, encryptor, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
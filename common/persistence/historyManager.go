@@ -254,10 +254,20 @@ func (m *historyV2ManagerImpl) AppendHistoryNodes(
 	}
 
 	err = m.persistence.AppendHistoryNodes(ctx, req)
+	if err != nil {
+		return &AppendHistoryNodesResponse{
+			DataBlob: *blob,
+		}, err
+	}
 
+	// compression is only applied to the stored blob, callers such as replication expect the ThriftRW blob
+	blob, err = DecompressDataBlob(blob)
+	if err != nil {
+		return nil, err
+	}
 	return &AppendHistoryNodesResponse{
 		DataBlob: *blob,
-	}, err
+	}, nil
 }
 
 // ReadHistoryBranchByBatch returns history node data for a branch by batch
@@ -427,9 +437,15 @@ func (m *historyV2ManagerImpl) readRawHistoryBranch(
 		return nil, nil, 0, nil, &types.EntityNotExistsError{Message: "Workflow execution history not found."}
 	}
 
-	dataBlobs := resp.History
+	// raw blobs are sent to other clusters and clients, so the compressed ones are returned as ThriftRW
+	dataBlobs := make([]*DataBlob, 0, len(resp.History))
 	dataSize := 0
 	for _, dataBlob := range resp.History {
+		dataBlob, err = DecompressDataBlob(dataBlob)
+		if err != nil {
+			return nil, nil, 0, nil, err
+		}
+		dataBlobs = append(dataBlobs, dataBlob)
 		dataSize += len(dataBlob.Data)
 	}
 
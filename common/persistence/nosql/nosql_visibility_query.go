@@ -0,0 +1,506 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nosql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// visibilityQuery is a visibility query as accepted by ListWorkflowExecutions, restricted to what can be served
+	// by the tables of NoSQL visibility: an equality on one of WorkflowType, WorkflowID, CloseStatus or an indexed
+	// search attribute, and a range of StartTime or CloseTime, combined by AND.
+	visibilityQuery struct {
+		// open is nil if the query matches both open and closed workflows
+		open                 *bool
+		workflowType         *string
+		workflowID           *string
+		closeStatus          *int32
+		searchAttributeKey   string
+		searchAttributeValue string
+		startTime            *visibilityTimeRange
+		closeTime            *visibilityTimeRange
+		// orderByCloseTime is nil if the query has no order by clause
+		orderByCloseTime *bool
+	}
+
+	// visibilityTimeRange is inclusive on both ends
+	visibilityTimeRange struct {
+		earliest time.Time
+		latest   time.Time
+	}
+)
+
+const (
+	visibilityQueryMissing = "missing"
+)
+
+var (
+	minVisibilityTime = time.Unix(0, 0)
+	maxVisibilityTime = time.Unix(0, math.MaxInt64)
+
+	errVisibilityQueryMultipleFilters = errors.New("only one of WorkflowType, WorkflowID, CloseStatus and indexed search attributes can be used in a query")
+)
+
+// parseVisibilityQuery parses a visibility query, and returns BadRequestError if the query is not supported.
+// Custom search attributes can only be used if they are in indexedSearchAttributes.
+func parseVisibilityQuery(query string, indexedSearchAttributes map[string]struct{}) (*visibilityQuery, error) {
+	result := &visibilityQuery{}
+	query = strings.TrimSpace(query)
+	if len(query) == 0 {
+		return result, nil
+	}
+
+	// IMPORTANT: This query is never executed, it is just used to parse the where and order by clauses
+	var placeholderQuery string
+	if common.IsJustOrderByClause(query) {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy %s", query)
+	} else {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy WHERE %s", query)
+	}
+	stmt, err := sqlparser.Parse(placeholderQuery)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: "Invalid query."}
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil, &types.BadRequestError{Message: "Invalid select query."}
+	}
+
+	if sel.Where != nil {
+		if err := result.parseExpr(sel.Where.Expr, indexedSearchAttributes); err != nil {
+			return nil, &types.BadRequestError{Message: err.Error()}
+		}
+	}
+	if err := result.parseOrderBy(sel.OrderBy); err != nil {
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+	if err := result.validate(); err != nil {
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+	return result, nil
+}
+
+// filter returns the filter selecting either the open or the closed workflows matched by the query
+func (q *visibilityQuery) filter(closed bool, sortByCloseTime bool) *nosqlplugin.VisibilityFilter {
+	filter := &nosqlplugin.VisibilityFilter{SortType: nosqlplugin.SortByStartTime}
+	if closed {
+		switch {
+		case q.closeTime != nil || (q.orderByCloseTime != nil && *q.orderByCloseTime):
+			filter.SortType = nosqlplugin.SortByClosedTime
+		case q.startTime == nil && q.orderByCloseTime == nil && q.searchAttributeKey == "" && sortByCloseTime:
+			filter.SortType = nosqlplugin.SortByClosedTime
+		}
+	}
+
+	switch {
+	case q.workflowType != nil:
+		filter.FilterType = nosqlplugin.OpenByWorkflowType
+		if closed {
+			filter.FilterType = nosqlplugin.ClosedByWorkflowType
+		}
+		filter.WorkflowType = *q.workflowType
+	case q.workflowID != nil:
+		filter.FilterType = nosqlplugin.OpenByWorkflowID
+		if closed {
+			filter.FilterType = nosqlplugin.ClosedByWorkflowID
+		}
+		filter.WorkflowID = *q.workflowID
+	case q.closeStatus != nil:
+		filter.FilterType = nosqlplugin.ClosedByClosedStatus
+		filter.CloseStatus = *q.closeStatus
+	case q.searchAttributeKey != "":
+		filter.FilterType = nosqlplugin.OpenBySearchAttribute
+		if closed {
+			filter.FilterType = nosqlplugin.ClosedBySearchAttribute
+		}
+		filter.SearchAttributeKey = q.searchAttributeKey
+		filter.SearchAttributeValue = q.searchAttributeValue
+	default:
+		filter.FilterType = nosqlplugin.AllOpen
+		if closed {
+			filter.FilterType = nosqlplugin.AllClosed
+		}
+	}
+
+	timeRange := q.startTime
+	if filter.SortType == nosqlplugin.SortByClosedTime {
+		timeRange = q.closeTime
+	}
+	filter.ListRequest.EarliestTime = minVisibilityTime
+	filter.ListRequest.LatestTime = maxVisibilityTime
+	if timeRange != nil {
+		filter.ListRequest.EarliestTime = timeRange.earliest
+		filter.ListRequest.LatestTime = timeRange.latest
+	}
+	return filter
+}
+
+func (q *visibilityQuery) parseExpr(expr sqlparser.Expr, indexedSearchAttributes map[string]struct{}) error {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		if err := q.parseExpr(expr.Left, indexedSearchAttributes); err != nil {
+			return err
+		}
+		return q.parseExpr(expr.Right, indexedSearchAttributes)
+	case *sqlparser.ParenExpr:
+		return q.parseExpr(expr.Expr, indexedSearchAttributes)
+	case *sqlparser.ComparisonExpr:
+		return q.parseComparisonExpr(expr, indexedSearchAttributes)
+	case *sqlparser.RangeCond:
+		return q.parseRangeCond(expr)
+	case *sqlparser.OrExpr:
+		return errors.New("OR is not supported by NoSQL visibility, predicates can only be combined by AND")
+	default:
+		return errors.New("invalid where clause")
+	}
+}
+
+func (q *visibilityQuery) parseComparisonExpr(expr *sqlparser.ComparisonExpr, indexedSearchAttributes map[string]struct{}) error {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return errors.New("invalid comparison expression")
+	}
+	name := colName.Name.String()
+
+	switch name {
+	case definition.StartTime:
+		return q.parseTimeComparison(&q.startTime, name, expr.Operator, expr.Right)
+	case definition.CloseTime:
+		if right, ok := expr.Right.(*sqlparser.ColName); ok && right.Name.String() == visibilityQueryMissing {
+			switch expr.Operator {
+			case sqlparser.EqualStr:
+				return q.setOpen(true)
+			case sqlparser.NotEqualStr:
+				return q.setOpen(false)
+			}
+			return fmt.Errorf("operator %q is not supported for missing value", expr.Operator)
+		}
+		if err := q.setOpen(false); err != nil {
+			return err
+		}
+		return q.parseTimeComparison(&q.closeTime, name, expr.Operator, expr.Right)
+	}
+
+	if expr.Operator != sqlparser.EqualStr {
+		return fmt.Errorf("operator %q is not supported on %s by NoSQL visibility, only \"=\" is supported", expr.Operator, name)
+	}
+	value, isString, err := parseVisibilityQueryLiteral(expr.Right)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case definition.WorkflowType:
+		if !isString {
+			return fmt.Errorf("invalid value for %s: expect string but got %v", name, value)
+		}
+		if q.workflowType != nil {
+			return fmt.Errorf("%s can only be used once in a query", name)
+		}
+		q.workflowType = &value
+		return nil
+	case definition.WorkflowID:
+		if !isString {
+			return fmt.Errorf("invalid value for %s: expect string but got %v", name, value)
+		}
+		if q.workflowID != nil {
+			return fmt.Errorf("%s can only be used once in a query", name)
+		}
+		q.workflowID = &value
+		return nil
+	case definition.CloseStatus:
+		status, err := parseVisibilityQueryCloseStatus(value, isString)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if q.closeStatus != nil {
+			return fmt.Errorf("%s can only be used once in a query", name)
+		}
+		q.closeStatus = &status
+		return q.setOpen(false)
+	}
+
+	key := strings.TrimPrefix(name, definition.Attr+".")
+	if definition.IsSystemIndexedKey(key) {
+		return fmt.Errorf("%s is not supported by NoSQL visibility, supported are WorkflowType, WorkflowID, CloseStatus, StartTime, CloseTime and indexed search attributes", key)
+	}
+	if _, ok := indexedSearchAttributes[key]; !ok {
+		return fmt.Errorf("search attribute %s is not indexed by NoSQL visibility", key)
+	}
+	if q.searchAttributeKey != "" {
+		return errVisibilityQueryMultipleFilters
+	}
+	q.searchAttributeKey = key
+	q.searchAttributeValue = value
+	return nil
+}
+
+func (q *visibilityQuery) parseRangeCond(expr *sqlparser.RangeCond) error {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return errors.New("invalid range expression")
+	}
+	name := colName.Name.String()
+	if expr.Operator != sqlparser.BetweenStr {
+		return fmt.Errorf("operator %q is not supported by NoSQL visibility", expr.Operator)
+	}
+
+	var timeRange **visibilityTimeRange
+	switch name {
+	case definition.StartTime:
+		timeRange = &q.startTime
+	case definition.CloseTime:
+		if err := q.setOpen(false); err != nil {
+			return err
+		}
+		timeRange = &q.closeTime
+	default:
+		return fmt.Errorf("range on %s is not supported by NoSQL visibility, only StartTime and CloseTime ranges are supported", name)
+	}
+	if err := q.parseTimeComparison(timeRange, name, sqlparser.GreaterEqualStr, expr.From); err != nil {
+		return err
+	}
+	return q.parseTimeComparison(timeRange, name, sqlparser.LessEqualStr, expr.To)
+}
+
+// parseTimeComparison narrows the time range by the comparison. Visibility records are stored with
+// millisecond precision, so the range is rounded to milliseconds.
+func (q *visibilityQuery) parseTimeComparison(timeRange **visibilityTimeRange, name string, operator string, expr sqlparser.Expr) error {
+	value, isString, err := parseVisibilityQueryLiteral(expr)
+	if err != nil {
+		return err
+	}
+	t, err := parseVisibilityQueryTime(value, isString)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+
+	if *timeRange == nil {
+		*timeRange = &visibilityTimeRange{earliest: minVisibilityTime, latest: maxVisibilityTime}
+	}
+	floor := t.Truncate(time.Millisecond)
+	ceil := floor
+	if floor.Before(t) {
+		ceil = floor.Add(time.Millisecond)
+	}
+	switch operator {
+	case sqlparser.GreaterEqualStr:
+		(*timeRange).earliest = maxTime((*timeRange).earliest, ceil)
+	case sqlparser.GreaterThanStr:
+		(*timeRange).earliest = maxTime((*timeRange).earliest, floor.Add(time.Millisecond))
+	case sqlparser.LessEqualStr:
+		(*timeRange).latest = minTime((*timeRange).latest, floor)
+	case sqlparser.LessThanStr:
+		(*timeRange).latest = minTime((*timeRange).latest, ceil.Add(-time.Millisecond))
+	default:
+		return fmt.Errorf("operator %q is not supported on %s by NoSQL visibility, only ranges are supported", operator, name)
+	}
+	return nil
+}
+
+func (q *visibilityQuery) parseOrderBy(orderBy sqlparser.OrderBy) error {
+	if len(orderBy) == 0 {
+		return nil
+	}
+	if len(orderBy) > 1 {
+		return errors.New("order by multiple fields is not supported by NoSQL visibility")
+	}
+	colName, ok := orderBy[0].Expr.(*sqlparser.ColName)
+	if !ok {
+		return errors.New("invalid order by expression")
+	}
+	name := colName.Name.String()
+	if (name != definition.StartTime && name != definition.CloseTime) || orderBy[0].Direction != sqlparser.DescScr {
+		return errors.New("NoSQL visibility only supports ORDER BY StartTime DESC or ORDER BY CloseTime DESC")
+	}
+	orderByCloseTime := name == definition.CloseTime
+	q.orderByCloseTime = &orderByCloseTime
+	return nil
+}
+
+func (q *visibilityQuery) setOpen(open bool) error {
+	if q.open != nil && *q.open != open {
+		return errors.New("CloseTime = missing cannot be combined with predicates on closed workflows")
+	}
+	q.open = &open
+	return nil
+}
+
+// validate checks the combination of predicates can be served by one of the visibility filters
+func (q *visibilityQuery) validate() error {
+	filters := 0
+	for _, set := range []bool{q.workflowType != nil, q.workflowID != nil, q.closeStatus != nil, q.searchAttributeKey != ""} {
+		if set {
+			filters++
+		}
+	}
+	if filters > 1 {
+		return errVisibilityQueryMultipleFilters
+	}
+	if q.startTime != nil && q.closeTime != nil {
+		return errors.New("StartTime and CloseTime ranges cannot be combined in a query")
+	}
+
+	if q.orderByCloseTime != nil {
+		if *q.orderByCloseTime {
+			if q.open == nil || *q.open {
+				return errors.New("ORDER BY CloseTime is only supported on closed workflows, add CloseTime != missing or CloseStatus to the query")
+			}
+			if q.startTime != nil {
+				return errors.New("ORDER BY CloseTime cannot be combined with a StartTime range")
+			}
+		} else if q.closeTime != nil {
+			return errors.New("ORDER BY StartTime cannot be combined with a CloseTime range")
+		}
+	}
+
+	if q.searchAttributeKey != "" && (q.closeTime != nil || (q.orderByCloseTime != nil && *q.orderByCloseTime)) {
+		return errors.New("search attributes can only be combined with a StartTime range")
+	}
+	return nil
+}
+
+// parseVisibilityQueryLiteral returns the text of a literal value, and whether the literal is a string
+func parseVisibilityQueryLiteral(expr sqlparser.Expr) (string, bool, error) {
+	switch val := expr.(type) {
+	case *sqlparser.SQLVal:
+		switch val.Type {
+		case sqlparser.StrVal:
+			return string(val.Val), true, nil
+		case sqlparser.IntVal, sqlparser.FloatVal:
+			return string(val.Val), false, nil
+		}
+	case sqlparser.BoolVal:
+		return strconv.FormatBool(bool(val)), false, nil
+	}
+	return "", false, fmt.Errorf("invalid value %q", sqlparser.String(expr))
+}
+
+// parseVisibilityQueryTime accepts unix nanoseconds or RFC3339 time
+func parseVisibilityQueryTime(value string, isString bool) (time.Time, error) {
+	if isString {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, nil
+		}
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expect unix nanoseconds or RFC3339 time but got %v", value)
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// parseVisibilityQueryCloseStatus accepts either the value or the name of a close status
+func parseVisibilityQueryCloseStatus(value string, isString bool) (int32, error) {
+	if !isString {
+		status, err := strconv.ParseInt(value, 10, 32)
+		return int32(status), err
+	}
+	var status types.WorkflowExecutionCloseStatus
+	if err := status.UnmarshalText([]byte(value)); err != nil {
+		return 0, err
+	}
+	return int32(status), nil
+}
+
+// encodeIndexedSearchAttributeValues returns the values of a search attribute as indexed by NoSQL visibility:
+// strings as they are, and numbers and bools as JSON literals, so that they are equal to the text of the literals in
+// visibility queries. Elements of arrays are indexed separately, nulls and objects are not indexed.
+func encodeIndexedSearchAttributeValues(data []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	var result []string
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			result = append(result, value)
+		case json.Number:
+			result = append(result, value.String())
+		case bool:
+			result = append(result, strconv.FormatBool(value))
+		}
+	}
+	return result, nil
+}
+
+func indexSearchAttributes(
+	searchAttributes map[string][]byte,
+	indexedSearchAttributes map[string]struct{},
+) (map[string][]string, error) {
+	var result map[string][]string
+	for key, data := range searchAttributes {
+		if _, ok := indexedSearchAttributes[key]; !ok {
+			continue
+		}
+		values, err := encodeIndexedSearchAttributeValues(data)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: fmt.Sprintf("invalid value of search attribute %s: %v", key, err)}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if result == nil {
+			result = make(map[string][]string)
+		}
+		result[key] = values
+	}
+	return result, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,185 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nosql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestParseVisibilityQuery(t *testing.T) {
+	indexed := map[string]struct{}{"CustomKeywordField": {}, "CustomIntField": {}}
+	startTime := time.Unix(0, 1547596872371000000)
+
+	tests := map[string]struct {
+		query           string
+		sortByCloseTime bool
+		open            *nosqlplugin.VisibilityFilter
+		closed          *nosqlplugin.VisibilityFilter
+		err             string
+	}{
+		"empty query": {
+			query:  "",
+			open:   &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.AllOpen, SortType: nosqlplugin.SortByStartTime},
+			closed: &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.AllClosed, SortType: nosqlplugin.SortByStartTime},
+		},
+		"empty query sorted by close time": {
+			query:           "",
+			sortByCloseTime: true,
+			open:            &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.AllOpen, SortType: nosqlplugin.SortByStartTime},
+			closed:          &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.AllClosed, SortType: nosqlplugin.SortByClosedTime},
+		},
+		"open by workflow type": {
+			query: "WorkflowType = 'wt' and CloseTime = missing",
+			open:  &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.OpenByWorkflowType, SortType: nosqlplugin.SortByStartTime, WorkflowType: "wt"},
+		},
+		"by workflow id": {
+			query:  "WorkflowID = 'wid'",
+			open:   &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.OpenByWorkflowID, SortType: nosqlplugin.SortByStartTime, WorkflowID: "wid"},
+			closed: &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.ClosedByWorkflowID, SortType: nosqlplugin.SortByStartTime, WorkflowID: "wid"},
+		},
+		"by close status name": {
+			query:  "CloseStatus = 'TIMED_OUT'",
+			closed: &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.ClosedByClosedStatus, SortType: nosqlplugin.SortByStartTime, CloseStatus: int32(types.WorkflowExecutionCloseStatusTimedOut)},
+		},
+		"by close status value with close time range": {
+			query:  "CloseStatus = 1 and CloseTime between 1000000 and 5000000 order by CloseTime desc",
+			closed: &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.ClosedByClosedStatus, SortType: nosqlplugin.SortByClosedTime, CloseStatus: 1},
+		},
+		"by indexed search attribute": {
+			query:  "(`Attr.CustomIntField` = 10) and StartTime > '2019-01-16T00:01:12.371Z'",
+			open:   &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.OpenBySearchAttribute, SortType: nosqlplugin.SortByStartTime, SearchAttributeKey: "CustomIntField", SearchAttributeValue: "10"},
+			closed: &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.ClosedBySearchAttribute, SortType: nosqlplugin.SortByStartTime, SearchAttributeKey: "CustomIntField", SearchAttributeValue: "10"},
+		},
+		"closed by indexed search attribute ignores sort by close time": {
+			query:           "CustomKeywordField = 'keyword' and CloseTime != missing",
+			sortByCloseTime: true,
+			closed:          &nosqlplugin.VisibilityFilter{FilterType: nosqlplugin.ClosedBySearchAttribute, SortType: nosqlplugin.SortByStartTime, SearchAttributeKey: "CustomKeywordField", SearchAttributeValue: "keyword"},
+		},
+		"or": {
+			query: "WorkflowType = 'wt' or WorkflowID = 'wid'",
+			err:   "OR is not supported by NoSQL visibility, predicates can only be combined by AND",
+		},
+		"multiple filters": {
+			query: "WorkflowType = 'wt' and WorkflowID = 'wid'",
+			err:   "only one of WorkflowType, WorkflowID, CloseStatus and indexed search attributes can be used in a query",
+		},
+		"not equal": {
+			query: "WorkflowType != 'wt'",
+			err:   `operator "!=" is not supported on WorkflowType by NoSQL visibility, only "=" is supported`,
+		},
+		"not indexed search attribute": {
+			query: "CustomStringField = 'value'",
+			err:   "search attribute CustomStringField is not indexed by NoSQL visibility",
+		},
+		"unsupported system attribute": {
+			query: "TaskList = 'tl'",
+			err:   "TaskList is not supported by NoSQL visibility, supported are WorkflowType, WorkflowID, CloseStatus, StartTime, CloseTime and indexed search attributes",
+		},
+		"open and closed": {
+			query: "CloseTime = missing and CloseStatus = 'COMPLETED'",
+			err:   "CloseTime = missing cannot be combined with predicates on closed workflows",
+		},
+		"start and close time ranges": {
+			query: "StartTime > 1 and CloseTime < 2",
+			err:   "StartTime and CloseTime ranges cannot be combined in a query",
+		},
+		"search attribute and close time range": {
+			query: "CustomKeywordField = 'keyword' and CloseTime < 2",
+			err:   "search attributes can only be combined with a StartTime range",
+		},
+		"order by close time for open workflows": {
+			query: "order by CloseTime desc",
+			err:   "ORDER BY CloseTime is only supported on closed workflows, add CloseTime != missing or CloseStatus to the query",
+		},
+		"order by ascending": {
+			query: "order by StartTime asc",
+			err:   "NoSQL visibility only supports ORDER BY StartTime DESC or ORDER BY CloseTime DESC",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := parseVisibilityQuery(test.query, indexed)
+			if test.err != "" {
+				assert.Equal(t, &types.BadRequestError{Message: test.err}, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, closed := range []bool{false, true} {
+				expected := test.open
+				if closed {
+					expected = test.closed
+				}
+				matched := query.open == nil || *query.open != closed
+				if !assert.Equal(t, expected != nil, matched, "closed: %v", closed) || expected == nil {
+					continue
+				}
+				filter := query.filter(closed, test.sortByCloseTime)
+				filter.ListRequest = expected.ListRequest
+				assert.Equal(t, expected, filter, "closed: %v", closed)
+			}
+		})
+	}
+
+	t.Run("time range", func(t *testing.T) {
+		query, err := parseVisibilityQuery("StartTime > '2019-01-16T00:01:12.371Z' and StartTime <= 1547596872371500000", indexed)
+		require.NoError(t, err)
+		filter := query.filter(false, false)
+		assert.True(t, startTime.Add(time.Millisecond).Equal(filter.ListRequest.EarliestTime))
+		assert.True(t, startTime.Equal(filter.ListRequest.LatestTime))
+
+		query, err = parseVisibilityQuery("CloseTime >= 1500000 and CloseTime < 3000000", indexed)
+		require.NoError(t, err)
+		filter = query.filter(true, false)
+		assert.Equal(t, nosqlplugin.SortByClosedTime, filter.SortType)
+		assert.True(t, time.Unix(0, 2000000).Equal(filter.ListRequest.EarliestTime))
+		assert.True(t, time.Unix(0, 2000000).Equal(filter.ListRequest.LatestTime))
+	})
+}
+
+func TestIndexSearchAttributes(t *testing.T) {
+	indexed := map[string]struct{}{"CustomKeywordField": {}, "CustomIntField": {}, "CustomBoolField": {}}
+
+	searchAttributes, err := indexSearchAttributes(map[string][]byte{
+		"CustomKeywordField": []byte(`["keyword1", "keyword2"]`),
+		"CustomIntField":     []byte(`10`),
+		"CustomBoolField":    []byte(`null`),
+		"CustomStringField":  []byte(`"not indexed"`),
+	}, indexed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"CustomKeywordField": {"keyword1", "keyword2"},
+		"CustomIntField":     {"10"},
+	}, searchAttributes)
+
+	_, err = indexSearchAttributes(map[string][]byte{"CustomIntField": []byte(`{`)}, indexed)
+	assert.IsType(t, &types.InternalServiceError{}, err)
+}
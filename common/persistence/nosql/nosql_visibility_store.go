@@ -22,10 +22,13 @@ package nosql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
@@ -36,12 +39,26 @@ import (
 const (
 	defaultCloseTTLSeconds = 86400
 	openExecutionTTLBuffer = int64(86400) // setting it to a day to account for shard going down
+
+	countWorkflowExecutionsPageSize = 1000
+	defaultMaxCountScanSize         = 10000
 )
 
-type nosqlVisibilityStore struct {
-	sortByCloseTime bool
-	nosqlStore
-}
+type (
+	nosqlVisibilityStore struct {
+		sortByCloseTime         bool
+		indexedSearchAttributes dynamicconfig.ListPropertyFn
+		maxCountScanSize        dynamicconfig.IntPropertyFn
+		nosqlStore
+	}
+
+	// visibilityQueryPageToken is the page token of visibility queries,
+	// open workflows are listed before closed workflows if a query matches both
+	visibilityQueryPageToken struct {
+		Closed    bool
+		PageState []byte
+	}
+)
 
 // newNoSQLVisibilityStore is used to create an instance of VisibilityStore implementation
 func newNoSQLVisibilityStore(
@@ -54,9 +71,17 @@ func newNoSQLVisibilityStore(
 	if err != nil {
 		return nil, err
 	}
+	var indexedSearchAttributes dynamicconfig.ListPropertyFn
+	maxCountScanSize := dynamicconfig.GetIntPropertyFn(defaultMaxCountScanSize)
+	if dc != nil {
+		indexedSearchAttributes = dc.CassandraVisibilityIndexedSearchAttributes
+		maxCountScanSize = dc.CassandraVisibilityMaxCountScanSize
+	}
 	return &nosqlVisibilityStore{
-		sortByCloseTime: listClosedOrderingByCloseTime,
-		nosqlStore:      shardedStore.GetDefaultShard(),
+		sortByCloseTime:         listClosedOrderingByCloseTime,
+		indexedSearchAttributes: indexedSearchAttributes,
+		maxCountScanSize:        maxCountScanSize,
+		nosqlStore:              shardedStore.GetDefaultShard(),
 	}, nil
 }
 
@@ -65,9 +90,14 @@ func (v *nosqlVisibilityStore) RecordWorkflowExecutionStarted(
 	request *persistence.InternalRecordWorkflowExecutionStartedRequest,
 ) error {
 	ttl := int64(request.WorkflowTimeout.Seconds()) + openExecutionTTLBuffer
+	searchAttributes, err := indexSearchAttributes(request.SearchAttributes, v.getIndexedSearchAttributes())
+	if err != nil {
+		return err
+	}
 
-	err := v.db.InsertVisibility(ctx, ttl, &nosqlplugin.VisibilityRowForInsert{
-		DomainID: request.DomainUUID,
+	err = v.db.InsertVisibility(ctx, ttl, &nosqlplugin.VisibilityRowForInsert{
+		DomainID:                request.DomainUUID,
+		IndexedSearchAttributes: searchAttributes,
 		VisibilityRow: nosqlplugin.VisibilityRow{
 			WorkflowID:    request.WorkflowID,
 			RunID:         request.RunID,
@@ -99,9 +129,15 @@ func (v *nosqlVisibilityStore) RecordWorkflowExecutionClosed(
 		retention = defaultCloseTTLSeconds * time.Second
 	}
 
-	err := v.db.UpdateVisibility(ctx, int64(retention.Seconds()), &nosqlplugin.VisibilityRowForUpdate{
-		DomainID:          request.DomainUUID,
-		UpdateOpenToClose: true,
+	searchAttributes, err := indexSearchAttributes(request.SearchAttributes, v.getIndexedSearchAttributes())
+	if err != nil {
+		return err
+	}
+
+	err = v.db.UpdateVisibility(ctx, int64(retention.Seconds()), &nosqlplugin.VisibilityRowForUpdate{
+		DomainID:                request.DomainUUID,
+		UpdateOpenToClose:       true,
+		IndexedSearchAttributes: searchAttributes,
 		VisibilityRow: nosqlplugin.VisibilityRow{
 			WorkflowID:    request.WorkflowID,
 			RunID:         request.RunID,
@@ -138,10 +174,45 @@ func (v *nosqlVisibilityStore) UpsertWorkflowExecution(
 	ctx context.Context,
 	request *persistence.InternalUpsertWorkflowExecutionRequest,
 ) error {
-	if persistence.IsNopUpsertWorkflowRequest(request) {
-		return nil
+	searchAttributes, err := indexSearchAttributes(request.SearchAttributes, v.getIndexedSearchAttributes())
+	if err != nil {
+		return err
+	}
+	if len(searchAttributes) == 0 {
+		if persistence.IsNopUpsertWorkflowRequest(request) {
+			return nil
+		}
+		return persistence.ErrVisibilityOperationNotSupported
 	}
-	return persistence.ErrVisibilityOperationNotSupported
+
+	ttl := int64(request.WorkflowTimeout.Seconds()) + openExecutionTTLBuffer
+	err = v.db.UpsertVisibility(ctx, ttl, &nosqlplugin.VisibilityRowForUpsert{
+		DomainID:                request.DomainUUID,
+		IndexedSearchAttributes: searchAttributes,
+		VisibilityRow: nosqlplugin.VisibilityRow{
+			WorkflowID:    request.WorkflowID,
+			RunID:         request.RunID,
+			TypeName:      request.WorkflowTypeName,
+			StartTime:     request.StartTimestamp,
+			ExecutionTime: request.ExecutionTimestamp,
+			Memo:          request.Memo,
+			TaskList:      request.TaskList,
+			IsCron:        request.IsCron,
+			NumClusters:   request.NumClusters,
+			UpdateTime:    request.UpdateTimestamp,
+			ShardID:       int16(request.ShardID),
+		},
+	})
+	if err == persistence.ErrVisibilityOperationNotSupported {
+		if persistence.IsNopUpsertWorkflowRequest(request) {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return convertCommonErrors(v.db, "UpsertWorkflowExecution", err)
+	}
+	return nil
 }
 
 func (v *nosqlVisibilityStore) ListOpenWorkflowExecutions(
@@ -366,21 +437,128 @@ func (v *nosqlVisibilityStore) DeleteUninitializedWorkflowExecution(
 }
 
 func (v *nosqlVisibilityStore) ListWorkflowExecutions(
-	_ context.Context,
-	_ *persistence.ListWorkflowExecutionsByQueryRequest,
+	ctx context.Context,
+	request *persistence.ListWorkflowExecutionsByQueryRequest,
 ) (*persistence.InternalListWorkflowExecutionsResponse, error) {
-	return nil, persistence.ErrVisibilityOperationNotSupported
+	return v.listWorkflowExecutionsByQuery(ctx, "ListWorkflowExecutions", request)
 }
 
+// ScanWorkflowExecutions is the same as ListWorkflowExecutions, as NoSQL visibility doesn't guarantee
+// the order of workflows matched by a query anyway
 func (v *nosqlVisibilityStore) ScanWorkflowExecutions(
-	_ context.Context,
-	_ *persistence.ListWorkflowExecutionsByQueryRequest) (*persistence.InternalListWorkflowExecutionsResponse, error) {
-	return nil, persistence.ErrVisibilityOperationNotSupported
+	ctx context.Context,
+	request *persistence.ListWorkflowExecutionsByQueryRequest,
+) (*persistence.InternalListWorkflowExecutionsResponse, error) {
+	return v.listWorkflowExecutionsByQuery(ctx, "ScanWorkflowExecutions", request)
 }
 
+// CountWorkflowExecutions counts the workflows by reading them, so the count of a query matching more workflows
+// than the max scan size fails instead of reading all of them
 func (v *nosqlVisibilityStore) CountWorkflowExecutions(
-	_ context.Context,
-	_ *persistence.CountWorkflowExecutionsRequest,
+	ctx context.Context,
+	request *persistence.CountWorkflowExecutionsRequest,
 ) (*persistence.CountWorkflowExecutionsResponse, error) {
-	return nil, persistence.ErrVisibilityOperationNotSupported
+	if request.GroupBy != "" {
+		return nil, persistence.ErrVisibilityOperationNotSupported
+	}
+	query, err := parseVisibilityQuery(request.Query, v.getIndexedSearchAttributes())
+	if err != nil {
+		return nil, err
+	}
+
+	maxScanSize := v.maxCountScanSize()
+	var count int
+	token := &visibilityQueryPageToken{}
+	for token != nil && count <= maxScanSize {
+		// read one more workflow than the max to know whether the query matches more
+		pageSize := common.MinInt(countWorkflowExecutionsPageSize, maxScanSize-count+1)
+		var executions []*persistence.InternalVisibilityWorkflowExecutionInfo
+		executions, token, err = v.selectVisibilityByQuery(ctx, request.DomainUUID, request.Domain, pageSize, query, token)
+		if err != nil {
+			return nil, convertCommonErrors(v.db, "CountWorkflowExecutions", err)
+		}
+		count += len(executions)
+	}
+	if count > maxScanSize {
+		return nil, &types.BadRequestError{Message: fmt.Sprintf(
+			"query matches more than %d workflows, which is the most Cassandra visibility can count, narrow it down with a StartTime range or more predicates",
+			maxScanSize,
+		)}
+	}
+	return &persistence.CountWorkflowExecutionsResponse{Count: int64(count)}, nil
+}
+
+func (v *nosqlVisibilityStore) listWorkflowExecutionsByQuery(
+	ctx context.Context,
+	operation string,
+	request *persistence.ListWorkflowExecutionsByQueryRequest,
+) (*persistence.InternalListWorkflowExecutionsResponse, error) {
+	query, err := parseVisibilityQuery(request.Query, v.getIndexedSearchAttributes())
+	if err != nil {
+		return nil, err
+	}
+	token := &visibilityQueryPageToken{}
+	if len(request.NextPageToken) > 0 {
+		if err := json.Unmarshal(request.NextPageToken, token); err != nil {
+			return nil, &types.BadRequestError{Message: fmt.Sprintf("invalid next page token: %v", err)}
+		}
+	}
+
+	executions, nextPageToken, err := v.selectVisibilityByQuery(ctx, request.DomainUUID, request.Domain, request.PageSize, query, token)
+	if err != nil {
+		return nil, convertCommonErrors(v.db, operation, err)
+	}
+	response := &persistence.InternalListWorkflowExecutionsResponse{Executions: executions}
+	if nextPageToken != nil {
+		if response.NextPageToken, err = json.Marshal(nextPageToken); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// selectVisibilityByQuery returns one page of workflows matched by the query, and the token of the next page
+// which is nil if there are no more pages
+func (v *nosqlVisibilityStore) selectVisibilityByQuery(
+	ctx context.Context,
+	domainID string,
+	domain string,
+	pageSize int,
+	query *visibilityQuery,
+	token *visibilityQueryPageToken,
+) ([]*persistence.InternalVisibilityWorkflowExecutionInfo, *visibilityQueryPageToken, error) {
+	closed := token.Closed || (query.open != nil && !*query.open)
+	filter := query.filter(closed, v.sortByCloseTime)
+	filter.ListRequest.DomainUUID = domainID
+	filter.ListRequest.Domain = domain
+	filter.ListRequest.PageSize = pageSize
+	filter.ListRequest.NextPageToken = token.PageState
+
+	resp, err := v.db.SelectVisibility(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case len(resp.NextPageToken) > 0:
+		return resp.Executions, &visibilityQueryPageToken{Closed: closed, PageState: resp.NextPageToken}, nil
+	case !closed && query.open == nil:
+		// continue with closed workflows
+		return resp.Executions, &visibilityQueryPageToken{Closed: true}, nil
+	default:
+		return resp.Executions, nil, nil
+	}
+}
+
+func (v *nosqlVisibilityStore) getIndexedSearchAttributes() map[string]struct{} {
+	if v.indexedSearchAttributes == nil {
+		return nil
+	}
+	result := make(map[string]struct{})
+	for _, key := range v.indexedSearchAttributes() {
+		if key, ok := key.(string); ok {
+			result[key] = struct{}{}
+		}
+	}
+	return result
 }
@@ -0,0 +1,230 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nosql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+)
+
+func newTestNosqlVisibilityStore(db nosqlplugin.DB, indexedSearchAttributes ...interface{}) *nosqlVisibilityStore {
+	return &nosqlVisibilityStore{
+		indexedSearchAttributes: func(...dynamicconfig.FilterOption) []interface{} {
+			return indexedSearchAttributes
+		},
+		maxCountScanSize: dynamicconfig.GetIntPropertyFn(defaultMaxCountScanSize),
+		nosqlStore:       nosqlStore{logger: log.NewNoop(), db: db},
+	}
+}
+
+func TestNosqlVisibilityStore_ListWorkflowExecutions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbMock := nosqlplugin.NewMockDB(ctrl)
+	store := newTestNosqlVisibilityStore(dbMock, definition.CustomKeywordField)
+
+	gomock.InOrder(
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				assert.Equal(t, nosqlplugin.OpenBySearchAttribute, filter.FilterType)
+				assert.Equal(t, "domain-id", filter.ListRequest.DomainUUID)
+				assert.Equal(t, 10, filter.ListRequest.PageSize)
+				assert.Empty(t, filter.ListRequest.NextPageToken)
+				return &nosqlplugin.SelectVisibilityResponse{
+					Executions:    []*nosqlplugin.VisibilityRow{{RunID: "rid-1"}},
+					NextPageToken: []byte("open-page"),
+				}, nil
+			}),
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				assert.Equal(t, nosqlplugin.OpenBySearchAttribute, filter.FilterType)
+				assert.Equal(t, []byte("open-page"), filter.ListRequest.NextPageToken)
+				return &nosqlplugin.SelectVisibilityResponse{Executions: []*nosqlplugin.VisibilityRow{{RunID: "rid-2"}}}, nil
+			}),
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				assert.Equal(t, nosqlplugin.ClosedBySearchAttribute, filter.FilterType)
+				assert.Empty(t, filter.ListRequest.NextPageToken)
+				return &nosqlplugin.SelectVisibilityResponse{Executions: []*nosqlplugin.VisibilityRow{{RunID: "rid-3"}}}, nil
+			}),
+	)
+
+	request := &persistence.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID: "domain-id",
+		PageSize:   10,
+		Query:      "`Attr.CustomKeywordField` = 'keyword'",
+	}
+	var runIDs []string
+	for {
+		resp, err := store.ListWorkflowExecutions(context.Background(), request)
+		require.NoError(t, err)
+		for _, execution := range resp.Executions {
+			runIDs = append(runIDs, execution.RunID)
+		}
+		if len(resp.NextPageToken) == 0 {
+			break
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+	assert.Equal(t, []string{"rid-1", "rid-2", "rid-3"}, runIDs)
+}
+
+func TestNosqlVisibilityStore_ListWorkflowExecutions_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := newTestNosqlVisibilityStore(nosqlplugin.NewMockDB(ctrl))
+
+	_, err := store.ListWorkflowExecutions(context.Background(), &persistence.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID: "domain-id",
+		PageSize:   10,
+		Query:      "CustomKeywordField = 'keyword'",
+	})
+	assert.Equal(t, &types.BadRequestError{Message: "search attribute CustomKeywordField is not indexed by NoSQL visibility"}, err)
+}
+
+func TestNosqlVisibilityStore_CountWorkflowExecutions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbMock := nosqlplugin.NewMockDB(ctrl)
+	store := newTestNosqlVisibilityStore(dbMock)
+
+	gomock.InOrder(
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				assert.Equal(t, nosqlplugin.ClosedByClosedStatus, filter.FilterType)
+				assert.Equal(t, countWorkflowExecutionsPageSize, filter.ListRequest.PageSize)
+				return &nosqlplugin.SelectVisibilityResponse{
+					Executions:    make([]*nosqlplugin.VisibilityRow, 2),
+					NextPageToken: []byte("page"),
+				}, nil
+			}),
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).Return(&nosqlplugin.SelectVisibilityResponse{
+			Executions: make([]*nosqlplugin.VisibilityRow, 1),
+		}, nil),
+	)
+
+	resp, err := store.CountWorkflowExecutions(context.Background(), &persistence.CountWorkflowExecutionsRequest{
+		DomainUUID: "domain-id",
+		Query:      "CloseStatus = 'FAILED'",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Count)
+}
+
+func TestNosqlVisibilityStore_CountWorkflowExecutions_MaxScanSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbMock := nosqlplugin.NewMockDB(ctrl)
+	store := newTestNosqlVisibilityStore(dbMock)
+	store.maxCountScanSize = dynamicconfig.GetIntPropertyFn(3)
+
+	gomock.InOrder(
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				// one more workflow than the max is read to know whether the query matches more
+				assert.Equal(t, 4, filter.ListRequest.PageSize)
+				return &nosqlplugin.SelectVisibilityResponse{
+					Executions:    make([]*nosqlplugin.VisibilityRow, 2),
+					NextPageToken: []byte("page"),
+				}, nil
+			}),
+		dbMock.EXPECT().SelectVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *nosqlplugin.VisibilityFilter) (*nosqlplugin.SelectVisibilityResponse, error) {
+				assert.Equal(t, 2, filter.ListRequest.PageSize)
+				return &nosqlplugin.SelectVisibilityResponse{
+					Executions:    make([]*nosqlplugin.VisibilityRow, 2),
+					NextPageToken: []byte("page"),
+				}, nil
+			}),
+	)
+
+	_, err := store.CountWorkflowExecutions(context.Background(), &persistence.CountWorkflowExecutionsRequest{
+		DomainUUID: "domain-id",
+		Query:      "CloseStatus = 'FAILED'",
+	})
+	var badRequestErr *types.BadRequestError
+	require.ErrorAs(t, err, &badRequestErr)
+	assert.Contains(t, badRequestErr.Message, "query matches more than 3 workflows")
+}
+
+func TestNosqlVisibilityStore_UpsertWorkflowExecution(t *testing.T) {
+	tests := map[string]struct {
+		searchAttributes map[string][]byte
+		upsert           bool
+		upsertErr        error
+		err              error
+	}{
+		"indexed search attributes are upserted": {
+			searchAttributes: map[string][]byte{
+				definition.CustomKeywordField: []byte(`"keyword"`),
+				definition.CustomStringField:  []byte(`"string"`),
+			},
+			upsert: true,
+		},
+		"not indexed": {
+			searchAttributes: map[string][]byte{definition.CustomStringField: []byte(`"string"`)},
+			err:              persistence.ErrVisibilityOperationNotSupported,
+		},
+		"workflow versioning upsert is ignored": {
+			searchAttributes: map[string][]byte{definition.CadenceChangeVersion: []byte(`["v1"]`)},
+		},
+		"not supported by database": {
+			searchAttributes: map[string][]byte{definition.CustomKeywordField: []byte(`"keyword"`)},
+			upsert:           true,
+			upsertErr:        persistence.ErrVisibilityOperationNotSupported,
+			err:              persistence.ErrVisibilityOperationNotSupported,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dbMock := nosqlplugin.NewMockDB(ctrl)
+			store := newTestNosqlVisibilityStore(dbMock, definition.CustomKeywordField)
+
+			if test.upsert {
+				dbMock.EXPECT().UpsertVisibility(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ int64, row *nosqlplugin.VisibilityRowForUpsert) error {
+						assert.Equal(t, "domain-id", row.DomainID)
+						assert.Equal(t, "rid", row.RunID)
+						assert.Equal(t, map[string][]string{definition.CustomKeywordField: {"keyword"}}, row.IndexedSearchAttributes)
+						return test.upsertErr
+					})
+			}
+
+			err := store.UpsertWorkflowExecution(context.Background(), &persistence.InternalUpsertWorkflowExecutionRequest{
+				DomainUUID:       "domain-id",
+				RunID:            "rid",
+				SearchAttributes: test.searchAttributes,
+			})
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
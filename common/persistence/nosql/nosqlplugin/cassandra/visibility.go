@@ -30,6 +30,7 @@ import (
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin/cassandra/gocql"
+	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/common/types/mapper/thrift"
 )
 
@@ -38,8 +39,12 @@ const (
 )
 
 // InsertVisibility creates a new visibility record, return error is there is any.
-// TODO: Cassandra implementation ignores search attributes
+// TODO: Cassandra implementation ignores search attributes which are not indexed
 func (db *cdb) InsertVisibility(ctx context.Context, ttlSeconds int64, row *nosqlplugin.VisibilityRowForInsert) error {
+	if len(row.IndexedSearchAttributes) > 0 {
+		return db.insertVisibilityWithIndexedSearchAttributes(ctx, ttlSeconds, row)
+	}
+
 	var query gocql.Query
 	if ttlSeconds > maxCassandraTTL {
 		query = db.session.Query(templateCreateWorkflowExecutionStarted,
@@ -81,6 +86,87 @@ func (db *cdb) InsertVisibility(ctx context.Context, ttlSeconds int64, row *nosq
 	return query.Exec()
 }
 
+func (db *cdb) insertVisibilityWithIndexedSearchAttributes(ctx context.Context, ttlSeconds int64, row *nosqlplugin.VisibilityRowForInsert) error {
+	batch := db.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(templateCreateWorkflowExecutionStartedWithIndexedSearchAttributes,
+		row.DomainID,
+		domainPartition,
+		row.WorkflowID,
+		row.RunID,
+		persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()),
+		persistence.UnixNanoToDBTimestamp(row.ExecutionTime.UnixNano()),
+		row.TypeName,
+		row.Memo.Data,
+		row.Memo.GetEncoding(),
+		row.TaskList,
+		row.IsCron,
+		row.NumClusters,
+		row.UpdateTime,
+		row.ShardID,
+		row.IndexedSearchAttributes,
+		visibilityTTL(ttlSeconds),
+	)
+	insertOpenWorkflowExecutionBySearchAttributes(batch, ttlSeconds, row.DomainID, &row.VisibilityRow, row.IndexedSearchAttributes)
+	batch = batch.WithTimestamp(persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()))
+	return db.session.ExecuteBatch(batch)
+}
+
+// UpsertVisibility updates the indexed search attributes of an open workflow, and the records of the workflow in
+// open_executions_by_search_attribute table. Since the records are keyed by the value of the search attributes,
+// the previous values are read from open_executions table to delete the stale records.
+func (db *cdb) UpsertVisibility(ctx context.Context, ttlSeconds int64, row *nosqlplugin.VisibilityRowForUpsert) error {
+	startTime := persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano())
+	var previous map[string][]string
+	query := db.session.Query(templateGetOpenWorkflowExecutionIndexedSearchAttributes,
+		row.DomainID,
+		domainPartition,
+		startTime,
+		row.RunID,
+	).WithContext(ctx)
+	if err := query.Scan(&previous); err != nil {
+		if db.client.IsNotFoundError(err) {
+			// the workflow is already closed, or the record has expired
+			return nil
+		}
+		return err
+	}
+
+	batch := db.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
+	for key, values := range previous {
+		for _, value := range values {
+			if containsString(row.IndexedSearchAttributes[key], value) {
+				continue
+			}
+			batch.Query(templateDeleteOpenWorkflowExecutionBySearchAttribute,
+				row.DomainID,
+				domainPartition,
+				key,
+				value,
+				startTime,
+				row.RunID,
+			)
+		}
+	}
+	batch.Query(templateUpdateOpenWorkflowExecutionIndexedSearchAttributes,
+		visibilityTTL(ttlSeconds),
+		row.IndexedSearchAttributes,
+		row.DomainID,
+		domainPartition,
+		startTime,
+		row.RunID,
+	)
+	insertOpenWorkflowExecutionBySearchAttributes(batch, ttlSeconds, row.DomainID, &row.VisibilityRow, row.IndexedSearchAttributes)
+
+	// the update must not win over the deletion of the open record when the workflow is closed,
+	// which uses the close time as timestamp
+	timestamp := row.UpdateTime
+	if timestamp.Before(row.StartTime) {
+		timestamp = row.StartTime
+	}
+	batch = batch.WithTimestamp(persistence.UnixNanoToDBTimestamp(timestamp.UnixNano()))
+	return db.session.ExecuteBatch(batch)
+}
+
 func (db *cdb) UpdateVisibility(ctx context.Context, ttlSeconds int64, row *nosqlplugin.VisibilityRowForUpdate) error {
 	batch := db.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
 
@@ -97,6 +183,20 @@ func (db *cdb) UpdateVisibility(ctx context.Context, ttlSeconds int64, row *nosq
 			persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()),
 			row.RunID,
 		)
+		// search attributes of the closed workflow are the latest upserted ones,
+		// so they are the values of the records in the open table
+		for key, values := range row.IndexedSearchAttributes {
+			for _, value := range values {
+				batch.Query(templateDeleteOpenWorkflowExecutionBySearchAttribute,
+					row.DomainID,
+					domainPartition,
+					key,
+					value,
+					persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()),
+					row.RunID,
+				)
+			}
+		}
 	}
 
 	// Next, add a row in the closed table.
@@ -184,6 +284,33 @@ func (db *cdb) UpdateVisibility(ctx context.Context, ttlSeconds int64, row *nosq
 		)
 	}
 
+	for key, values := range row.IndexedSearchAttributes {
+		for _, value := range values {
+			batch.Query(templateCreateClosedWorkflowExecutionBySearchAttribute,
+				row.DomainID,
+				domainPartition,
+				key,
+				value,
+				row.WorkflowID,
+				row.RunID,
+				persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()),
+				persistence.UnixNanoToDBTimestamp(row.ExecutionTime.UnixNano()),
+				persistence.UnixNanoToDBTimestamp(row.CloseTime.UnixNano()),
+				row.TypeName,
+				row.Status,
+				row.HistoryLength,
+				row.Memo.Data,
+				row.Memo.GetEncoding(),
+				row.TaskList,
+				row.IsCron,
+				row.NumClusters,
+				row.UpdateTime,
+				row.ShardID,
+				visibilityTTL(ttlSeconds),
+			)
+		}
+	}
+
 	// RecordWorkflowExecutionStarted is using StartTimestamp as
 	// the timestamp to issue query to Cassandra
 	// due to the fact that cross DC using mutable state creation time as workflow start time
@@ -299,6 +426,17 @@ func (db *cdb) SelectVisibility(ctx context.Context, filter *nosqlplugin.Visibil
 			panic("not supported sorting type")
 		}
 
+	// by indexed search attribute
+	case nosqlplugin.OpenBySearchAttribute:
+		return db.openFilteredBySearchAttributeSortedByStartTime(ctx, &filter.ListRequest, filter.SearchAttributeKey, filter.SearchAttributeValue)
+	case nosqlplugin.ClosedBySearchAttribute:
+		switch filter.SortType {
+		case nosqlplugin.SortByStartTime:
+			return db.closedFilteredBySearchAttributeSortedByStartTime(ctx, &filter.ListRequest, filter.SearchAttributeKey, filter.SearchAttributeValue)
+		default:
+			return nil, &types.InternalServiceError{Message: "closed workflows filtered by search attribute can only be sorted by start time"}
+		}
+
 	// closeStatus
 	case nosqlplugin.ClosedByClosedStatus:
 		switch filter.SortType {
@@ -462,6 +600,40 @@ func (db *cdb) closedFilteredByClosedStatusSortedByClosedTime(
 	return processQuery(query, request, readClosedWorkflowExecutionRecord)
 }
 
+func (db *cdb) openFilteredBySearchAttributeSortedByStartTime(
+	ctx context.Context,
+	request *persistence.InternalListWorkflowExecutionsRequest,
+	key string,
+	value string,
+) (*nosqlplugin.SelectVisibilityResponse, error) {
+	query := db.session.Query(templateGetOpenWorkflowExecutionsBySearchAttribute,
+		request.DomainUUID,
+		domainPartition,
+		key,
+		value,
+		persistence.UnixNanoToDBTimestamp(request.EarliestTime.UnixNano()),
+		persistence.UnixNanoToDBTimestamp(request.LatestTime.UnixNano()),
+	).Consistency(cassandraLowConslevel).WithContext(ctx)
+	return processQuery(query, request, readOpenWorkflowExecutionRecord)
+}
+
+func (db *cdb) closedFilteredBySearchAttributeSortedByStartTime(
+	ctx context.Context,
+	request *persistence.InternalListWorkflowExecutionsRequest,
+	key string,
+	value string,
+) (*nosqlplugin.SelectVisibilityResponse, error) {
+	query := db.session.Query(templateGetClosedWorkflowExecutionsBySearchAttribute,
+		request.DomainUUID,
+		domainPartition,
+		key,
+		value,
+		persistence.UnixNanoToDBTimestamp(request.EarliestTime.UnixNano()),
+		persistence.UnixNanoToDBTimestamp(request.LatestTime.UnixNano()),
+	).Consistency(cassandraLowConslevel).WithContext(ctx)
+	return processQuery(query, request, readClosedWorkflowExecutionRecord)
+}
+
 func (db *cdb) openSortedByStartTime(
 	ctx context.Context,
 	request *persistence.InternalListWorkflowExecutionsRequest,
@@ -502,6 +674,55 @@ func (db *cdb) closedSortedByClosedTime(
 	return processQuery(query, request, readClosedWorkflowExecutionRecord)
 }
 
+func insertOpenWorkflowExecutionBySearchAttributes(
+	batch gocql.Batch,
+	ttlSeconds int64,
+	domainID string,
+	row *nosqlplugin.VisibilityRow,
+	searchAttributes map[string][]string,
+) {
+	for key, values := range searchAttributes {
+		for _, value := range values {
+			batch.Query(templateCreateOpenWorkflowExecutionBySearchAttribute,
+				domainID,
+				domainPartition,
+				key,
+				value,
+				row.WorkflowID,
+				row.RunID,
+				persistence.UnixNanoToDBTimestamp(row.StartTime.UnixNano()),
+				persistence.UnixNanoToDBTimestamp(row.ExecutionTime.UnixNano()),
+				row.TypeName,
+				row.Memo.Data,
+				row.Memo.GetEncoding(),
+				row.TaskList,
+				row.IsCron,
+				row.NumClusters,
+				row.UpdateTime,
+				row.ShardID,
+				visibilityTTL(ttlSeconds),
+			)
+		}
+	}
+}
+
+// visibilityTTL returns the TTL of a visibility record, 0 means the record never expires
+func visibilityTTL(ttlSeconds int64) int64 {
+	if ttlSeconds > maxCassandraTTL {
+		return 0
+	}
+	return ttlSeconds
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 type recorderReaderFunc func(iter gocql.Iter) (*persistence.InternalVisibilityWorkflowExecutionInfo, bool)
 
 func processQuery(
@@ -34,6 +34,25 @@ const (
 		openExecutionsColumnsForInsert +
 		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
+	// TTL of 0 means the record never expires
+	templateCreateWorkflowExecutionStartedWithIndexedSearchAttributes = `INSERT INTO open_executions ` +
+		`(domain_id, domain_partition, ` + openExecutionsColumnsForSelect + `, indexed_search_attributes) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) using TTL ?`
+
+	templateGetOpenWorkflowExecutionIndexedSearchAttributes = `SELECT indexed_search_attributes ` +
+		`FROM open_executions ` +
+		`WHERE domain_id = ? ` +
+		`AND domain_partition = ? ` +
+		`AND start_time = ? ` +
+		`AND run_id = ?`
+
+	templateUpdateOpenWorkflowExecutionIndexedSearchAttributes = `UPDATE open_executions USING TTL ? ` +
+		`SET indexed_search_attributes = ? ` +
+		`WHERE domain_id = ? ` +
+		`AND domain_partition = ? ` +
+		`AND start_time = ? ` +
+		`AND run_id = ?`
+
 	templateDeleteWorkflowExecutionStarted = `DELETE FROM open_executions ` +
 		`WHERE domain_id = ? ` +
 		`AND domain_partition = ? ` +
@@ -167,4 +186,40 @@ const (
 		`AND close_time >= ? ` +
 		`AND close_time <= ? ` +
 		`AND status = ? `
+
+	///////////////// Executions By Search Attribute /////////////////
+	// TTL of 0 means the record never expires
+	templateCreateOpenWorkflowExecutionBySearchAttribute = `INSERT INTO open_executions_by_search_attribute ` +
+		`(domain_id, domain_partition, search_attribute_key, search_attribute_value, ` + openExecutionsColumnsForSelect + `) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) using TTL ?`
+
+	templateDeleteOpenWorkflowExecutionBySearchAttribute = `DELETE FROM open_executions_by_search_attribute ` +
+		`WHERE domain_id = ? ` +
+		`AND domain_partition = ? ` +
+		`AND search_attribute_key = ? ` +
+		`AND search_attribute_value = ? ` +
+		`AND start_time = ? ` +
+		`AND run_id = ?`
+
+	templateGetOpenWorkflowExecutionsBySearchAttribute = `SELECT ` + openExecutionsColumnsForSelect +
+		`FROM open_executions_by_search_attribute ` +
+		`WHERE domain_id = ? ` +
+		`AND domain_partition = ? ` +
+		`AND search_attribute_key = ? ` +
+		`AND search_attribute_value = ? ` +
+		`AND start_time >= ? ` +
+		`AND start_time <= ? `
+
+	templateCreateClosedWorkflowExecutionBySearchAttribute = `INSERT INTO closed_executions_by_search_attribute ` +
+		`(domain_id, domain_partition, search_attribute_key, search_attribute_value, ` + closedExecutionColumnsForSelect + `) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) using TTL ?`
+
+	templateGetClosedWorkflowExecutionsBySearchAttribute = `SELECT ` + closedExecutionColumnsForSelect +
+		`FROM closed_executions_by_search_attribute ` +
+		`WHERE domain_id = ? ` +
+		`AND domain_partition = ? ` +
+		`AND search_attribute_key = ? ` +
+		`AND search_attribute_value = ? ` +
+		`AND start_time >= ? ` +
+		`AND start_time <= ? `
 )
@@ -1,5 +1,4 @@
-// Copyright (c) 2021 Uber Technologies, Inc.
-// Portions of the Software are attributed to Copyright (c) 2020 Temporal Technologies Inc.
+// Copyright (c) 2020 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -21,14 +20,91 @@
 
 package dynamodb
 
-import "github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
 
 var _ nosqlplugin.AdminDB = (*ddb)(nil)
 
+const (
+	testSchemaDir = "schema/dynamodb/"
+)
+
 func (db *ddb) SetupTestDatabase(schemaBaseDir string) error {
-	panic("TODO")
+	if schemaBaseDir == "" {
+		var err error
+		schemaBaseDir, err = nosqlplugin.GetDefaultTestSchemaDir(testSchemaDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	schemaFile := schemaBaseDir + "cadence/schema.json"
+	byteValues, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return err
+	}
+	var tables []*cadence.TableSchema
+	if err := json.Unmarshal(byteValues, &tables); err != nil {
+		return err
+	}
+	// TODO SetupTestDatabase doesn't pass in context.Context so we are using background for now
+	ctx := context.Background()
+	for _, table := range tables {
+		input := table.CreateTableInput
+		input.TableName = db.tableName(aws.StringValue(table.TableName))
+		if _, err := db.client.CreateTableWithContext(ctx, &input); err != nil {
+			return err
+		}
+		if err := db.client.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{
+			TableName: input.TableName,
+		}); err != nil {
+			return err
+		}
+		if table.TimeToLiveAttributeName == nil {
+			continue
+		}
+		_, err := db.client.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: input.TableName,
+			TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+				AttributeName: table.TimeToLiveAttributeName,
+				Enabled:       aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (db *ddb) TeardownTestDatabase() error {
-	panic("TODO")
+	ctx := context.Background()
+	var tableNames []*string
+	err := db.client.ListTablesPagesWithContext(ctx, &dynamodb.ListTablesInput{}, func(output *dynamodb.ListTablesOutput, lastPage bool) bool {
+		for _, name := range output.TableNames {
+			if strings.HasPrefix(aws.StringValue(name), db.tablePrefix) {
+				tableNames = append(tableNames, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range tableNames {
+		if _, err := db.client.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput{TableName: name}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,258 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
+
+const (
+	// maxInlineBlobSize is the max size of a binary attribute kept in an item, larger ones are moved to the blob table
+	// so that mutable state and history nodes with large payloads stay under the 400KB item size limit of DynamoDB
+	maxInlineBlobSize = 32 * 1024
+	// maxBlobChunkSize is the max size of a chunk in the blob table
+	maxBlobChunkSize = 350 * 1024
+
+	// the attributes of a blob reference, which replaces the binary attribute in the item
+	blobRefOwnerAttr  = "cadence:blobowner"
+	blobRefKeyAttr    = "cadence:blobkey"
+	blobRefChunksAttr = "cadence:blobchunks"
+)
+
+// blobOwner identifies the item that the offloaded blobs belong to.
+// The blobs of an owner must be deleted together with the owner item
+type blobOwner struct {
+	// key is the hash key of the blobs in the blob table
+	key string
+	// prefix is the prefix of the blob keys written by this write, it must be unique for every write of the owner
+	prefix string
+}
+
+// offloadBlobs moves the binary attributes larger than maxInlineBlobSize out of the items into the blob table, and
+// replaces them with blob references. The blobs are written before the items, so a failed write only leaves
+// unreferenced blobs behind, which are deleted together with their owner
+func (db *ddb) offloadBlobs(ctx context.Context, items []*transactItem) error {
+	var puts []*dynamodb.Put
+	for _, item := range items {
+		owner := item.blobOwner
+		if owner == nil {
+			continue
+		}
+		seq := 0
+		offload := func(data []byte) (*dynamodb.AttributeValue, error) {
+			key := compositeKey(owner.prefix, fmt.Sprintf("%04d", seq))
+			seq++
+			chunks := 0
+			for start := 0; start < len(data); start += maxBlobChunkSize {
+				end := start + maxBlobChunkSize
+				if end > len(data) {
+					end = len(data)
+				}
+				put, err := db.newPutItem(cadence.BlobTableName, &cadence.BlobItem{
+					OwnerKey: owner.key,
+					BlobKey:  compositeKey(key, fmt.Sprintf("%06d", chunks)),
+					Data:     data[start:end],
+				}, "", nil)
+				if err != nil {
+					return nil, err
+				}
+				puts = append(puts, put.Put)
+				chunks++
+			}
+			return newBlobRef(owner.key, key, chunks), nil
+		}
+
+		var err error
+		switch {
+		case item.item.Put != nil:
+			err = offloadAttributes(item.item.Put.Item, offload)
+		case item.item.Update != nil:
+			err = offloadAttributes(item.item.Update.ExpressionAttributeValues, offload)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return db.batchPut(ctx, puts)
+}
+
+func offloadAttributes(
+	attributes map[string]*dynamodb.AttributeValue,
+	offload func(data []byte) (*dynamodb.AttributeValue, error),
+) error {
+	for name, av := range attributes {
+		offloaded, err := offloadAttribute(av, offload)
+		if err != nil {
+			return err
+		}
+		attributes[name] = offloaded
+	}
+	return nil
+}
+
+func offloadAttribute(
+	av *dynamodb.AttributeValue,
+	offload func(data []byte) (*dynamodb.AttributeValue, error),
+) (*dynamodb.AttributeValue, error) {
+	switch {
+	case av == nil:
+		return av, nil
+	case len(av.B) > maxInlineBlobSize:
+		return offload(av.B)
+	case av.M != nil:
+		return av, offloadAttributes(av.M, offload)
+	case av.L != nil:
+		for i, element := range av.L {
+			offloaded, err := offloadAttribute(element, offload)
+			if err != nil {
+				return nil, err
+			}
+			av.L[i] = offloaded
+		}
+	}
+	return av, nil
+}
+
+// resolveBlobs replaces the blob references in the items with the blobs read from the blob table
+func (db *ddb) resolveBlobs(ctx context.Context, items ...map[string]*dynamodb.AttributeValue) error {
+	for _, item := range items {
+		for name, av := range item {
+			resolved, err := db.resolveAttribute(ctx, av)
+			if err != nil {
+				return err
+			}
+			item[name] = resolved
+		}
+	}
+	return nil
+}
+
+func (db *ddb) resolveAttribute(ctx context.Context, av *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	if av == nil {
+		return av, nil
+	}
+	if owner, key, chunks, ok := parseBlobRef(av); ok {
+		data, err := db.readBlob(ctx, owner, key, chunks)
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{B: data}, nil
+	}
+	switch {
+	case av.M != nil:
+		if err := db.resolveBlobs(ctx, av.M); err != nil {
+			return nil, err
+		}
+	case av.L != nil:
+		for i, element := range av.L {
+			resolved, err := db.resolveAttribute(ctx, element)
+			if err != nil {
+				return nil, err
+			}
+			av.L[i] = resolved
+		}
+	}
+	return av, nil
+}
+
+// readBlob concatenates the chunks of a blob, which are sorted by the chunk index
+func (db *ddb) readBlob(ctx context.Context, owner, key string, chunks int) ([]byte, error) {
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v = %v AND begins_with(%v, %v)",
+		builder.name("ownerkey"), builder.value(owner),
+		builder.name("blobkey"), builder.value(compositeKey(key, "")))
+	input, err := db.newQueryInput(cadence.BlobTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	read := 0
+	var decodeErr error
+	err = db.client.QueryPagesWithContext(ctx, input, func(output *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, av := range output.Items {
+			var item cadence.BlobItem
+			if decodeErr = unmarshalItem(av, &item); decodeErr != nil {
+				return false
+			}
+			buffer.Write(item.Data)
+			read++
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if read != chunks {
+		return nil, fmt.Errorf("blob %v of %v is incomplete, expected %v chunks but found %v", key, owner, chunks, read)
+	}
+	return buffer.Bytes(), nil
+}
+
+// deleteBlobs deletes the blobs of the owner whose keys are not less than the given key, or all of them if it's empty
+func (db *ddb) deleteBlobs(ctx context.Context, owner string, minBlobKey string) error {
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v = %v", builder.name("ownerkey"), builder.value(owner))
+	if minBlobKey != "" {
+		keyCondition += fmt.Sprintf(" AND %v >= %v", builder.name("blobkey"), builder.value(minBlobKey))
+	}
+	input, err := db.newQueryInput(cadence.BlobTableName, builder, keyCondition, "")
+	if err != nil {
+		return err
+	}
+	input.ProjectionExpression = aws.String("ownerkey, blobkey")
+	return db.rangeDelete(ctx, cadence.BlobTableName, input, "ownerkey", "blobkey")
+}
+
+func newBlobRef(owner, key string, chunks int) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+		blobRefOwnerAttr:  stringAttr(owner),
+		blobRefKeyAttr:    stringAttr(key),
+		blobRefChunksAttr: numberAttr(int64(chunks)),
+	}}
+}
+
+// parseBlobRef returns the blob reference if the attribute is one. A reference is a map of exactly the reference
+// attributes with the expected types, so that it can't be confused with the maps of the items, e.g. timer infos
+func parseBlobRef(av *dynamodb.AttributeValue) (string, string, int, bool) {
+	if len(av.M) != 3 {
+		return "", "", 0, false
+	}
+	owner, key, chunks := av.M[blobRefOwnerAttr], av.M[blobRefKeyAttr], av.M[blobRefChunksAttr]
+	if owner == nil || owner.S == nil || key == nil || key.S == nil || chunks == nil || chunks.N == nil {
+		return "", "", 0, false
+	}
+	n, err := strconv.Atoi(*chunks.N)
+	if err != nil {
+		return "", "", 0, false
+	}
+	return *owner.S, *key.S, n, true
+}
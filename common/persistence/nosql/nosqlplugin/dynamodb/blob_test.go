@@ -0,0 +1,184 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
+
+// fakeClient keeps the items written by BatchWriteItem in memory, and serves the blob queries from them
+type fakeClient struct {
+	dynamodbiface.DynamoDBAPI
+
+	puts        []map[string]*dynamodb.AttributeValue
+	transact    []*dynamodb.TransactWriteItemsInput
+	transactErr error
+}
+
+func (c *fakeClient) BatchWriteItemWithContext(_ aws.Context, input *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, requests := range input.RequestItems {
+		for _, r := range requests {
+			c.puts = append(c.puts, r.PutRequest.Item)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *fakeClient) TransactWriteItemsWithContext(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.transact = append(c.transact, input)
+	if c.transactErr != nil {
+		return nil, c.transactErr
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *fakeClient) QueryPagesWithContext(_ aws.Context, input *dynamodb.QueryInput, fn func(*dynamodb.QueryOutput, bool) bool, _ ...request.Option) error {
+	// the blob query has the owner key as the first value and the blob key prefix as the second
+	owner, prefix := *input.ExpressionAttributeValues[":v0"].S, *input.ExpressionAttributeValues[":v1"].S
+	var items []map[string]*dynamodb.AttributeValue
+	for _, item := range c.puts {
+		if *item["ownerkey"].S == owner && strings.HasPrefix(*item["blobkey"].S, prefix) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i]["blobkey"].S < *items[j]["blobkey"].S })
+	fn(&dynamodb.QueryOutput{Items: items}, true)
+	return nil
+}
+
+func TestOffloadAndResolveBlobs(t *testing.T) {
+	client := &fakeClient{}
+	db := &ddb{client: client}
+
+	small := []byte("small")
+	large := bytes.Repeat([]byte("0123456789"), 80*1024)
+	item, err := marshalItem(map[string]interface{}{
+		"small": small,
+		"large": large,
+		"map":   map[string]interface{}{"large": large},
+		"list":  []interface{}{map[string]interface{}{"data": large}},
+	})
+	require.NoError(t, err)
+
+	err = db.offloadBlobs(context.Background(), []*transactItem{{
+		item:      &dynamodb.TransactWriteItem{Put: &dynamodb.Put{Item: item}},
+		blobOwner: &blobOwner{key: "owner", prefix: "prefix"},
+	}})
+	require.NoError(t, err)
+
+	// each large blob is split into 3 chunks
+	assert.Len(t, client.puts, 9)
+	assert.Equal(t, small, item["small"].B)
+	for _, av := range []*dynamodb.AttributeValue{item["large"], item["map"].M["large"], item["list"].L[0].M["data"]} {
+		owner, _, chunks, ok := parseBlobRef(av)
+		assert.True(t, ok)
+		assert.Equal(t, "owner", owner)
+		assert.Equal(t, 3, chunks)
+	}
+
+	require.NoError(t, db.resolveBlobs(context.Background(), item))
+	assert.Equal(t, small, item["small"].B)
+	assert.Equal(t, large, item["large"].B)
+	assert.Equal(t, large, item["map"].M["large"].B)
+	assert.Equal(t, large, item["list"].L[0].M["data"].B)
+}
+
+func TestParseBlobRef(t *testing.T) {
+	_, _, _, ok := parseBlobRef(newBlobRef("owner", "key", 1))
+	assert.True(t, ok)
+
+	// a map with the same keys but not the reference types, e.g. timer infos keyed by timer IDs
+	timerInfos := &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+		blobRefOwnerAttr:  {M: map[string]*dynamodb.AttributeValue{}},
+		blobRefKeyAttr:    {M: map[string]*dynamodb.AttributeValue{}},
+		blobRefChunksAttr: {M: map[string]*dynamodb.AttributeValue{}},
+	}}
+	_, _, _, ok = parseBlobRef(timerInfos)
+	assert.False(t, ok)
+}
+
+func TestTransactWriteWithTasks(t *testing.T) {
+	client := &fakeClient{}
+	db := &ddb{client: client}
+
+	newItems := func(table string, n int) []*transactItem {
+		var items []*transactItem
+		for i := 0; i < n; i++ {
+			put, err := db.newPutItem(table, map[string]interface{}{"id": i}, "", nil)
+			require.NoError(t, err)
+			items = append(items, &transactItem{item: put})
+		}
+		return items
+	}
+	items := newItems(cadence.WorkflowExecutionTableName, 3)
+	taskItems := newItems(cadence.TransferTaskTableName, 120)
+
+	require.NoError(t, db.transactWriteWithTasks(context.Background(), items, taskItems))
+	// no task is written outside of a transaction conditioned on the shard
+	assert.Empty(t, client.puts)
+	require.Len(t, client.transact, 2)
+	assert.Len(t, client.transact[0].TransactItems, maxTransactWriteItems)
+	assert.Equal(t, items[0].item, client.transact[0].TransactItems[0])
+	assert.Len(t, client.transact[1].TransactItems, 24)
+	assert.Equal(t, items[0].item, client.transact[1].TransactItems[0])
+	assert.Equal(t, taskItems[119].item, client.transact[1].TransactItems[23])
+}
+
+func TestTransactWriteWithTasks_ConditionFailure(t *testing.T) {
+	client := &fakeClient{
+		transactErr: &dynamodb.TransactionCanceledException{
+			CancellationReasons: []*dynamodb.CancellationReason{
+				{},
+				{Code: aws.String(dynamodb.BatchStatementErrorCodeEnumConditionalCheckFailed)},
+			},
+		},
+	}
+	db := &ddb{client: client}
+	conditionFailure := errors.New("condition failure")
+	put, err := db.newPutItem(cadence.WorkflowExecutionTableName, map[string]interface{}{"id": 0}, "", nil)
+	require.NoError(t, err)
+	items := []*transactItem{{item: put}, {item: put, onConditionFailure: func(map[string]*dynamodb.AttributeValue) error {
+		return conditionFailure
+	}}}
+	var taskItems []*transactItem
+	for i := 0; i < 120; i++ {
+		taskItems = append(taskItems, &transactItem{item: put})
+	}
+
+	// the tasks which don't fit into the failed transaction aren't written
+	assert.Equal(t, conditionFailure, db.transactWriteWithTasks(context.Background(), items, taskItems))
+	assert.Empty(t, client.puts)
+	assert.Len(t, client.transact, 1)
+}
@@ -22,15 +22,57 @@ package dynamodb
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 func (db *ddb) InsertConfig(ctx context.Context, row *persistence.InternalConfigStoreEntry) error {
-	return errors.New("TODO")
+	item := cadence.ClusterConfigItem{
+		RowType:              row.RowType,
+		Version:              row.Version,
+		UnixTimestampSeconds: row.Timestamp.Unix(),
+		Data:                 row.Values.Data,
+		DataEncoding:         row.Values.GetEncodingString(),
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("attribute_not_exists(%v)", builder.name("version"))
+	err := db.putItem(ctx, cadence.ClusterConfigTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		return nosqlplugin.NewConditionFailure("InsertConfig operation failed because of version collision")
+	}
+	return err
 }
 
 func (db *ddb) SelectLatestConfig(ctx context.Context, rowType int) (*persistence.InternalConfigStoreEntry, error) {
-	return nil, errors.New("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v = %v", builder.name("rowtype"), builder.value(rowType))
+	input, err := db.newQueryInput(cadence.ClusterConfigTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, err
+	}
+	input.ScanIndexForward = aws.Bool(false)
+	items, _, err := db.query(ctx, input, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errItemNotFound
+	}
+	var result cadence.ClusterConfigItem
+	if err := unmarshalItem(items[0], &result); err != nil {
+		return nil, err
+	}
+	return &persistence.InternalConfigStoreEntry{
+		RowType:   rowType,
+		Version:   result.Version,
+		Timestamp: time.Unix(result.UnixTimestampSeconds, 0),
+		Values:    persistence.NewDataBlob(result.Data, common.EncodingType(result.DataEncoding)),
+	}, nil
 }
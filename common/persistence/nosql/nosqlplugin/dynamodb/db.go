@@ -21,58 +21,101 @@
 package dynamodb
 
 import (
-	"errors"
+	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
 )
 
 const (
-	// PluginName is the name of the plugin
-	PluginName = "dynamodb"
-)
-
-var (
-	errConditionFailed = errors.New("internal condition fail error")
+	// maxBatchWriteItems is the max number of items of a BatchWriteItem request
+	maxBatchWriteItems = 25
+	// maxTransactWriteItems is the max number of items of a TransactWriteItems request
+	maxTransactWriteItems = 100
 )
 
 // ddb represents a logical connection to DynamoDB database
 type ddb struct {
+	client      dynamodbiface.DynamoDBAPI
+	cfg         *config.NoSQL
+	logger      log.Logger
+	tablePrefix string
 }
 
 var _ nosqlplugin.DB = (*ddb)(nil)
 
-// NewDynamoDB return a new DB
-func NewDynamoDB(cfg config.NoSQL, logger log.Logger) (nosqlplugin.DB, error) {
-	return nil, fmt.Errorf("TODO")
+// transactItem is an item of a TransactWriteItems request.
+// onConditionFailure is called with the existing item(nil if not exists) when the condition of this item fails.
+// If blobOwner is set, large binary attributes of the item are moved to the blob table, see offloadBlobs
+type transactItem struct {
+	item               *dynamodb.TransactWriteItem
+	onConditionFailure func(existing map[string]*dynamodb.AttributeValue) error
+	blobOwner          *blobOwner
 }
 
 func (db *ddb) Close() {
-	panic("TODO")
+	// the client is stateless, nothing to close
 }
 
 func (db *ddb) PluginName() string {
 	return PluginName
 }
 
-func (db *ddb) IsNotFoundError(err error) bool {
-	panic("TODO")
-}
-
-func (db *ddb) IsTimeoutError(err error) bool {
-	panic("TODO")
-}
-
-func (db *ddb) IsThrottlingError(err error) bool {
-	panic("TODO")
+// tableName returns the actual name of the table, which is prefixed by the keyspace
+func (db *ddb) tableName(name string) *string {
+	return aws.String(db.tablePrefix + name)
 }
 
-func (db *ddb) IsDBUnavailableError(err error) bool {
-	panic("TODO")
+// transactWrite executes the items in a single transaction.
+// If the transaction is canceled because of condition failure, the onConditionFailure of the first failed item
+// is returned, so the items should be ordered by the priority of their conditions.
+func (db *ddb) transactWrite(ctx context.Context, items []*transactItem) error {
+	if len(items) > maxTransactWriteItems {
+		return fmt.Errorf("transaction of %v items exceeds the limit of %v items", len(items), maxTransactWriteItems)
+	}
+	if err := db.offloadBlobs(ctx, items); err != nil {
+		return err
+	}
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: make([]*dynamodb.TransactWriteItem, 0, len(items)),
+	}
+	for _, item := range items {
+		setReturnValuesOnConditionCheckFailure(item.item)
+		input.TransactItems = append(input.TransactItems, item.item)
+	}
+	_, err := db.client.TransactWriteItemsWithContext(ctx, input)
+	if canceled, ok := err.(*dynamodb.TransactionCanceledException); ok {
+		for i, reason := range canceled.CancellationReasons {
+			if i >= len(items) || items[i].onConditionFailure == nil {
+				continue
+			}
+			if aws.StringValue(reason.Code) == dynamodb.BatchStatementErrorCodeEnumConditionalCheckFailed {
+				if err := db.resolveBlobs(ctx, reason.Item); err != nil {
+					return err
+				}
+				return items[i].onConditionFailure(reason.Item)
+			}
+		}
+	}
+	return err
 }
 
-func (db *ddb) IsConditionFailedError(err error) bool {
-	return err == errConditionFailed
+func setReturnValuesOnConditionCheckFailure(item *dynamodb.TransactWriteItem) {
+	allOld := aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld)
+	switch {
+	case item.ConditionCheck != nil:
+		item.ConditionCheck.ReturnValuesOnConditionCheckFailure = allOld
+	case item.Put != nil && item.Put.ConditionExpression != nil:
+		item.Put.ReturnValuesOnConditionCheckFailure = allOld
+	case item.Update != nil && item.Update.ConditionExpression != nil:
+		item.Update.ReturnValuesOnConditionCheckFailure = allOld
+	case item.Delete != nil && item.Delete.ConditionExpression != nil:
+		item.Delete.ReturnValuesOnConditionCheckFailure = allOld
+	}
 }
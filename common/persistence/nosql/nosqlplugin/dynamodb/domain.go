@@ -22,8 +22,21 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
+
+const (
+	domainMetadataItemID = "cadence-domain-metadata"
 )
 
 // Insert a new record to domain, return error if failed or already exists
@@ -32,7 +45,93 @@ func (db *ddb) InsertDomain(
 	ctx context.Context,
 	row *nosqlplugin.DomainRow,
 ) error {
-	panic("TODO")
+	metadataNotificationVersion, err := db.SelectDomainMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	item := toDomainItem(row)
+	item.FailoverNotificationVersion = persistence.InitialFailoverNotificationVersion
+	item.PreviousFailoverVersion = common.InitialPreviousFailoverVersion
+	item.NotificationVersion = metadataNotificationVersion
+
+	nameBuilder := newExpressionBuilder()
+	nameItem, err := db.newPutItem(
+		cadence.DomainByNameTableName,
+		&cadence.DomainByNameItem{Name: row.Info.Name, ID: row.Info.ID},
+		fmt.Sprintf("attribute_not_exists(%v)", nameBuilder.name("name")),
+		nameBuilder,
+	)
+	if err != nil {
+		return err
+	}
+	idBuilder := newExpressionBuilder()
+	idItem, err := db.newPutItem(
+		cadence.DomainTableName,
+		item,
+		fmt.Sprintf("attribute_not_exists(%v)", idBuilder.name("id")),
+		idBuilder,
+	)
+	if err != nil {
+		return err
+	}
+	metadataItem, err := db.newUpdateDomainMetadataItem(metadataNotificationVersion)
+	if err != nil {
+		return err
+	}
+
+	return db.transactWrite(ctx, []*transactItem{
+		{
+			item: nameItem,
+			onConditionFailure: func(map[string]*dynamodb.AttributeValue) error {
+				db.logger.Warn(fmt.Sprintf("Domain %v already exists", row.Info.Name))
+				return &types.DomainAlreadyExistsError{
+					Message: fmt.Sprintf("Domain %v already exists", row.Info.Name),
+				}
+			},
+		},
+		{
+			item: idItem,
+			onConditionFailure: func(map[string]*dynamodb.AttributeValue) error {
+				return fmt.Errorf("CreateDomain operation failed because of uuid collision")
+			},
+		},
+		metadataItem,
+	})
+}
+
+// newUpdateDomainMetadataItem increases the notification version by one if current version matches
+func (db *ddb) newUpdateDomainMetadataItem(
+	notificationVersion int64,
+) (*transactItem, error) {
+	builder := newExpressionBuilder()
+	versionName := builder.name("notificationversion")
+	condition := fmt.Sprintf("%v = %v", versionName, builder.value(notificationVersion))
+	if notificationVersion == 0 {
+		// the metadata item doesn't exist in the very beginning, when there is no domain created yet
+		condition = fmt.Sprintf("attribute_not_exists(%v) OR %v", versionName, condition)
+	}
+	update := fmt.Sprintf("SET %v = %v", versionName, builder.value(notificationVersion+1))
+	names, values, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	return &transactItem{
+		item: &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				TableName:                 db.tableName(cadence.DomainMetadataTableName),
+				Key:                       map[string]*dynamodb.AttributeValue{"id": stringAttr(domainMetadataItemID)},
+				UpdateExpression:          aws.String(update),
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			},
+		},
+		onConditionFailure: func(map[string]*dynamodb.AttributeValue) error {
+			db.logger.Warn("Domain operation failed because of condition update failure on domain metadata record")
+			return nosqlplugin.NewConditionFailure("domain")
+		},
+	}, nil
 }
 
 // Update domain
@@ -40,7 +139,29 @@ func (db *ddb) UpdateDomain(
 	ctx context.Context,
 	row *nosqlplugin.DomainRow,
 ) error {
-	panic("TODO")
+	metadataItem, err := db.newUpdateDomainMetadataItem(row.NotificationVersion)
+	if err != nil {
+		return err
+	}
+	builder := newExpressionBuilder()
+	domainItem, err := db.newPutItem(
+		cadence.DomainTableName,
+		toDomainItem(row),
+		fmt.Sprintf("%v = %v", builder.name("name"), builder.value(row.Info.Name)),
+		builder,
+	)
+	if err != nil {
+		return err
+	}
+	return db.transactWrite(ctx, []*transactItem{
+		metadataItem,
+		{
+			item: domainItem,
+			onConditionFailure: func(map[string]*dynamodb.AttributeValue) error {
+				return nosqlplugin.NewConditionFailure("domain")
+			},
+		},
+	})
 }
 
 // Get one domain data, either by domainID or domainName
@@ -49,7 +170,21 @@ func (db *ddb) SelectDomain(
 	domainID *string,
 	domainName *string,
 ) (*nosqlplugin.DomainRow, error) {
-	panic("TODO")
+	if domainID != nil && domainName != nil {
+		return nil, fmt.Errorf("GetDomain operation failed.  Both ID and Name specified in request")
+	} else if domainID == nil && domainName == nil {
+		return nil, fmt.Errorf("GetDomain operation failed.  Both ID and Name are empty")
+	}
+
+	id, err := db.getDomainID(ctx, domainID, domainName)
+	if err != nil {
+		return nil, err
+	}
+	var item cadence.DomainItem
+	if err := db.getItem(ctx, cadence.DomainTableName, domainKey(id), &item); err != nil {
+		return nil, err
+	}
+	return fromDomainItem(&item), nil
 }
 
 // Get all domain data
@@ -58,7 +193,23 @@ func (db *ddb) SelectAllDomains(
 	pageSize int,
 	pageToken []byte,
 ) ([]*nosqlplugin.DomainRow, []byte, error) {
-	panic("TODO")
+	items, nextPageToken, err := db.scan(ctx, &dynamodb.ScanInput{
+		TableName:      db.tableName(cadence.DomainTableName),
+		ConsistentRead: aws.Bool(true),
+	}, pageSize, pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]*nosqlplugin.DomainRow, 0, len(items))
+	for _, av := range items {
+		var item cadence.DomainItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, fromDomainItem(&item))
+	}
+	return rows, nextPageToken, nil
 }
 
 // Delete a domain, either by domainID or domainName
@@ -67,11 +218,150 @@ func (db *ddb) DeleteDomain(
 	domainID *string,
 	domainName *string,
 ) error {
-	panic("TODO")
+	if domainID == nil && domainName == nil {
+		return fmt.Errorf("must provide either domainID or domainName")
+	}
+	id, err := db.getDomainID(ctx, domainID, domainName)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+	var item cadence.DomainItem
+	if err := db.getItem(ctx, cadence.DomainTableName, domainKey(id), &item); err != nil {
+		if db.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return db.transactWrite(ctx, []*transactItem{
+		{
+			item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName: db.tableName(cadence.DomainByNameTableName),
+					Key:       map[string]*dynamodb.AttributeValue{"name": stringAttr(item.Name)},
+				},
+			},
+		},
+		{
+			item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName: db.tableName(cadence.DomainTableName),
+					Key:       domainKey(id),
+				},
+			},
+		},
+	})
 }
 
 func (db *ddb) SelectDomainMetadata(
 	ctx context.Context,
 ) (int64, error) {
-	panic("TODO")
+	var item cadence.DomainMetadataItem
+	err := db.getItem(ctx, cadence.DomainMetadataTableName, map[string]*dynamodb.AttributeValue{
+		"id": stringAttr(domainMetadataItemID),
+	}, &item)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			// this error can be thrown in the very beginning, when there is no domain created yet
+			return 0, nil
+		}
+		return -1, err
+	}
+	return item.NotificationVersion, nil
+}
+
+// getDomainID returns the domainID, and looks it up by domainName if domainID is not provided
+func (db *ddb) getDomainID(
+	ctx context.Context,
+	domainID *string,
+	domainName *string,
+) (string, error) {
+	if domainID != nil {
+		return *domainID, nil
+	}
+	var item cadence.DomainByNameItem
+	err := db.getItem(ctx, cadence.DomainByNameTableName, map[string]*dynamodb.AttributeValue{
+		"name": stringAttr(*domainName),
+	}, &item)
+	if err != nil {
+		return "", err
+	}
+	return item.ID, nil
+}
+
+func domainKey(domainID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"id": stringAttr(domainID)}
+}
+
+func toDomainItem(row *nosqlplugin.DomainRow) *cadence.DomainItem {
+	badBinaries, badBinariesEncoding := persistence.FromDataBlob(row.Config.BadBinaries)
+	isolationGroups, isolationGroupsEncoding := persistence.FromDataBlob(row.Config.IsolationGroups)
+	asyncWFConfig, asyncWFConfigEncoding := persistence.FromDataBlob(row.Config.AsyncWorkflowsConfig)
+	item := &cadence.DomainItem{
+		ID:   row.Info.ID,
+		Name: row.Info.Name,
+		Info: row.Info,
+		Config: &cadence.DomainConfig{
+			RetentionDays:                common.DurationToDays(row.Config.Retention),
+			EmitMetric:                   row.Config.EmitMetric,
+			ArchivalBucket:               row.Config.ArchivalBucket,
+			ArchivalStatus:               row.Config.ArchivalStatus,
+			HistoryArchivalStatus:        row.Config.HistoryArchivalStatus,
+			HistoryArchivalURI:           row.Config.HistoryArchivalURI,
+			VisibilityArchivalStatus:     row.Config.VisibilityArchivalStatus,
+			VisibilityArchivalURI:        row.Config.VisibilityArchivalURI,
+			BadBinaries:                  badBinaries,
+			BadBinariesEncoding:          badBinariesEncoding,
+			IsolationGroups:              isolationGroups,
+			IsolationGroupsEncoding:      isolationGroupsEncoding,
+			AsyncWorkflowsConfig:         asyncWFConfig,
+			AsyncWorkflowsConfigEncoding: asyncWFConfigEncoding,
+		},
+		ReplicationConfig:           row.ReplicationConfig,
+		IsGlobalDomain:              row.IsGlobalDomain,
+		ConfigVersion:               row.ConfigVersion,
+		FailoverVersion:             row.FailoverVersion,
+		FailoverNotificationVersion: row.FailoverNotificationVersion,
+		PreviousFailoverVersion:     row.PreviousFailoverVersion,
+		LastUpdatedTime:             row.LastUpdatedTime.UnixNano(),
+		NotificationVersion:         row.NotificationVersion,
+	}
+	if row.FailoverEndTime != nil {
+		item.FailoverEndTime = common.Int64Ptr(row.FailoverEndTime.UnixNano())
+	}
+	return item
+}
+
+func fromDomainItem(item *cadence.DomainItem) *nosqlplugin.DomainRow {
+	row := &nosqlplugin.DomainRow{
+		Info: item.Info,
+		Config: &nosqlplugin.NoSQLInternalDomainConfig{
+			Retention:                common.DaysToDuration(item.Config.RetentionDays),
+			EmitMetric:               item.Config.EmitMetric,
+			ArchivalBucket:           item.Config.ArchivalBucket,
+			ArchivalStatus:           item.Config.ArchivalStatus,
+			HistoryArchivalStatus:    item.Config.HistoryArchivalStatus,
+			HistoryArchivalURI:       item.Config.HistoryArchivalURI,
+			VisibilityArchivalStatus: item.Config.VisibilityArchivalStatus,
+			VisibilityArchivalURI:    item.Config.VisibilityArchivalURI,
+			BadBinaries:              persistence.NewDataBlob(item.Config.BadBinaries, common.EncodingType(item.Config.BadBinariesEncoding)),
+			IsolationGroups:          persistence.NewDataBlob(item.Config.IsolationGroups, common.EncodingType(item.Config.IsolationGroupsEncoding)),
+			AsyncWorkflowsConfig:     persistence.NewDataBlob(item.Config.AsyncWorkflowsConfig, common.EncodingType(item.Config.AsyncWorkflowsConfigEncoding)),
+		},
+		ReplicationConfig:           item.ReplicationConfig,
+		IsGlobalDomain:              item.IsGlobalDomain,
+		ConfigVersion:               item.ConfigVersion,
+		FailoverVersion:             item.FailoverVersion,
+		FailoverNotificationVersion: item.FailoverNotificationVersion,
+		PreviousFailoverVersion:     item.PreviousFailoverVersion,
+		LastUpdatedTime:             time.Unix(0, item.LastUpdatedTime),
+		NotificationVersion:         item.NotificationVersion,
+	}
+	if item.FailoverEndTime != nil {
+		row.FailoverEndTime = common.TimePtr(time.Unix(0, *item.FailoverEndTime))
+	}
+	return row
 }
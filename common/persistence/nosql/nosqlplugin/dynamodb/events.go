@@ -22,31 +22,233 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 // InsertIntoHistoryTreeAndNode inserts one or two rows: tree row and node row(at least one of them)
 func (db *ddb) InsertIntoHistoryTreeAndNode(ctx context.Context, treeRow *nosqlplugin.HistoryTreeRow, nodeRow *nosqlplugin.HistoryNodeRow) error {
-	panic("TODO")
+	if treeRow == nil && nodeRow == nil {
+		return fmt.Errorf("require at least a tree row or a node row to insert")
+	}
+
+	var items []*transactItem
+	if treeRow != nil {
+		ancestors := make([]*cadence.HistoryBranchRange, 0, len(treeRow.Ancestors))
+		for _, an := range treeRow.Ancestors {
+			ancestors = append(ancestors, &cadence.HistoryBranchRange{
+				BranchID:  an.BranchID,
+				EndNodeID: an.EndNodeID,
+			})
+		}
+		put, err := db.newPutItem(cadence.HistoryTreeTableName, &cadence.HistoryTreeItem{
+			TreeID:          treeRow.TreeID,
+			BranchID:        treeRow.BranchID,
+			ShardID:         treeRow.ShardID,
+			Ancestors:       ancestors,
+			CreateTimestamp: treeRow.CreateTimestamp,
+			Info:            treeRow.Info,
+		}, "", nil)
+		if err != nil {
+			return err
+		}
+		items = append(items, &transactItem{item: put})
+	}
+	if nodeRow != nil {
+		put, err := db.newPutItem(cadence.HistoryNodeTableName, &cadence.HistoryNodeItem{
+			BranchKey:    compositeKey(nodeRow.TreeID, nodeRow.BranchID),
+			NodeKey:      historyNodeKey(nodeRow.NodeID, *nodeRow.TxnID),
+			ShardID:      nodeRow.ShardID,
+			TreeID:       nodeRow.TreeID,
+			BranchID:     nodeRow.BranchID,
+			NodeID:       nodeRow.NodeID,
+			TxnID:        *nodeRow.TxnID,
+			Data:         nodeRow.Data,
+			DataEncoding: nodeRow.DataEncoding,
+		}, "", nil)
+		if err != nil {
+			return err
+		}
+		items = append(items, &transactItem{
+			item: put,
+			// a node is immutable once written, so its key is unique for the blobs
+			blobOwner: &blobOwner{
+				key:    historyBranchBlobOwnerKey(nodeRow.TreeID, nodeRow.BranchID),
+				prefix: historyNodeKey(nodeRow.NodeID, *nodeRow.TxnID),
+			},
+		})
+	}
+
+	if len(items) > 1 {
+		return db.transactWrite(ctx, items)
+	}
+	if err := db.offloadBlobs(ctx, items); err != nil {
+		return err
+	}
+	_, err := db.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: items[0].item.Put.TableName,
+		Item:      items[0].item.Put.Item,
+	})
+	return err
 }
 
 // SelectFromHistoryNode read nodes based on a filter
 func (db *ddb) SelectFromHistoryNode(ctx context.Context, filter *nosqlplugin.HistoryNodeFilter) ([]*nosqlplugin.HistoryNodeRow, []byte, error) {
-	panic("TODO")
+	if filter.MinNodeID >= filter.MaxNodeID {
+		return nil, nil, nil
+	}
+	builder := newExpressionBuilder()
+	// nodes are ordered by nodeID ASC, txnID DESC, and the range is [MinNodeID, MaxNodeID)
+	keyCondition := fmt.Sprintf("%v = %v AND %v BETWEEN %v AND %v",
+		builder.name("branchkey"), builder.value(compositeKey(filter.TreeID, filter.BranchID)),
+		builder.name("nodekey"), builder.value(sortableInt(filter.MinNodeID)), builder.value(sortableInt(filter.MaxNodeID)))
+	input, err := db.newQueryInput(cadence.HistoryNodeTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	avs, pagingToken, err := db.query(ctx, input, filter.PageSize, filter.NextPageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []*nosqlplugin.HistoryNodeRow
+	for _, av := range avs {
+		var item cadence.HistoryNodeItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		txnID := item.TxnID
+		rows = append(rows, &nosqlplugin.HistoryNodeRow{
+			ShardID:      item.ShardID,
+			TreeID:       item.TreeID,
+			BranchID:     item.BranchID,
+			NodeID:       item.NodeID,
+			TxnID:        &txnID,
+			Data:         item.Data,
+			DataEncoding: item.DataEncoding,
+		})
+	}
+	return rows, pagingToken, nil
 }
 
 // DeleteFromHistoryTreeAndNode delete a branch record, and a list of ranges of nodes.
 func (db *ddb) DeleteFromHistoryTreeAndNode(ctx context.Context, treeFilter *nosqlplugin.HistoryTreeFilter, nodeFilters []*nosqlplugin.HistoryNodeFilter) error {
-	panic("TODO")
+	treeInput, err := db.newHistoryTreeQueryInput(treeFilter)
+	if err != nil {
+		return err
+	}
+	if err := db.rangeDelete(ctx, cadence.HistoryTreeTableName, treeInput, "treeid", "branchid"); err != nil {
+		return err
+	}
+	for _, nodeFilter := range nodeFilters {
+		builder := newExpressionBuilder()
+		keyCondition := fmt.Sprintf("%v = %v AND %v >= %v",
+			builder.name("branchkey"), builder.value(compositeKey(nodeFilter.TreeID, nodeFilter.BranchID)),
+			builder.name("nodekey"), builder.value(sortableInt(nodeFilter.MinNodeID)))
+		input, err := db.newQueryInput(cadence.HistoryNodeTableName, builder, keyCondition, "")
+		if err != nil {
+			return err
+		}
+		if err := db.rangeDelete(ctx, cadence.HistoryNodeTableName, input, "branchkey", "nodekey"); err != nil {
+			return err
+		}
+		// the blob keys start with the node keys, so the blobs of the deleted nodes are in the same range
+		if err := db.deleteBlobs(ctx, historyBranchBlobOwnerKey(nodeFilter.TreeID, nodeFilter.BranchID), sortableInt(nodeFilter.MinNodeID)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SelectAllHistoryTrees will return all tree branches with pagination
 func (db *ddb) SelectAllHistoryTrees(ctx context.Context, nextPageToken []byte, pageSize int) ([]*nosqlplugin.HistoryTreeRow, []byte, error) {
-	panic("TODO")
+	avs, pagingToken, err := db.scan(ctx, &dynamodb.ScanInput{
+		TableName:      db.tableName(cadence.HistoryTreeTableName),
+		ConsistentRead: aws.Bool(true),
+	}, pageSize, nextPageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := fromHistoryTreeItems(avs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, pagingToken, nil
 }
 
 // SelectFromHistoryTree read branch records for a tree
 func (db *ddb) SelectFromHistoryTree(ctx context.Context, filter *nosqlplugin.HistoryTreeFilter) ([]*nosqlplugin.HistoryTreeRow, error) {
-	panic("TODO")
+	input, err := db.newHistoryTreeQueryInput(filter)
+	if err != nil {
+		return nil, err
+	}
+	avs, _, err := db.query(ctx, input, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fromHistoryTreeItems(avs)
+}
+
+func (db *ddb) newHistoryTreeQueryInput(filter *nosqlplugin.HistoryTreeFilter) (*dynamodb.QueryInput, error) {
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v = %v", builder.name("treeid"), builder.value(filter.TreeID))
+	if filter.BranchID != nil {
+		keyCondition += fmt.Sprintf(" AND %v = %v", builder.name("branchid"), builder.value(*filter.BranchID))
+	}
+	return db.newQueryInput(cadence.HistoryTreeTableName, builder, keyCondition, "")
+}
+
+func historyBranchBlobOwnerKey(treeID, branchID string) string {
+	return compositeKey(cadence.HistoryNodeTableName, treeID, branchID)
+}
+
+// historyNodeKey makes the nodes sorted by nodeID ASC, txnID DESC
+func historyNodeKey(nodeID, txnID int64) string {
+	return compositeKey(sortableInt(nodeID), sortableInt(math.MaxInt64-txnID))
+}
+
+func fromHistoryTreeItems(avs []map[string]*dynamodb.AttributeValue) ([]*nosqlplugin.HistoryTreeRow, error) {
+	var rows []*nosqlplugin.HistoryTreeRow
+	for _, av := range avs {
+		var item cadence.HistoryTreeItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		rows = append(rows, fromHistoryTreeItem(&item))
+	}
+	return rows, nil
+}
+
+func fromHistoryTreeItem(item *cadence.HistoryTreeItem) *nosqlplugin.HistoryTreeRow {
+	ancestors := make([]*types.HistoryBranchRange, 0, len(item.Ancestors))
+	for _, an := range item.Ancestors {
+		ancestors = append(ancestors, &types.HistoryBranchRange{
+			BranchID:  an.BranchID,
+			EndNodeID: an.EndNodeID,
+		})
+	}
+	if len(ancestors) > 0 {
+		// sort ancestors based on EndNodeID so that we can set BeginNodeID
+		sort.Slice(ancestors, func(i, j int) bool { return ancestors[i].EndNodeID < ancestors[j].EndNodeID })
+		ancestors[0].BeginNodeID = int64(1)
+		for i := 1; i < len(ancestors); i++ {
+			ancestors[i].BeginNodeID = ancestors[i-1].EndNodeID
+		}
+	}
+	return &nosqlplugin.HistoryTreeRow{
+		ShardID:         item.ShardID,
+		TreeID:          item.TreeID,
+		BranchID:        item.BranchID,
+		Ancestors:       ancestors,
+		CreateTimestamp: item.CreateTimestamp,
+		Info:            item.Info,
+	}
 }
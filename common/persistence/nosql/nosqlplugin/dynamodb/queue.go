@@ -22,9 +22,14 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 // Insert message into queue, return error if failed or already exists
@@ -33,7 +38,18 @@ func (db *ddb) InsertIntoQueue(
 	ctx context.Context,
 	row *nosqlplugin.QueueMessageRow,
 ) error {
-	panic("TODO")
+	item := cadence.QueueMessageItem{
+		QueueType: row.QueueType,
+		MessageID: row.ID,
+		Payload:   row.Payload,
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("attribute_not_exists(%v)", builder.name("messageid"))
+	err := db.putItem(ctx, cadence.QueueMessageTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		return nosqlplugin.NewConditionFailure("queue")
+	}
+	return err
 }
 
 // Get the ID of last message inserted into the queue
@@ -41,7 +57,21 @@ func (db *ddb) SelectLastEnqueuedMessageID(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (int64, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := queueTypeCondition(builder, queueType)
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, keyCondition, "")
+	if err != nil {
+		return 0, err
+	}
+	input.ScanIndexForward = aws.Bool(false)
+	items, err := db.selectQueueMessages(ctx, input, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, errItemNotFound
+	}
+	return items[0].MessageID, nil
 }
 
 // Read queue messages starting from the exclusiveBeginMessageID
@@ -51,7 +81,23 @@ func (db *ddb) SelectMessagesFrom(
 	exclusiveBeginMessageID int64,
 	maxRows int,
 ) ([]*nosqlplugin.QueueMessageRow, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v > %v",
+		queueTypeCondition(builder, queueType), builder.name("messageid"), builder.value(exclusiveBeginMessageID))
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, err
+	}
+	items, err := db.selectQueueMessages(ctx, input, maxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*nosqlplugin.QueueMessageRow
+	for _, item := range items {
+		result = append(result, &nosqlplugin.QueueMessageRow{QueueType: item.QueueType, ID: item.MessageID, Payload: item.Payload})
+	}
+	return result, nil
 }
 
 // Read queue message starting from exclusiveBeginMessageID int64, inclusiveEndMessageID int64
@@ -59,7 +105,54 @@ func (db *ddb) SelectMessagesBetween(
 	ctx context.Context,
 	request nosqlplugin.SelectMessagesBetweenRequest,
 ) (*nosqlplugin.SelectMessagesBetweenResponse, error) {
-	panic("TODO")
+	if request.ExclusiveBeginMessageID >= request.InclusiveEndMessageID {
+		return &nosqlplugin.SelectMessagesBetweenResponse{}, nil
+	}
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v",
+		queueTypeCondition(builder, request.QueueType),
+		messageIDRangeCondition(builder, request.ExclusiveBeginMessageID, request.InclusiveEndMessageID))
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, err
+	}
+	avs, nextPageToken, err := db.query(ctx, input, request.PageSize, request.NextPageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []nosqlplugin.QueueMessageRow
+	for _, av := range avs {
+		var item cadence.QueueMessageItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		rows = append(rows, nosqlplugin.QueueMessageRow{QueueType: item.QueueType, ID: item.MessageID, Payload: item.Payload})
+	}
+	return &nosqlplugin.SelectMessagesBetweenResponse{
+		Rows:          rows,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (db *ddb) selectQueueMessages(
+	ctx context.Context,
+	input *dynamodb.QueryInput,
+	limit int,
+) ([]*cadence.QueueMessageItem, error) {
+	avs, _, err := db.query(ctx, input, limit, nil)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*cadence.QueueMessageItem, 0, len(avs))
+	for _, av := range avs {
+		var item cadence.QueueMessageItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, nil
 }
 
 // Delete all messages before exclusiveBeginMessageID
@@ -68,7 +161,14 @@ func (db *ddb) DeleteMessagesBefore(
 	queueType persistence.QueueType,
 	exclusiveBeginMessageID int64,
 ) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v < %v",
+		queueTypeCondition(builder, queueType), builder.name("messageid"), builder.value(exclusiveBeginMessageID))
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, keyCondition, "")
+	if err != nil {
+		return err
+	}
+	return db.rangeDelete(ctx, cadence.QueueMessageTableName, input, "queuetype", "messageid")
 }
 
 // Delete all messages in a range between exclusiveBeginMessageID and inclusiveEndMessageID
@@ -78,7 +178,18 @@ func (db *ddb) DeleteMessagesInRange(
 	exclusiveBeginMessageID int64,
 	inclusiveEndMessageID int64,
 ) error {
-	panic("TODO")
+	if exclusiveBeginMessageID >= inclusiveEndMessageID {
+		return nil
+	}
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v",
+		queueTypeCondition(builder, queueType),
+		messageIDRangeCondition(builder, exclusiveBeginMessageID, inclusiveEndMessageID))
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, keyCondition, "")
+	if err != nil {
+		return err
+	}
+	return db.rangeDelete(ctx, cadence.QueueMessageTableName, input, "queuetype", "messageid")
 }
 
 // Delete one message
@@ -87,7 +198,10 @@ func (db *ddb) DeleteMessage(
 	queueType persistence.QueueType,
 	messageID int64,
 ) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.QueueMessageTableName, map[string]*dynamodb.AttributeValue{
+		"queuetype": numberAttr(int64(queueType)),
+		"messageid": numberAttr(messageID),
+	})
 }
 
 // Insert an empty metadata row, starting from a version
@@ -96,7 +210,19 @@ func (db *ddb) InsertQueueMetadata(
 	queueType persistence.QueueType,
 	version int64,
 ) error {
-	panic("TODO")
+	item := cadence.QueueMetadataItem{
+		QueueType:        queueType,
+		ClusterAckLevels: map[string]int64{},
+		Version:          version,
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("attribute_not_exists(%v)", builder.name("queuetype"))
+	err := db.putItem(ctx, cadence.QueueMetadataTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		// it's ok if the record exists already
+		return nil
+	}
+	return err
 }
 
 // **Conditionally** update a queue metadata row, if current version is matched(meaning current == row.Version - 1),
@@ -106,7 +232,27 @@ func (db *ddb) UpdateQueueMetadataCas(
 	ctx context.Context,
 	row nosqlplugin.QueueMetadataRow,
 ) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	versionName := builder.name("version")
+	update := fmt.Sprintf("SET %v = %v, %v = %v",
+		builder.name("clusteracklevels"), builder.value(row.ClusterAckLevels), versionName, builder.value(row.Version))
+	condition := fmt.Sprintf("%v = %v", versionName, builder.value(row.Version-1))
+	names, values, err := builder.build()
+	if err != nil {
+		return err
+	}
+	_, err = db.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 db.tableName(cadence.QueueMetadataTableName),
+		Key:                       map[string]*dynamodb.AttributeValue{"queuetype": numberAttr(int64(row.QueueType))},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return nosqlplugin.NewConditionFailure("queue")
+	}
+	return err
 }
 
 // Read a QueueMetadata
@@ -114,12 +260,43 @@ func (db *ddb) SelectQueueMetadata(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (*nosqlplugin.QueueMetadataRow, error) {
-	panic("TODO")
+	var item cadence.QueueMetadataItem
+	err := db.getItem(ctx, cadence.QueueMetadataTableName, map[string]*dynamodb.AttributeValue{
+		"queuetype": numberAttr(int64(queueType)),
+	}, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	// if record exist but ackLevels is empty, we initialize the map
+	if item.ClusterAckLevels == nil {
+		item.ClusterAckLevels = make(map[string]int64)
+	}
+	return &nosqlplugin.QueueMetadataRow{
+		QueueType:        queueType,
+		ClusterAckLevels: item.ClusterAckLevels,
+		Version:          item.Version,
+	}, nil
 }
 
 func (db *ddb) GetQueueSize(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (int64, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	input, err := db.newQueryInput(cadence.QueueMessageTableName, builder, queueTypeCondition(builder, queueType), "")
+	if err != nil {
+		return 0, err
+	}
+	return db.count(ctx, input)
+}
+
+func queueTypeCondition(builder *expressionBuilder, queueType persistence.QueueType) string {
+	return fmt.Sprintf("%v = %v", builder.name("queuetype"), builder.value(queueType))
+}
+
+// messageIDRangeCondition returns the condition of messageID in range of (exclusiveBeginMessageID, inclusiveEndMessageID]
+func messageIDRangeCondition(builder *expressionBuilder, exclusiveBeginMessageID, inclusiveEndMessageID int64) string {
+	return fmt.Sprintf("%v BETWEEN %v AND %v",
+		builder.name("messageid"), builder.value(exclusiveBeginMessageID+1), builder.value(inclusiveEndMessageID))
 }
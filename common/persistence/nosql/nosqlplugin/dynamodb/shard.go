@@ -22,29 +22,150 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 // InsertShard creates a new shard, return error is there is any.
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *ddb) InsertShard(ctx context.Context, row *nosqlplugin.ShardRow) error {
-	panic("TODO")
+	shard := *row
+	shard.UpdatedAt = time.Now()
+	item := cadence.ShardItem{
+		ShardID: row.ShardID,
+		RangeID: row.RangeID,
+		Shard:   &shard,
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("attribute_not_exists(%v)", builder.name("shardid"))
+	err := db.putItem(ctx, cadence.ShardTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedShardRow(ctx, row.ShardID, "InsertShard")
+	}
+	return err
 }
 
 // SelectShard gets a shard
 func (db *ddb) SelectShard(ctx context.Context, shardID int, currentClusterName string) (int64, *nosqlplugin.ShardRow, error) {
-	panic("TODO")
+	var item cadence.ShardItem
+	if err := db.getItem(ctx, cadence.ShardTableName, shardKey(shardID), &item); err != nil {
+		return 0, nil, err
+	}
+
+	info := item.Shard
+	if info.ClusterTransferAckLevel == nil {
+		info.ClusterTransferAckLevel = map[string]int64{
+			currentClusterName: info.TransferAckLevel,
+		}
+	}
+	if info.ClusterTimerAckLevel == nil {
+		info.ClusterTimerAckLevel = map[string]time.Time{
+			currentClusterName: info.TimerAckLevel,
+		}
+	}
+	if info.ClusterReplicationLevel == nil {
+		info.ClusterReplicationLevel = make(map[string]int64)
+	}
+	if info.ReplicationDLQAckLevel == nil {
+		info.ReplicationDLQAckLevel = make(map[string]int64)
+	}
+	return item.RangeID, info, nil
 }
 
 // UpdateRangeID updates the rangeID, return error is there is any
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *ddb) UpdateRangeID(ctx context.Context, shardID int, rangeID int64, previousRangeID int64) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	rangeIDName := builder.name("rangeid")
+	update := fmt.Sprintf("SET %v = %v", rangeIDName, builder.value(rangeID))
+	condition := fmt.Sprintf("%v = %v", rangeIDName, builder.value(previousRangeID))
+	names, values, err := builder.build()
+	if err != nil {
+		return err
+	}
+	_, err = db.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 db.tableName(cadence.ShardTableName),
+		Key:                       shardKey(shardID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedShardRow(ctx, shardID, "UpdateRangeID")
+	}
+	return err
 }
 
 // UpdateShard updates a shard, return error is there is any.
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *ddb) UpdateShard(ctx context.Context, row *nosqlplugin.ShardRow, previousRangeID int64) error {
-	panic("TODO")
+	shard := *row
+	shard.UpdatedAt = time.Now()
+	item := cadence.ShardItem{
+		ShardID: row.ShardID,
+		RangeID: row.RangeID,
+		Shard:   &shard,
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("%v = %v", builder.name("rangeid"), builder.value(previousRangeID))
+	err := db.putItem(ctx, cadence.ShardTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedShardRow(ctx, row.ShardID, "UpdateShard")
+	}
+	return err
+}
+
+// newShardConditionItem returns the condition check of the rangeID of a shard within a transaction
+func (db *ddb) newShardConditionItem(condition *nosqlplugin.ShardCondition) (*transactItem, error) {
+	builder := newExpressionBuilder()
+	expression := fmt.Sprintf("%v = %v", builder.name("rangeid"), builder.value(condition.RangeID))
+	names, values, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	return &transactItem{
+		item: &dynamodb.TransactWriteItem{
+			ConditionCheck: &dynamodb.ConditionCheck{
+				TableName:                 db.tableName(cadence.ShardTableName),
+				Key:                       shardKey(condition.ShardID),
+				ConditionExpression:       aws.String(expression),
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			},
+		},
+		onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+			var item cadence.ShardItem
+			if existing == nil {
+				return fmt.Errorf("shard %v doesn't exist", condition.ShardID)
+			}
+			if err := unmarshalItem(existing, &item); err != nil {
+				return err
+			}
+			return &nosqlplugin.WorkflowOperationConditionFailure{
+				ShardRangeIDNotMatch: &item.RangeID,
+			}
+		},
+	}, nil
+}
+
+func (db *ddb) convertToConflictedShardRow(ctx context.Context, shardID int, operation string) error {
+	var item cadence.ShardItem
+	if err := db.getItem(ctx, cadence.ShardTableName, shardKey(shardID), &item); err != nil {
+		return err
+	}
+	return &nosqlplugin.ShardOperationConditionFailure{
+		RangeID: item.RangeID,
+		Details: fmt.Sprintf("%v failed. shard_id=%v, range_id=%v", operation, shardID, item.RangeID),
+	}
+}
+
+func shardKey(shardID int) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"shardid": numberAttr(int64(shardID))}
 }
@@ -1,4 +1,4 @@
-// Copyright (c) 2021 Uber Technologies, Inc.
+// Copyright (c) 2020 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -22,20 +22,67 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
+
+const (
+	initialRangeID = 1 // Id of the first range of a new task list
 )
 
 // SelectTaskList returns a single tasklist row.
 // Return IsNotFoundError if the row doesn't exist
 func (db *ddb) SelectTaskList(ctx context.Context, filter *nosqlplugin.TaskListFilter) (*nosqlplugin.TaskListRow, error) {
-	panic("TODO")
+	var item cadence.TaskListItem
+	if err := db.getItem(ctx, cadence.TaskListTableName, taskListKey(filter), &item); err != nil {
+		return nil, err
+	}
+	return &nosqlplugin.TaskListRow{
+		DomainID:     item.DomainID,
+		TaskListName: item.TaskListName,
+		TaskListType: item.TaskListType,
+
+		TaskListKind:    item.TaskListKind,
+		LastUpdatedTime: item.LastUpdatedTime,
+		AckLevel:        item.AckLevel,
+		RangeID:         item.RangeID,
+	}, nil
 }
 
 // InsertTaskList insert a single tasklist row
-// Return IsConditionFailedError if the row already exists, and also the existing row
+// Return TaskOperationConditionFailure if the row already exists
 func (db *ddb) InsertTaskList(ctx context.Context, row *nosqlplugin.TaskListRow) error {
-	panic("TODO")
+	filter := &nosqlplugin.TaskListFilter{
+		DomainID:     row.DomainID,
+		TaskListName: row.TaskListName,
+		TaskListType: row.TaskListType,
+	}
+	item := cadence.TaskListItem{
+		TaskListKey:     taskListKeyString(filter),
+		DomainID:        row.DomainID,
+		TaskListName:    row.TaskListName,
+		TaskListType:    row.TaskListType,
+		RangeID:         initialRangeID,
+		TaskListKind:    row.TaskListKind,
+		AckLevel:        0,
+		LastUpdatedTime: row.LastUpdatedTime,
+	}
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("attribute_not_exists(%v)", builder.name("tasklistkey"))
+	err := db.putItem(ctx, cadence.TaskListTableName, item, condition, builder)
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedTaskListRow(ctx, filter, "InsertTaskList")
+	}
+	return err
 }
 
 // UpdateTaskList updates a single tasklist row
@@ -45,7 +92,7 @@ func (db *ddb) UpdateTaskList(
 	row *nosqlplugin.TaskListRow,
 	previousRangeID int64,
 ) error {
-	panic("TODO")
+	return db.updateTaskList(ctx, row, previousRangeID, row.LastUpdatedTime, 0)
 }
 
 // UpdateTaskList updates a single tasklist row, and set an TTL on the record
@@ -58,39 +105,209 @@ func (db *ddb) UpdateTaskListWithTTL(
 	row *nosqlplugin.TaskListRow,
 	previousRangeID int64,
 ) error {
-	panic("TODO")
+	now := time.Now()
+	return db.updateTaskList(ctx, row, previousRangeID, now, now.Unix()+ttlSeconds)
+}
+
+// updateTaskList updates the tasklist with the condition of rangeID, expireTime is removed if it's zero
+func (db *ddb) updateTaskList(
+	ctx context.Context,
+	row *nosqlplugin.TaskListRow,
+	previousRangeID int64,
+	lastUpdatedTime time.Time,
+	expireTime int64,
+) error {
+	filter := &nosqlplugin.TaskListFilter{
+		DomainID:     row.DomainID,
+		TaskListName: row.TaskListName,
+		TaskListType: row.TaskListType,
+	}
+	builder := newExpressionBuilder()
+	rangeIDName := builder.name("rangeid")
+	update := fmt.Sprintf("SET %v = %v, %v = %v, %v = %v, %v = %v",
+		rangeIDName, builder.value(row.RangeID),
+		builder.name("acklevel"), builder.value(row.AckLevel),
+		builder.name("tasklistkind"), builder.value(row.TaskListKind),
+		builder.name("lastupdatedtime"), builder.value(lastUpdatedTime),
+	)
+	if expireTime > 0 {
+		update += fmt.Sprintf(", %v = %v", builder.name("expiretime"), builder.value(expireTime))
+	} else {
+		update += fmt.Sprintf(" REMOVE %v", builder.name("expiretime"))
+	}
+	condition := fmt.Sprintf("%v = %v", rangeIDName, builder.value(previousRangeID))
+	names, values, err := builder.build()
+	if err != nil {
+		return err
+	}
+	_, err = db.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 db.tableName(cadence.TaskListTableName),
+		Key:                       taskListKey(filter),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedTaskListRow(ctx, filter, "UpdateTaskList")
+	}
+	return err
 }
 
 // ListTaskList returns all tasklists.
 // Noop if TTL is already implemented in other methods
 func (db *ddb) ListTaskList(ctx context.Context, pageSize int, nextPageToken []byte) (*nosqlplugin.ListTaskListResult, error) {
-	panic("TODO")
+	return nil, &types.InternalServiceError{
+		Message: "unsupported operation",
+	}
 }
 
 // DeleteTaskList deletes a single tasklist row
 // Return TaskOperationConditionFailure if the condition doesn't meet
 func (db *ddb) DeleteTaskList(ctx context.Context, filter *nosqlplugin.TaskListFilter, previousRangeID int64) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("%v = %v", builder.name("rangeid"), builder.value(previousRangeID))
+	names, values, err := builder.build()
+	if err != nil {
+		return err
+	}
+	_, err = db.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 db.tableName(cadence.TaskListTableName),
+		Key:                       taskListKey(filter),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return db.convertToConflictedTaskListRow(ctx, filter, "DeleteTaskList")
+	}
+	return err
 }
 
 // InsertTasks inserts a batch of tasks
 // Return TaskOperationConditionFailure if the condition doesn't meet
+// NOTE: tasks are written in multiple transactions if they don't fit into one, each of them checks the rangeID of tasklist
 func (db *ddb) InsertTasks(
 	ctx context.Context,
 	tasksToInsert []*nosqlplugin.TaskRowForInsert,
 	tasklistCondition *nosqlplugin.TaskListRow,
 ) error {
-	panic("TODO")
+	tlFilter := &nosqlplugin.TaskListFilter{
+		DomainID:     tasklistCondition.DomainID,
+		TaskListName: tasklistCondition.TaskListName,
+		TaskListType: tasklistCondition.TaskListType,
+	}
+	tlKey := taskListKeyString(tlFilter)
+	now := time.Now()
+	var puts []*transactItem
+	for _, task := range tasksToInsert {
+		item := &cadence.TaskItem{
+			TaskListKey:     tlKey,
+			TaskID:          task.TaskID,
+			DomainID:        tlFilter.DomainID,
+			TaskListName:    tlFilter.TaskListName,
+			TaskListType:    tlFilter.TaskListType,
+			WorkflowID:      task.WorkflowID,
+			RunID:           task.RunID,
+			ScheduledID:     task.ScheduledID,
+			CreatedTime:     task.CreatedTime,
+			PartitionConfig: task.PartitionConfig,
+		}
+		if task.TTLSeconds > 0 {
+			item.ExpireTime = now.Unix() + int64(task.TTLSeconds)
+		}
+		put, err := db.newPutItem(cadence.TaskTableName, item, "", nil)
+		if err != nil {
+			return err
+		}
+		puts = append(puts, &transactItem{item: put})
+	}
+
+	// the condition check is used to ensure that range_id didn't change
+	maxTasksPerTransaction := maxTransactWriteItems - 1
+	for start := 0; start == 0 || start < len(puts); start += maxTasksPerTransaction {
+		end := start + maxTasksPerTransaction
+		if end > len(puts) {
+			end = len(puts)
+		}
+		builder := newExpressionBuilder()
+		condition := fmt.Sprintf("%v = %v", builder.name("rangeid"), builder.value(tasklistCondition.RangeID))
+		names, values, err := builder.build()
+		if err != nil {
+			return err
+		}
+		items := []*transactItem{
+			{
+				item: &dynamodb.TransactWriteItem{
+					ConditionCheck: &dynamodb.ConditionCheck{
+						TableName:                 db.tableName(cadence.TaskListTableName),
+						Key:                       taskListKey(tlFilter),
+						ConditionExpression:       aws.String(condition),
+						ExpressionAttributeNames:  names,
+						ExpressionAttributeValues: values,
+					},
+				},
+				onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+					return convertToTaskOperationConditionFailure(existing, "InsertTasks")
+				},
+			},
+		}
+		items = append(items, puts[start:end]...)
+		if err := db.transactWrite(ctx, items); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SelectTasks return tasks that associated to a tasklist
 func (db *ddb) SelectTasks(ctx context.Context, filter *nosqlplugin.TasksFilter) ([]*nosqlplugin.TaskRow, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	taskIDCondition, ok := taskIDRangeCondition(builder, filter.MinTaskID, filter.MaxTaskID)
+	if !ok {
+		return nil, nil
+	}
+	keyCondition := fmt.Sprintf("%v AND %v", taskListKeyCondition(builder, &filter.TaskListFilter), taskIDCondition)
+	input, err := db.newQueryInput(cadence.TaskTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, err
+	}
+	avs, _, err := db.query(ctx, input, filter.BatchSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []*nosqlplugin.TaskRow
+	for _, av := range avs {
+		var item cadence.TaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		response = append(response, &nosqlplugin.TaskRow{
+			DomainID:        item.DomainID,
+			TaskListName:    item.TaskListName,
+			TaskListType:    item.TaskListType,
+			TaskID:          item.TaskID,
+			WorkflowID:      item.WorkflowID,
+			RunID:           item.RunID,
+			ScheduledID:     item.ScheduledID,
+			CreatedTime:     item.CreatedTime,
+			PartitionConfig: item.PartitionConfig,
+		})
+	}
+	return response, nil
 }
 
 // SelectTasks return tasks that associated to a tasklist
 func (db *ddb) GetTasksCount(ctx context.Context, filter *nosqlplugin.TasksFilter) (int64, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v > %v",
+		taskListKeyCondition(builder, &filter.TaskListFilter), builder.name("taskid"), builder.value(filter.MinTaskID))
+	input, err := db.newQueryInput(cadence.TaskTableName, builder, keyCondition, "")
+	if err != nil {
+		return 0, err
+	}
+	return db.count(ctx, input)
 }
 
 // DeleteTask delete a batch tasks that taskIDs less than the row
@@ -98,5 +315,56 @@ func (db *ddb) GetTasksCount(ctx context.Context, filter *nosqlplugin.TasksFilte
 // NOTE: This API ignores the `BatchSize` request parameter i.e. either all tasks leq the task_id will be deleted or an error will
 // be returned to the caller, because rowsDeleted is not supported by Cassandra
 func (db *ddb) RangeDeleteTasks(ctx context.Context, filter *nosqlplugin.TasksFilter) (rowsDeleted int, err error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	taskIDCondition, ok := taskIDRangeCondition(builder, filter.MinTaskID, filter.MaxTaskID)
+	if !ok {
+		return persistence.UnknownNumRowsAffected, nil
+	}
+	keyCondition := fmt.Sprintf("%v AND %v", taskListKeyCondition(builder, &filter.TaskListFilter), taskIDCondition)
+	input, err := db.newQueryInput(cadence.TaskTableName, builder, keyCondition, "")
+	if err != nil {
+		return 0, err
+	}
+	err = db.rangeDelete(ctx, cadence.TaskTableName, input, "tasklistkey", "taskid")
+	return persistence.UnknownNumRowsAffected, err
+}
+
+func taskListKeyString(filter *nosqlplugin.TaskListFilter) string {
+	return compositeKey(filter.DomainID, strconv.Itoa(filter.TaskListType), filter.TaskListName)
+}
+
+func taskListKey(filter *nosqlplugin.TaskListFilter) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"tasklistkey": stringAttr(taskListKeyString(filter))}
+}
+
+func taskListKeyCondition(builder *expressionBuilder, filter *nosqlplugin.TaskListFilter) string {
+	return fmt.Sprintf("%v = %v", builder.name("tasklistkey"), builder.value(taskListKeyString(filter)))
+}
+
+func (db *ddb) convertToConflictedTaskListRow(ctx context.Context, filter *nosqlplugin.TaskListFilter, operation string) error {
+	output, err := db.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      db.tableName(cadence.TaskListTableName),
+		Key:            taskListKey(filter),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	return convertToTaskOperationConditionFailure(output.Item, operation)
+}
+
+func convertToTaskOperationConditionFailure(existing map[string]*dynamodb.AttributeValue, operation string) error {
+	if existing == nil {
+		return &nosqlplugin.TaskOperationConditionFailure{
+			Details: fmt.Sprintf("%v failed. tasklist doesn't exist", operation),
+		}
+	}
+	var item cadence.TaskListItem
+	if err := unmarshalItem(existing, &item); err != nil {
+		return err
+	}
+	return &nosqlplugin.TaskOperationConditionFailure{
+		RangeID: item.RangeID,
+		Details: fmt.Sprintf("%v failed. range_id=%v, ack_level=%v", operation, item.RangeID, item.AckLevel),
+	}
 }
@@ -0,0 +1,441 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var (
+	// empty collections are preserved so that maps can be updated by keys, and lists can be appended
+	encoder = dynamodbattribute.NewEncoder(func(e *dynamodbattribute.Encoder) {
+		e.EnableEmptyCollections = true
+	})
+	decoder = dynamodbattribute.NewDecoder(func(d *dynamodbattribute.Decoder) {
+		d.EnableEmptyCollections = true
+	})
+)
+
+func marshalItem(in interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	av, err := encoder.Encode(in)
+	if err != nil {
+		return nil, err
+	}
+	return av.M, nil
+}
+
+func unmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) error {
+	return decoder.Decode(&dynamodb.AttributeValue{M: item}, out)
+}
+
+func stringAttr(s string) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{S: aws.String(s)}
+}
+
+func numberAttr(n int64) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(n, 10))}
+}
+
+// compositeKey concatenates the parts into a single key attribute
+func compositeKey(parts ...string) string {
+	return strings.Join(parts, "|")
+}
+
+// sortableInt formats a non-negative number into a fixed length string so that it can be sorted as string
+func sortableInt(n int64) string {
+	if n < 0 {
+		n = 0
+	}
+	return fmt.Sprintf("%020d", n)
+}
+
+func sortableTime(t time.Time) string {
+	return sortableInt(t.UnixNano())
+}
+
+// expressionBuilder generates the placeholders of attribute names and values for DynamoDB expressions.
+// The first error of marshaling values is kept and returned by build
+type expressionBuilder struct {
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	err    error
+}
+
+func newExpressionBuilder() *expressionBuilder {
+	return &expressionBuilder{
+		names:  map[string]*string{},
+		values: map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+// name returns the placeholder of a document path. Each element is an attribute name or a map key
+func (b *expressionBuilder) name(path ...string) string {
+	placeholders := make([]string, 0, len(path))
+	for _, name := range path {
+		placeholder := fmt.Sprintf("#n%v", len(b.names))
+		b.names[placeholder] = aws.String(name)
+		placeholders = append(placeholders, placeholder)
+	}
+	return strings.Join(placeholders, ".")
+}
+
+// value returns the placeholder of a value
+func (b *expressionBuilder) value(v interface{}) string {
+	placeholder := fmt.Sprintf(":v%v", len(b.values))
+	av, ok := v.(*dynamodb.AttributeValue)
+	if !ok {
+		var err error
+		av, err = encoder.Encode(v)
+		if err != nil && b.err == nil {
+			b.err = err
+		}
+	}
+	b.values[placeholder] = av
+	return placeholder
+}
+
+// build returns the names and values for the request, nil if empty because DynamoDB rejects empty maps
+func (b *expressionBuilder) build() (map[string]*string, map[string]*dynamodb.AttributeValue, error) {
+	var names map[string]*string
+	var values map[string]*dynamodb.AttributeValue
+	if len(b.names) > 0 {
+		names = b.names
+	}
+	if len(b.values) > 0 {
+		values = b.values
+	}
+	return names, values, b.err
+}
+
+// newPageToken encodes the LastEvaluatedKey returned by DynamoDB as the token of next page
+func newPageToken(lastEvaluatedKey map[string]*dynamodb.AttributeValue) ([]byte, error) {
+	if len(lastEvaluatedKey) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(lastEvaluatedKey)
+}
+
+// decodePageToken decodes the token into the ExclusiveStartKey of DynamoDB, it's nil if token is empty
+func decodePageToken(token []byte) (map[string]*dynamodb.AttributeValue, error) {
+	if len(token) == 0 {
+		return nil, nil
+	}
+	var key map[string]*dynamodb.AttributeValue
+	if err := json.Unmarshal(token, &key); err != nil {
+		return nil, fmt.Errorf("invalid page token: %v", err)
+	}
+	return key, nil
+}
+
+// query reads the items starting from the pageToken, until it reaches the limit(no limit if it's not positive)
+// or there is no more items. It returns the items and the token for the next page, which is nil if there is no more items
+func (db *ddb) query(
+	ctx context.Context,
+	input *dynamodb.QueryInput,
+	limit int,
+	pageToken []byte,
+) ([]map[string]*dynamodb.AttributeValue, []byte, error) {
+	startKey, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	input.ExclusiveStartKey = startKey
+	var items []map[string]*dynamodb.AttributeValue
+	for {
+		if limit > 0 {
+			input.Limit = aws.Int64(int64(limit - len(items)))
+		}
+		output, err := db.client.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := db.resolveBlobs(ctx, output.Items...); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 || (limit > 0 && len(items) >= limit) {
+			nextPageToken, err := newPageToken(output.LastEvaluatedKey)
+			return items, nextPageToken, err
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// scan is the same as query, but reads the whole table
+func (db *ddb) scan(
+	ctx context.Context,
+	input *dynamodb.ScanInput,
+	limit int,
+	pageToken []byte,
+) ([]map[string]*dynamodb.AttributeValue, []byte, error) {
+	startKey, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	input.ExclusiveStartKey = startKey
+	var items []map[string]*dynamodb.AttributeValue
+	for {
+		if limit > 0 {
+			input.Limit = aws.Int64(int64(limit - len(items)))
+		}
+		output, err := db.client.ScanWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := db.resolveBlobs(ctx, output.Items...); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 || (limit > 0 && len(items) >= limit) {
+			nextPageToken, err := newPageToken(output.LastEvaluatedKey)
+			return items, nextPageToken, err
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// count returns the number of items matched by the query
+func (db *ddb) count(ctx context.Context, input *dynamodb.QueryInput) (int64, error) {
+	input.Select = aws.String(dynamodb.SelectCount)
+	var count int64
+	err := db.client.QueryPagesWithContext(ctx, input, func(output *dynamodb.QueryOutput, lastPage bool) bool {
+		count += aws.Int64Value(output.Count)
+		return true
+	})
+	return count, err
+}
+
+// getItem reads an item by the key into out, return errItemNotFound if it doesn't exist
+func (db *ddb) getItem(
+	ctx context.Context,
+	table string,
+	key map[string]*dynamodb.AttributeValue,
+	out interface{},
+) error {
+	output, err := db.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      db.tableName(table),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if output.Item == nil {
+		return errItemNotFound
+	}
+	if err := db.resolveBlobs(ctx, output.Item); err != nil {
+		return err
+	}
+	return unmarshalItem(output.Item, out)
+}
+
+func (db *ddb) deleteItem(
+	ctx context.Context,
+	table string,
+	key map[string]*dynamodb.AttributeValue,
+) error {
+	_, err := db.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: db.tableName(table),
+		Key:       key,
+	})
+	return err
+}
+
+// rangeDelete deletes all the items matched by the query. The items are deleted in batches and it's not atomic
+func (db *ddb) rangeDelete(
+	ctx context.Context,
+	table string,
+	input *dynamodb.QueryInput,
+	keyAttributes ...string,
+) error {
+	input.TableName = db.tableName(table)
+	input.ConsistentRead = aws.Bool(true)
+	var keys []map[string]*dynamodb.AttributeValue
+	err := db.client.QueryPagesWithContext(ctx, input, func(output *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range output.Items {
+			key := make(map[string]*dynamodb.AttributeValue, len(keyAttributes))
+			for _, attr := range keyAttributes {
+				key[attr] = item[attr]
+			}
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return db.batchDelete(ctx, table, keys)
+}
+
+func (db *ddb) batchDelete(
+	ctx context.Context,
+	table string,
+	keys []map[string]*dynamodb.AttributeValue,
+) error {
+	tableName := aws.StringValue(db.tableName(table))
+	for start := 0; start < len(keys); start += maxBatchWriteItems {
+		end := start + maxBatchWriteItems
+		if end > len(keys) {
+			end = len(keys)
+		}
+		requests := make([]*dynamodb.WriteRequest, 0, end-start)
+		for _, key := range keys[start:end] {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: key},
+			})
+		}
+		if err := db.batchWrite(ctx, map[string][]*dynamodb.WriteRequest{tableName: requests}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchPut writes the items in batches without conditions. The items can be of different tables and it's not atomic
+func (db *ddb) batchPut(ctx context.Context, puts []*dynamodb.Put) error {
+	for start := 0; start < len(puts); start += maxBatchWriteItems {
+		end := start + maxBatchWriteItems
+		if end > len(puts) {
+			end = len(puts)
+		}
+		requests := map[string][]*dynamodb.WriteRequest{}
+		for _, put := range puts[start:end] {
+			tableName := aws.StringValue(put.TableName)
+			requests[tableName] = append(requests[tableName], &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{Item: put.Item},
+			})
+		}
+		if err := db.batchWrite(ctx, requests); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchWrite executes a BatchWriteItem request until all the items are processed
+func (db *ddb) batchWrite(ctx context.Context, pending map[string][]*dynamodb.WriteRequest) error {
+	for len(pending) > 0 {
+		output, err := db.client.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return err
+		}
+		pending = output.UnprocessedItems
+	}
+	return nil
+}
+
+// taskIDRangeCondition returns the key condition of taskID in range of (exclusiveMinTaskID, inclusiveMaxTaskID].
+// It returns false if the range is empty, because DynamoDB rejects a BETWEEN condition with reversed bounds
+func taskIDRangeCondition(builder *expressionBuilder, exclusiveMinTaskID, inclusiveMaxTaskID int64) (string, bool) {
+	if exclusiveMinTaskID >= inclusiveMaxTaskID {
+		return "", false
+	}
+	return fmt.Sprintf("%v BETWEEN %v AND %v",
+		builder.name("taskid"), builder.value(exclusiveMinTaskID+1), builder.value(inclusiveMaxTaskID)), true
+}
+
+// putItem writes the item, and the condition is applied if it's not empty
+func (db *ddb) putItem(
+	ctx context.Context,
+	table string,
+	in interface{},
+	condition string,
+	builder *expressionBuilder,
+) error {
+	item, err := db.newPutItem(table, in, condition, builder)
+	if err != nil {
+		return err
+	}
+	_, err = db.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                 item.Put.TableName,
+		Item:                      item.Put.Item,
+		ConditionExpression:       item.Put.ConditionExpression,
+		ExpressionAttributeNames:  item.Put.ExpressionAttributeNames,
+		ExpressionAttributeValues: item.Put.ExpressionAttributeValues,
+	})
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// newQueryInput returns a strongly consistent query on the table. The filterExpression is optional
+func (db *ddb) newQueryInput(
+	table string,
+	builder *expressionBuilder,
+	keyCondition string,
+	filterExpression string,
+) (*dynamodb.QueryInput, error) {
+	names, values, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.QueryInput{
+		TableName:                 db.tableName(table),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ConsistentRead:            aws.Bool(true),
+	}
+	if filterExpression != "" {
+		input.FilterExpression = aws.String(filterExpression)
+	}
+	return input, nil
+}
+
+// newPutItem returns a Put of transaction, and the condition is applied if it's not empty
+func (db *ddb) newPutItem(
+	table string,
+	in interface{},
+	condition string,
+	builder *expressionBuilder,
+) (*dynamodb.TransactWriteItem, error) {
+	item, err := marshalItem(in)
+	if err != nil {
+		return nil, err
+	}
+	put := &dynamodb.Put{
+		TableName: db.tableName(table),
+		Item:      item,
+	}
+	if condition != "" {
+		put.ConditionExpression = aws.String(condition)
+		put.ExpressionAttributeNames, put.ExpressionAttributeValues, err = builder.build()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &dynamodb.TransactWriteItem{Put: put}, nil
+}
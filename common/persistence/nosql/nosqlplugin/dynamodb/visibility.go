@@ -1,4 +1,4 @@
-// Copyright (c) 2021 Uber Technologies, Inc.
+// Copyright (c) 2020 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -22,8 +22,16 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 func (db *ddb) InsertVisibility(
@@ -31,7 +39,11 @@ func (db *ddb) InsertVisibility(
 	ttlSeconds int64,
 	row *nosqlplugin.VisibilityRowForInsert,
 ) error {
-	panic("TODO")
+	visibilityRow := row.VisibilityRow
+	// open record doesn't have close status
+	visibilityRow.Status = nil
+	item := toVisibilityItem(row.DomainID, ttlSeconds, &visibilityRow)
+	return db.putItem(ctx, cadence.VisibilityTableName, item, "", nil)
 }
 
 func (db *ddb) UpdateVisibility(
@@ -39,26 +51,188 @@ func (db *ddb) UpdateVisibility(
 	ttlSeconds int64,
 	row *nosqlplugin.VisibilityRowForUpdate,
 ) error {
-	panic("TODO")
+	if row.UpdateCloseToOpen {
+		// TODO implement it when where is a need
+		return &types.InternalServiceError{
+			Message: "unsupported operation",
+		}
+	}
+	// UpdateOpenToClose is ignored because open and closed records are stored in the same table
+	item := toVisibilityItem(row.DomainID, ttlSeconds, &row.VisibilityRow)
+	return db.putItem(ctx, cadence.VisibilityTableName, item, "", nil)
+}
+
+func (db *ddb) UpsertVisibility(
+	ctx context.Context,
+	ttlSeconds int64,
+	row *nosqlplugin.VisibilityRowForUpsert,
+) error {
+	// search attributes are not indexed
+	return persistence.ErrVisibilityOperationNotSupported
 }
 
 func (db *ddb) SelectVisibility(
 	ctx context.Context,
 	filter *nosqlplugin.VisibilityFilter,
 ) (*nosqlplugin.SelectVisibilityResponse, error) {
-	panic("TODO")
+	request := &filter.ListRequest
+	builder := newExpressionBuilder()
+
+	var filterExpression string
+	switch filter.FilterType {
+	case nosqlplugin.AllOpen, nosqlplugin.OpenByWorkflowType, nosqlplugin.OpenByWorkflowID:
+		filterExpression = fmt.Sprintf("attribute_not_exists(%v)", builder.name("closestatus"))
+		if filter.SortType != nosqlplugin.SortByStartTime {
+			return nil, &types.InternalServiceError{
+				Message: "open workflows can only be sorted by start time",
+			}
+		}
+	case nosqlplugin.AllClosed, nosqlplugin.ClosedByWorkflowType, nosqlplugin.ClosedByWorkflowID:
+		filterExpression = fmt.Sprintf("attribute_exists(%v)", builder.name("closestatus"))
+	case nosqlplugin.ClosedByClosedStatus:
+		filterExpression = fmt.Sprintf("%v = %v", builder.name("closestatus"), builder.value(filter.CloseStatus))
+	default:
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("unsupported filter type: %v", filter.FilterType),
+		}
+	}
+
+	switch filter.FilterType {
+	case nosqlplugin.OpenByWorkflowType, nosqlplugin.ClosedByWorkflowType:
+		filterExpression += fmt.Sprintf(" AND %v = %v", builder.name("workflowtype"), builder.value(filter.WorkflowType))
+	case nosqlplugin.OpenByWorkflowID, nosqlplugin.ClosedByWorkflowID:
+		filterExpression += fmt.Sprintf(" AND %v = %v", builder.name("workflowid"), builder.value(filter.WorkflowID))
+	}
+
+	var indexName, indexKey string
+	switch filter.SortType {
+	case nosqlplugin.SortByStartTime:
+		indexName = cadence.VisibilityStartTimeIndexName
+		indexKey = "startkey"
+	case nosqlplugin.SortByClosedTime:
+		indexName = cadence.VisibilityCloseTimeIndexName
+		indexKey = "closekey"
+	default:
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("unsupported sort type: %v", filter.SortType),
+		}
+	}
+	// index keys are prefixed by the time, and the range is inclusive on both ends
+	keyCondition := fmt.Sprintf("%v = %v AND %v BETWEEN %v AND %v",
+		builder.name("domainid"), builder.value(request.DomainUUID),
+		builder.name(indexKey), builder.value(sortableTime(request.EarliestTime)),
+		builder.value(compositeKey(sortableTime(request.LatestTime), "~")))
+
+	input, err := db.newQueryInput(cadence.VisibilityTableName, builder, keyCondition, filterExpression)
+	if err != nil {
+		return nil, err
+	}
+	input.IndexName = &indexName
+	// records are ordered by time DESC, and global secondary indexes don't support consistent read
+	input.ConsistentRead = nil
+	input.ScanIndexForward = common.BoolPtr(false)
+	avs, nextPageToken, err := db.query(ctx, input, request.PageSize, request.NextPageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &nosqlplugin.SelectVisibilityResponse{
+		Executions:    make([]*nosqlplugin.VisibilityRow, 0, len(avs)),
+		NextPageToken: nextPageToken,
+	}
+	for _, av := range avs {
+		var item cadence.VisibilityItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		response.Executions = append(response.Executions, fromVisibilityItem(&item))
+	}
+	return response, nil
 }
 
 func (db *ddb) DeleteVisibility(
 	ctx context.Context,
 	domainID, workflowID, runID string,
 ) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.VisibilityTableName, visibilityKey(domainID, runID))
 }
 
 func (db *ddb) SelectOneClosedWorkflow(
 	ctx context.Context,
 	domainID, workflowID, runID string,
 ) (*nosqlplugin.VisibilityRow, error) {
-	panic("TODO")
+	var item cadence.VisibilityItem
+	err := db.getItem(ctx, cadence.VisibilityTableName, visibilityKey(domainID, runID), &item)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			// Special case: return nil,nil if not found(since we will deprecate it, it's not worth refactor to be consistent)
+			return nil, nil
+		}
+		return nil, err
+	}
+	if item.CloseStatus == nil || item.WorkflowID != workflowID {
+		return nil, nil
+	}
+	return fromVisibilityItem(&item), nil
+}
+
+func visibilityKey(domainID, runID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"domainid": stringAttr(domainID),
+		"runid":    stringAttr(runID),
+	}
+}
+
+func toVisibilityItem(domainID string, ttlSeconds int64, row *nosqlplugin.VisibilityRow) *cadence.VisibilityItem {
+	memo, memoEncoding := persistence.FromDataBlob(row.Memo)
+	item := &cadence.VisibilityItem{
+		DomainID:      domainID,
+		RunID:         row.RunID,
+		StartKey:      compositeKey(sortableTime(row.StartTime), row.RunID),
+		WorkflowID:    row.WorkflowID,
+		WorkflowType:  row.TypeName,
+		StartTime:     row.StartTime,
+		ExecutionTime: row.ExecutionTime,
+		HistoryLength: row.HistoryLength,
+		Memo:          memo,
+		MemoEncoding:  memoEncoding,
+		TaskList:      row.TaskList,
+		IsCron:        row.IsCron,
+		NumClusters:   row.NumClusters,
+		UpdateTime:    row.UpdateTime,
+		ShardID:       row.ShardID,
+	}
+	// only closed records have close time, so that closetime_index is sparse
+	if row.Status != nil {
+		item.CloseKey = compositeKey(sortableTime(row.CloseTime), row.RunID)
+		item.CloseTime = common.TimePtr(row.CloseTime)
+		item.CloseStatus = row.Status
+	}
+	if ttlSeconds > 0 {
+		item.ExpireTime = time.Now().Unix() + ttlSeconds
+	}
+	return item
+}
+
+func fromVisibilityItem(item *cadence.VisibilityItem) *nosqlplugin.VisibilityRow {
+	row := &nosqlplugin.VisibilityRow{
+		DomainID:      item.DomainID,
+		WorkflowID:    item.WorkflowID,
+		RunID:         item.RunID,
+		TypeName:      item.WorkflowType,
+		StartTime:     item.StartTime,
+		ExecutionTime: item.ExecutionTime,
+		Status:        item.CloseStatus,
+		HistoryLength: item.HistoryLength,
+		Memo:          persistence.NewDataBlob(item.Memo, common.EncodingType(item.MemoEncoding)),
+		TaskList:      item.TaskList,
+		IsCron:        item.IsCron,
+		NumClusters:   item.NumClusters,
+		UpdateTime:    item.UpdateTime,
+		ShardID:       item.ShardID,
+	}
+	if item.CloseTime != nil {
+		row.CloseTime = *item.CloseTime
+	}
+	return row
 }
@@ -23,10 +23,18 @@ package dynamodb
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
 )
 
 var _ nosqlplugin.WorkflowCRUD = (*ddb)(nil)
@@ -41,7 +49,31 @@ func (db *ddb) InsertWorkflowExecutionWithTasks(
 	timerTasks []*nosqlplugin.TimerTask,
 	shardCondition *nosqlplugin.ShardCondition,
 ) error {
-	panic("TODO")
+	shardID := shardCondition.ShardID
+	shardItem, err := db.newShardConditionItem(shardCondition)
+	if err != nil {
+		return err
+	}
+	currentWorkflowItem, err := db.newCurrentWorkflowItem(shardID, execution.DomainID, execution.WorkflowID, currentWorkflowRequest)
+	if err != nil {
+		return err
+	}
+	executionItem, err := db.newCreateWorkflowExecutionItem(shardID, execution)
+	if err != nil {
+		return err
+	}
+	taskItems, err := db.newCreateTaskItems(shardID, transferTasks, crossClusterTasks, replicationTasks, timerTasks)
+	if err != nil {
+		return err
+	}
+
+	// the shard condition goes first so that it takes precedence over other conditions
+	items := []*transactItem{shardItem}
+	if currentWorkflowItem != nil {
+		items = append(items, currentWorkflowItem)
+	}
+	items = append(items, executionItem)
+	return db.transactWriteWithTasks(ctx, items, taskItems)
 }
 
 func (db *ddb) UpdateWorkflowExecutionWithTasks(
@@ -56,105 +88,482 @@ func (db *ddb) UpdateWorkflowExecutionWithTasks(
 	timerTasks []*nosqlplugin.TimerTask,
 	shardCondition *nosqlplugin.ShardCondition,
 ) error {
-	panic("TODO")
+	var domainID, workflowID string
+	if mutatedExecution != nil {
+		if mutatedExecution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeUpdate {
+			return fmt.Errorf("should only support WorkflowExecutionMapsWriteModeUpdate for mutatedExecution")
+		}
+		domainID = mutatedExecution.DomainID
+		workflowID = mutatedExecution.WorkflowID
+	} else if resetExecution != nil {
+		if resetExecution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeReset ||
+			resetExecution.EventBufferWriteMode != nosqlplugin.EventBufferWriteModeClear {
+			return fmt.Errorf("should only support WorkflowExecutionMapsWriteModeReset and EventBufferWriteModeClear for resetExecution")
+		}
+		domainID = resetExecution.DomainID
+		workflowID = resetExecution.WorkflowID
+	} else {
+		return fmt.Errorf("at least one of mutatedExecution and resetExecution should be provided")
+	}
+
+	shardID := shardCondition.ShardID
+	shardItem, err := db.newShardConditionItem(shardCondition)
+	if err != nil {
+		return err
+	}
+	items := []*transactItem{shardItem}
+	currentWorkflowItem, err := db.newCurrentWorkflowItem(shardID, domainID, workflowID, currentWorkflowRequest)
+	if err != nil {
+		return err
+	}
+	if currentWorkflowItem != nil {
+		items = append(items, currentWorkflowItem)
+	}
+	if mutatedExecution != nil {
+		item, err := db.newUpdateWorkflowExecutionItem(shardID, mutatedExecution)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if insertedExecution != nil {
+		item, err := db.newCreateWorkflowExecutionItem(shardID, insertedExecution)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if resetExecution != nil {
+		item, err := db.newUpdateWorkflowExecutionItem(shardID, resetExecution)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	taskItems, err := db.newCreateTaskItems(shardID, transferTasks, crossClusterTasks, replicationTasks, timerTasks)
+	if err != nil {
+		return err
+	}
+	return db.transactWriteWithTasks(ctx, items, taskItems)
+}
+
+// transactWriteWithTasks writes the items and the task items in a transaction, the first item must be the shard
+// condition. The task items that don't fit into the transaction because of the item limit of DynamoDB are written by
+// the following transactions, each of them conditioned on the shard range, so that no task is written unless the
+// items are. If the shard is stolen in between, the shard ownership lost error is returned as for a single transaction,
+// but the tasks of the failed transactions are lost
+func (db *ddb) transactWriteWithTasks(ctx context.Context, items []*transactItem, taskItems []*transactItem) error {
+	size := maxTransactWriteItems - len(items)
+	if size < 0 {
+		return fmt.Errorf("transaction of %v items exceeds the limit of %v items", len(items), maxTransactWriteItems)
+	}
+	if size > len(taskItems) {
+		size = len(taskItems)
+	}
+	transaction := make([]*transactItem, 0, len(items)+size)
+	transaction = append(transaction, items...)
+	if err := db.transactWrite(ctx, append(transaction, taskItems[:size]...)); err != nil {
+		return err
+	}
+
+	shardItem := items[0]
+	for taskItems = taskItems[size:]; len(taskItems) > 0; taskItems = taskItems[size:] {
+		size = maxTransactWriteItems - 1
+		if size > len(taskItems) {
+			size = len(taskItems)
+		}
+		transaction = append([]*transactItem{shardItem}, taskItems[:size]...)
+		if err := db.transactWrite(ctx, transaction); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (db *ddb) SelectCurrentWorkflow(ctx context.Context, shardID int, domainID, workflowID string) (*nosqlplugin.CurrentWorkflowRow, error) {
-	panic("TODO")
+	var item cadence.CurrentWorkflowItem
+	if err := db.getItem(ctx, cadence.CurrentWorkflowTableName, currentWorkflowKey(shardID, domainID, workflowID), &item); err != nil {
+		return nil, err
+	}
+	return &nosqlplugin.CurrentWorkflowRow{
+		ShardID:          shardID,
+		DomainID:         domainID,
+		WorkflowID:       workflowID,
+		RunID:            item.RunID,
+		State:            item.State,
+		CloseStatus:      item.CloseStatus,
+		CreateRequestID:  item.CreateRequestID,
+		LastWriteVersion: item.LastWriteVersion,
+	}, nil
 }
 
 func (db *ddb) SelectWorkflowExecution(ctx context.Context, shardID int, domainID, workflowID, runID string) (*nosqlplugin.WorkflowExecution, error) {
-	panic("TODO")
+	var item cadence.WorkflowExecutionItem
+	if err := db.getItem(ctx, cadence.WorkflowExecutionTableName, workflowExecutionKey(shardID, domainID, workflowID, runID), &item); err != nil {
+		return nil, err
+	}
+	return fromWorkflowExecutionItem(&item)
 }
 
 func (db *ddb) DeleteCurrentWorkflow(ctx context.Context, shardID int, domainID, workflowID, currentRunIDCondition string) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	condition := fmt.Sprintf("%v = %v", builder.name("runid"), builder.value(currentRunIDCondition))
+	names, values, err := builder.build()
+	if err != nil {
+		return err
+	}
+	_, err = db.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 db.tableName(cadence.CurrentWorkflowTableName),
+		Key:                       currentWorkflowKey(shardID, domainID, workflowID),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		// it's a noop if the current run is not the one to delete
+		return nil
+	}
+	return err
 }
 
 func (db *ddb) DeleteWorkflowExecution(ctx context.Context, shardID int, domainID, workflowID, runID string) error {
-	panic("TODO")
+	if err := db.deleteItem(ctx, cadence.WorkflowExecutionTableName, workflowExecutionKey(shardID, domainID, workflowID, runID)); err != nil {
+		return err
+	}
+	return db.deleteBlobs(ctx, workflowExecutionBlobOwnerKey(shardID, domainID, workflowID, runID), "")
 }
 
 func (db *ddb) SelectAllCurrentWorkflows(ctx context.Context, shardID int, pageToken []byte, pageSize int) ([]*persistence.CurrentWorkflowExecution, []byte, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	input, err := db.newQueryInput(cadence.CurrentWorkflowTableName, builder, shardIDCondition(builder, shardID), "")
+	if err != nil {
+		return nil, nil, err
+	}
+	avs, nextPageToken, err := db.query(ctx, input, pageSize, pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var executions []*persistence.CurrentWorkflowExecution
+	for _, av := range avs {
+		var item cadence.CurrentWorkflowItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		executions = append(executions, &persistence.CurrentWorkflowExecution{
+			DomainID:     item.DomainID,
+			WorkflowID:   item.WorkflowID,
+			RunID:        item.RunID,
+			State:        item.State,
+			CurrentRunID: item.RunID,
+		})
+	}
+	return executions, nextPageToken, nil
 }
 
 func (db *ddb) SelectAllWorkflowExecutions(ctx context.Context, shardID int, pageToken []byte, pageSize int) ([]*persistence.InternalListConcreteExecutionsEntity, []byte, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := shardIDCondition(builder, shardID)
+	projection := strings.Join([]string{
+		builder.name("execution"),
+		builder.name("versionhistories"),
+		builder.name("versionhistoriesencoding"),
+	}, ", ")
+	input, err := db.newQueryInput(cadence.WorkflowExecutionTableName, builder, keyCondition, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	input.ProjectionExpression = aws.String(projection)
+	avs, nextPageToken, err := db.query(ctx, input, pageSize, pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var executions []*persistence.InternalListConcreteExecutionsEntity
+	for _, av := range avs {
+		var item cadence.WorkflowExecutionItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		executions = append(executions, &persistence.InternalListConcreteExecutionsEntity{
+			ExecutionInfo:    item.Execution,
+			VersionHistories: persistence.NewDataBlob(item.VersionHistories, common.EncodingType(item.VersionHistoriesEncoding)),
+		})
+	}
+	return executions, nextPageToken, nil
 }
 
 func (db *ddb) IsWorkflowExecutionExists(ctx context.Context, shardID int, domainID, workflowID, runID string) (bool, error) {
-	panic("TODO")
+	output, err := db.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:            db.tableName(cadence.WorkflowExecutionTableName),
+		Key:                  workflowExecutionKey(shardID, domainID, workflowID, runID),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("shardid"),
+	})
+	if err != nil {
+		return false, err
+	}
+	return output.Item != nil, nil
 }
 
 func (db *ddb) SelectTransferTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.TransferTask, []byte, error) {
-	panic("TODO")
+	avs, nextPageToken, err := db.selectTasksOrderByTaskID(ctx, cadence.TransferTaskTableName, "shardid", shardID, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.TransferTask
+	for _, av := range avs {
+		var item cadence.TransferTaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, item.Task)
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *ddb) DeleteTransferTask(ctx context.Context, shardID int, taskID int64) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.TransferTaskTableName, map[string]*dynamodb.AttributeValue{
+		"shardid": numberAttr(int64(shardID)),
+		"taskid":  numberAttr(taskID),
+	})
 }
 
 func (db *ddb) RangeDeleteTransferTasks(ctx context.Context, shardID int, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	return db.rangeDeleteTasks(ctx, cadence.TransferTaskTableName, "shardid", shardID, exclusiveBeginTaskID, inclusiveEndTaskID)
 }
 
 func (db *ddb) SelectTimerTasksOrderByVisibilityTime(ctx context.Context, shardID, pageSize int, pageToken []byte, inclusiveMinTime, exclusiveMaxTime time.Time) ([]*nosqlplugin.TimerTask, []byte, error) {
-	panic("TODO")
+	if !inclusiveMinTime.Before(exclusiveMaxTime) {
+		return nil, nil, nil
+	}
+	builder := newExpressionBuilder()
+	input, err := db.newQueryInput(cadence.TimerTaskTableName, builder, timerTaskKeyCondition(builder, shardID, inclusiveMinTime, exclusiveMaxTime), "")
+	if err != nil {
+		return nil, nil, err
+	}
+	avs, nextPageToken, err := db.query(ctx, input, pageSize, pageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []*nosqlplugin.TimerTask
+	for _, av := range avs {
+		var item cadence.TimerTaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, item.Task)
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *ddb) DeleteTimerTask(ctx context.Context, shardID int, taskID int64, visibilityTimestamp time.Time) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.TimerTaskTableName, map[string]*dynamodb.AttributeValue{
+		"shardid":  numberAttr(int64(shardID)),
+		"timerkey": stringAttr(timerTaskKey(visibilityTimestamp.UnixNano(), taskID)),
+	})
 }
 
 func (db *ddb) RangeDeleteTimerTasks(ctx context.Context, shardID int, inclusiveMinTime, exclusiveMaxTime time.Time) error {
-	panic("TODO")
+	if !inclusiveMinTime.Before(exclusiveMaxTime) {
+		return nil
+	}
+	builder := newExpressionBuilder()
+	input, err := db.newQueryInput(cadence.TimerTaskTableName, builder, timerTaskKeyCondition(builder, shardID, inclusiveMinTime, exclusiveMaxTime), "")
+	if err != nil {
+		return err
+	}
+	return db.rangeDelete(ctx, cadence.TimerTaskTableName, input, "shardid", "timerkey")
 }
 
 func (db *ddb) SelectReplicationTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.ReplicationTask, []byte, error) {
-	panic("TODO")
+	avs, nextPageToken, err := db.selectTasksOrderByTaskID(ctx, cadence.ReplicationTaskTableName, "shardid", shardID, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.ReplicationTask
+	for _, av := range avs {
+		var item cadence.ReplicationTaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, item.Task)
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *ddb) DeleteReplicationTask(ctx context.Context, shardID int, taskID int64) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.ReplicationTaskTableName, map[string]*dynamodb.AttributeValue{
+		"shardid": numberAttr(int64(shardID)),
+		"taskid":  numberAttr(taskID),
+	})
 }
 
 func (db *ddb) RangeDeleteReplicationTasks(ctx context.Context, shardID int, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v AND %v <= %v",
+		shardIDCondition(builder, shardID), builder.name("taskid"), builder.value(inclusiveEndTaskID))
+	input, err := db.newQueryInput(cadence.ReplicationTaskTableName, builder, keyCondition, "")
+	if err != nil {
+		return err
+	}
+	return db.rangeDelete(ctx, cadence.ReplicationTaskTableName, input, "shardid", "taskid")
 }
 
 func (db *ddb) InsertReplicationTask(ctx context.Context, tasks []*nosqlplugin.ReplicationTask, condition nosqlplugin.ShardCondition) error {
-	panic("TODO")
+	shardItem, err := db.newShardConditionItem(&condition)
+	if err != nil {
+		return err
+	}
+	taskItems, err := db.newCreateTaskItems(condition.ShardID, nil, nil, tasks, nil)
+	if err != nil {
+		return err
+	}
+	err = db.transactWrite(ctx, append([]*transactItem{shardItem}, taskItems...))
+	if conditionFailure, ok := err.(*nosqlplugin.WorkflowOperationConditionFailure); ok && conditionFailure.ShardRangeIDNotMatch != nil {
+		return &nosqlplugin.ShardOperationConditionFailure{
+			RangeID: *conditionFailure.ShardRangeIDNotMatch,
+		}
+	}
+	return err
 }
 
 func (db *ddb) SelectCrossClusterTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, targetCluster string, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.CrossClusterTask, []byte, error) {
-	panic("TODO")
+	shardKey := compositeKey(strconv.Itoa(shardID), targetCluster)
+	avs, nextPageToken, err := db.selectTasksOrderByTaskID(ctx, cadence.CrossClusterTaskTableName, "shardkey", shardKey, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.CrossClusterTask
+	for _, av := range avs {
+		var item cadence.CrossClusterTaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, &nosqlplugin.CrossClusterTask{
+			TransferTask:  *item.Task,
+			TargetCluster: item.TargetCluster,
+		})
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *ddb) DeleteCrossClusterTask(ctx context.Context, shardID int, targetCluster string, taskID int64) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.CrossClusterTaskTableName, map[string]*dynamodb.AttributeValue{
+		"shardkey": stringAttr(compositeKey(strconv.Itoa(shardID), targetCluster)),
+		"taskid":   numberAttr(taskID),
+	})
 }
 
 func (db *ddb) RangeDeleteCrossClusterTasks(ctx context.Context, shardID int, targetCluster string, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	shardKey := compositeKey(strconv.Itoa(shardID), targetCluster)
+	return db.rangeDeleteTasks(ctx, cadence.CrossClusterTaskTableName, "shardkey", shardKey, exclusiveBeginTaskID, inclusiveEndTaskID)
 }
 
 func (db *ddb) InsertReplicationDLQTask(ctx context.Context, shardID int, sourceCluster string, task nosqlplugin.ReplicationTask) error {
-	panic("TODO")
+	return db.putItem(ctx, cadence.ReplicationDLQTaskTableName, &cadence.ReplicationDLQTaskItem{
+		ShardKey:      compositeKey(strconv.Itoa(shardID), sourceCluster),
+		TaskID:        task.TaskID,
+		ShardID:       shardID,
+		SourceCluster: sourceCluster,
+		Task:          &task,
+	}, "", nil)
 }
 
 func (db *ddb) SelectReplicationDLQTasksOrderByTaskID(ctx context.Context, shardID int, sourceCluster string, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.ReplicationTask, []byte, error) {
-	panic("TODO")
+	shardKey := compositeKey(strconv.Itoa(shardID), sourceCluster)
+	avs, nextPageToken, err := db.selectTasksOrderByTaskID(ctx, cadence.ReplicationDLQTaskTableName, "shardkey", shardKey, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.ReplicationTask
+	for _, av := range avs {
+		var item cadence.ReplicationDLQTaskItem
+		if err := unmarshalItem(av, &item); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, item.Task)
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *ddb) SelectReplicationDLQTasksCount(ctx context.Context, shardID int, sourceCluster string) (int64, error) {
-	panic("TODO")
+	builder := newExpressionBuilder()
+	keyCondition := fmt.Sprintf("%v = %v", builder.name("shardkey"), builder.value(compositeKey(strconv.Itoa(shardID), sourceCluster)))
+	input, err := db.newQueryInput(cadence.ReplicationDLQTaskTableName, builder, keyCondition, "")
+	if err != nil {
+		return 0, err
+	}
+	return db.count(ctx, input)
 }
 
 func (db *ddb) DeleteReplicationDLQTask(ctx context.Context, shardID int, sourceCluster string, taskID int64) error {
-	panic("TODO")
+	return db.deleteItem(ctx, cadence.ReplicationDLQTaskTableName, map[string]*dynamodb.AttributeValue{
+		"shardkey": stringAttr(compositeKey(strconv.Itoa(shardID), sourceCluster)),
+		"taskid":   numberAttr(taskID),
+	})
 }
 
 func (db *ddb) RangeDeleteReplicationDLQTasks(ctx context.Context, shardID int, sourceCluster string, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	shardKey := compositeKey(strconv.Itoa(shardID), sourceCluster)
+	return db.rangeDeleteTasks(ctx, cadence.ReplicationDLQTaskTableName, "shardkey", shardKey, exclusiveBeginTaskID, inclusiveEndTaskID)
+}
+
+// selectTasksOrderByTaskID reads a page of tasks in range of (exclusiveMinTaskID, inclusiveMaxTaskID]
+func (db *ddb) selectTasksOrderByTaskID(
+	ctx context.Context,
+	table string,
+	hashKeyName string,
+	hashKey interface{},
+	pageSize int,
+	pageToken []byte,
+	exclusiveMinTaskID int64,
+	inclusiveMaxTaskID int64,
+) ([]map[string]*dynamodb.AttributeValue, []byte, error) {
+	builder := newExpressionBuilder()
+	taskIDCondition, ok := taskIDRangeCondition(builder, exclusiveMinTaskID, inclusiveMaxTaskID)
+	if !ok {
+		return nil, nil, nil
+	}
+	keyCondition := fmt.Sprintf("%v = %v AND %v", builder.name(hashKeyName), builder.value(hashKey), taskIDCondition)
+	input, err := db.newQueryInput(table, builder, keyCondition, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.query(ctx, input, pageSize, pageToken)
+}
+
+// rangeDeleteTasks deletes the tasks in range of (exclusiveBeginTaskID, inclusiveEndTaskID]
+func (db *ddb) rangeDeleteTasks(
+	ctx context.Context,
+	table string,
+	hashKeyName string,
+	hashKey interface{},
+	exclusiveBeginTaskID int64,
+	inclusiveEndTaskID int64,
+) error {
+	builder := newExpressionBuilder()
+	taskIDCondition, ok := taskIDRangeCondition(builder, exclusiveBeginTaskID, inclusiveEndTaskID)
+	if !ok {
+		return nil
+	}
+	keyCondition := fmt.Sprintf("%v = %v AND %v", builder.name(hashKeyName), builder.value(hashKey), taskIDCondition)
+	input, err := db.newQueryInput(table, builder, keyCondition, "")
+	if err != nil {
+		return err
+	}
+	return db.rangeDelete(ctx, table, input, hashKeyName, "taskid")
+}
+
+func shardIDCondition(builder *expressionBuilder, shardID int) string {
+	return fmt.Sprintf("%v = %v", builder.name("shardid"), builder.value(shardID))
+}
+
+// timerTaskKeyCondition returns the key condition of visibility timestamp in range of [inclusiveMinTime, exclusiveMaxTime)
+func timerTaskKeyCondition(builder *expressionBuilder, shardID int, inclusiveMinTime, exclusiveMaxTime time.Time) string {
+	// the upper bound is a prefix of the timer keys of exclusiveMaxTime, so it's smaller than all of them
+	return fmt.Sprintf("%v AND %v BETWEEN %v AND %v",
+		shardIDCondition(builder, shardID), builder.name("timerkey"),
+		builder.value(sortableTime(inclusiveMinTime)), builder.value(sortableTime(exclusiveMaxTime)))
 }
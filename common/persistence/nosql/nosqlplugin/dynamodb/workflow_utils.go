@@ -0,0 +1,485 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamodb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pborman/uuid"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/dynamodb/cadence"
+)
+
+// newCurrentWorkflowItem returns the write of current workflow in the transaction, nil for CurrentWorkflowWriteModeNoop
+func (db *ddb) newCurrentWorkflowItem(
+	shardID int,
+	domainID string,
+	workflowID string,
+	request *nosqlplugin.CurrentWorkflowWriteRequest,
+) (*transactItem, error) {
+	item := &cadence.CurrentWorkflowItem{
+		ShardID:          shardID,
+		WorkflowKey:      compositeKey(domainID, workflowID),
+		DomainID:         domainID,
+		WorkflowID:       workflowID,
+		RunID:            request.Row.RunID,
+		State:            request.Row.State,
+		CloseStatus:      request.Row.CloseStatus,
+		CreateRequestID:  request.Row.CreateRequestID,
+		LastWriteVersion: request.Row.LastWriteVersion,
+	}
+
+	switch request.WriteMode {
+	case nosqlplugin.CurrentWorkflowWriteModeNoop:
+		return nil, nil
+	case nosqlplugin.CurrentWorkflowWriteModeInsert:
+		builder := newExpressionBuilder()
+		put, err := db.newPutItem(cadence.CurrentWorkflowTableName, item,
+			fmt.Sprintf("attribute_not_exists(%v)", builder.name("workflowkey")), builder)
+		if err != nil {
+			return nil, err
+		}
+		return &transactItem{
+			item: put,
+			onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+				var previous cadence.CurrentWorkflowItem
+				if err := unmarshalItem(existing, &previous); err != nil {
+					return err
+				}
+				msg := fmt.Sprintf("Workflow execution already running. WorkflowId: %v, RunId: %v, CreateRequestId: %v",
+					workflowID, previous.RunID, previous.CreateRequestID)
+				return &nosqlplugin.WorkflowOperationConditionFailure{
+					WorkflowExecutionAlreadyExists: &nosqlplugin.WorkflowExecutionAlreadyExists{
+						OtherInfo:        msg,
+						CreateRequestID:  previous.CreateRequestID,
+						RunID:            previous.RunID,
+						State:            previous.State,
+						CloseStatus:      previous.CloseStatus,
+						LastWriteVersion: previous.LastWriteVersion,
+					},
+				}
+			},
+		}, nil
+	case nosqlplugin.CurrentWorkflowWriteModeUpdate:
+		if request.Condition == nil || request.Condition.GetCurrentRunID() == "" {
+			return nil, fmt.Errorf("CurrentWorkflowWriteModeUpdate require Condition.CurrentRunID")
+		}
+		builder := newExpressionBuilder()
+		condition := fmt.Sprintf("%v = %v", builder.name("runid"), builder.value(*request.Condition.CurrentRunID))
+		if request.Condition.LastWriteVersion != nil && request.Condition.State != nil {
+			condition += fmt.Sprintf(" AND %v = %v AND %v = %v",
+				builder.name("lastwriteversion"), builder.value(*request.Condition.LastWriteVersion),
+				builder.name("state"), builder.value(*request.Condition.State))
+		}
+		put, err := db.newPutItem(cadence.CurrentWorkflowTableName, item, condition, builder)
+		if err != nil {
+			return nil, err
+		}
+		return &transactItem{
+			item: put,
+			onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+				var previous cadence.CurrentWorkflowItem
+				if existing != nil {
+					if err := unmarshalItem(existing, &previous); err != nil {
+						return err
+					}
+				}
+				msg := fmt.Sprintf("Workflow execution creation condition failed. WorkflowId: %v, Expected Current RunID: %v, Actual Current RunID: %v, Actual LastWriteVersion: %v, Actual State: %v",
+					workflowID, request.Condition.GetCurrentRunID(), previous.RunID, previous.LastWriteVersion, previous.State)
+				return &nosqlplugin.WorkflowOperationConditionFailure{
+					CurrentWorkflowConditionFailInfo: &msg,
+				}
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %v", request.WriteMode)
+	}
+}
+
+func (db *ddb) newCreateWorkflowExecutionItem(
+	shardID int,
+	execution *nosqlplugin.WorkflowExecutionRequest,
+) (*transactItem, error) {
+	if execution.EventBufferWriteMode != nosqlplugin.EventBufferWriteModeNone {
+		return nil, fmt.Errorf("should only support EventBufferWriteModeNone")
+	}
+	if execution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeCreate {
+		return nil, fmt.Errorf("should only support WorkflowExecutionMapsWriteModeCreate")
+	}
+
+	versionHistories, versionHistoriesEncoding := persistence.FromDataBlob(execution.VersionHistories)
+	info := execution.InternalWorkflowExecutionInfo
+	// maps and lists must not be null so that they can be updated by keys later
+	item := &cadence.WorkflowExecutionItem{
+		ShardID:                  shardID,
+		ExecutionKey:             compositeKey(execution.DomainID, execution.WorkflowID, execution.RunID),
+		DomainID:                 execution.DomainID,
+		WorkflowID:               execution.WorkflowID,
+		RunID:                    execution.RunID,
+		NextEventID:              execution.NextEventID,
+		LastWriteVersion:         execution.LastWriteVersion,
+		Execution:                &info,
+		VersionHistories:         versionHistories,
+		VersionHistoriesEncoding: versionHistoriesEncoding,
+		ActivityInfos:            toStringKeys(execution.ActivityInfos),
+		TimerInfos:               nonNilMap(execution.TimerInfos),
+		ChildExecutionInfos:      toStringKeys(execution.ChildWorkflowInfos),
+		RequestCancelInfos:       toStringKeys(execution.RequestCancelInfos),
+		SignalInfos:              toStringKeys(execution.SignalInfos),
+		SignalRequestedIDs:       toSignalRequestedIDs(execution.SignalRequestedIDs),
+		BufferedEvents:           []*persistence.DataBlob{},
+	}
+	if execution.Checksums != nil {
+		item.Checksum = *execution.Checksums
+	}
+
+	builder := newExpressionBuilder()
+	put, err := db.newPutItem(cadence.WorkflowExecutionTableName, item,
+		fmt.Sprintf("attribute_not_exists(%v)", builder.name("executionkey")), builder)
+	if err != nil {
+		return nil, err
+	}
+	return &transactItem{
+		item:      put,
+		blobOwner: newWorkflowExecutionBlobOwner(shardID, execution),
+		onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+			var previous cadence.WorkflowExecutionItem
+			if err := unmarshalItem(existing, &previous); err != nil {
+				return err
+			}
+			msg := fmt.Sprintf("Workflow execution already running. WorkflowId: %v, RunId: %v",
+				execution.WorkflowID, execution.RunID)
+			return &nosqlplugin.WorkflowOperationConditionFailure{
+				WorkflowExecutionAlreadyExists: &nosqlplugin.WorkflowExecutionAlreadyExists{
+					OtherInfo:        msg,
+					CreateRequestID:  execution.CreateRequestID,
+					RunID:            execution.RunID,
+					State:            execution.State,
+					CloseStatus:      execution.CloseStatus,
+					LastWriteVersion: previous.LastWriteVersion,
+				},
+			}
+		},
+	}, nil
+}
+
+// newUpdateWorkflowExecutionItem updates the workflow execution with the condition of nextEventID
+// For WorkflowExecutionMapsWriteModeUpdate, maps are merged and deleted by keys;
+// for WorkflowExecutionMapsWriteModeReset, maps are overridden by the whole
+func (db *ddb) newUpdateWorkflowExecutionItem(
+	shardID int,
+	execution *nosqlplugin.WorkflowExecutionRequest,
+) (*transactItem, error) {
+	versionHistories, versionHistoriesEncoding := persistence.FromDataBlob(execution.VersionHistories)
+	info := execution.InternalWorkflowExecutionInfo
+
+	builder := newExpressionBuilder()
+	var set, remove []string
+	setValue := func(value interface{}, path ...string) {
+		set = append(set, fmt.Sprintf("%v = %v", builder.name(path...), builder.value(value)))
+	}
+	setValue(execution.NextEventID, "nexteventid")
+	setValue(execution.LastWriteVersion, "lastwriteversion")
+	setValue(&info, "execution")
+	setValue(versionHistories, "versionhistories")
+	setValue(versionHistoriesEncoding, "versionhistoriesencoding")
+	if execution.Checksums != nil {
+		setValue(execution.Checksums, "checksum")
+	}
+
+	switch execution.MapsWriteMode {
+	case nosqlplugin.WorkflowExecutionMapsWriteModeUpdate:
+		// the same key cannot be both set and removed in one update, deletion wins as it's applied after update in other databases
+		setMapEntries(setValue, "activityinfos", toStringKeys(execution.ActivityInfos), int64Keys(execution.ActivityInfoKeysToDelete))
+		setMapEntries(setValue, "timerinfos", execution.TimerInfos, execution.TimerInfoKeysToDelete)
+		setMapEntries(setValue, "childexecutioninfos", toStringKeys(execution.ChildWorkflowInfos), int64Keys(execution.ChildWorkflowInfoKeysToDelete))
+		setMapEntries(setValue, "requestcancelinfos", toStringKeys(execution.RequestCancelInfos), int64Keys(execution.RequestCancelInfoKeysToDelete))
+		setMapEntries(setValue, "signalinfos", toStringKeys(execution.SignalInfos), int64Keys(execution.SignalInfoKeysToDelete))
+		setMapEntries(setValue, "signalrequestedids", toSignalRequestedIDs(execution.SignalRequestedIDs), execution.SignalRequestedIDsKeysToDelete)
+
+		removeKeys := func(attribute string, keys []string) {
+			for _, key := range keys {
+				remove = append(remove, builder.name(attribute, key))
+			}
+		}
+		removeKeys("activityinfos", int64Keys(execution.ActivityInfoKeysToDelete))
+		removeKeys("timerinfos", execution.TimerInfoKeysToDelete)
+		removeKeys("childexecutioninfos", int64Keys(execution.ChildWorkflowInfoKeysToDelete))
+		removeKeys("requestcancelinfos", int64Keys(execution.RequestCancelInfoKeysToDelete))
+		removeKeys("signalinfos", int64Keys(execution.SignalInfoKeysToDelete))
+		removeKeys("signalrequestedids", execution.SignalRequestedIDsKeysToDelete)
+	case nosqlplugin.WorkflowExecutionMapsWriteModeReset:
+		setValue(toStringKeys(execution.ActivityInfos), "activityinfos")
+		setValue(nonNilMap(execution.TimerInfos), "timerinfos")
+		setValue(toStringKeys(execution.ChildWorkflowInfos), "childexecutioninfos")
+		setValue(toStringKeys(execution.RequestCancelInfos), "requestcancelinfos")
+		setValue(toStringKeys(execution.SignalInfos), "signalinfos")
+		setValue(toSignalRequestedIDs(execution.SignalRequestedIDs), "signalrequestedids")
+	default:
+		return nil, fmt.Errorf("unsupported WorkflowExecutionMapsWriteMode %v", execution.MapsWriteMode)
+	}
+
+	switch execution.EventBufferWriteMode {
+	case nosqlplugin.EventBufferWriteModeNone:
+	case nosqlplugin.EventBufferWriteModeAppend:
+		name := builder.name("bufferedevents")
+		set = append(set, fmt.Sprintf("%v = list_append(%v, %v)",
+			name, name, builder.value([]*persistence.DataBlob{execution.NewBufferedEventBatch})))
+	case nosqlplugin.EventBufferWriteModeClear:
+		setValue([]*persistence.DataBlob{}, "bufferedevents")
+	default:
+		return nil, fmt.Errorf("unsupported EventBufferWriteMode %v", execution.EventBufferWriteMode)
+	}
+
+	update := "SET " + strings.Join(set, ", ")
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+	condition := fmt.Sprintf("%v = %v", builder.name("nexteventid"), builder.value(*execution.PreviousNextEventIDCondition))
+	names, values, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	return &transactItem{
+		item: &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				TableName:                 db.tableName(cadence.WorkflowExecutionTableName),
+				Key:                       workflowExecutionKey(shardID, execution.DomainID, execution.WorkflowID, execution.RunID),
+				UpdateExpression:          aws.String(update),
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			},
+		},
+		blobOwner: newWorkflowExecutionBlobOwner(shardID, execution),
+		onConditionFailure: func(existing map[string]*dynamodb.AttributeValue) error {
+			var previous cadence.WorkflowExecutionItem
+			if existing != nil {
+				if err := unmarshalItem(existing, &previous); err != nil {
+					return err
+				}
+			}
+			msg := fmt.Sprintf("Failed to update mutable state. previousNextEventIDCondition: %v, actualNextEventID: %v, Request Current RunID: %v",
+				*execution.PreviousNextEventIDCondition, previous.NextEventID, execution.RunID)
+			return &nosqlplugin.WorkflowOperationConditionFailure{
+				UnknownConditionFailureDetails: &msg,
+			}
+		},
+	}, nil
+}
+
+// newWorkflowExecutionBlobOwner returns the blob owner of a write of the workflow execution, every write has its own
+// blob key prefix because the blobs of previous writes may still be referenced by the entries not updated by this write
+func newWorkflowExecutionBlobOwner(shardID int, execution *nosqlplugin.WorkflowExecutionRequest) *blobOwner {
+	return &blobOwner{
+		key:    workflowExecutionBlobOwnerKey(shardID, execution.DomainID, execution.WorkflowID, execution.RunID),
+		prefix: uuid.New(),
+	}
+}
+
+func workflowExecutionBlobOwnerKey(shardID int, domainID, workflowID, runID string) string {
+	return compositeKey(cadence.WorkflowExecutionTableName, strconv.Itoa(shardID), domainID, workflowID, runID)
+}
+
+// setMapEntries sets the entries of a map attribute by keys, except for the keys to delete
+func setMapEntries[V any](setValue func(value interface{}, path ...string), attribute string, entries map[string]V, keysToDelete []string) {
+	deleted := make(map[string]struct{}, len(keysToDelete))
+	for _, key := range keysToDelete {
+		deleted[key] = struct{}{}
+	}
+	for key, value := range entries {
+		if _, ok := deleted[key]; !ok {
+			setValue(value, attribute, key)
+		}
+	}
+}
+
+func (db *ddb) newCreateTaskItems(
+	shardID int,
+	transferTasks []*nosqlplugin.TransferTask,
+	crossClusterTasks []*nosqlplugin.CrossClusterTask,
+	replicationTasks []*nosqlplugin.ReplicationTask,
+	timerTasks []*nosqlplugin.TimerTask,
+) ([]*transactItem, error) {
+	var items []*transactItem
+	addItem := func(table string, in interface{}) error {
+		put, err := db.newPutItem(table, in, "", nil)
+		if err != nil {
+			return err
+		}
+		items = append(items, &transactItem{item: put})
+		return nil
+	}
+
+	for _, task := range transferTasks {
+		if err := addItem(cadence.TransferTaskTableName, &cadence.TransferTaskItem{
+			ShardID: shardID,
+			TaskID:  task.TaskID,
+			Task:    task,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, task := range crossClusterTasks {
+		transferTask := task.TransferTask
+		if err := addItem(cadence.CrossClusterTaskTableName, &cadence.CrossClusterTaskItem{
+			ShardKey:      compositeKey(strconv.Itoa(shardID), task.TargetCluster),
+			TaskID:        task.TaskID,
+			ShardID:       shardID,
+			TargetCluster: task.TargetCluster,
+			Task:          &transferTask,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, task := range replicationTasks {
+		if err := addItem(cadence.ReplicationTaskTableName, &cadence.ReplicationTaskItem{
+			ShardID: shardID,
+			TaskID:  task.TaskID,
+			Task:    task,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, task := range timerTasks {
+		if err := addItem(cadence.TimerTaskTableName, &cadence.TimerTaskItem{
+			ShardID:             shardID,
+			TimerKey:            timerTaskKey(task.VisibilityTimestamp.UnixNano(), task.TaskID),
+			VisibilityTimestamp: task.VisibilityTimestamp,
+			TaskID:              task.TaskID,
+			Task:                task,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func currentWorkflowKey(shardID int, domainID, workflowID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"shardid":     numberAttr(int64(shardID)),
+		"workflowkey": stringAttr(compositeKey(domainID, workflowID)),
+	}
+}
+
+func workflowExecutionKey(shardID int, domainID, workflowID, runID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"shardid":      numberAttr(int64(shardID)),
+		"executionkey": stringAttr(compositeKey(domainID, workflowID, runID)),
+	}
+}
+
+// timerTaskKey makes the timer tasks sorted by visibilityTimestamp, taskID
+func timerTaskKey(visibilityTimestamp int64, taskID int64) string {
+	return compositeKey(sortableInt(visibilityTimestamp), sortableInt(taskID))
+}
+
+func fromWorkflowExecutionItem(item *cadence.WorkflowExecutionItem) (*nosqlplugin.WorkflowExecution, error) {
+	activityInfos, err := toInt64Keys(item.ActivityInfos)
+	if err != nil {
+		return nil, err
+	}
+	childExecutionInfos, err := toInt64Keys(item.ChildExecutionInfos)
+	if err != nil {
+		return nil, err
+	}
+	requestCancelInfos, err := toInt64Keys(item.RequestCancelInfos)
+	if err != nil {
+		return nil, err
+	}
+	signalInfos, err := toInt64Keys(item.SignalInfos)
+	if err != nil {
+		return nil, err
+	}
+	signalRequestedIDs := make(map[string]struct{}, len(item.SignalRequestedIDs))
+	for id := range item.SignalRequestedIDs {
+		signalRequestedIDs[id] = struct{}{}
+	}
+	bufferedEvents := item.BufferedEvents
+	if bufferedEvents == nil {
+		bufferedEvents = []*persistence.DataBlob{}
+	}
+	return &nosqlplugin.WorkflowExecution{
+		ExecutionInfo:       item.Execution,
+		VersionHistories:    persistence.NewDataBlob(item.VersionHistories, common.EncodingType(item.VersionHistoriesEncoding)),
+		ActivityInfos:       activityInfos,
+		TimerInfos:          nonNilMap(item.TimerInfos),
+		ChildExecutionInfos: childExecutionInfos,
+		RequestCancelInfos:  requestCancelInfos,
+		SignalInfos:         signalInfos,
+		SignalRequestedIDs:  signalRequestedIDs,
+		BufferedEvents:      bufferedEvents,
+		Checksum:            item.Checksum,
+	}, nil
+}
+
+// toStringKeys converts the int64 keys of map into strings, because DynamoDB only supports string keys.
+// The result is never nil so that the map can be updated by keys later
+func toStringKeys[V any](m map[int64]V) map[string]V {
+	result := make(map[string]V, len(m))
+	for k, v := range m {
+		result[strconv.FormatInt(k, 10)] = v
+	}
+	return result
+}
+
+func toInt64Keys[V any](m map[string]V) (map[int64]V, error) {
+	result := make(map[int64]V, len(m))
+	for k, v := range m {
+		key, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid map key %v: %v", k, err)
+		}
+		result[key] = v
+	}
+	return result, nil
+}
+
+func int64Keys(keys []int64) []string {
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, strconv.FormatInt(k, 10))
+	}
+	return result
+}
+
+func nonNilMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return map[string]V{}
+	}
+	return m
+}
+
+// toSignalRequestedIDs converts the IDs into a map, because a string set of DynamoDB cannot be empty
+func toSignalRequestedIDs(ids []string) map[string]bool {
+	result := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result
+}
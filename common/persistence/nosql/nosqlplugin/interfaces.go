@@ -248,6 +248,9 @@ type (
 	VisibilityCRUD interface {
 		InsertVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForInsert) error
 		UpdateVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForUpdate) error
+		// UpsertVisibility updates the indexed search attributes of an open workflow.
+		// Return ErrVisibilityOperationNotSupported if search attributes are not indexed by the implementation
+		UpsertVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForUpsert) error
 		SelectVisibility(ctx context.Context, filter *VisibilityFilter) (*SelectVisibilityResponse, error)
 		DeleteVisibility(ctx context.Context, domainID, workflowID, runID string) error
 		// TODO deprecated this in the future in favor of SelectVisibility
@@ -258,11 +261,17 @@ type (
 	VisibilityRowForInsert struct {
 		VisibilityRow
 		DomainID string
+		// IndexedSearchAttributes are the values of the search attributes which can be queried by equality,
+		// keyed by search attribute. Values are encoded as text: strings as they are, numbers and bools as JSON literals,
+		// and elements of arrays are indexed separately
+		IndexedSearchAttributes map[string][]string
 	}
 
 	VisibilityRowForUpdate struct {
 		VisibilityRow
 		DomainID string
+		// Same as VisibilityRowForInsert
+		IndexedSearchAttributes map[string][]string
 		// NOTE: this is only for some implementation (e.g. Cassandra) that uses multiple tables,
 		// they needs to delete record from the open execution table. Ignore this field if not need it
 		UpdateOpenToClose bool
@@ -270,6 +279,13 @@ type (
 		UpdateCloseToOpen bool
 	}
 
+	VisibilityRowForUpsert struct {
+		VisibilityRow
+		DomainID string
+		// Same as VisibilityRowForInsert
+		IndexedSearchAttributes map[string][]string
+	}
+
 	// TODO separate in the future when need it
 	VisibilityRow = persistence.InternalVisibilityWorkflowExecutionInfo
 
@@ -287,6 +303,9 @@ type (
 		WorkflowType string
 		WorkflowID   string
 		CloseStatus  int32
+		// SearchAttributeKey and SearchAttributeValue are for filtering by an indexed search attribute
+		SearchAttributeKey   string
+		SearchAttributeValue string
 	}
 
 	VisibilityFilterType int
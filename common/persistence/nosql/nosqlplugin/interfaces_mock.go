@@ -1269,6 +1269,20 @@ func (mr *MockDBMockRecorder) UpdateWorkflowExecutionWithTasks(ctx, currentWorkf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWorkflowExecutionWithTasks", reflect.TypeOf((*MockDB)(nil).UpdateWorkflowExecutionWithTasks), ctx, currentWorkflowRequest, mutatedExecution, insertedExecution, resetExecution, transferTasks, crossClusterTasks, replicationTasks, timerTasks, shardCondition)
 }
 
+// UpsertVisibility mocks base method.
+func (m *MockDB) UpsertVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForUpsert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertVisibility", ctx, ttlSeconds, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertVisibility indicates an expected call of UpsertVisibility.
+func (mr *MockDBMockRecorder) UpsertVisibility(ctx, ttlSeconds, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertVisibility", reflect.TypeOf((*MockDB)(nil).UpsertVisibility), ctx, ttlSeconds, row)
+}
+
 // MocktableCRUD is a mock of tableCRUD interface.
 type MocktableCRUD struct {
 	ctrl     *gomock.Controller
@@ -2314,6 +2328,20 @@ func (mr *MocktableCRUDMockRecorder) UpdateWorkflowExecutionWithTasks(ctx, curre
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWorkflowExecutionWithTasks", reflect.TypeOf((*MocktableCRUD)(nil).UpdateWorkflowExecutionWithTasks), ctx, currentWorkflowRequest, mutatedExecution, insertedExecution, resetExecution, transferTasks, crossClusterTasks, replicationTasks, timerTasks, shardCondition)
 }
 
+// UpsertVisibility mocks base method.
+func (m *MocktableCRUD) UpsertVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForUpsert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertVisibility", ctx, ttlSeconds, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertVisibility indicates an expected call of UpsertVisibility.
+func (mr *MocktableCRUDMockRecorder) UpsertVisibility(ctx, ttlSeconds, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertVisibility", reflect.TypeOf((*MocktableCRUD)(nil).UpsertVisibility), ctx, ttlSeconds, row)
+}
+
 // MockClientErrorChecker is a mock of ClientErrorChecker interface.
 type MockClientErrorChecker struct {
 	ctrl     *gomock.Controller
@@ -2960,6 +2988,20 @@ func (mr *MockVisibilityCRUDMockRecorder) UpdateVisibility(ctx, ttlSeconds, row
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisibility", reflect.TypeOf((*MockVisibilityCRUD)(nil).UpdateVisibility), ctx, ttlSeconds, row)
 }
 
+// UpsertVisibility mocks base method.
+func (m *MockVisibilityCRUD) UpsertVisibility(ctx context.Context, ttlSeconds int64, row *VisibilityRowForUpsert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertVisibility", ctx, ttlSeconds, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertVisibility indicates an expected call of UpsertVisibility.
+func (mr *MockVisibilityCRUDMockRecorder) UpsertVisibility(ctx, ttlSeconds, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertVisibility", reflect.TypeOf((*MockVisibilityCRUD)(nil).UpsertVisibility), ctx, ttlSeconds, row)
+}
+
 // MockTaskCRUD is a mock of TaskCRUD interface.
 type MockTaskCRUD struct {
 	ctrl     *gomock.Controller
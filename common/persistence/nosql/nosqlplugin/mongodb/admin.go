@@ -58,14 +58,14 @@ func (db *mdb) SetupTestDatabase(schemaBaseDir string) error {
 	for _, cmd := range commands {
 		result := db.dbConn.RunCommand(context.Background(), cmd)
 		if result.Err() != nil {
-			return err
+			return result.Err()
 		}
 	}
 	return nil
 }
 
 func (db *mdb) TeardownTestDatabase() error {
-	result := db.dbConn.RunCommand(context.Background(), bson.D{{"dropDatabase", 1}})
+	result := db.dbConn.RunCommand(context.Background(), bson.D{{Key: "dropDatabase", Value: 1}})
 	err := result.Err()
 	return err
 }
@@ -24,6 +24,9 @@ import (
 	"context"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log"
@@ -47,3 +50,22 @@ func (db *mdb) Close() {
 func (db *mdb) PluginName() string {
 	return PluginName
 }
+
+// executeTransaction runs fn within a multi-document transaction.
+// Any error returned from fn(including write errors) aborts the whole transaction.
+// NOTE: MongoDB only supports transactions on a replica set or a sharded cluster.
+func (db *mdb) executeTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := db.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	txnOptions := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	}, txnOptions)
+	return err
+}
@@ -22,17 +22,81 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/mongodb/cadence"
+)
+
+const (
+	domainMetadataDocumentID = "cadence-domain-metadata"
 )
 
-// Insert a new record to domain, return error if failed or already exists
-// Return ConditionFailure if the condition doesn't meet
+// Insert a new record to domain
+// return types.DomainAlreadyExistsError error if failed or already exists
+// Must return ConditionFailure error if other condition doesn't match
 func (db *mdb) InsertDomain(
 	ctx context.Context,
 	row *nosqlplugin.DomainRow,
 ) error {
-	panic("TODO")
+	domainCollection := db.dbConn.Collection(cadence.DomainCollectionName)
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		count, err := domainCollection.CountDocuments(sessCtx, bson.M{"name": row.Info.Name})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			db.logger.Warn(fmt.Sprintf("Domain %v already exists", row.Info.Name))
+			return &types.DomainAlreadyExistsError{
+				Message: fmt.Sprintf("Domain %v already exists", row.Info.Name),
+			}
+		}
+
+		metadataNotificationVersion, err := db.SelectDomainMetadata(sessCtx)
+		if err != nil {
+			return err
+		}
+
+		doc := toDomainCollectionEntry(row)
+		doc.FailoverNotificationVersion = persistence.InitialFailoverNotificationVersion
+		doc.PreviousFailoverVersion = common.InitialPreviousFailoverVersion
+		doc.NotificationVersion = metadataNotificationVersion
+		if _, err := domainCollection.InsertOne(sessCtx, doc); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("CreateDomain operation failed because of uuid collision")
+			}
+			return err
+		}
+
+		return db.updateDomainMetadata(sessCtx, metadataNotificationVersion)
+	})
+}
+
+// updateDomainMetadata increases the notification version by one if current version matches
+func (db *mdb) updateDomainMetadata(
+	sessCtx mongo.SessionContext,
+	notificationVersion int64,
+) error {
+	collection := db.dbConn.Collection(cadence.DomainMetadataCollectionName)
+	_, err := collection.UpdateOne(sessCtx,
+		bson.M{"_id": domainMetadataDocumentID, "notificationversion": notificationVersion},
+		bson.M{"$set": bson.M{"notificationversion": notificationVersion + 1}},
+		// upsert will fail with duplicate key error if current version doesn't match
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		db.logger.Warn("Domain operation failed because of condition update failure on domain metadata record")
+		return nosqlplugin.NewConditionFailure("domain")
+	}
+	return err
 }
 
 // Update domain
@@ -40,7 +104,22 @@ func (db *mdb) UpdateDomain(
 	ctx context.Context,
 	row *nosqlplugin.DomainRow,
 ) error {
-	panic("TODO")
+	domainCollection := db.dbConn.Collection(cadence.DomainCollectionName)
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := db.updateDomainMetadata(sessCtx, row.NotificationVersion); err != nil {
+			return err
+		}
+
+		doc := toDomainCollectionEntry(row)
+		result, err := domainCollection.ReplaceOne(sessCtx, bson.M{"_id": row.Info.ID, "name": row.Info.Name}, doc)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return nosqlplugin.NewConditionFailure("domain")
+		}
+		return nil
+	})
 }
 
 // Get one domain data, either by domainID or domainName
@@ -49,7 +128,23 @@ func (db *mdb) SelectDomain(
 	domainID *string,
 	domainName *string,
 ) (*nosqlplugin.DomainRow, error) {
-	panic("TODO")
+	var filter bson.M
+	if domainID != nil && domainName != nil {
+		return nil, fmt.Errorf("GetDomain operation failed.  Both ID and Name specified in request")
+	} else if domainID == nil && domainName == nil {
+		return nil, fmt.Errorf("GetDomain operation failed.  Both ID and Name are empty")
+	} else if domainID != nil {
+		filter = bson.M{"_id": *domainID}
+	} else {
+		filter = bson.M{"name": *domainName}
+	}
+
+	var doc cadence.DomainCollectionEntry
+	collection := db.dbConn.Collection(cadence.DomainCollectionName)
+	if err := collection.FindOne(ctx, filter).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return fromDomainCollectionEntry(&doc), nil
 }
 
 // Get all domain data
@@ -58,7 +153,40 @@ func (db *mdb) SelectAllDomains(
 	pageSize int,
 	pageToken []byte,
 ) ([]*nosqlplugin.DomainRow, []byte, error) {
-	panic("TODO")
+	filter := bson.M{}
+	var lastName string
+	hasToken, err := decodePageToken(pageToken, &lastName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		filter["name"] = bson.M{"$gt": lastName}
+	}
+
+	collection := db.dbConn.Collection(cadence.DomainCollectionName)
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.DomainCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]*nosqlplugin.DomainRow, 0, len(docs))
+	for _, doc := range docs {
+		rows = append(rows, fromDomainCollectionEntry(doc))
+	}
+	var nextPageToken []byte
+	if len(docs) > 0 && len(docs) == pageSize {
+		nextPageToken, err = newPageToken(docs[len(docs)-1].Name)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rows, nextPageToken, nil
 }
 
 // Delete a domain, either by domainID or domainName
@@ -67,11 +195,102 @@ func (db *mdb) DeleteDomain(
 	domainID *string,
 	domainName *string,
 ) error {
-	panic("TODO")
+	var filter bson.M
+	if domainID != nil {
+		filter = bson.M{"_id": *domainID}
+	} else if domainName != nil {
+		filter = bson.M{"name": *domainName}
+	} else {
+		return fmt.Errorf("must provide either domainID or domainName")
+	}
+
+	collection := db.dbConn.Collection(cadence.DomainCollectionName)
+	_, err := collection.DeleteOne(ctx, filter)
+	return err
 }
 
 func (db *mdb) SelectDomainMetadata(
 	ctx context.Context,
 ) (int64, error) {
-	panic("TODO")
+	var doc cadence.DomainMetadataCollectionEntry
+	collection := db.dbConn.Collection(cadence.DomainMetadataCollectionName)
+	err := collection.FindOne(ctx, bson.M{"_id": domainMetadataDocumentID}).Decode(&doc)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			// this error can be thrown in the very beginning, when there is no domain created yet
+			return 0, nil
+		}
+		return -1, err
+	}
+	return doc.NotificationVersion, nil
+}
+
+func toDomainCollectionEntry(row *nosqlplugin.DomainRow) *cadence.DomainCollectionEntry {
+	badBinaries, badBinariesEncoding := persistence.FromDataBlob(row.Config.BadBinaries)
+	isolationGroups, isolationGroupsEncoding := persistence.FromDataBlob(row.Config.IsolationGroups)
+	asyncWFConfig, asyncWFConfigEncoding := persistence.FromDataBlob(row.Config.AsyncWorkflowsConfig)
+	doc := &cadence.DomainCollectionEntry{
+		ID:   row.Info.ID,
+		Name: row.Info.Name,
+		Info: row.Info,
+		Config: &cadence.DomainConfig{
+			RetentionDays:                common.DurationToDays(row.Config.Retention),
+			EmitMetric:                   row.Config.EmitMetric,
+			ArchivalBucket:               row.Config.ArchivalBucket,
+			ArchivalStatus:               row.Config.ArchivalStatus,
+			HistoryArchivalStatus:        row.Config.HistoryArchivalStatus,
+			HistoryArchivalURI:           row.Config.HistoryArchivalURI,
+			VisibilityArchivalStatus:     row.Config.VisibilityArchivalStatus,
+			VisibilityArchivalURI:        row.Config.VisibilityArchivalURI,
+			BadBinaries:                  badBinaries,
+			BadBinariesEncoding:          badBinariesEncoding,
+			IsolationGroups:              isolationGroups,
+			IsolationGroupsEncoding:      isolationGroupsEncoding,
+			AsyncWorkflowsConfig:         asyncWFConfig,
+			AsyncWorkflowsConfigEncoding: asyncWFConfigEncoding,
+		},
+		ReplicationConfig:           row.ReplicationConfig,
+		IsGlobalDomain:              row.IsGlobalDomain,
+		ConfigVersion:               row.ConfigVersion,
+		FailoverVersion:             row.FailoverVersion,
+		FailoverNotificationVersion: row.FailoverNotificationVersion,
+		PreviousFailoverVersion:     row.PreviousFailoverVersion,
+		LastUpdatedTime:             row.LastUpdatedTime.UnixNano(),
+		NotificationVersion:         row.NotificationVersion,
+	}
+	if row.FailoverEndTime != nil {
+		doc.FailoverEndTime = common.Int64Ptr(row.FailoverEndTime.UnixNano())
+	}
+	return doc
+}
+
+func fromDomainCollectionEntry(doc *cadence.DomainCollectionEntry) *nosqlplugin.DomainRow {
+	row := &nosqlplugin.DomainRow{
+		Info: doc.Info,
+		Config: &nosqlplugin.NoSQLInternalDomainConfig{
+			Retention:                common.DaysToDuration(doc.Config.RetentionDays),
+			EmitMetric:               doc.Config.EmitMetric,
+			ArchivalBucket:           doc.Config.ArchivalBucket,
+			ArchivalStatus:           doc.Config.ArchivalStatus,
+			HistoryArchivalStatus:    doc.Config.HistoryArchivalStatus,
+			HistoryArchivalURI:       doc.Config.HistoryArchivalURI,
+			VisibilityArchivalStatus: doc.Config.VisibilityArchivalStatus,
+			VisibilityArchivalURI:    doc.Config.VisibilityArchivalURI,
+			BadBinaries:              persistence.NewDataBlob(doc.Config.BadBinaries, common.EncodingType(doc.Config.BadBinariesEncoding)),
+			IsolationGroups:          persistence.NewDataBlob(doc.Config.IsolationGroups, common.EncodingType(doc.Config.IsolationGroupsEncoding)),
+			AsyncWorkflowsConfig:     persistence.NewDataBlob(doc.Config.AsyncWorkflowsConfig, common.EncodingType(doc.Config.AsyncWorkflowsConfigEncoding)),
+		},
+		ReplicationConfig:           doc.ReplicationConfig,
+		IsGlobalDomain:              doc.IsGlobalDomain,
+		ConfigVersion:               doc.ConfigVersion,
+		FailoverVersion:             doc.FailoverVersion,
+		FailoverNotificationVersion: doc.FailoverNotificationVersion,
+		PreviousFailoverVersion:     doc.PreviousFailoverVersion,
+		LastUpdatedTime:             time.Unix(0, doc.LastUpdatedTime),
+		NotificationVersion:         doc.NotificationVersion,
+	}
+	if doc.FailoverEndTime != nil {
+		row.FailoverEndTime = common.TimePtr(time.Unix(0, *doc.FailoverEndTime))
+	}
+	return row
 }
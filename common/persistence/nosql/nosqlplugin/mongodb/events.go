@@ -22,31 +22,249 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/mongodb/cadence"
 )
 
 // InsertIntoHistoryTreeAndNode inserts one or two rows: tree row and node row(at least one of them)
 func (db *mdb) InsertIntoHistoryTreeAndNode(ctx context.Context, treeRow *nosqlplugin.HistoryTreeRow, nodeRow *nosqlplugin.HistoryNodeRow) error {
-	panic("TODO")
+	if treeRow == nil && nodeRow == nil {
+		return fmt.Errorf("require at least a tree row or a node row to insert")
+	}
+
+	if treeRow != nil && nodeRow != nil {
+		return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+			if err := db.upsertHistoryTree(sessCtx, treeRow); err != nil {
+				return err
+			}
+			return db.upsertHistoryNode(sessCtx, nodeRow)
+		})
+	}
+	if treeRow != nil {
+		return db.upsertHistoryTree(ctx, treeRow)
+	}
+	return db.upsertHistoryNode(ctx, nodeRow)
+}
+
+func (db *mdb) upsertHistoryTree(ctx context.Context, treeRow *nosqlplugin.HistoryTreeRow) error {
+	ancestors := make([]*cadence.HistoryBranchRange, 0, len(treeRow.Ancestors))
+	for _, an := range treeRow.Ancestors {
+		ancestors = append(ancestors, &cadence.HistoryBranchRange{
+			BranchID:  an.BranchID,
+			EndNodeID: an.EndNodeID,
+		})
+	}
+	doc := cadence.HistoryTreeCollectionEntry{
+		ShardID:         treeRow.ShardID,
+		TreeID:          treeRow.TreeID,
+		BranchID:        treeRow.BranchID,
+		Ancestors:       ancestors,
+		CreateTimestamp: treeRow.CreateTimestamp,
+		Info:            treeRow.Info,
+	}
+	collection := db.dbConn.Collection(cadence.HistoryTreeCollectionName)
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"treeid": treeRow.TreeID, "branchid": treeRow.BranchID},
+		doc,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (db *mdb) upsertHistoryNode(ctx context.Context, nodeRow *nosqlplugin.HistoryNodeRow) error {
+	doc := cadence.HistoryNodeCollectionEntry{
+		ShardID:      nodeRow.ShardID,
+		TreeID:       nodeRow.TreeID,
+		BranchID:     nodeRow.BranchID,
+		NodeID:       nodeRow.NodeID,
+		TxnID:        *nodeRow.TxnID,
+		Data:         nodeRow.Data,
+		DataEncoding: nodeRow.DataEncoding,
+	}
+	collection := db.dbConn.Collection(cadence.HistoryNodeCollectionName)
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"treeid": nodeRow.TreeID, "branchid": nodeRow.BranchID, "nodeid": nodeRow.NodeID, "txnid": *nodeRow.TxnID},
+		doc,
+		options.Replace().SetUpsert(true),
+	)
+	return err
 }
 
 // SelectFromHistoryNode read nodes based on a filter
 func (db *mdb) SelectFromHistoryNode(ctx context.Context, filter *nosqlplugin.HistoryNodeFilter) ([]*nosqlplugin.HistoryNodeRow, []byte, error) {
-	panic("TODO")
+	nodeIDFilter := bson.M{"$gte": filter.MinNodeID, "$lt": filter.MaxNodeID}
+	query := bson.M{"treeid": filter.TreeID, "branchid": filter.BranchID, "nodeid": nodeIDFilter}
+
+	var lastNodeID, lastTxnID int64
+	hasToken, err := decodePageToken(filter.NextPageToken, &lastNodeID, &lastTxnID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		// nodes are ordered by nodeID ASC, txnID DESC
+		query["$or"] = bson.A{
+			bson.M{"nodeid": bson.M{"$gt": lastNodeID}},
+			bson.M{"nodeid": lastNodeID, "txnid": bson.M{"$lt": lastTxnID}},
+		}
+	}
+
+	collection := db.dbConn.Collection(cadence.HistoryNodeCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "nodeid", Value: 1}, {Key: "txnid", Value: -1}}).
+		SetLimit(int64(filter.PageSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.HistoryNodeCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	var rows []*nosqlplugin.HistoryNodeRow
+	for _, doc := range docs {
+		txnID := doc.TxnID
+		rows = append(rows, &nosqlplugin.HistoryNodeRow{
+			ShardID:      doc.ShardID,
+			TreeID:       doc.TreeID,
+			BranchID:     doc.BranchID,
+			NodeID:       doc.NodeID,
+			TxnID:        &txnID,
+			Data:         doc.Data,
+			DataEncoding: doc.DataEncoding,
+		})
+	}
+	var pagingToken []byte
+	if len(docs) > 0 && len(docs) == filter.PageSize {
+		last := docs[len(docs)-1]
+		pagingToken, err = newPageToken(last.NodeID, last.TxnID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rows, pagingToken, nil
 }
 
 // DeleteFromHistoryTreeAndNode delete a branch record, and a list of ranges of nodes.
 func (db *mdb) DeleteFromHistoryTreeAndNode(ctx context.Context, treeFilter *nosqlplugin.HistoryTreeFilter, nodeFilters []*nosqlplugin.HistoryNodeFilter) error {
-	panic("TODO")
+	treeCollection := db.dbConn.Collection(cadence.HistoryTreeCollectionName)
+	nodeCollection := db.dbConn.Collection(cadence.HistoryNodeCollectionName)
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		treeQuery := bson.M{"treeid": treeFilter.TreeID}
+		if treeFilter.BranchID != nil {
+			treeQuery["branchid"] = *treeFilter.BranchID
+		}
+		if _, err := treeCollection.DeleteMany(sessCtx, treeQuery); err != nil {
+			return err
+		}
+		for _, nodeFilter := range nodeFilters {
+			_, err := nodeCollection.DeleteMany(sessCtx, bson.M{
+				"treeid":   nodeFilter.TreeID,
+				"branchid": nodeFilter.BranchID,
+				"nodeid":   bson.M{"$gte": nodeFilter.MinNodeID},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // SelectAllHistoryTrees will return all tree branches with pagination
 func (db *mdb) SelectAllHistoryTrees(ctx context.Context, nextPageToken []byte, pageSize int) ([]*nosqlplugin.HistoryTreeRow, []byte, error) {
-	panic("TODO")
+	query := bson.M{}
+	var lastTreeID, lastBranchID string
+	hasToken, err := decodePageToken(nextPageToken, &lastTreeID, &lastBranchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		query["$or"] = bson.A{
+			bson.M{"treeid": bson.M{"$gt": lastTreeID}},
+			bson.M{"treeid": lastTreeID, "branchid": bson.M{"$gt": lastBranchID}},
+		}
+	}
+
+	collection := db.dbConn.Collection(cadence.HistoryTreeCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "treeid", Value: 1}, {Key: "branchid", Value: 1}}).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.HistoryTreeCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	var rows []*nosqlplugin.HistoryTreeRow
+	for _, doc := range docs {
+		rows = append(rows, fromHistoryTreeCollectionEntry(doc))
+	}
+	var pagingToken []byte
+	if len(docs) > 0 && len(docs) == pageSize {
+		last := docs[len(docs)-1]
+		pagingToken, err = newPageToken(last.TreeID, last.BranchID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rows, pagingToken, nil
 }
 
 // SelectFromHistoryTree read branch records for a tree
 func (db *mdb) SelectFromHistoryTree(ctx context.Context, filter *nosqlplugin.HistoryTreeFilter) ([]*nosqlplugin.HistoryTreeRow, error) {
-	panic("TODO")
+	query := bson.M{"treeid": filter.TreeID}
+	if filter.BranchID != nil {
+		query["branchid"] = *filter.BranchID
+	}
+	collection := db.dbConn.Collection(cadence.HistoryTreeCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "branchid", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []*cadence.HistoryTreeCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var rows []*nosqlplugin.HistoryTreeRow
+	for _, doc := range docs {
+		rows = append(rows, fromHistoryTreeCollectionEntry(doc))
+	}
+	return rows, nil
+}
+
+func fromHistoryTreeCollectionEntry(doc *cadence.HistoryTreeCollectionEntry) *nosqlplugin.HistoryTreeRow {
+	ancestors := make([]*types.HistoryBranchRange, 0, len(doc.Ancestors))
+	for _, an := range doc.Ancestors {
+		ancestors = append(ancestors, &types.HistoryBranchRange{
+			BranchID:  an.BranchID,
+			EndNodeID: an.EndNodeID,
+		})
+	}
+	if len(ancestors) > 0 {
+		// sort ancestors based on EndNodeID so that we can set BeginNodeID
+		sort.Slice(ancestors, func(i, j int) bool { return ancestors[i].EndNodeID < ancestors[j].EndNodeID })
+		ancestors[0].BeginNodeID = int64(1)
+		for i := 1; i < len(ancestors); i++ {
+			ancestors[i].BeginNodeID = ancestors[i-1].EndNodeID
+		}
+	}
+	return &nosqlplugin.HistoryTreeRow{
+		ShardID:         doc.ShardID,
+		TreeID:          doc.TreeID,
+		BranchID:        doc.BranchID,
+		Ancestors:       ancestors,
+		CreateTimestamp: doc.CreateTimestamp,
+		Info:            doc.Info,
+	}
 }
@@ -22,10 +22,14 @@ package mongodb
 
 import (
 	"context"
-	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/mongodb/cadence"
 )
 
 // Insert message into queue, return error if failed or already exists
@@ -34,7 +38,17 @@ func (db *mdb) InsertIntoQueue(
 	ctx context.Context,
 	row *nosqlplugin.QueueMessageRow,
 ) error {
-	panic("TODO")
+	doc := cadence.QueueMessageCollectionEntry{
+		QueueType: row.QueueType,
+		MessageID: row.ID,
+		Payload:   row.Payload,
+	}
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return nosqlplugin.NewConditionFailure("queue")
+	}
+	return err
 }
 
 // Get the ID of last message inserted into the queue
@@ -42,7 +56,16 @@ func (db *mdb) SelectLastEnqueuedMessageID(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (int64, error) {
-	panic("TODO")
+	var doc cadence.QueueMessageCollectionEntry
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	err := collection.FindOne(ctx,
+		bson.M{"queuetype": queueType},
+		options.FindOne().SetSort(bson.D{{Key: "messageid", Value: -1}}),
+	).Decode(&doc)
+	if err != nil {
+		return 0, err
+	}
+	return doc.MessageID, nil
 }
 
 // Read queue messages starting from the exclusiveBeginMessageID
@@ -52,7 +75,19 @@ func (db *mdb) SelectMessagesFrom(
 	exclusiveBeginMessageID int64,
 	maxRows int,
 ) ([]*nosqlplugin.QueueMessageRow, error) {
-	panic("TODO")
+	docs, err := db.selectQueueMessages(ctx,
+		bson.M{"queuetype": queueType, "messageid": bson.M{"$gt": exclusiveBeginMessageID}},
+		maxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*nosqlplugin.QueueMessageRow
+	for _, doc := range docs {
+		result = append(result, &nosqlplugin.QueueMessageRow{QueueType: doc.QueueType, ID: doc.MessageID, Payload: doc.Payload})
+	}
+	return result, nil
 }
 
 // Read queue message starting from exclusiveBeginMessageID int64, inclusiveEndMessageID int64
@@ -60,7 +95,61 @@ func (db *mdb) SelectMessagesBetween(
 	ctx context.Context,
 	request nosqlplugin.SelectMessagesBetweenRequest,
 ) (*nosqlplugin.SelectMessagesBetweenResponse, error) {
-	panic("TODO")
+	exclusiveBeginMessageID := request.ExclusiveBeginMessageID
+	var lastMessageID int64
+	hasToken, err := decodePageToken(request.NextPageToken, &lastMessageID)
+	if err != nil {
+		return nil, err
+	}
+	if hasToken && lastMessageID > exclusiveBeginMessageID {
+		exclusiveBeginMessageID = lastMessageID
+	}
+
+	docs, err := db.selectQueueMessages(ctx,
+		bson.M{
+			"queuetype": request.QueueType,
+			"messageid": bson.M{"$gt": exclusiveBeginMessageID, "$lte": request.InclusiveEndMessageID},
+		},
+		request.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []nosqlplugin.QueueMessageRow
+	for _, doc := range docs {
+		rows = append(rows, nosqlplugin.QueueMessageRow{QueueType: doc.QueueType, ID: doc.MessageID, Payload: doc.Payload})
+	}
+	var nextPageToken []byte
+	if len(docs) > 0 && len(docs) == request.PageSize {
+		nextPageToken, err = newPageToken(docs[len(docs)-1].MessageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &nosqlplugin.SelectMessagesBetweenResponse{
+		Rows:          rows,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (db *mdb) selectQueueMessages(
+	ctx context.Context,
+	filter bson.M,
+	limit int,
+) ([]*cadence.QueueMessageCollectionEntry, error) {
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "messageid", Value: 1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []*cadence.QueueMessageCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
 }
 
 // Delete all messages before exclusiveBeginMessageID
@@ -69,7 +158,12 @@ func (db *mdb) DeleteMessagesBefore(
 	queueType persistence.QueueType,
 	exclusiveBeginMessageID int64,
 ) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{
+		"queuetype": queueType,
+		"messageid": bson.M{"$lt": exclusiveBeginMessageID},
+	})
+	return err
 }
 
 // Delete all messages in a range between exclusiveBeginMessageID and inclusiveEndMessageID
@@ -79,7 +173,12 @@ func (db *mdb) DeleteMessagesInRange(
 	exclusiveBeginMessageID int64,
 	inclusiveEndMessageID int64,
 ) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{
+		"queuetype": queueType,
+		"messageid": bson.M{"$gt": exclusiveBeginMessageID, "$lte": inclusiveEndMessageID},
+	})
+	return err
 }
 
 // Delete one message
@@ -88,7 +187,9 @@ func (db *mdb) DeleteMessage(
 	queueType persistence.QueueType,
 	messageID int64,
 ) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"queuetype": queueType, "messageid": messageID})
+	return err
 }
 
 // Insert an empty metadata row, starting from a version
@@ -97,8 +198,18 @@ func (db *mdb) InsertQueueMetadata(
 	queueType persistence.QueueType,
 	version int64,
 ) error {
-	fmt.Println("not implemented, ignore the eror for testing")
-	return nil
+	doc := cadence.QueueMetadataCollectionEntry{
+		ID:               queueType,
+		ClusterAckLevels: map[string]int64{},
+		Version:          version,
+	}
+	collection := db.dbConn.Collection(cadence.QueueMetadataCollectionName)
+	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// it's ok if the record exists already
+		return nil
+	}
+	return err
 }
 
 // **Conditionally** update a queue metadata row, if current version is matched(meaning current == row.Version - 1),
@@ -108,7 +219,18 @@ func (db *mdb) UpdateQueueMetadataCas(
 	ctx context.Context,
 	row nosqlplugin.QueueMetadataRow,
 ) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.QueueMetadataCollectionName)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": row.QueueType, "version": row.Version - 1},
+		bson.M{"$set": bson.M{"clusteracklevels": row.ClusterAckLevels, "version": row.Version}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return nosqlplugin.NewConditionFailure("queue")
+	}
+	return nil
 }
 
 // Read a QueueMetadata
@@ -116,13 +238,27 @@ func (db *mdb) SelectQueueMetadata(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (*nosqlplugin.QueueMetadataRow, error) {
-	fmt.Println("not implemented, ignore the eror for testing")
-	return nil, nil
+	var doc cadence.QueueMetadataCollectionEntry
+	collection := db.dbConn.Collection(cadence.QueueMetadataCollectionName)
+	if err := collection.FindOne(ctx, bson.M{"_id": queueType}).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	// if record exist but ackLevels is empty, we initialize the map
+	if doc.ClusterAckLevels == nil {
+		doc.ClusterAckLevels = make(map[string]int64)
+	}
+	return &nosqlplugin.QueueMetadataRow{
+		QueueType:        queueType,
+		ClusterAckLevels: doc.ClusterAckLevels,
+		Version:          doc.Version,
+	}, nil
 }
 
 func (db *mdb) GetQueueSize(
 	ctx context.Context,
 	queueType persistence.QueueType,
 ) (int64, error) {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.QueueMessageCollectionName)
+	return collection.CountDocuments(ctx, bson.M{"queuetype": queueType})
 }
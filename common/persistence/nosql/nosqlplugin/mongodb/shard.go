@@ -22,31 +22,132 @@ package mongodb
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/mongodb/cadence"
 )
 
 // InsertShard creates a new shard, return error is there is any.
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *mdb) InsertShard(ctx context.Context, row *nosqlplugin.ShardRow) error {
-	log.Println("not implemented...ignore the error for testing...")
-	return nil
+	shard := *row
+	shard.UpdatedAt = time.Now()
+	doc := cadence.ShardCollectionEntry{
+		ID:      row.ShardID,
+		RangeID: row.RangeID,
+		Shard:   &shard,
+	}
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return db.convertToConflictedShardRow(ctx, row.ShardID, "InsertShard")
+	}
+	return err
 }
 
 // SelectShard gets a shard
 func (db *mdb) SelectShard(ctx context.Context, shardID int, currentClusterName string) (int64, *nosqlplugin.ShardRow, error) {
-	panic("TODO")
+	var doc cadence.ShardCollectionEntry
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	err := collection.FindOne(ctx, bson.M{"_id": shardID}).Decode(&doc)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	info := doc.Shard
+	if info.ClusterTransferAckLevel == nil {
+		info.ClusterTransferAckLevel = map[string]int64{
+			currentClusterName: info.TransferAckLevel,
+		}
+	}
+	if info.ClusterTimerAckLevel == nil {
+		info.ClusterTimerAckLevel = map[string]time.Time{
+			currentClusterName: info.TimerAckLevel,
+		}
+	}
+	if info.ClusterReplicationLevel == nil {
+		info.ClusterReplicationLevel = make(map[string]int64)
+	}
+	if info.ReplicationDLQAckLevel == nil {
+		info.ReplicationDLQAckLevel = make(map[string]int64)
+	}
+	return doc.RangeID, info, nil
 }
 
 // UpdateRangeID updates the rangeID, return error is there is any
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *mdb) UpdateRangeID(ctx context.Context, shardID int, rangeID int64, previousRangeID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": shardID, "rangeid": previousRangeID},
+		bson.M{"$set": bson.M{"rangeid": rangeID}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return db.convertToConflictedShardRow(ctx, shardID, "UpdateRangeID")
+	}
+	return nil
 }
 
 // UpdateShard updates a shard, return error is there is any.
 // Return ShardOperationConditionFailure if the condition doesn't meet
 func (db *mdb) UpdateShard(ctx context.Context, row *nosqlplugin.ShardRow, previousRangeID int64) error {
-	panic("TODO")
+	shard := *row
+	shard.UpdatedAt = time.Now()
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": row.ShardID, "rangeid": previousRangeID},
+		bson.M{"$set": bson.M{"rangeid": row.RangeID, "shard": &shard}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return db.convertToConflictedShardRow(ctx, row.ShardID, "UpdateShard")
+	}
+	return nil
+}
+
+// lockShard is used within a transaction to check the rangeID condition of a shard.
+// It always writes to the shard document so that any concurrent transaction on the same shard will run into write conflict.
+func (db *mdb) lockShard(sessCtx mongo.SessionContext, condition *nosqlplugin.ShardCondition) error {
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	result, err := collection.UpdateOne(sessCtx,
+		bson.M{"_id": condition.ShardID, "rangeid": condition.RangeID},
+		bson.M{"$inc": bson.M{"writecount": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		var doc cadence.ShardCollectionEntry
+		err := collection.FindOne(sessCtx, bson.M{"_id": condition.ShardID}).Decode(&doc)
+		if err != nil {
+			return err
+		}
+		return &nosqlplugin.WorkflowOperationConditionFailure{
+			ShardRangeIDNotMatch: &doc.RangeID,
+		}
+	}
+	return nil
+}
+
+func (db *mdb) convertToConflictedShardRow(ctx context.Context, shardID int, operation string) error {
+	var doc cadence.ShardCollectionEntry
+	collection := db.dbConn.Collection(cadence.ShardCollectionName)
+	err := collection.FindOne(ctx, bson.M{"_id": shardID}).Decode(&doc)
+	if err != nil {
+		return err
+	}
+	return &nosqlplugin.ShardOperationConditionFailure{
+		RangeID: doc.RangeID,
+		Details: fmt.Sprintf("%v failed. shard_id=%v, range_id=%v", operation, shardID, doc.RangeID),
+	}
 }
@@ -1,4 +1,4 @@
-// Copyright (c) 2021 Uber Technologies, Inc.
+// Copyright (c) 2020 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -22,20 +22,66 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/mongodb/cadence"
+)
+
+const (
+	initialRangeID = 1 // Id of the first range of a new task list
 )
 
 // SelectTaskList returns a single tasklist row.
 // Return IsNotFoundError if the row doesn't exist
 func (db *mdb) SelectTaskList(ctx context.Context, filter *nosqlplugin.TaskListFilter) (*nosqlplugin.TaskListRow, error) {
-	panic("TODO")
+	var doc cadence.TaskListCollectionEntry
+	collection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	if err := collection.FindOne(ctx, taskListFilter(filter)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &nosqlplugin.TaskListRow{
+		DomainID:     doc.DomainID,
+		TaskListName: doc.TaskListName,
+		TaskListType: doc.TaskListType,
+
+		TaskListKind:    doc.TaskListKind,
+		LastUpdatedTime: doc.LastUpdatedTime,
+		AckLevel:        doc.AckLevel,
+		RangeID:         doc.RangeID,
+	}, nil
 }
 
 // InsertTaskList insert a single tasklist row
-// Return IsConditionFailedError if the row already exists, and also the existing row
+// Return TaskOperationConditionFailure if the row already exists
 func (db *mdb) InsertTaskList(ctx context.Context, row *nosqlplugin.TaskListRow) error {
-	panic("TODO")
+	doc := cadence.TaskListCollectionEntry{
+		DomainID:        row.DomainID,
+		TaskListName:    row.TaskListName,
+		TaskListType:    row.TaskListType,
+		RangeID:         initialRangeID,
+		TaskListKind:    row.TaskListKind,
+		AckLevel:        0,
+		LastUpdatedTime: row.LastUpdatedTime,
+	}
+	collection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return db.convertToConflictedTaskListRow(ctx, &nosqlplugin.TaskListFilter{
+			DomainID:     row.DomainID,
+			TaskListName: row.TaskListName,
+			TaskListType: row.TaskListType,
+		}, "InsertTaskList")
+	}
+	return err
 }
 
 // UpdateTaskList updates a single tasklist row
@@ -45,7 +91,7 @@ func (db *mdb) UpdateTaskList(
 	row *nosqlplugin.TaskListRow,
 	previousRangeID int64,
 ) error {
-	panic("TODO")
+	return db.updateTaskList(ctx, row, previousRangeID, row.LastUpdatedTime, nil)
 }
 
 // UpdateTaskList updates a single tasklist row, and set an TTL on the record
@@ -58,19 +104,72 @@ func (db *mdb) UpdateTaskListWithTTL(
 	row *nosqlplugin.TaskListRow,
 	previousRangeID int64,
 ) error {
-	panic("TODO")
+	now := time.Now()
+	expireTime := now.Add(time.Duration(ttlSeconds) * time.Second)
+	return db.updateTaskList(ctx, row, previousRangeID, now, &expireTime)
+}
+
+func (db *mdb) updateTaskList(
+	ctx context.Context,
+	row *nosqlplugin.TaskListRow,
+	previousRangeID int64,
+	lastUpdatedTime time.Time,
+	expireTime *time.Time,
+) error {
+	update := bson.M{
+		"$set": bson.M{
+			"rangeid":         row.RangeID,
+			"acklevel":        row.AckLevel,
+			"tasklistkind":    row.TaskListKind,
+			"lastupdatedtime": lastUpdatedTime,
+		},
+	}
+	if expireTime != nil {
+		update["$set"].(bson.M)["expiretime"] = *expireTime
+	} else {
+		update["$unset"] = bson.M{"expiretime": ""}
+	}
+
+	tlFilter := &nosqlplugin.TaskListFilter{
+		DomainID:     row.DomainID,
+		TaskListName: row.TaskListName,
+		TaskListType: row.TaskListType,
+	}
+	filter := taskListFilter(tlFilter)
+	filter["rangeid"] = previousRangeID
+	collection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return db.convertToConflictedTaskListRow(ctx, tlFilter, "UpdateTaskList")
+	}
+	return nil
 }
 
 // ListTaskList returns all tasklists.
 // Noop if TTL is already implemented in other methods
 func (db *mdb) ListTaskList(ctx context.Context, pageSize int, nextPageToken []byte) (*nosqlplugin.ListTaskListResult, error) {
-	panic("TODO")
+	return nil, &types.InternalServiceError{
+		Message: "unsupported operation",
+	}
 }
 
 // DeleteTaskList deletes a single tasklist row
 // Return TaskOperationConditionFailure if the condition doesn't meet
 func (db *mdb) DeleteTaskList(ctx context.Context, filter *nosqlplugin.TaskListFilter, previousRangeID int64) error {
-	panic("TODO")
+	deleteFilter := taskListFilter(filter)
+	deleteFilter["rangeid"] = previousRangeID
+	collection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	result, err := collection.DeleteOne(ctx, deleteFilter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return db.convertToConflictedTaskListRow(ctx, filter, "DeleteTaskList")
+	}
+	return nil
 }
 
 // InsertTasks inserts a batch of tasks
@@ -80,22 +179,126 @@ func (db *mdb) InsertTasks(
 	tasksToInsert []*nosqlplugin.TaskRowForInsert,
 	tasklistCondition *nosqlplugin.TaskListRow,
 ) error {
-	panic("TODO")
+	tlFilter := &nosqlplugin.TaskListFilter{
+		DomainID:     tasklistCondition.DomainID,
+		TaskListName: tasklistCondition.TaskListName,
+		TaskListType: tasklistCondition.TaskListType,
+	}
+	now := time.Now()
+	docs := make([]interface{}, 0, len(tasksToInsert))
+	for _, task := range tasksToInsert {
+		doc := &cadence.TaskCollectionEntry{
+			DomainID:        tlFilter.DomainID,
+			TaskListName:    tlFilter.TaskListName,
+			TaskListType:    tlFilter.TaskListType,
+			TaskID:          task.TaskID,
+			WorkflowID:      task.WorkflowID,
+			RunID:           task.RunID,
+			ScheduledID:     task.ScheduledID,
+			CreatedTime:     task.CreatedTime,
+			PartitionConfig: task.PartitionConfig,
+		}
+		if task.TTLSeconds > 0 {
+			doc.ExpireTime = common.TimePtr(now.Add(time.Duration(task.TTLSeconds) * time.Second))
+		}
+		docs = append(docs, doc)
+	}
+
+	taskListCollection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	taskCollection := db.dbConn.Collection(cadence.TaskCollectionName)
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		// The following update is used to ensure that range_id didn't change
+		filter := taskListFilter(tlFilter)
+		filter["rangeid"] = tasklistCondition.RangeID
+		result, err := taskListCollection.UpdateOne(sessCtx, filter, bson.M{"$inc": bson.M{"writecount": 1}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return db.convertToConflictedTaskListRow(sessCtx, tlFilter, "InsertTasks")
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err = taskCollection.InsertMany(sessCtx, docs)
+		return err
+	})
 }
 
 // SelectTasks return tasks that associated to a tasklist
 func (db *mdb) SelectTasks(ctx context.Context, filter *nosqlplugin.TasksFilter) ([]*nosqlplugin.TaskRow, error) {
-	panic("TODO")
+	query := taskListFilter(&filter.TaskListFilter)
+	query["taskid"] = taskIDRangeFilter(filter.MinTaskID, filter.MaxTaskID)
+	collection := db.dbConn.Collection(cadence.TaskCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "taskid", Value: 1}}).
+		SetLimit(int64(filter.BatchSize)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []*cadence.TaskCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var response []*nosqlplugin.TaskRow
+	for _, doc := range docs {
+		response = append(response, &nosqlplugin.TaskRow{
+			DomainID:        doc.DomainID,
+			TaskListName:    doc.TaskListName,
+			TaskListType:    doc.TaskListType,
+			TaskID:          doc.TaskID,
+			WorkflowID:      doc.WorkflowID,
+			RunID:           doc.RunID,
+			ScheduledID:     doc.ScheduledID,
+			CreatedTime:     doc.CreatedTime,
+			PartitionConfig: doc.PartitionConfig,
+		})
+	}
+	return response, nil
 }
 
+// GetTasksCount returns number of tasks from a tasklist
 func (db *mdb) GetTasksCount(ctx context.Context, filter *nosqlplugin.TasksFilter) (int64, error) {
-	panic("TODO")
+	query := taskListFilter(&filter.TaskListFilter)
+	query["taskid"] = bson.M{"$gt": filter.MinTaskID}
+	collection := db.dbConn.Collection(cadence.TaskCollectionName)
+	return collection.CountDocuments(ctx, query)
 }
 
 // DeleteTask delete a batch tasks that taskIDs less than the row
 // If TTL is not implemented, then should also return the number of rows deleted, otherwise persistence.UnknownNumRowsAffected
 // NOTE: This API ignores the `BatchSize` request parameter i.e. either all tasks leq the task_id will be deleted or an error will
-// be returned to the caller, because rowsDeleted is not supported by Cassandra
+// be returned to the caller
 func (db *mdb) RangeDeleteTasks(ctx context.Context, filter *nosqlplugin.TasksFilter) (rowsDeleted int, err error) {
-	panic("TODO")
+	query := taskListFilter(&filter.TaskListFilter)
+	query["taskid"] = taskIDRangeFilter(filter.MinTaskID, filter.MaxTaskID)
+	collection := db.dbConn.Collection(cadence.TaskCollectionName)
+	_, err = collection.DeleteMany(ctx, query)
+	return persistence.UnknownNumRowsAffected, err
+}
+
+func taskListFilter(filter *nosqlplugin.TaskListFilter) bson.M {
+	return bson.M{
+		"domainid":     filter.DomainID,
+		"tasklistname": filter.TaskListName,
+		"tasklisttype": filter.TaskListType,
+	}
+}
+
+func (db *mdb) convertToConflictedTaskListRow(ctx context.Context, filter *nosqlplugin.TaskListFilter, operation string) error {
+	var doc cadence.TaskListCollectionEntry
+	collection := db.dbConn.Collection(cadence.TaskListCollectionName)
+	if err := collection.FindOne(ctx, taskListFilter(filter)).Decode(&doc); err != nil {
+		if db.IsNotFoundError(err) {
+			return &nosqlplugin.TaskOperationConditionFailure{
+				Details: fmt.Sprintf("%v failed. tasklist doesn't exist", operation),
+			}
+		}
+		return err
+	}
+	return &nosqlplugin.TaskOperationConditionFailure{
+		RangeID: doc.RangeID,
+		Details: fmt.Sprintf("%v failed. range_id=%v, ack_level=%v", operation, doc.RangeID, doc.AckLevel),
+	}
 }
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mongodb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// pageToken is the token for paginating by the key(s) of the last document returned in the previous page
+type pageToken struct {
+	LastKeys []interface{} `json:"lastKeys"`
+}
+
+func newPageToken(lastKeys ...interface{}) ([]byte, error) {
+	return json.Marshal(pageToken{LastKeys: lastKeys})
+}
+
+// decodePageToken decodes the token into the given pointers of last keys, it's noop if token is empty
+func decodePageToken(token []byte, lastKeys ...interface{}) (bool, error) {
+	if len(token) == 0 {
+		return false, nil
+	}
+	var raw struct {
+		LastKeys []json.RawMessage `json:"lastKeys"`
+	}
+	if err := json.Unmarshal(token, &raw); err != nil {
+		return false, fmt.Errorf("invalid page token: %v", err)
+	}
+	if len(raw.LastKeys) != len(lastKeys) {
+		return false, fmt.Errorf("invalid page token: expect %v keys but got %v", len(lastKeys), len(raw.LastKeys))
+	}
+	for i, key := range raw.LastKeys {
+		if err := json.Unmarshal(key, lastKeys[i]); err != nil {
+			return false, fmt.Errorf("invalid page token: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// taskIDRangeFilter returns the filter of taskID in range of (exclusiveMinTaskID, inclusiveMaxTaskID]
+func taskIDRangeFilter(exclusiveMinTaskID, inclusiveMaxTaskID int64) bson.M {
+	return bson.M{"$gt": exclusiveMinTaskID, "$lte": inclusiveMaxTaskID}
+}
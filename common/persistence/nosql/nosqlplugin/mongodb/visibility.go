@@ -1,4 +1,4 @@
-// Copyright (c) 2021 Uber Technologies, Inc.
+// Copyright (c) 2020 Uber Technologies, Inc.
 //
 // Permission is hereby granted, free of charge, to any person obtaining a copy
 // of this software and associated documentation files (the "Software"), to deal
@@ -22,8 +22,17 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/schema/mongodb/cadence"
 )
 
 func (db *mdb) InsertVisibility(
@@ -31,7 +40,11 @@ func (db *mdb) InsertVisibility(
 	ttlSeconds int64,
 	row *nosqlplugin.VisibilityRowForInsert,
 ) error {
-	panic("TODO")
+	doc := toVisibilityCollectionEntry(row.DomainID, ttlSeconds, &row.VisibilityRow)
+	// open record doesn't have close time or close status
+	doc.CloseTime = nil
+	doc.CloseStatus = nil
+	return db.upsertVisibility(ctx, doc)
 }
 
 func (db *mdb) UpdateVisibility(
@@ -39,26 +52,211 @@ func (db *mdb) UpdateVisibility(
 	ttlSeconds int64,
 	row *nosqlplugin.VisibilityRowForUpdate,
 ) error {
-	panic("TODO")
+	if row.UpdateCloseToOpen {
+		// TODO implement it when where is a need
+		return &types.InternalServiceError{
+			Message: "unsupported operation",
+		}
+	}
+	// UpdateOpenToClose is ignored because open and closed records are stored in the same collection
+	doc := toVisibilityCollectionEntry(row.DomainID, ttlSeconds, &row.VisibilityRow)
+	return db.upsertVisibility(ctx, doc)
+}
+
+func (db *mdb) UpsertVisibility(
+	ctx context.Context,
+	ttlSeconds int64,
+	row *nosqlplugin.VisibilityRowForUpsert,
+) error {
+	// search attributes are not indexed
+	return persistence.ErrVisibilityOperationNotSupported
+}
+
+func (db *mdb) upsertVisibility(
+	ctx context.Context,
+	doc *cadence.VisibilityCollectionEntry,
+) error {
+	collection := db.dbConn.Collection(cadence.VisibilityCollectionName)
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"domainid": doc.DomainID, "workflowid": doc.WorkflowID, "runid": doc.RunID},
+		doc,
+		options.Replace().SetUpsert(true),
+	)
+	return err
 }
 
 func (db *mdb) SelectVisibility(
 	ctx context.Context,
 	filter *nosqlplugin.VisibilityFilter,
 ) (*nosqlplugin.SelectVisibilityResponse, error) {
-	panic("TODO")
+	request := &filter.ListRequest
+	query := bson.M{"domainid": request.DomainUUID}
+
+	switch filter.FilterType {
+	case nosqlplugin.AllOpen, nosqlplugin.OpenByWorkflowType, nosqlplugin.OpenByWorkflowID:
+		query["closestatus"] = nil
+		if filter.SortType != nosqlplugin.SortByStartTime {
+			return nil, &types.InternalServiceError{
+				Message: "open workflows can only be sorted by start time",
+			}
+		}
+	case nosqlplugin.AllClosed, nosqlplugin.ClosedByWorkflowType, nosqlplugin.ClosedByWorkflowID:
+		query["closestatus"] = bson.M{"$ne": nil}
+	case nosqlplugin.ClosedByClosedStatus:
+		query["closestatus"] = filter.CloseStatus
+	default:
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("unsupported filter type: %v", filter.FilterType),
+		}
+	}
+
+	switch filter.FilterType {
+	case nosqlplugin.OpenByWorkflowType, nosqlplugin.ClosedByWorkflowType:
+		query["workflowtype"] = filter.WorkflowType
+	case nosqlplugin.OpenByWorkflowID, nosqlplugin.ClosedByWorkflowID:
+		query["workflowid"] = filter.WorkflowID
+	}
+
+	var timeField string
+	switch filter.SortType {
+	case nosqlplugin.SortByStartTime:
+		timeField = "starttime"
+	case nosqlplugin.SortByClosedTime:
+		timeField = "closetime"
+	default:
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("unsupported sort type: %v", filter.SortType),
+		}
+	}
+	timeRange := bson.M{"$gte": request.EarliestTime, "$lte": request.LatestTime}
+
+	var lastTime int64
+	var lastRunID string
+	hasToken, err := decodePageToken(request.NextPageToken, &lastTime, &lastRunID)
+	if err != nil {
+		return nil, err
+	}
+	if hasToken {
+		// records are ordered by time DESC, runID ASC
+		lastTimestamp := time.Unix(0, lastTime)
+		query["$or"] = bson.A{
+			bson.M{timeField: bson.M{"$lt": lastTimestamp}},
+			bson.M{timeField: lastTimestamp, "runid": bson.M{"$gt": lastRunID}},
+		}
+		// the range is still needed in addition to the $or condition
+		query["$and"] = bson.A{bson.M{timeField: timeRange}}
+	} else {
+		query[timeField] = timeRange
+	}
+
+	collection := db.dbConn.Collection(cadence.VisibilityCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: timeField, Value: -1}, {Key: "runid", Value: 1}}).
+		SetLimit(int64(request.PageSize)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []*cadence.VisibilityCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	response := &nosqlplugin.SelectVisibilityResponse{
+		Executions: make([]*nosqlplugin.VisibilityRow, 0, len(docs)),
+	}
+	for _, doc := range docs {
+		response.Executions = append(response.Executions, fromVisibilityCollectionEntry(doc))
+	}
+	if len(docs) > 0 && len(docs) == request.PageSize {
+		last := docs[len(docs)-1]
+		lastTimestamp := last.StartTime
+		if filter.SortType == nosqlplugin.SortByClosedTime {
+			lastTimestamp = *last.CloseTime
+		}
+		response.NextPageToken, err = newPageToken(lastTimestamp.UnixNano(), last.RunID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
 }
 
 func (db *mdb) DeleteVisibility(
 	ctx context.Context,
 	domainID, workflowID, runID string,
 ) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.VisibilityCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"domainid": domainID, "workflowid": workflowID, "runid": runID})
+	return err
 }
 
 func (db *mdb) SelectOneClosedWorkflow(
 	ctx context.Context,
 	domainID, workflowID, runID string,
 ) (*nosqlplugin.VisibilityRow, error) {
-	panic("TODO")
+	var doc cadence.VisibilityCollectionEntry
+	collection := db.dbConn.Collection(cadence.VisibilityCollectionName)
+	err := collection.FindOne(ctx, bson.M{
+		"domainid":    domainID,
+		"workflowid":  workflowID,
+		"runid":       runID,
+		"closestatus": bson.M{"$ne": nil},
+	}).Decode(&doc)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			// Special case: return nil,nil if not found(since we will deprecate it, it's not worth refactor to be consistent)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fromVisibilityCollectionEntry(&doc), nil
+}
+
+func toVisibilityCollectionEntry(domainID string, ttlSeconds int64, row *nosqlplugin.VisibilityRow) *cadence.VisibilityCollectionEntry {
+	memo, memoEncoding := persistence.FromDataBlob(row.Memo)
+	doc := &cadence.VisibilityCollectionEntry{
+		DomainID:      domainID,
+		WorkflowID:    row.WorkflowID,
+		RunID:         row.RunID,
+		WorkflowType:  row.TypeName,
+		StartTime:     row.StartTime,
+		ExecutionTime: row.ExecutionTime,
+		CloseTime:     common.TimePtr(row.CloseTime),
+		CloseStatus:   row.Status,
+		HistoryLength: row.HistoryLength,
+		Memo:          memo,
+		MemoEncoding:  memoEncoding,
+		TaskList:      row.TaskList,
+		IsCron:        row.IsCron,
+		NumClusters:   row.NumClusters,
+		UpdateTime:    row.UpdateTime,
+		ShardID:       row.ShardID,
+	}
+	if ttlSeconds > 0 {
+		doc.ExpireTime = common.TimePtr(time.Now().Add(time.Duration(ttlSeconds) * time.Second))
+	}
+	return doc
+}
+
+func fromVisibilityCollectionEntry(doc *cadence.VisibilityCollectionEntry) *nosqlplugin.VisibilityRow {
+	row := &nosqlplugin.VisibilityRow{
+		DomainID:      doc.DomainID,
+		WorkflowID:    doc.WorkflowID,
+		RunID:         doc.RunID,
+		TypeName:      doc.WorkflowType,
+		StartTime:     doc.StartTime,
+		ExecutionTime: doc.ExecutionTime,
+		Status:        doc.CloseStatus,
+		HistoryLength: doc.HistoryLength,
+		Memo:          persistence.NewDataBlob(doc.Memo, common.EncodingType(doc.MemoEncoding)),
+		TaskList:      doc.TaskList,
+		IsCron:        doc.IsCron,
+		NumClusters:   doc.NumClusters,
+		UpdateTime:    doc.UpdateTime,
+		ShardID:       doc.ShardID,
+	}
+	if doc.CloseTime != nil {
+		row.CloseTime = *doc.CloseTime
+	}
+	return row
 }
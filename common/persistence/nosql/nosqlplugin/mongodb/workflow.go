@@ -23,10 +23,17 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/mongodb/cadence"
 )
 
 var _ nosqlplugin.WorkflowCRUD = (*mdb)(nil)
@@ -41,7 +48,19 @@ func (db *mdb) InsertWorkflowExecutionWithTasks(
 	timerTasks []*nosqlplugin.TimerTask,
 	shardCondition *nosqlplugin.ShardCondition,
 ) error {
-	panic("TODO")
+	shardID := shardCondition.ShardID
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := db.lockShard(sessCtx, shardCondition); err != nil {
+			return err
+		}
+		if err := db.createOrUpdateCurrentWorkflow(sessCtx, shardID, execution.DomainID, execution.WorkflowID, currentWorkflowRequest); err != nil {
+			return err
+		}
+		if err := db.createWorkflowExecution(sessCtx, shardID, execution); err != nil {
+			return err
+		}
+		return db.createTasks(sessCtx, shardID, transferTasks, crossClusterTasks, replicationTasks, timerTasks)
+	})
 }
 
 func (db *mdb) UpdateWorkflowExecutionWithTasks(
@@ -56,105 +75,457 @@ func (db *mdb) UpdateWorkflowExecutionWithTasks(
 	timerTasks []*nosqlplugin.TimerTask,
 	shardCondition *nosqlplugin.ShardCondition,
 ) error {
-	panic("TODO")
+	var domainID, workflowID string
+	if mutatedExecution != nil {
+		if mutatedExecution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeUpdate {
+			return fmt.Errorf("should only support WorkflowExecutionMapsWriteModeUpdate for mutatedExecution")
+		}
+		domainID = mutatedExecution.DomainID
+		workflowID = mutatedExecution.WorkflowID
+	} else if resetExecution != nil {
+		if resetExecution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeReset ||
+			resetExecution.EventBufferWriteMode != nosqlplugin.EventBufferWriteModeClear {
+			return fmt.Errorf("should only support WorkflowExecutionMapsWriteModeReset and EventBufferWriteModeClear for resetExecution")
+		}
+		domainID = resetExecution.DomainID
+		workflowID = resetExecution.WorkflowID
+	} else {
+		return fmt.Errorf("at least one of mutatedExecution and resetExecution should be provided")
+	}
+
+	shardID := shardCondition.ShardID
+	return db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := db.lockShard(sessCtx, shardCondition); err != nil {
+			return err
+		}
+		if err := db.createOrUpdateCurrentWorkflow(sessCtx, shardID, domainID, workflowID, currentWorkflowRequest); err != nil {
+			return err
+		}
+		if mutatedExecution != nil {
+			if err := db.updateWorkflowExecution(sessCtx, shardID, mutatedExecution); err != nil {
+				return err
+			}
+		}
+		if insertedExecution != nil {
+			if err := db.createWorkflowExecution(sessCtx, shardID, insertedExecution); err != nil {
+				return err
+			}
+		}
+		if resetExecution != nil {
+			if err := db.updateWorkflowExecution(sessCtx, shardID, resetExecution); err != nil {
+				return err
+			}
+		}
+		return db.createTasks(sessCtx, shardID, transferTasks, crossClusterTasks, replicationTasks, timerTasks)
+	})
 }
 
 func (db *mdb) SelectCurrentWorkflow(ctx context.Context, shardID int, domainID, workflowID string) (*nosqlplugin.CurrentWorkflowRow, error) {
-	panic("TODO")
+	var doc cadence.CurrentWorkflowCollectionEntry
+	collection := db.dbConn.Collection(cadence.CurrentWorkflowCollectionName)
+	err := collection.FindOne(ctx, bson.M{"shardid": shardID, "domainid": domainID, "workflowid": workflowID}).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return &nosqlplugin.CurrentWorkflowRow{
+		ShardID:          shardID,
+		DomainID:         domainID,
+		WorkflowID:       workflowID,
+		RunID:            doc.RunID,
+		State:            doc.State,
+		CloseStatus:      doc.CloseStatus,
+		CreateRequestID:  doc.CreateRequestID,
+		LastWriteVersion: doc.LastWriteVersion,
+	}, nil
 }
 
 func (db *mdb) SelectWorkflowExecution(ctx context.Context, shardID int, domainID, workflowID, runID string) (*nosqlplugin.WorkflowExecution, error) {
-	panic("TODO")
+	var doc cadence.WorkflowExecutionCollectionEntry
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	err := collection.FindOne(ctx, workflowExecutionFilter(shardID, domainID, workflowID, runID)).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return fromWorkflowExecutionCollectionEntry(&doc)
 }
 
 func (db *mdb) DeleteCurrentWorkflow(ctx context.Context, shardID int, domainID, workflowID, currentRunIDCondition string) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.CurrentWorkflowCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "domainid": domainID, "workflowid": workflowID, "runid": currentRunIDCondition})
+	return err
 }
 
 func (db *mdb) DeleteWorkflowExecution(ctx context.Context, shardID int, domainID, workflowID, runID string) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	_, err := collection.DeleteOne(ctx, workflowExecutionFilter(shardID, domainID, workflowID, runID))
+	return err
 }
 
 func (db *mdb) SelectAllCurrentWorkflows(ctx context.Context, shardID int, pageToken []byte, pageSize int) ([]*persistence.CurrentWorkflowExecution, []byte, error) {
-	panic("TODO")
+	query := bson.M{"shardid": shardID}
+	var lastDomainID, lastWorkflowID string
+	hasToken, err := decodePageToken(pageToken, &lastDomainID, &lastWorkflowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		query["$or"] = bson.A{
+			bson.M{"domainid": bson.M{"$gt": lastDomainID}},
+			bson.M{"domainid": lastDomainID, "workflowid": bson.M{"$gt": lastWorkflowID}},
+		}
+	}
+
+	collection := db.dbConn.Collection(cadence.CurrentWorkflowCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "domainid", Value: 1}, {Key: "workflowid", Value: 1}}).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.CurrentWorkflowCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	var executions []*persistence.CurrentWorkflowExecution
+	for _, doc := range docs {
+		executions = append(executions, &persistence.CurrentWorkflowExecution{
+			DomainID:     doc.DomainID,
+			WorkflowID:   doc.WorkflowID,
+			RunID:        doc.RunID,
+			State:        doc.State,
+			CurrentRunID: doc.RunID,
+		})
+	}
+	var nextPageToken []byte
+	if len(docs) > 0 && len(docs) == pageSize {
+		last := docs[len(docs)-1]
+		nextPageToken, err = newPageToken(last.DomainID, last.WorkflowID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return executions, nextPageToken, nil
 }
 
 func (db *mdb) SelectAllWorkflowExecutions(ctx context.Context, shardID int, pageToken []byte, pageSize int) ([]*persistence.InternalListConcreteExecutionsEntity, []byte, error) {
-	panic("TODO")
+	query := bson.M{"shardid": shardID}
+	var lastDomainID, lastWorkflowID, lastRunID string
+	hasToken, err := decodePageToken(pageToken, &lastDomainID, &lastWorkflowID, &lastRunID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		query["$or"] = bson.A{
+			bson.M{"domainid": bson.M{"$gt": lastDomainID}},
+			bson.M{"domainid": lastDomainID, "workflowid": bson.M{"$gt": lastWorkflowID}},
+			bson.M{"domainid": lastDomainID, "workflowid": lastWorkflowID, "runid": bson.M{"$gt": lastRunID}},
+		}
+	}
+
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "domainid", Value: 1}, {Key: "workflowid", Value: 1}, {Key: "runid", Value: 1}}).
+		SetLimit(int64(pageSize)).
+		SetProjection(bson.M{"domainid": 1, "workflowid": 1, "runid": 1, "execution": 1, "versionhistories": 1, "versionhistoriesencoding": 1}))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.WorkflowExecutionCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	var executions []*persistence.InternalListConcreteExecutionsEntity
+	for _, doc := range docs {
+		executions = append(executions, &persistence.InternalListConcreteExecutionsEntity{
+			ExecutionInfo:    doc.Execution,
+			VersionHistories: persistence.NewDataBlob(doc.VersionHistories, common.EncodingType(doc.VersionHistoriesEncoding)),
+		})
+	}
+	var nextPageToken []byte
+	if len(docs) > 0 && len(docs) == pageSize {
+		last := docs[len(docs)-1]
+		nextPageToken, err = newPageToken(last.DomainID, last.WorkflowID, last.RunID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return executions, nextPageToken, nil
 }
 
 func (db *mdb) IsWorkflowExecutionExists(ctx context.Context, shardID int, domainID, workflowID, runID string) (bool, error) {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	count, err := collection.CountDocuments(ctx, workflowExecutionFilter(shardID, domainID, workflowID, runID))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 func (db *mdb) SelectTransferTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.TransferTask, []byte, error) {
-	panic("TODO")
+	var docs []*cadence.TransferTaskCollectionEntry
+	err := db.findTasksOrderByTaskID(ctx, cadence.TransferTaskCollectionName, bson.M{"shardid": shardID}, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID, &docs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.TransferTask
+	var lastTaskID int64
+	for _, doc := range docs {
+		tasks = append(tasks, doc.Task)
+		lastTaskID = doc.TaskID
+	}
+	nextPageToken, err := newTaskIDPageToken(pageSize, len(docs), lastTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *mdb) DeleteTransferTask(ctx context.Context, shardID int, taskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.TransferTaskCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "taskid": taskID})
+	return err
 }
 
 func (db *mdb) RangeDeleteTransferTasks(ctx context.Context, shardID int, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.TransferTaskCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{"shardid": shardID, "taskid": taskIDRangeFilter(exclusiveBeginTaskID, inclusiveEndTaskID)})
+	return err
 }
 
 func (db *mdb) SelectTimerTasksOrderByVisibilityTime(ctx context.Context, shardID, pageSize int, pageToken []byte, inclusiveMinTime, exclusiveMaxTime time.Time) ([]*nosqlplugin.TimerTask, []byte, error) {
-	panic("TODO")
+	query := bson.M{
+		"shardid":             shardID,
+		"visibilitytimestamp": bson.M{"$gte": inclusiveMinTime, "$lt": exclusiveMaxTime},
+	}
+	var lastTimestamp, lastTaskID int64
+	hasToken, err := decodePageToken(pageToken, &lastTimestamp, &lastTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToken {
+		lastTime := time.Unix(0, lastTimestamp)
+		query["$or"] = bson.A{
+			bson.M{"visibilitytimestamp": bson.M{"$gt": lastTime}},
+			bson.M{"visibilitytimestamp": lastTime, "taskid": bson.M{"$gt": lastTaskID}},
+		}
+	}
+
+	collection := db.dbConn.Collection(cadence.TimerTaskCollectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "visibilitytimestamp", Value: 1}, {Key: "taskid", Value: 1}}).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []*cadence.TimerTaskCollectionEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []*nosqlplugin.TimerTask
+	for _, doc := range docs {
+		tasks = append(tasks, doc.Task)
+	}
+	var nextPageToken []byte
+	if len(docs) > 0 && len(docs) == pageSize {
+		last := docs[len(docs)-1]
+		nextPageToken, err = newPageToken(last.VisibilityTimestamp.UnixNano(), last.TaskID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *mdb) DeleteTimerTask(ctx context.Context, shardID int, taskID int64, visibilityTimestamp time.Time) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.TimerTaskCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "visibilitytimestamp": visibilityTimestamp, "taskid": taskID})
+	return err
 }
 
 func (db *mdb) RangeDeleteTimerTasks(ctx context.Context, shardID int, inclusiveMinTime, exclusiveMaxTime time.Time) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.TimerTaskCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{
+		"shardid":             shardID,
+		"visibilitytimestamp": bson.M{"$gte": inclusiveMinTime, "$lt": exclusiveMaxTime},
+	})
+	return err
 }
 
 func (db *mdb) SelectReplicationTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.ReplicationTask, []byte, error) {
-	panic("TODO")
+	return db.selectReplicationTasks(ctx, cadence.ReplicationTaskCollectionName, bson.M{"shardid": shardID}, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
 }
 
 func (db *mdb) DeleteReplicationTask(ctx context.Context, shardID int, taskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationTaskCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "taskid": taskID})
+	return err
 }
 
 func (db *mdb) RangeDeleteReplicationTasks(ctx context.Context, shardID int, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationTaskCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{"shardid": shardID, "taskid": bson.M{"$lte": inclusiveEndTaskID}})
+	return err
 }
 
 func (db *mdb) InsertReplicationTask(ctx context.Context, tasks []*nosqlplugin.ReplicationTask, condition nosqlplugin.ShardCondition) error {
-	panic("TODO")
+	err := db.executeTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := db.lockShard(sessCtx, &condition); err != nil {
+			return err
+		}
+		return db.createTasks(sessCtx, condition.ShardID, nil, nil, tasks, nil)
+	})
+	if conditionFailure, ok := err.(*nosqlplugin.WorkflowOperationConditionFailure); ok && conditionFailure.ShardRangeIDNotMatch != nil {
+		return &nosqlplugin.ShardOperationConditionFailure{
+			RangeID: *conditionFailure.ShardRangeIDNotMatch,
+		}
+	}
+	return err
 }
 
 func (db *mdb) SelectCrossClusterTasksOrderByTaskID(ctx context.Context, shardID, pageSize int, pageToken []byte, targetCluster string, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.CrossClusterTask, []byte, error) {
-	panic("TODO")
+	var docs []*cadence.CrossClusterTaskCollectionEntry
+	query := bson.M{"shardid": shardID, "targetcluster": targetCluster}
+	err := db.findTasksOrderByTaskID(ctx, cadence.CrossClusterTaskCollectionName, query, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID, &docs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.CrossClusterTask
+	var lastTaskID int64
+	for _, doc := range docs {
+		tasks = append(tasks, &nosqlplugin.CrossClusterTask{
+			TransferTask:  *doc.Task,
+			TargetCluster: doc.TargetCluster,
+		})
+		lastTaskID = doc.TaskID
+	}
+	nextPageToken, err := newTaskIDPageToken(pageSize, len(docs), lastTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, nextPageToken, nil
 }
 
 func (db *mdb) DeleteCrossClusterTask(ctx context.Context, shardID int, targetCluster string, taskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.CrossClusterTaskCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "targetcluster": targetCluster, "taskid": taskID})
+	return err
 }
 
 func (db *mdb) RangeDeleteCrossClusterTasks(ctx context.Context, shardID int, targetCluster string, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.CrossClusterTaskCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{
+		"shardid":       shardID,
+		"targetcluster": targetCluster,
+		"taskid":        taskIDRangeFilter(exclusiveBeginTaskID, inclusiveEndTaskID),
+	})
+	return err
 }
 
 func (db *mdb) InsertReplicationDLQTask(ctx context.Context, shardID int, sourceCluster string, task nosqlplugin.ReplicationTask) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationDLQTaskCollectionName)
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"shardid": shardID, "sourcecluster": sourceCluster, "taskid": task.TaskID},
+		&cadence.ReplicationTaskCollectionEntry{
+			ShardID:       shardID,
+			SourceCluster: sourceCluster,
+			TaskID:        task.TaskID,
+			Task:          &task,
+		},
+		options.Replace().SetUpsert(true),
+	)
+	return err
 }
 
 func (db *mdb) SelectReplicationDLQTasksOrderByTaskID(ctx context.Context, shardID int, sourceCluster string, pageSize int, pageToken []byte, exclusiveMinTaskID, inclusiveMaxTaskID int64) ([]*nosqlplugin.ReplicationTask, []byte, error) {
-	panic("TODO")
+	query := bson.M{"shardid": shardID, "sourcecluster": sourceCluster}
+	return db.selectReplicationTasks(ctx, cadence.ReplicationDLQTaskCollectionName, query, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID)
 }
 
 func (db *mdb) SelectReplicationDLQTasksCount(ctx context.Context, shardID int, sourceCluster string) (int64, error) {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationDLQTaskCollectionName)
+	return collection.CountDocuments(ctx, bson.M{"shardid": shardID, "sourcecluster": sourceCluster})
 }
 
 func (db *mdb) DeleteReplicationDLQTask(ctx context.Context, shardID int, sourceCluster string, taskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationDLQTaskCollectionName)
+	_, err := collection.DeleteOne(ctx, bson.M{"shardid": shardID, "sourcecluster": sourceCluster, "taskid": taskID})
+	return err
 }
 
 func (db *mdb) RangeDeleteReplicationDLQTasks(ctx context.Context, shardID int, sourceCluster string, exclusiveBeginTaskID, inclusiveEndTaskID int64) error {
-	panic("TODO")
+	collection := db.dbConn.Collection(cadence.ReplicationDLQTaskCollectionName)
+	_, err := collection.DeleteMany(ctx, bson.M{
+		"shardid":       shardID,
+		"sourcecluster": sourceCluster,
+		"taskid":        taskIDRangeFilter(exclusiveBeginTaskID, inclusiveEndTaskID),
+	})
+	return err
+}
+
+func (db *mdb) selectReplicationTasks(
+	ctx context.Context,
+	collectionName string,
+	query bson.M,
+	pageSize int,
+	pageToken []byte,
+	exclusiveMinTaskID int64,
+	inclusiveMaxTaskID int64,
+) ([]*nosqlplugin.ReplicationTask, []byte, error) {
+	var docs []*cadence.ReplicationTaskCollectionEntry
+	err := db.findTasksOrderByTaskID(ctx, collectionName, query, pageSize, pageToken, exclusiveMinTaskID, inclusiveMaxTaskID, &docs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tasks []*nosqlplugin.ReplicationTask
+	var lastTaskID int64
+	for _, doc := range docs {
+		tasks = append(tasks, doc.Task)
+		lastTaskID = doc.TaskID
+	}
+	nextPageToken, err := newTaskIDPageToken(pageSize, len(docs), lastTaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, nextPageToken, nil
+}
+
+// findTasksOrderByTaskID reads a page of tasks in range of (exclusiveMinTaskID, inclusiveMaxTaskID] into results
+func (db *mdb) findTasksOrderByTaskID(
+	ctx context.Context,
+	collectionName string,
+	query bson.M,
+	pageSize int,
+	pageToken []byte,
+	exclusiveMinTaskID int64,
+	inclusiveMaxTaskID int64,
+	results interface{},
+) error {
+	var lastTaskID int64
+	hasToken, err := decodePageToken(pageToken, &lastTaskID)
+	if err != nil {
+		return err
+	}
+	if hasToken && lastTaskID > exclusiveMinTaskID {
+		exclusiveMinTaskID = lastTaskID
+	}
+	query["taskid"] = taskIDRangeFilter(exclusiveMinTaskID, inclusiveMaxTaskID)
+
+	collection := db.dbConn.Collection(collectionName)
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "taskid", Value: 1}}).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+func newTaskIDPageToken(pageSize, count int, lastTaskID int64) ([]byte, error) {
+	if count > 0 && count == pageSize {
+		return newPageToken(lastTaskID)
+	}
+	return nil, nil
 }
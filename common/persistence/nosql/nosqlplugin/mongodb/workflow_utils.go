@@ -0,0 +1,464 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+// Portions of the Software are attributed to Copyright (c) 2020 Temporal Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mongodb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/nosql/nosqlplugin"
+	"github.com/uber/cadence/schema/mongodb/cadence"
+)
+
+func (db *mdb) createOrUpdateCurrentWorkflow(
+	sessCtx mongo.SessionContext,
+	shardID int,
+	domainID string,
+	workflowID string,
+	request *nosqlplugin.CurrentWorkflowWriteRequest,
+) error {
+	collection := db.dbConn.Collection(cadence.CurrentWorkflowCollectionName)
+	filter := bson.M{"shardid": shardID, "domainid": domainID, "workflowid": workflowID}
+	doc := &cadence.CurrentWorkflowCollectionEntry{
+		ShardID:          shardID,
+		DomainID:         domainID,
+		WorkflowID:       workflowID,
+		RunID:            request.Row.RunID,
+		State:            request.Row.State,
+		CloseStatus:      request.Row.CloseStatus,
+		CreateRequestID:  request.Row.CreateRequestID,
+		LastWriteVersion: request.Row.LastWriteVersion,
+	}
+
+	switch request.WriteMode {
+	case nosqlplugin.CurrentWorkflowWriteModeNoop:
+		return nil
+	case nosqlplugin.CurrentWorkflowWriteModeInsert:
+		var previous cadence.CurrentWorkflowCollectionEntry
+		err := collection.FindOne(sessCtx, filter).Decode(&previous)
+		if err == nil {
+			msg := fmt.Sprintf("Workflow execution already running. WorkflowId: %v, RunId: %v, CreateRequestId: %v",
+				workflowID, previous.RunID, previous.CreateRequestID)
+			return &nosqlplugin.WorkflowOperationConditionFailure{
+				WorkflowExecutionAlreadyExists: &nosqlplugin.WorkflowExecutionAlreadyExists{
+					OtherInfo:        msg,
+					CreateRequestID:  previous.CreateRequestID,
+					RunID:            previous.RunID,
+					State:            previous.State,
+					CloseStatus:      previous.CloseStatus,
+					LastWriteVersion: previous.LastWriteVersion,
+				},
+			}
+		}
+		if !db.IsNotFoundError(err) {
+			return err
+		}
+		_, err = collection.InsertOne(sessCtx, doc)
+		return err
+	case nosqlplugin.CurrentWorkflowWriteModeUpdate:
+		if request.Condition == nil || request.Condition.GetCurrentRunID() == "" {
+			return fmt.Errorf("CurrentWorkflowWriteModeUpdate require Condition.CurrentRunID")
+		}
+		filter["runid"] = *request.Condition.CurrentRunID
+		if request.Condition.LastWriteVersion != nil && request.Condition.State != nil {
+			filter["lastwriteversion"] = *request.Condition.LastWriteVersion
+			filter["state"] = *request.Condition.State
+		}
+		result, err := collection.ReplaceOne(sessCtx, filter, doc)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			var previous cadence.CurrentWorkflowCollectionEntry
+			err := collection.FindOne(sessCtx, bson.M{"shardid": shardID, "domainid": domainID, "workflowid": workflowID}).Decode(&previous)
+			if err != nil && !db.IsNotFoundError(err) {
+				return err
+			}
+			msg := fmt.Sprintf("Workflow execution creation condition failed. WorkflowId: %v, Expected Current RunID: %v, Actual Current RunID: %v, Actual LastWriteVersion: %v, Actual State: %v",
+				workflowID, request.Condition.GetCurrentRunID(), previous.RunID, previous.LastWriteVersion, previous.State)
+			return &nosqlplugin.WorkflowOperationConditionFailure{
+				CurrentWorkflowConditionFailInfo: &msg,
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %v", request.WriteMode)
+	}
+}
+
+func (db *mdb) createWorkflowExecution(
+	sessCtx mongo.SessionContext,
+	shardID int,
+	execution *nosqlplugin.WorkflowExecutionRequest,
+) error {
+	if execution.EventBufferWriteMode != nosqlplugin.EventBufferWriteModeNone {
+		return fmt.Errorf("should only support EventBufferWriteModeNone")
+	}
+	if execution.MapsWriteMode != nosqlplugin.WorkflowExecutionMapsWriteModeCreate {
+		return fmt.Errorf("should only support WorkflowExecutionMapsWriteModeCreate")
+	}
+
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	filter := workflowExecutionFilter(shardID, execution.DomainID, execution.WorkflowID, execution.RunID)
+	var previous cadence.WorkflowExecutionCollectionEntry
+	err := collection.FindOne(sessCtx, filter).Decode(&previous)
+	if err == nil {
+		msg := fmt.Sprintf("Workflow execution already running. WorkflowId: %v, RunId: %v",
+			execution.WorkflowID, execution.RunID)
+		return &nosqlplugin.WorkflowOperationConditionFailure{
+			WorkflowExecutionAlreadyExists: &nosqlplugin.WorkflowExecutionAlreadyExists{
+				OtherInfo:        msg,
+				CreateRequestID:  execution.CreateRequestID,
+				RunID:            execution.RunID,
+				State:            execution.State,
+				CloseStatus:      execution.CloseStatus,
+				LastWriteVersion: previous.LastWriteVersion,
+			},
+		}
+	}
+	if !db.IsNotFoundError(err) {
+		return err
+	}
+
+	versionHistories, versionHistoriesEncoding := persistence.FromDataBlob(execution.VersionHistories)
+	info := execution.InternalWorkflowExecutionInfo
+	doc := &cadence.WorkflowExecutionCollectionEntry{
+		ShardID:                  shardID,
+		DomainID:                 execution.DomainID,
+		WorkflowID:               execution.WorkflowID,
+		RunID:                    execution.RunID,
+		NextEventID:              execution.NextEventID,
+		LastWriteVersion:         execution.LastWriteVersion,
+		Execution:                &info,
+		VersionHistories:         versionHistories,
+		VersionHistoriesEncoding: versionHistoriesEncoding,
+		ActivityInfos:            execution.ActivityInfos,
+		TimerInfos:               encodeTimerInfos(execution.TimerInfos),
+		ChildExecutionInfos:      execution.ChildWorkflowInfos,
+		RequestCancelInfos:       execution.RequestCancelInfos,
+		SignalInfos:              execution.SignalInfos,
+		SignalRequestedIDs:       execution.SignalRequestedIDs,
+		BufferedEvents:           []*persistence.DataBlob{},
+	}
+	if execution.Checksums != nil {
+		doc.Checksum = *execution.Checksums
+	}
+	// maps and lists must not be null so that they can be updated by keys later
+	if doc.ActivityInfos == nil {
+		doc.ActivityInfos = map[int64]*persistence.InternalActivityInfo{}
+	}
+	if doc.ChildExecutionInfos == nil {
+		doc.ChildExecutionInfos = map[int64]*persistence.InternalChildExecutionInfo{}
+	}
+	if doc.RequestCancelInfos == nil {
+		doc.RequestCancelInfos = map[int64]*persistence.RequestCancelInfo{}
+	}
+	if doc.SignalInfos == nil {
+		doc.SignalInfos = map[int64]*persistence.SignalInfo{}
+	}
+	if doc.SignalRequestedIDs == nil {
+		doc.SignalRequestedIDs = []string{}
+	}
+	_, err = collection.InsertOne(sessCtx, doc)
+	return err
+}
+
+// updateWorkflowExecution updates the workflow execution with the condition of nextEventID
+// For WorkflowExecutionMapsWriteModeUpdate, maps are merged and deleted by keys;
+// for WorkflowExecutionMapsWriteModeReset, maps are overridden by the whole
+func (db *mdb) updateWorkflowExecution(
+	sessCtx mongo.SessionContext,
+	shardID int,
+	execution *nosqlplugin.WorkflowExecutionRequest,
+) error {
+	versionHistories, versionHistoriesEncoding := persistence.FromDataBlob(execution.VersionHistories)
+	info := execution.InternalWorkflowExecutionInfo
+	set := bson.M{
+		"nexteventid":              execution.NextEventID,
+		"lastwriteversion":         execution.LastWriteVersion,
+		"execution":                &info,
+		"versionhistories":         versionHistories,
+		"versionhistoriesencoding": versionHistoriesEncoding,
+	}
+	if execution.Checksums != nil {
+		set["checksum"] = *execution.Checksums
+	}
+	update := bson.M{}
+	unset := bson.M{}
+	var signalRequestedIDsToDelete []string
+
+	switch execution.MapsWriteMode {
+	case nosqlplugin.WorkflowExecutionMapsWriteModeUpdate:
+		for k, v := range execution.ActivityInfos {
+			set["activityinfos."+strconv.FormatInt(k, 10)] = v
+		}
+		for k, v := range execution.TimerInfos {
+			set["timerinfos."+encodeTimerID(k)] = v
+		}
+		for k, v := range execution.ChildWorkflowInfos {
+			set["childexecutioninfos."+strconv.FormatInt(k, 10)] = v
+		}
+		for k, v := range execution.RequestCancelInfos {
+			set["requestcancelinfos."+strconv.FormatInt(k, 10)] = v
+		}
+		for k, v := range execution.SignalInfos {
+			set["signalinfos."+strconv.FormatInt(k, 10)] = v
+		}
+		if len(execution.SignalRequestedIDs) > 0 {
+			update["$addToSet"] = bson.M{"signalrequestedids": bson.M{"$each": execution.SignalRequestedIDs}}
+		}
+
+		for _, k := range execution.ActivityInfoKeysToDelete {
+			unset["activityinfos."+strconv.FormatInt(k, 10)] = ""
+		}
+		for _, k := range execution.TimerInfoKeysToDelete {
+			unset["timerinfos."+encodeTimerID(k)] = ""
+		}
+		for _, k := range execution.ChildWorkflowInfoKeysToDelete {
+			unset["childexecutioninfos."+strconv.FormatInt(k, 10)] = ""
+		}
+		for _, k := range execution.RequestCancelInfoKeysToDelete {
+			unset["requestcancelinfos."+strconv.FormatInt(k, 10)] = ""
+		}
+		for _, k := range execution.SignalInfoKeysToDelete {
+			unset["signalinfos."+strconv.FormatInt(k, 10)] = ""
+		}
+		signalRequestedIDsToDelete = execution.SignalRequestedIDsKeysToDelete
+	case nosqlplugin.WorkflowExecutionMapsWriteModeReset:
+		set["activityinfos"] = nonNilActivityInfos(execution.ActivityInfos)
+		set["timerinfos"] = encodeTimerInfos(execution.TimerInfos)
+		set["childexecutioninfos"] = nonNilChildExecutionInfos(execution.ChildWorkflowInfos)
+		set["requestcancelinfos"] = nonNilRequestCancelInfos(execution.RequestCancelInfos)
+		set["signalinfos"] = nonNilSignalInfos(execution.SignalInfos)
+		signalRequestedIDs := execution.SignalRequestedIDs
+		if signalRequestedIDs == nil {
+			signalRequestedIDs = []string{}
+		}
+		set["signalrequestedids"] = signalRequestedIDs
+	default:
+		return fmt.Errorf("unsupported WorkflowExecutionMapsWriteMode %v", execution.MapsWriteMode)
+	}
+
+	switch execution.EventBufferWriteMode {
+	case nosqlplugin.EventBufferWriteModeNone:
+	case nosqlplugin.EventBufferWriteModeAppend:
+		update["$push"] = bson.M{"bufferedevents": execution.NewBufferedEventBatch}
+	case nosqlplugin.EventBufferWriteModeClear:
+		set["bufferedevents"] = []*persistence.DataBlob{}
+	default:
+		return fmt.Errorf("unsupported EventBufferWriteMode %v", execution.EventBufferWriteMode)
+	}
+	update["$set"] = set
+
+	collection := db.dbConn.Collection(cadence.WorkflowExecutionCollectionName)
+	filter := workflowExecutionFilter(shardID, execution.DomainID, execution.WorkflowID, execution.RunID)
+	conditionFilter := workflowExecutionFilter(shardID, execution.DomainID, execution.WorkflowID, execution.RunID)
+	conditionFilter["nexteventid"] = *execution.PreviousNextEventIDCondition
+	result, err := collection.UpdateOne(sessCtx, conditionFilter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		var previous cadence.WorkflowExecutionCollectionEntry
+		err := collection.FindOne(sessCtx, filter).Decode(&previous)
+		if err != nil && !db.IsNotFoundError(err) {
+			return err
+		}
+		msg := fmt.Sprintf("Failed to update mutable state. previousNextEventIDCondition: %v, actualNextEventID: %v, Request Current RunID: %v",
+			*execution.PreviousNextEventIDCondition, previous.NextEventID, execution.RunID)
+		return &nosqlplugin.WorkflowOperationConditionFailure{
+			UnknownConditionFailureDetails: &msg,
+		}
+	}
+
+	// deletion must be in a separate update because the same key cannot be both set and unset in one update
+	if len(unset) > 0 || len(signalRequestedIDsToDelete) > 0 {
+		deletion := bson.M{}
+		if len(unset) > 0 {
+			deletion["$unset"] = unset
+		}
+		if len(signalRequestedIDsToDelete) > 0 {
+			deletion["$pullAll"] = bson.M{"signalrequestedids": signalRequestedIDsToDelete}
+		}
+		if _, err := collection.UpdateOne(sessCtx, filter, deletion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *mdb) createTasks(
+	sessCtx mongo.SessionContext,
+	shardID int,
+	transferTasks []*nosqlplugin.TransferTask,
+	crossClusterTasks []*nosqlplugin.CrossClusterTask,
+	replicationTasks []*nosqlplugin.ReplicationTask,
+	timerTasks []*nosqlplugin.TimerTask,
+) error {
+	if len(transferTasks) > 0 {
+		docs := make([]interface{}, 0, len(transferTasks))
+		for _, task := range transferTasks {
+			docs = append(docs, &cadence.TransferTaskCollectionEntry{
+				ShardID: shardID,
+				TaskID:  task.TaskID,
+				Task:    task,
+			})
+		}
+		if _, err := db.dbConn.Collection(cadence.TransferTaskCollectionName).InsertMany(sessCtx, docs); err != nil {
+			return err
+		}
+	}
+	if len(crossClusterTasks) > 0 {
+		docs := make([]interface{}, 0, len(crossClusterTasks))
+		for _, task := range crossClusterTasks {
+			transferTask := task.TransferTask
+			docs = append(docs, &cadence.CrossClusterTaskCollectionEntry{
+				ShardID:       shardID,
+				TargetCluster: task.TargetCluster,
+				TaskID:        task.TaskID,
+				Task:          &transferTask,
+			})
+		}
+		if _, err := db.dbConn.Collection(cadence.CrossClusterTaskCollectionName).InsertMany(sessCtx, docs); err != nil {
+			return err
+		}
+	}
+	if len(replicationTasks) > 0 {
+		docs := make([]interface{}, 0, len(replicationTasks))
+		for _, task := range replicationTasks {
+			docs = append(docs, &cadence.ReplicationTaskCollectionEntry{
+				ShardID: shardID,
+				TaskID:  task.TaskID,
+				Task:    task,
+			})
+		}
+		if _, err := db.dbConn.Collection(cadence.ReplicationTaskCollectionName).InsertMany(sessCtx, docs); err != nil {
+			return err
+		}
+	}
+	if len(timerTasks) > 0 {
+		docs := make([]interface{}, 0, len(timerTasks))
+		for _, task := range timerTasks {
+			docs = append(docs, &cadence.TimerTaskCollectionEntry{
+				ShardID:             shardID,
+				VisibilityTimestamp: task.VisibilityTimestamp,
+				TaskID:              task.TaskID,
+				Task:                task,
+			})
+		}
+		if _, err := db.dbConn.Collection(cadence.TimerTaskCollectionName).InsertMany(sessCtx, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func workflowExecutionFilter(shardID int, domainID, workflowID, runID string) bson.M {
+	return bson.M{"shardid": shardID, "domainid": domainID, "workflowid": workflowID, "runid": runID}
+}
+
+func fromWorkflowExecutionCollectionEntry(doc *cadence.WorkflowExecutionCollectionEntry) (*nosqlplugin.WorkflowExecution, error) {
+	timerInfos, err := decodeTimerInfos(doc.TimerInfos)
+	if err != nil {
+		return nil, err
+	}
+	signalRequestedIDs := make(map[string]struct{}, len(doc.SignalRequestedIDs))
+	for _, id := range doc.SignalRequestedIDs {
+		signalRequestedIDs[id] = struct{}{}
+	}
+	bufferedEvents := doc.BufferedEvents
+	if bufferedEvents == nil {
+		bufferedEvents = []*persistence.DataBlob{}
+	}
+	return &nosqlplugin.WorkflowExecution{
+		ExecutionInfo:       doc.Execution,
+		VersionHistories:    persistence.NewDataBlob(doc.VersionHistories, common.EncodingType(doc.VersionHistoriesEncoding)),
+		ActivityInfos:       nonNilActivityInfos(doc.ActivityInfos),
+		TimerInfos:          timerInfos,
+		ChildExecutionInfos: nonNilChildExecutionInfos(doc.ChildExecutionInfos),
+		RequestCancelInfos:  nonNilRequestCancelInfos(doc.RequestCancelInfos),
+		SignalInfos:         nonNilSignalInfos(doc.SignalInfos),
+		SignalRequestedIDs:  signalRequestedIDs,
+		BufferedEvents:      bufferedEvents,
+		Checksum:            doc.Checksum,
+	}, nil
+}
+
+// timerID is user provided and may contain characters(like '.' and '$') that are not allowed in document field name.
+// So it's hex encoded when used as key
+func encodeTimerID(timerID string) string {
+	return hex.EncodeToString([]byte(timerID))
+}
+
+func encodeTimerInfos(timerInfos map[string]*persistence.TimerInfo) map[string]*persistence.TimerInfo {
+	encoded := make(map[string]*persistence.TimerInfo, len(timerInfos))
+	for k, v := range timerInfos {
+		encoded[encodeTimerID(k)] = v
+	}
+	return encoded
+}
+
+func decodeTimerInfos(timerInfos map[string]*persistence.TimerInfo) (map[string]*persistence.TimerInfo, error) {
+	decoded := make(map[string]*persistence.TimerInfo, len(timerInfos))
+	for k, v := range timerInfos {
+		timerID, err := hex.DecodeString(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timerID key %v: %v", k, err)
+		}
+		decoded[string(timerID)] = v
+	}
+	return decoded, nil
+}
+
+func nonNilActivityInfos(m map[int64]*persistence.InternalActivityInfo) map[int64]*persistence.InternalActivityInfo {
+	if m == nil {
+		return map[int64]*persistence.InternalActivityInfo{}
+	}
+	return m
+}
+
+func nonNilChildExecutionInfos(m map[int64]*persistence.InternalChildExecutionInfo) map[int64]*persistence.InternalChildExecutionInfo {
+	if m == nil {
+		return map[int64]*persistence.InternalChildExecutionInfo{}
+	}
+	return m
+}
+
+func nonNilRequestCancelInfos(m map[int64]*persistence.RequestCancelInfo) map[int64]*persistence.RequestCancelInfo {
+	if m == nil {
+		return map[int64]*persistence.RequestCancelInfo{}
+	}
+	return m
+}
+
+func nonNilSignalInfos(m map[int64]*persistence.SignalInfo) map[int64]*persistence.SignalInfo {
+	if m == nil {
+		return map[int64]*persistence.SignalInfo{}
+	}
+	return m
+}
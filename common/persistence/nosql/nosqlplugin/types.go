@@ -304,6 +304,8 @@ const (
 	OpenByWorkflowID
 	ClosedByWorkflowID
 	ClosedByClosedStatus
+	OpenBySearchAttribute
+	ClosedBySearchAttribute
 )
 
 // enums of VisibilitySortType
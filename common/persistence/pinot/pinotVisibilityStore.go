@@ -24,12 +24,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/uber/cadence/.gen/go/indexer"
 	workflow "github.com/uber/cadence/.gen/go/shared"
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/messaging"
@@ -519,6 +521,12 @@ func (v *pinotVisibilityStore) ScanWorkflowExecutions(ctx context.Context, reque
 }
 
 func (v *pinotVisibilityStore) CountWorkflowExecutions(ctx context.Context, request *p.CountWorkflowExecutionsRequest) (*p.CountWorkflowExecutionsResponse, error) {
+	if request.GroupBy != "" {
+		if err := p.ValidateCountGroupBy(request.GroupBy, v.config.ValidSearchAttributes(), v.logger); err != nil {
+			return nil, err
+		}
+	}
+
 	query := v.getCountWorkflowExecutionsQuery(v.pinotClient.GetTableName(), request)
 
 	resp, err := v.pinotClient.CountByQuery(query)
@@ -528,9 +536,38 @@ func (v *pinotVisibilityStore) CountWorkflowExecutions(ctx context.Context, requ
 		}
 	}
 
-	return &p.CountWorkflowExecutionsResponse{
+	response := &p.CountWorkflowExecutionsResponse{
 		Count: resp,
-	}, nil
+	}
+	if request.GroupBy == "" {
+		return response, nil
+	}
+
+	// the total is counted separately, as groups beyond MaxWorkflowExecutionCountGroups are not returned
+	rows, err := v.pinotClient.SearchAggr(v.getCountWorkflowExecutionsGroupByQuery(v.pinotClient.GetTableName(), request))
+	if err != nil {
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("CountWorkflowExecutions failed, %v", err),
+		}
+	}
+	for _, row := range rows {
+		if len(row) != 2 {
+			return nil, &types.InternalServiceError{
+				Message: fmt.Sprintf("CountWorkflowExecutions got unexpected group by result %v", row),
+			}
+		}
+		count, err := strconv.ParseInt(fmt.Sprint(row[1]), 10, 64)
+		if err != nil {
+			return nil, &types.InternalServiceError{
+				Message: fmt.Sprintf("CountWorkflowExecutions can't convert group count to integer, %v", err),
+			}
+		}
+		response.Groups = append(response.Groups, &p.WorkflowExecutionCountGroup{
+			Value: p.WorkflowExecutionCountGroupValue(request.GroupBy, row[0]),
+			Count: count,
+		})
+	}
+	return response, nil
 }
 
 // a new function to create visibility message for deletion
@@ -676,6 +713,15 @@ func NewPinotCountQuery(tableName string) PinotQuery {
 	}
 }
 
+func NewPinotCountGroupByQuery(tableName string, groupBy string) PinotQuery {
+	return PinotQuery{
+		query:   fmt.Sprintf("SELECT %s, COUNT(*)\nFROM %s\n", groupBy, tableName),
+		filters: PinotQueryFilter{},
+		sorters: "",
+		limits:  "",
+	}
+}
+
 func (q *PinotQuery) String() string {
 	return fmt.Sprintf("%s%s%s%s", q.query, q.filters.string, q.sorters, q.limits)
 }
@@ -758,7 +804,30 @@ func (v *pinotVisibilityStore) getCountWorkflowExecutionsQuery(tableName string,
 	}
 
 	query := NewPinotCountQuery(tableName)
+	v.addCountWorkflowExecutionsFilters(&query, request)
+	return query.String()
+}
+
+func (v *pinotVisibilityStore) getCountWorkflowExecutionsGroupByQuery(tableName string, request *p.CountWorkflowExecutionsRequest) string {
+	if request == nil {
+		return ""
+	}
 
+	// custom search attributes are not columns, they are extracted from the Attr json
+	groupBy := request.GroupBy
+	if !definition.IsSystemIndexedKey(groupBy) {
+		groupBy = fmt.Sprintf("JSON_EXTRACT_SCALAR(Attr, '$.%s', 'STRING', '')", groupBy)
+	}
+
+	query := NewPinotCountGroupByQuery(tableName, groupBy)
+	v.addCountWorkflowExecutionsFilters(&query, request)
+	query.concatSorter(fmt.Sprintf("GROUP BY %s", groupBy))
+	query.concatSorter("ORDER BY COUNT(*) DESC")
+	query.addLimits(p.MaxWorkflowExecutionCountGroups)
+	return query.String()
+}
+
+func (v *pinotVisibilityStore) addCountWorkflowExecutionsFilters(query *PinotQuery, request *p.CountWorkflowExecutionsRequest) {
 	// need to add Domain ID
 	query.filters.addEqual(DomainID, request.DomainUUID)
 	query.filters.addEqual(IsDeleted, false)
@@ -767,7 +836,7 @@ func (v *pinotVisibilityStore) getCountWorkflowExecutionsQuery(tableName string,
 
 	// if customized query is empty, directly return
 	if requestQuery == "" {
-		return query.String()
+		return
 	}
 
 	requestQuery = filterPrefix(requestQuery)
@@ -781,8 +850,6 @@ func (v *pinotVisibilityStore) getCountWorkflowExecutionsQuery(tableName string,
 	if comparExpr != "" {
 		query.filters.addQuery(comparExpr)
 	}
-
-	return query.String()
 }
 
 func (v *pinotVisibilityStore) getListWorkflowExecutionsByQueryQuery(tableName string, request *p.ListWorkflowExecutionsByQueryRequest) (string, error) {
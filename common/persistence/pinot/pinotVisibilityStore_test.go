@@ -81,6 +81,45 @@ AND WorkflowID = 'wfid'
 	assert.Equal(t, nilResult, "")
 }
 
+func TestGetCountWorkflowExecutionsGroupByQuery(t *testing.T) {
+	request := &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainID,
+		Domain:     testDomain,
+		Query:      "WorkflowType = 'wt'",
+		GroupBy:    "CloseStatus",
+	}
+
+	result := visibilityStore.getCountWorkflowExecutionsGroupByQuery(testTableName, request)
+	expectResult := fmt.Sprintf(`SELECT CloseStatus, COUNT(*)
+FROM %s
+WHERE DomainID = 'bfd5c907-f899-4baf-a7b2-2ab85e623ebd'
+AND IsDeleted = false
+AND WorkflowType = 'wt'
+GROUP BY CloseStatus
+ORDER BY COUNT(*) DESC
+LIMIT 1000
+`, testTableName)
+
+	assert.Equal(t, expectResult, result)
+
+	request.GroupBy = "CustomKeywordField"
+	result = visibilityStore.getCountWorkflowExecutionsGroupByQuery(testTableName, request)
+	expectResult = fmt.Sprintf(`SELECT JSON_EXTRACT_SCALAR(Attr, '$.CustomKeywordField', 'STRING', ''), COUNT(*)
+FROM %s
+WHERE DomainID = 'bfd5c907-f899-4baf-a7b2-2ab85e623ebd'
+AND IsDeleted = false
+AND WorkflowType = 'wt'
+GROUP BY JSON_EXTRACT_SCALAR(Attr, '$.CustomKeywordField', 'STRING', '')
+ORDER BY COUNT(*) DESC
+LIMIT 1000
+`, testTableName)
+
+	assert.Equal(t, expectResult, result)
+
+	nilResult := visibilityStore.getCountWorkflowExecutionsGroupByQuery(testTableName, nil)
+	assert.Equal(t, "", nilResult)
+}
+
 func TestGetListWorkflowExecutionQuery(t *testing.T) {
 
 	token := pnt.PinotVisibilityPageToken{
@@ -128,13 +128,23 @@ func compareCloseStatus(esInput interface{}, pinotInput interface{}) error {
 		return fmt.Errorf("interface is not a pinot WorkflowExecutionCloseStatus! ")
 	}
 
-	if esStatus != pinotStatus {
+	if esStatus.String() != pinotStatus.String() {
 		return fmt.Errorf(fmt.Sprintf("Comparison Failed: WorkflowExecutionCloseStatus are not equal. ES value = %s, Pinot value = %s", esStatus, pinotStatus))
 	}
 
 	return nil
 }
 
+// CompareWorkflowExecutionInfo compares a visibility record read from the source store with the same record
+// read from the target store. Fields which are not set in the source record are not compared.
+// In the error message the source store is reported as ES and the target store as Pinot.
+func CompareWorkflowExecutionInfo(
+	source *types.WorkflowExecutionInfo,
+	target *types.WorkflowExecutionInfo,
+) error {
+	return compareListWorkflowExecutionInfo(source, target)
+}
+
 func compareListWorkflowExecutionInfo(
 	esExecutionInfo *types.WorkflowExecutionInfo,
 	pinotExecutionInfo *types.WorkflowExecutionInfo,
@@ -148,14 +158,14 @@ func compareListWorkflowExecutionInfo(
 		esValue := vOfES.Field(i).Interface()
 		pinotValue := vOfPinot.Field(i).Interface()
 
-		// if the value in ES is nil, then we don't need to compare
-		if esValue == nil {
+		// if the value in ES is not set, then we don't need to compare
+		if vOfES.Field(i).IsZero() {
 			continue
 		}
 
 		// if the value in ES is not nil but in pinot is nil, then there's an error
-		if pinotValue == nil {
-			return fmt.Errorf("Pinot result is nil while ES result is not. ")
+		if pinotField := vOfPinot.Field(i); (pinotField.Kind() == reflect.Ptr || pinotField.Kind() == reflect.Map) && pinotField.IsNil() {
+			return fmt.Errorf("Pinot result is nil while ES result is not. Field = %s", esFieldName)
 		}
 
 		switch strings.ToLower(esFieldName) {
@@ -182,8 +192,9 @@ func compareListWorkflowExecutionInfo(
 				return err
 			}
 		default:
-			if esValue != pinotValue {
-				return fmt.Errorf(fmt.Sprintf("Comparison Failed: response.%s are not equal. ES value = %s, Pinot value = %s", esFieldName, esValue, pinotValue))
+			if !reflect.DeepEqual(esValue, pinotValue) {
+				return fmt.Errorf(fmt.Sprintf("Comparison Failed: response.%s are not equal. ES value = %v, Pinot value = %v",
+					esFieldName, reflect.Indirect(vOfES.Field(i)), reflect.Indirect(vOfPinot.Field(i))))
 			}
 		}
 	}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -406,6 +407,41 @@ func TestCompareListWorkflowExecutionInfo(t *testing.T) {
 			},
 			expectedResult: fmt.Errorf("Comparison Failed: response.TestAttr1 are not equal. ES value = \"val1\", Pinot value = \"val2\""),
 		},
+		"Case7: pass case with equal values behind different pointers": {
+			esInfo: &types.WorkflowExecutionInfo{
+				Execution: &types.WorkflowExecution{
+					WorkflowID: testWorkflowID,
+					RunID:      testRunID,
+				},
+				StartTime:   common.Int64Ptr(testEarliestTime),
+				CloseStatus: types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+			},
+			pinotInfo: &types.WorkflowExecutionInfo{
+				Execution: &types.WorkflowExecution{
+					WorkflowID: testWorkflowID,
+					RunID:      testRunID,
+				},
+				StartTime:   common.Int64Ptr(testEarliestTime),
+				CloseStatus: types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+			},
+			expectedResult: nil,
+		},
+		"Case8: error case with wrong start time": {
+			esInfo: &types.WorkflowExecutionInfo{
+				StartTime: common.Int64Ptr(testEarliestTime),
+			},
+			pinotInfo: &types.WorkflowExecutionInfo{
+				StartTime: common.Int64Ptr(testLatestTime),
+			},
+			expectedResult: fmt.Errorf("Comparison Failed: response.StartTime are not equal. ES value = %v, Pinot value = %v", testEarliestTime, testLatestTime),
+		},
+		"Case9: error case with missing close time": {
+			esInfo: &types.WorkflowExecutionInfo{
+				CloseTime: common.Int64Ptr(testLatestTime),
+			},
+			pinotInfo:      &types.WorkflowExecutionInfo{},
+			expectedResult: fmt.Errorf("Pinot result is nil while ES result is not. Field = CloseTime"),
+		},
 	}
 
 	for name, test := range tests {
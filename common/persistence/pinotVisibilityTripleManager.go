@@ -240,6 +240,20 @@ func (v *pinotVisibilityTripleManager) chooseVisibilityModeForAdmin() string {
 }
 
 func (v *pinotVisibilityTripleManager) chooseVisibilityManagerForWrite(ctx context.Context, dbVisFunc, esVisFunc, pinotVisFunc func() error) error {
+	if store := getVisibilityStoreOverride(ctx); store != "" {
+		if _, err := chooseVisibilityManagerForStore(store, v.dbVisibilityManager, v.esVisibilityManager, v.pinotVisibilityManager); err != nil {
+			return err
+		}
+		switch store {
+		case VisibilityStoreDB:
+			return dbVisFunc()
+		case VisibilityStoreES:
+			return esVisFunc()
+		default:
+			return pinotVisFunc()
+		}
+	}
+
 	var writeMode string
 	if v.writeMode != nil {
 		writeMode = v.writeMode()
@@ -370,7 +384,10 @@ func (v *pinotVisibilityTripleManager) ListOpenWorkflowExecutions(
 		latestTime:   request.LatestTime,
 	}, request.Domain)
 
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutions(ctx, request)
 }
 
@@ -385,7 +402,10 @@ func (v *pinotVisibilityTripleManager) ListClosedWorkflowExecutions(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutions(ctx, request)
 }
 
@@ -401,7 +421,10 @@ func (v *pinotVisibilityTripleManager) ListOpenWorkflowExecutionsByType(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutionsByType(ctx, request)
 }
 
@@ -417,7 +440,10 @@ func (v *pinotVisibilityTripleManager) ListClosedWorkflowExecutionsByType(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByType(ctx, request)
 }
 
@@ -433,7 +459,10 @@ func (v *pinotVisibilityTripleManager) ListOpenWorkflowExecutionsByWorkflowID(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutionsByWorkflowID(ctx, request)
 }
 
@@ -449,7 +478,10 @@ func (v *pinotVisibilityTripleManager) ListClosedWorkflowExecutionsByWorkflowID(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByWorkflowID(ctx, request)
 }
 
@@ -464,7 +496,10 @@ func (v *pinotVisibilityTripleManager) ListClosedWorkflowExecutionsByStatus(
 		earliestTime: request.EarliestTime,
 		latestTime:   request.LatestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByStatus(ctx, request)
 }
 
@@ -482,7 +517,10 @@ func (v *pinotVisibilityTripleManager) GetClosedWorkflowExecution(
 		earliestTime: earlistTime,
 		latestTime:   latestTime,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.GetClosedWorkflowExecution(ctx, request)
 }
 
@@ -498,7 +536,10 @@ func (v *pinotVisibilityTripleManager) ListWorkflowExecutions(
 		earliestTime: -1,
 		latestTime:   -1,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListWorkflowExecutions(ctx, request)
 }
 
@@ -514,7 +555,10 @@ func (v *pinotVisibilityTripleManager) ScanWorkflowExecutions(
 		earliestTime: -1,
 		latestTime:   -1,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ScanWorkflowExecutions(ctx, request)
 }
 
@@ -530,17 +574,24 @@ func (v *pinotVisibilityTripleManager) CountWorkflowExecutions(
 		earliestTime: -1,
 		latestTime:   -1,
 	}, request.Domain)
-	manager := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.CountWorkflowExecutions(ctx, request)
 }
 
-func (v *pinotVisibilityTripleManager) chooseVisibilityManagerForRead(ctx context.Context, domain string) VisibilityManager {
+func (v *pinotVisibilityTripleManager) chooseVisibilityManagerForRead(ctx context.Context, domain string) (VisibilityManager, error) {
+	if store := getVisibilityStoreOverride(ctx); store != "" {
+		return chooseVisibilityManagerForStore(store, v.dbVisibilityManager, v.esVisibilityManager, v.pinotVisibilityManager)
+	}
+
 	if override := ctx.Value(ContextKey); override == VisibilityOverridePrimary {
 		v.logger.Info("Pinot Migration log: Primary visibility manager was chosen for read.")
-		return v.esVisibilityManager
+		return v.esVisibilityManager, nil
 	} else if override == VisibilityOverrideSecondary {
 		v.logger.Info("Pinot Migration log: Secondary visibility manager was chosen for read.")
-		return v.pinotVisibilityManager
+		return v.pinotVisibilityManager, nil
 	}
 
 	var visibilityMgr VisibilityManager
@@ -569,5 +620,5 @@ func (v *pinotVisibilityTripleManager) chooseVisibilityManagerForRead(ctx contex
 				tag.WorkflowDomainName(domain))
 		}
 	}
-	return visibilityMgr
+	return visibilityMgr, nil
 }
@@ -23,9 +23,16 @@
 package persistence
 
 import (
+	"context"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/types"
 )
 
 func TestFilterAttrPrefix(t *testing.T) {
@@ -60,3 +67,75 @@ func TestFilterAttrPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestTripleManagerVisibilityStoreOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbManager := NewMockVisibilityManager(ctrl)
+	esManager := NewMockVisibilityManager(ctrl)
+	pinotManager := NewMockVisibilityManager(ctrl)
+	manager := NewPinotVisibilityTripleManager(
+		dbManager,
+		pinotManager,
+		esManager,
+		dynamicconfig.GetBoolPropertyFnFilteredByDomain(true),
+		dynamicconfig.GetBoolPropertyFnFilteredByDomain(false),
+		dynamicconfig.GetStringPropertyFn(common.AdvancedVisibilityWritingModeTriple),
+		dynamicconfig.GetBoolPropertyFnFilteredByDomain(false),
+		log.NewNoop(),
+	)
+
+	readRequest := &ListWorkflowExecutionsRequest{Domain: "test-domain"}
+	writeRequest := &RecordWorkflowExecutionStartedRequest{Domain: "test-domain"}
+	response := &ListWorkflowExecutionsResponse{}
+
+	// without override, reads go to pinot and writes go to all stores
+	pinotManager.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), readRequest).Return(response, nil).Times(1)
+	dbManager.EXPECT().RecordWorkflowExecutionStarted(gomock.Any(), writeRequest).Return(nil).Times(1)
+	pinotManager.EXPECT().RecordWorkflowExecutionStarted(gomock.Any(), writeRequest).Return(nil).Times(1)
+	esManager.EXPECT().RecordWorkflowExecutionStarted(gomock.Any(), writeRequest).Return(nil).Times(1)
+	_, err := manager.ListOpenWorkflowExecutions(context.Background(), readRequest)
+	assert.NoError(t, err)
+	assert.NoError(t, manager.RecordWorkflowExecutionStarted(context.Background(), writeRequest))
+
+	// with override, reads and writes only go to the overridden store
+	ctx := ContextWithVisibilityStoreOverride(context.Background(), VisibilityStoreDB)
+	dbManager.EXPECT().ListOpenWorkflowExecutions(ctx, readRequest).Return(response, nil).Times(1)
+	dbManager.EXPECT().RecordWorkflowExecutionStarted(ctx, writeRequest).Return(nil).Times(1)
+	_, err = manager.ListOpenWorkflowExecutions(ctx, readRequest)
+	assert.NoError(t, err)
+	assert.NoError(t, manager.RecordWorkflowExecutionStarted(ctx, writeRequest))
+
+	ctx = ContextWithVisibilityStoreOverride(context.Background(), VisibilityStoreES)
+	esManager.EXPECT().ListOpenWorkflowExecutions(ctx, readRequest).Return(response, nil).Times(1)
+	_, err = manager.ListOpenWorkflowExecutions(ctx, readRequest)
+	assert.NoError(t, err)
+
+	ctx = ContextWithVisibilityStoreOverride(context.Background(), "unknown")
+	_, err = manager.ListOpenWorkflowExecutions(ctx, readRequest)
+	assert.IsType(t, &types.BadRequestError{}, err)
+	assert.IsType(t, &types.BadRequestError{}, manager.RecordWorkflowExecutionStarted(ctx, writeRequest))
+}
+
+func TestDualManagerVisibilityStoreOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbManager := NewMockVisibilityManager(ctrl)
+	manager := NewVisibilityDualManager(
+		dbManager,
+		nil,
+		dynamicconfig.GetBoolPropertyFnFilteredByDomain(false),
+		dynamicconfig.GetStringPropertyFn(common.AdvancedVisibilityWritingModeOff),
+		log.NewNoop(),
+	)
+
+	readRequest := &ListWorkflowExecutionsRequest{Domain: "test-domain"}
+	ctx := ContextWithVisibilityStoreOverride(context.Background(), VisibilityStoreDB)
+	dbManager.EXPECT().ListOpenWorkflowExecutions(ctx, readRequest).Return(&ListWorkflowExecutionsResponse{}, nil).Times(1)
+	_, err := manager.ListOpenWorkflowExecutions(ctx, readRequest)
+	assert.NoError(t, err)
+
+	// es is not configured, so the override must fail instead of falling back to db
+	ctx = ContextWithVisibilityStoreOverride(context.Background(), VisibilityStoreES)
+	_, err = manager.ListOpenWorkflowExecutions(ctx, readRequest)
+	assert.IsType(t, &types.BadRequestError{}, err)
+	assert.IsType(t, &types.BadRequestError{}, manager.RecordWorkflowExecutionStarted(ctx, &RecordWorkflowExecutionStartedRequest{}))
+}
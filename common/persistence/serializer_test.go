@@ -50,6 +50,9 @@ var encodingTypes = map[common.EncodingType]bool{
 	common.EncodingTypeJSON:     true,
 	common.EncodingTypeThriftRW: true,
 	common.EncodingTypeGob:      false,
+
+	common.EncodingTypeThriftRWSnappy: true,
+	common.EncodingTypeThriftRWZstd:   true,
 }
 
 type runnableTest struct {
@@ -127,6 +127,7 @@ func convertCommonErrors(
 		*persistence.TimeoutError,
 		*types.DomainAlreadyExistsError,
 		*types.EntityNotExistsError,
+		*types.BadRequestError,
 		*types.ServiceBusyError,
 		*types.InternalServiceError:
 		return err
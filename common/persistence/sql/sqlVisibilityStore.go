@@ -21,6 +21,7 @@
 package sql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -31,6 +32,7 @@ import (
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
 	p "github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
 	"github.com/uber/cadence/common/types"
@@ -66,7 +68,11 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionStarted(
 	ctx context.Context,
 	request *p.InternalRecordWorkflowExecutionStartedRequest,
 ) error {
-	_, err := s.db.InsertIntoVisibility(ctx, &sqlplugin.VisibilityRow{
+	searchAttributes, err := serializeSearchAttributes(request.SearchAttributes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.InsertIntoVisibility(ctx, &sqlplugin.VisibilityRow{
 		DomainID:         request.DomainUUID,
 		WorkflowID:       request.WorkflowID,
 		RunID:            request.RunID,
@@ -79,6 +85,7 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionStarted(
 		NumClusters:      request.NumClusters,
 		UpdateTime:       request.UpdateTimestamp,
 		ShardID:          request.ShardID,
+		SearchAttributes: searchAttributes,
 	})
 
 	if err != nil {
@@ -91,6 +98,10 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionClosed(
 	ctx context.Context,
 	request *p.InternalRecordWorkflowExecutionClosedRequest,
 ) error {
+	searchAttributes, err := serializeSearchAttributes(request.SearchAttributes)
+	if err != nil {
+		return err
+	}
 	closeTime := request.CloseTimestamp
 	result, err := s.db.ReplaceIntoVisibility(ctx, &sqlplugin.VisibilityRow{
 		DomainID:         request.DomainUUID,
@@ -108,6 +119,7 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionClosed(
 		NumClusters:      request.NumClusters,
 		UpdateTime:       request.UpdateTimestamp,
 		ShardID:          request.ShardID,
+		SearchAttributes: searchAttributes,
 	})
 	if err != nil {
 		return convertCommonErrors(s.db, "RecordWorkflowExecutionClosed", "", err)
@@ -135,13 +147,33 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionUninitialized(
 }
 
 func (s *sqlVisibilityStore) UpsertWorkflowExecution(
-	_ context.Context,
+	ctx context.Context,
 	request *p.InternalUpsertWorkflowExecutionRequest,
 ) error {
-	if p.IsNopUpsertWorkflowRequest(request) {
+	if len(request.SearchAttributes) == 0 {
+		// like the other visibility stores, an upsert must carry search attributes
+		return p.ErrVisibilityOperationNotSupported
+	}
+	searchAttributes, err := serializeSearchAttributes(request.SearchAttributes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.UpdateVisibility(ctx, &sqlplugin.VisibilityRow{
+		DomainID:         request.DomainUUID,
+		RunID:            request.RunID,
+		Memo:             request.Memo.Data,
+		Encoding:         string(request.Memo.GetEncoding()),
+		UpdateTime:       request.UpdateTimestamp,
+		SearchAttributes: searchAttributes,
+	})
+	if err == p.ErrVisibilityOperationNotSupported && p.IsNopUpsertWorkflowRequest(request) {
+		// plugins not storing search attributes keep ignoring the upserts of workflow versioning
 		return nil
 	}
-	return p.ErrVisibilityOperationNotSupported
+	if err != nil {
+		return convertCommonErrors(s.db, "UpsertWorkflowExecution", "", err)
+	}
+	return nil
 }
 
 func (s *sqlVisibilityStore) ListOpenWorkflowExecutions(
@@ -313,24 +345,110 @@ func (s *sqlVisibilityStore) DeleteUninitializedWorkflowExecution(
 }
 
 func (s *sqlVisibilityStore) ListWorkflowExecutions(
-	_ context.Context,
-	_ *p.ListWorkflowExecutionsByQueryRequest,
+	ctx context.Context,
+	request *p.ListWorkflowExecutionsByQueryRequest,
 ) (*p.InternalListWorkflowExecutionsResponse, error) {
-	return nil, p.ErrVisibilityOperationNotSupported
+	query, err := sqlplugin.ParseVisibilityQuery(request.Query)
+	if err != nil {
+		return nil, err
+	}
+	filter := &sqlplugin.VisibilityQueryFilter{
+		DomainID: request.DomainUUID,
+		Query:    query,
+		PageSize: request.PageSize,
+	}
+	if len(request.NextPageToken) > 0 {
+		filter.After, err = sqlplugin.ParseVisibilityQueryCursor(query, request.NextPageToken)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: fmt.Sprintf("invalid next page token: %v", err)}
+		}
+	}
+	rows, err := s.db.SelectFromVisibilityByQuery(ctx, filter)
+	if err != nil {
+		return nil, convertCommonErrors(s.db, "ListWorkflowExecutions", "", err)
+	}
+
+	var nextPageToken []byte
+	if len(rows) == request.PageSize {
+		// the next page starts after the last row, so that rows added or removed meanwhile don't shift it
+		cursor, err := sqlplugin.NewVisibilityQueryCursor(query, &rows[len(rows)-1])
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		nextPageToken, err = json.Marshal(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &p.InternalListWorkflowExecutionsResponse{
+		Executions:    s.rowsToInfos(rows),
+		NextPageToken: nextPageToken,
+	}, nil
 }
 
 func (s *sqlVisibilityStore) ScanWorkflowExecutions(
-	_ context.Context,
-	_ *p.ListWorkflowExecutionsByQueryRequest,
+	ctx context.Context,
+	request *p.ListWorkflowExecutionsByQueryRequest,
 ) (*p.InternalListWorkflowExecutionsResponse, error) {
-	return nil, p.ErrVisibilityOperationNotSupported
+	query, err := sqlplugin.ParseVisibilityQuery(request.Query)
+	if err != nil {
+		return nil, err
+	}
+	filter := &sqlplugin.VisibilityQueryFilter{
+		DomainID: request.DomainUUID,
+		Query:    query,
+		PageSize: request.PageSize,
+		Scan:     true,
+	}
+	if len(request.NextPageToken) > 0 {
+		token, err := s.deserializePageToken(request.NextPageToken)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: fmt.Sprintf("invalid next page token: %v", err)}
+		}
+		filter.LastStartTime = &token.Time
+		filter.LastRunID = &token.RunID
+	}
+	rows, err := s.db.SelectFromVisibilityByQuery(ctx, filter)
+	if err != nil {
+		return nil, convertCommonErrors(s.db, "ScanWorkflowExecutions", "", err)
+	}
+
+	var nextPageToken []byte
+	if len(rows) == request.PageSize {
+		lastRow := rows[len(rows)-1]
+		nextPageToken, err = s.serializePageToken(&visibilityPageToken{
+			Time:  lastRow.StartTime,
+			RunID: lastRow.RunID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &p.InternalListWorkflowExecutionsResponse{
+		Executions:    s.rowsToInfos(rows),
+		NextPageToken: nextPageToken,
+	}, nil
 }
 
 func (s *sqlVisibilityStore) CountWorkflowExecutions(
-	_ context.Context,
-	_ *p.CountWorkflowExecutionsRequest,
+	ctx context.Context,
+	request *p.CountWorkflowExecutionsRequest,
 ) (*p.CountWorkflowExecutionsResponse, error) {
-	return nil, p.ErrVisibilityOperationNotSupported
+	if request.GroupBy != "" {
+		return nil, p.ErrVisibilityOperationNotSupported
+	}
+	query, err := sqlplugin.ParseVisibilityQuery(request.Query)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.db.CountFromVisibilityByQuery(ctx, &sqlplugin.VisibilityQueryFilter{
+		DomainID: request.DomainUUID,
+		Query:    query,
+	})
+	if err != nil {
+		return nil, convertCommonErrors(s.db, "CountWorkflowExecutions", "", err)
+	}
+	return &p.CountWorkflowExecutionsResponse{Count: count}, nil
 }
 
 func (s *sqlVisibilityStore) rowToInfo(row *sqlplugin.VisibilityRow) *p.InternalVisibilityWorkflowExecutionInfo {
@@ -355,9 +473,24 @@ func (s *sqlVisibilityStore) rowToInfo(row *sqlplugin.VisibilityRow) *p.Internal
 		info.CloseTime = *row.CloseTime
 		info.HistoryLength = *row.HistoryLength
 	}
+	if len(row.SearchAttributes) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(row.SearchAttributes))
+		decoder.UseNumber()
+		if err := decoder.Decode(&info.SearchAttributes); err != nil {
+			s.logger.Error("failed to deserialize search attributes", tag.Error(err), tag.WorkflowRunID(row.RunID))
+		}
+	}
 	return info
 }
 
+func (s *sqlVisibilityStore) rowsToInfos(rows []sqlplugin.VisibilityRow) []*p.InternalVisibilityWorkflowExecutionInfo {
+	infos := make([]*p.InternalVisibilityWorkflowExecutionInfo, len(rows))
+	for i := range rows {
+		infos[i] = s.rowToInfo(&rows[i])
+	}
+	return infos
+}
+
 func (s *sqlVisibilityStore) listWorkflowExecutions(opName string, pageToken []byte, earliestTime time.Time, latestTime time.Time, selectOp func(readLevel *visibilityPageToken) ([]sqlplugin.VisibilityRow, error)) (*p.InternalListWorkflowExecutionsResponse, error) {
 	var readLevel *visibilityPageToken
 	var err error
@@ -409,3 +542,32 @@ func (s *sqlVisibilityStore) serializePageToken(token *visibilityPageToken) ([]b
 	data, err := json.Marshal(token)
 	return data, err
 }
+
+// serializeSearchAttributes encodes search attributes, whose values are already JSON encoded, as one JSON object
+func serializeSearchAttributes(searchAttributes map[string][]byte) ([]byte, error) {
+	if len(searchAttributes) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]json.RawMessage, len(searchAttributes))
+	for key, value := range searchAttributes {
+		if json.Valid(value) {
+			fields[key] = value
+			continue
+		}
+		// values which aren't JSON, like the ones of workflow versioning, are stored as JSON strings
+		text, err := json.Marshal(string(value))
+		if err != nil {
+			return nil, &types.InternalServiceError{
+				Message: fmt.Sprintf("failed to serialize search attribute %s: %v", key, err),
+			}
+		}
+		fields[key] = text
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("failed to serialize search attributes: %v", err),
+		}
+	}
+	return data, nil
+}
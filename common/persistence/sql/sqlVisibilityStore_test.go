@@ -0,0 +1,233 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/log"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestSQLVisibilityStore_ListWorkflowExecutions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDB := sqlplugin.NewMockDB(ctrl)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: mockDB, logger: log.NewNoop()}}
+
+	rows := []sqlplugin.VisibilityRow{
+		{WorkflowID: "wid-1", RunID: "rid-1", StartTime: time.Unix(10, 0), SearchAttributes: []byte(`{"CustomIntField":1}`)},
+		{WorkflowID: "wid-2", RunID: "rid-2", StartTime: time.Unix(5, 0)},
+	}
+	mockDB.EXPECT().SelectFromVisibilityByQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+			assert.Equal(t, "domain-id", filter.DomainID)
+			assert.Equal(t, 2, filter.PageSize)
+			assert.Nil(t, filter.After)
+			assert.False(t, filter.Scan)
+			return rows, nil
+		})
+	mockDB.EXPECT().SelectFromVisibilityByQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+			// the next page starts after the last row of the previous one
+			require.NotNil(t, filter.After)
+			assert.Equal(t, []interface{}{time.Unix(5, 0).UnixNano()}, filter.After.SortValues)
+			assert.Equal(t, "rid-2", filter.After.RunID)
+			return rows[:1], nil
+		})
+
+	request := &p.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID: "domain-id",
+		PageSize:   2,
+		Query:      "WorkflowType = 'wt'",
+	}
+	resp, err := store.ListWorkflowExecutions(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, resp.Executions, 2)
+	assert.Equal(t, "rid-1", resp.Executions[0].RunID)
+	assert.Equal(t, map[string]interface{}{definition.CustomIntField: json.Number("1")}, resp.Executions[0].SearchAttributes)
+	assert.Nil(t, resp.Executions[1].SearchAttributes)
+	require.NotNil(t, resp.NextPageToken)
+
+	request.NextPageToken = resp.NextPageToken
+	resp, err = store.ListWorkflowExecutions(context.Background(), request)
+	require.NoError(t, err)
+	assert.Len(t, resp.Executions, 1)
+	assert.Nil(t, resp.NextPageToken)
+}
+
+func TestSQLVisibilityStore_ListWorkflowExecutions_InvalidPageToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: sqlplugin.NewMockDB(ctrl), logger: log.NewNoop()}}
+
+	// the token of a query sorted by another number of keys
+	_, err := store.ListWorkflowExecutions(context.Background(), &p.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID:    "domain-id",
+		PageSize:      10,
+		Query:         "order by CloseTime desc, WorkflowID",
+		NextPageToken: []byte(`{"SortValues":[1700000000000000000],"RunID":"rid"}`),
+	})
+	assert.IsType(t, &types.BadRequestError{}, err)
+}
+
+func TestSQLVisibilityStore_ListWorkflowExecutions_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: sqlplugin.NewMockDB(ctrl), logger: log.NewNoop()}}
+
+	_, err := store.ListWorkflowExecutions(context.Background(), &p.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID: "domain-id",
+		PageSize:   10,
+		Query:      "WorkflowType like 'wt'",
+	})
+	assert.IsType(t, &types.BadRequestError{}, err)
+}
+
+func TestSQLVisibilityStore_ScanWorkflowExecutions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDB := sqlplugin.NewMockDB(ctrl)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: mockDB, logger: log.NewNoop()}}
+
+	lastStartTime := time.Unix(5, 0)
+	mockDB.EXPECT().SelectFromVisibilityByQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+			assert.True(t, filter.Scan)
+			assert.Nil(t, filter.LastStartTime)
+			assert.Nil(t, filter.LastRunID)
+			return []sqlplugin.VisibilityRow{{RunID: "rid-1", StartTime: lastStartTime}}, nil
+		})
+	mockDB.EXPECT().SelectFromVisibilityByQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+			assert.True(t, filter.Scan)
+			assert.True(t, lastStartTime.Equal(*filter.LastStartTime))
+			assert.Equal(t, "rid-1", *filter.LastRunID)
+			return nil, nil
+		})
+
+	request := &p.ListWorkflowExecutionsByQueryRequest{
+		DomainUUID: "domain-id",
+		PageSize:   1,
+	}
+	resp, err := store.ScanWorkflowExecutions(context.Background(), request)
+	require.NoError(t, err)
+	assert.Len(t, resp.Executions, 1)
+	require.NotNil(t, resp.NextPageToken)
+
+	request.NextPageToken = resp.NextPageToken
+	resp, err = store.ScanWorkflowExecutions(context.Background(), request)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Executions)
+	assert.Nil(t, resp.NextPageToken)
+}
+
+func TestSQLVisibilityStore_CountWorkflowExecutions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDB := sqlplugin.NewMockDB(ctrl)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: mockDB, logger: log.NewNoop()}}
+
+	mockDB.EXPECT().CountFromVisibilityByQuery(gomock.Any(), gomock.Any()).Return(int64(10), nil)
+
+	resp, err := store.CountWorkflowExecutions(context.Background(), &p.CountWorkflowExecutionsRequest{
+		DomainUUID: "domain-id",
+		Query:      "CloseTime = missing",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), resp.Count)
+}
+
+func TestSQLVisibilityStore_UpsertWorkflowExecution(t *testing.T) {
+	tests := map[string]struct {
+		searchAttributes map[string][]byte
+		stored           map[string]string
+		updateErr        error
+		err              error
+	}{
+		"search attributes are stored": {
+			searchAttributes: map[string][]byte{definition.CustomKeywordField: []byte(`"keyword"`)},
+		},
+		"values which aren't JSON are stored as strings": {
+			searchAttributes: map[string][]byte{definition.CadenceChangeVersion: []byte("dummy")},
+			stored:           map[string]string{definition.CadenceChangeVersion: `"dummy"`},
+		},
+		"workflow versioning upsert is ignored if not supported": {
+			searchAttributes: map[string][]byte{definition.CadenceChangeVersion: []byte(`["v1"]`)},
+			updateErr:        p.ErrVisibilityOperationNotSupported,
+		},
+		"not supported": {
+			searchAttributes: map[string][]byte{definition.CustomKeywordField: []byte(`"keyword"`)},
+			updateErr:        p.ErrVisibilityOperationNotSupported,
+			err:              p.ErrVisibilityOperationNotSupported,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDB := sqlplugin.NewMockDB(ctrl)
+			store := &sqlVisibilityStore{sqlStore: sqlStore{db: mockDB, logger: log.NewNoop()}}
+
+			mockDB.EXPECT().UpdateVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, row *sqlplugin.VisibilityRow) (interface{}, error) {
+					var searchAttributes map[string]json.RawMessage
+					require.NoError(t, json.Unmarshal(row.SearchAttributes, &searchAttributes))
+					for key, value := range test.searchAttributes {
+						expected, ok := test.stored[key]
+						if !ok {
+							expected = string(value)
+						}
+						assert.JSONEq(t, expected, string(searchAttributes[key]))
+					}
+					return nil, test.updateErr
+				})
+
+			err := store.UpsertWorkflowExecution(context.Background(), &p.InternalUpsertWorkflowExecutionRequest{
+				DomainUUID:       "domain-id",
+				RunID:            "rid",
+				Memo:             &p.DataBlob{Encoding: common.EncodingTypeThriftRW},
+				SearchAttributes: test.searchAttributes,
+			})
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestSQLVisibilityStore_UpsertWorkflowExecution_NoSearchAttributes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := &sqlVisibilityStore{sqlStore: sqlStore{db: sqlplugin.NewMockDB(ctrl), logger: log.NewNoop()}}
+
+	err := store.UpsertWorkflowExecution(context.Background(), &p.InternalUpsertWorkflowExecutionRequest{
+		DomainUUID: "domain-id",
+		RunID:      "rid",
+		Memo:       &p.DataBlob{Encoding: common.EncodingTypeThriftRW},
+	})
+	assert.Equal(t, p.ErrVisibilityOperationNotSupported, err)
+}
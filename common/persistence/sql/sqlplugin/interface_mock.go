@@ -114,6 +114,21 @@ func (m *MocktableCRUD) EXPECT() *MocktableCRUDMockRecorder {
 	return m.recorder
 }
 
+// CountFromVisibilityByQuery mocks base method.
+func (m *MocktableCRUD) CountFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFromVisibilityByQuery indicates an expected call of CountFromVisibilityByQuery.
+func (mr *MocktableCRUDMockRecorder) CountFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFromVisibilityByQuery", reflect.TypeOf((*MocktableCRUD)(nil).CountFromVisibilityByQuery), ctx, filter)
+}
+
 // DeleteFromActivityInfoMaps mocks base method.
 func (m *MocktableCRUD) DeleteFromActivityInfoMaps(ctx context.Context, filter *ActivityInfoMapsFilter) (sql.Result, error) {
 	m.ctrl.T.Helper()
@@ -1506,6 +1521,21 @@ func (mr *MocktableCRUDMockRecorder) SelectFromVisibility(ctx, filter interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibility", reflect.TypeOf((*MocktableCRUD)(nil).SelectFromVisibility), ctx, filter)
 }
 
+// SelectFromVisibilityByQuery mocks base method.
+func (m *MocktableCRUD) SelectFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) ([]VisibilityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].([]VisibilityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectFromVisibilityByQuery indicates an expected call of SelectFromVisibilityByQuery.
+func (mr *MocktableCRUDMockRecorder) SelectFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibilityByQuery", reflect.TypeOf((*MocktableCRUD)(nil).SelectFromVisibilityByQuery), ctx, filter)
+}
+
 // SelectLatestConfig mocks base method.
 func (m *MocktableCRUD) SelectLatestConfig(ctx context.Context, rowType int) (*persistence.InternalConfigStoreEntry, error) {
 	m.ctrl.T.Helper()
@@ -1668,6 +1698,21 @@ func (mr *MocktableCRUDMockRecorder) UpdateTaskListsWithTTL(ctx, row interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskListsWithTTL", reflect.TypeOf((*MocktableCRUD)(nil).UpdateTaskListsWithTTL), ctx, row)
 }
 
+// UpdateVisibility mocks base method.
+func (m *MocktableCRUD) UpdateVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisibility", ctx, row)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisibility indicates an expected call of UpdateVisibility.
+func (mr *MocktableCRUDMockRecorder) UpdateVisibility(ctx, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisibility", reflect.TypeOf((*MocktableCRUD)(nil).UpdateVisibility), ctx, row)
+}
+
 // WriteLockExecutions mocks base method.
 func (m *MocktableCRUD) WriteLockExecutions(ctx context.Context, filter *ExecutionsFilter) (int, error) {
 	m.ctrl.T.Helper()
@@ -1905,6 +1950,21 @@ func (mr *MockTxMockRecorder) Commit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTx)(nil).Commit))
 }
 
+// CountFromVisibilityByQuery mocks base method.
+func (m *MockTx) CountFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFromVisibilityByQuery indicates an expected call of CountFromVisibilityByQuery.
+func (mr *MockTxMockRecorder) CountFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFromVisibilityByQuery", reflect.TypeOf((*MockTx)(nil).CountFromVisibilityByQuery), ctx, filter)
+}
+
 // DeleteFromActivityInfoMaps mocks base method.
 func (m *MockTx) DeleteFromActivityInfoMaps(ctx context.Context, filter *ActivityInfoMapsFilter) (sql.Result, error) {
 	m.ctrl.T.Helper()
@@ -3367,6 +3427,21 @@ func (mr *MockTxMockRecorder) SelectFromVisibility(ctx, filter interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibility", reflect.TypeOf((*MockTx)(nil).SelectFromVisibility), ctx, filter)
 }
 
+// SelectFromVisibilityByQuery mocks base method.
+func (m *MockTx) SelectFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) ([]VisibilityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].([]VisibilityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectFromVisibilityByQuery indicates an expected call of SelectFromVisibilityByQuery.
+func (mr *MockTxMockRecorder) SelectFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibilityByQuery", reflect.TypeOf((*MockTx)(nil).SelectFromVisibilityByQuery), ctx, filter)
+}
+
 // SelectLatestConfig mocks base method.
 func (m *MockTx) SelectLatestConfig(ctx context.Context, rowType int) (*persistence.InternalConfigStoreEntry, error) {
 	m.ctrl.T.Helper()
@@ -3529,6 +3604,21 @@ func (mr *MockTxMockRecorder) UpdateTaskListsWithTTL(ctx, row interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskListsWithTTL", reflect.TypeOf((*MockTx)(nil).UpdateTaskListsWithTTL), ctx, row)
 }
 
+// UpdateVisibility mocks base method.
+func (m *MockTx) UpdateVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisibility", ctx, row)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisibility indicates an expected call of UpdateVisibility.
+func (mr *MockTxMockRecorder) UpdateVisibility(ctx, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisibility", reflect.TypeOf((*MockTx)(nil).UpdateVisibility), ctx, row)
+}
+
 // WriteLockExecutions mocks base method.
 func (m *MockTx) WriteLockExecutions(ctx context.Context, filter *ExecutionsFilter) (int, error) {
 	m.ctrl.T.Helper()
@@ -3611,6 +3701,21 @@ func (mr *MockDBMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDB)(nil).Close))
 }
 
+// CountFromVisibilityByQuery mocks base method.
+func (m *MockDB) CountFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFromVisibilityByQuery indicates an expected call of CountFromVisibilityByQuery.
+func (mr *MockDBMockRecorder) CountFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFromVisibilityByQuery", reflect.TypeOf((*MockDB)(nil).CountFromVisibilityByQuery), ctx, filter)
+}
+
 // DeleteFromActivityInfoMaps mocks base method.
 func (m *MockDB) DeleteFromActivityInfoMaps(ctx context.Context, filter *ActivityInfoMapsFilter) (sql.Result, error) {
 	m.ctrl.T.Helper()
@@ -5087,6 +5192,21 @@ func (mr *MockDBMockRecorder) SelectFromVisibility(ctx, filter interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibility", reflect.TypeOf((*MockDB)(nil).SelectFromVisibility), ctx, filter)
 }
 
+// SelectFromVisibilityByQuery mocks base method.
+func (m *MockDB) SelectFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) ([]VisibilityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectFromVisibilityByQuery", ctx, filter)
+	ret0, _ := ret[0].([]VisibilityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectFromVisibilityByQuery indicates an expected call of SelectFromVisibilityByQuery.
+func (mr *MockDBMockRecorder) SelectFromVisibilityByQuery(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectFromVisibilityByQuery", reflect.TypeOf((*MockDB)(nil).SelectFromVisibilityByQuery), ctx, filter)
+}
+
 // SelectLatestConfig mocks base method.
 func (m *MockDB) SelectLatestConfig(ctx context.Context, rowType int) (*persistence.InternalConfigStoreEntry, error) {
 	m.ctrl.T.Helper()
@@ -5249,6 +5369,21 @@ func (mr *MockDBMockRecorder) UpdateTaskListsWithTTL(ctx, row interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskListsWithTTL", reflect.TypeOf((*MockDB)(nil).UpdateTaskListsWithTTL), ctx, row)
 }
 
+// UpdateVisibility mocks base method.
+func (m *MockDB) UpdateVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisibility", ctx, row)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisibility indicates an expected call of UpdateVisibility.
+func (mr *MockDBMockRecorder) UpdateVisibility(ctx, row interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisibility", reflect.TypeOf((*MockDB)(nil).UpdateVisibility), ctx, row)
+}
+
 // WriteLockExecutions mocks base method.
 func (m *MockDB) WriteLockExecutions(ctx context.Context, filter *ExecutionsFilter) (int, error) {
 	m.ctrl.T.Helper()
@@ -542,6 +542,7 @@ type (
 		NumClusters      int16
 		UpdateTime       time.Time
 		ShardID          int16
+		SearchAttributes []byte
 	}
 
 	// VisibilityFilter contains the column names within executions_visibility table that
@@ -558,6 +559,22 @@ type (
 		PageSize         *int
 	}
 
+	// VisibilityQueryFilter contains the visibility query and the pagination params used to select
+	// or count rows of executions_visibility table
+	VisibilityQueryFilter struct {
+		DomainID string
+		Query    *VisibilityQuery
+		PageSize int
+		// After is the position of the last row of the previous page, results are sorted by the order by
+		// clause of the query
+		After *VisibilityQueryCursor
+		// Scan sorts results by start_time and run_id instead of the order by clause of the query,
+		// LastStartTime and LastRunID are the values of the last row returned by the previous page
+		Scan          bool
+		LastStartTime *time.Time
+		LastRunID     *string
+	}
+
 	// QueueRow represents a row in queue table
 	QueueRow struct {
 		QueueType      persistence.QueueType
@@ -799,6 +816,14 @@ type (
 		//     - workflowID, workflowTypeName, closeStatus (along with closed=true)
 		SelectFromVisibility(ctx context.Context, filter *VisibilityFilter) ([]VisibilityRow, error)
 		DeleteFromVisibility(ctx context.Context, filter *VisibilityFilter) (sql.Result, error)
+		// UpdateVisibility updates memo and search attributes of an existing row in visibility table
+		UpdateVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error)
+		// SelectFromVisibilityByQuery returns one page of rows from visibility table matching the query
+		// Required filter params - {domainID, query, pageSize}
+		SelectFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) ([]VisibilityRow, error)
+		// CountFromVisibilityByQuery returns the number of rows from visibility table matching the query
+		// Required filter params - {domainID, query}
+		CountFromVisibilityByQuery(ctx context.Context, filter *VisibilityQueryFilter) (int64, error)
 
 		InsertIntoQueue(ctx context.Context, row *QueueRow) (sql.Result, error)
 		GetLastEnqueuedMessageIDForUpdate(ctx context.Context, queueType persistence.QueueType) (int64, error)
@@ -25,18 +25,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
 )
 
 const (
 	templateCreateWorkflowExecutionStarted = `INSERT IGNORE INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, update_time, shard_id) ` +
-		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, update_time, shard_id, search_attributes) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	templateCreateWorkflowExecutionClosed = `REPLACE INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, update_time, shard_id) ` +
-		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, update_time, shard_id, search_attributes) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	templateUpdateWorkflowExecution = `UPDATE executions_visibility SET memo = ?, encoding = ?, update_time = ?, search_attributes = ? ` +
+		`WHERE domain_id = ? AND run_id = ?`
 
 	// RunID condition is needed for correct pagination
 	templateConditions = ` AND domain_id = ?
@@ -95,7 +99,8 @@ func (mdb *db) InsertIntoVisibility(ctx context.Context, row *sqlplugin.Visibili
 		row.IsCron,
 		row.NumClusters,
 		row.UpdateTime,
-		row.ShardID)
+		row.ShardID,
+		searchAttributesValue(row.SearchAttributes))
 }
 
 // ReplaceIntoVisibility replaces an existing row if it exist or creates a new row in visibility table
@@ -122,12 +127,27 @@ func (mdb *db) ReplaceIntoVisibility(ctx context.Context, row *sqlplugin.Visibil
 			row.IsCron,
 			row.NumClusters,
 			row.UpdateTime,
-			row.ShardID)
+			row.ShardID,
+			searchAttributesValue(row.SearchAttributes))
 	default:
 		return nil, errCloseParams
 	}
 }
 
+// UpdateVisibility updates memo and search attributes of an existing row in visibility table
+func (mdb *db) UpdateVisibility(ctx context.Context, row *sqlplugin.VisibilityRow) (sql.Result, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(row.DomainID, mdb.GetTotalNumDBShards())
+	return mdb.driver.ExecContext(ctx,
+		dbShardID,
+		templateUpdateWorkflowExecution,
+		row.Memo,
+		row.Encoding,
+		row.UpdateTime,
+		searchAttributesValue(row.SearchAttributes),
+		row.DomainID,
+		row.RunID)
+}
+
 // DeleteFromVisibility deletes a row from visibility table if it exist
 func (mdb *db) DeleteFromVisibility(ctx context.Context, filter *sqlplugin.VisibilityFilter) (sql.Result, error) {
 	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, mdb.GetTotalNumDBShards())
@@ -227,3 +247,72 @@ func (mdb *db) SelectFromVisibility(ctx context.Context, filter *sqlplugin.Visib
 	}
 	return rows, err
 }
+
+// SelectFromVisibilityByQuery reads one page of rows matching the query from visibility table
+func (mdb *db) SelectFromVisibilityByQuery(ctx context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, mdb.GetTotalNumDBShards())
+	query, args := sqlplugin.BuildSelectFromVisibilityByQuery(filter, visibilityQueryDialect{})
+	var rows []sqlplugin.VisibilityRow
+	if err := mdb.driver.SelectContext(ctx, dbShardID, &rows, query, mdb.toMySQLDateTimeArgs(args)...); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].StartTime = mdb.converter.FromMySQLDateTime(rows[i].StartTime)
+		rows[i].ExecutionTime = mdb.converter.FromMySQLDateTime(rows[i].ExecutionTime)
+		if rows[i].CloseTime != nil {
+			closeTime := mdb.converter.FromMySQLDateTime(*rows[i].CloseTime)
+			rows[i].CloseTime = &closeTime
+		}
+	}
+	return rows, nil
+}
+
+// CountFromVisibilityByQuery counts the rows matching the query from visibility table
+func (mdb *db) CountFromVisibilityByQuery(ctx context.Context, filter *sqlplugin.VisibilityQueryFilter) (int64, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, mdb.GetTotalNumDBShards())
+	query, args := sqlplugin.BuildCountFromVisibilityByQuery(filter, visibilityQueryDialect{})
+	var count int64
+	err := mdb.driver.GetContext(ctx, dbShardID, &count, query, mdb.toMySQLDateTimeArgs(args)...)
+	return count, err
+}
+
+func (mdb *db) toMySQLDateTimeArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			args[i] = mdb.converter.ToMySQLDateTime(t)
+		}
+	}
+	return args
+}
+
+// searchAttributesValue returns the search attributes as string, as MySQL doesn't accept binary strings for JSON columns
+func searchAttributesValue(searchAttributes []byte) interface{} {
+	if len(searchAttributes) == 0 {
+		return nil
+	}
+	return string(searchAttributes)
+}
+
+// visibilityQueryDialect renders search attributes with MySQL JSON functions
+type visibilityQueryDialect struct{}
+
+func (visibilityQueryDialect) SearchAttribute(key string) string {
+	return fmt.Sprintf(`JSON_EXTRACT(search_attributes, '$."%s"')`, key)
+}
+
+func (visibilityQueryDialect) SearchAttributeText(key string) string {
+	return fmt.Sprintf(`JSON_UNQUOTE(JSON_EXTRACT(search_attributes, '$."%s"'))`, key)
+}
+
+func (d visibilityQueryDialect) SearchAttributeNumber(key string) string {
+	// JSON numbers are compared numerically with SQL numbers
+	return d.SearchAttribute(key)
+}
+
+func (visibilityQueryDialect) SearchAttributeContains(key string) string {
+	return fmt.Sprintf(`JSON_CONTAINS(search_attributes, ?, '$."%s"')`, key)
+}
+
+func (visibilityQueryDialect) JSONValue() string {
+	return `CAST(? AS JSON)`
+}
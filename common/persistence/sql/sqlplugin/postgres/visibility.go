@@ -26,19 +26,22 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
 )
 
 const (
 	templateCreateWorkflowExecutionStarted = `INSERT INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, update_time, shard_id) ` +
-		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, update_time, shard_id, search_attributes) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
          ON CONFLICT (domain_id, run_id) DO NOTHING`
 
 	templateCreateWorkflowExecutionClosed = `INSERT INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, update_time, shard_id) ` +
-		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, update_time, shard_id, search_attributes) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		ON CONFLICT (domain_id, run_id) DO UPDATE
 		  SET workflow_id = excluded.workflow_id,
 		      start_time = excluded.start_time,
@@ -52,7 +55,11 @@ const (
 				is_cron = excluded.is_cron,
 				num_clusters = excluded.num_clusters,
 				update_time = excluded.update_time,
-				shard_id = excluded.shard_id`
+				shard_id = excluded.shard_id,
+				search_attributes = excluded.search_attributes`
+
+	templateUpdateWorkflowExecution = `UPDATE executions_visibility SET memo = $1, encoding = $2, update_time = $3, search_attributes = $4 ` +
+		`WHERE domain_id = $5 AND run_id = $6`
 
 	// RunID condition is needed for correct pagination
 	templateConditions1 = ` AND domain_id = $1
@@ -116,7 +123,8 @@ func (pdb *db) InsertIntoVisibility(ctx context.Context, row *sqlplugin.Visibili
 		row.IsCron,
 		row.NumClusters,
 		row.UpdateTime,
-		row.ShardID)
+		row.ShardID,
+		searchAttributesValue(row.SearchAttributes))
 }
 
 // ReplaceIntoVisibility replaces an existing row if it exist or creates a new row in visibility table
@@ -141,12 +149,25 @@ func (pdb *db) ReplaceIntoVisibility(ctx context.Context, row *sqlplugin.Visibil
 			row.IsCron,
 			row.NumClusters,
 			row.UpdateTime,
-			row.ShardID)
+			row.ShardID,
+			searchAttributesValue(row.SearchAttributes))
 	default:
 		return nil, errCloseParams
 	}
 }
 
+// UpdateVisibility updates memo and search attributes of an existing row in visibility table
+func (pdb *db) UpdateVisibility(ctx context.Context, row *sqlplugin.VisibilityRow) (sql.Result, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(row.DomainID, pdb.GetTotalNumDBShards())
+	return pdb.driver.ExecContext(ctx, dbShardID, templateUpdateWorkflowExecution,
+		row.Memo,
+		row.Encoding,
+		row.UpdateTime,
+		searchAttributesValue(row.SearchAttributes),
+		row.DomainID,
+		row.RunID)
+}
+
 // DeleteFromVisibility deletes a row from visibility table if it exist
 func (pdb *db) DeleteFromVisibility(ctx context.Context, filter *sqlplugin.VisibilityFilter) (sql.Result, error) {
 	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, pdb.GetTotalNumDBShards())
@@ -242,3 +263,75 @@ func (pdb *db) SelectFromVisibility(ctx context.Context, filter *sqlplugin.Visib
 	}
 	return rows, err
 }
+
+// SelectFromVisibilityByQuery reads one page of rows matching the query from visibility table
+func (pdb *db) SelectFromVisibilityByQuery(ctx context.Context, filter *sqlplugin.VisibilityQueryFilter) ([]sqlplugin.VisibilityRow, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, pdb.GetTotalNumDBShards())
+	query, args := sqlplugin.BuildSelectFromVisibilityByQuery(filter, visibilityQueryDialect{})
+	var rows []sqlplugin.VisibilityRow
+	err := pdb.driver.SelectContext(ctx, dbShardID, &rows, sqlx.Rebind(sqlx.BindType(PluginName), query), pdb.toPostgresDateTimeArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].StartTime = pdb.converter.FromPostgresDateTime(rows[i].StartTime)
+		rows[i].ExecutionTime = pdb.converter.FromPostgresDateTime(rows[i].ExecutionTime)
+		if rows[i].CloseTime != nil {
+			closeTime := pdb.converter.FromPostgresDateTime(*rows[i].CloseTime)
+			rows[i].CloseTime = &closeTime
+		}
+		rows[i].RunID = strings.TrimSpace(rows[i].RunID)
+		rows[i].WorkflowID = strings.TrimSpace(rows[i].WorkflowID)
+	}
+	return rows, nil
+}
+
+// CountFromVisibilityByQuery counts the rows matching the query from visibility table
+func (pdb *db) CountFromVisibilityByQuery(ctx context.Context, filter *sqlplugin.VisibilityQueryFilter) (int64, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, pdb.GetTotalNumDBShards())
+	query, args := sqlplugin.BuildCountFromVisibilityByQuery(filter, visibilityQueryDialect{})
+	var count int64
+	err := pdb.driver.GetContext(ctx, dbShardID, &count, sqlx.Rebind(sqlx.BindType(PluginName), query), pdb.toPostgresDateTimeArgs(args)...)
+	return count, err
+}
+
+func (pdb *db) toPostgresDateTimeArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			args[i] = pdb.converter.ToPostgresDateTime(t)
+		}
+	}
+	return args
+}
+
+// searchAttributesValue returns the search attributes as string, so that they are sent as JSON text instead of bytea
+func searchAttributesValue(searchAttributes []byte) interface{} {
+	if len(searchAttributes) == 0 {
+		return nil
+	}
+	return string(searchAttributes)
+}
+
+// visibilityQueryDialect renders search attributes with Postgres JSONB operators
+type visibilityQueryDialect struct{}
+
+func (visibilityQueryDialect) SearchAttribute(key string) string {
+	return fmt.Sprintf(`search_attributes->'%s'`, key)
+}
+
+func (visibilityQueryDialect) SearchAttributeText(key string) string {
+	return fmt.Sprintf(`search_attributes->>'%s'`, key)
+}
+
+func (visibilityQueryDialect) SearchAttributeNumber(key string) string {
+	// only cast numbers to avoid failing the query on values of other types
+	return fmt.Sprintf(`CASE WHEN jsonb_typeof(search_attributes->'%s') = 'number' THEN (search_attributes->>'%s')::numeric END`, key, key)
+}
+
+func (visibilityQueryDialect) SearchAttributeContains(key string) string {
+	return fmt.Sprintf(`search_attributes->'%s' @> ?::jsonb`, key)
+}
+
+func (visibilityQueryDialect) JSONValue() string {
+	return `?::jsonb`
+}
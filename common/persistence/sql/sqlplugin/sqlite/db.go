@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/uber/cadence/common/persistence/sql/sqldriver"
+	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
+)
+
+type (
+	db struct {
+		converter   DataConverter
+		driver      sqldriver.Driver
+		originalDBs []*sqlx.DB
+		numDBShards int
+		// inMemory is true if the connection is shared by all the users of an in-memory database
+		inMemory bool
+	}
+)
+
+func (mdb *db) GetTotalNumDBShards() int {
+	return mdb.numDBShards
+}
+
+var _ sqlplugin.AdminDB = (*db)(nil)
+var _ sqlplugin.DB = (*db)(nil)
+var _ sqlplugin.Tx = (*db)(nil)
+
+func (mdb *db) IsDupEntryError(err error) bool {
+	sqlErr, ok := err.(sqlite3.Error)
+	return ok && (sqlErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey ||
+		sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique)
+}
+
+func (mdb *db) IsNotFoundError(err error) bool {
+	return err == sql.ErrNoRows
+}
+
+func (mdb *db) IsTimeoutError(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	sqlErr, ok := err.(sqlite3.Error)
+	// SQLITE_BUSY is returned when the database lock is not acquired within the busy timeout
+	return ok && sqlErr.Code == sqlite3.ErrBusy
+}
+
+func (mdb *db) IsThrottlingError(err error) bool {
+	return false
+}
+
+// newDB returns an instance of DB, which is a logical
+// connection to the underlying sqlite database
+// dbShardID is needed when tx is not nil
+func newDB(xdbs []*sqlx.DB, tx *sqlx.Tx, dbShardID int, numDBShards int, inMemory bool) (*db, error) {
+	driver, err := sqldriver.NewDriver(xdbs, tx, dbShardID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &db{
+		converter:   &converter{},
+		originalDBs: xdbs, // this is kept because newDB will be called again when starting a transaction
+		driver:      driver,
+		numDBShards: numDBShards,
+		inMemory:    inMemory,
+	}
+
+	return db, nil
+}
+
+// BeginTx starts a new transaction and returns a reference to the Tx object
+func (mdb *db) BeginTx(ctx context.Context, dbShardID int) (sqlplugin.Tx, error) {
+	xtx, err := mdb.driver.BeginTxx(ctx, dbShardID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newDB(mdb.originalDBs, xtx, dbShardID, mdb.numDBShards, mdb.inMemory)
+}
+
+// Commit commits a previously started transaction
+func (mdb *db) Commit() error {
+	return mdb.driver.Commit()
+}
+
+// Rollback triggers rollback of a previously started transaction
+func (mdb *db) Rollback() error {
+	return mdb.driver.Rollback()
+}
+
+// Close closes the connection to the sqlite db
+func (mdb *db) Close() error {
+	if mdb.inMemory {
+		// the in-memory database would be lost if the shared connection is closed
+		return nil
+	}
+	return mdb.driver.Close()
+}
+
+// PluginName returns the name of the sqlite plugin
+func (mdb *db) PluginName() string {
+	return PluginName
+}
+
+// SupportsTTL returns weather SQLite supports TTL
+func (mdb *db) SupportsTTL() bool {
+	return false
+}
+
+// MaxAllowedTTL returns the max allowed ttl SQLite supports
+func (mdb *db) MaxAllowedTTL() (*time.Duration, error) {
+	return nil, sqlplugin.ErrTTLNotSupported
+}
+
+// SupportsAsyncTransaction returns weather SQLite supports Asynchronous transaction
+func (mdb *db) SupportsAsyncTransaction() bool {
+	return false
+}
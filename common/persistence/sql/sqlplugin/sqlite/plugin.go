@@ -0,0 +1,229 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iancoleman/strcase"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // needed to register the sqlite3 driver
+
+	"github.com/uber/cadence/common/config"
+	pt "github.com/uber/cadence/common/persistence/persistence-tests"
+	"github.com/uber/cadence/common/persistence/sql"
+	"github.com/uber/cadence/common/persistence/sql/sqldriver"
+	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
+	"github.com/uber/cadence/schema/sqlite"
+)
+
+const (
+	// PluginName is the name of the plugin
+	PluginName = "sqlite"
+	// InMemoryDatabaseName is the database name of an in-memory database. Empty database name is the same.
+	// The in-memory database is created with the latest schema when it's first used, and lives until the process exits.
+	InMemoryDatabaseName = ":memory:"
+
+	// driverName is the name of the database/sql driver registered by go-sqlite3
+	driverName = "sqlite3"
+
+	defaultMaxConns = 1
+)
+
+// dsnAttrDefaults are applied only if not specified in the connect attributes
+var dsnAttrDefaults = map[string]string{
+	"_busy_timeout": "10000",
+	"_journal_mode": "WAL",
+}
+
+var dsnAttrOverrides = map[string]string{
+	// SQLite has only database level locks. Transactions must take the write lock when they begin,
+	// otherwise a read-then-write transaction can fail with SQLITE_BUSY without waiting for the busy timeout.
+	"_txlock": "immediate",
+	// times are always stored in UTC, so that they are ordered correctly when compared as text
+	"_loc": "UTC",
+}
+
+var inMemoryDBs = struct {
+	sync.Mutex
+	dbs map[string]*sqlx.DB
+}{dbs: map[string]*sqlx.DB{}}
+
+type plugin struct{}
+
+var _ sqlplugin.Plugin = (*plugin)(nil)
+
+func init() {
+	sql.RegisterPlugin(PluginName, &plugin{})
+}
+
+// CreateDB initialize the db object
+func (p *plugin) CreateDB(cfg *config.SQL) (sqlplugin.DB, error) {
+	return p.createDB(cfg)
+}
+
+// CreateAdminDB initialize the adminDb object
+func (p *plugin) CreateAdminDB(cfg *config.SQL) (sqlplugin.AdminDB, error) {
+	return p.createDB(cfg)
+}
+
+func (p *plugin) createDB(cfg *config.SQL) (*db, error) {
+	if isInMemory(cfg.DatabaseName) {
+		xdb, err := getOrCreateInMemoryDB(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newDB([]*sqlx.DB{xdb}, nil, sqlplugin.DbShardUndefined, cfg.NumShards, true)
+	}
+	conns, err := sqldriver.CreateDBConnections(cfg, func(cfg *config.SQL) (*sqlx.DB, error) {
+		return p.createSingleDBConn(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newDB(conns, nil, sqlplugin.DbShardUndefined, cfg.NumShards, false)
+}
+
+func (p *plugin) createSingleDBConn(cfg *config.SQL) (*sqlx.DB, error) {
+	db, err := sqlx.Connect(driverName, buildDSN(cfg))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer at a time, so by default all the queries are serialized
+	// on one connection rather than having concurrent writers fail with SQLITE_BUSY.
+	maxConns := defaultMaxConns
+	if cfg.MaxConns > 0 {
+		maxConns = cfg.MaxConns
+	}
+	db.SetMaxOpenConns(maxConns)
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxConnLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.MaxConnLifetime)
+	}
+
+	// Maps struct names in CamelCase to snake without need for db struct tags.
+	db.MapperFunc(strcase.ToSnake)
+	return db, nil
+}
+
+// getOrCreateInMemoryDB returns the in-memory database of the given name, and creates it if not exists yet.
+// Every connection to ":memory:" opens a new empty database, so all the users of the same in-memory database
+// share a single connection, which is never closed.
+func getOrCreateInMemoryDB(cfg *config.SQL) (*sqlx.DB, error) {
+	inMemoryDBs.Lock()
+	defer inMemoryDBs.Unlock()
+
+	if xdb, ok := inMemoryDBs.dbs[cfg.DatabaseName]; ok {
+		return xdb, nil
+	}
+	xdb, err := sqlx.Connect(driverName, buildDSN(cfg))
+	if err != nil {
+		return nil, err
+	}
+	xdb.SetMaxOpenConns(1)
+	xdb.SetMaxIdleConns(1)
+	xdb.SetConnMaxLifetime(0)
+	xdb.MapperFunc(strcase.ToSnake)
+
+	if err := setupInMemorySchema(xdb, cfg.DatabaseName); err != nil {
+		_ = xdb.Close()
+		return nil, fmt.Errorf("failed to setup schema of in-memory database: %v", err)
+	}
+	inMemoryDBs.dbs[cfg.DatabaseName] = xdb
+	return xdb, nil
+}
+
+// setupInMemorySchema creates the latest cadence and visibility schema in a new in-memory database
+func setupInMemorySchema(xdb *sqlx.DB, databaseName string) error {
+	mdb, err := newDB([]*sqlx.DB{xdb}, nil, sqlplugin.DbShardUndefined, 1, true)
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{"cadence/schema.sql", "visibility/schema.sql"} {
+		content, err := fs.ReadFile(sqlite.SchemaFS, file)
+		if err != nil {
+			return err
+		}
+		if err := mdb.ExecSchemaOperationQuery(context.Background(), string(content)); err != nil {
+			return fmt.Errorf("failed to execute %v: %v", file, err)
+		}
+	}
+	if err := mdb.CreateSchemaVersionTables(); err != nil {
+		return err
+	}
+	return mdb.UpdateSchemaVersion(databaseName, sqlite.Version, sqlite.Version)
+}
+
+func isInMemory(databaseName string) bool {
+	return databaseName == "" || databaseName == InMemoryDatabaseName
+}
+
+func buildDSN(cfg *config.SQL) string {
+	// go-sqlite3 passes the dsn to sqlite as a URI filename only if it starts with "file:"
+	dsn := "file:" + cfg.DatabaseName
+	if isInMemory(cfg.DatabaseName) {
+		dsn = "file:" + InMemoryDatabaseName
+	}
+	return dsn + "?" + buildDSNAttrs(cfg)
+}
+
+func buildDSNAttrs(cfg *config.SQL) string {
+	attrs := make(map[string]string, len(dsnAttrDefaults)+len(dsnAttrOverrides)+len(cfg.ConnectAttributes))
+	for k, v := range dsnAttrDefaults {
+		attrs[k] = v
+	}
+	for k, v := range cfg.ConnectAttributes {
+		attrs[k] = v
+	}
+	// these attrs are always overridden
+	for k, v := range dsnAttrOverrides {
+		attrs[k] = v
+	}
+
+	values := url.Values{}
+	for k, v := range attrs {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+const (
+	testSchemaDir = "schema/sqlite"
+)
+
+// GetTestClusterOption return test options
+func GetTestClusterOption() *pt.TestBaseOptions {
+	return &pt.TestBaseOptions{
+		DBPluginName: PluginName,
+		// the database is a file, keep it out of the source tree
+		DBName:    filepath.Join(os.TempDir(), "cadence_test_"+pt.GenerateRandomDBName(10)+".db"),
+		SchemaDir: testSchemaDir,
+		StoreType: config.StoreTypeSQL,
+	}
+}
@@ -0,0 +1,699 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// VisibilityQuery is a visibility query parsed by ParseVisibilityQuery.
+	// It is translated into SQL over executions_visibility table by BuildSelectFromVisibilityByQuery
+	// and BuildCountFromVisibilityByQuery
+	VisibilityQuery struct {
+		where   visibilityQueryExpr
+		orderBy []visibilityQueryOrder
+	}
+
+	// VisibilityQueryDialect renders the custom search attribute expressions of a visibility query,
+	// search attributes are stored as a JSON object in search_attributes column
+	VisibilityQueryDialect interface {
+		// SearchAttribute returns an expression evaluating to the JSON value of the key, or NULL if the key is not set
+		SearchAttribute(key string) string
+		// SearchAttributeText returns an expression evaluating to the value of the key as text
+		SearchAttributeText(key string) string
+		// SearchAttributeNumber returns an expression evaluating to the value of the key as a number
+		SearchAttributeNumber(key string) string
+		// SearchAttributeContains returns a predicate which is true if the value of the key is equal to,
+		// or is an array containing, the JSON document bound to the placeholder
+		SearchAttributeContains(key string) string
+		// JSONValue returns a placeholder for a JSON document bound as text, comparable with SearchAttribute
+		JSONValue() string
+	}
+
+	// VisibilityQueryCursor is the position of a row in the order of a visibility query, it's used as the page
+	// token of the query so that the next page starts after the last row of the previous one even if rows were
+	// added or removed in the meantime
+	VisibilityQueryCursor struct {
+		// SortValues are the values of the order by keys of the row, times are unix nanoseconds and custom
+		// search attributes are JSON text, nil if the value is NULL
+		SortValues []interface{}
+		RunID      string
+	}
+
+	visibilityQueryExpr interface {
+		render(d VisibilityQueryDialect, args []interface{}) (string, []interface{})
+	}
+
+	// visibilityQueryLogicalExpr is an and/or of two expressions
+	visibilityQueryLogicalExpr struct {
+		operator string
+		left     visibilityQueryExpr
+		right    visibilityQueryExpr
+	}
+
+	// visibilityQueryColumnExpr is a predicate on a column of executions_visibility table
+	visibilityQueryColumnExpr struct {
+		column   string
+		operator string
+		values   []interface{}
+	}
+
+	// visibilityQueryAttributeExpr is a predicate on a custom search attribute
+	visibilityQueryAttributeExpr struct {
+		key      string
+		operator string
+		values   []interface{}
+	}
+
+	visibilityQueryOrder struct {
+		column    visibilityQueryColumn
+		key       string
+		direction string
+	}
+
+	visibilityQueryColumn struct {
+		name  string
+		parse func(value interface{}) (interface{}, error)
+		// value returns the sort value of the column in a row, it's nil for columns which can't be sorted by
+		value func(row *VisibilityRow) interface{}
+		// nullable is true if the column is NULL for some rows, NULL values are sorted last
+		nullable bool
+	}
+)
+
+const (
+	visibilityQueryIsNull    = "is null"
+	visibilityQueryIsNotNull = "is not null"
+	visibilityQueryMissing   = "missing"
+
+	templateVisibilityQueryFieldNames = `workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, update_time, shard_id, ` +
+		`close_time, close_status, history_length, search_attributes`
+)
+
+var (
+	visibilityQueryColumns = map[string]visibilityQueryColumn{
+		definition.DomainID: {name: "domain_id", parse: parseVisibilityQueryString},
+		definition.WorkflowID: {name: "workflow_id", parse: parseVisibilityQueryString,
+			value: func(row *VisibilityRow) interface{} { return row.WorkflowID }},
+		definition.RunID: {name: "run_id", parse: parseVisibilityQueryString,
+			value: func(row *VisibilityRow) interface{} { return row.RunID }},
+		definition.WorkflowType: {name: "workflow_type_name", parse: parseVisibilityQueryString,
+			value: func(row *VisibilityRow) interface{} { return row.WorkflowTypeName }},
+		definition.TaskList: {name: "task_list", parse: parseVisibilityQueryString},
+		definition.StartTime: {name: "start_time", parse: parseVisibilityQueryTime,
+			value: func(row *VisibilityRow) interface{} { return row.StartTime.UnixNano() }},
+		definition.ExecutionTime: {name: "execution_time", parse: parseVisibilityQueryTime,
+			value: func(row *VisibilityRow) interface{} { return row.ExecutionTime.UnixNano() }},
+		definition.CloseTime: {name: "close_time", parse: parseVisibilityQueryTime, nullable: true,
+			value: func(row *VisibilityRow) interface{} {
+				if row.CloseTime == nil {
+					return nil
+				}
+				return row.CloseTime.UnixNano()
+			}},
+		definition.UpdateTime: {name: "update_time", parse: parseVisibilityQueryTime,
+			value: func(row *VisibilityRow) interface{} { return row.UpdateTime.UnixNano() }},
+		definition.CloseStatus: {name: "close_status", parse: parseVisibilityQueryCloseStatus, nullable: true,
+			value: func(row *VisibilityRow) interface{} {
+				if row.CloseStatus == nil {
+					return nil
+				}
+				return int64(*row.CloseStatus)
+			}},
+		definition.HistoryLength: {name: "history_length", parse: parseVisibilityQueryInt, nullable: true,
+			value: func(row *VisibilityRow) interface{} {
+				if row.HistoryLength == nil {
+					return nil
+				}
+				return *row.HistoryLength
+			}},
+		definition.NumClusters: {name: "num_clusters", parse: parseVisibilityQueryInt,
+			value: func(row *VisibilityRow) interface{} { return int64(row.NumClusters) }},
+		definition.IsCron: {name: "is_cron", parse: parseVisibilityQueryBool,
+			value: func(row *VisibilityRow) interface{} { return row.IsCron }},
+	}
+
+	// visibilityQueryDefaultOrder is the order of the rows of a query without order by clause
+	visibilityQueryDefaultOrder = []visibilityQueryOrder{
+		{column: visibilityQueryColumns[definition.StartTime], direction: sqlparser.DescScr},
+	}
+
+	// search attribute keys are embedded in the JSON path of the query, so they must not contain quotes
+	visibilityQueryKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+	visibilityQueryOperators = map[string]bool{
+		sqlparser.EqualStr:        true,
+		sqlparser.NotEqualStr:     true,
+		sqlparser.LessThanStr:     true,
+		sqlparser.LessEqualStr:    true,
+		sqlparser.GreaterThanStr:  true,
+		sqlparser.GreaterEqualStr: true,
+		sqlparser.InStr:           true,
+		sqlparser.NotInStr:        true,
+	}
+)
+
+// ParseVisibilityQuery parses and validates a visibility query, i.e. a where clause with an optional
+// order by clause as accepted by ListWorkflowExecutions. Custom search attributes may be prefixed with Attr,
+// as done by the frontend query validator. Errors are returned as BadRequestError.
+func ParseVisibilityQuery(query string) (*VisibilityQuery, error) {
+	query = strings.TrimSpace(query)
+	if len(query) == 0 {
+		return &VisibilityQuery{}, nil
+	}
+
+	// IMPORTANT: This query is never executed, it is just used to parse the where and order by clauses
+	var placeholderQuery string
+	if common.IsJustOrderByClause(query) {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy %s", query)
+	} else {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy WHERE %s", query)
+	}
+	stmt, err := sqlparser.Parse(placeholderQuery)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: "Invalid query."}
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil, &types.BadRequestError{Message: "Invalid select query."}
+	}
+
+	result := &VisibilityQuery{}
+	if sel.Where != nil {
+		result.where, err = parseVisibilityQueryExpr(sel.Where.Expr)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: err.Error()}
+		}
+	}
+	for _, order := range sel.OrderBy {
+		colName, ok := order.Expr.(*sqlparser.ColName)
+		if !ok {
+			return nil, &types.BadRequestError{Message: "invalid order by expression"}
+		}
+		column, key, err := parseVisibilityQueryColName(colName)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: err.Error()}
+		}
+		if key == "" && column.value == nil {
+			return nil, &types.BadRequestError{Message: fmt.Sprintf("cannot order by %s", colName.Name.String())}
+		}
+		result.orderBy = append(result.orderBy, visibilityQueryOrder{
+			column:    column,
+			key:       key,
+			direction: order.Direction,
+		})
+	}
+	return result, nil
+}
+
+// BuildSelectFromVisibilityByQuery returns the statement and its arguments selecting one page of rows
+// of executions_visibility table matching the filter. The statement uses ? as placeholder.
+func BuildSelectFromVisibilityByQuery(filter *VisibilityQueryFilter, d VisibilityQueryDialect) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(`SELECT ` + templateVisibilityQueryFieldNames + ` FROM executions_visibility WHERE domain_id = ?`)
+	args := buildVisibilityQueryConditions(&sb, filter, d, []interface{}{filter.DomainID})
+
+	if filter.Scan {
+		if filter.LastStartTime != nil && filter.LastRunID != nil {
+			sb.WriteString(` AND (start_time < ? OR (start_time = ? AND run_id > ?))`)
+			args = append(args, *filter.LastStartTime, *filter.LastStartTime, *filter.LastRunID)
+		}
+		sb.WriteString(` ORDER BY start_time DESC, run_id LIMIT ?`)
+		return sb.String(), append(args, filter.PageSize)
+	}
+
+	orderBy := filter.Query.getOrderBy()
+	if filter.After != nil {
+		var after string
+		after, args = renderVisibilityQueryAfter(orderBy, filter.After, d, args)
+		sb.WriteString(` AND ` + after)
+	}
+	sb.WriteString(` ORDER BY `)
+	for _, order := range orderBy {
+		expr := order.render(d)
+		if order.nullable() {
+			// NULL values are sorted last in both directions, whatever the database
+			sb.WriteString(expr + ` IS NULL, `)
+		}
+		sb.WriteString(expr + ` ` + strings.ToUpper(order.direction) + `, `)
+	}
+	// run_id makes the order deterministic for pagination
+	sb.WriteString(`run_id LIMIT ?`)
+	return sb.String(), append(args, filter.PageSize)
+}
+
+// NewVisibilityQueryCursor returns the position of the row in the order of the query
+func NewVisibilityQueryCursor(query *VisibilityQuery, row *VisibilityRow) (*VisibilityQueryCursor, error) {
+	var attributes map[string]json.RawMessage
+	orderBy := query.getOrderBy()
+	cursor := &VisibilityQueryCursor{SortValues: make([]interface{}, len(orderBy)), RunID: row.RunID}
+	for i, order := range orderBy {
+		if order.key == "" {
+			cursor.SortValues[i] = order.column.value(row)
+			continue
+		}
+		if attributes == nil && len(row.SearchAttributes) > 0 {
+			if err := json.Unmarshal(row.SearchAttributes, &attributes); err != nil {
+				return nil, fmt.Errorf("invalid search attributes of run %s: %w", row.RunID, err)
+			}
+		}
+		if value, ok := attributes[order.key]; ok {
+			cursor.SortValues[i] = string(value)
+		}
+	}
+	return cursor, nil
+}
+
+// ParseVisibilityQueryCursor parses the page token of the query returned by the previous page
+func ParseVisibilityQueryCursor(query *VisibilityQuery, token []byte) (*VisibilityQueryCursor, error) {
+	decoder := json.NewDecoder(bytes.NewReader(token))
+	decoder.UseNumber()
+	cursor := &VisibilityQueryCursor{}
+	if err := decoder.Decode(cursor); err != nil {
+		return nil, err
+	}
+	orderBy := query.getOrderBy()
+	if len(cursor.SortValues) != len(orderBy) {
+		return nil, errors.New("page token doesn't match the order of the query")
+	}
+	for i, order := range orderBy {
+		value := cursor.SortValues[i]
+		if value == nil {
+			continue
+		}
+		if order.key != "" {
+			if text, ok := value.(string); !ok || !json.Valid([]byte(text)) {
+				return nil, fmt.Errorf("invalid value %v of %s", value, order.key)
+			}
+			continue
+		}
+		if number, ok := value.(json.Number); ok {
+			parsed, err := number.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %v of %s: %w", value, order.column.name, err)
+			}
+			value = parsed
+		}
+		if _, err := order.column.parse(value); err != nil {
+			return nil, fmt.Errorf("invalid value %v of %s: %w", value, order.column.name, err)
+		}
+		cursor.SortValues[i] = value
+	}
+	return cursor, nil
+}
+
+func (q *VisibilityQuery) getOrderBy() []visibilityQueryOrder {
+	if q == nil || len(q.orderBy) == 0 {
+		return visibilityQueryDefaultOrder
+	}
+	return q.orderBy
+}
+
+func (o visibilityQueryOrder) render(d VisibilityQueryDialect) string {
+	if o.key != "" {
+		return d.SearchAttribute(o.key)
+	}
+	return o.column.name
+}
+
+func (o visibilityQueryOrder) nullable() bool {
+	return o.key != "" || o.column.nullable
+}
+
+// renderVisibilityQueryAfter returns a predicate matching the rows after the cursor in the order of the query,
+// i.e. the rows sorted after the cursor by one key and equal to the cursor by the previous keys
+func renderVisibilityQueryAfter(orderBy []visibilityQueryOrder, cursor *VisibilityQueryCursor, d VisibilityQueryDialect, args []interface{}) (string, []interface{}) {
+	var predicates []string
+	var equal []string
+	var equalArgs []interface{}
+	for i, order := range orderBy {
+		expr := order.render(d)
+		value := cursor.SortValues[i]
+		placeholder := "?"
+		if order.key != "" {
+			placeholder = d.JSONValue()
+		} else if value != nil {
+			// the value was validated when the cursor was parsed
+			value, _ = order.column.parse(value)
+		}
+
+		if value != nil {
+			operator := sqlparser.GreaterThanStr
+			if order.direction == sqlparser.DescScr {
+				operator = sqlparser.LessThanStr
+			}
+			after := fmt.Sprintf("%s %s %s", expr, operator, placeholder)
+			if order.nullable() {
+				after = fmt.Sprintf("(%s OR %s IS NULL)", after, expr)
+			}
+			predicates = append(predicates, strings.Join(append(equal[:len(equal):len(equal)], after), " AND "))
+			args = append(append(args, equalArgs...), value)
+
+			equal = append(equal, fmt.Sprintf("%s = %s", expr, placeholder))
+			equalArgs = append(equalArgs, value)
+		} else {
+			// NULL values are sorted last, so only the NULL values of the key can be after the cursor
+			equal = append(equal, expr+" IS NULL")
+		}
+	}
+	predicates = append(predicates, strings.Join(append(equal, "run_id > ?"), " AND "))
+	args = append(append(args, equalArgs...), cursor.RunID)
+	return "(" + strings.Join(predicates, " OR ") + ")", args
+}
+
+// BuildCountFromVisibilityByQuery returns the statement and its arguments counting the rows of
+// executions_visibility table matching the filter. The statement uses ? as placeholder.
+func BuildCountFromVisibilityByQuery(filter *VisibilityQueryFilter, d VisibilityQueryDialect) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(`SELECT COUNT(*) FROM executions_visibility WHERE domain_id = ?`)
+	args := buildVisibilityQueryConditions(&sb, filter, d, []interface{}{filter.DomainID})
+	return sb.String(), args
+}
+
+func buildVisibilityQueryConditions(sb *strings.Builder, filter *VisibilityQueryFilter, d VisibilityQueryDialect, args []interface{}) []interface{} {
+	if filter.Query == nil || filter.Query.where == nil {
+		return args
+	}
+	var where string
+	where, args = filter.Query.where.render(d, args)
+	sb.WriteString(` AND ` + where)
+	return args
+}
+
+func parseVisibilityQueryExpr(expr sqlparser.Expr) (visibilityQueryExpr, error) {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		return parseVisibilityQueryLogicalExpr("AND", expr.Left, expr.Right)
+	case *sqlparser.OrExpr:
+		return parseVisibilityQueryLogicalExpr("OR", expr.Left, expr.Right)
+	case *sqlparser.ParenExpr:
+		return parseVisibilityQueryExpr(expr.Expr)
+	case *sqlparser.ComparisonExpr:
+		return parseVisibilityQueryComparisonExpr(expr)
+	case *sqlparser.RangeCond:
+		return parseVisibilityQueryRangeCond(expr)
+	default:
+		return nil, errors.New("invalid where clause")
+	}
+}
+
+func parseVisibilityQueryLogicalExpr(operator string, left, right sqlparser.Expr) (visibilityQueryExpr, error) {
+	leftExpr, err := parseVisibilityQueryExpr(left)
+	if err != nil {
+		return nil, err
+	}
+	rightExpr, err := parseVisibilityQueryExpr(right)
+	if err != nil {
+		return nil, err
+	}
+	return &visibilityQueryLogicalExpr{operator: operator, left: leftExpr, right: rightExpr}, nil
+}
+
+func parseVisibilityQueryComparisonExpr(expr *sqlparser.ComparisonExpr) (visibilityQueryExpr, error) {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return nil, errors.New("invalid comparison expression")
+	}
+	column, key, err := parseVisibilityQueryColName(colName)
+	if err != nil {
+		return nil, err
+	}
+	if !visibilityQueryOperators[expr.Operator] {
+		return nil, fmt.Errorf("operator %q is not supported", expr.Operator)
+	}
+
+	operator := expr.Operator
+	var values []interface{}
+	switch right := expr.Right.(type) {
+	case *sqlparser.ColName:
+		// unquoted value is parsed as a column name, the only one allowed is missing
+		if right.Name.String() != visibilityQueryMissing {
+			return nil, fmt.Errorf("invalid value %q for %s", right.Name.String(), colName.Name.String())
+		}
+		switch operator {
+		case sqlparser.EqualStr:
+			operator = visibilityQueryIsNull
+		case sqlparser.NotEqualStr:
+			operator = visibilityQueryIsNotNull
+		default:
+			return nil, fmt.Errorf("operator %q is not supported for missing value", operator)
+		}
+	case sqlparser.ValTuple:
+		if operator != sqlparser.InStr && operator != sqlparser.NotInStr {
+			return nil, fmt.Errorf("operator %q is not supported for value list", operator)
+		}
+		for _, val := range right {
+			value, err := parseVisibilityQueryValue(val)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	default:
+		if operator == sqlparser.InStr || operator == sqlparser.NotInStr {
+			return nil, fmt.Errorf("operator %q requires a value list", operator)
+		}
+		value, err := parseVisibilityQueryValue(right)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return newVisibilityQueryPredicate(column, key, operator, values)
+}
+
+func parseVisibilityQueryRangeCond(expr *sqlparser.RangeCond) (visibilityQueryExpr, error) {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return nil, errors.New("invalid range expression")
+	}
+	column, key, err := parseVisibilityQueryColName(colName)
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseVisibilityQueryValue(expr.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseVisibilityQueryValue(expr.To)
+	if err != nil {
+		return nil, err
+	}
+	return newVisibilityQueryPredicate(column, key, expr.Operator, []interface{}{from, to})
+}
+
+func newVisibilityQueryPredicate(column visibilityQueryColumn, key string, operator string, values []interface{}) (visibilityQueryExpr, error) {
+	if key != "" {
+		if err := validateVisibilityQueryAttributeValues(key, operator, values); err != nil {
+			return nil, err
+		}
+		return &visibilityQueryAttributeExpr{key: key, operator: operator, values: values}, nil
+	}
+	for i, value := range values {
+		parsed, err := column.parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", column.name, err)
+		}
+		values[i] = parsed
+	}
+	return &visibilityQueryColumnExpr{column: column.name, operator: operator, values: values}, nil
+}
+
+// parseVisibilityQueryColName returns either the column of a system search attribute or the key of a custom one
+func parseVisibilityQueryColName(colName *sqlparser.ColName) (visibilityQueryColumn, string, error) {
+	name := colName.Name.String()
+	if column, ok := visibilityQueryColumns[name]; ok {
+		return column, "", nil
+	}
+	key := strings.TrimPrefix(name, definition.Attr+".")
+	if definition.IsSystemIndexedKey(key) || !visibilityQueryKeyRegex.MatchString(key) {
+		return visibilityQueryColumn{}, "", fmt.Errorf("invalid search attribute %q", name)
+	}
+	return visibilityQueryColumn{}, key, nil
+}
+
+func parseVisibilityQueryValue(expr sqlparser.Expr) (interface{}, error) {
+	switch val := expr.(type) {
+	case *sqlparser.SQLVal:
+		switch val.Type {
+		case sqlparser.StrVal:
+			return string(val.Val), nil
+		case sqlparser.IntVal:
+			return strconv.ParseInt(string(val.Val), 10, 64)
+		case sqlparser.FloatVal:
+			return strconv.ParseFloat(string(val.Val), 64)
+		}
+	case sqlparser.BoolVal:
+		return bool(val), nil
+	}
+	return nil, fmt.Errorf("invalid value %q", sqlparser.String(expr))
+}
+
+func validateVisibilityQueryAttributeValues(key string, operator string, values []interface{}) error {
+	switch operator {
+	case sqlparser.EqualStr, sqlparser.NotEqualStr, sqlparser.InStr, sqlparser.NotInStr, visibilityQueryIsNull, visibilityQueryIsNotNull:
+		return nil
+	}
+	// range comparison is done either on text or on numbers, so values must be all strings or all numbers
+	_, isText := values[0].(string)
+	for _, value := range values {
+		switch value.(type) {
+		case string:
+			if !isText {
+				return fmt.Errorf("invalid values for %s: mixed text and number", key)
+			}
+		case int64, float64:
+			if isText {
+				return fmt.Errorf("invalid values for %s: mixed text and number", key)
+			}
+		default:
+			return fmt.Errorf("operator %q is not supported for value %v of %s", operator, value, key)
+		}
+	}
+	return nil
+}
+
+func parseVisibilityQueryString(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("expect string but got %v", value)
+}
+
+// parseVisibilityQueryTime accepts unix nanoseconds or RFC3339 time
+func parseVisibilityQueryTime(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return time.Unix(0, v).UTC(), nil
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.UTC(), nil
+		}
+		if nanos, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(0, nanos).UTC(), nil
+		}
+	}
+	return nil, fmt.Errorf("expect unix nanoseconds or RFC3339 time but got %v", value)
+}
+
+func parseVisibilityQueryInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return nil, fmt.Errorf("expect integer but got %v", value)
+}
+
+// parseVisibilityQueryCloseStatus accepts either the value or the name of a close status
+func parseVisibilityQueryCloseStatus(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return int32(v), nil
+	case string:
+		var status types.WorkflowExecutionCloseStatus
+		if err := status.UnmarshalText([]byte(v)); err != nil {
+			return nil, err
+		}
+		return int32(status), nil
+	}
+	return nil, fmt.Errorf("expect close status but got %v", value)
+}
+
+func parseVisibilityQueryBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return nil, fmt.Errorf("expect bool but got %v", value)
+}
+
+func (e *visibilityQueryLogicalExpr) render(d VisibilityQueryDialect, args []interface{}) (string, []interface{}) {
+	left, args := e.left.render(d, args)
+	right, args := e.right.render(d, args)
+	return fmt.Sprintf("(%s %s %s)", left, e.operator, right), args
+}
+
+func (e *visibilityQueryColumnExpr) render(_ VisibilityQueryDialect, args []interface{}) (string, []interface{}) {
+	return renderVisibilityQueryComparison(e.column, e.operator, e.values, args)
+}
+
+func (e *visibilityQueryAttributeExpr) render(d VisibilityQueryDialect, args []interface{}) (string, []interface{}) {
+	switch e.operator {
+	case visibilityQueryIsNull, visibilityQueryIsNotNull:
+		return renderVisibilityQueryComparison(d.SearchAttribute(e.key), e.operator, nil, args)
+	case sqlparser.EqualStr, sqlparser.NotEqualStr, sqlparser.InStr, sqlparser.NotInStr:
+		// equality is checked by JSON containment, so keyword arrays match any of their elements
+		predicates := make([]string, len(e.values))
+		for i, value := range e.values {
+			predicates[i] = d.SearchAttributeContains(e.key)
+			args = append(args, encodeVisibilityQueryJSON(value))
+		}
+		predicate := "(" + strings.Join(predicates, " OR ") + ")"
+		if e.operator == sqlparser.NotEqualStr || e.operator == sqlparser.NotInStr {
+			predicate = "NOT " + predicate
+		}
+		return predicate, args
+	}
+	if _, isText := e.values[0].(string); isText {
+		return renderVisibilityQueryComparison(d.SearchAttributeText(e.key), e.operator, e.values, args)
+	}
+	return renderVisibilityQueryComparison(d.SearchAttributeNumber(e.key), e.operator, e.values, args)
+}
+
+func renderVisibilityQueryComparison(left string, operator string, values []interface{}, args []interface{}) (string, []interface{}) {
+	switch operator {
+	case visibilityQueryIsNull, visibilityQueryIsNotNull:
+		return fmt.Sprintf("%s %s", left, strings.ToUpper(operator)), args
+	case sqlparser.BetweenStr, sqlparser.NotBetweenStr:
+		return fmt.Sprintf("%s %s ? AND ?", left, strings.ToUpper(operator)), append(args, values...)
+	case sqlparser.InStr, sqlparser.NotInStr:
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return fmt.Sprintf("%s %s (%s)", left, strings.ToUpper(operator), placeholders), append(args, values...)
+	default:
+		return fmt.Sprintf("%s %s ?", left, operator), append(args, values...)
+	}
+}
+
+func encodeVisibilityQueryJSON(value interface{}) string {
+	// values are strings, numbers or bools, which can always be encoded
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,298 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testVisibilityQueryDialect struct{}
+
+func (testVisibilityQueryDialect) SearchAttribute(key string) string {
+	return fmt.Sprintf("attr(%s)", key)
+}
+
+func (testVisibilityQueryDialect) SearchAttributeText(key string) string {
+	return fmt.Sprintf("text(%s)", key)
+}
+
+func (testVisibilityQueryDialect) SearchAttributeNumber(key string) string {
+	return fmt.Sprintf("num(%s)", key)
+}
+
+func (testVisibilityQueryDialect) SearchAttributeContains(key string) string {
+	return fmt.Sprintf("contains(%s, ?)", key)
+}
+
+func (testVisibilityQueryDialect) JSONValue() string {
+	return "json(?)"
+}
+
+func TestBuildCountFromVisibilityByQuery(t *testing.T) {
+	startTime := time.Unix(0, 1700000000000000000).UTC()
+	tests := map[string]struct {
+		query string
+		where string
+		args  []interface{}
+		err   string
+	}{
+		"empty query": {
+			query: "",
+			where: "",
+			args:  []interface{}{"domain-id"},
+		},
+		"just order by": {
+			query: " order by StartTime desc",
+			where: "",
+			args:  []interface{}{"domain-id"},
+		},
+		"system keys": {
+			query: "WorkflowType = 'wt' and CloseTime = missing",
+			where: " AND (workflow_type_name = ? AND close_time IS NULL)",
+			args:  []interface{}{"domain-id", "wt"},
+		},
+		"operator priorities": {
+			query: "WorkflowID = 'wid' or RunID = 'rid' and CloseStatus != missing",
+			where: " AND (workflow_id = ? OR (run_id = ? AND close_status IS NOT NULL))",
+			args:  []interface{}{"domain-id", "wid", "rid"},
+		},
+		"time in nanoseconds and RFC3339": {
+			query: "StartTime >= 1700000000000000000 and StartTime < '2023-11-14T22:13:20Z'",
+			where: " AND (start_time >= ? AND start_time < ?)",
+			args:  []interface{}{"domain-id", startTime, startTime},
+		},
+		"close status by name and value": {
+			query: "CloseStatus in ('FAILED', 3)",
+			where: " AND close_status IN (?, ?)",
+			args:  []interface{}{"domain-id", int32(1), int32(3)},
+		},
+		"range on system key": {
+			query: "HistoryLength between 10 and '20'",
+			where: " AND history_length BETWEEN ? AND ?",
+			args:  []interface{}{"domain-id", int64(10), int64(20)},
+		},
+		"bool system key": {
+			query: "IsCron = true",
+			where: " AND is_cron = ?",
+			args:  []interface{}{"domain-id", true},
+		},
+		"custom keys with Attr prefix": {
+			query: "`Attr.CustomKeywordField` = 'keyword' and `Attr.CustomIntField` != 5",
+			where: " AND ((contains(CustomKeywordField, ?)) AND NOT (contains(CustomIntField, ?)))",
+			args:  []interface{}{"domain-id", `"keyword"`, "5"},
+		},
+		"custom key in list": {
+			query: "CustomKeywordField not in ('a', 'b')",
+			where: " AND NOT (contains(CustomKeywordField, ?) OR contains(CustomKeywordField, ?))",
+			args:  []interface{}{"domain-id", `"a"`, `"b"`},
+		},
+		"custom key range": {
+			query: "CustomDoubleField > 1.5 and CustomStringField <= 'abc' and CustomIntField between 1 and 10",
+			where: " AND ((num(CustomDoubleField) > ? AND text(CustomStringField) <= ?) AND num(CustomIntField) BETWEEN ? AND ?)",
+			args:  []interface{}{"domain-id", 1.5, "abc", int64(1), int64(10)},
+		},
+		"custom key missing": {
+			query: "CustomKeywordField = missing",
+			where: " AND attr(CustomKeywordField) IS NULL",
+			args:  []interface{}{"domain-id"},
+		},
+		"invalid query": {
+			query: "Invalid SQL",
+			err:   "Invalid query.",
+		},
+		"invalid where clause": {
+			query: "WorkflowID = 'wid' and not RunID = 'rid'",
+			err:   "invalid where clause",
+		},
+		"unsupported operator": {
+			query: "WorkflowID like 'wid%'",
+			err:   "operator \"like\" is not supported",
+		},
+		"invalid missing value": {
+			query: "CloseTime = unknown",
+			err:   "invalid value \"unknown\" for CloseTime",
+		},
+		"invalid system key value": {
+			query: "WorkflowID = 1",
+			err:   "invalid value for workflow_id: expect string but got 1",
+		},
+		"invalid close status": {
+			query: "CloseStatus = 'UNKNOWN'",
+			err:   "invalid value for close_status",
+		},
+		"invalid custom key": {
+			query: "`Attr.Custom'Field` = 'a'",
+			err:   "invalid search attribute \"Attr.Custom'Field\"",
+		},
+		"mixed custom key range": {
+			query: "CustomIntField between 1 and 'a'",
+			err:   "invalid values for CustomIntField: mixed text and number",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := ParseVisibilityQuery(test.query)
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			sql, args := BuildCountFromVisibilityByQuery(&VisibilityQueryFilter{
+				DomainID: "domain-id",
+				Query:    query,
+			}, testVisibilityQueryDialect{})
+			assert.Equal(t, "SELECT COUNT(*) FROM executions_visibility WHERE domain_id = ?"+test.where, sql)
+			assert.Equal(t, test.args, args)
+		})
+	}
+}
+
+func TestBuildSelectFromVisibilityByQuery(t *testing.T) {
+	lastStartTime := time.Unix(0, 1700000000000000000).UTC()
+	lastRunID := "run-id"
+	tests := map[string]struct {
+		query  string
+		filter VisibilityQueryFilter
+		sql    string
+		args   []interface{}
+	}{
+		"default order": {
+			query:  "WorkflowType = 'wt'",
+			filter: VisibilityQueryFilter{PageSize: 10},
+			sql:    " AND workflow_type_name = ? ORDER BY start_time DESC, run_id LIMIT ?",
+			args:   []interface{}{"domain-id", "wt", 10},
+		},
+		"default order next page": {
+			query: "WorkflowType = 'wt'",
+			filter: VisibilityQueryFilter{PageSize: 10, After: &VisibilityQueryCursor{
+				SortValues: []interface{}{lastStartTime.UnixNano()},
+				RunID:      lastRunID,
+			}},
+			sql:  " AND workflow_type_name = ? AND (start_time < ? OR start_time = ? AND run_id > ?) ORDER BY start_time DESC, run_id LIMIT ?",
+			args: []interface{}{"domain-id", "wt", lastStartTime, lastStartTime, lastRunID, 10},
+		},
+		"order by system and custom keys": {
+			query:  "WorkflowType = 'wt' order by CloseTime asc, `Attr.CustomIntField` desc",
+			filter: VisibilityQueryFilter{PageSize: 10},
+			sql: " AND workflow_type_name = ? ORDER BY close_time IS NULL, close_time ASC, " +
+				"attr(CustomIntField) IS NULL, attr(CustomIntField) DESC, run_id LIMIT ?",
+			args: []interface{}{"domain-id", "wt", 10},
+		},
+		"order by system and custom keys next page": {
+			query: "order by CloseTime asc, `Attr.CustomIntField` desc",
+			filter: VisibilityQueryFilter{PageSize: 10, After: &VisibilityQueryCursor{
+				SortValues: []interface{}{lastStartTime.UnixNano(), "5"},
+				RunID:      lastRunID,
+			}},
+			sql: " AND ((close_time > ? OR close_time IS NULL) OR " +
+				"close_time = ? AND (attr(CustomIntField) < json(?) OR attr(CustomIntField) IS NULL) OR " +
+				"close_time = ? AND attr(CustomIntField) = json(?) AND run_id > ?) " +
+				"ORDER BY close_time IS NULL, close_time ASC, attr(CustomIntField) IS NULL, attr(CustomIntField) DESC, run_id LIMIT ?",
+			args: []interface{}{"domain-id", lastStartTime, lastStartTime, "5", lastStartTime, "5", lastRunID, 10},
+		},
+		"next page after NULL values": {
+			query: "order by CloseTime desc",
+			filter: VisibilityQueryFilter{PageSize: 10, After: &VisibilityQueryCursor{
+				SortValues: []interface{}{nil},
+				RunID:      lastRunID,
+			}},
+			sql:  " AND (close_time IS NULL AND run_id > ?) ORDER BY close_time IS NULL, close_time DESC, run_id LIMIT ?",
+			args: []interface{}{"domain-id", lastRunID, 10},
+		},
+		"scan first page ignores order by": {
+			query:  "order by CloseTime asc",
+			filter: VisibilityQueryFilter{PageSize: 10, Scan: true},
+			sql:    " ORDER BY start_time DESC, run_id LIMIT ?",
+			args:   []interface{}{"domain-id", 10},
+		},
+		"scan next page": {
+			query:  "WorkflowType = 'wt'",
+			filter: VisibilityQueryFilter{PageSize: 10, Scan: true, LastStartTime: &lastStartTime, LastRunID: &lastRunID},
+			sql:    " AND workflow_type_name = ? AND (start_time < ? OR (start_time = ? AND run_id > ?)) ORDER BY start_time DESC, run_id LIMIT ?",
+			args:   []interface{}{"domain-id", "wt", lastStartTime, lastStartTime, lastRunID, 10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := ParseVisibilityQuery(test.query)
+			require.NoError(t, err)
+
+			filter := test.filter
+			filter.DomainID = "domain-id"
+			filter.Query = query
+			sql, args := BuildSelectFromVisibilityByQuery(&filter, testVisibilityQueryDialect{})
+			assert.Equal(t, "SELECT "+templateVisibilityQueryFieldNames+" FROM executions_visibility WHERE domain_id = ?"+test.sql, sql)
+			assert.Equal(t, test.args, args)
+		})
+	}
+}
+
+func TestParseVisibilityQuery_InvalidOrderBy(t *testing.T) {
+	_, err := ParseVisibilityQuery("order by `Attr.Custom Field` desc")
+	assert.Error(t, err)
+
+	// the value of task list isn't read, so the position of a row can't be tracked
+	_, err = ParseVisibilityQuery("order by TaskList")
+	assert.Error(t, err)
+}
+
+func TestVisibilityQueryCursor(t *testing.T) {
+	query, err := ParseVisibilityQuery("order by CloseTime desc, `Attr.CustomKeywordField`, CustomIntField")
+	require.NoError(t, err)
+
+	closeTime := time.Unix(0, 1700000000000000000)
+	cursor, err := NewVisibilityQueryCursor(query, &VisibilityRow{
+		RunID:            "run-id",
+		CloseTime:        &closeTime,
+		SearchAttributes: []byte(`{"CustomKeywordField":"keyword"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &VisibilityQueryCursor{
+		SortValues: []interface{}{closeTime.UnixNano(), `"keyword"`, nil},
+		RunID:      "run-id",
+	}, cursor)
+
+	token, err := json.Marshal(cursor)
+	require.NoError(t, err)
+	parsed, err := ParseVisibilityQueryCursor(query, token)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+
+	// the token must match the order of the query
+	otherQuery, err := ParseVisibilityQuery("order by CloseTime desc")
+	require.NoError(t, err)
+	_, err = ParseVisibilityQueryCursor(otherQuery, token)
+	assert.Error(t, err)
+	_, err = ParseVisibilityQueryCursor(query, []byte(`{"SortValues":["not a time","\"keyword\"",null],"RunID":"run-id"}`))
+	assert.Error(t, err)
+}
@@ -184,6 +184,16 @@ func (v *visibilityDualManager) chooseVisibilityModeForAdmin() string {
 }
 
 func (v *visibilityDualManager) chooseVisibilityManagerForWrite(ctx context.Context, dbVisFunc, esVisFunc func() error) error {
+	if store := getVisibilityStoreOverride(ctx); store != "" {
+		if _, err := chooseVisibilityManagerForStore(store, v.dbVisibilityManager, v.esVisibilityManager, nil); err != nil {
+			return err
+		}
+		if store == VisibilityStoreDB {
+			return dbVisFunc()
+		}
+		return esVisFunc()
+	}
+
 	var writeMode string
 	if v.writeMode != nil {
 		writeMode = v.writeMode()
@@ -231,7 +241,10 @@ func (v *visibilityDualManager) ListOpenWorkflowExecutions(
 	ctx context.Context,
 	request *ListWorkflowExecutionsRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutions(ctx, request)
 }
 
@@ -239,7 +252,10 @@ func (v *visibilityDualManager) ListClosedWorkflowExecutions(
 	ctx context.Context,
 	request *ListWorkflowExecutionsRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutions(ctx, request)
 }
 
@@ -247,7 +263,10 @@ func (v *visibilityDualManager) ListOpenWorkflowExecutionsByType(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByTypeRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutionsByType(ctx, request)
 }
 
@@ -255,7 +274,10 @@ func (v *visibilityDualManager) ListClosedWorkflowExecutionsByType(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByTypeRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByType(ctx, request)
 }
 
@@ -263,7 +285,10 @@ func (v *visibilityDualManager) ListOpenWorkflowExecutionsByWorkflowID(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByWorkflowIDRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListOpenWorkflowExecutionsByWorkflowID(ctx, request)
 }
 
@@ -271,7 +296,10 @@ func (v *visibilityDualManager) ListClosedWorkflowExecutionsByWorkflowID(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByWorkflowIDRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByWorkflowID(ctx, request)
 }
 
@@ -279,7 +307,10 @@ func (v *visibilityDualManager) ListClosedWorkflowExecutionsByStatus(
 	ctx context.Context,
 	request *ListClosedWorkflowExecutionsByStatusRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListClosedWorkflowExecutionsByStatus(ctx, request)
 }
 
@@ -287,7 +318,10 @@ func (v *visibilityDualManager) GetClosedWorkflowExecution(
 	ctx context.Context,
 	request *GetClosedWorkflowExecutionRequest,
 ) (*GetClosedWorkflowExecutionResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.GetClosedWorkflowExecution(ctx, request)
 }
 
@@ -295,7 +329,10 @@ func (v *visibilityDualManager) ListWorkflowExecutions(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByQueryRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ListWorkflowExecutions(ctx, request)
 }
 
@@ -303,7 +340,10 @@ func (v *visibilityDualManager) ScanWorkflowExecutions(
 	ctx context.Context,
 	request *ListWorkflowExecutionsByQueryRequest,
 ) (*ListWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.ScanWorkflowExecutions(ctx, request)
 }
 
@@ -311,11 +351,18 @@ func (v *visibilityDualManager) CountWorkflowExecutions(
 	ctx context.Context,
 	request *CountWorkflowExecutionsRequest,
 ) (*CountWorkflowExecutionsResponse, error) {
-	manager := v.chooseVisibilityManagerForRead(request.Domain)
+	manager, err := v.chooseVisibilityManagerForRead(ctx, request.Domain)
+	if err != nil {
+		return nil, err
+	}
 	return manager.CountWorkflowExecutions(ctx, request)
 }
 
-func (v *visibilityDualManager) chooseVisibilityManagerForRead(domain string) VisibilityManager {
+func (v *visibilityDualManager) chooseVisibilityManagerForRead(ctx context.Context, domain string) (VisibilityManager, error) {
+	if store := getVisibilityStoreOverride(ctx); store != "" {
+		return chooseVisibilityManagerForStore(store, v.dbVisibilityManager, v.esVisibilityManager, nil)
+	}
+
 	var visibilityMgr VisibilityManager
 	if v.readModeIsFromES(domain) {
 		if v.esVisibilityManager != nil {
@@ -334,5 +381,5 @@ func (v *visibilityDualManager) chooseVisibilityManagerForRead(domain string) Vi
 				tag.WorkflowDomainName(domain))
 		}
 	}
-	return visibilityMgr
+	return visibilityMgr, nil
 }
@@ -0,0 +1,132 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package persistence
+
+import (
+	"context"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+)
+
+type (
+	// visibilitySearchAttributesMigrator writes the values of the search attributes being migrated to their new keys
+	// as well, so that the records written during a migration don't have to be copied
+	visibilitySearchAttributesMigrator struct {
+		VisibilityManager
+		validSearchAttributes    dynamicconfig.MapPropertyFn
+		migratedSearchAttributes dynamicconfig.MapPropertyFn
+		logger                   log.Logger
+	}
+)
+
+// NewVisibilitySearchAttributesMigrator returns a visibility manager which also writes the values of the
+// migrated search attributes to the keys they are migrated to
+func NewVisibilitySearchAttributesMigrator(
+	manager VisibilityManager,
+	validSearchAttributes dynamicconfig.MapPropertyFn,
+	migratedSearchAttributes dynamicconfig.MapPropertyFn,
+	logger log.Logger,
+) VisibilityManager {
+	return &visibilitySearchAttributesMigrator{
+		VisibilityManager:        manager,
+		validSearchAttributes:    validSearchAttributes,
+		migratedSearchAttributes: migratedSearchAttributes,
+		logger:                   logger,
+	}
+}
+
+func (v *visibilitySearchAttributesMigrator) RecordWorkflowExecutionStarted(
+	ctx context.Context,
+	request *RecordWorkflowExecutionStartedRequest,
+) error {
+	migratedRequest := *request
+	migratedRequest.SearchAttributes = v.migrateSearchAttributes(request.Domain, request.SearchAttributes)
+	return v.VisibilityManager.RecordWorkflowExecutionStarted(ctx, &migratedRequest)
+}
+
+func (v *visibilitySearchAttributesMigrator) RecordWorkflowExecutionClosed(
+	ctx context.Context,
+	request *RecordWorkflowExecutionClosedRequest,
+) error {
+	migratedRequest := *request
+	migratedRequest.SearchAttributes = v.migrateSearchAttributes(request.Domain, request.SearchAttributes)
+	return v.VisibilityManager.RecordWorkflowExecutionClosed(ctx, &migratedRequest)
+}
+
+func (v *visibilitySearchAttributesMigrator) UpsertWorkflowExecution(
+	ctx context.Context,
+	request *UpsertWorkflowExecutionRequest,
+) error {
+	migratedRequest := *request
+	migratedRequest.SearchAttributes = v.migrateSearchAttributes(request.Domain, request.SearchAttributes)
+	return v.VisibilityManager.UpsertWorkflowExecution(ctx, &migratedRequest)
+}
+
+// migrateSearchAttributes returns a copy of the search attributes with the values of the migrated keys added to their
+// new keys. A value which can't be converted to the type of its new key is only written to its old key.
+func (v *visibilitySearchAttributesMigrator) migrateSearchAttributes(
+	domain string,
+	searchAttributes map[string][]byte,
+) map[string][]byte {
+	migratedKeys := v.migratedSearchAttributes()
+	if len(migratedKeys) == 0 || len(searchAttributes) == 0 {
+		return searchAttributes
+	}
+
+	var result map[string][]byte
+	validKeys := v.validSearchAttributes()
+	for key, newKeyValue := range migratedKeys {
+		value, ok := searchAttributes[key]
+		newKey, isString := newKeyValue.(string)
+		if !ok || !isString {
+			continue
+		}
+		// the workflow already writes the new key
+		if _, ok := searchAttributes[newKey]; ok {
+			continue
+		}
+		valueType, ok := validKeys[newKey]
+		if !ok {
+			continue
+		}
+		converted, err := common.ConvertSearchAttributeValue(value, common.ConvertIndexedValueTypeToInternalType(valueType, v.logger))
+		if err != nil {
+			v.logger.Warn("Failed to convert the value of a migrated search attribute.",
+				tag.WorkflowDomainName(domain), tag.ESKey(key), tag.ESValue(value), tag.Error(err))
+			continue
+		}
+		if result == nil {
+			result = make(map[string][]byte, len(searchAttributes)+len(migratedKeys))
+			for k, v := range searchAttributes {
+				result[k] = v
+			}
+		}
+		result[newKey] = converted
+	}
+	if result == nil {
+		return searchAttributes
+	}
+	return result
+}
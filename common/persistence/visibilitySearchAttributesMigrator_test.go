@@ -0,0 +1,111 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package persistence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestVisibilitySearchAttributesMigrator(t *testing.T) {
+	validKeys := map[string]interface{}{
+		"OldIntField":     types.IndexedValueTypeKeyword,
+		"NewIntField":     types.IndexedValueTypeInt,
+		"CustomBoolField": types.IndexedValueTypeBool,
+	}
+	migratedKeys := map[string]interface{}{"OldIntField": "NewIntField"}
+
+	tests := map[string]struct {
+		searchAttributes map[string][]byte
+		expected         map[string][]byte
+	}{
+		"old key is copied to new key": {
+			searchAttributes: map[string][]byte{"OldIntField": []byte(`"12"`), "CustomBoolField": []byte(`true`)},
+			expected:         map[string][]byte{"OldIntField": []byte(`"12"`), "NewIntField": []byte(`12`), "CustomBoolField": []byte(`true`)},
+		},
+		"new key written by the workflow is kept": {
+			searchAttributes: map[string][]byte{"OldIntField": []byte(`"12"`), "NewIntField": []byte(`13`)},
+			expected:         map[string][]byte{"OldIntField": []byte(`"12"`), "NewIntField": []byte(`13`)},
+		},
+		"value which can't be converted is only written to old key": {
+			searchAttributes: map[string][]byte{"OldIntField": []byte(`"twelve"`)},
+			expected:         map[string][]byte{"OldIntField": []byte(`"twelve"`)},
+		},
+		"no search attributes": {
+			searchAttributes: nil,
+			expected:         nil,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockManager := NewMockVisibilityManager(ctrl)
+			migrator := NewVisibilitySearchAttributesMigrator(
+				mockManager,
+				dynamicconfig.GetMapPropertyFn(validKeys),
+				dynamicconfig.GetMapPropertyFn(migratedKeys),
+				log.NewNoop(),
+			)
+
+			mockManager.EXPECT().RecordWorkflowExecutionStarted(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, request *RecordWorkflowExecutionStartedRequest) error {
+					assert.Equal(t, test.expected, request.SearchAttributes)
+					return nil
+				})
+			mockManager.EXPECT().RecordWorkflowExecutionClosed(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, request *RecordWorkflowExecutionClosedRequest) error {
+					assert.Equal(t, test.expected, request.SearchAttributes)
+					return nil
+				})
+			mockManager.EXPECT().UpsertWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, request *UpsertWorkflowExecutionRequest) error {
+					assert.Equal(t, test.expected, request.SearchAttributes)
+					return nil
+				})
+
+			original := copySearchAttributes(test.searchAttributes)
+			assert.NoError(t, migrator.RecordWorkflowExecutionStarted(context.Background(), &RecordWorkflowExecutionStartedRequest{SearchAttributes: test.searchAttributes}))
+			assert.NoError(t, migrator.RecordWorkflowExecutionClosed(context.Background(), &RecordWorkflowExecutionClosedRequest{SearchAttributes: test.searchAttributes}))
+			assert.NoError(t, migrator.UpsertWorkflowExecution(context.Background(), &UpsertWorkflowExecutionRequest{SearchAttributes: test.searchAttributes}))
+			// the search attributes of the caller, e.g. of the mutable state, are not changed
+			assert.Equal(t, original, test.searchAttributes)
+		})
+	}
+}
+
+func copySearchAttributes(searchAttributes map[string][]byte) map[string][]byte {
+	if searchAttributes == nil {
+		return nil
+	}
+	result := make(map[string][]byte, len(searchAttributes))
+	for k, v := range searchAttributes {
+		result[k] = v
+	}
+	return result
+}
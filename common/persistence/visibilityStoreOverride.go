@@ -0,0 +1,85 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uber/cadence/common/types"
+)
+
+// VisibilityStoreOverrideContextKey is the type of the context key used to override the visibility store
+type VisibilityStoreOverrideContextKey string
+
+const (
+	// VisibilityStoreDB is the basic visibility store, i.e. Cassandra or SQL
+	VisibilityStoreDB = "db"
+	// VisibilityStoreES is the ElasticSearch or OpenSearch advanced visibility store
+	VisibilityStoreES = "es"
+	// VisibilityStorePinot is the Pinot advanced visibility store
+	VisibilityStorePinot = "pinot"
+
+	visibilityStoreOverrideKey = VisibilityStoreOverrideContextKey("visibility-store-override")
+)
+
+// ContextWithVisibilityStoreOverride returns a context which pins the reads and writes of the dual and triple
+// visibility managers to a single visibility store, regardless of the configured read and write modes.
+// It is used to copy records from one visibility store to another.
+func ContextWithVisibilityStoreOverride(ctx context.Context, store string) context.Context {
+	return context.WithValue(ctx, visibilityStoreOverrideKey, store)
+}
+
+// IsValidVisibilityStore returns whether the store can be used as a visibility store override
+func IsValidVisibilityStore(store string) bool {
+	switch store {
+	case VisibilityStoreDB, VisibilityStoreES, VisibilityStorePinot:
+		return true
+	default:
+		return false
+	}
+}
+
+func getVisibilityStoreOverride(ctx context.Context) string {
+	store, _ := ctx.Value(visibilityStoreOverrideKey).(string)
+	return store
+}
+
+// chooseVisibilityManagerForStore returns the visibility manager of the overridden store, managers can be nil if not configured
+func chooseVisibilityManagerForStore(store string, dbVisibilityManager, esVisibilityManager, pinotVisibilityManager VisibilityManager) (VisibilityManager, error) {
+	var manager VisibilityManager
+	switch store {
+	case VisibilityStoreDB:
+		manager = dbVisibilityManager
+	case VisibilityStoreES:
+		manager = esVisibilityManager
+	case VisibilityStorePinot:
+		manager = pinotVisibilityManager
+	default:
+		return nil, &types.BadRequestError{Message: fmt.Sprintf("unknown visibility store: %s", store)}
+	}
+	if manager == nil {
+		return nil, &types.BadRequestError{Message: fmt.Sprintf("visibility store %s is not configured", store)}
+	}
+	return manager, nil
+}
@@ -0,0 +1,142 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encrypted
+
+import (
+	"context"
+
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/encryption"
+)
+
+// encryptedExecutionManager encrypts the payloads of the mutable state it writes and decrypts the ones it reads.
+type encryptedExecutionManager struct {
+	persistence.ExecutionManager
+	encryptor encryption.Encryptor
+	// writeEncryptor encrypts the payloads written, or only escapes them when encryption is disabled
+	writeEncryptor encryption.Encryptor
+}
+
+// NewExecutionManager creates a new instance of ExecutionManager with encryption at rest.
+// Payloads are only encrypted when encryptWrites is true, but encrypted payloads are always decrypted.
+// When encryptWrites is false, the payloads which look like encrypted payloads are escaped.
+func NewExecutionManager(
+	wrapped persistence.ExecutionManager,
+	encryptor encryption.Encryptor,
+	encryptWrites bool,
+) persistence.ExecutionManager {
+	return &encryptedExecutionManager{
+		ExecutionManager: wrapped,
+		encryptor:        encryptor,
+		writeEncryptor:   writeEncryptor(encryptor, encryptWrites),
+	}
+}
+
+func (c *encryptedExecutionManager) CreateWorkflowExecution(
+	ctx context.Context,
+	request *persistence.CreateWorkflowExecutionRequest,
+) (*persistence.CreateWorkflowExecutionResponse, error) {
+	snapshot, err := encryption.EncryptWorkflowSnapshot(ctx, c.writeEncryptor, request.DomainName, &request.NewWorkflowSnapshot)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	encryptedRequest := *request
+	encryptedRequest.NewWorkflowSnapshot = *snapshot
+	return c.ExecutionManager.CreateWorkflowExecution(ctx, &encryptedRequest)
+}
+
+func (c *encryptedExecutionManager) UpdateWorkflowExecution(
+	ctx context.Context,
+	request *persistence.UpdateWorkflowExecutionRequest,
+) (*persistence.UpdateWorkflowExecutionResponse, error) {
+	mutation, err := encryption.EncryptWorkflowMutation(ctx, c.writeEncryptor, request.DomainName, &request.UpdateWorkflowMutation)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	newSnapshot, err := encryption.EncryptWorkflowSnapshot(ctx, c.writeEncryptor, request.DomainName, request.NewWorkflowSnapshot)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	encryptedRequest := *request
+	encryptedRequest.UpdateWorkflowMutation = *mutation
+	encryptedRequest.NewWorkflowSnapshot = newSnapshot
+	return c.ExecutionManager.UpdateWorkflowExecution(ctx, &encryptedRequest)
+}
+
+func (c *encryptedExecutionManager) ConflictResolveWorkflowExecution(
+	ctx context.Context,
+	request *persistence.ConflictResolveWorkflowExecutionRequest,
+) (*persistence.ConflictResolveWorkflowExecutionResponse, error) {
+	resetSnapshot, err := encryption.EncryptWorkflowSnapshot(ctx, c.writeEncryptor, request.DomainName, &request.ResetWorkflowSnapshot)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	newSnapshot, err := encryption.EncryptWorkflowSnapshot(ctx, c.writeEncryptor, request.DomainName, request.NewWorkflowSnapshot)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	currentMutation, err := encryption.EncryptWorkflowMutation(ctx, c.writeEncryptor, request.DomainName, request.CurrentWorkflowMutation)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	encryptedRequest := *request
+	encryptedRequest.ResetWorkflowSnapshot = *resetSnapshot
+	encryptedRequest.NewWorkflowSnapshot = newSnapshot
+	encryptedRequest.CurrentWorkflowMutation = currentMutation
+	return c.ExecutionManager.ConflictResolveWorkflowExecution(ctx, &encryptedRequest)
+}
+
+func (c *encryptedExecutionManager) GetWorkflowExecution(
+	ctx context.Context,
+	request *persistence.GetWorkflowExecutionRequest,
+) (*persistence.GetWorkflowExecutionResponse, error) {
+	resp, err := c.ExecutionManager.GetWorkflowExecution(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp.State, err = encryption.DecryptWorkflowMutableState(ctx, c.encryptor, resp.State)
+	if err != nil {
+		return nil, decryptionError(err)
+	}
+	return resp, nil
+}
+
+func (c *encryptedExecutionManager) ListConcreteExecutions(
+	ctx context.Context,
+	request *persistence.ListConcreteExecutionsRequest,
+) (*persistence.ListConcreteExecutionsResponse, error) {
+	resp, err := c.ExecutionManager.ListConcreteExecutions(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for _, execution := range resp.Executions {
+		if execution == nil {
+			continue
+		}
+		execution.ExecutionInfo, err = encryption.DecryptWorkflowExecutionInfo(ctx, c.encryptor, execution.ExecutionInfo)
+		if err != nil {
+			return nil, decryptionError(err)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,155 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encrypted
+
+import (
+	"context"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/encryption"
+	"github.com/uber/cadence/common/types"
+)
+
+// encryptedHistoryManager encrypts the payloads of the history events it writes and decrypts the ones it reads.
+type encryptedHistoryManager struct {
+	persistence.HistoryManager
+	encryptor encryption.Encryptor
+	// writeEncryptor encrypts the payloads written, or only escapes them when encryption is disabled
+	writeEncryptor encryption.Encryptor
+	serializer     persistence.PayloadSerializer
+}
+
+// NewHistoryManager creates a new instance of HistoryManager with encryption at rest.
+// Payloads are only encrypted when encryptWrites is true, but encrypted payloads are always decrypted,
+// so that encryption can be turned off without losing access to the data written before.
+// When encryptWrites is false, the payloads which look like encrypted payloads are escaped.
+func NewHistoryManager(
+	wrapped persistence.HistoryManager,
+	encryptor encryption.Encryptor,
+	encryptWrites bool,
+) persistence.HistoryManager {
+	return &encryptedHistoryManager{
+		HistoryManager: wrapped,
+		encryptor:      encryptor,
+		writeEncryptor: writeEncryptor(encryptor, encryptWrites),
+		serializer:     persistence.NewPayloadSerializer(),
+	}
+}
+
+func writeEncryptor(encryptor encryption.Encryptor, encryptWrites bool) encryption.Encryptor {
+	if encryptWrites {
+		return encryptor
+	}
+	return encryption.NewPlaintextEncryptor(encryptor)
+}
+
+func (c *encryptedHistoryManager) AppendHistoryNodes(
+	ctx context.Context,
+	request *persistence.AppendHistoryNodesRequest,
+) (*persistence.AppendHistoryNodesResponse, error) {
+	events, err := encryption.EncryptEvents(ctx, c.writeEncryptor, request.DomainName, request.Events)
+	if err != nil {
+		return nil, encryptionError(err)
+	}
+	encryptedRequest := *request
+	encryptedRequest.Events = events
+	resp, err := c.HistoryManager.AppendHistoryNodes(ctx, &encryptedRequest)
+	if err != nil {
+		return resp, err
+	}
+
+	// the persisted blob is used by replication, which sends it to other clusters in plain text
+	blob, err := c.serializer.SerializeBatchEvents(request.Events, common.EncodingTypeThriftRW)
+	if err != nil {
+		return nil, err
+	}
+	return &persistence.AppendHistoryNodesResponse{
+		DataBlob: *blob,
+	}, nil
+}
+
+func (c *encryptedHistoryManager) ReadHistoryBranch(
+	ctx context.Context,
+	request *persistence.ReadHistoryBranchRequest,
+) (*persistence.ReadHistoryBranchResponse, error) {
+	resp, err := c.HistoryManager.ReadHistoryBranch(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp.HistoryEvents, err = encryption.DecryptEvents(ctx, c.encryptor, resp.HistoryEvents)
+	if err != nil {
+		return nil, decryptionError(err)
+	}
+	return resp, nil
+}
+
+func (c *encryptedHistoryManager) ReadHistoryBranchByBatch(
+	ctx context.Context,
+	request *persistence.ReadHistoryBranchRequest,
+) (*persistence.ReadHistoryBranchByBatchResponse, error) {
+	resp, err := c.HistoryManager.ReadHistoryBranchByBatch(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for i, batch := range resp.History {
+		if batch == nil {
+			continue
+		}
+		events, err := encryption.DecryptEvents(ctx, c.encryptor, batch.Events)
+		if err != nil {
+			return nil, decryptionError(err)
+		}
+		resp.History[i] = &types.History{Events: events}
+	}
+	return resp, nil
+}
+
+func (c *encryptedHistoryManager) ReadRawHistoryBranch(
+	ctx context.Context,
+	request *persistence.ReadHistoryBranchRequest,
+) (*persistence.ReadRawHistoryBranchResponse, error) {
+	resp, err := c.HistoryManager.ReadRawHistoryBranch(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for i, blob := range resp.HistoryEventBlobs {
+		// raw history is sent to other clusters, so the blobs which contain encrypted payloads are rewritten
+		if blob == nil || (blob.Encoding == common.EncodingTypeThriftRW && !encryption.ContainsEncrypted(blob.Data)) {
+			continue
+		}
+		events, err := c.serializer.DeserializeBatchEvents(blob)
+		if err != nil {
+			return nil, err
+		}
+		events, err = encryption.DecryptEvents(ctx, c.encryptor, events)
+		if err != nil {
+			return nil, decryptionError(err)
+		}
+		resp.HistoryEventBlobs[i], err = c.serializer.SerializeBatchEvents(events, common.EncodingTypeThriftRW)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
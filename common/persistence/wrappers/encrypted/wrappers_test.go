@@ -0,0 +1,265 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encrypted
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/encryption"
+	"github.com/uber/cadence/common/types"
+)
+
+func newTestEncryptor(t *testing.T) encryption.Encryptor {
+	return newTestEncryptorWithKey(t, 0)
+}
+
+func newTestEncryptorWithKey(t *testing.T, b byte) encryption.Encryptor {
+	keyBytes := make([]byte, 32)
+	for i := range keyBytes {
+		keyBytes[i] = b
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("primaryKey: key-1\nkeys:\n  key-1: "+key+"\n"), 0600))
+	kms, err := encryption.NewKeyFileKMS(path)
+	require.NoError(t, err)
+	return encryption.NewEncryptor(kms, 0)
+}
+
+func newTestEvents() []*types.HistoryEvent {
+	return []*types.HistoryEvent{
+		{
+			ID:        1,
+			EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+			WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+				Input: []byte("input"),
+			},
+		},
+	}
+}
+
+func TestHistoryManager(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	wrapped := persistence.NewMockHistoryManager(ctrl)
+	encryptor := newTestEncryptor(t)
+	manager := NewHistoryManager(wrapped, encryptor, true)
+	serializer := persistence.NewPayloadSerializer()
+
+	// events are encrypted at rest, and the response contains the plain text blob
+	events := newTestEvents()
+	var persisted []*types.HistoryEvent
+	wrapped.EXPECT().AppendHistoryNodes(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.AppendHistoryNodesRequest) (*persistence.AppendHistoryNodesResponse, error) {
+			persisted = request.Events
+			blob, err := serializer.SerializeBatchEvents(request.Events, common.EncodingTypeThriftRW)
+			return &persistence.AppendHistoryNodesResponse{DataBlob: *blob}, err
+		})
+	resp, err := manager.AppendHistoryNodes(ctx, &persistence.AppendHistoryNodesRequest{
+		Events:     events,
+		DomainName: "domain",
+	})
+	require.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(persisted[0].WorkflowExecutionStartedEventAttributes.Input))
+	assert.Equal(t, []byte("input"), events[0].WorkflowExecutionStartedEventAttributes.Input)
+	respEvents, err := serializer.DeserializeBatchEvents(&resp.DataBlob)
+	require.NoError(t, err)
+	assert.Equal(t, events, respEvents)
+
+	// reads are decrypted
+	wrapped.EXPECT().ReadHistoryBranch(gomock.Any(), gomock.Any()).Return(&persistence.ReadHistoryBranchResponse{
+		HistoryEvents: persisted,
+	}, nil)
+	readResp, err := manager.ReadHistoryBranch(ctx, &persistence.ReadHistoryBranchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, events, readResp.HistoryEvents)
+
+	wrapped.EXPECT().ReadHistoryBranchByBatch(gomock.Any(), gomock.Any()).Return(&persistence.ReadHistoryBranchByBatchResponse{
+		History: []*types.History{{Events: persisted}},
+	}, nil)
+	batchResp, err := manager.ReadHistoryBranchByBatch(ctx, &persistence.ReadHistoryBranchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, events, batchResp.History[0].Events)
+
+	// raw history is rewritten in plain text
+	encryptedBlob, err := serializer.SerializeBatchEvents(persisted, common.EncodingTypeThriftRW)
+	require.NoError(t, err)
+	plainBlob, err := serializer.SerializeBatchEvents(events, common.EncodingTypeThriftRW)
+	require.NoError(t, err)
+	wrapped.EXPECT().ReadRawHistoryBranch(gomock.Any(), gomock.Any()).Return(&persistence.ReadRawHistoryBranchResponse{
+		HistoryEventBlobs: []*persistence.DataBlob{encryptedBlob, plainBlob},
+	}, nil)
+	rawResp, err := manager.ReadRawHistoryBranch(ctx, &persistence.ReadHistoryBranchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []*persistence.DataBlob{plainBlob, plainBlob}, rawResp.HistoryEventBlobs)
+}
+
+func TestHistoryManager_EncryptionDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wrapped := persistence.NewMockHistoryManager(ctrl)
+	manager := NewHistoryManager(wrapped, newTestEncryptor(t), false)
+
+	// payloads are written in plain text, except the ones which look like encrypted payloads, which are escaped
+	events := newTestEvents()
+	colliding := append([]byte{0x00, 'c', 'e', 'n', 'c', 1}, "user data"...)
+	events = append(events, &types.HistoryEvent{
+		ID:        2,
+		EventType: types.EventTypeWorkflowExecutionSignaled.Ptr(),
+		WorkflowExecutionSignaledEventAttributes: &types.WorkflowExecutionSignaledEventAttributes{
+			Input: colliding,
+		},
+	})
+	var persisted []*types.HistoryEvent
+	wrapped.EXPECT().AppendHistoryNodes(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.AppendHistoryNodesRequest) (*persistence.AppendHistoryNodesResponse, error) {
+			persisted = request.Events
+			return &persistence.AppendHistoryNodesResponse{}, nil
+		})
+	_, err := manager.AppendHistoryNodes(context.Background(), &persistence.AppendHistoryNodesRequest{Events: events})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("input"), persisted[0].WorkflowExecutionStartedEventAttributes.Input)
+	assert.NotEqual(t, colliding, persisted[1].WorkflowExecutionSignaledEventAttributes.Input)
+
+	wrapped.EXPECT().ReadHistoryBranch(gomock.Any(), gomock.Any()).Return(&persistence.ReadHistoryBranchResponse{
+		HistoryEvents: persisted,
+	}, nil)
+	readResp, err := manager.ReadHistoryBranch(context.Background(), &persistence.ReadHistoryBranchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, events, readResp.HistoryEvents)
+}
+
+func TestExecutionManager(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	wrapped := persistence.NewMockExecutionManager(ctrl)
+	manager := NewExecutionManager(wrapped, newTestEncryptor(t), true)
+
+	executionInfo := &persistence.WorkflowExecutionInfo{
+		WorkflowID: "workflow-id",
+		Memo:       map[string][]byte{"key": []byte("memo")},
+	}
+	activityInfo := &persistence.ActivityInfo{
+		ScheduleID: 5,
+		Details:    []byte("heartbeat details"),
+	}
+
+	var persistedInfo *persistence.WorkflowExecutionInfo
+	var persistedActivity *persistence.ActivityInfo
+	wrapped.EXPECT().CreateWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.CreateWorkflowExecutionRequest) (*persistence.CreateWorkflowExecutionResponse, error) {
+			persistedInfo = request.NewWorkflowSnapshot.ExecutionInfo
+			return &persistence.CreateWorkflowExecutionResponse{}, nil
+		})
+	_, err := manager.CreateWorkflowExecution(ctx, &persistence.CreateWorkflowExecutionRequest{
+		NewWorkflowSnapshot: persistence.WorkflowSnapshot{ExecutionInfo: executionInfo},
+		DomainName:          "domain",
+	})
+	require.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(persistedInfo.Memo["key"]))
+
+	wrapped.EXPECT().UpdateWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.UpdateWorkflowExecutionRequest) (*persistence.UpdateWorkflowExecutionResponse, error) {
+			persistedActivity = request.UpdateWorkflowMutation.UpsertActivityInfos[0]
+			return &persistence.UpdateWorkflowExecutionResponse{}, nil
+		})
+	_, err = manager.UpdateWorkflowExecution(ctx, &persistence.UpdateWorkflowExecutionRequest{
+		UpdateWorkflowMutation: persistence.WorkflowMutation{
+			ExecutionInfo:       executionInfo,
+			UpsertActivityInfos: []*persistence.ActivityInfo{activityInfo},
+		},
+		DomainName: "domain",
+	})
+	require.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(persistedActivity.Details))
+	assert.Equal(t, []byte("heartbeat details"), activityInfo.Details)
+
+	wrapped.EXPECT().ConflictResolveWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.ConflictResolveWorkflowExecutionRequest) (*persistence.ConflictResolveWorkflowExecutionResponse, error) {
+			assert.True(t, encryption.IsEncrypted(request.ResetWorkflowSnapshot.ExecutionInfo.Memo["key"]))
+			assert.Nil(t, request.NewWorkflowSnapshot)
+			assert.True(t, encryption.IsEncrypted(request.CurrentWorkflowMutation.ExecutionInfo.Memo["key"]))
+			return &persistence.ConflictResolveWorkflowExecutionResponse{}, nil
+		})
+	_, err = manager.ConflictResolveWorkflowExecution(ctx, &persistence.ConflictResolveWorkflowExecutionRequest{
+		ResetWorkflowSnapshot:   persistence.WorkflowSnapshot{ExecutionInfo: executionInfo},
+		CurrentWorkflowMutation: &persistence.WorkflowMutation{ExecutionInfo: executionInfo},
+		DomainName:              "domain",
+	})
+	require.NoError(t, err)
+
+	wrapped.EXPECT().GetWorkflowExecution(gomock.Any(), gomock.Any()).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{
+			ExecutionInfo: persistedInfo,
+			ActivityInfos: map[int64]*persistence.ActivityInfo{5: persistedActivity},
+		},
+	}, nil)
+	getResp, err := manager.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, executionInfo, getResp.State.ExecutionInfo)
+	assert.Equal(t, activityInfo, getResp.State.ActivityInfos[5])
+
+	wrapped.EXPECT().ListConcreteExecutions(gomock.Any(), gomock.Any()).Return(&persistence.ListConcreteExecutionsResponse{
+		Executions: []*persistence.ListConcreteExecutionsEntity{{ExecutionInfo: persistedInfo}},
+	}, nil)
+	listResp, err := manager.ListConcreteExecutions(ctx, &persistence.ListConcreteExecutionsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, executionInfo, listResp.Executions[0].ExecutionInfo)
+}
+
+func TestExecutionManager_DecryptionError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wrapped := persistence.NewMockExecutionManager(ctrl)
+	writer := NewExecutionManager(wrapped, newTestEncryptor(t), true)
+	// a different master key can't decrypt the data key
+	reader := NewExecutionManager(wrapped, newTestEncryptorWithKey(t, 1), true)
+
+	var persistedInfo *persistence.WorkflowExecutionInfo
+	wrapped.EXPECT().CreateWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *persistence.CreateWorkflowExecutionRequest) (*persistence.CreateWorkflowExecutionResponse, error) {
+			persistedInfo = request.NewWorkflowSnapshot.ExecutionInfo
+			return &persistence.CreateWorkflowExecutionResponse{}, nil
+		})
+	_, err := writer.CreateWorkflowExecution(context.Background(), &persistence.CreateWorkflowExecutionRequest{
+		NewWorkflowSnapshot: persistence.WorkflowSnapshot{
+			ExecutionInfo: &persistence.WorkflowExecutionInfo{Memo: map[string][]byte{"key": []byte("memo")}},
+		},
+	})
+	require.NoError(t, err)
+
+	wrapped.EXPECT().GetWorkflowExecution(gomock.Any(), gomock.Any()).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{ExecutionInfo: persistedInfo},
+	}, nil)
+	_, err = reader.GetWorkflowExecution(context.Background(), &persistence.GetWorkflowExecutionRequest{})
+	var deserializationErr *persistence.CadenceDeserializationError
+	assert.ErrorAs(t, err, &deserializationErr)
+}
@@ -32,6 +32,8 @@ type (
 		Search(request *SearchRequest) (*SearchResponse, error)
 		// CountByQuery is for returning the count of workflow executions that match the query
 		CountByQuery(query string) (int64, error)
+		// SearchAggr is for returning the rows of an aggregation query, e.g. counts with GROUP BY
+		SearchAggr(query string) (AggrResponse, error)
 		GetTableName() string
 	}
 
@@ -49,4 +51,7 @@ type (
 
 	// SearchResponse is a response to Search, SearchByQuery and ScanByQuery
 	SearchResponse = p.InternalListWorkflowExecutionsResponse
+
+	// AggrResponse is the result rows of an aggregation query
+	AggrResponse [][]interface{}
 )
@@ -88,6 +88,20 @@ func (c *PinotClient) CountByQuery(query string) (int64, error) {
 	}
 }
 
+func (c *PinotClient) SearchAggr(query string) (AggrResponse, error) {
+	resp, err := c.client.ExecuteSQL(c.tableName, query)
+	if err != nil {
+		return nil, &types.InternalServiceError{
+			Message: fmt.Sprintf("SearchAggr ExecuteSQL failed, %v", err),
+		}
+	}
+	if resp.ResultTable == nil {
+		return nil, nil
+	}
+
+	return resp.ResultTable.Rows, nil
+}
+
 func (c *PinotClient) GetTableName() string {
 	return c.tableName
 }
@@ -60,6 +60,25 @@ const (
 
 	// ClientIsolationGroupHeaderName refers to the name of the header that contains the isolation group which the client request is from
 	ClientIsolationGroupHeaderName = "cadence-client-isolation-group"
+
+	// PriorityHeaderName refers to the name of the header that contains the priority of the tasks of the workflow started by the request
+	PriorityHeaderName = "cadence-priority"
+	// FairnessKeyHeaderName refers to the name of the header that contains the fairness key of the tasks of the workflow started by the request
+	FairnessKeyHeaderName = "cadence-fairness-key"
+
+	// AsyncWorkflowRequestHeaderName refers to the name of the header that marks the request is consumed from the async workflow queue
+	AsyncWorkflowRequestHeaderName = "cadence-async-workflow-request"
+
+	// QueueStartOverConcurrencyLimitHeaderName refers to the name of the header that opts in to queue a start over the workflow type
+	// concurrency limit to the async workflow queue, in which case the start returns no run ID
+	QueueStartOverConcurrencyLimitHeaderName = "cadence-queue-start-over-concurrency-limit"
+
+	// CountGroupByHeaderName refers to the name of the header that contains the field to break a workflow count down by.
+	// Experimental: it will be replaced by a field of CountWorkflowExecutionsRequest, see docs/idl-follow-ups.md
+	CountGroupByHeaderName = "cadence-count-group-by"
+	// CountGroupsHeaderName refers to the name of the response header that contains the json encoded counts of each group of a workflow count.
+	// Experimental: it will be replaced by a field of CountWorkflowExecutionsResponse, see docs/idl-follow-ups.md
+	CountGroupsHeaderName = "cadence-count-groups"
 )
 
 type (
@@ -95,5 +114,11 @@ func GetClientHeaders(ctx context.Context) map[string]string {
 	if _, ok := headerExists[ClientIsolationGroupHeaderName]; !ok {
 		headers[ClientIsolationGroupHeaderName] = call.Header(ClientIsolationGroupHeaderName)
 	}
+	if _, ok := headerExists[PriorityHeaderName]; !ok {
+		headers[PriorityHeaderName] = call.Header(PriorityHeaderName)
+	}
+	if _, ok := headerExists[FairnessKeyHeaderName]; !ok {
+		headers[FairnessKeyHeaderName] = call.Header(FairnessKeyHeaderName)
+	}
 	return headers
 }
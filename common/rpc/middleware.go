@@ -248,16 +248,26 @@ func (m *ForwardPartitionConfigMiddleware) Call(ctx context.Context, request *tr
 }
 
 // ClientPartitionConfigMiddleware stores the partition config and isolation group of the request into the context
-// It reads a header from client request and uses it as the isolation group
+// It reads a header from client request and uses it as the isolation group, and the others as the task priority and fairness key
 type ClientPartitionConfigMiddleware struct{}
 
 func (m *ClientPartitionConfigMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	config := make(map[string]string)
 	zone, _ := req.Headers.Get(common.ClientIsolationGroupHeaderName)
 	if zone != "" {
-		ctx = partition.ContextWithConfig(ctx, map[string]string{
-			partition.IsolationGroupKey: zone,
-		})
+		config[partition.IsolationGroupKey] = zone
 		ctx = partition.ContextWithIsolationGroup(ctx, zone)
 	}
+	priority, _ := req.Headers.Get(common.PriorityHeaderName)
+	if priority != "" {
+		config[partition.PriorityKey] = priority
+	}
+	fairnessKey, _ := req.Headers.Get(common.FairnessKeyHeaderName)
+	if fairnessKey != "" {
+		config[partition.FairnessKey] = fairnessKey
+	}
+	if len(config) > 0 {
+		ctx = partition.ContextWithConfig(ctx, config)
+	}
 	return h.Handle(ctx, req, resw)
 }
@@ -316,6 +316,26 @@ func TestClientPartitionConfigMiddleware(t *testing.T) {
 		assert.Equal(t, "dca1", partition.IsolationGroupFromContext(h.ctx))
 	})
 
+	t.Run("it sets the priority and fairness key", func(t *testing.T) {
+		m := &ClientPartitionConfigMiddleware{}
+		h := &fakeHandler{}
+		headers := transport.NewHeaders().
+			With(common.ClientIsolationGroupHeaderName, "dca1").
+			With(common.PriorityHeaderName, "1")
+		err := m.Handle(context.Background(), &transport.Request{Headers: headers}, nil, h)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{partition.IsolationGroupKey: "dca1", partition.PriorityKey: "1"}, partition.ConfigFromContext(h.ctx))
+		assert.Equal(t, "dca1", partition.IsolationGroupFromContext(h.ctx))
+
+		headers = transport.NewHeaders().
+			With(common.PriorityHeaderName, "2").
+			With(common.FairnessKeyHeaderName, "tenant-1")
+		err = m.Handle(context.Background(), &transport.Request{Headers: headers}, nil, h)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{partition.PriorityKey: "2", partition.FairnessKey: "tenant-1"}, partition.ConfigFromContext(h.ctx))
+		assert.Equal(t, "", partition.IsolationGroupFromContext(h.ctx))
+	})
+
 	t.Run("noop when header is empty", func(t *testing.T) {
 		m := &ClientPartitionConfigMiddleware{}
 		h := &fakeHandler{}
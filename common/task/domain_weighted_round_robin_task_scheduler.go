@@ -0,0 +1,447 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package task
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/quotas"
+)
+
+type (
+	domainWeightedRoundRobinTaskSchedulerImpl struct {
+		sync.Mutex
+
+		status       int32
+		weights      atomic.Value // store the currently used priority weights
+		domainQueues map[string]*domainTaskQueue
+		spaceCond    *sync.Cond // signaled when tasks are removed from the domain queues
+		shutdownCh   chan struct{}
+		notifyCh     chan struct{}
+		dispatcherWG sync.WaitGroup
+		timeSource   clock.TimeSource
+		rateLimiters *quotas.Collection
+		logger       log.Logger
+		metricsScope metrics.Scope
+		options      *DomainWeightedRoundRobinTaskSchedulerOptions
+
+		processor Processor
+	}
+
+	// domainTaskQueue holds the pending tasks of one domain, grouped by task priority
+	domainTaskQueue struct {
+		tasks map[int][]domainQueuedTask
+		size  int
+		// new tasks of the domain are rejected until backoffUntil
+		// after the queue of the domain has been found full
+		backoffUntil time.Time
+	}
+
+	domainQueuedTask struct {
+		task        PriorityTask
+		enqueueTime time.Time
+	}
+)
+
+const (
+	defaultDomainWeight = 1
+
+	// how long a dispatcher waits before checking the domain queues again
+	// when all pending tasks are throttled by the per domain rate limit
+	domainThrottleRetryInterval = 10 * time.Millisecond
+)
+
+// NewDomainWeightedRoundRobinTaskScheduler creates a new task scheduler which keeps a separate
+// queue for each domain, so that a noisy domain can't delay the tasks of other domains.
+// Tasks are dispatched in a weighted round robin fashion, the weight of a queue is
+// the weight of the domain multiplied by the weight of the task priority.
+func NewDomainWeightedRoundRobinTaskScheduler(
+	logger log.Logger,
+	metricsClient metrics.Client,
+	options *DomainWeightedRoundRobinTaskSchedulerOptions,
+) (Scheduler, error) {
+	weights, err := common.ConvertDynamicConfigMapPropertyToIntMap(options.Weights())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(weights) == 0 {
+		return nil, errors.New("weight is not specified in the scheduler option")
+	}
+
+	if options.DomainFn == nil {
+		return nil, errors.New("domain function is not specified in the scheduler option")
+	}
+
+	scheduler := &domainWeightedRoundRobinTaskSchedulerImpl{
+		status:       common.DaemonStatusInitialized,
+		domainQueues: make(map[string]*domainTaskQueue),
+		shutdownCh:   make(chan struct{}),
+		notifyCh:     make(chan struct{}, 1),
+		timeSource:   clock.NewRealTimeSource(),
+		logger:       logger,
+		metricsScope: metricsClient.Scope(metrics.TaskSchedulerScope),
+		options:      options,
+		processor: NewParallelTaskProcessor(
+			logger,
+			metricsClient,
+			&ParallelTaskProcessorOptions{
+				QueueSize:   wRRTaskProcessorQueueSize,
+				WorkerCount: options.WorkerCount,
+				RetryPolicy: options.RetryPolicy,
+			},
+		),
+	}
+	scheduler.spaceCond = sync.NewCond(&scheduler.Mutex)
+	if options.DomainRPS != nil {
+		scheduler.rateLimiters = quotas.NewCollection(quotas.NewSimpleDynamicRateLimiterFactory(options.DomainRPS))
+	}
+	scheduler.weights.Store(weights)
+
+	return scheduler, nil
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) Start() {
+	if !atomic.CompareAndSwapInt32(&w.status, common.DaemonStatusInitialized, common.DaemonStatusStarted) {
+		return
+	}
+
+	w.processor.Start()
+
+	w.dispatcherWG.Add(w.options.DispatcherCount)
+	for i := 0; i != w.options.DispatcherCount; i++ {
+		go w.dispatcher()
+	}
+	go w.updateWeightsAndEmitMetrics()
+
+	w.logger.Info("Domain weighted round robin task scheduler started.")
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) Stop() {
+	if !atomic.CompareAndSwapInt32(&w.status, common.DaemonStatusStarted, common.DaemonStatusStopped) {
+		return
+	}
+
+	close(w.shutdownCh)
+
+	w.processor.Stop()
+
+	w.Lock()
+	for _, queue := range w.domainQueues {
+		for _, tasks := range queue.tasks {
+			for _, queuedTask := range tasks {
+				queuedTask.task.Nack()
+			}
+		}
+	}
+	w.domainQueues = make(map[string]*domainTaskQueue)
+	// wake up blocked submitters so that they can find out the scheduler is stopped
+	w.spaceCond.Broadcast()
+	w.Unlock()
+
+	if success := common.AwaitWaitGroup(&w.dispatcherWG, time.Minute); !success {
+		w.logger.Warn("Domain weighted round robin task scheduler timedout on shutdown.")
+	}
+
+	w.logger.Info("Domain weighted round robin task scheduler shutdown.")
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) Submit(task PriorityTask) error {
+	w.metricsScope.IncCounter(metrics.PriorityTaskSubmitRequest)
+	sw := w.metricsScope.StartTimer(metrics.PriorityTaskSubmitLatency)
+	defer sw.Stop()
+
+	if w.isStopped() {
+		return ErrTaskSchedulerClosed
+	}
+
+	priority := task.Priority()
+	if err := w.validatePriority(priority); err != nil {
+		return err
+	}
+	domain := w.options.DomainFn(task)
+
+	w.Lock()
+	for {
+		if w.isStopped() {
+			w.Unlock()
+			return ErrTaskSchedulerClosed
+		}
+		// blocking submit doesn't respect the noisy domain backoff,
+		// it only waits until there's space in the queue of the domain
+		if w.getOrCreateDomainQueueLocked(domain).enqueue(task, priority, w.timeSource.Now(), w.options.QueueSize) {
+			break
+		}
+		w.spaceCond.Wait()
+	}
+	w.Unlock()
+
+	w.notifyDispatcher()
+	return nil
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) TrySubmit(
+	task PriorityTask,
+) (bool, error) {
+	if w.isStopped() {
+		return false, ErrTaskSchedulerClosed
+	}
+
+	priority := task.Priority()
+	if err := w.validatePriority(priority); err != nil {
+		return false, err
+	}
+	domain := w.options.DomainFn(task)
+
+	w.Lock()
+	if w.isStopped() {
+		w.Unlock()
+		return false, ErrTaskSchedulerClosed
+	}
+	now := w.timeSource.Now()
+	queue := w.getOrCreateDomainQueueLocked(domain)
+	if now.Before(queue.backoffUntil) {
+		w.Unlock()
+		return false, nil
+	}
+	if !queue.enqueue(task, priority, now, w.options.QueueSize) {
+		if w.options.NoisyDomainBackoff != nil {
+			queue.backoffUntil = now.Add(w.options.NoisyDomainBackoff())
+		}
+		w.Unlock()
+		w.metricsScope.Tagged(metrics.DomainTag(domain)).IncCounter(metrics.PriorityTaskDomainBackoff)
+		return false, nil
+	}
+	w.Unlock()
+
+	w.metricsScope.IncCounter(metrics.PriorityTaskSubmitRequest)
+	w.notifyDispatcher()
+	return true, nil
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) dispatcher() {
+	defer w.dispatcherWG.Done()
+
+	outstandingTasks := false
+	throttled := false
+
+	for {
+		if !outstandingTasks {
+			// if no task is dispatched in the last round,
+			// wait for a notification, or retry later if some tasks were throttled
+			var retryCh <-chan time.Time
+			if throttled {
+				retryCh = time.After(domainThrottleRetryInterval)
+			}
+			select {
+			case <-w.notifyCh:
+				// block until there's a new task
+			case <-retryCh:
+				// check if throttled tasks can be dispatched now
+			case <-w.shutdownCh:
+				return
+			}
+		}
+
+		var tasks []PriorityTask
+		tasks, throttled = w.nextRound()
+		outstandingTasks = len(tasks) != 0
+		for _, task := range tasks {
+			if err := w.processor.Submit(task); err != nil {
+				w.logger.Error("fail to submit task to processor", tag.Error(err))
+				task.Nack()
+			}
+		}
+	}
+}
+
+// nextRound removes the tasks to be dispatched in the next round from the domain queues,
+// it also returns whether any of the domains has been throttled by its rate limit
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) nextRound() ([]PriorityTask, bool) {
+	weights := w.getWeights()
+	now := w.timeSource.Now()
+
+	w.Lock()
+	defer w.Unlock()
+
+	var tasks []PriorityTask
+	throttled := false
+	for domain, queue := range w.domainQueues {
+		if queue.size == 0 {
+			continue
+		}
+
+		domainWeight := defaultDomainWeight
+		if w.options.DomainWeight != nil {
+			domainWeight = common.MaxInt(w.options.DomainWeight(domain), 1)
+		}
+		var limiter quotas.Limiter
+		if w.rateLimiters != nil && w.options.DomainRPS(domain) > 0 {
+			limiter = w.rateLimiters.For(domain)
+		}
+		scope := w.metricsScope.Tagged(metrics.DomainTag(domain))
+
+	Priority_Loop:
+		for _, priority := range queue.priorities() {
+			count, ok := weights[priority]
+			if !ok {
+				w.logger.Error("weights not found for task priority", tag.Dynamic("priority", priority), tag.Dynamic("weights", weights))
+				continue
+			}
+			for i := 0; i < count*domainWeight && len(queue.tasks[priority]) != 0; i++ {
+				if limiter != nil && !limiter.Allow() {
+					throttled = true
+					scope.IncCounter(metrics.PriorityTaskDomainThrottled)
+					break Priority_Loop
+				}
+				queuedTask := queue.dequeue(priority)
+				scope.RecordTimer(metrics.PriorityTaskDomainQueueLatency, now.Sub(queuedTask.enqueueTime))
+				tasks = append(tasks, queuedTask.task)
+			}
+		}
+	}
+
+	if len(tasks) != 0 {
+		w.spaceCond.Broadcast()
+	}
+	return tasks, throttled
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) getOrCreateDomainQueueLocked(domain string) *domainTaskQueue {
+	queue, ok := w.domainQueues[domain]
+	if !ok {
+		queue = &domainTaskQueue{
+			tasks: make(map[int][]domainQueuedTask),
+		}
+		w.domainQueues[domain] = queue
+	}
+	return queue
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) validatePriority(priority int) error {
+	if _, ok := w.getWeights()[priority]; !ok {
+		return fmt.Errorf("unknown task priority: %v", priority)
+	}
+	return nil
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) notifyDispatcher() {
+	select {
+	case w.notifyCh <- struct{}{}:
+		// sent a notification to the dispatcher
+	default:
+		// do not block if there's already a notification
+	}
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) getWeights() map[int]int {
+	return w.weights.Load().(map[int]int)
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) updateWeightsAndEmitMetrics() {
+	ticker := time.NewTicker(defaultUpdateWeightsInterval)
+	for {
+		select {
+		case <-ticker.C:
+			weights, err := common.ConvertDynamicConfigMapPropertyToIntMap(w.options.Weights())
+			if err != nil {
+				w.logger.Error("failed to update weight for domain round robin task scheduler", tag.Error(err))
+			} else {
+				w.weights.Store(weights)
+			}
+			w.emitQueueSizeAndCleanup()
+		case <-w.shutdownCh:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// emitQueueSizeAndCleanup emits the queue size of each domain and
+// removes the queues which are empty and not backing off any more
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) emitQueueSizeAndCleanup() {
+	now := w.timeSource.Now()
+	queueSizes := make(map[string]int)
+
+	w.Lock()
+	for domain, queue := range w.domainQueues {
+		queueSizes[domain] = queue.size
+		if queue.size == 0 && !now.Before(queue.backoffUntil) {
+			delete(w.domainQueues, domain)
+		}
+	}
+	w.Unlock()
+
+	for domain, size := range queueSizes {
+		w.metricsScope.Tagged(metrics.DomainTag(domain)).UpdateGauge(metrics.PriorityTaskDomainQueueSize, float64(size))
+	}
+}
+
+func (w *domainWeightedRoundRobinTaskSchedulerImpl) isStopped() bool {
+	return atomic.LoadInt32(&w.status) == common.DaemonStatusStopped
+}
+
+func (q *domainTaskQueue) enqueue(task PriorityTask, priority int, now time.Time, maxSize int) bool {
+	if q.size >= maxSize {
+		return false
+	}
+	q.tasks[priority] = append(q.tasks[priority], domainQueuedTask{
+		task:        task,
+		enqueueTime: now,
+	})
+	q.size++
+	return true
+}
+
+func (q *domainTaskQueue) dequeue(priority int) domainQueuedTask {
+	tasks := q.tasks[priority]
+	queuedTask := tasks[0]
+	if len(tasks) == 1 {
+		delete(q.tasks, priority)
+	} else {
+		tasks[0] = domainQueuedTask{}
+		q.tasks[priority] = tasks[1:]
+	}
+	q.size--
+	return queuedTask
+}
+
+// priorities returns the priorities which have pending tasks, from high to low
+func (q *domainTaskQueue) priorities() []int {
+	priorities := make([]int, 0, len(q.tasks))
+	for priority := range q.tasks {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	return priorities
+}
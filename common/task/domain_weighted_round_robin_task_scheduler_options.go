@@ -0,0 +1,52 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package task
+
+import (
+	"fmt"
+
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/dynamicconfig"
+)
+
+// DomainWeightedRoundRobinTaskSchedulerOptions configs the domain WRR task scheduler
+type DomainWeightedRoundRobinTaskSchedulerOptions struct {
+	Weights         dynamicconfig.MapPropertyFn
+	QueueSize       int // max number of pending tasks for each domain
+	WorkerCount     dynamicconfig.IntPropertyFn
+	DispatcherCount int
+	RetryPolicy     backoff.RetryPolicy
+
+	// DomainFn returns the name of the domain a task belongs to
+	DomainFn func(PriorityTask) string
+	// DomainWeight is multiplied with the priority weights for tasks of the domain, defaults to 1
+	DomainWeight dynamicconfig.IntPropertyFnWithDomainFilter
+	// DomainRPS limits the dispatch rate of the domain, 0 means no limit
+	DomainRPS dynamicconfig.IntPropertyFnWithDomainFilter
+	// NoisyDomainBackoff is how long TrySubmit rejects tasks of a domain after its queue is full
+	NoisyDomainBackoff dynamicconfig.DurationPropertyFn
+}
+
+func (o *DomainWeightedRoundRobinTaskSchedulerOptions) String() string {
+	return fmt.Sprintf("{QueueSize: %v, WorkerCount: %v, DispatcherCount: %v, Weights: %v}", o.QueueSize, o.WorkerCount(), o.DispatcherCount, o.Weights())
+}
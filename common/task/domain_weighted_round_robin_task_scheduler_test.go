@@ -0,0 +1,358 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package task
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/metrics"
+)
+
+type (
+	domainWeightedRoundRobinTaskSchedulerSuite struct {
+		*require.Assertions
+		suite.Suite
+
+		controller    *gomock.Controller
+		mockProcessor *MockProcessor
+		timeSource    clock.MockedTimeSource
+
+		queueSize   int
+		taskDomains sync.Map
+
+		scheduler *domainWeightedRoundRobinTaskSchedulerImpl
+	}
+)
+
+const (
+	testDomainA = "domainA"
+	testDomainB = "domainB"
+)
+
+func TestDomainWeightedRoundRobinTaskSchedulerSuite(t *testing.T) {
+	s := new(domainWeightedRoundRobinTaskSchedulerSuite)
+	suite.Run(t, s)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.controller = gomock.NewController(s.T())
+	s.mockProcessor = NewMockProcessor(s.controller)
+	s.timeSource = clock.NewMockedTimeSource()
+	s.taskDomains = sync.Map{}
+
+	s.queueSize = 10
+	s.scheduler = s.newTestDomainWeightedRoundRobinTaskScheduler(s.queueSize, nil, nil)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TearDownTest() {
+	s.controller.Finish()
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestSubmit_Success() {
+	mockTask := s.newMockTask(testDomainA, 1)
+
+	err := s.scheduler.Submit(mockTask)
+	s.NoError(err)
+
+	queue := s.scheduler.domainQueues[testDomainA]
+	s.Equal(1, queue.size)
+	s.Len(queue.tasks[1], 1)
+	s.Equal(mockTask, queue.tasks[1][0].task)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestSubmit_Fail_SchedulerShutDown() {
+	mockTask := NewMockPriorityTask(s.controller)
+	s.scheduler.Start()
+	s.scheduler.Stop()
+	err := s.scheduler.Submit(mockTask)
+	s.Equal(ErrTaskSchedulerClosed, err)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestSubmit_Fail_UnknownPriority() {
+	mockTask := NewMockPriorityTask(s.controller)
+	mockTask.EXPECT().Priority().Return(5) // make sure the number is not in testSchedulerWeights
+	err := s.scheduler.Submit(mockTask)
+	s.Error(err)
+	s.NotEqual(ErrTaskSchedulerClosed, err)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestSubmit_BlockUntilQueueHasSpace() {
+	for i := 0; i != s.queueSize; i++ {
+		s.NoError(s.scheduler.Submit(s.newMockTask(testDomainA, 0)))
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		s.NoError(s.scheduler.Submit(s.newMockTask(testDomainA, 0)))
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		s.Fail("submit should be blocked when the queue of the domain is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the queue of other domains is not affected
+	s.NoError(s.scheduler.Submit(s.newMockTask(testDomainB, 0)))
+
+	tasks, throttled := s.scheduler.nextRound()
+	s.False(throttled)
+	s.Len(tasks, 4)
+	<-doneCh
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestTrySubmit_NoisyDomainBackoff() {
+	for i := 0; i != s.queueSize; i++ {
+		submitted, err := s.scheduler.TrySubmit(s.newMockTask(testDomainA, 0))
+		s.NoError(err)
+		s.True(submitted)
+	}
+
+	// the queue of the domain is full, the domain starts to backoff
+	submitted, err := s.scheduler.TrySubmit(s.newMockTask(testDomainA, 0))
+	s.NoError(err)
+	s.False(submitted)
+
+	submitted, err = s.scheduler.TrySubmit(s.newMockTask(testDomainB, 0))
+	s.NoError(err)
+	s.True(submitted)
+
+	tasks, _ := s.scheduler.nextRound()
+	s.Len(tasks, 4)
+
+	// tasks of the domain are rejected during backoff even if its queue is no longer full
+	submitted, err = s.scheduler.TrySubmit(s.newMockTask(testDomainA, 0))
+	s.NoError(err)
+	s.False(submitted)
+
+	s.timeSource.Advance(time.Second)
+	submitted, err = s.scheduler.TrySubmit(s.newMockTask(testDomainA, 0))
+	s.NoError(err)
+	s.True(submitted)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestNextRound_DomainWeight() {
+	s.scheduler = s.newTestDomainWeightedRoundRobinTaskScheduler(
+		100,
+		func(domain string) int {
+			if domain == testDomainA {
+				return 2
+			}
+			return 1
+		},
+		nil,
+	)
+
+	for _, domain := range []string{testDomainA, testDomainB} {
+		for priority := 0; priority != 3; priority++ {
+			for i := 0; i != 10; i++ {
+				s.NoError(s.scheduler.Submit(s.newMockTask(domain, priority)))
+			}
+		}
+	}
+
+	tasks, throttled := s.scheduler.nextRound()
+	s.False(throttled)
+	s.Len(tasks, 12+6)
+	s.Equal(30-12, s.scheduler.domainQueues[testDomainA].size)
+	s.Equal(30-6, s.scheduler.domainQueues[testDomainB].size)
+	for priority, weight := range map[int]int{0: 3, 1: 2, 2: 1} {
+		s.Len(s.scheduler.domainQueues[testDomainA].tasks[priority], 10-2*weight)
+		s.Len(s.scheduler.domainQueues[testDomainB].tasks[priority], 10-weight)
+	}
+
+	// tasks are dispatched in priority order within the domain
+	for i := 1; i < len(tasks); i++ {
+		if s.taskDomain(tasks[i]) == s.taskDomain(tasks[i-1]) {
+			s.LessOrEqual(tasks[i-1].Priority(), tasks[i].Priority())
+		}
+	}
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestNextRound_DomainRPS() {
+	s.scheduler = s.newTestDomainWeightedRoundRobinTaskScheduler(
+		s.queueSize,
+		nil,
+		func(domain string) int {
+			if domain == testDomainA {
+				return 1
+			}
+			return 0
+		},
+	)
+
+	for _, domain := range []string{testDomainA, testDomainB} {
+		for i := 0; i != 3; i++ {
+			s.NoError(s.scheduler.Submit(s.newMockTask(domain, 0)))
+		}
+	}
+
+	tasks, throttled := s.scheduler.nextRound()
+	s.True(throttled)
+	s.Len(tasks, 1+3)
+	s.Equal(2, s.scheduler.domainQueues[testDomainA].size)
+	s.Equal(0, s.scheduler.domainQueues[testDomainB].size)
+
+	tasks, throttled = s.scheduler.nextRound()
+	s.True(throttled)
+	s.Empty(tasks)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestDispatcher_FailToSubmit() {
+	mockTask := s.newMockTask(testDomainA, 0)
+	mockTask.EXPECT().Nack()
+	s.NoError(s.scheduler.Submit(mockTask))
+
+	var taskWG sync.WaitGroup
+	taskWG.Add(1)
+	mockFn := func(_ Task) error {
+		taskWG.Done()
+		return errors.New("some random error")
+	}
+	s.mockProcessor.EXPECT().Submit(newMockPriorityTaskMatcher(mockTask)).DoAndReturn(mockFn)
+	s.scheduler.processor = s.mockProcessor
+
+	doneCh := make(chan struct{})
+	s.scheduler.dispatcherWG.Add(1)
+	go func() {
+		s.scheduler.dispatcher()
+		close(doneCh)
+	}()
+	s.scheduler.notifyDispatcher()
+
+	taskWG.Wait()
+	close(s.scheduler.shutdownCh)
+
+	<-doneCh
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestEmitQueueSizeAndCleanup() {
+	s.NoError(s.scheduler.Submit(s.newMockTask(testDomainA, 0)))
+	s.NoError(s.scheduler.Submit(s.newMockTask(testDomainB, 0)))
+	tasks, _ := s.scheduler.nextRound()
+	s.Len(tasks, 2)
+	s.NoError(s.scheduler.Submit(s.newMockTask(testDomainB, 0)))
+
+	s.scheduler.emitQueueSizeAndCleanup()
+	s.NotContains(s.scheduler.domainQueues, testDomainA)
+	s.Contains(s.scheduler.domainQueues, testDomainB)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestDomainWRR() {
+	numTasks := 1000
+	var taskWG sync.WaitGroup
+
+	s.mockProcessor.EXPECT().Start()
+	s.mockProcessor.EXPECT().Stop()
+
+	s.scheduler = s.newTestDomainWeightedRoundRobinTaskScheduler(numTasks, nil, nil)
+	tasks := []PriorityTask{}
+	mockFn := func(_ Task) error {
+		taskWG.Done()
+		return nil
+	}
+	for i := 0; i != numTasks; i++ {
+		mockTask := s.newMockTask([]string{testDomainA, testDomainB}[rand.Intn(2)], rand.Intn(len(testSchedulerWeights())))
+		tasks = append(tasks, mockTask)
+		taskWG.Add(1)
+		s.mockProcessor.EXPECT().Submit(newMockPriorityTaskMatcher(mockTask)).DoAndReturn(mockFn)
+	}
+
+	s.scheduler.processor = s.mockProcessor
+	s.scheduler.Start()
+	for _, task := range tasks {
+		if rand.Intn(2) == 0 {
+			s.NoError(s.scheduler.Submit(task))
+		} else {
+			submitted, err := s.scheduler.TrySubmit(task)
+			s.NoError(err)
+			s.True(submitted)
+		}
+	}
+	taskWG.Wait()
+	s.scheduler.Stop()
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) TestSchedulerContract() {
+	s.scheduler = s.newTestDomainWeightedRoundRobinTaskScheduler(1000, nil, nil)
+	testSchedulerContract(s.Assertions, s.controller, s.scheduler)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) newMockTask(
+	domain string,
+	priority int,
+) *MockPriorityTask {
+	mockTask := NewMockPriorityTask(s.controller)
+	mockTask.EXPECT().Priority().Return(priority).AnyTimes()
+	s.taskDomains.Store(mockTask, domain)
+	return mockTask
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) taskDomain(task PriorityTask) string {
+	domain, ok := s.taskDomains.Load(task)
+	if !ok {
+		return testDomainA
+	}
+	return domain.(string)
+}
+
+func (s *domainWeightedRoundRobinTaskSchedulerSuite) newTestDomainWeightedRoundRobinTaskScheduler(
+	queueSize int,
+	domainWeight dynamicconfig.IntPropertyFnWithDomainFilter,
+	domainRPS dynamicconfig.IntPropertyFnWithDomainFilter,
+) *domainWeightedRoundRobinTaskSchedulerImpl {
+	scheduler, err := NewDomainWeightedRoundRobinTaskScheduler(
+		testlogger.New(s.Suite.T()),
+		metrics.NewClient(tally.NoopScope, metrics.Common),
+		&DomainWeightedRoundRobinTaskSchedulerOptions{
+			Weights:            testSchedulerWeights,
+			QueueSize:          queueSize,
+			WorkerCount:        dynamicconfig.GetIntPropertyFn(1),
+			DispatcherCount:    3,
+			RetryPolicy:        backoff.NewExponentialRetryPolicy(time.Millisecond),
+			DomainFn:           s.taskDomain,
+			DomainWeight:       domainWeight,
+			DomainRPS:          domainRPS,
+			NoisyDomainBackoff: dynamicconfig.GetDurationPropertyFn(time.Second),
+		},
+	)
+	s.NoError(err)
+	scheduler.(*domainWeightedRoundRobinTaskSchedulerImpl).timeSource = s.timeSource
+	return scheduler.(*domainWeightedRoundRobinTaskSchedulerImpl)
+}
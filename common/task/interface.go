@@ -96,6 +96,8 @@ const (
 	SchedulerTypeFIFO SchedulerType = iota + 1
 	// SchedulerTypeWRR is the scheduler type for weighted round robin scheduler implementation
 	SchedulerTypeWRR
+	// SchedulerTypeDomainWRR is the scheduler type for per domain weighted round robin scheduler implementation
+	SchedulerTypeDomainWRR
 )
 
 const (
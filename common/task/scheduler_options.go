@@ -28,9 +28,10 @@ import (
 )
 
 type SchedulerOptions struct {
-	SchedulerType        SchedulerType
-	FIFOSchedulerOptions *FIFOTaskSchedulerOptions
-	WRRSchedulerOptions  *WeightedRoundRobinTaskSchedulerOptions
+	SchedulerType             SchedulerType
+	FIFOSchedulerOptions      *FIFOTaskSchedulerOptions
+	WRRSchedulerOptions       *WeightedRoundRobinTaskSchedulerOptions
+	DomainWRRSchedulerOptions *DomainWeightedRoundRobinTaskSchedulerOptions
 }
 
 func NewSchedulerOptions(
@@ -59,6 +60,15 @@ func NewSchedulerOptions(
 			DispatcherCount: dispatcherCount,
 			RetryPolicy:     common.CreateTaskProcessingRetryPolicy(),
 		}
+	case SchedulerTypeDomainWRR:
+		// domain related options need to be set by the caller
+		options.DomainWRRSchedulerOptions = &DomainWeightedRoundRobinTaskSchedulerOptions{
+			Weights:         weights,
+			QueueSize:       queueSize,
+			WorkerCount:     workerCount,
+			DispatcherCount: dispatcherCount,
+			RetryPolicy:     common.CreateTaskProcessingRetryPolicy(),
+		}
 	default:
 		return nil, fmt.Errorf("unknown task scheduler type: %v", schedulerType)
 	}
@@ -66,6 +76,6 @@ func NewSchedulerOptions(
 }
 
 func (o *SchedulerOptions) String() string {
-	return fmt.Sprintf("{schedulerType:%v, fifoSchedulerOptions:%s, wrrSchedulerOptions:%s}",
-		o.SchedulerType, o.FIFOSchedulerOptions, o.WRRSchedulerOptions)
+	return fmt.Sprintf("{schedulerType:%v, fifoSchedulerOptions:%s, wrrSchedulerOptions:%s, domainWRRSchedulerOptions:%s}",
+		o.SchedulerType, o.FIFOSchedulerOptions, o.WRRSchedulerOptions, o.DomainWRRSchedulerOptions)
 }
@@ -43,7 +43,7 @@ func TestSchedulerOptionsString(t *testing.T) {
 			queueSize:       1,
 			workerCount:     dynamicconfig.GetIntPropertyFn(3),
 			dispatcherCount: 1,
-			want:            "{schedulerType:1, fifoSchedulerOptions:{QueueSize: 1, WorkerCount: 3, DispatcherCount: 1}, wrrSchedulerOptions:<nil>}",
+			want:            "{schedulerType:1, fifoSchedulerOptions:{QueueSize: 1, WorkerCount: 3, DispatcherCount: 1}, wrrSchedulerOptions:<nil>, domainWRRSchedulerOptions:<nil>}",
 		},
 		{
 			desc:            "WRR",
@@ -55,11 +55,22 @@ func TestSchedulerOptionsString(t *testing.T) {
 				"1": 500,
 				"9": 20,
 			}),
-			want: "{schedulerType:2, fifoSchedulerOptions:<nil>, wrrSchedulerOptions:{QueueSize: 3, WorkerCount: 4, DispatcherCount: 5, Weights: map[1:500 9:20]}}",
+			want: "{schedulerType:2, fifoSchedulerOptions:<nil>, wrrSchedulerOptions:{QueueSize: 3, WorkerCount: 4, DispatcherCount: 5, Weights: map[1:500 9:20]}, domainWRRSchedulerOptions:<nil>}",
+		},
+		{
+			desc:            "DomainWRR",
+			schedulerType:   int(SchedulerTypeDomainWRR),
+			queueSize:       3,
+			workerCount:     dynamicconfig.GetIntPropertyFn(4),
+			dispatcherCount: 5,
+			weights: dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+				"1": 500,
+			}),
+			want: "{schedulerType:3, fifoSchedulerOptions:<nil>, wrrSchedulerOptions:<nil>, domainWRRSchedulerOptions:{QueueSize: 3, WorkerCount: 4, DispatcherCount: 5, Weights: map[1:500]}}",
 		},
 		{
 			desc:          "InvalidSchedulerType",
-			schedulerType: 3,
+			schedulerType: 4,
 			wantErr:       true,
 		},
 	}
@@ -21,6 +21,7 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -79,6 +80,10 @@ const (
 	replicationServiceBusyMaxInterval        = 10 * time.Second
 	replicationServiceBusyExpirationInterval = 5 * time.Minute
 
+	blobstoreClientOperationInitialInterval    = 100 * time.Millisecond
+	blobstoreClientOperationMaxInterval        = 10 * time.Second
+	blobstoreClientOperationExpirationInterval = 30 * time.Second
+
 	contextExpireThreshold = 10 * time.Millisecond
 
 	// FailureReasonCompleteResultExceedsLimit is failureReason for complete result exceeds limit
@@ -201,6 +206,15 @@ func CreateReplicationServiceBusyRetryPolicy() backoff.RetryPolicy {
 	return policy
 }
 
+// CreateBlobstoreClientRetryPolicy creates a retry policy for calls to blobstore
+func CreateBlobstoreClientRetryPolicy() backoff.RetryPolicy {
+	policy := backoff.NewExponentialRetryPolicy(blobstoreClientOperationInitialInterval)
+	policy.SetMaximumInterval(blobstoreClientOperationMaxInterval)
+	policy.SetExpirationInterval(blobstoreClientOperationExpirationInterval)
+
+	return policy
+}
+
 // IsValidIDLength checks if id is valid according to its length
 func IsValidIDLength(
 	id string,
@@ -870,6 +884,78 @@ func DeserializeSearchAttributeValue(value []byte, valueType types.IndexedValueT
 	}
 }
 
+// ConvertSearchAttributeValue converts a serialized search attribute value to the given value type,
+// each value of a list is converted on its own
+func ConvertSearchAttributeValue(value []byte, valueType types.IndexedValueType) ([]byte, error) {
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if list, ok := decoded.([]interface{}); ok {
+		for i, item := range list {
+			if list[i], err = convertSearchAttributeScalar(item, valueType); err != nil {
+				return nil, err
+			}
+		}
+	} else if decoded, err = convertSearchAttributeScalar(decoded, valueType); err != nil {
+		return nil, err
+	}
+
+	converted, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DeserializeSearchAttributeValue(converted, valueType); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+func convertSearchAttributeScalar(value interface{}, valueType types.IndexedValueType) (interface{}, error) {
+	switch value.(type) {
+	case string, json.Number, bool:
+	default:
+		return nil, fmt.Errorf("cannot convert %v to %v", value, valueType)
+	}
+	text := fmt.Sprint(value)
+
+	switch valueType {
+	case types.IndexedValueTypeString, types.IndexedValueTypeKeyword:
+		return text, nil
+	case types.IndexedValueTypeInt:
+		val, err := strconv.ParseInt(text, 10, 64)
+		if err == nil {
+			return val, nil
+		}
+		// numbers are truncated to integers
+		if number, ok := value.(json.Number); ok {
+			if floatVal, floatErr := number.Float64(); floatErr == nil {
+				return int64(floatVal), nil
+			}
+		}
+		return nil, err
+	case types.IndexedValueTypeDouble:
+		return strconv.ParseFloat(text, 64)
+	case types.IndexedValueTypeBool:
+		return strconv.ParseBool(text)
+	case types.IndexedValueTypeDatetime:
+		// integers are unix nanoseconds
+		if nanos, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return time.Unix(0, nanos).UTC().Format(time.RFC3339Nano), nil
+		}
+		if _, err := time.Parse(time.RFC3339Nano, text); err != nil {
+			return nil, err
+		}
+		return text, nil
+	default:
+		return nil, fmt.Errorf("error: unknown index value type [%v]", valueType)
+	}
+}
+
 // IsAdvancedVisibilityWritingEnabled returns true if we should write to advanced visibility
 func IsAdvancedVisibilityWritingEnabled(advancedVisibilityWritingMode string, isAdvancedVisConfigExist bool) bool {
 	return advancedVisibilityWritingMode != AdvancedVisibilityWritingModeOff && isAdvancedVisConfigExist
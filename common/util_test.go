@@ -569,6 +569,12 @@ func TestCreateXXXRetryPolicyWithSetExpirationInterval(t *testing.T) {
 			wantMaximumInterval:       replicationServiceBusyMaxInterval,
 			wantSetExpirationInterval: replicationServiceBusyExpirationInterval,
 		},
+		"CreateBlobstoreClientRetryPolicy": {
+			createFn:                  CreateBlobstoreClientRetryPolicy,
+			wantInitialInterval:       blobstoreClientOperationInitialInterval,
+			wantMaximumInterval:       blobstoreClientOperationMaxInterval,
+			wantSetExpirationInterval: blobstoreClientOperationExpirationInterval,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			want := backoff.NewExponentialRetryPolicy(c.wantInitialInterval)
@@ -1535,3 +1541,69 @@ func TestSecondsToDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertSearchAttributeValue(t *testing.T) {
+	for name, c := range map[string]struct {
+		value     string
+		valueType types.IndexedValueType
+
+		wantValue    string
+		wantErrorMsg string
+	}{
+		"int to keyword": {
+			value:     `1`,
+			valueType: types.IndexedValueTypeKeyword,
+			wantValue: `"1"`,
+		},
+		"string to int": {
+			value:     `"12"`,
+			valueType: types.IndexedValueTypeInt,
+			wantValue: `12`,
+		},
+		"double to int": {
+			value:     `1.7`,
+			valueType: types.IndexedValueTypeInt,
+			wantValue: `1`,
+		},
+		"list of strings to double": {
+			value:     `["1.5","2"]`,
+			valueType: types.IndexedValueTypeDouble,
+			wantValue: `[1.5,2]`,
+		},
+		"string to bool": {
+			value:     `"true"`,
+			valueType: types.IndexedValueTypeBool,
+			wantValue: `true`,
+		},
+		"int to datetime": {
+			value:     `1546300800000000000`,
+			valueType: types.IndexedValueTypeDatetime,
+			wantValue: `"2019-01-01T00:00:00Z"`,
+		},
+		"invalid int": {
+			value:        `"a"`,
+			valueType:    types.IndexedValueTypeInt,
+			wantErrorMsg: `strconv.ParseInt: parsing "a": invalid syntax`,
+		},
+		"object": {
+			value:        `{"a":1}`,
+			valueType:    types.IndexedValueTypeKeyword,
+			wantErrorMsg: "cannot convert",
+		},
+		"invalid json": {
+			value:        `"a`,
+			valueType:    types.IndexedValueTypeKeyword,
+			wantErrorMsg: "unexpected EOF",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			gotValue, err := ConvertSearchAttributeValue([]byte(c.value), c.valueType)
+			if c.wantErrorMsg != "" {
+				require.ErrorContains(t, err, c.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.wantValue, string(gotValue))
+		})
+	}
+}
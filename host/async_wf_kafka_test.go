@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build !race && asyncwfintegration
+// +build !race,asyncwfintegration
+
+/*
+To run locally:
+
+1. Stop the previous run if any
+
+	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml down
+
+2. Build the integration-test-async-wf image
+
+	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml build integration-test-async-wf
+
+3. Run the test in the docker container
+
+	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml run --rm integration-test-async-wf
+
+4. Full test run logs can be found at test.log file
+*/
+package host
+
+import (
+	"testing"
+
+	_ "github.com/uber/cadence/common/asyncworkflow/queue/kafka" // needed to load kafka asyncworkflow queue
+)
+
+func TestAsyncWFIntegrationSuite(t *testing.T) {
+	runAsyncWFIntegrationSuite(t, "testdata/integration_async_wf_with_kafka_cluster.yaml")
+}
@@ -18,26 +18,6 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-//go:build !race && asyncwfintegration
-// +build !race,asyncwfintegration
-
-/*
-To run locally:
-
-1. Stop the previous run if any
-
-	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml down
-
-2. Build the integration-test-async-wf image
-
-	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml build integration-test-async-wf
-
-3. Run the test in the docker container
-
-	docker-compose -f docker/buildkite/docker-compose-local-async-wf.yml run --rm integration-test-async-wf
-
-4. Full test run logs can be found at test.log file
-*/
 package host
 
 import (
@@ -57,13 +37,16 @@ import (
 	pt "github.com/uber/cadence/common/persistence/persistence-tests"
 	"github.com/uber/cadence/common/types"
 
-	_ "github.com/uber/cadence/common/asyncworkflow/queue/kafka" // needed to load kafka asyncworkflow queue
+	_ "github.com/uber/cadence/common/asyncworkflow/queue/persistence" // needed to load persistence asyncworkflow queue
 )
 
-func TestAsyncWFIntegrationSuite(t *testing.T) {
+func TestAsyncWFPersistenceQueueIntegrationSuite(t *testing.T) {
+	runAsyncWFIntegrationSuite(t, "testdata/integration_async_wf_with_persistence_queue_cluster.yaml")
+}
+
+func runAsyncWFIntegrationSuite(t *testing.T, confPath string) {
 	flag.Parse()
 
-	confPath := "testdata/integration_async_wf_with_kafka_cluster.yaml"
 	clusterConfig, err := GetTestClusterConfig(confPath)
 	if err != nil {
 		t.Fatalf("failed creating cluster config from %s, err: %v", confPath, err)
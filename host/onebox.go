@@ -44,6 +44,7 @@ import (
 	carchiver "github.com/uber/cadence/common/archiver"
 	"github.com/uber/cadence/common/archiver/provider"
 	"github.com/uber/cadence/common/asyncworkflow/queue"
+	asyncqueueprovider "github.com/uber/cadence/common/asyncworkflow/queue/provider"
 	"github.com/uber/cadence/common/authorization"
 	"github.com/uber/cadence/common/cache"
 	cc "github.com/uber/cadence/common/client"
@@ -121,6 +122,7 @@ type (
 		pinotConfig                   *config.PinotVisibilityConfig
 		pinotClient                   pinot.GenericClient
 		asyncWFQueues                 map[string]config.AsyncWorkflowQueueProvider
+		asyncWFQueueManager           asyncqueueprovider.QueueManagerFn
 		timeSource                    clock.TimeSource
 
 		// dynamicconfig overrides per service
@@ -162,6 +164,7 @@ type (
 		PinotConfig                   *config.PinotVisibilityConfig
 		PinotClient                   pinot.GenericClient
 		AsyncWFQueues                 map[string]config.AsyncWorkflowQueueProvider
+		AsyncWFQueueManager           asyncqueueprovider.QueueManagerFn
 		TimeSource                    clock.TimeSource
 
 		FrontendDynCfgOverrides map[dynamicconfig.Key]interface{}
@@ -196,6 +199,7 @@ func NewCadence(params *CadenceParams) Cadence {
 		pinotConfig:                   params.PinotConfig,
 		pinotClient:                   params.PinotClient,
 		asyncWFQueues:                 params.AsyncWFQueues,
+		asyncWFQueueManager:           params.AsyncWFQueueManager,
 		timeSource:                    params.TimeSource,
 		frontendDynCfgOverrides:       params.FrontendDynCfgOverrides,
 		historyDynCfgOverrides:        params.HistoryDynCfgOverrides,
@@ -708,7 +712,9 @@ func (c *cadenceImpl) startWorker(hosts map[string][]membership.HostInfo, startW
 			service.GetMetricsClient(),
 			asyncWFDomainCache,
 			queueProvider,
+			c.asyncWFQueueManager,
 			c.frontendClient,
+			service.GetMembershipResolver(),
 			asyncworkflow.WithTimeSource(params.TimeSource),
 			asyncworkflow.WithRefreshInterval(time.Second),
 		)
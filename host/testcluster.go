@@ -54,6 +54,7 @@ import (
 	"github.com/uber/cadence/common/persistence/sql"
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin/mysql"
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin/postgres"
+	"github.com/uber/cadence/common/persistence/sql/sqlplugin/sqlite"
 	pnt "github.com/uber/cadence/common/pinot"
 	"github.com/uber/cadence/testflags"
 
@@ -173,6 +174,7 @@ func NewCluster(t *testing.T, options *TestClusterConfig, logger log.Logger, par
 		DomainReplicationTaskExecutor: domain.NewReplicationTaskExecutor(testBase.DomainManager, clock.NewRealTimeSource(), logger),
 		AuthorizationConfig:           aConfig,
 		AsyncWFQueues:                 options.AsyncWFQueues,
+		AsyncWFQueueManager:           testBase.ExecutionMgrFactory.NewAsyncWorkflowQueueManager,
 		TimeSource:                    options.TimeSource,
 		FrontendDynCfgOverrides:       options.FrontendDynamicConfigOverrides,
 		HistoryDynCfgOverrides:        options.HistoryDynamicConfigOverrides,
@@ -308,6 +310,10 @@ func NewPersistenceTestCluster(t *testing.T, clusterConfig *TestClusterConfig) t
 		} else if TestFlags.SQLPluginName == postgres.PluginName {
 			testflags.RequirePostgres(t)
 			ops = postgres.GetTestClusterOption()
+		} else if TestFlags.SQLPluginName == sqlite.PluginName {
+			// sqlite doesn't need any external database, the database file is created under the temp dir
+			ops = sqlite.GetTestClusterOption()
+			clusterConfig.Persistence.DBName = ops.DBName
 		} else {
 			panic("not supported plugin " + TestFlags.SQLPluginName)
 		}
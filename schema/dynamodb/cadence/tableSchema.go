@@ -0,0 +1,336 @@
+// Copyright (c) 2021 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cadence
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/uber/cadence/common/checksum"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+// below are the names of all DynamoDB tables, the actual table name is prefixed by the keyspace
+const (
+	ClusterConfigTableName      = "cluster_config"
+	DomainTableName             = "domain"
+	DomainByNameTableName       = "domain_by_name"
+	DomainMetadataTableName     = "domain_metadata"
+	ShardTableName              = "shard"
+	CurrentWorkflowTableName    = "current_workflow"
+	WorkflowExecutionTableName  = "workflow_execution"
+	TransferTaskTableName       = "transfer_task"
+	CrossClusterTaskTableName   = "cross_cluster_task"
+	ReplicationTaskTableName    = "replication_task"
+	ReplicationDLQTaskTableName = "replication_dlq_task"
+	TimerTaskTableName          = "timer_task"
+	HistoryTreeTableName        = "history_tree"
+	HistoryNodeTableName        = "history_node"
+	BlobTableName               = "blob"
+	QueueMessageTableName       = "queue_message"
+	QueueMetadataTableName      = "queue_metadata"
+	TaskListTableName           = "task_list"
+	TaskTableName               = "task"
+	VisibilityTableName         = "visibility"
+)
+
+// below are the names of the global secondary indexes of visibility table
+const (
+	VisibilityStartTimeIndexName = "starttime_index"
+	VisibilityCloseTimeIndexName = "closetime_index"
+)
+
+// TableSchema is the element of schema json files. It's a CreateTable request, and optionally with the name of
+// the TTL attribute if TTL should be enabled on the table
+type TableSchema struct {
+	dynamodb.CreateTableInput
+	TimeToLiveAttributeName *string
+}
+
+// NOTE1: DynamoDB only defines the key attributes of a table. We use Go lang structs to define the rest of item attributes.
+
+// NOTE2: Composite keys are concatenated with "|". For range keys that need to be sorted by numbers,
+// the numbers are formatted into fixed length strings, see the comments of each field.
+
+// ClusterConfigItem is the schema of configStore
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ClusterConfigItem struct {
+	RowType              int    `dynamodbav:"rowtype"` // hash key
+	Version              int64  `dynamodbav:"version"` // range key
+	Data                 []byte `dynamodbav:"data"`
+	DataEncoding         string `dynamodbav:"dataencoding"`
+	UnixTimestampSeconds int64  `dynamodbav:"unixtimestampseconds"`
+}
+
+// DomainItem is the schema of domain table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type DomainItem struct {
+	ID                          string                               `dynamodbav:"id"` // hash key
+	Name                        string                               `dynamodbav:"name"`
+	Info                        *persistence.DomainInfo              `dynamodbav:"info"`
+	Config                      *DomainConfig                        `dynamodbav:"config"`
+	ReplicationConfig           *persistence.DomainReplicationConfig `dynamodbav:"replicationconfig"`
+	IsGlobalDomain              bool                                 `dynamodbav:"isglobaldomain"`
+	ConfigVersion               int64                                `dynamodbav:"configversion"`
+	FailoverVersion             int64                                `dynamodbav:"failoverversion"`
+	FailoverNotificationVersion int64                                `dynamodbav:"failovernotificationversion"`
+	PreviousFailoverVersion     int64                                `dynamodbav:"previousfailoverversion"`
+	FailoverEndTime             *int64                               `dynamodbav:"failoverendtime"` // unix nano
+	LastUpdatedTime             int64                                `dynamodbav:"lastupdatedtime"` // unix nano
+	NotificationVersion         int64                                `dynamodbav:"notificationversion"`
+}
+
+// DomainConfig is the embedded domain configuration of DomainItem
+type DomainConfig struct {
+	RetentionDays                int32                `dynamodbav:"retentiondays"`
+	EmitMetric                   bool                 `dynamodbav:"emitmetric"`
+	ArchivalBucket               string               `dynamodbav:"archivalbucket"`
+	ArchivalStatus               types.ArchivalStatus `dynamodbav:"archivalstatus"`
+	HistoryArchivalStatus        types.ArchivalStatus `dynamodbav:"historyarchivalstatus"`
+	HistoryArchivalURI           string               `dynamodbav:"historyarchivaluri"`
+	VisibilityArchivalStatus     types.ArchivalStatus `dynamodbav:"visibilityarchivalstatus"`
+	VisibilityArchivalURI        string               `dynamodbav:"visibilityarchivaluri"`
+	BadBinaries                  []byte               `dynamodbav:"badbinaries"`
+	BadBinariesEncoding          string               `dynamodbav:"badbinariesencoding"`
+	IsolationGroups              []byte               `dynamodbav:"isolationgroups"`
+	IsolationGroupsEncoding      string               `dynamodbav:"isolationgroupsencoding"`
+	AsyncWorkflowsConfig         []byte               `dynamodbav:"asyncworkflowsconfig"`
+	AsyncWorkflowsConfigEncoding string               `dynamodbav:"asyncworkflowsconfigencoding"`
+}
+
+// DomainByNameItem is the schema of domain_by_name table. It's to look up domainID by name, and guarantee the uniqueness of domain name
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type DomainByNameItem struct {
+	Name string `dynamodbav:"name"` // hash key
+	ID   string `dynamodbav:"id"`
+}
+
+// DomainMetadataItem is the schema of domain metadata table. There is only one item in the table.
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type DomainMetadataItem struct {
+	ID                  string `dynamodbav:"id"` // hash key
+	NotificationVersion int64  `dynamodbav:"notificationversion"`
+}
+
+// ShardItem is the schema of shard table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ShardItem struct {
+	ShardID int                            `dynamodbav:"shardid"` // hash key
+	RangeID int64                          `dynamodbav:"rangeid"`
+	Shard   *persistence.InternalShardInfo `dynamodbav:"shard"`
+}
+
+// CurrentWorkflowItem is the schema of current workflow table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type CurrentWorkflowItem struct {
+	ShardID          int    `dynamodbav:"shardid"`     // hash key
+	WorkflowKey      string `dynamodbav:"workflowkey"` // range key: domainID|workflowID
+	DomainID         string `dynamodbav:"domainid"`
+	WorkflowID       string `dynamodbav:"workflowid"`
+	RunID            string `dynamodbav:"runid"`
+	State            int    `dynamodbav:"state"`
+	CloseStatus      int    `dynamodbav:"closestatus"`
+	CreateRequestID  string `dynamodbav:"createrequestid"`
+	LastWriteVersion int64  `dynamodbav:"lastwriteversion"`
+}
+
+// WorkflowExecutionItem is the schema of workflow execution table
+// The keys of the maps are int64 IDs in string format, except for TimerInfos which is keyed by timerID
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type WorkflowExecutionItem struct {
+	ShardID                  int                                                `dynamodbav:"shardid"`      // hash key
+	ExecutionKey             string                                             `dynamodbav:"executionkey"` // range key: domainID|workflowID|runID
+	DomainID                 string                                             `dynamodbav:"domainid"`
+	WorkflowID               string                                             `dynamodbav:"workflowid"`
+	RunID                    string                                             `dynamodbav:"runid"`
+	NextEventID              int64                                              `dynamodbav:"nexteventid"`
+	LastWriteVersion         int64                                              `dynamodbav:"lastwriteversion"`
+	Execution                *persistence.InternalWorkflowExecutionInfo         `dynamodbav:"execution"`
+	VersionHistories         []byte                                             `dynamodbav:"versionhistories"`
+	VersionHistoriesEncoding string                                             `dynamodbav:"versionhistoriesencoding"`
+	Checksum                 checksum.Checksum                                  `dynamodbav:"checksum"`
+	ActivityInfos            map[string]*persistence.InternalActivityInfo       `dynamodbav:"activityinfos"`
+	TimerInfos               map[string]*persistence.TimerInfo                  `dynamodbav:"timerinfos"`
+	ChildExecutionInfos      map[string]*persistence.InternalChildExecutionInfo `dynamodbav:"childexecutioninfos"`
+	RequestCancelInfos       map[string]*persistence.RequestCancelInfo          `dynamodbav:"requestcancelinfos"`
+	SignalInfos              map[string]*persistence.SignalInfo                 `dynamodbav:"signalinfos"`
+	SignalRequestedIDs       map[string]bool                                    `dynamodbav:"signalrequestedids"`
+	BufferedEvents           []*persistence.DataBlob                            `dynamodbav:"bufferedevents"`
+}
+
+// TransferTaskItem is the schema of transfer task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TransferTaskItem struct {
+	ShardID int                           `dynamodbav:"shardid"` // hash key
+	TaskID  int64                         `dynamodbav:"taskid"`  // range key
+	Task    *persistence.TransferTaskInfo `dynamodbav:"task"`
+}
+
+// CrossClusterTaskItem is the schema of cross cluster task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type CrossClusterTaskItem struct {
+	ShardKey      string                        `dynamodbav:"shardkey"` // hash key: shardID|targetCluster
+	TaskID        int64                         `dynamodbav:"taskid"`   // range key
+	ShardID       int                           `dynamodbav:"shardid"`
+	TargetCluster string                        `dynamodbav:"targetcluster"`
+	Task          *persistence.TransferTaskInfo `dynamodbav:"task"`
+}
+
+// ReplicationTaskItem is the schema of replication task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ReplicationTaskItem struct {
+	ShardID int                                      `dynamodbav:"shardid"` // hash key
+	TaskID  int64                                    `dynamodbav:"taskid"`  // range key
+	Task    *persistence.InternalReplicationTaskInfo `dynamodbav:"task"`
+}
+
+// ReplicationDLQTaskItem is the schema of replication DLQ task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ReplicationDLQTaskItem struct {
+	ShardKey      string                                   `dynamodbav:"shardkey"` // hash key: shardID|sourceCluster
+	TaskID        int64                                    `dynamodbav:"taskid"`   // range key
+	ShardID       int                                      `dynamodbav:"shardid"`
+	SourceCluster string                                   `dynamodbav:"sourcecluster"`
+	Task          *persistence.InternalReplicationTaskInfo `dynamodbav:"task"`
+}
+
+// TimerTaskItem is the schema of timer task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TimerTaskItem struct {
+	ShardID             int                        `dynamodbav:"shardid"`  // hash key
+	TimerKey            string                     `dynamodbav:"timerkey"` // range key: zero padded visibilityTimestamp(unix nano)|zero padded taskID
+	VisibilityTimestamp time.Time                  `dynamodbav:"visibilitytimestamp"`
+	TaskID              int64                      `dynamodbav:"taskid"`
+	Task                *persistence.TimerTaskInfo `dynamodbav:"task"`
+}
+
+// HistoryTreeItem is the schema of history tree table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type HistoryTreeItem struct {
+	TreeID          string                `dynamodbav:"treeid"`   // hash key
+	BranchID        string                `dynamodbav:"branchid"` // range key
+	ShardID         int                   `dynamodbav:"shardid"`
+	Ancestors       []*HistoryBranchRange `dynamodbav:"ancestors"`
+	CreateTimestamp time.Time             `dynamodbav:"createtimestamp"`
+	Info            string                `dynamodbav:"info"`
+}
+
+// HistoryBranchRange is the embedded ancestor of HistoryTreeItem
+type HistoryBranchRange struct {
+	BranchID  string `dynamodbav:"branchid"`
+	EndNodeID int64  `dynamodbav:"endnodeid"`
+}
+
+// HistoryNodeItem is the schema of history node table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type HistoryNodeItem struct {
+	BranchKey    string `dynamodbav:"branchkey"` // hash key: treeID|branchID
+	NodeKey      string `dynamodbav:"nodekey"`   // range key: zero padded nodeID|zero padded (MaxInt64 - txnID), so that it's sorted by nodeID ASC, txnID DESC
+	ShardID      int    `dynamodbav:"shardid"`
+	TreeID       string `dynamodbav:"treeid"`
+	BranchID     string `dynamodbav:"branchid"`
+	NodeID       int64  `dynamodbav:"nodeid"`
+	TxnID        int64  `dynamodbav:"txnid"`
+	Data         []byte `dynamodbav:"data"`
+	DataEncoding string `dynamodbav:"dataencoding"`
+}
+
+// BlobItem is the schema of blob table. It stores the binary attributes that are too large to be kept in the items of
+// other tables, which refer to them by the owner key and the blob key prefix. A blob larger than the item size limit is
+// split into chunks, whose keys are suffixed by the zero padded chunk index
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type BlobItem struct {
+	OwnerKey string `dynamodbav:"ownerkey"` // hash key: table|key of the owner item
+	BlobKey  string `dynamodbav:"blobkey"`  // range key: blob key prefix|zero padded chunk index
+	Data     []byte `dynamodbav:"data"`
+}
+
+// QueueMessageItem is the schema of queue message table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type QueueMessageItem struct {
+	QueueType persistence.QueueType `dynamodbav:"queuetype"` // hash key
+	MessageID int64                 `dynamodbav:"messageid"` // range key
+	Payload   []byte                `dynamodbav:"payload"`
+}
+
+// QueueMetadataItem is the schema of queue metadata table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type QueueMetadataItem struct {
+	QueueType        persistence.QueueType `dynamodbav:"queuetype"` // hash key
+	ClusterAckLevels map[string]int64      `dynamodbav:"clusteracklevels"`
+	Version          int64                 `dynamodbav:"version"`
+}
+
+// TaskListItem is the schema of task list table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TaskListItem struct {
+	TaskListKey     string    `dynamodbav:"tasklistkey"` // hash key: domainID|taskListType|taskListName
+	DomainID        string    `dynamodbav:"domainid"`
+	TaskListName    string    `dynamodbav:"tasklistname"`
+	TaskListType    int       `dynamodbav:"tasklisttype"`
+	RangeID         int64     `dynamodbav:"rangeid"`
+	TaskListKind    int       `dynamodbav:"tasklistkind"`
+	AckLevel        int64     `dynamodbav:"acklevel"`
+	LastUpdatedTime time.Time `dynamodbav:"lastupdatedtime"`
+	ExpireTime      int64     `dynamodbav:"expiretime,omitempty"` // unix seconds, for TTL
+}
+
+// TaskItem is the schema of task table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TaskItem struct {
+	TaskListKey     string            `dynamodbav:"tasklistkey"` // hash key: domainID|taskListType|taskListName
+	TaskID          int64             `dynamodbav:"taskid"`      // range key
+	DomainID        string            `dynamodbav:"domainid"`
+	TaskListName    string            `dynamodbav:"tasklistname"`
+	TaskListType    int               `dynamodbav:"tasklisttype"`
+	WorkflowID      string            `dynamodbav:"workflowid"`
+	RunID           string            `dynamodbav:"runid"`
+	ScheduledID     int64             `dynamodbav:"scheduledid"`
+	CreatedTime     time.Time         `dynamodbav:"createdtime"`
+	PartitionConfig map[string]string `dynamodbav:"partitionconfig"`
+	ExpireTime      int64             `dynamodbav:"expiretime,omitempty"` // unix seconds, for TTL
+}
+
+// VisibilityItem is the schema of visibility table
+// IMPORTANT: making change to this struct is changing the DynamoDB table schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type VisibilityItem struct {
+	DomainID      string                              `dynamodbav:"domainid"`           // hash key
+	RunID         string                              `dynamodbav:"runid"`              // range key
+	StartKey      string                              `dynamodbav:"startkey"`           // range key of starttime_index: zero padded startTime(unix nano)|runID
+	CloseKey      string                              `dynamodbav:"closekey,omitempty"` // range key of closetime_index: zero padded closeTime(unix nano)|runID, empty for open records
+	WorkflowID    string                              `dynamodbav:"workflowid"`
+	WorkflowType  string                              `dynamodbav:"workflowtype"`
+	StartTime     time.Time                           `dynamodbav:"starttime"`
+	ExecutionTime time.Time                           `dynamodbav:"executiontime"`
+	CloseTime     *time.Time                          `dynamodbav:"closetime,omitempty"`
+	CloseStatus   *types.WorkflowExecutionCloseStatus `dynamodbav:"closestatus,omitempty"` // absent for open records
+	HistoryLength int64                               `dynamodbav:"historylength"`
+	Memo          []byte                              `dynamodbav:"memo"`
+	MemoEncoding  string                              `dynamodbav:"memoencoding"`
+	TaskList      string                              `dynamodbav:"tasklist"`
+	IsCron        bool                                `dynamodbav:"iscron"`
+	NumClusters   int16                               `dynamodbav:"numclusters"`
+	UpdateTime    time.Time                           `dynamodbav:"updatetime"`
+	ShardID       int16                               `dynamodbav:"shardid"`
+	ExpireTime    int64                               `dynamodbav:"expiretime,omitempty"` // unix seconds, for TTL
+}
@@ -20,9 +20,34 @@
 
 package cadence
 
+import (
+	"time"
+
+	"github.com/uber/cadence/common/checksum"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
 // below are the names of all mongoDB collections
 const (
-	ClusterConfigCollectionName = "cluster_config"
+	ClusterConfigCollectionName      = "cluster_config"
+	DomainCollectionName             = "domain"
+	DomainMetadataCollectionName     = "domain_metadata"
+	ShardCollectionName              = "shard"
+	CurrentWorkflowCollectionName    = "current_workflow"
+	WorkflowExecutionCollectionName  = "workflow_execution"
+	TransferTaskCollectionName       = "transfer_task"
+	CrossClusterTaskCollectionName   = "cross_cluster_task"
+	ReplicationTaskCollectionName    = "replication_task"
+	ReplicationDLQTaskCollectionName = "replication_dlq_task"
+	TimerTaskCollectionName          = "timer_task"
+	HistoryTreeCollectionName        = "history_tree"
+	HistoryNodeCollectionName        = "history_node"
+	QueueMessageCollectionName       = "queue_message"
+	QueueMetadataCollectionName      = "queue_metadata"
+	TaskListCollectionName           = "task_list"
+	TaskCollectionName               = "task"
+	VisibilityCollectionName         = "visibility"
 )
 
 // NOTE1: MongoDB collection is schemaless -- there is no schema file for collection. We use Go lang structs to define the collection fields.
@@ -39,3 +64,225 @@ type ClusterConfigCollectionEntry struct {
 	DataEncoding         string `json:"dataencoding"`
 	UnixTimestampSeconds int64  `json:"unixtimestampseconds"`
 }
+
+// DomainCollectionEntry is the schema of domain collection, the document ID is the domainID
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type DomainCollectionEntry struct {
+	ID                          string                               `bson:"_id"`
+	Name                        string                               `bson:"name"`
+	Info                        *persistence.DomainInfo              `bson:"info"`
+	Config                      *DomainConfig                        `bson:"config"`
+	ReplicationConfig           *persistence.DomainReplicationConfig `bson:"replicationconfig"`
+	IsGlobalDomain              bool                                 `bson:"isglobaldomain"`
+	ConfigVersion               int64                                `bson:"configversion"`
+	FailoverVersion             int64                                `bson:"failoverversion"`
+	FailoverNotificationVersion int64                                `bson:"failovernotificationversion"`
+	PreviousFailoverVersion     int64                                `bson:"previousfailoverversion"`
+	FailoverEndTime             *int64                               `bson:"failoverendtime"` // unix nano
+	LastUpdatedTime             int64                                `bson:"lastupdatedtime"` // unix nano
+	NotificationVersion         int64                                `bson:"notificationversion"`
+}
+
+// DomainConfig is the embedded domain configuration of DomainCollectionEntry
+type DomainConfig struct {
+	RetentionDays                int32                `bson:"retentiondays"`
+	EmitMetric                   bool                 `bson:"emitmetric"`
+	ArchivalBucket               string               `bson:"archivalbucket"`
+	ArchivalStatus               types.ArchivalStatus `bson:"archivalstatus"`
+	HistoryArchivalStatus        types.ArchivalStatus `bson:"historyarchivalstatus"`
+	HistoryArchivalURI           string               `bson:"historyarchivaluri"`
+	VisibilityArchivalStatus     types.ArchivalStatus `bson:"visibilityarchivalstatus"`
+	VisibilityArchivalURI        string               `bson:"visibilityarchivaluri"`
+	BadBinaries                  []byte               `bson:"badbinaries"`
+	BadBinariesEncoding          string               `bson:"badbinariesencoding"`
+	IsolationGroups              []byte               `bson:"isolationgroups"`
+	IsolationGroupsEncoding      string               `bson:"isolationgroupsencoding"`
+	AsyncWorkflowsConfig         []byte               `bson:"asyncworkflowsconfig"`
+	AsyncWorkflowsConfigEncoding string               `bson:"asyncworkflowsconfigencoding"`
+}
+
+// DomainMetadataCollectionEntry is the schema of domain metadata collection. There is only one document in the collection.
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type DomainMetadataCollectionEntry struct {
+	ID                  string `bson:"_id"`
+	NotificationVersion int64  `bson:"notificationversion"`
+}
+
+// ShardCollectionEntry is the schema of shard collection, the document ID is the shardID
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ShardCollectionEntry struct {
+	ID      int                            `bson:"_id"`
+	RangeID int64                          `bson:"rangeid"`
+	Shard   *persistence.InternalShardInfo `bson:"shard"`
+	// WriteCount is bumped by every conditional write on the shard, so that concurrent transactions of the same shard
+	// conflict with each other instead of being applied on a stale rangeID
+	WriteCount int64 `bson:"writecount"`
+}
+
+// CurrentWorkflowCollectionEntry is the schema of current workflow collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type CurrentWorkflowCollectionEntry struct {
+	ShardID          int    `bson:"shardid"`
+	DomainID         string `bson:"domainid"`
+	WorkflowID       string `bson:"workflowid"`
+	RunID            string `bson:"runid"`
+	State            int    `bson:"state"`
+	CloseStatus      int    `bson:"closestatus"`
+	CreateRequestID  string `bson:"createrequestid"`
+	LastWriteVersion int64  `bson:"lastwriteversion"`
+}
+
+// WorkflowExecutionCollectionEntry is the schema of workflow execution collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type WorkflowExecutionCollectionEntry struct {
+	ShardID                  int                                               `bson:"shardid"`
+	DomainID                 string                                            `bson:"domainid"`
+	WorkflowID               string                                            `bson:"workflowid"`
+	RunID                    string                                            `bson:"runid"`
+	NextEventID              int64                                             `bson:"nexteventid"`
+	LastWriteVersion         int64                                             `bson:"lastwriteversion"`
+	Execution                *persistence.InternalWorkflowExecutionInfo        `bson:"execution"`
+	VersionHistories         []byte                                            `bson:"versionhistories"`
+	VersionHistoriesEncoding string                                            `bson:"versionhistoriesencoding"`
+	Checksum                 checksum.Checksum                                 `bson:"checksum"`
+	ActivityInfos            map[int64]*persistence.InternalActivityInfo       `bson:"activityinfos"`
+	TimerInfos               map[string]*persistence.TimerInfo                 `bson:"timerinfos"` // keyed by the hex encoded timerID
+	ChildExecutionInfos      map[int64]*persistence.InternalChildExecutionInfo `bson:"childexecutioninfos"`
+	RequestCancelInfos       map[int64]*persistence.RequestCancelInfo          `bson:"requestcancelinfos"`
+	SignalInfos              map[int64]*persistence.SignalInfo                 `bson:"signalinfos"`
+	SignalRequestedIDs       []string                                          `bson:"signalrequestedids"`
+	BufferedEvents           []*persistence.DataBlob                           `bson:"bufferedevents"`
+}
+
+// TransferTaskCollectionEntry is the schema of transfer task collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TransferTaskCollectionEntry struct {
+	ShardID int                           `bson:"shardid"`
+	TaskID  int64                         `bson:"taskid"`
+	Task    *persistence.TransferTaskInfo `bson:"task"`
+}
+
+// CrossClusterTaskCollectionEntry is the schema of cross cluster task collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type CrossClusterTaskCollectionEntry struct {
+	ShardID       int                           `bson:"shardid"`
+	TargetCluster string                        `bson:"targetcluster"`
+	TaskID        int64                         `bson:"taskid"`
+	Task          *persistence.TransferTaskInfo `bson:"task"`
+}
+
+// ReplicationTaskCollectionEntry is the schema of replication task collection and replication DLQ collection.
+// SourceCluster is only used by replication DLQ
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type ReplicationTaskCollectionEntry struct {
+	ShardID       int                                      `bson:"shardid"`
+	SourceCluster string                                   `bson:"sourcecluster,omitempty"`
+	TaskID        int64                                    `bson:"taskid"`
+	Task          *persistence.InternalReplicationTaskInfo `bson:"task"`
+}
+
+// TimerTaskCollectionEntry is the schema of timer task collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TimerTaskCollectionEntry struct {
+	ShardID             int                        `bson:"shardid"`
+	VisibilityTimestamp time.Time                  `bson:"visibilitytimestamp"`
+	TaskID              int64                      `bson:"taskid"`
+	Task                *persistence.TimerTaskInfo `bson:"task"`
+}
+
+// HistoryTreeCollectionEntry is the schema of history tree collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type HistoryTreeCollectionEntry struct {
+	ShardID         int                   `bson:"shardid"`
+	TreeID          string                `bson:"treeid"`
+	BranchID        string                `bson:"branchid"`
+	Ancestors       []*HistoryBranchRange `bson:"ancestors"`
+	CreateTimestamp time.Time             `bson:"createtimestamp"`
+	Info            string                `bson:"info"`
+}
+
+// HistoryBranchRange is the embedded ancestor of HistoryTreeCollectionEntry
+type HistoryBranchRange struct {
+	BranchID  string `bson:"branchid"`
+	EndNodeID int64  `bson:"endnodeid"`
+}
+
+// HistoryNodeCollectionEntry is the schema of history node collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type HistoryNodeCollectionEntry struct {
+	ShardID      int    `bson:"shardid"`
+	TreeID       string `bson:"treeid"`
+	BranchID     string `bson:"branchid"`
+	NodeID       int64  `bson:"nodeid"`
+	TxnID        int64  `bson:"txnid"`
+	Data         []byte `bson:"data"`
+	DataEncoding string `bson:"dataencoding"`
+}
+
+// QueueMessageCollectionEntry is the schema of queue message collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type QueueMessageCollectionEntry struct {
+	QueueType persistence.QueueType `bson:"queuetype"`
+	MessageID int64                 `bson:"messageid"`
+	Payload   []byte                `bson:"payload"`
+}
+
+// QueueMetadataCollectionEntry is the schema of queue metadata collection, the document ID is the queue type
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type QueueMetadataCollectionEntry struct {
+	ID               persistence.QueueType `bson:"_id"`
+	ClusterAckLevels map[string]int64      `bson:"clusteracklevels"`
+	Version          int64                 `bson:"version"`
+}
+
+// TaskListCollectionEntry is the schema of task list collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TaskListCollectionEntry struct {
+	DomainID        string     `bson:"domainid"`
+	TaskListName    string     `bson:"tasklistname"`
+	TaskListType    int        `bson:"tasklisttype"`
+	RangeID         int64      `bson:"rangeid"`
+	TaskListKind    int        `bson:"tasklistkind"`
+	AckLevel        int64      `bson:"acklevel"`
+	LastUpdatedTime time.Time  `bson:"lastupdatedtime"`
+	ExpireTime      *time.Time `bson:"expiretime,omitempty"`
+	// WriteCount is bumped when inserting tasks, so that it conflicts with concurrent updates of the tasklist
+	WriteCount int64 `bson:"writecount"`
+}
+
+// TaskCollectionEntry is the schema of task collection
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type TaskCollectionEntry struct {
+	DomainID        string            `bson:"domainid"`
+	TaskListName    string            `bson:"tasklistname"`
+	TaskListType    int               `bson:"tasklisttype"`
+	TaskID          int64             `bson:"taskid"`
+	WorkflowID      string            `bson:"workflowid"`
+	RunID           string            `bson:"runid"`
+	ScheduledID     int64             `bson:"scheduledid"`
+	CreatedTime     time.Time         `bson:"createdtime"`
+	PartitionConfig map[string]string `bson:"partitionconfig"`
+	ExpireTime      *time.Time        `bson:"expiretime,omitempty"`
+}
+
+// VisibilityCollectionEntry is the schema of visibility collection. CloseStatus is nil for open workflows.
+// IMPORTANT: making change to this struct is changing the MongoDB collection schema. Please make sure it's backward compatible(e.g., don't delete the field, or change the annotation value).
+type VisibilityCollectionEntry struct {
+	DomainID      string                              `bson:"domainid"`
+	WorkflowID    string                              `bson:"workflowid"`
+	RunID         string                              `bson:"runid"`
+	WorkflowType  string                              `bson:"workflowtype"`
+	StartTime     time.Time                           `bson:"starttime"`
+	ExecutionTime time.Time                           `bson:"executiontime"`
+	CloseTime     *time.Time                          `bson:"closetime"`
+	CloseStatus   *types.WorkflowExecutionCloseStatus `bson:"closestatus"`
+	HistoryLength int64                               `bson:"historylength"`
+	Memo          []byte                              `bson:"memo"`
+	MemoEncoding  string                              `bson:"memoencoding"`
+	TaskList      string                              `bson:"tasklist"`
+	IsCron        bool                                `bson:"iscron"`
+	NumClusters   int16                               `bson:"numclusters"`
+	UpdateTime    time.Time                           `bson:"updatetime"`
+	ShardID       int16                               `bson:"shardid"`
+	ExpireTime    *time.Time                          `bson:"expiretime,omitempty"`
+}
@@ -192,11 +192,19 @@ func (adh *adminHandlerImpl) AddSearchAttribute(
 	if err != nil {
 		return adh.error(&types.InternalServiceError{Message: fmt.Sprintf("Failed to get dynamic config, err: %v", err)}, scope)
 	}
+	removedAttr, err := adh.params.DynamicConfig.GetMapValue(dc.RemovedSearchAttributes, nil)
+	if err != nil {
+		return adh.error(&types.InternalServiceError{Message: fmt.Sprintf("Failed to get dynamic config, err: %v", err)}, scope)
+	}
 
 	for keyName, valueType := range searchAttr {
 		if definition.IsSystemIndexedKey(keyName) {
 			return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] is reserved by system", keyName)}, scope)
 		}
+		// removed keys may still exist in the ES mapping with their old type, so they can't be added back
+		if _, removed := removedAttr[keyName]; removed {
+			return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] has been removed, use a new key instead", keyName)}, scope)
+		}
 		if currValType, exist := currentValidAttr[keyName]; exist {
 			if currValType != int(valueType) {
 				return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] is already whitelisted as a different type", keyName)}, scope)
@@ -576,6 +576,8 @@ func (s *adminHandlerSuite) Test_AddSearchAttribute_Validate() {
 	}
 	dynamicConfig.EXPECT().GetMapValue(dynamicconfig.ValidSearchAttributes, nil).
 		Return(mockValidAttr, nil).AnyTimes()
+	dynamicConfig.EXPECT().GetMapValue(dynamicconfig.RemovedSearchAttributes, nil).
+		Return(map[string]interface{}{"removedkey": "unused"}, nil).AnyTimes()
 
 	testCases2 := []test{
 		{
@@ -596,6 +598,15 @@ func (s *adminHandlerSuite) Test_AddSearchAttribute_Validate() {
 			},
 			Expected: &types.BadRequestError{Message: "Key [testkey] is already whitelisted as a different type"},
 		},
+		{
+			Name: "key removed",
+			Request: &types.AddSearchAttributeRequest{
+				SearchAttribute: map[string]types.IndexedValueType{
+					"removedkey": 1,
+				},
+			},
+			Expected: &types.BadRequestError{Message: "Key [removedkey] has been removed, use a new key instead"},
+		},
 	}
 	for _, testCase := range testCases2 {
 		s.Equal(testCase.Expected, handler.AddSearchAttribute(ctx, testCase.Request))
@@ -0,0 +1,146 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+)
+
+const (
+	// openExecutionCountTTL is how long the count of open executions loaded from visibility is used,
+	// starts made by the host in the meantime are added to the count so that bursts can't go over the limit
+	openExecutionCountTTL = 10 * time.Second
+)
+
+type (
+	// workflowConcurrencyLimiter limits the number of open executions of a workflow type in a domain.
+	// Counts are loaded from visibility so the limit is enforced on a best effort basis, the count of an
+	// execution is only removed when its close is visible. Until the count is loaded again, each frontend
+	// host only starts its share of the executions left under the limit, so that the hosts together don't
+	// go over the limit by more than the number of hosts.
+	// Only the starts through the frontend are limited, the child workflows, continue as new and cron runs
+	// started by history are counted but never rejected.
+	workflowConcurrencyLimiter struct {
+		limit    dynamicconfig.IntPropertyFnWithWorkflowTypeFilter
+		count    openExecutionCountFn
+		numHosts func() int
+		counts   cache.Cache
+	}
+
+	// openExecutionCountFn returns the number of open executions of a workflow type in a domain,
+	// it may stop counting once the limit is reached
+	openExecutionCountFn func(ctx context.Context, domain, workflowType string, limit int) (int64, error)
+
+	workflowTypeKey struct {
+		domain       string
+		workflowType string
+	}
+
+	openExecutionCount struct {
+		sync.Mutex
+		loaded  int64 // open executions in visibility
+		started int64 // executions started by the host since the count was loaded
+		pending int64 // starts allowed by the host which aren't done yet
+	}
+)
+
+func newWorkflowConcurrencyLimiter(
+	limit dynamicconfig.IntPropertyFnWithWorkflowTypeFilter,
+	count openExecutionCountFn,
+	numHosts func() int,
+) *workflowConcurrencyLimiter {
+	return &workflowConcurrencyLimiter{
+		limit:    limit,
+		count:    count,
+		numHosts: numHosts,
+		counts: cache.New(&cache.Options{
+			TTL:             openExecutionCountTTL,
+			InitialCapacity: 32,
+			MaxCount:        10000,
+		}),
+	}
+}
+
+// Allow returns true if a new execution of the workflow type can be started in the domain. If it's allowed,
+// the returned function must be called once the start is done, with whether the execution was started, so
+// that only started executions are counted.
+func (l *workflowConcurrencyLimiter) Allow(ctx context.Context, domain, workflowType string) (bool, func(started bool), error) {
+	limit := l.limit(domain, workflowType)
+	if limit <= 0 {
+		return true, func(bool) {}, nil
+	}
+
+	count, err := l.getCount(ctx, domain, workflowType, limit)
+	if err != nil {
+		return false, nil, err
+	}
+	count.Lock()
+	defer count.Unlock()
+	if count.started+count.pending >= l.hostShare(int64(limit)-count.loaded) {
+		return false, nil, nil
+	}
+	count.pending++
+	return true, func(started bool) {
+		count.Lock()
+		defer count.Unlock()
+		count.pending--
+		if started {
+			count.started++
+		}
+	}, nil
+}
+
+// hostShare returns the number of executions the host can start out of the ones left under the limit
+func (l *workflowConcurrencyLimiter) hostShare(left int64) int64 {
+	if left <= 0 {
+		return 0
+	}
+	numHosts := int64(l.numHosts())
+	if numHosts <= 1 {
+		return left
+	}
+	// round up so that every host can start executions while the limit isn't reached
+	return (left + numHosts - 1) / numHosts
+}
+
+func (l *workflowConcurrencyLimiter) getCount(ctx context.Context, domain, workflowType string, limit int) (*openExecutionCount, error) {
+	key := workflowTypeKey{domain: domain, workflowType: workflowType}
+	if count, ok := l.counts.Get(key).(*openExecutionCount); ok {
+		return count, nil
+	}
+
+	loaded, err := l.count(ctx, domain, workflowType, limit)
+	if err != nil {
+		return nil, err
+	}
+	count, err := l.counts.PutIfNotExist(key, &openExecutionCount{loaded: loaded})
+	if err != nil {
+		return nil, err
+	}
+	return count.(*openExecutionCount), nil
+}
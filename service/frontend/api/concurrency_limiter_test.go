@@ -0,0 +1,123 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowConcurrencyLimiter(t *testing.T) {
+	limits := map[string]int{"limited": 3}
+	loads := 0
+	limiter := newWorkflowConcurrencyLimiter(
+		func(domain, workflowType string) int { return limits[workflowType] },
+		func(ctx context.Context, domain, workflowType string, limit int) (int64, error) {
+			loads++
+			return 1, nil
+		},
+		func() int { return 1 },
+	)
+
+	// no limit, the count isn't loaded
+	allowed, done, err := limiter.Allow(context.Background(), "test-domain", "unlimited")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	done(true)
+	assert.Equal(t, 0, loads)
+
+	// one execution is open, a start which failed isn't counted
+	allowed, done, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	done(false)
+
+	// starts in progress and started executions are counted until the count is loaded again
+	allowed, done, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, _, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, _, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	done(true)
+	allowed, _, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 1, loads)
+
+	// counts are kept per domain
+	allowed, _, err = limiter.Allow(context.Background(), "other-domain", "limited")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, loads)
+
+	// raising the limit takes effect without reloading the count
+	limits["limited"] = 4
+	allowed, _, err = limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, loads)
+}
+
+func TestWorkflowConcurrencyLimiter_SharedAcrossHosts(t *testing.T) {
+	limiter := newWorkflowConcurrencyLimiter(
+		func(domain, workflowType string) int { return 10 },
+		func(ctx context.Context, domain, workflowType string, limit int) (int64, error) {
+			return 3, nil
+		},
+		func() int { return 3 },
+	)
+
+	// 7 executions are left under the limit, the host can start a third of them rounded up
+	for i := 0; i < 3; i++ {
+		allowed, done, err := limiter.Allow(context.Background(), "test-domain", "limited")
+		require.NoError(t, err)
+		require.True(t, allowed)
+		done(true)
+	}
+	allowed, _, err := limiter.Allow(context.Background(), "test-domain", "limited")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestWorkflowConcurrencyLimiter_CountFailed(t *testing.T) {
+	countErr := errors.New("visibility unavailable")
+	limiter := newWorkflowConcurrencyLimiter(
+		func(domain, workflowType string) int { return 1 },
+		func(ctx context.Context, domain, workflowType string, limit int) (int64, error) {
+			return 0, countErr
+		},
+		func() int { return 1 },
+	)
+
+	allowed, _, err := limiter.Allow(context.Background(), "test-domain", "limited")
+	assert.Equal(t, countErr, err)
+	assert.False(t, allowed)
+}
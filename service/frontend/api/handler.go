@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -79,6 +80,7 @@ type (
 		searchAttributesValidator *validator.SearchAttributesValidator
 		throttleRetry             *backoff.ThrottleRetry
 		producerManager           ProducerManager
+		concurrencyLimiter        *workflowConcurrencyLimiter
 	}
 
 	getHistoryContinuationToken struct {
@@ -110,7 +112,7 @@ func NewWorkflowHandler(
 	versionChecker client.VersionChecker,
 	domainHandler domain.Handler,
 ) *WorkflowHandler {
-	return &WorkflowHandler{
+	wh := &WorkflowHandler{
 		Resource:        resource,
 		config:          config,
 		healthStatus:    int32(HealthStatusWarmingUp),
@@ -125,6 +127,8 @@ func NewWorkflowHandler(
 			resource.GetLogger(),
 			config.EnableQueryAttributeValidation,
 			config.ValidSearchAttributes,
+			config.DeprecatedSearchAttributes,
+			config.RemovedSearchAttributes,
 			config.SearchAttributesNumberOfKeysLimit,
 			config.SearchAttributesSizeOfValueLimit,
 			config.SearchAttributesTotalSizeLimit,
@@ -136,10 +140,13 @@ func NewWorkflowHandler(
 		producerManager: NewProducerManager(
 			resource.GetDomainCache(),
 			resource.GetAsyncWorkflowQueueProvider(),
+			resource.GetPersistenceBean().GetAsyncWorkflowQueueManager,
 			resource.GetLogger(),
 			resource.GetMetricsClient(),
 		),
 	}
+	wh.concurrencyLimiter = newWorkflowConcurrencyLimiter(config.WorkflowTypeConcurrencyLimit, wh.countOpenWorkflowExecutions, wh.frontendHostCount)
+	return wh
 }
 
 // Start starts the handler
@@ -1755,15 +1762,24 @@ func (wh *WorkflowHandler) StartWorkflowExecutionAsync(
 	if err != nil {
 		return nil, err
 	}
+	if err := wh.publishStartWorkflowExecutionAsync(ctx, startRequest); err != nil {
+		return nil, err
+	}
+	return &types.StartWorkflowExecutionAsyncResponse{}, nil
+}
 
+func (wh *WorkflowHandler) publishStartWorkflowExecutionAsync(
+	ctx context.Context,
+	startRequest *types.StartWorkflowExecutionAsyncRequest,
+) error {
 	producer, err := wh.producerManager.GetProducerByDomain(startRequest.GetDomain())
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// serialize the message to be sent to the queue
 	payload, err := json.Marshal(startRequest)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// propagate the headers from the context to the message
 	clientHeaders := common.GetClientHeaders(ctx)
@@ -1773,6 +1789,8 @@ func (wh *WorkflowHandler) StartWorkflowExecutionAsync(
 	for k, v := range clientHeaders {
 		header.Fields[k] = []byte(v)
 	}
+	// mark the request so that it's not queued again when it's consumed
+	header.Fields[common.AsyncWorkflowRequestHeaderName] = []byte("true")
 	messageType := sqlblobs.AsyncRequestTypeStartWorkflowExecutionAsyncRequest
 	message := &sqlblobs.AsyncRequestMessage{
 		PartitionKey: common.StringPtr(startRequest.GetWorkflowID()),
@@ -1781,11 +1799,7 @@ func (wh *WorkflowHandler) StartWorkflowExecutionAsync(
 		Encoding:     common.StringPtr(string(common.EncodingTypeJSON)),
 		Payload:      payload,
 	}
-	err = producer.Publish(ctx, message)
-	if err != nil {
-		return nil, err
-	}
-	return &types.StartWorkflowExecutionAsyncResponse{}, nil
+	return producer.Publish(ctx, message)
 }
 
 // StartWorkflowExecution - Creates a new workflow execution
@@ -1807,19 +1821,140 @@ func (wh *WorkflowHandler) StartWorkflowExecution(
 		return nil, err
 	}
 	wh.GetLogger().Debug("Start workflow execution request domainID", tag.WorkflowDomainID(domainID))
+	allowed, done, err := wh.concurrencyLimiter.Allow(ctx, domainName, startRequest.WorkflowType.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return wh.handleStartOverConcurrencyLimit(ctx, startRequest, scope)
+	}
 	historyRequest, err := common.CreateHistoryStartWorkflowRequest(
 		domainID, startRequest, time.Now(), wh.getPartitionConfig(ctx, domainName))
 	if err != nil {
+		done(false)
 		return nil, err
 	}
 
 	resp, err = wh.GetHistoryClient().StartWorkflowExecution(ctx, historyRequest)
+	// only count the execution towards the concurrency limit once it's started
+	done(err == nil)
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
+// handleStartOverConcurrencyLimit rejects the start of a workflow over the concurrency limit of its type,
+// or queues it to the async workflow queue of the domain if it's enabled and the caller opted in, as the
+// run ID of a queued start is unknown.
+// Starts consumed from the queue are rejected with a retryable error so that the consumer backs off.
+func (wh *WorkflowHandler) handleStartOverConcurrencyLimit(
+	ctx context.Context,
+	startRequest *types.StartWorkflowExecutionRequest,
+	scope metrics.Scope,
+) (*types.StartWorkflowExecutionResponse, error) {
+	domainName := startRequest.GetDomain()
+	optedIn := yarpc.CallFromContext(ctx).Header(common.QueueStartOverConcurrencyLimitHeaderName) != ""
+	consumed := yarpc.CallFromContext(ctx).Header(common.AsyncWorkflowRequestHeaderName) != ""
+	if optedIn && !consumed && wh.config.QueueStartsOverConcurrencyLimit(domainName) {
+		err := wh.publishStartWorkflowExecutionAsync(ctx, &types.StartWorkflowExecutionAsyncRequest{
+			StartWorkflowExecutionRequest: startRequest,
+		})
+		if err != nil {
+			return nil, err
+		}
+		scope.IncCounter(metrics.WorkflowConcurrencyLimitQueuedCounter)
+		// the run ID is unknown until the start is consumed from the queue
+		return &types.StartWorkflowExecutionResponse{}, nil
+	}
+	return nil, wh.concurrencyLimitExceededError(ctx, domainName, startRequest.WorkflowType.GetName(), scope)
+}
+
+// concurrencyLimitExceededError returns the error of a start over the concurrency limit of its workflow type,
+// which is retryable for the starts consumed from the async workflow queue so that the consumer backs off
+func (wh *WorkflowHandler) concurrencyLimitExceededError(
+	ctx context.Context,
+	domainName string,
+	workflowType string,
+	scope metrics.Scope,
+) error {
+	scope.IncCounter(metrics.WorkflowConcurrencyLimitExceededCounter)
+	message := fmt.Sprintf("Domain %s has reached the concurrency limit of workflow type %s.", domainName, workflowType)
+	if yarpc.CallFromContext(ctx).Header(common.AsyncWorkflowRequestHeaderName) != "" {
+		return &types.ServiceBusyError{Message: message}
+	}
+	return &types.LimitExceededError{Message: message}
+}
+
+// countOpenWorkflowExecutions returns the number of open executions of a workflow type in a domain from visibility.
+// Without advanced visibility, the open executions are listed by type up to the limit since the basic visibility
+// stores can't count them efficiently.
+func (wh *WorkflowHandler) countOpenWorkflowExecutions(ctx context.Context, domain, workflowType string, limit int) (int64, error) {
+	if !common.IsAdvancedVisibilityReadingEnabled(wh.config.EnableReadVisibilityFromES(domain), wh.config.IsAdvancedVisConfigExist) {
+		return wh.listOpenWorkflowExecutionsUpTo(ctx, domain, workflowType, limit)
+	}
+	domainID, err := wh.GetDomainCache().GetDomainID(domain)
+	if err != nil {
+		return 0, err
+	}
+	query, err := wh.visibilityQueryValidator.ValidateQuery(
+		fmt.Sprintf("WorkflowType = '%s' AND CloseTime = missing", strings.ReplaceAll(workflowType, "'", "\\'")),
+	)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := wh.GetVisibilityManager().CountWorkflowExecutions(ctx, &persistence.CountWorkflowExecutionsRequest{
+		DomainUUID: domainID,
+		Domain:     domain,
+		Query:      query,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// listOpenWorkflowExecutionsUpTo returns the number of open executions of a workflow type in a domain,
+// it stops listing them once the limit is reached
+func (wh *WorkflowHandler) listOpenWorkflowExecutionsUpTo(ctx context.Context, domain, workflowType string, limit int) (int64, error) {
+	domainID, err := wh.GetDomainCache().GetDomainID(domain)
+	if err != nil {
+		return 0, err
+	}
+	request := &persistence.ListWorkflowExecutionsByTypeRequest{
+		ListWorkflowExecutionsRequest: persistence.ListWorkflowExecutionsRequest{
+			DomainUUID:   domainID,
+			Domain:       domain,
+			EarliestTime: 0,
+			LatestTime:   time.Now().UnixNano(),
+			PageSize:     common.MinInt(limit, wh.config.VisibilityMaxPageSize(domain)),
+		},
+		WorkflowTypeName: workflowType,
+	}
+	var count int64
+	for count < int64(limit) {
+		resp, err := wh.GetVisibilityManager().ListOpenWorkflowExecutionsByType(ctx, request)
+		if err != nil {
+			return 0, err
+		}
+		count += int64(len(resp.Executions))
+		if len(resp.NextPageToken) == 0 {
+			break
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+	return count, nil
+}
+
+// frontendHostCount returns the number of frontend hosts sharing the workflow type concurrency limits
+func (wh *WorkflowHandler) frontendHostCount() int {
+	count, err := wh.GetMembershipResolver().MemberCount(service.Frontend)
+	if err != nil {
+		return 1
+	}
+	return count
+}
+
 func (wh *WorkflowHandler) validateStartWorkflowExecutionRequest(ctx context.Context, startRequest *types.StartWorkflowExecutionRequest, scope metrics.Scope) error {
 	if startRequest == nil {
 		return validate.ErrRequestNotSet
@@ -2390,11 +2525,28 @@ func (wh *WorkflowHandler) SignalWithStartWorkflowExecution(
 	if err != nil {
 		return nil, err
 	}
+	allowed, done, err := wh.concurrencyLimiter.Allow(ctx, domainName, signalWithStartRequest.WorkflowType.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		// over the limit, the workflow can still be signaled if it's running
+		running, err := wh.isWorkflowRunning(ctx, domainID, signalWithStartRequest.GetWorkflowID())
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			return nil, wh.concurrencyLimitExceededError(ctx, domainName, signalWithStartRequest.WorkflowType.GetName(), scope)
+		}
+		done = func(bool) {}
+	}
 	resp, err = wh.GetHistoryClient().SignalWithStartWorkflowExecution(ctx, &types.HistorySignalWithStartWorkflowExecutionRequest{
 		DomainUUID:             domainID,
 		SignalWithStartRequest: signalWithStartRequest,
 		PartitionConfig:        wh.getPartitionConfig(ctx, domainName),
 	})
+	// the execution may only have been signaled, it's counted until the count is loaded again from visibility
+	done(err == nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2402,6 +2554,21 @@ func (wh *WorkflowHandler) SignalWithStartWorkflowExecution(
 	return resp, nil
 }
 
+// isWorkflowRunning returns true if the current execution of the workflow is running
+func (wh *WorkflowHandler) isWorkflowRunning(ctx context.Context, domainID, workflowID string) (bool, error) {
+	resp, err := wh.GetHistoryClient().GetMutableState(ctx, &types.GetMutableStateRequest{
+		DomainUUID: domainID,
+		Execution:  &types.WorkflowExecution{WorkflowID: workflowID},
+	})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return resp.GetIsWorkflowRunning(), nil
+}
+
 func (wh *WorkflowHandler) validateSignalWithStartWorkflowExecutionRequest(ctx context.Context, signalWithStartRequest *types.SignalWithStartWorkflowExecutionRequest, scope metrics.Scope) error {
 	if err := wh.versionChecker.ClientSupported(ctx, wh.config.EnableClientVersionCheck()); err != nil {
 		return err
@@ -3219,16 +3386,36 @@ func (wh *WorkflowHandler) CountWorkflowExecutions(
 		return nil, err
 	}
 
+	// the groups of a count have no field in the API yet, they are requested and returned through experimental headers
+	// which are only supported by this server and the CLI
+	groupBy := yarpc.CallFromContext(ctx).Header(common.CountGroupByHeaderName)
+	if groupBy != "" {
+		if err := persistence.ValidateCountGroupBy(groupBy, wh.config.ValidSearchAttributes(), wh.GetLogger()); err != nil {
+			return nil, err
+		}
+	}
+
 	req := &persistence.CountWorkflowExecutionsRequest{
 		DomainUUID: domainID,
 		Domain:     domain,
 		Query:      validatedQuery,
+		GroupBy:    groupBy,
 	}
 	persistenceResp, err := wh.GetVisibilityManager().CountWorkflowExecutions(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	if groupBy != "" {
+		groups, err := json.Marshal(persistenceResp.Groups)
+		if err != nil {
+			return nil, err
+		}
+		if err := yarpc.CallFromContext(ctx).WriteResponseHeader(common.CountGroupsHeaderName, string(groups)); err != nil {
+			return nil, err
+		}
+	}
+
 	resp = &types.CountWorkflowExecutionsResponse{
 		Count: persistenceResp.Count,
 	}
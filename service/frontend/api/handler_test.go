@@ -33,6 +33,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/yarpc/yarpctest"
 
 	"github.com/uber/cadence/client/history"
 	"github.com/uber/cadence/common"
@@ -1725,6 +1726,38 @@ func (s *workflowHandlerSuite) TestCountWorkflowExecutions() {
 	s.NotNil(err)
 }
 
+func (s *workflowHandlerSuite) TestCountWorkflowExecutions_GroupBy() {
+	wh := s.getWorkflowHandler(s.newConfig(dc.NewInMemoryClient()))
+
+	s.mockDomainCache.EXPECT().GetDomainID(gomock.Any()).Return(s.testDomainID, nil).AnyTimes()
+	s.mockVisibilityMgr.On("CountWorkflowExecutions", mock.Anything, mock.MatchedBy(func(request *persistence.CountWorkflowExecutionsRequest) bool {
+		return request.GroupBy == "CustomKeywordField"
+	})).Return(&persistence.CountWorkflowExecutionsResponse{
+		Count: 3,
+		Groups: []*persistence.WorkflowExecutionCountGroup{
+			{Value: "keyword1", Count: 2},
+			{Value: "keyword2", Count: 1},
+		},
+	}, nil).Once()
+
+	countRequest := &types.CountWorkflowExecutionsRequest{
+		Domain: s.testDomain,
+		Query:  "WorkflowType = 'wt'",
+	}
+	call := &yarpctest.Call{
+		Headers:         map[string]string{common.CountGroupByHeaderName: "CustomKeywordField"},
+		ResponseHeaders: map[string]string{},
+	}
+	resp, err := wh.CountWorkflowExecutions(yarpctest.ContextWithCall(context.Background(), call), countRequest)
+	s.NoError(err)
+	s.Equal(int64(3), resp.GetCount())
+	s.JSONEq(`[{"value":"keyword1","count":2},{"value":"keyword2","count":1}]`, call.ResponseHeaders[common.CountGroupsHeaderName])
+
+	call.Headers[common.CountGroupByHeaderName] = "CustomIntField"
+	_, err = wh.CountWorkflowExecutions(yarpctest.ContextWithCall(context.Background(), call), countRequest)
+	s.IsType(&types.BadRequestError{}, err)
+}
+
 func (s *workflowHandlerSuite) TestConvertIndexedKeyToThrift() {
 	wh := s.getWorkflowHandler(s.newConfig(dc.NewInMemoryClient()))
 	m := map[string]interface{}{
@@ -2141,6 +2174,238 @@ func TestStartWorkflowExecutionAsync(t *testing.T) {
 	}
 }
 
+func TestStartWorkflowExecution_ConcurrencyLimit(t *testing.T) {
+	testCases := []struct {
+		name            string
+		openCount       int64
+		basicVisibility bool
+		queue           bool
+		ctx             context.Context
+		setupMocks      func(*resource.Test, *MockProducerManager)
+		wantErr         error
+	}{
+		{
+			name:      "Under limit",
+			openCount: 1,
+			ctx:       context.Background(),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {
+				mockResource.HistoryClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.StartWorkflowExecutionResponse{RunID: testRunID}, nil)
+			},
+		},
+		{
+			name:       "Over limit - rejected",
+			openCount:  2,
+			ctx:        context.Background(),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {},
+			wantErr:    &types.LimitExceededError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+		{
+			name:            "Under limit - basic visibility",
+			openCount:       1,
+			basicVisibility: true,
+			ctx:             context.Background(),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {
+				mockResource.HistoryClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.StartWorkflowExecutionResponse{RunID: testRunID}, nil)
+			},
+		},
+		{
+			name:            "Over limit - basic visibility",
+			openCount:       2,
+			basicVisibility: true,
+			ctx:             context.Background(),
+			setupMocks:      func(mockResource *resource.Test, mockQueue *MockProducerManager) {},
+			wantErr:         &types.LimitExceededError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+		{
+			name:       "Over limit - queueing not opted in",
+			openCount:  2,
+			queue:      true,
+			ctx:        context.Background(),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {},
+			wantErr:    &types.LimitExceededError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+		{
+			name:      "Over limit - queued",
+			openCount: 2,
+			queue:     true,
+			ctx:       yarpctest.ContextWithCall(context.Background(), &yarpctest.Call{Headers: map[string]string{common.QueueStartOverConcurrencyLimitHeaderName: "true"}}),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {
+				mockProducer := &mocks.KafkaProducer{}
+				mockQueue.EXPECT().GetProducerByDomain("test-domain").Return(mockProducer, nil)
+				mockProducer.On("Publish", mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:       "Over limit - consumed from queue",
+			openCount:  2,
+			queue:      true,
+			ctx:        yarpctest.ContextWithCall(context.Background(), &yarpctest.Call{Headers: map[string]string{common.AsyncWorkflowRequestHeaderName: "true"}}),
+			setupMocks: func(mockResource *resource.Test, mockQueue *MockProducerManager) {},
+			wantErr:    &types.ServiceBusyError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockResource := resource.NewTest(t, mockCtrl, metrics.Frontend)
+			mockResource.DomainCache.EXPECT().GetDomainID("test-domain").Return("test-domain-id", nil).AnyTimes()
+			mockResource.MembershipResolver.EXPECT().MemberCount(service.Frontend).Return(1, nil).AnyTimes()
+			if tc.basicVisibility {
+				mockResource.VisibilityMgr.On("ListOpenWorkflowExecutionsByType", mock.Anything, mock.MatchedBy(func(request *persistence.ListWorkflowExecutionsByTypeRequest) bool {
+					return request.DomainUUID == "test-domain-id" && request.WorkflowTypeName == "test-workflow-type" && request.PageSize == 2
+				})).Return(&persistence.ListWorkflowExecutionsResponse{
+					Executions: make([]*types.WorkflowExecutionInfo, tc.openCount),
+				}, nil).Once()
+			} else {
+				mockResource.VisibilityMgr.On("CountWorkflowExecutions", mock.Anything, mock.MatchedBy(func(request *persistence.CountWorkflowExecutionsRequest) bool {
+					return request.Domain == "test-domain" && request.Query == "WorkflowType = 'test-workflow-type' and CloseTime = missing"
+				})).Return(&persistence.CountWorkflowExecutionsResponse{Count: tc.openCount}, nil).Once()
+			}
+			mockVersionChecker := client.NewMockVersionChecker(mockCtrl)
+			mockVersionChecker.EXPECT().ClientSupported(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockProducerManager := NewMockProducerManager(mockCtrl)
+
+			cfg := frontendcfg.NewConfig(
+				dc.NewCollection(
+					dc.NewInMemoryClient(),
+					mockResource.GetLogger(),
+				),
+				numHistoryShards,
+				!tc.basicVisibility,
+				"hostname",
+			)
+			cfg.EnableReadVisibilityFromES = dc.GetBoolPropertyFnFilteredByDomain(!tc.basicVisibility)
+			cfg.WorkflowTypeConcurrencyLimit = dc.GetIntPropertyFilteredByWorkflowType(2)
+			cfg.QueueStartsOverConcurrencyLimit = dc.GetBoolPropertyFnFilteredByDomain(tc.queue)
+			wh := NewWorkflowHandler(mockResource, cfg, mockVersionChecker, nil)
+			wh.producerManager = mockProducerManager
+
+			tc.setupMocks(mockResource, mockProducerManager)
+
+			_, err := wh.StartWorkflowExecution(tc.ctx, &types.StartWorkflowExecutionRequest{
+				Domain:     "test-domain",
+				WorkflowID: "test-workflow-id",
+				WorkflowType: &types.WorkflowType{
+					Name: "test-workflow-type",
+				},
+				TaskList: &types.TaskList{
+					Name: "test-task-list",
+				},
+				ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(60),
+				TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(10),
+				Identity:                            "test-identity",
+				RequestID:                           uuid.New(),
+			})
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestSignalWithStartWorkflowExecution_ConcurrencyLimit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		openCount  int64
+		ctx        context.Context
+		setupMocks func(*resource.Test)
+		wantErr    error
+	}{
+		{
+			name:      "Under limit",
+			openCount: 1,
+			ctx:       context.Background(),
+			setupMocks: func(mockResource *resource.Test) {
+				mockResource.HistoryClient.EXPECT().SignalWithStartWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.StartWorkflowExecutionResponse{RunID: testRunID}, nil)
+			},
+		},
+		{
+			name:      "Over limit - workflow running",
+			openCount: 2,
+			ctx:       context.Background(),
+			setupMocks: func(mockResource *resource.Test) {
+				mockResource.HistoryClient.EXPECT().GetMutableState(gomock.Any(), &types.GetMutableStateRequest{
+					DomainUUID: "test-domain-id",
+					Execution:  &types.WorkflowExecution{WorkflowID: "test-workflow-id"},
+				}).Return(&types.GetMutableStateResponse{IsWorkflowRunning: true}, nil)
+				mockResource.HistoryClient.EXPECT().SignalWithStartWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.StartWorkflowExecutionResponse{RunID: testRunID}, nil)
+			},
+		},
+		{
+			name:      "Over limit - workflow closed",
+			openCount: 2,
+			ctx:       context.Background(),
+			setupMocks: func(mockResource *resource.Test) {
+				mockResource.HistoryClient.EXPECT().GetMutableState(gomock.Any(), gomock.Any()).Return(&types.GetMutableStateResponse{IsWorkflowRunning: false}, nil)
+			},
+			wantErr: &types.LimitExceededError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+		{
+			name:      "Over limit - workflow not found",
+			openCount: 2,
+			ctx:       context.Background(),
+			setupMocks: func(mockResource *resource.Test) {
+				mockResource.HistoryClient.EXPECT().GetMutableState(gomock.Any(), gomock.Any()).Return(nil, &types.EntityNotExistsError{})
+			},
+			wantErr: &types.LimitExceededError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+		{
+			name:      "Over limit - consumed from queue",
+			openCount: 2,
+			ctx:       yarpctest.ContextWithCall(context.Background(), &yarpctest.Call{Headers: map[string]string{common.AsyncWorkflowRequestHeaderName: "true"}}),
+			setupMocks: func(mockResource *resource.Test) {
+				mockResource.HistoryClient.EXPECT().GetMutableState(gomock.Any(), gomock.Any()).Return(nil, &types.EntityNotExistsError{})
+			},
+			wantErr: &types.ServiceBusyError{Message: "Domain test-domain has reached the concurrency limit of workflow type test-workflow-type."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockResource := resource.NewTest(t, mockCtrl, metrics.Frontend)
+			mockResource.DomainCache.EXPECT().GetDomainID("test-domain").Return("test-domain-id", nil).AnyTimes()
+			mockResource.MembershipResolver.EXPECT().MemberCount(service.Frontend).Return(1, nil).AnyTimes()
+			mockResource.VisibilityMgr.On("CountWorkflowExecutions", mock.Anything, mock.MatchedBy(func(request *persistence.CountWorkflowExecutionsRequest) bool {
+				return request.DomainUUID == "test-domain-id" && request.Query == "WorkflowType = 'test-workflow-type' and CloseTime = missing"
+			})).Return(&persistence.CountWorkflowExecutionsResponse{Count: tc.openCount}, nil).Once()
+			mockVersionChecker := client.NewMockVersionChecker(mockCtrl)
+			mockVersionChecker.EXPECT().ClientSupported(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			cfg := frontendcfg.NewConfig(
+				dc.NewCollection(
+					dc.NewInMemoryClient(),
+					mockResource.GetLogger(),
+				),
+				numHistoryShards,
+				true,
+				"hostname",
+			)
+			cfg.EnableReadVisibilityFromES = dc.GetBoolPropertyFnFilteredByDomain(true)
+			cfg.WorkflowTypeConcurrencyLimit = dc.GetIntPropertyFilteredByWorkflowType(2)
+			wh := NewWorkflowHandler(mockResource, cfg, mockVersionChecker, nil)
+
+			tc.setupMocks(mockResource)
+
+			_, err := wh.SignalWithStartWorkflowExecution(tc.ctx, &types.SignalWithStartWorkflowExecutionRequest{
+				Domain:     "test-domain",
+				WorkflowID: "test-workflow-id",
+				WorkflowType: &types.WorkflowType{
+					Name: "test-workflow-type",
+				},
+				TaskList: &types.TaskList{
+					Name: "test-task-list",
+				},
+				ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(60),
+				TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(10),
+				Identity:                            "test-identity",
+				RequestID:                           uuid.New(),
+				SignalName:                          "test-signal",
+			})
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
 func TestSignalWithStartWorkflowExecutionAsync(t *testing.T) {
 	testCases := []struct {
 		name       string
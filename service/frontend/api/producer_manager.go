@@ -46,6 +46,7 @@ type (
 	producerManagerImpl struct {
 		domainCache   cache.DomainCache
 		provider      queue.Provider
+		queueManager  provider.QueueManagerFn
 		logger        log.Logger
 		metricsClient metrics.Client
 
@@ -56,12 +57,14 @@ type (
 func NewProducerManager(
 	domainCache cache.DomainCache,
 	provider queue.Provider,
+	queueManager provider.QueueManagerFn,
 	logger log.Logger,
 	metricsClient metrics.Client,
 ) ProducerManager {
 	return &producerManagerImpl{
 		domainCache:   domainCache,
 		provider:      provider,
+		queueManager:  queueManager,
 		logger:        logger,
 		metricsClient: metricsClient,
 		producerCache: cache.New(&cache.Options{
@@ -104,7 +107,7 @@ func (q *producerManagerImpl) GetProducerByDomain(
 		return val.(messaging.Producer), nil
 	}
 
-	producer, err := queue.CreateProducer(&provider.Params{Logger: q.logger, MetricsClient: q.metricsClient})
+	producer, err := queue.CreateProducer(&provider.Params{Logger: q.logger, MetricsClient: q.metricsClient, QueueManagerFn: q.queueManager})
 	if err != nil {
 		return nil, err
 	}
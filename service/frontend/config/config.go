@@ -97,6 +97,8 @@ type Config struct {
 
 	// ValidSearchAttributes is legal indexed keys that can be used in list APIs
 	ValidSearchAttributes             dynamicconfig.MapPropertyFn
+	DeprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	RemovedSearchAttributes           dynamicconfig.MapPropertyFn
 	SearchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -109,6 +111,10 @@ type Config struct {
 	// max number of decisions per RespondDecisionTaskCompleted request (unlimited by default)
 	DecisionResultCountLimit dynamicconfig.IntPropertyFnWithDomainFilter
 
+	// max number of open executions per workflow type (unlimited by default)
+	WorkflowTypeConcurrencyLimit    dynamicconfig.IntPropertyFnWithWorkflowTypeFilter
+	QueueStartsOverConcurrencyLimit dynamicconfig.BoolPropertyFnWithDomainFilter
+
 	// Debugging
 
 	// Emit signal related metrics with signal name tag. Be aware of cardinality.
@@ -170,6 +176,8 @@ func NewConfig(dc *dynamicconfig.Collection, numHistoryShards int, isAdvancedVis
 		EnableClientVersionCheck:                    dc.GetBoolProperty(dynamicconfig.EnableClientVersionCheck),
 		EnableQueryAttributeValidation:              dc.GetBoolProperty(dynamicconfig.EnableQueryAttributeValidation),
 		ValidSearchAttributes:                       dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+		DeprecatedSearchAttributes:                  dc.GetMapProperty(dynamicconfig.DeprecatedSearchAttributes),
+		RemovedSearchAttributes:                     dc.GetMapProperty(dynamicconfig.RemovedSearchAttributes),
 		SearchAttributesNumberOfKeysLimit:           dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesNumberOfKeysLimit),
 		SearchAttributesSizeOfValueLimit:            dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesSizeOfValueLimit),
 		SearchAttributesTotalSizeLimit:              dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesTotalSizeLimit),
@@ -177,6 +185,8 @@ func NewConfig(dc *dynamicconfig.Collection, numHistoryShards int, isAdvancedVis
 		DisallowQuery:                               dc.GetBoolPropertyFilteredByDomain(dynamicconfig.DisallowQuery),
 		SendRawWorkflowHistory:                      dc.GetBoolPropertyFilteredByDomain(dynamicconfig.SendRawWorkflowHistory),
 		DecisionResultCountLimit:                    dc.GetIntPropertyFilteredByDomain(dynamicconfig.FrontendDecisionResultCountLimit),
+		WorkflowTypeConcurrencyLimit:                dc.GetIntPropertyFilteredByWorkflowType(dynamicconfig.FrontendWorkflowTypeConcurrencyLimit),
+		QueueStartsOverConcurrencyLimit:             dc.GetBoolPropertyFilteredByDomain(dynamicconfig.FrontendQueueStartsOverConcurrencyLimit),
 		EmitSignalNameMetricsTag:                    dc.GetBoolPropertyFilteredByDomain(dynamicconfig.FrontendEmitSignalNameMetricsTag),
 		Lockdown:                                    dc.GetBoolPropertyFilteredByDomain(dynamicconfig.Lockdown),
 		EnableTasklistIsolation:                     dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableTasklistIsolation),
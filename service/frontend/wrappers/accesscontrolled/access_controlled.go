@@ -24,7 +24,9 @@ package accesscontrolled
 
 import (
 	"context"
+	"strings"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/authorization"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
@@ -49,6 +51,25 @@ func (a *apiHandler) isAuthorized(
 	sw := scope.StartTimer(metrics.CadenceAuthorizationLatency)
 	defer sw.Stop()
 
+	isAuth, err := a.authorize(ctx, attr, scope)
+	if err != nil || !isAuth {
+		return isAuth, err
+	}
+	// schedule workflows act in the domain encoded in their workflow IDs with the internal clients,
+	// so the caller needs the same permission in that domain
+	if scheduleDomain := getScheduleDomain(attr); scheduleDomain != "" {
+		scheduleAttr := *attr
+		scheduleAttr.DomainName = scheduleDomain
+		return a.authorize(ctx, &scheduleAttr, scope)
+	}
+	return true, nil
+}
+
+func (a *apiHandler) authorize(
+	ctx context.Context,
+	attr *authorization.Attributes,
+	scope metrics.Scope,
+) (bool, error) {
 	result, err := a.authorizer.Authorize(ctx, attr)
 	if err != nil {
 		scope.IncCounter(metrics.CadenceErrAuthorizeFailedCounter)
@@ -61,6 +82,45 @@ func (a *apiHandler) isAuthorized(
 	return isAuth, nil
 }
 
+// getScheduleDomain returns the target domain of the schedule workflow a request is about, or empty
+// if the request is not about a schedule workflow
+func getScheduleDomain(attr *authorization.Attributes) string {
+	if attr.DomainName != common.SchedulerLocalDomainName {
+		return ""
+	}
+	var workflowID string
+	switch request := attr.RequestBody.(type) {
+	case *types.StartWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowID()
+	case *types.StartWorkflowExecutionAsyncRequest:
+		workflowID = request.GetWorkflowID()
+	case *types.SignalWithStartWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowID()
+	case *types.SignalWithStartWorkflowExecutionAsyncRequest:
+		workflowID = request.GetWorkflowID()
+	case *types.SignalWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowExecution().GetWorkflowID()
+	case *types.TerminateWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowExecution().GetWorkflowID()
+	case *types.RequestCancelWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowExecution().GetWorkflowID()
+	case *types.ResetWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowExecution().GetWorkflowID()
+	case *types.RestartWorkflowExecutionRequest:
+		workflowID = request.GetWorkflowExecution().GetWorkflowID()
+	case *types.QueryWorkflowRequest:
+		workflowID = request.GetExecution().GetWorkflowID()
+	case *types.DescribeWorkflowExecutionRequest:
+		workflowID = request.GetExecution().GetWorkflowID()
+	case *types.GetWorkflowExecutionHistoryRequest:
+		workflowID = request.GetExecution().GetWorkflowID()
+	}
+	if i := strings.Index(workflowID, common.SchedulerWorkflowIDSeparator); i > 0 {
+		return workflowID[:i]
+	}
+	return ""
+}
+
 // getMetricsScopeWithDomain return metrics scope with domain tag
 func (a *apiHandler) getMetricsScopeWithDomain(
 	scope int,
@@ -30,9 +30,11 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/authorization"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/metrics/mocks"
+	"github.com/uber/cadence/common/types"
 )
 
 func TestIsAuthorized(t *testing.T) {
@@ -92,3 +94,72 @@ func TestIsAuthorized(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAuthorized_ScheduleWorkflow(t *testing.T) {
+	testCases := []struct {
+		name           string
+		request        authorization.FilteredRequestBody
+		targetDecision authorization.Decision
+		isAuthorized   bool
+	}{
+		{
+			name:           "Success case",
+			request:        &types.StartWorkflowExecutionRequest{WorkflowID: "target-domain/schedule"},
+			targetDecision: authorization.DecisionAllow,
+			isAuthorized:   true,
+		},
+		{
+			name: "Error case - unauthorized in the target domain",
+			request: &types.SignalWorkflowExecutionRequest{
+				WorkflowExecution: &types.WorkflowExecution{WorkflowID: "target-domain/schedule"},
+			},
+			targetDecision: authorization.DecisionDeny,
+			isAuthorized:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+
+			mockAuthorizer := authorization.NewMockAuthorizer(controller)
+			mockAuthorizer.EXPECT().Authorize(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, attr *authorization.Attributes) (authorization.Result, error) {
+					if attr.DomainName == common.SchedulerLocalDomainName {
+						return authorization.Result{Decision: authorization.DecisionAllow}, nil
+					}
+					assert.Equal(t, "target-domain", attr.DomainName)
+					return authorization.Result{Decision: tc.targetDecision}, nil
+				},
+			).Times(2)
+			mockMetricsScope := &mocks.Scope{}
+			mockMetricsScope.On("StartTimer", metrics.CadenceAuthorizationLatency).Return(metrics.NewTestStopwatch()).Once()
+			mockMetricsScope.On("IncCounter", metrics.CadenceErrUnauthorizedCounter).Return().Maybe()
+
+			handler := &apiHandler{authorizer: mockAuthorizer}
+			got, err := handler.isAuthorized(context.Background(), &authorization.Attributes{
+				DomainName:  common.SchedulerLocalDomainName,
+				RequestBody: tc.request,
+			}, mockMetricsScope)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.isAuthorized, got)
+		})
+	}
+}
+
+func TestGetScheduleDomain(t *testing.T) {
+	assert.Equal(t, "", getScheduleDomain(&authorization.Attributes{
+		DomainName:  "target-domain",
+		RequestBody: &types.StartWorkflowExecutionRequest{WorkflowID: "target-domain/schedule"},
+	}))
+	assert.Equal(t, "", getScheduleDomain(&authorization.Attributes{
+		DomainName:  common.SchedulerLocalDomainName,
+		RequestBody: &types.StartWorkflowExecutionRequest{WorkflowID: "schedule"},
+	}))
+	assert.Equal(t, "target-domain", getScheduleDomain(&authorization.Attributes{
+		DomainName: common.SchedulerLocalDomainName,
+		RequestBody: &types.QueryWorkflowRequest{
+			Execution: &types.WorkflowExecution{WorkflowID: "target-domain/schedule/backfill"},
+		},
+	}))
+}
@@ -96,6 +96,9 @@ type Config struct {
 	TaskSchedulerShardQueueSize             dynamicconfig.IntPropertyFn
 	TaskSchedulerDispatcherCount            dynamicconfig.IntPropertyFn
 	TaskSchedulerRoundRobinWeights          dynamicconfig.MapPropertyFn
+	TaskSchedulerDomainWeight               dynamicconfig.IntPropertyFnWithDomainFilter
+	TaskSchedulerDomainRPS                  dynamicconfig.IntPropertyFnWithDomainFilter
+	TaskSchedulerNoisyDomainBackoff         dynamicconfig.DurationPropertyFn
 	TaskCriticalRetryCount                  dynamicconfig.IntPropertyFn
 	ActiveTaskRedispatchInterval            dynamicconfig.DurationPropertyFn
 	StandbyTaskRedispatchInterval           dynamicconfig.DurationPropertyFn
@@ -247,6 +250,9 @@ type Config struct {
 	// ValidSearchAttributes is legal indexed keys that can be used in list APIs
 	EnableQueryAttributeValidation    dynamicconfig.BoolPropertyFn
 	ValidSearchAttributes             dynamicconfig.MapPropertyFn
+	DeprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	RemovedSearchAttributes           dynamicconfig.MapPropertyFn
+	MigratedSearchAttributes          dynamicconfig.MapPropertyFn
 	SearchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -286,6 +292,8 @@ type Config struct {
 	ReplicationTaskProcessorShardQPS                   dynamicconfig.FloatPropertyFn
 	ReplicationTaskGenerationQPS                       dynamicconfig.FloatPropertyFn
 	EnableReplicationTaskGeneration                    dynamicconfig.BoolPropertyFnWithDomainIDAndWorkflowIDFilter
+	WorkflowPaused                                     dynamicconfig.BoolPropertyFnWithDomainAndWorkflowIDFilter
+	EnableActivityPartitionConfig                      dynamicconfig.BoolPropertyFnWithDomainFilter
 	EnableRecordWorkflowExecutionUninitialized         dynamicconfig.BoolPropertyFnWithDomainFilter
 
 	// The following are used by the history workflowID cache
@@ -397,6 +405,9 @@ func New(dc *dynamicconfig.Collection, numberOfShards int, maxMessageSize int, s
 		TaskSchedulerShardQueueSize:             dc.GetIntProperty(dynamicconfig.TaskSchedulerShardQueueSize),
 		TaskSchedulerDispatcherCount:            dc.GetIntProperty(dynamicconfig.TaskSchedulerDispatcherCount),
 		TaskSchedulerRoundRobinWeights:          dc.GetMapProperty(dynamicconfig.TaskSchedulerRoundRobinWeights),
+		TaskSchedulerDomainWeight:               dc.GetIntPropertyFilteredByDomain(dynamicconfig.TaskSchedulerDomainWeight),
+		TaskSchedulerDomainRPS:                  dc.GetIntPropertyFilteredByDomain(dynamicconfig.TaskSchedulerDomainRPS),
+		TaskSchedulerNoisyDomainBackoff:         dc.GetDurationProperty(dynamicconfig.TaskSchedulerNoisyDomainBackoff),
 		TaskCriticalRetryCount:                  dc.GetIntProperty(dynamicconfig.TaskCriticalRetryCount),
 		ActiveTaskRedispatchInterval:            dc.GetDurationProperty(dynamicconfig.ActiveTaskRedispatchInterval),
 		StandbyTaskRedispatchInterval:           dc.GetDurationProperty(dynamicconfig.StandbyTaskRedispatchInterval),
@@ -524,6 +535,9 @@ func New(dc *dynamicconfig.Collection, numberOfShards int, maxMessageSize int, s
 		EnableStickyQuery: dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableStickyQuery),
 
 		ValidSearchAttributes:                    dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+		DeprecatedSearchAttributes:               dc.GetMapProperty(dynamicconfig.DeprecatedSearchAttributes),
+		RemovedSearchAttributes:                  dc.GetMapProperty(dynamicconfig.RemovedSearchAttributes),
+		MigratedSearchAttributes:                 dc.GetMapProperty(dynamicconfig.MigratedSearchAttributes),
 		SearchAttributesNumberOfKeysLimit:        dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesNumberOfKeysLimit),
 		SearchAttributesSizeOfValueLimit:         dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesSizeOfValueLimit),
 		SearchAttributesTotalSizeLimit:           dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesTotalSizeLimit),
@@ -554,6 +568,8 @@ func New(dc *dynamicconfig.Collection, numberOfShards int, maxMessageSize int, s
 		ReplicationTaskProcessorShardQPS:                   dc.GetFloat64Property(dynamicconfig.ReplicationTaskProcessorShardQPS),
 		ReplicationTaskGenerationQPS:                       dc.GetFloat64Property(dynamicconfig.ReplicationTaskGenerationQPS),
 		EnableReplicationTaskGeneration:                    dc.GetBoolPropertyFilteredByDomainIDAndWorkflowID(dynamicconfig.EnableReplicationTaskGeneration),
+		WorkflowPaused:                                     dc.GetBoolPropertyFilteredByDomainAndWorkflowID(dynamicconfig.HistoryWorkflowPaused),
+		EnableActivityPartitionConfig:                      dc.GetBoolPropertyFilteredByDomain(dynamicconfig.HistoryEnableActivityPartitionConfig),
 		EnableRecordWorkflowExecutionUninitialized:         dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableRecordWorkflowExecutionUninitialized),
 
 		WorkflowIDCacheExternalEnabled:     dc.GetBoolPropertyFilteredByDomain(dynamicconfig.WorkflowIDCacheExternalEnabled),
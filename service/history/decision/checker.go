@@ -81,6 +81,8 @@ func newAttrValidator(
 			logger,
 			config.EnableQueryAttributeValidation,
 			config.ValidSearchAttributes,
+			config.DeprecatedSearchAttributes,
+			config.RemovedSearchAttributes,
 			config.SearchAttributesNumberOfKeysLimit,
 			config.SearchAttributesSizeOfValueLimit,
 			config.SearchAttributesTotalSizeLimit,
@@ -614,6 +616,7 @@ func (v *attrValidator) validateSignalExternalWorkflowExecutionAttributes(
 func (v *attrValidator) validateUpsertWorkflowSearchAttributes(
 	domainName string,
 	attributes *types.UpsertWorkflowSearchAttributesDecisionAttributes,
+	metricsScope int,
 ) error {
 
 	if attributes == nil {
@@ -628,6 +631,7 @@ func (v *attrValidator) validateUpsertWorkflowSearchAttributes(
 		return &types.BadRequestError{Message: "IndexedFields is empty on decision."}
 	}
 
+	v.dropRemovedSearchAttributes(attributes.GetSearchAttributes(), domainName, metricsScope)
 	return v.searchAttributesValidator.ValidateSearchAttributes(attributes.GetSearchAttributes(), domainName)
 }
 
@@ -685,9 +689,23 @@ func (v *attrValidator) validateContinueAsNewWorkflowExecutionAttributes(
 	if err != nil {
 		return err
 	}
+	v.dropRemovedSearchAttributes(attributes.GetSearchAttributes(), domainName, metricsScope)
 	return v.searchAttributesValidator.ValidateSearchAttributes(attributes.GetSearchAttributes(), domainName)
 }
 
+// dropRemovedSearchAttributes drops the removed search attributes written by a workflow instead of failing its decision,
+// only the frontend rejects them
+func (v *attrValidator) dropRemovedSearchAttributes(
+	searchAttributes *types.SearchAttributes,
+	domainName string,
+	metricsScope int,
+) {
+	if dropped := v.searchAttributesValidator.DropRemovedSearchAttributes(searchAttributes, domainName); len(dropped) > 0 {
+		v.metricsClient.Scope(metricsScope, metrics.DomainTag(domainName)).
+			AddCounter(metrics.RemovedSearchAttributesDroppedCounter, int64(len(dropped)))
+	}
+}
+
 func (v *attrValidator) validateStartChildExecutionAttributes(
 	domainID string,
 	targetDomainID string,
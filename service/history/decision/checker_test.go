@@ -92,6 +92,7 @@ func (s *attrValidatorSuite) SetupTest() {
 		TimerIDMaxLength:                  dynamicconfig.GetIntPropertyFilteredByDomain(1000),
 		ValidSearchAttributes:             dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys()),
 		EnableQueryAttributeValidation:    dynamicconfig.GetBoolPropertyFn(true),
+		RemovedSearchAttributes:           dynamicconfig.GetMapPropertyFn(map[string]interface{}{"CustomDatetimeField": "removed"}),
 		SearchAttributesNumberOfKeysLimit: dynamicconfig.GetIntPropertyFilteredByDomain(100),
 		SearchAttributesSizeOfValueLimit:  dynamicconfig.GetIntPropertyFilteredByDomain(2 * 1024),
 		SearchAttributesTotalSizeLimit:    dynamicconfig.GetIntPropertyFilteredByDomain(40 * 1024),
@@ -159,20 +160,24 @@ func (s *attrValidatorSuite) TestValidateUpsertWorkflowSearchAttributes() {
 	domainName := "testDomain"
 	var attributes *types.UpsertWorkflowSearchAttributesDecisionAttributes
 
-	err := s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes)
+	err := s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes, metrics.HistoryRespondDecisionTaskCompletedScope)
 	s.EqualError(err, "UpsertWorkflowSearchAttributesDecisionAttributes is not set on decision.")
 
 	attributes = &types.UpsertWorkflowSearchAttributesDecisionAttributes{}
-	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes)
+	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes, metrics.HistoryRespondDecisionTaskCompletedScope)
 	s.EqualError(err, "SearchAttributes is not set on decision.")
 
 	attributes.SearchAttributes = &types.SearchAttributes{}
-	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes)
+	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes, metrics.HistoryRespondDecisionTaskCompletedScope)
 	s.EqualError(err, "IndexedFields is empty on decision.")
 
 	attributes.SearchAttributes.IndexedFields = map[string][]byte{"CustomKeywordField": []byte(`"bytes"`)}
-	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes)
+	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes, metrics.HistoryRespondDecisionTaskCompletedScope)
 	s.Nil(err)
+	attributes.SearchAttributes.IndexedFields["CustomDatetimeField"] = []byte(`"2019-01-01T00:00:00Z"`)
+	err = s.validator.validateUpsertWorkflowSearchAttributes(domainName, attributes, metrics.HistoryRespondDecisionTaskCompletedScope)
+	s.Nil(err)
+	s.Equal(map[string][]byte{"CustomKeywordField": []byte(`"bytes"`)}, attributes.SearchAttributes.IndexedFields)
 }
 
 func (s *attrValidatorSuite) TestValidateCrossDomainCall_LocalToLocal() {
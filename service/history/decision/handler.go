@@ -157,6 +157,9 @@ func (handler *handlerImpl) HandleDecisionTaskStarted(
 		return nil, err
 	}
 	domainID := domainEntry.GetInfo().ID
+	if handler.config.WorkflowPaused(domainEntry.GetInfo().Name, req.WorkflowExecution.GetWorkflowID()) {
+		return nil, workflow.ErrPaused
+	}
 
 	workflowExecution := types.WorkflowExecution{
 		WorkflowID: req.WorkflowExecution.WorkflowID,
@@ -991,6 +991,7 @@ func (handler *taskHandlerImpl) handleDecisionUpsertWorkflowSearchAttributes(
 			return handler.attrValidator.validateUpsertWorkflowSearchAttributes(
 				domainName,
 				attr,
+				metrics.HistoryRespondDecisionTaskCompletedScope,
 			)
 		},
 		types.DecisionTaskFailedCauseBadSearchAttributes,
@@ -1797,6 +1797,9 @@ func (e *historyEngineImpl) RecordActivityTaskStarted(
 
 	domainID := domainInfo.ID
 	domainName := domainInfo.Name
+	if e.config.WorkflowPaused(domainName, request.WorkflowExecution.GetWorkflowID()) {
+		return nil, workflow.ErrPaused
+	}
 
 	workflowExecution := types.WorkflowExecution{
 		WorkflowID: request.WorkflowExecution.WorkflowID,
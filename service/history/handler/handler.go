@@ -142,6 +142,7 @@ func (h *handlerImpl) Start() {
 	h.queueTaskProcessor, err = task.NewProcessor(
 		taskPriorityAssigner,
 		h.config,
+		h.GetDomainCache(),
 		h.GetLogger(),
 		h.GetMetricsClient(),
 	)
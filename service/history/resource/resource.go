@@ -104,9 +104,10 @@ func New(
 			WriteDBVisibilityOpenMaxQPS:                 config.VisibilityOpenMaxQPS,
 			WriteDBVisibilityClosedMaxQPS:               config.VisibilityClosedMaxQPS,
 
-			ESVisibilityListMaxQPS: nil,                          // history service never read,
-			ESIndexMaxResultWindow: nil,                          // history service never read,
-			ValidSearchAttributes:  config.ValidSearchAttributes, // history service never read, (Pinot need this to initialize pinotQueryValidator)
+			ESVisibilityListMaxQPS:   nil,                          // history service never read,
+			ESIndexMaxResultWindow:   nil,                          // history service never read,
+			ValidSearchAttributes:    config.ValidSearchAttributes, // history service never read, (Pinot need this to initialize pinotQueryValidator)
+			MigratedSearchAttributes: config.MigratedSearchAttributes,
 		},
 	)
 	if err != nil {
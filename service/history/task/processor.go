@@ -27,6 +27,7 @@ import (
 	"sync/atomic"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
@@ -57,6 +58,7 @@ var (
 func NewProcessor(
 	priorityAssigner PriorityAssigner,
 	config *config.Config,
+	domainCache cache.DomainCache,
 	logger log.Logger,
 	metricsClient metrics.Client,
 ) (Processor, error) {
@@ -70,6 +72,7 @@ func NewProcessor(
 	if err != nil {
 		return nil, err
 	}
+	setDomainSchedulerOptions(options, config, domainCache)
 	hostScheduler, err := createTaskScheduler(options, logger, metricsClient)
 	if err != nil {
 		return nil, err
@@ -88,6 +91,7 @@ func NewProcessor(
 		if err != nil {
 			return nil, err
 		}
+		setDomainSchedulerOptions(shardOptions, config, domainCache)
 		logger.Debug("Shard level task scheduler is enabled", tag.Dynamic("scheduler_options", shardOptions.String()))
 	}
 
@@ -265,6 +269,12 @@ func createTaskScheduler(
 			metricsClient,
 			options.WRRSchedulerOptions,
 		)
+	case task.SchedulerTypeDomainWRR:
+		scheduler, err = task.NewDomainWeightedRoundRobinTaskScheduler(
+			logger,
+			metricsClient,
+			options.DomainWRRSchedulerOptions,
+		)
 	default:
 		// the scheduler type has already been verified when initializing the processor
 		panic(fmt.Sprintf("Unknown task scheduler type, %v", options.SchedulerType))
@@ -272,3 +282,27 @@ func createTaskScheduler(
 
 	return scheduler, err
 }
+
+// setDomainSchedulerOptions fills in the domain related options of the per domain task scheduler
+func setDomainSchedulerOptions(
+	options *task.SchedulerOptions,
+	config *config.Config,
+	domainCache cache.DomainCache,
+) {
+	if options.DomainWRRSchedulerOptions == nil {
+		return
+	}
+
+	options.DomainWRRSchedulerOptions.DomainFn = func(priorityTask task.PriorityTask) string {
+		queueTask, ok := priorityTask.(Task)
+		if !ok {
+			return ""
+		}
+		// tasks of unknown domains share the same queue
+		domainName, _ := domainCache.GetDomainName(queueTask.GetDomainID())
+		return domainName
+	}
+	options.DomainWRRSchedulerOptions.DomainWeight = config.TaskSchedulerDomainWeight
+	options.DomainWRRSchedulerOptions.DomainRPS = config.TaskSchedulerDomainRPS
+	options.DomainWRRSchedulerOptions.NoisyDomainBackoff = config.TaskSchedulerNoisyDomainBackoff
+}
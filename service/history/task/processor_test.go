@@ -36,6 +36,7 @@ import (
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/task"
 	"github.com/uber/cadence/service/history/config"
+	"github.com/uber/cadence/service/history/constants"
 	"github.com/uber/cadence/service/history/shard"
 )
 
@@ -212,12 +213,35 @@ func (s *queueTaskProcessorSuite) TestNewSchedulerOptions_UnknownSchedulerType()
 	s.Nil(options)
 }
 
+func (s *queueTaskProcessorSuite) TestNewProcessor_DomainWRRScheduler() {
+	config := config.NewForTest()
+	config.TaskSchedulerType = dynamicconfig.GetIntPropertyFn(int(task.SchedulerTypeDomainWRR))
+	config.TaskSchedulerShardWorkerCount = dynamicconfig.GetIntPropertyFn(1)
+	processor, err := NewProcessor(
+		s.mockPriorityAssigner,
+		config,
+		s.mockShard.Resource.DomainCache,
+		s.logger,
+		s.metricsClient,
+	)
+	s.NoError(err)
+
+	mockTask := NewMockTask(s.controller)
+	mockTask.EXPECT().GetDomainID().Return(constants.TestDomainID).Times(2)
+	s.mockShard.Resource.DomainCache.EXPECT().GetDomainName(constants.TestDomainID).Return(constants.TestDomainName, nil).Times(2)
+	for _, options := range []*task.SchedulerOptions{processor.(*processorImpl).options, processor.(*processorImpl).shardOptions} {
+		s.NotNil(options.DomainWRRSchedulerOptions)
+		s.Equal(constants.TestDomainName, options.DomainWRRSchedulerOptions.DomainFn(mockTask))
+	}
+}
+
 func (s *queueTaskProcessorSuite) newTestQueueTaskProcessor() *processorImpl {
 	config := config.NewForTest()
 	config.TaskSchedulerShardWorkerCount = dynamicconfig.GetIntPropertyFn(1)
 	processor, err := NewProcessor(
 		s.mockPriorityAssigner,
 		config,
+		s.mockShard.Resource.DomainCache,
 		s.logger,
 		s.metricsClient,
 	)
@@ -30,8 +30,10 @@ import (
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/partition"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/history/config"
 	"github.com/uber/cadence/service/history/execution"
 	"github.com/uber/cadence/service/history/shard"
 )
@@ -471,6 +473,21 @@ func retryWorkflow(
 	return newMutableState, nil
 }
 
+// isWorkflowPaused returns true if the workflow is paused by the dynamic config. The decision and activity tasks of
+// a paused workflow are dropped instead of dispatched, and they are generated again when its tasks are refreshed.
+func isWorkflowPaused(
+	shard shard.Context,
+	domainID string,
+	workflowID string,
+) bool {
+
+	domainName, err := shard.GetDomainCache().GetDomainName(domainID)
+	if err != nil {
+		return false
+	}
+	return shard.GetConfig().WorkflowPaused(domainName, workflowID)
+}
+
 func getWorkflowExecution(
 	taskInfo Info,
 ) types.WorkflowExecution {
@@ -481,6 +498,65 @@ func getWorkflowExecution(
 	}
 }
 
+// activityPartitionConfigHeaders are the activity headers overriding the partition config of the workflow
+var activityPartitionConfigHeaders = map[string]string{
+	common.PriorityHeaderName:    partition.PriorityKey,
+	common.FairnessKeyHeaderName: partition.FairnessKey,
+}
+
+// getActivityPartitionConfig returns the partition config of the workflow with the activity type, and with the priority
+// and the fairness key overridden by the headers of the activity if they are set.
+// They are read from the scheduled event of the activity, so only when they are enabled for the domain. If the event is
+// missing the activity is dispatched with the partition config of the workflow, other errors are returned to retry the task.
+func getActivityPartitionConfig(
+	ctx context.Context,
+	mutableState execution.MutableState,
+	scheduleID int64,
+	config *config.Config,
+	metricsScope metrics.Scope,
+	logger log.Logger,
+) (map[string]string, error) {
+
+	partitionConfig := mutableState.GetExecutionInfo().PartitionConfig
+	domainName := mutableState.GetDomainEntry().GetInfo().Name
+	if !config.EnableActivityPartitionConfig(domainName) {
+		return partitionConfig, nil
+	}
+
+	scheduledEvent, err := mutableState.GetActivityScheduledEvent(ctx, scheduleID)
+	if err != nil {
+		if err != execution.ErrMissingActivityScheduledEvent {
+			return nil, err
+		}
+		metricsScope.Tagged(metrics.DomainTag(domainName)).IncCounter(metrics.ActivityPartitionConfigFailureCounter)
+		logger.Warn("Failed to read the scheduled event of the activity, dispatching it without its activity type, priority and fairness key.",
+			tag.WorkflowDomainName(domainName),
+			tag.WorkflowScheduleID(scheduleID),
+			tag.Error(err),
+		)
+		return partitionConfig, nil
+	}
+	attributes := scheduledEvent.ActivityTaskScheduledEventAttributes
+	if attributes == nil {
+		return partitionConfig, nil
+	}
+	activityPartitionConfig := make(map[string]string, len(partitionConfig)+len(activityPartitionConfigHeaders)+1)
+	for k, v := range partitionConfig {
+		activityPartitionConfig[k] = v
+	}
+	if attributes.ActivityType != nil {
+		activityPartitionConfig[partition.ActivityTypeKey] = attributes.ActivityType.GetName()
+	}
+	if attributes.Header != nil {
+		for header, key := range activityPartitionConfigHeaders {
+			if value := attributes.Header.Fields[header]; len(value) != 0 {
+				activityPartitionConfig[key] = string(value)
+			}
+		}
+	}
+	return activityPartitionConfig, nil
+}
+
 // NewMockTaskMatcher creates a gomock matcher for mock Task
 func NewMockTaskMatcher(mockTask *MockTask) gomock.Matcher {
 	return &mockTaskMatcher{
@@ -0,0 +1,113 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log/testlogger"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/history/config"
+	"github.com/uber/cadence/service/history/constants"
+	"github.com/uber/cadence/service/history/execution"
+)
+
+func TestGetActivityPartitionConfig(t *testing.T) {
+	workflowPartitionConfig := map[string]string{partition.PriorityKey: "2", "isolation-group": "zone-a"}
+	scheduledEvent := &types.HistoryEvent{
+		ActivityTaskScheduledEventAttributes: &types.ActivityTaskScheduledEventAttributes{
+			ActivityType: &types.ActivityType{Name: "activityType"},
+			Header: &types.Header{
+				Fields: map[string][]byte{
+					common.PriorityHeaderName:    []byte("1"),
+					common.FairnessKeyHeaderName: []byte("tenant-1"),
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		enabled                 bool
+		scheduledEvent          *types.HistoryEvent
+		scheduledEventErr       error
+		expectedPartitionConfig map[string]string
+		expectedErr             error
+	}{
+		"disabled": {
+			expectedPartitionConfig: workflowPartitionConfig,
+		},
+		"enabled": {
+			enabled:        true,
+			scheduledEvent: scheduledEvent,
+			expectedPartitionConfig: map[string]string{
+				partition.ActivityTypeKey: "activityType",
+				partition.PriorityKey:     "1",
+				partition.FairnessKey:     "tenant-1",
+				"isolation-group":         "zone-a",
+			},
+		},
+		"missing scheduled event": {
+			enabled:                 true,
+			scheduledEventErr:       execution.ErrMissingActivityScheduledEvent,
+			expectedPartitionConfig: workflowPartitionConfig,
+		},
+		"transient error": {
+			enabled:           true,
+			scheduledEventErr: &persistence.TimeoutError{Msg: "timeout"},
+			expectedErr:       &persistence.TimeoutError{Msg: "timeout"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mutableState := execution.NewMockMutableState(controller)
+			mutableState.EXPECT().GetExecutionInfo().Return(&persistence.WorkflowExecutionInfo{PartitionConfig: workflowPartitionConfig}).AnyTimes()
+			mutableState.EXPECT().GetDomainEntry().Return(constants.TestLocalDomainEntry).AnyTimes()
+			if test.enabled {
+				mutableState.EXPECT().GetActivityScheduledEvent(gomock.Any(), int64(5)).Return(test.scheduledEvent, test.scheduledEventErr)
+			}
+			config := config.NewForTest()
+			config.EnableActivityPartitionConfig = dynamicconfig.GetBoolPropertyFnFilteredByDomain(test.enabled)
+
+			partitionConfig, err := getActivityPartitionConfig(
+				context.Background(),
+				mutableState,
+				5,
+				config,
+				metrics.NewNoopMetricsClient().Scope(metrics.TransferActiveTaskActivityScope),
+				testlogger.New(t),
+			)
+			require.Equal(t, test.expectedErr, err)
+			require.Equal(t, test.expectedPartitionConfig, partitionConfig)
+		})
+	}
+}
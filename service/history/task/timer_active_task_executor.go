@@ -375,6 +375,11 @@ Loop:
 			}
 		}
 
+		// the activity task of a paused workflow is not dispatched, so it can't be started in time
+		if timerSequenceID.TimerType == execution.TimerTypeScheduleToStart && isWorkflowPaused(t.shard, task.DomainID, task.WorkflowID) {
+			continue Loop
+		}
+
 		// check if it's possible that the timeout is due to activity task lost
 		if timerSequenceID.TimerType == execution.TimerTypeScheduleToStart {
 			domainName, err := t.shard.GetDomainCache().GetDomainName(mutableState.GetExecutionInfo().DomainID)
@@ -517,6 +522,10 @@ func (t *timerActiveTaskExecutor) executeDecisionTimeoutTask(
 			// decision has already started
 			return nil
 		}
+		if isWorkflowPaused(t.shard, task.DomainID, task.WorkflowID) {
+			// the decision task of a paused workflow is not dispatched, so it can't be started in time
+			return nil
+		}
 
 		if !isStickyDecision {
 			t.logger.Warn("Potential lost normal decision task",
@@ -592,6 +601,10 @@ func (t *timerActiveTaskExecutor) executeActivityRetryTimerTask(
 	task *persistence.TimerTaskInfo,
 ) (retError error) {
 
+	if isWorkflowPaused(t.shard, task.DomainID, task.WorkflowID) {
+		return nil
+	}
+
 	wfContext, release, err := t.executionCache.GetOrCreateWorkflowExecutionWithTimeout(
 		task.DomainID,
 		getWorkflowExecution(task),
@@ -664,6 +677,17 @@ func (t *timerActiveTaskExecutor) executeActivityRetryTimerTask(
 		Name: activityInfo.TaskList,
 	}
 	scheduleToStartTimeout := activityInfo.ScheduleToStartTimeout
+	partitionConfig, err := getActivityPartitionConfig(
+		ctx,
+		mutableState,
+		scheduledID,
+		t.config,
+		t.metricsClient.Scope(metrics.TimerActiveTaskActivityRetryTimerScope),
+		t.logger,
+	)
+	if err != nil {
+		return err
+	}
 
 	release(nil) // release earlier as we don't need the lock anymore
 
@@ -674,7 +698,7 @@ func (t *timerActiveTaskExecutor) executeActivityRetryTimerTask(
 		TaskList:                      taskList,
 		ScheduleID:                    scheduledID,
 		ScheduleToStartTimeoutSeconds: common.Int32Ptr(scheduleToStartTimeout),
-		PartitionConfig:               mutableState.GetExecutionInfo().PartitionConfig,
+		PartitionConfig:               partitionConfig,
 	})
 }
 
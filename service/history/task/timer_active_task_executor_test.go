@@ -99,6 +99,7 @@ func (s *timerActiveTaskExecutorSuite) SetupTest() {
 	s.controller = gomock.NewController(s.T())
 
 	config := config.NewForTest()
+	config.EnableActivityPartitionConfig = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
 	s.mockShard = shard.NewTestContext(
 		s.T(),
 		s.controller,
@@ -809,6 +810,37 @@ func (s *timerActiveTaskExecutorSuite) TestDecisionScheduleToStartTimeout_Normal
 	s.NoError(err)
 }
 
+func (s *timerActiveTaskExecutorSuite) TestDecisionScheduleToStartTimeout_Paused() {
+
+	workflowExecution, mutableState, err := test.StartWorkflow(s.mockShard, s.domainID)
+	s.NoError(err)
+
+	di := test.AddDecisionTaskScheduledEvent(mutableState)
+
+	timerTask := s.newTimerTaskFromInfo(&persistence.TimerTaskInfo{
+		Version:             s.version,
+		DomainID:            s.domainID,
+		WorkflowID:          workflowExecution.GetWorkflowID(),
+		RunID:               workflowExecution.GetRunID(),
+		TaskID:              int64(100),
+		TaskType:            persistence.TaskTypeDecisionTimeout,
+		TimeoutType:         int(types.TimeoutTypeScheduleToStart),
+		VisibilityTimestamp: s.timeSource.Now(),
+		EventID:             di.ScheduleID,
+	})
+
+	persistenceMutableState, err := test.CreatePersistenceMutableState(mutableState, di.ScheduleID, di.Version)
+	s.NoError(err)
+	s.mockExecutionMgr.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(&persistence.GetWorkflowExecutionResponse{State: persistenceMutableState}, nil).Once()
+
+	s.mockShard.GetConfig().WorkflowPaused = func(domain string, workflowID string) bool {
+		return domain == s.domain && workflowID == workflowExecution.GetWorkflowID()
+	}
+	err = s.timerActiveTaskExecutor.Execute(timerTask, true)
+	s.NoError(err)
+	s.mockExecutionMgr.AssertNotCalled(s.T(), "UpdateWorkflowExecution", mock.Anything, mock.Anything)
+}
+
 func (s *timerActiveTaskExecutorSuite) TestDecisionScheduleToStartTimeout_TransientDecision() {
 	s.mockShard.GetConfig().NormalDecisionScheduleToStartMaxAttempts = dynamicconfig.GetIntPropertyFilteredByDomain(1)
 
@@ -1068,7 +1100,7 @@ func (s *timerActiveTaskExecutorSuite) TestActivityRetryTimer_Fire() {
 			},
 			ScheduleID:                    activityInfo.ScheduleID,
 			ScheduleToStartTimeoutSeconds: common.Int32Ptr(activityInfo.ScheduleToStartTimeout),
-			PartitionConfig:               mutableState.GetExecutionInfo().PartitionConfig,
+			PartitionConfig:               createActivityPartitionConfig(mutableState, "activity type"),
 		},
 	).Return(nil).Times(1)
 
@@ -1076,6 +1108,53 @@ func (s *timerActiveTaskExecutorSuite) TestActivityRetryTimer_Fire() {
 	s.NoError(err)
 }
 
+func (s *timerActiveTaskExecutorSuite) TestActivityRetryTimer_Paused() {
+
+	workflowExecution, mutableState, decisionCompletionID, err := test.SetupWorkflowWithCompletedDecision(s.mockShard, s.domainID)
+	s.NoError(err)
+
+	timerTimeout := 2 * time.Second
+	_, activityInfo := test.AddActivityTaskScheduledEventWithRetry(
+		mutableState,
+		decisionCompletionID,
+		"activity",
+		"activity type",
+		mutableState.GetExecutionInfo().TaskList,
+		[]byte(nil),
+		int32(timerTimeout.Seconds()),
+		int32(timerTimeout.Seconds()),
+		int32(timerTimeout.Seconds()),
+		int32(timerTimeout.Seconds()),
+		&types.RetryPolicy{
+			InitialIntervalInSeconds:    1,
+			BackoffCoefficient:          1.2,
+			MaximumIntervalInSeconds:    5,
+			MaximumAttempts:             5,
+			ExpirationIntervalInSeconds: 999,
+		},
+	)
+	activityInfo.Attempt = 1
+
+	timerTask := s.newTimerTaskFromInfo(&persistence.TimerTaskInfo{
+		Version:             s.version,
+		DomainID:            s.domainID,
+		WorkflowID:          workflowExecution.GetWorkflowID(),
+		RunID:               workflowExecution.GetRunID(),
+		TaskID:              int64(100),
+		TaskType:            persistence.TaskTypeActivityRetryTimer,
+		TimeoutType:         0,
+		VisibilityTimestamp: s.timeSource.Now(),
+		EventID:             activityInfo.ScheduleID,
+		ScheduleAttempt:     int64(activityInfo.Attempt),
+	})
+
+	s.mockShard.GetConfig().WorkflowPaused = func(domain string, workflowID string) bool {
+		return domain == s.domain && workflowID == workflowExecution.GetWorkflowID()
+	}
+	err = s.timerActiveTaskExecutor.Execute(timerTask, true)
+	s.NoError(err)
+}
+
 func (s *timerActiveTaskExecutorSuite) TestActivityRetryTimer_Noop() {
 
 	workflowExecution, mutableState, decisionCompletionID, err := test.SetupWorkflowWithCompletedDecision(s.mockShard, s.domainID)
@@ -161,6 +161,10 @@ func (t *transferActiveTaskExecutor) processActivityTask(
 	task *persistence.TransferTaskInfo,
 ) (retError error) {
 
+	if isWorkflowPaused(t.shard, task.DomainID, task.WorkflowID) {
+		return nil
+	}
+
 	wfContext, release, err := t.executionCache.GetOrCreateWorkflowExecutionWithTimeout(
 		task.DomainID,
 		getWorkflowExecution(task),
@@ -193,6 +197,17 @@ func (t *transferActiveTaskExecutor) processActivityTask(
 	}
 
 	timeout := common.MinInt32(ai.ScheduleToStartTimeout, common.MaxTaskTimeout)
+	partitionConfig, err := getActivityPartitionConfig(
+		ctx,
+		mutableState,
+		task.ScheduleID,
+		t.config,
+		t.metricsClient.Scope(metrics.TransferActiveTaskActivityScope),
+		t.logger,
+	)
+	if err != nil {
+		return err
+	}
 	// release the context lock since we no longer need mutable state builder and
 	// the rest of logic is making RPC call, which takes time.
 	release(nil)
@@ -200,7 +215,7 @@ func (t *transferActiveTaskExecutor) processActivityTask(
 	// Ratelimiting is not done. This is only to count the number of requests via metrics
 	t.wfIDCache.AllowInternal(task.DomainID, task.WorkflowID)
 
-	return t.pushActivity(ctx, task, timeout, mutableState.GetExecutionInfo().PartitionConfig)
+	return t.pushActivity(ctx, task, timeout, partitionConfig)
 }
 
 func (t *transferActiveTaskExecutor) processDecisionTask(
@@ -208,6 +223,10 @@ func (t *transferActiveTaskExecutor) processDecisionTask(
 	task *persistence.TransferTaskInfo,
 ) (retError error) {
 
+	if isWorkflowPaused(t.shard, task.DomainID, task.WorkflowID) {
+		return nil
+	}
+
 	wfContext, release, err := t.executionCache.GetOrCreateWorkflowExecutionWithTimeout(
 		task.DomainID,
 		getWorkflowExecution(task),
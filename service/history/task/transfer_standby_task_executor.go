@@ -137,10 +137,18 @@ func (t *transferStandbyTaskExecutor) processActivityTask(
 		}
 
 		if activityInfo.StartedID == common.EmptyEventID {
-			return newPushActivityToMatchingInfo(
-				activityInfo.ScheduleToStartTimeout,
-				mutableState.GetExecutionInfo().PartitionConfig,
-			), nil
+			partitionConfig, err := getActivityPartitionConfig(
+				ctx,
+				mutableState,
+				transferTask.ScheduleID,
+				t.config,
+				t.metricsClient.Scope(metrics.TransferStandbyTaskActivityScope),
+				t.logger,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return newPushActivityToMatchingInfo(activityInfo.ScheduleToStartTimeout, partitionConfig), nil
 		}
 
 		return nil, nil
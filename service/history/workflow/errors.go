@@ -35,6 +35,8 @@ var (
 	ErrActivityTaskNotFound = &types.EntityNotExistsError{Message: "activity task not found"}
 	// ErrNotExists is the error to indicate workflow doesn't exist
 	ErrNotExists = &types.EntityNotExistsError{Message: "workflow execution already completed"}
+	// ErrPaused is the error to indicate workflow execution is paused, so that its tasks are dropped by matching
+	ErrPaused = &types.EntityNotExistsError{Message: "workflow execution is paused"}
 	// ErrAlreadyCompleted is the error to indicate workflow execution already completed
 	ErrAlreadyCompleted = &types.WorkflowExecutionAlreadyCompletedError{Message: "workflow execution already completed"}
 	// ErrParentMismatch is the error to parent execution is given and mismatch
@@ -0,0 +1,137 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/quotas"
+	"github.com/uber/cadence/common/service"
+)
+
+type (
+	// activityTypeRateLimiter limits the rate at which the activity tasks of a domain are dispatched by activity
+	// type. It's shared by all the task lists of the matching host, and the limit of an activity type is divided
+	// equally across the matching hosts, so that it's enforced for the domain as a whole.
+	activityTypeRateLimiter struct {
+		sync.Mutex
+		rps      dynamicconfig.IntPropertyFnWithActivityTypeFilter
+		resolver membership.Resolver
+		limiters map[activityTypeKey]*quotas.RateLimiter
+	}
+
+	activityTypeKey struct {
+		domainID     string
+		activityType string
+	}
+
+	// activityTypeThrottledError is returned when the activity type of a task is throttled, the task can be
+	// dispatched again after the delay
+	activityTypeThrottledError struct {
+		activityType string
+		delay        time.Duration
+	}
+)
+
+func newActivityTypeRateLimiter(rps dynamicconfig.IntPropertyFnWithActivityTypeFilter, resolver membership.Resolver) *activityTypeRateLimiter {
+	return &activityTypeRateLimiter{
+		rps:      rps,
+		resolver: resolver,
+		limiters: make(map[activityTypeKey]*quotas.RateLimiter),
+	}
+}
+
+func (e *activityTypeThrottledError) Error() string {
+	return fmt.Sprintf("activity type %v is throttled for %v", e.activityType, e.delay)
+}
+
+// TryReserve reserves a token for the task if one is available now, it never blocks. The returned reservation
+// is nil if there's no limit for the activity type, otherwise it should be canceled if the task isn't dispatched.
+// It returns an activityTypeThrottledError if the activity type of the task is throttled.
+func (l *activityTypeRateLimiter) TryReserve(domainID string, domainName string, task *InternalTask) (*rate.Reservation, error) {
+	activityType, limiter := l.getLimiter(domainID, domainName, task)
+	if limiter == nil {
+		return nil, nil
+	}
+	rsv := limiter.Reserve()
+	if !rsv.OK() {
+		return nil, &activityTypeThrottledError{activityType: activityType, delay: time.Second}
+	}
+	if delay := rsv.Delay(); delay > 0 {
+		rsv.Cancel()
+		return nil, &activityTypeThrottledError{activityType: activityType, delay: delay}
+	}
+	return rsv, nil
+}
+
+// getLimiter returns the activity type of the task and its rate limiter, the rate limiter is nil if there's no limit
+func (l *activityTypeRateLimiter) getLimiter(domainID string, domainName string, task *InternalTask) (string, *quotas.RateLimiter) {
+	activityType := getActivityType(task)
+	if activityType == "" {
+		return "", nil
+	}
+	rps := l.rps(domainName, activityType)
+	key := activityTypeKey{domainID: domainID, activityType: activityType}
+
+	l.Lock()
+	defer l.Unlock()
+	limiter, ok := l.limiters[key]
+	if rps <= 0 {
+		if ok {
+			delete(l.limiters, key)
+		}
+		return activityType, nil
+	}
+	dispatchRate := float64(rps)
+	if l.resolver != nil {
+		// divide the rate equally across the matching hosts
+		dispatchRate = quotas.PerMember(service.Matching, dispatchRate, dispatchRate, l.resolver)
+	}
+	if !ok {
+		limiter = quotas.NewRateLimiter(&dispatchRate, _defaultTaskDispatchRPSTTL, 1)
+		l.limiters[key] = limiter
+	} else {
+		limiter.UpdateMaxDispatch(&dispatchRate)
+	}
+	return activityType, limiter
+}
+
+func getActivityType(task *InternalTask) string {
+	if info := task.activityTaskDispatchInfo; info != nil {
+		// activities dispatched with the decision response carry the scheduled event
+		if attributes := info.ScheduledEvent.GetActivityTaskScheduledEventAttributes(); attributes != nil {
+			return attributes.ActivityType.GetName()
+		}
+	}
+	if task.event == nil || task.event.TaskInfo == nil {
+		return ""
+	}
+	return task.event.PartitionConfig[partition.ActivityTypeKey]
+}
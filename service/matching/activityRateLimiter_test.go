@@ -0,0 +1,106 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+func newActivityTypeTask(activityType string) *InternalTask {
+	info := &persistence.TaskInfo{
+		PartitionConfig: map[string]string{partition.ActivityTypeKey: activityType},
+	}
+	return newInternalTask(info, nil, types.TaskSourceDbBacklog, "", false, nil, "")
+}
+
+func newTestActivityTypeRateLimiter(rps map[string]int) *activityTypeRateLimiter {
+	return newActivityTypeRateLimiter(func(domainName string, activityType string) int {
+		return rps[domainName+"/"+activityType]
+	}, nil)
+}
+
+func TestGetActivityType(t *testing.T) {
+	assert.Equal(t, "", getActivityType(newInternalTask(&persistence.TaskInfo{}, nil, types.TaskSourceDbBacklog, "", false, nil, "")))
+	assert.Equal(t, "foo", getActivityType(newActivityTypeTask("foo")))
+
+	dispatchInfo := &types.ActivityTaskDispatchInfo{
+		ScheduledEvent: &types.HistoryEvent{
+			ActivityTaskScheduledEventAttributes: &types.ActivityTaskScheduledEventAttributes{
+				ActivityType: &types.ActivityType{Name: "bar"},
+			},
+		},
+	}
+	task := newInternalTask(&persistence.TaskInfo{}, nil, types.TaskSourceHistory, "", true, dispatchInfo, "")
+	assert.Equal(t, "bar", getActivityType(task))
+}
+
+func TestActivityTypeRateLimiter_TryReserve(t *testing.T) {
+	rps := map[string]int{"domain/limited": 1}
+	limiter := newTestActivityTypeRateLimiter(rps)
+
+	for i := 0; i < 10; i++ {
+		rsv, err := limiter.TryReserve("domainID", "domain", newActivityTypeTask("unlimited"))
+		assert.NoError(t, err)
+		assert.Nil(t, rsv)
+	}
+
+	rsv, err := limiter.TryReserve("domainID", "domain", newActivityTypeTask("limited"))
+	require.NoError(t, err)
+	require.NotNil(t, rsv)
+	_, err = limiter.TryReserve("domainID", "domain", newActivityTypeTask("limited"))
+	var throttledErr *activityTypeThrottledError
+	require.True(t, errors.As(err, &throttledErr))
+	assert.Equal(t, "limited", throttledErr.activityType)
+	assert.True(t, throttledErr.delay > 0)
+
+	// the same activity type isn't limited in another domain
+	rsv, err = limiter.TryReserve("otherDomainID", "otherDomain", newActivityTypeTask("limited"))
+	assert.NoError(t, err)
+	assert.Nil(t, rsv)
+
+	// removing the limit removes the rate limiter
+	rps["domain/limited"] = 0
+	rsv, err = limiter.TryReserve("domainID", "domain", newActivityTypeTask("limited"))
+	assert.NoError(t, err)
+	assert.Nil(t, rsv)
+	assert.Empty(t, limiter.limiters)
+}
+
+func TestActivityTypeRateLimiter_SharedAcrossTaskLists(t *testing.T) {
+	limiter := newTestActivityTypeRateLimiter(map[string]int{"domain/foo": 1})
+
+	// the tasks of different task lists of the domain share the limit
+	rsv, err := limiter.TryReserve("domainID", "domain", newActivityTypeTask("foo"))
+	require.NoError(t, err)
+	require.NotNil(t, rsv)
+	_, err = limiter.TryReserve("domainID", "domain", newActivityTypeTask("foo"))
+	assert.Error(t, err)
+}
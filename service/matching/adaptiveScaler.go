@@ -0,0 +1,326 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/cadence/client/matching"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// adaptiveScaler scales the number of partitions of a root task list based on its add and
+	// dispatch rates. Clients read the number of partitions with ListTaskListPartitions and use
+	// it for both adds and polls. The partitions removed by a downscale are drained before they
+	// are forgotten: once clients have stopped writing to them, they are loaded until their
+	// backlog is empty, and their tasks are forwarded to the root partition.
+	adaptiveScaler struct {
+		status       int32
+		taskListID   *taskListID
+		config       *taskListConfig
+		timeSource   clock.TimeSource
+		logger       log.Logger
+		scope        metrics.Scope
+		shutdownChan chan struct{}
+		// getBacklog returns the backlog of the given partition
+		getBacklog func(partition int) (int64, error)
+		// getRootBacklog returns the backlog of the root partition
+		getRootBacklog func() int64
+
+		addCount      int64
+		dispatchCount int64
+
+		sync.RWMutex
+		numPartitions int
+		// partitions in [numPartitions, drainPartitionsUpTo) are being drained
+		drainPartitionsUpTo int
+		drainStartTime      time.Time
+		upscaleStartTime    time.Time
+		downscaleStartTime  time.Time
+		lastUpdateTime      time.Time
+	}
+)
+
+const (
+	adaptiveScalerRequestTimeout = 5 * time.Second
+)
+
+var _ common.Daemon = (*adaptiveScaler)(nil)
+
+func newAdaptiveScaler(
+	taskListID *taskListID,
+	config *taskListConfig,
+	timeSource clock.TimeSource,
+	logger log.Logger,
+	scope metrics.Scope,
+	getBacklog func(partition int) (int64, error),
+	getRootBacklog func() int64,
+) *adaptiveScaler {
+	now := timeSource.Now()
+	numPartitions := config.NumWritePartitions()
+	drainPartitionsUpTo := numPartitions
+	if config.EnableAdaptiveScaler() {
+		// the number of partitions isn't persisted, so the partitions added before the root
+		// partition was loaded on this host are drained as if they were just removed
+		drainPartitionsUpTo = common.MaxInt(numPartitions, config.AdaptiveScalerMaxPartitions())
+	}
+	return &adaptiveScaler{
+		status:              common.DaemonStatusInitialized,
+		taskListID:          taskListID,
+		config:              config,
+		timeSource:          timeSource,
+		logger:              logger,
+		scope:               scope,
+		shutdownChan:        make(chan struct{}),
+		getBacklog:          getBacklog,
+		getRootBacklog:      getRootBacklog,
+		numPartitions:       numPartitions,
+		drainPartitionsUpTo: drainPartitionsUpTo,
+		drainStartTime:      now,
+		lastUpdateTime:      now,
+	}
+}
+
+func (s *adaptiveScaler) Start() {
+	if !atomic.CompareAndSwapInt32(&s.status, common.DaemonStatusInitialized, common.DaemonStatusStarted) {
+		return
+	}
+	go s.eventLoop()
+}
+
+func (s *adaptiveScaler) Stop() {
+	if !atomic.CompareAndSwapInt32(&s.status, common.DaemonStatusStarted, common.DaemonStatusStopped) {
+		return
+	}
+	close(s.shutdownChan)
+}
+
+// NumPartitions returns the number of partitions clients should add tasks to and poll from
+func (s *adaptiveScaler) NumPartitions() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.numPartitions
+}
+
+func (s *adaptiveScaler) recordAdd() {
+	atomic.AddInt64(&s.addCount, 1)
+}
+
+func (s *adaptiveScaler) recordDispatch() {
+	atomic.AddInt64(&s.dispatchCount, 1)
+}
+
+func (s *adaptiveScaler) eventLoop() {
+	timer := s.timeSource.NewTimer(s.config.AdaptiveScalerUpdateInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.Chan():
+			s.update()
+			timer.Reset(s.config.AdaptiveScalerUpdateInterval())
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// update samples the rates of the root partition since the last update, scales the number
+// of partitions and checks the partitions which are being drained. It's only called from
+// the event loop, so the state is only locked for the readers of the number of partitions.
+func (s *adaptiveScaler) update() {
+	now := s.timeSource.Now()
+	addCount := atomic.SwapInt64(&s.addCount, 0)
+	dispatchCount := atomic.SwapInt64(&s.dispatchCount, 0)
+	elapsed := now.Sub(s.lastUpdateTime)
+	s.lastUpdateTime = now
+
+	numPartitions := s.getTargetNumPartitions(now, addCount, dispatchCount, elapsed)
+	if numPartitions != s.numPartitions {
+		s.setNumPartitions(now, numPartitions)
+	}
+	s.drain(now)
+
+	s.scope.UpdateGauge(metrics.TaskListPartitionsGauge, float64(s.numPartitions))
+	s.scope.UpdateGauge(metrics.TaskListDrainingPartitionsGauge, float64(common.MaxInt(0, s.drainPartitionsUpTo-s.numPartitions)))
+}
+
+func (s *adaptiveScaler) getTargetNumPartitions(now time.Time, addCount, dispatchCount int64, elapsed time.Duration) int {
+	minPartitions := s.config.NumWritePartitions()
+	if !s.config.EnableAdaptiveScaler() || elapsed <= 0 {
+		// clients use the dynamic config when the scaler is disabled, so the partitions
+		// added by the scaler are drained
+		s.upscaleStartTime = time.Time{}
+		s.downscaleStartTime = time.Time{}
+		return minPartitions
+	}
+	// partitions forward their tasks to their parent while they are drained, which only has
+	// pollers when it's the root partition
+	maxPartitions := common.MaxInt(minPartitions, common.MinInt(s.config.AdaptiveScalerMaxPartitions(), s.config.ForwarderMaxChildrenPerNode()+1))
+	if s.numPartitions < minPartitions || s.numPartitions > maxPartitions {
+		return common.MinInt(maxPartitions, common.MaxInt(minPartitions, s.numPartitions))
+	}
+
+	// partitions are picked randomly by clients, so the rate of the root partition is
+	// representative of the others
+	rate := float64(common.MaxInt64(addCount, dispatchCount)) / elapsed.Seconds() * float64(s.numPartitions)
+	upscaleRPS := float64(common.MaxInt(1, s.config.PartitionUpscaleRPS()))
+	target := common.MinInt(maxPartitions, common.MaxInt(minPartitions, int(math.Ceil(rate/upscaleRPS))))
+	switch {
+	case target > s.numPartitions:
+		s.downscaleStartTime = time.Time{}
+		if s.upscaleStartTime.IsZero() {
+			s.upscaleStartTime = now
+		}
+		if now.Sub(s.upscaleStartTime) >= s.config.UpscaleSustainedDuration() {
+			s.upscaleStartTime = time.Time{}
+			s.scope.IncCounter(metrics.TaskListPartitionUpscaleCounter)
+			return target
+		}
+	case target < s.numPartitions &&
+		rate < s.config.PartitionDownscaleFactor()*upscaleRPS*float64(s.numPartitions-1) &&
+		s.getRootBacklog() == 0:
+		s.upscaleStartTime = time.Time{}
+		if s.downscaleStartTime.IsZero() {
+			s.downscaleStartTime = now
+		}
+		if now.Sub(s.downscaleStartTime) >= s.config.DownscaleSustainedDuration() {
+			s.downscaleStartTime = time.Time{}
+			s.scope.IncCounter(metrics.TaskListPartitionDownscaleCounter)
+			return target
+		}
+	default:
+		s.upscaleStartTime = time.Time{}
+		s.downscaleStartTime = time.Time{}
+	}
+	return s.numPartitions
+}
+
+func (s *adaptiveScaler) setNumPartitions(now time.Time, numPartitions int) {
+	s.logger.Info("Task list partitions scaled",
+		tag.Dynamic("from", s.numPartitions),
+		tag.Dynamic("to", numPartitions),
+	)
+	s.Lock()
+	defer s.Unlock()
+	if numPartitions < s.numPartitions {
+		s.drainPartitionsUpTo = common.MaxInt(s.drainPartitionsUpTo, s.numPartitions)
+		s.drainStartTime = now
+	}
+	s.numPartitions = numPartitions
+}
+
+// drain checks the backlog of the partitions being drained once clients had enough time to
+// refresh the number of partitions, and stops draining the partitions above the highest one
+// with a backlog
+func (s *adaptiveScaler) drain(now time.Time) {
+	if s.drainPartitionsUpTo <= s.numPartitions || now.Sub(s.drainStartTime) < 2*s.config.PartitionConfigRefreshInterval() {
+		return
+	}
+
+	drainPartitionsUpTo := s.numPartitions
+	for partition := s.drainPartitionsUpTo - 1; partition >= s.numPartitions; partition-- {
+		backlog, err := s.getBacklog(partition)
+		if err != nil {
+			s.logger.Warn("Failed to get backlog of drained task list partition", tag.Dynamic("partition", partition), tag.Error(err))
+		}
+		if err != nil || backlog > 0 {
+			drainPartitionsUpTo = partition + 1
+			break
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.drainPartitionsUpTo = drainPartitionsUpTo
+}
+
+// newPartitionBacklogFn returns a function to get the backlog of the partitions of a root
+// task list. The partitions with tasks, including the tasks of their priority backlogs, are
+// loaded by describing them, which gets their exact backlog and starts forwarding their tasks
+// to the root partition.
+func newPartitionBacklogFn(
+	taskManager persistence.TaskManager,
+	matchingClient matching.Client,
+	taskListID *taskListID,
+	domainName string,
+	priorities func() []int,
+) func(int) (int64, error) {
+	taskListType := types.TaskListTypeDecision
+	if taskListID.taskType == persistence.TaskListTypeActivity {
+		taskListType = types.TaskListTypeActivity
+	}
+	return func(partition int) (int64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), adaptiveScalerRequestTimeout)
+		defer cancel()
+
+		name := taskListID.mkName(partition)
+		// the tasks which are acked but not deleted yet are counted here, but a partition
+		// without any task doesn't need to be loaded
+		names := []string{name}
+		for _, priority := range priorities() {
+			names = append(names, priorityBacklogName(name, priority))
+		}
+		var size int64
+		for _, backlogName := range names {
+			resp, err := taskManager.GetTaskListSize(ctx, &persistence.GetTaskListSizeRequest{
+				DomainID:     taskListID.domainID,
+				DomainName:   domainName,
+				TaskListName: backlogName,
+				TaskListType: taskListID.taskType,
+			})
+			if err != nil {
+				return 0, err
+			}
+			size += resp.Size
+		}
+		if size == 0 {
+			return 0, nil
+		}
+
+		desc, err := matchingClient.DescribeTaskList(ctx, &types.MatchingDescribeTaskListRequest{
+			DomainUUID: taskListID.domainID,
+			DescRequest: &types.DescribeTaskListRequest{
+				Domain:                domainName,
+				TaskList:              &types.TaskList{Name: name, Kind: types.TaskListKindNormal.Ptr()},
+				TaskListType:          taskListType.Ptr(),
+				IncludeTaskListStatus: true,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return desc.GetTaskListStatus().GetBacklogCountHint(), nil
+	}
+}
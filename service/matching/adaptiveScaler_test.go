@@ -0,0 +1,202 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+)
+
+type testAdaptiveScaler struct {
+	*adaptiveScaler
+	timeSource     clock.MockedTimeSource
+	enabled        bool
+	backlogs       map[int]int64
+	backlogErr     error
+	rootBacklog    int64
+	checkedBacklog []int
+}
+
+func newTestAdaptiveScaler(t *testing.T, minPartitions int) *testAdaptiveScaler {
+	taskListID, err := newTaskListID("domain-id", "tl", persistence.TaskListTypeDecision)
+	assert.NoError(t, err)
+	s := &testAdaptiveScaler{
+		timeSource: clock.NewMockedTimeSource(),
+		enabled:    true,
+		backlogs:   make(map[int]int64),
+	}
+	config := &taskListConfig{
+		NumWritePartitions:             func() int { return minPartitions },
+		EnableAdaptiveScaler:           func() bool { return s.enabled },
+		PartitionUpscaleRPS:            func() int { return 10 },
+		PartitionDownscaleFactor:       func() float64 { return 0.75 },
+		UpscaleSustainedDuration:       func() time.Duration { return time.Minute },
+		DownscaleSustainedDuration:     func() time.Duration { return 2 * time.Minute },
+		AdaptiveScalerUpdateInterval:   func() time.Duration { return 15 * time.Second },
+		AdaptiveScalerMaxPartitions:    func() int { return 8 },
+		PartitionConfigRefreshInterval: func() time.Duration { return 10 * time.Second },
+		forwarderConfig: forwarderConfig{
+			ForwarderMaxChildrenPerNode: func() int { return 20 },
+		},
+	}
+	s.adaptiveScaler = newAdaptiveScaler(
+		taskListID,
+		config,
+		s.timeSource,
+		log.NewNoop(),
+		metrics.NoopScope(metrics.Matching),
+		func(partition int) (int64, error) {
+			s.checkedBacklog = append(s.checkedBacklog, partition)
+			return s.backlogs[partition], s.backlogErr
+		},
+		func() int64 { return s.rootBacklog },
+	)
+	return s
+}
+
+// step advances the time by the update interval and updates the scaler with the given per second rates
+func (s *testAdaptiveScaler) step(addRPS, dispatchRPS int64) {
+	interval := s.config.AdaptiveScalerUpdateInterval()
+	atomic.StoreInt64(&s.addCount, addRPS*int64(interval/time.Second))
+	atomic.StoreInt64(&s.dispatchCount, dispatchRPS*int64(interval/time.Second))
+	s.timeSource.Advance(interval)
+	s.update()
+}
+
+func TestAdaptiveScaler_Upscale(t *testing.T) {
+	s := newTestAdaptiveScaler(t, 1)
+	assert.Equal(t, 1, s.NumPartitions())
+
+	// 25 tasks per second need 3 partitions, which is only applied after a minute
+	for i := 0; i < 4; i++ {
+		s.step(25, 0)
+		assert.Equal(t, 1, s.NumPartitions())
+	}
+	s.step(25, 0)
+	assert.Equal(t, 3, s.NumPartitions())
+
+	// the rate of the root partition is a third of the total rate now
+	for i := 0; i < 10; i++ {
+		s.step(0, 9)
+		assert.Equal(t, 3, s.NumPartitions())
+	}
+
+	// the rate drops below the upscale rate before the upscale is applied
+	for i := 0; i < 3; i++ {
+		s.step(20, 0)
+	}
+	s.step(5, 0)
+	s.step(20, 0)
+	assert.Equal(t, 3, s.NumPartitions())
+
+	// the number of partitions is capped
+	for i := 0; i < 5; i++ {
+		s.step(1000, 0)
+	}
+	assert.Equal(t, 8, s.NumPartitions())
+}
+
+func TestAdaptiveScaler_MaxPartitionsCappedByForwarderChildren(t *testing.T) {
+	s := newTestAdaptiveScaler(t, 1)
+	s.config.ForwarderMaxChildrenPerNode = func() int { return 3 }
+	for i := 0; i < 5; i++ {
+		s.step(1000, 0)
+	}
+	assert.Equal(t, 4, s.NumPartitions())
+}
+
+func TestAdaptiveScaler_DownscaleAndDrain(t *testing.T) {
+	s := newTestAdaptiveScaler(t, 1)
+	for i := 0; i < 5; i++ {
+		s.step(25, 0)
+	}
+	assert.Equal(t, 3, s.NumPartitions())
+
+	// partitions aren't removed while the root partition has a backlog
+	s.rootBacklog = 10
+	for i := 0; i < 10; i++ {
+		s.step(0, 0)
+	}
+	assert.Equal(t, 3, s.NumPartitions())
+
+	s.rootBacklog = 0
+	for i := 0; i < 8; i++ {
+		s.step(0, 0)
+		assert.Equal(t, 3, s.NumPartitions())
+	}
+	s.backlogs[1] = 5
+	s.step(0, 0)
+	assert.Equal(t, 1, s.NumPartitions())
+	assert.Equal(t, 3, s.drainPartitionsUpTo)
+
+	s.checkedBacklog = nil
+	// clients are given two refresh intervals to stop writing to the drained partitions
+	s.step(0, 0)
+	assert.Empty(t, s.checkedBacklog)
+	s.step(0, 0)
+	assert.Equal(t, []int{2, 1}, s.checkedBacklog)
+	assert.Equal(t, 2, s.drainPartitionsUpTo)
+
+	s.backlogErr = errors.New("error")
+	s.backlogs[1] = 0
+	s.step(0, 0)
+	assert.Equal(t, 2, s.drainPartitionsUpTo)
+
+	s.backlogErr = nil
+	s.step(0, 0)
+	assert.Equal(t, 1, s.drainPartitionsUpTo)
+	s.checkedBacklog = nil
+	s.step(0, 0)
+	assert.Empty(t, s.checkedBacklog)
+}
+
+func TestAdaptiveScaler_Disabled(t *testing.T) {
+	s := newTestAdaptiveScaler(t, 2)
+	// partitions added before the scaler started are drained
+	assert.Equal(t, 2, s.NumPartitions())
+	assert.Equal(t, 8, s.drainPartitionsUpTo)
+	s.step(0, 0)
+	s.step(0, 0)
+	assert.Equal(t, []int{7, 6, 5, 4, 3, 2}, s.checkedBacklog)
+	assert.Equal(t, 2, s.drainPartitionsUpTo)
+
+	for i := 0; i < 5; i++ {
+		s.step(100, 0)
+	}
+	assert.Equal(t, 8, s.NumPartitions())
+
+	// clients go back to the dynamic config, so the partitions added by the scaler are drained
+	s.enabled = false
+	s.step(100, 0)
+	assert.Equal(t, 2, s.NumPartitions())
+	assert.Equal(t, 8, s.drainPartitionsUpTo)
+}
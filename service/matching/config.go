@@ -74,6 +74,30 @@ type (
 		// isolation configuration
 		EnableTasklistIsolation dynamicconfig.BoolPropertyFnWithDomainFilter
 		AllIsolationGroups      []string
+
+		// priority configuration
+		EnableTaskPriority  dynamicconfig.BoolPropertyFnWithTaskListInfoFilters
+		DefaultTaskPriority dynamicconfig.IntPropertyFnWithTaskListInfoFilters
+		TaskPriorityWeights dynamicconfig.MapPropertyFn
+
+		// fairness configuration
+		EnableTaskFairness             dynamicconfig.BoolPropertyFnWithTaskListInfoFilters
+		FairnessKeyWorkflowIDDelimiter dynamicconfig.StringPropertyFnWithDomainFilter
+		TaskFairnessKeyWeights         dynamicconfig.MapPropertyFn
+
+		// adaptive scaler configuration
+		EnableAdaptiveScaler           dynamicconfig.BoolPropertyFnWithTaskListInfoFilters
+		PartitionUpscaleRPS            dynamicconfig.IntPropertyFnWithTaskListInfoFilters
+		PartitionDownscaleFactor       dynamicconfig.FloatPropertyFn
+		UpscaleSustainedDuration       dynamicconfig.DurationPropertyFnWithTaskListInfoFilters
+		DownscaleSustainedDuration     dynamicconfig.DurationPropertyFnWithTaskListInfoFilters
+		AdaptiveScalerUpdateInterval   dynamicconfig.DurationPropertyFnWithTaskListInfoFilters
+		AdaptiveScalerMaxPartitions    dynamicconfig.IntPropertyFnWithTaskListInfoFilters
+		PartitionConfigRefreshInterval dynamicconfig.DurationPropertyFn
+
+		// activity rate limit configuration
+		ActivityTypeDispatchRPS dynamicconfig.IntPropertyFnWithActivityTypeFilter
+
 		// hostname info
 		HostName string
 	}
@@ -107,6 +131,23 @@ type (
 		// isolation configuration
 		EnableTasklistIsolation func() bool
 		AllIsolationGroups      []string
+		// priority configuration
+		EnableTaskPriority  func() bool
+		DefaultTaskPriority func() int
+		TaskPriorityWeights dynamicconfig.MapPropertyFn
+		// fairness configuration
+		EnableTaskFairness             func() bool
+		FairnessKeyWorkflowIDDelimiter func() string
+		TaskFairnessKeyWeights         dynamicconfig.MapPropertyFn
+		// adaptive scaler configuration
+		EnableAdaptiveScaler           func() bool
+		PartitionUpscaleRPS            func() int
+		PartitionDownscaleFactor       func() float64
+		UpscaleSustainedDuration       func() time.Duration
+		DownscaleSustainedDuration     func() time.Duration
+		AdaptiveScalerUpdateInterval   func() time.Duration
+		AdaptiveScalerMaxPartitions    func() int
+		PartitionConfigRefreshInterval func() time.Duration
 		// hostname
 		HostName string
 	}
@@ -146,6 +187,21 @@ func NewConfig(dc *dynamicconfig.Collection, hostName string) *Config {
 		EnableTasklistIsolation:         dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableTasklistIsolation),
 		AllIsolationGroups:              mapIGs(dc.GetListProperty(dynamicconfig.AllIsolationGroups)()),
 		AsyncTaskDispatchTimeout:        dc.GetDurationPropertyFilteredByTaskListInfo(dynamicconfig.AsyncTaskDispatchTimeout),
+		EnableTaskPriority:              dc.GetBoolPropertyFilteredByTaskListInfo(dynamicconfig.MatchingEnableTaskPriority),
+		DefaultTaskPriority:             dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingDefaultTaskPriority),
+		TaskPriorityWeights:             dc.GetMapProperty(dynamicconfig.MatchingTaskPriorityWeights),
+		EnableTaskFairness:              dc.GetBoolPropertyFilteredByTaskListInfo(dynamicconfig.MatchingEnableTaskFairness),
+		FairnessKeyWorkflowIDDelimiter:  dc.GetStringPropertyFilteredByDomain(dynamicconfig.MatchingFairnessKeyWorkflowIDDelimiter),
+		TaskFairnessKeyWeights:          dc.GetMapProperty(dynamicconfig.MatchingTaskFairnessKeyWeights),
+		EnableAdaptiveScaler:            dc.GetBoolPropertyFilteredByTaskListInfo(dynamicconfig.MatchingEnableAdaptiveScaler),
+		PartitionUpscaleRPS:             dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingPartitionUpscaleRPS),
+		PartitionDownscaleFactor:        dc.GetFloat64Property(dynamicconfig.MatchingPartitionDownscaleFactor),
+		UpscaleSustainedDuration:        dc.GetDurationPropertyFilteredByTaskListInfo(dynamicconfig.MatchingPartitionUpscaleSustainedDuration),
+		DownscaleSustainedDuration:      dc.GetDurationPropertyFilteredByTaskListInfo(dynamicconfig.MatchingPartitionDownscaleSustainedDuration),
+		AdaptiveScalerUpdateInterval:    dc.GetDurationPropertyFilteredByTaskListInfo(dynamicconfig.MatchingAdaptiveScalerUpdateInterval),
+		AdaptiveScalerMaxPartitions:     dc.GetIntPropertyFilteredByTaskListInfo(dynamicconfig.MatchingAdaptiveScalerMaxPartitions),
+		PartitionConfigRefreshInterval:  dc.GetDurationProperty(dynamicconfig.MatchingPartitionConfigRefreshInterval),
+		ActivityTypeDispatchRPS:         dc.GetIntPropertyFilteredByActivityType(dynamicconfig.MatchingActivityTypeDispatchRPS),
 		HostName:                        hostName,
 	}
 }
@@ -215,6 +271,44 @@ func newTaskListConfig(id *taskListID, config *Config, domainCache cache.DomainC
 		AsyncTaskDispatchTimeout: func() time.Duration {
 			return config.AsyncTaskDispatchTimeout(domainName, taskListName, taskType)
 		},
+		EnableTaskPriority: func() bool {
+			return config.EnableTaskPriority(domainName, taskListName, taskType)
+		},
+		DefaultTaskPriority: func() int {
+			return config.DefaultTaskPriority(domainName, taskListName, taskType)
+		},
+		TaskPriorityWeights: config.TaskPriorityWeights,
+		EnableTaskFairness: func() bool {
+			return config.EnableTaskFairness(domainName, taskListName, taskType)
+		},
+		FairnessKeyWorkflowIDDelimiter: func() string {
+			return config.FairnessKeyWorkflowIDDelimiter(domainName)
+		},
+		TaskFairnessKeyWeights: config.TaskFairnessKeyWeights,
+		EnableAdaptiveScaler: func() bool {
+			return config.EnableAdaptiveScaler(domainName, taskListName, taskType)
+		},
+		PartitionUpscaleRPS: func() int {
+			return config.PartitionUpscaleRPS(domainName, taskListName, taskType)
+		},
+		PartitionDownscaleFactor: func() float64 {
+			return config.PartitionDownscaleFactor()
+		},
+		UpscaleSustainedDuration: func() time.Duration {
+			return config.UpscaleSustainedDuration(domainName, taskListName, taskType)
+		},
+		DownscaleSustainedDuration: func() time.Duration {
+			return config.DownscaleSustainedDuration(domainName, taskListName, taskType)
+		},
+		AdaptiveScalerUpdateInterval: func() time.Duration {
+			return config.AdaptiveScalerUpdateInterval(domainName, taskListName, taskType)
+		},
+		AdaptiveScalerMaxPartitions: func() int {
+			return common.MaxInt(1, config.AdaptiveScalerMaxPartitions(domainName, taskListName, taskType))
+		},
+		PartitionConfigRefreshInterval: func() time.Duration {
+			return config.PartitionConfigRefreshInterval()
+		},
 		forwarderConfig: forwarderConfig{
 			ForwarderMaxOutstandingPolls: func() int {
 				return config.ForwarderMaxOutstandingPolls(domainName, taskListName, taskType)
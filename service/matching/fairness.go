@@ -0,0 +1,119 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"strings"
+
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+)
+
+type (
+	// fairTaskQueue holds the tasks in sub-queues by fairness key and returns them by weighted round robin across
+	// the keys: a key gets as many tasks dispatched in a row as its weight, then it's the turn of the next key.
+	// Keys are added to the end of the round when they get their first task and removed when they have no tasks
+	// left, so a key flooding the task list only delays the other keys by its weight.
+	fairTaskQueue struct {
+		weightOf func(string) int
+
+		queues  map[string][]*persistence.TaskInfo
+		keys    []string // the keys with tasks in round robin order
+		next    int      // index of the key whose turn it is
+		credits int      // tasks left to dispatch in the turn of the key, 0 if its turn hasn't started
+		size    int
+	}
+)
+
+func newFairTaskQueue(weightOf func(string) int) *fairTaskQueue {
+	return &fairTaskQueue{
+		weightOf: weightOf,
+		queues:   make(map[string][]*persistence.TaskInfo),
+	}
+}
+
+// getTaskFairnessKey returns the fairness key set in the partition config of the task, or the workflow ID prefix
+// before the delimiter. The whole workflow ID is used if the delimiter is empty or not found.
+func getTaskFairnessKey(task *persistence.TaskInfo, workflowIDDelimiter string) string {
+	if key := task.PartitionConfig[partition.FairnessKey]; key != "" {
+		return key
+	}
+	if workflowIDDelimiter != "" {
+		if i := strings.Index(task.WorkflowID, workflowIDDelimiter); i >= 0 {
+			return task.WorkflowID[:i]
+		}
+	}
+	return task.WorkflowID
+}
+
+// Add adds a task to the sub-queue of the fairness key
+func (q *fairTaskQueue) Add(key string, task *persistence.TaskInfo) {
+	queue := q.queues[key]
+	if len(queue) == 0 {
+		q.keys = append(q.keys, key)
+	}
+	q.queues[key] = append(queue, task)
+	q.size++
+}
+
+// Len returns the number of tasks in the queue
+func (q *fairTaskQueue) Len() int {
+	return q.size
+}
+
+// Keys returns the number of fairness keys with tasks
+func (q *fairTaskQueue) Keys() int {
+	return len(q.keys)
+}
+
+// Next removes and returns the next task to dispatch, or nil if the queue is empty
+func (q *fairTaskQueue) Next() *persistence.TaskInfo {
+	if q.size == 0 {
+		return nil
+	}
+	key := q.keys[q.next]
+	if q.credits <= 0 {
+		q.credits = q.weightOf(key)
+	}
+	queue := q.queues[key]
+	task := queue[0]
+	queue[0] = nil
+	q.credits--
+	q.size--
+
+	if len(queue) == 1 {
+		// the key has no task left, the next key takes its place in the round
+		delete(q.queues, key)
+		q.keys = append(q.keys[:q.next], q.keys[q.next+1:]...)
+		q.credits = 0
+	} else {
+		q.queues[key] = queue[1:]
+		if q.credits == 0 {
+			q.next++
+		}
+	}
+	if q.next >= len(q.keys) {
+		q.next = 0
+	}
+	return task
+}
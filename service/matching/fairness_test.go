@@ -0,0 +1,122 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+)
+
+func TestGetTaskFairnessKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		task      *persistence.TaskInfo
+		delimiter string
+		key       string
+	}{
+		{"workflow ID", &persistence.TaskInfo{WorkflowID: "tenant-1:order-1"}, "", "tenant-1:order-1"},
+		{"workflow ID prefix", &persistence.TaskInfo{WorkflowID: "tenant-1:order-1"}, ":", "tenant-1"},
+		{"delimiter not found", &persistence.TaskInfo{WorkflowID: "tenant-1/order-1"}, ":", "tenant-1/order-1"},
+		{
+			"fairness key",
+			&persistence.TaskInfo{WorkflowID: "tenant-1:order-1", PartitionConfig: map[string]string{partition.FairnessKey: "tenant-2"}},
+			":",
+			"tenant-2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.key, getTaskFairnessKey(tc.task, tc.delimiter))
+		})
+	}
+}
+
+func TestFairTaskQueue(t *testing.T) {
+	weights := map[string]int{"b": 2}
+	queue := newFairTaskQueue(func(key string) int {
+		if weight, ok := weights[key]; ok {
+			return weight
+		}
+		return 1
+	})
+	require.Nil(t, queue.Next())
+
+	// key a floods the queue before b and c get their tasks
+	for i := 0; i < 5; i++ {
+		queue.Add("a", &persistence.TaskInfo{TaskID: int64(i)})
+	}
+	for i := 10; i < 13; i++ {
+		queue.Add("b", &persistence.TaskInfo{TaskID: int64(i)})
+	}
+	queue.Add("c", &persistence.TaskInfo{TaskID: 20})
+	require.Equal(t, 9, queue.Len())
+	require.Equal(t, 3, queue.Keys())
+
+	assert.Equal(t, int64(0), queue.Next().TaskID)
+	assert.Equal(t, int64(10), queue.Next().TaskID)
+	assert.Equal(t, int64(11), queue.Next().TaskID)
+	assert.Equal(t, int64(20), queue.Next().TaskID)
+	assert.Equal(t, 2, queue.Keys())
+
+	// a key getting new tasks after it was emptied is added to the end of the round
+	queue.Add("c", &persistence.TaskInfo{TaskID: 21})
+	var taskIDs []int64
+	for queue.Len() > 0 {
+		taskIDs = append(taskIDs, queue.Next().TaskID)
+	}
+	assert.Equal(t, []int64{1, 12, 21, 2, 3, 4}, taskIDs)
+	assert.Equal(t, 0, queue.Keys())
+	assert.Nil(t, queue.Next())
+}
+
+func TestPriorityTaskBuffer_Fairness(t *testing.T) {
+	buffer := newPriorityTaskBuffer(&priorityTaskBufferOptions{
+		PriorityWeights: func() map[int]int { return map[int]int{1: 2, 2: 1} },
+		DefaultPriority: func() int { return 2 },
+		Priority:        getTaskPriority,
+		FairnessKey: func(task *persistence.TaskInfo) string {
+			return getTaskFairnessKey(task, ":")
+		},
+		FairnessKeyWeights: func() map[string]int { return nil },
+	})
+	for i := 0; i < 3; i++ {
+		buffer.Add(&persistence.TaskInfo{TaskID: int64(i), WorkflowID: "noisy:wf", PartitionConfig: map[string]string{partition.PriorityKey: "1"}})
+	}
+	buffer.Add(&persistence.TaskInfo{TaskID: 10, WorkflowID: "quiet:wf", PartitionConfig: map[string]string{partition.PriorityKey: "1"}})
+	buffer.Add(&persistence.TaskInfo{TaskID: 20, WorkflowID: "noisy:wf"})
+	buffer.Add(&persistence.TaskInfo{TaskID: 21, WorkflowID: "other:wf"})
+	assert.Equal(t, 4, buffer.FairnessKeys())
+
+	var taskIDs []int64
+	for buffer.Len() > 0 {
+		taskIDs = append(taskIDs, buffer.Next().TaskID)
+	}
+	assert.Equal(t, []int64{0, 10, 20, 1, 2, 21}, taskIDs)
+	assert.Equal(t, 0, buffer.FairnessKeys())
+}
@@ -92,6 +92,7 @@ type (
 		versionChecker       client.VersionChecker
 		membershipResolver   membership.Resolver
 		partitioner          partition.Partitioner
+		activityLimiter      *activityTypeRateLimiter // shared by the activity task lists of the host
 	}
 
 	// HistoryInfo consists of two integer regarding the history size and history count
@@ -149,6 +150,7 @@ func NewEngine(taskManager persistence.TaskManager,
 		versionChecker:       client.NewVersionChecker(),
 		membershipResolver:   resolver,
 		partitioner:          partitioner,
+		activityLimiter:      newActivityTypeRateLimiter(config.ActivityTypeDispatchRPS, resolver),
 	}
 }
 
@@ -886,6 +888,18 @@ func (e *matchingEngineImpl) getAllPartitions(
 
 	nWritePartitions := e.config.NumTasklistWritePartitions
 	n := nWritePartitions(request.GetDomain(), rootPartition, taskListType)
+	if e.config.EnableAdaptiveScaler(request.GetDomain(), rootPartition, taskListType) {
+		// the number of partitions is owned by the root partition, which is loaded to start scaling it
+		rootID, err := newTaskListID(domainID, rootPartition, taskListType)
+		if err != nil {
+			return partitionKeys, err
+		}
+		tlMgr, err := e.getTaskListManager(rootID, types.TaskListKindNormal.Ptr())
+		if err != nil {
+			return partitionKeys, err
+		}
+		n = tlMgr.NumPartitions()
+	}
 	if n <= 0 {
 		return partitionKeys, nil
 	}
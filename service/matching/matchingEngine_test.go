@@ -165,6 +165,7 @@ func newMatchingEngine(
 		config:          config,
 		domainCache:     mockDomainCache,
 		partitioner:     partitioner,
+		activityLimiter: newActivityTypeRateLimiter(config.ActivityTypeDispatchRPS, nil),
 	}
 }
 
@@ -1287,6 +1288,40 @@ func (s *matchingEngineSuite) UnloadTasklistOnIsolationConfigChange(taskType int
 	s.Contains(err.Error(), errShutdown.Error())
 }
 
+func (s *matchingEngineSuite) TestGetAllPartitionsWithAdaptiveScaler() {
+	testParam := newTestParam(persistence.TaskListTypeDecision)
+	s.mockDomainCache.EXPECT().GetDomainID(matchingTestDomainName).Return(testParam.DomainID, nil).AnyTimes()
+	s.matchingEngine.config.NumTasklistWritePartitions = dynamicconfig.GetIntPropertyFilteredByTaskListInfo(2)
+	request := &types.MatchingListTaskListPartitionsRequest{
+		Domain:   matchingTestDomainName,
+		TaskList: testParam.TaskList,
+	}
+
+	partitions, err := s.matchingEngine.getAllPartitions(request, persistence.TaskListTypeDecision)
+	s.NoError(err)
+	s.Equal([]string{testParam.TaskList.Name, "/__cadence_sys/" + testParam.TaskList.Name + "/1"}, partitions)
+	s.Empty(s.matchingEngine.getTaskLists(100))
+
+	s.matchingEngine.config.EnableAdaptiveScaler = dynamicconfig.GetBoolPropertyFnFilteredByTaskListInfo(true)
+	partitions, err = s.matchingEngine.getAllPartitions(request, persistence.TaskListTypeDecision)
+	s.NoError(err)
+	s.Len(partitions, 2)
+
+	// the number of partitions is owned by the root partition, which is loaded by the first call
+	tlMgr, ok := s.matchingEngine.getTaskLists(100)[0].(*taskListManagerImpl)
+	s.True(ok)
+	s.NotNil(tlMgr.adaptiveScaler)
+	tlMgr.adaptiveScaler.numPartitions = 4
+	partitions, err = s.matchingEngine.getAllPartitions(request, persistence.TaskListTypeDecision)
+	s.NoError(err)
+	s.Equal([]string{
+		testParam.TaskList.Name,
+		"/__cadence_sys/" + testParam.TaskList.Name + "/1",
+		"/__cadence_sys/" + testParam.TaskList.Name + "/2",
+		"/__cadence_sys/" + testParam.TaskList.Name + "/3",
+	}, partitions)
+}
+
 func (s *matchingEngineSuite) TestDrainActivityBacklogNoPollersIsolationGroup() {
 	s.DrainBacklogNoPollersIsolationGroup(persistence.TaskListTypeActivity)
 }
@@ -1637,12 +1672,25 @@ func newTestTaskListID(domainID string, name string, taskType int) *taskListID {
 	return result
 }
 
+// newTestPersistedTaskListID returns the ID of a task list stored by testTaskManager, the names of
+// the priority backlogs aren't valid task list names so they're used as is
+func newTestPersistedTaskListID(domainID string, name string, taskType int) *taskListID {
+	if result, err := newTaskListID(domainID, name, taskType); err == nil {
+		return result
+	}
+	return &taskListID{
+		qualifiedTaskListName: qualifiedTaskListName{name: name, baseName: name},
+		domainID:              domainID,
+		taskType:              taskType,
+	}
+}
+
 // LeaseTaskList provides a mock function with given fields: ctx, request
 func (m *testTaskManager) LeaseTaskList(
 	_ context.Context,
 	request *persistence.LeaseTaskListRequest,
 ) (*persistence.LeaseTaskListResponse, error) {
-	tlm := m.getTaskListManager(newTestTaskListID(request.DomainID, request.TaskList, request.TaskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(request.DomainID, request.TaskList, request.TaskType))
 	tlm.Lock()
 	defer tlm.Unlock()
 	tlm.rangeID++
@@ -1668,7 +1716,7 @@ func (m *testTaskManager) UpdateTaskList(
 	m.logger.Debug(fmt.Sprintf("UpdateTaskList taskListInfo=%v, ackLevel=%v", request.TaskListInfo, request.TaskListInfo.AckLevel))
 
 	tli := request.TaskListInfo
-	tlm := m.getTaskListManager(newTestTaskListID(tli.DomainID, tli.Name, tli.TaskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(tli.DomainID, tli.Name, tli.TaskType))
 
 	tlm.Lock()
 	defer tlm.Unlock()
@@ -1692,7 +1740,7 @@ func (m *testTaskManager) CompleteTask(
 	}
 
 	tli := request.TaskList
-	tlm := m.getTaskListManager(newTestTaskListID(tli.DomainID, tli.Name, tli.TaskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(tli.DomainID, tli.Name, tli.TaskType))
 
 	tlm.Lock()
 	defer tlm.Unlock()
@@ -1706,7 +1754,7 @@ func (m *testTaskManager) CompleteTasksLessThan(
 	_ context.Context,
 	request *persistence.CompleteTasksLessThanRequest,
 ) (*persistence.CompleteTasksLessThanResponse, error) {
-	tlm := m.getTaskListManager(newTestTaskListID(request.DomainID, request.TaskListName, request.TaskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(request.DomainID, request.TaskListName, request.TaskType))
 	tlm.Lock()
 	defer tlm.Unlock()
 	rowsDeleted := 0
@@ -1736,7 +1784,7 @@ func (m *testTaskManager) DeleteTaskList(
 ) error {
 	m.Lock()
 	defer m.Unlock()
-	key := newTestTaskListID(request.DomainID, request.TaskListName, request.TaskListType)
+	key := newTestPersistedTaskListID(request.DomainID, request.TaskListName, request.TaskListType)
 	delete(m.taskLists, *key)
 	return nil
 }
@@ -1751,7 +1799,7 @@ func (m *testTaskManager) CreateTasks(
 	taskType := request.TaskListInfo.TaskType
 	rangeID := request.TaskListInfo.RangeID
 
-	tlm := m.getTaskListManager(newTestTaskListID(domainID, taskList, taskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(domainID, taskList, taskType))
 	tlm.Lock()
 	defer tlm.Unlock()
 
@@ -1805,7 +1853,7 @@ func (m *testTaskManager) GetTasks(
 ) (*persistence.GetTasksResponse, error) {
 	m.logger.Debug(fmt.Sprintf("testTaskManager.GetTasks readLevel=%v, maxReadLevel=%v", request.ReadLevel, *request.MaxReadLevel))
 
-	tlm := m.getTaskListManager(newTestTaskListID(request.DomainID, request.TaskList, request.TaskType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(request.DomainID, request.TaskList, request.TaskType))
 	tlm.Lock()
 	defer tlm.Unlock()
 	var tasks []*persistence.TaskInfo
@@ -1827,7 +1875,7 @@ func (m *testTaskManager) GetTasks(
 }
 
 func (m *testTaskManager) GetTaskListSize(_ context.Context, request *persistence.GetTaskListSizeRequest) (*persistence.GetTaskListSizeResponse, error) {
-	tlm := m.getTaskListManager(newTestTaskListID(request.DomainID, request.TaskListName, request.TaskListType))
+	tlm := m.getTaskListManager(newTestPersistedTaskListID(request.DomainID, request.TaskListName, request.TaskListType))
 	tlm.Lock()
 	defer tlm.Unlock()
 	count := int64(0)
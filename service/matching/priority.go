@@ -0,0 +1,181 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+)
+
+// defaultTaskPriorityWeights are used when matching.taskPriorityWeights is invalid
+var defaultTaskPriorityWeights = map[int]int{1: 16, 2: 8, 3: 4, 4: 2, 5: 1}
+
+type (
+	// priorityTaskBufferOptions are the functions used by priorityTaskBuffer to classify the tasks
+	priorityTaskBufferOptions struct {
+		PriorityWeights func() map[int]int
+		DefaultPriority func() int
+		// Priority returns the priority of the task, or false if the default priority should be used
+		Priority           func(*persistence.TaskInfo) (int, bool)
+		FairnessKey        func(*persistence.TaskInfo) string
+		FairnessKeyWeights func() map[string]int
+	}
+
+	// priorityTaskBuffer holds the tasks read ahead by a dispatcher of the task reader and returns them by
+	// weighted round robin across the priorities. In every round a priority gets as many tasks dispatched as its
+	// weight, and the priorities with a lower value go first, so high priority tasks are dispatched before the
+	// others without starving them. Inside a priority, the tasks are dispatched by weighted round robin across
+	// their fairness keys, see fairTaskQueue.
+	// It's only used by the dispatcher goroutine, so it isn't thread safe.
+	priorityTaskBuffer struct {
+		options *priorityTaskBufferOptions
+
+		weights            map[int]int    // priority weights of the current round
+		fairnessKeyWeights map[string]int // fairness key weights of the current round
+		credits            map[int]int    // tasks left to dispatch for each priority in the current round
+		queues             map[int]*fairTaskQueue
+		size               int
+	}
+)
+
+func newPriorityTaskBuffer(options *priorityTaskBufferOptions) *priorityTaskBuffer {
+	return &priorityTaskBuffer{
+		options:            options,
+		weights:            options.PriorityWeights(),
+		fairnessKeyWeights: options.FairnessKeyWeights(),
+		credits:            make(map[int]int),
+		queues:             make(map[int]*fairTaskQueue),
+	}
+}
+
+// getTaskPriority returns the priority set in the partition config of the task, if any
+func getTaskPriority(task *persistence.TaskInfo) (int, bool) {
+	value, ok := task.PartitionConfig[partition.PriorityKey]
+	if !ok {
+		return 0, false
+	}
+	priority, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, false
+	}
+	return priority, true
+}
+
+// Add adds a task to the buffer. Tasks without a priority or with a priority which has no weight
+// get the default priority.
+func (b *priorityTaskBuffer) Add(task *persistence.TaskInfo) {
+	priority, ok := b.options.Priority(task)
+	if _, known := b.weights[priority]; !ok || !known {
+		priority = b.options.DefaultPriority()
+	}
+	queue, ok := b.queues[priority]
+	if !ok {
+		queue = newFairTaskQueue(b.fairnessKeyWeightOf)
+		b.queues[priority] = queue
+		// don't wait for the next round to dispatch the tasks of a new priority
+		b.credits[priority] = b.weightOf(priority)
+	}
+	queue.Add(b.options.FairnessKey(task), task)
+	b.size++
+}
+
+// Len returns the number of tasks in the buffer
+func (b *priorityTaskBuffer) Len() int {
+	return b.size
+}
+
+// Next removes and returns the next task to dispatch, or nil if the buffer is empty
+func (b *priorityTaskBuffer) Next() *persistence.TaskInfo {
+	if b.size == 0 {
+		return nil
+	}
+	priorities := b.priorities()
+	for {
+		for _, priority := range priorities {
+			queue := b.queues[priority]
+			if queue.Len() == 0 || b.credits[priority] <= 0 {
+				continue
+			}
+			b.credits[priority]--
+			b.size--
+			return queue.Next()
+		}
+		b.newRound()
+	}
+}
+
+// Backlog returns the number of buffered tasks by priority, including the priorities which had tasks before
+func (b *priorityTaskBuffer) Backlog() map[int]int {
+	backlog := make(map[int]int, len(b.queues))
+	for priority, queue := range b.queues {
+		backlog[priority] = queue.Len()
+	}
+	return backlog
+}
+
+// FairnessKeys returns the number of fairness keys with buffered tasks
+func (b *priorityTaskBuffer) FairnessKeys() int {
+	keys := 0
+	for _, queue := range b.queues {
+		keys += queue.Keys()
+	}
+	return keys
+}
+
+func (b *priorityTaskBuffer) newRound() {
+	b.weights = b.options.PriorityWeights()
+	b.fairnessKeyWeights = b.options.FairnessKeyWeights()
+	for priority := range b.queues {
+		b.credits[priority] = b.weightOf(priority)
+	}
+}
+
+func (b *priorityTaskBuffer) weightOf(priority int) int {
+	weight := b.weights[priority]
+	if weight <= 0 {
+		// the default priority or a priority whose weight was removed, make sure it's not starved
+		weight = 1
+	}
+	return weight
+}
+
+func (b *priorityTaskBuffer) fairnessKeyWeightOf(key string) int {
+	weight := b.fairnessKeyWeights[key]
+	if weight <= 0 {
+		weight = 1
+	}
+	return weight
+}
+
+func (b *priorityTaskBuffer) priorities() []int {
+	priorities := make([]int, 0, len(b.queues))
+	for priority := range b.queues {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	return priorities
+}
@@ -0,0 +1,285 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+)
+
+// priorityBacklogNamePrefix is the prefix of the last segment of the name of a priority backlog. The name doesn't
+// end with a partition ID, so it's rejected as a task list name by the matching APIs.
+const priorityBacklogNamePrefix = "priority-"
+
+type (
+	// priorityBacklog is the persisted backlog of the tasks of a priority other than the default one. Each priority
+	// is stored in its own task list, so the tasks of a priority are read as soon as they're written instead of
+	// after all the older tasks of the task list. The tasks read from all the backlogs of a task list are dispatched
+	// by the dispatchers of its default backlog, by weighted round robin across the priorities.
+	priorityBacklog struct {
+		priority       int
+		db             *taskListDB
+		taskWriter     *taskWriter
+		taskReader     *taskReader
+		taskAckManager messaging.AckManager
+	}
+
+	// priorityBacklogs holds the priority backlogs of a task list. The backlog of a priority is opened when the
+	// first task of the priority is written to persistence, or when the task list is loaded if the backlog has tasks.
+	priorityBacklogs struct {
+		sync.Mutex
+		tlMgr           *taskListManagerImpl
+		isolationGroups []string
+		backlogs        map[int]*priorityBacklog
+		readers         atomic.Value // []*taskReader, read by the dispatchers without locking
+		stopped         bool
+	}
+
+	// backlogTaskBuffer is the priority buffer of a dispatcher, it keeps track of the backlog each task was read
+	// from so that the task is dispatched and completed by the reader of its backlog.
+	// It's only used by the dispatcher goroutine, so it isn't thread safe.
+	backlogTaskBuffer struct {
+		buffer  *priorityTaskBuffer
+		readers map[*persistence.TaskInfo]*taskReader
+		counts  map[*taskReader]int
+	}
+)
+
+// priorityBacklogName returns the name of the task list storing the backlog of a priority of a task list
+func priorityBacklogName(name string, priority int) string {
+	return fmt.Sprintf("%v%v/%v%v", common.ReservedTaskListPrefix, name, priorityBacklogNamePrefix, priority)
+}
+
+func newPriorityBacklogDB(tlMgr *taskListManagerImpl, priority int) *taskListDB {
+	return newTaskListDB(
+		tlMgr.db.store,
+		tlMgr.taskListID.domainID,
+		tlMgr.domainName,
+		priorityBacklogName(tlMgr.taskListID.name, priority),
+		tlMgr.taskListID.taskType,
+		int(tlMgr.taskListKind),
+		tlMgr.logger,
+	)
+}
+
+func newPriorityBacklog(tlMgr *taskListManagerImpl, priority int, isolationGroups []string) *priorityBacklog {
+	db := newPriorityBacklogDB(tlMgr, priority)
+	taskAckManager := messaging.NewAckManager(tlMgr.logger)
+	taskWriter := newBacklogTaskWriter(tlMgr, db, taskAckManager)
+	taskReader := newBacklogTaskReader(
+		tlMgr,
+		db,
+		taskWriter,
+		newTaskGC(db, tlMgr.config),
+		taskAckManager,
+		tlMgr.scope.Tagged(metrics.TaskPriorityTag(priority)),
+		isolationGroups,
+		tlMgr.taskReader.dispatchNotifyC,
+	)
+	taskReader.pumpOnly = true
+	return &priorityBacklog{
+		priority:       priority,
+		db:             db,
+		taskWriter:     taskWriter,
+		taskReader:     taskReader,
+		taskAckManager: taskAckManager,
+	}
+}
+
+func newPriorityBacklogs(tlMgr *taskListManagerImpl, isolationGroups []string) *priorityBacklogs {
+	b := &priorityBacklogs{
+		tlMgr:           tlMgr,
+		isolationGroups: isolationGroups,
+		backlogs:        make(map[int]*priorityBacklog),
+	}
+	b.readers.Store([]*taskReader(nil))
+	return b
+}
+
+// Start opens the priority backlogs which have tasks, so they're drained even if task priority was disabled
+// since they were written.
+func (b *priorityBacklogs) Start() error {
+	for _, priority := range b.priorities() {
+		size, err := newPriorityBacklogDB(b.tlMgr, priority).GetTaskListSize(0)
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			continue
+		}
+		if _, err := b.Get(priority); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops the priority backlogs
+func (b *priorityBacklogs) Stop() {
+	b.Lock()
+	defer b.Unlock()
+	b.stopped = true
+	for _, backlog := range b.backlogs {
+		backlog.taskWriter.Stop()
+		backlog.taskReader.Stop()
+	}
+}
+
+// Readers returns the readers of the opened priority backlogs
+func (b *priorityBacklogs) Readers() []*taskReader {
+	return b.readers.Load().([]*taskReader)
+}
+
+// BacklogCount returns the number of tasks in the opened priority backlogs, by priority
+func (b *priorityBacklogs) BacklogCount() map[int]int64 {
+	b.Lock()
+	defer b.Unlock()
+	counts := make(map[int]int64, len(b.backlogs))
+	for priority, backlog := range b.backlogs {
+		counts[priority] = backlog.taskAckManager.GetBacklogCount()
+	}
+	return counts
+}
+
+// GetTaskListSize returns the number of tasks in the opened priority backlogs from persistence, by priority
+func (b *priorityBacklogs) GetTaskListSize() (map[int]int64, error) {
+	b.Lock()
+	backlogs := make([]*priorityBacklog, 0, len(b.backlogs))
+	for _, backlog := range b.backlogs {
+		backlogs = append(backlogs, backlog)
+	}
+	b.Unlock()
+
+	sizes := make(map[int]int64, len(backlogs))
+	for _, backlog := range backlogs {
+		size, err := backlog.db.GetTaskListSize(backlog.taskAckManager.GetAckLevel())
+		if err != nil {
+			return nil, err
+		}
+		sizes[backlog.priority] = size
+	}
+	return sizes, nil
+}
+
+// Get returns the backlog of the priority, opening it if needed
+func (b *priorityBacklogs) Get(priority int) (*priorityBacklog, error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.stopped {
+		return nil, errShutdown
+	}
+	if backlog, ok := b.backlogs[priority]; ok {
+		return backlog, nil
+	}
+	backlog := newPriorityBacklog(b.tlMgr, priority, b.isolationGroups)
+	if err := backlog.taskWriter.Start(); err != nil {
+		return nil, err
+	}
+	backlog.taskReader.Start()
+	b.backlogs[priority] = backlog
+
+	priorities := make([]int, 0, len(b.backlogs))
+	for priority := range b.backlogs {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	readers := make([]*taskReader, 0, len(priorities))
+	for _, priority := range priorities {
+		readers = append(readers, b.backlogs[priority].taskReader)
+	}
+	b.readers.Store(readers)
+	return backlog, nil
+}
+
+// priorities returns the priorities which may have a backlog, they're the priorities with a weight, including
+// the default weights in case they were overridden since the tasks were written
+func (b *priorityBacklogs) priorities() []int {
+	var priorities []int
+	weights := b.tlMgr.taskReader.getTaskPriorityWeights()
+	for priority := range weights {
+		priorities = append(priorities, priority)
+	}
+	for priority := range defaultTaskPriorityWeights {
+		if _, ok := weights[priority]; !ok {
+			priorities = append(priorities, priority)
+		}
+	}
+	sort.Ints(priorities)
+	return priorities
+}
+
+func newBacklogTaskBuffer(buffer *priorityTaskBuffer) *backlogTaskBuffer {
+	return &backlogTaskBuffer{
+		buffer:  buffer,
+		readers: make(map[*persistence.TaskInfo]*taskReader),
+		counts:  make(map[*taskReader]int),
+	}
+}
+
+// Add adds a task read by the reader to the buffer
+func (b *backlogTaskBuffer) Add(reader *taskReader, task *persistence.TaskInfo) {
+	b.buffer.Add(task)
+	b.readers[task] = reader
+	b.counts[reader]++
+}
+
+// Next removes and returns the next task to dispatch and the reader of its backlog
+func (b *backlogTaskBuffer) Next() (*taskReader, *persistence.TaskInfo) {
+	task := b.buffer.Next()
+	if task == nil {
+		return nil, nil
+	}
+	reader := b.readers[task]
+	delete(b.readers, task)
+	if b.counts[reader]--; b.counts[reader] == 0 {
+		delete(b.counts, reader)
+	}
+	return reader, task
+}
+
+// Len returns the number of tasks in the buffer
+func (b *backlogTaskBuffer) Len() int {
+	return b.buffer.Len()
+}
+
+// Count returns the number of tasks of the reader in the buffer
+func (b *backlogTaskBuffer) Count(reader *taskReader) int {
+	return b.counts[reader]
+}
+
+// Backlog returns the number of buffered tasks by priority
+func (b *backlogTaskBuffer) Backlog() map[int]int {
+	return b.buffer.Backlog()
+}
+
+// FairnessKeys returns the number of fairness keys with buffered tasks
+func (b *backlogTaskBuffer) FairnessKeys() int {
+	return b.buffer.FairnessKeys()
+}
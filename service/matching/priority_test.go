@@ -0,0 +1,250 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package matching
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/partition"
+	"github.com/uber/cadence/common/persistence"
+)
+
+func newPriorityTask(taskID int64, priority string) *persistence.TaskInfo {
+	task := &persistence.TaskInfo{TaskID: taskID}
+	if priority != "" {
+		task.PartitionConfig = map[string]string{partition.PriorityKey: priority}
+	}
+	return task
+}
+
+func newTestPriorityTaskBuffer(weights func() map[int]int, defaultPriority int) *priorityTaskBuffer {
+	return newPriorityTaskBuffer(&priorityTaskBufferOptions{
+		PriorityWeights:    weights,
+		DefaultPriority:    func() int { return defaultPriority },
+		Priority:           getTaskPriority,
+		FairnessKey:        func(*persistence.TaskInfo) string { return "" },
+		FairnessKeyWeights: func() map[string]int { return nil },
+	})
+}
+
+func TestGetTaskPriority(t *testing.T) {
+	testCases := []struct {
+		name     string
+		task     *persistence.TaskInfo
+		priority int
+		ok       bool
+	}{
+		{"no partition config", &persistence.TaskInfo{}, 0, false},
+		{"no priority", &persistence.TaskInfo{PartitionConfig: map[string]string{partition.IsolationGroupKey: "zone-1"}}, 0, false},
+		{"valid priority", newPriorityTask(1, "2"), 2, true},
+		{"priority with spaces", newPriorityTask(1, " 4 "), 4, true},
+		{"invalid priority", newPriorityTask(1, "high"), 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			priority, ok := getTaskPriority(tc.task)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.priority, priority)
+		})
+	}
+}
+
+func TestPriorityTaskBuffer(t *testing.T) {
+	weights := map[int]int{1: 3, 2: 1}
+	buffer := newTestPriorityTaskBuffer(func() map[int]int { return weights }, 2)
+	require.Nil(t, buffer.Next())
+
+	// tasks 0-5 are high priority, 10-12 have the default priority
+	for i := 0; i < 6; i++ {
+		buffer.Add(newPriorityTask(int64(i), "1"))
+	}
+	buffer.Add(newPriorityTask(10, ""))
+	buffer.Add(newPriorityTask(11, "invalid"))
+	buffer.Add(newPriorityTask(12, "7"))
+	require.Equal(t, 9, buffer.Len())
+	assert.Equal(t, map[int]int{1: 6, 2: 3}, buffer.Backlog())
+
+	var taskIDs []int64
+	for buffer.Len() > 0 {
+		taskIDs = append(taskIDs, buffer.Next().TaskID)
+	}
+	assert.Equal(t, []int64{0, 1, 2, 10, 3, 4, 5, 11, 12}, taskIDs)
+	assert.Equal(t, map[int]int{1: 0, 2: 0}, buffer.Backlog())
+	assert.Nil(t, buffer.Next())
+}
+
+func TestPriorityTaskBuffer_WeightsUpdate(t *testing.T) {
+	weights := map[int]int{1: 1, 2: 1}
+	buffer := newTestPriorityTaskBuffer(func() map[int]int { return weights }, 2)
+	for i := 0; i < 4; i++ {
+		buffer.Add(newPriorityTask(int64(i), "1"))
+		buffer.Add(newPriorityTask(int64(10+i), "2"))
+	}
+	assert.Equal(t, int64(0), buffer.Next().TaskID)
+	assert.Equal(t, int64(10), buffer.Next().TaskID)
+
+	// new weights are used from the next round, and a priority without weight isn't starved
+	weights = map[int]int{1: 2}
+	var taskIDs []int64
+	for buffer.Len() > 0 {
+		taskIDs = append(taskIDs, buffer.Next().TaskID)
+	}
+	assert.Equal(t, []int64{1, 2, 11, 3, 12, 13}, taskIDs)
+}
+
+func TestFillPriorityTaskBuffer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskBuffer := make(chan *persistence.TaskInfo, 10)
+	tr := &taskReader{
+		taskBuffers: map[string]chan *persistence.TaskInfo{defaultTaskBufferIsolationGroup: taskBuffer},
+		taskListID:  &taskListID{taskType: persistence.TaskListTypeActivity},
+		config: &taskListConfig{
+			GetTasksBatchSize:      func() int { return 3 },
+			EnableTaskPriority:     func() bool { return true },
+			DefaultTaskPriority:    func() int { return 3 },
+			TaskPriorityWeights:    func(...dynamicconfig.FilterOption) map[string]interface{} { return nil },
+			EnableTaskFairness:     func() bool { return false },
+			TaskFairnessKeyWeights: func(...dynamicconfig.FilterOption) map[string]interface{} { return nil },
+		},
+		cancelCtx: ctx,
+		logger:    log.NewNoop(),
+		scope:     metrics.NoopScope(metrics.Matching),
+	}
+	buffer := newBacklogTaskBuffer(newPriorityTaskBuffer(&priorityTaskBufferOptions{
+		PriorityWeights:    tr.getTaskPriorityWeights,
+		DefaultPriority:    tr.config.DefaultTaskPriority,
+		Priority:           tr.getTaskPriority,
+		FairnessKey:        tr.getTaskFairnessKey,
+		FairnessKeyWeights: tr.getTaskFairnessKeyWeights,
+	}))
+	for i := 5; i > 0; i-- {
+		taskBuffer <- newPriorityTask(int64(i), strconv.Itoa(i))
+	}
+	nextTaskID := func() int64 {
+		reader, task := buffer.Next()
+		assert.Equal(t, tr, reader)
+		return task.TaskID
+	}
+
+	// the buffer is filled up to the batch size
+	require.True(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+	assert.Equal(t, 3, buffer.Len())
+	assert.Equal(t, 2, len(taskBuffer))
+	assert.Equal(t, int64(3), nextTaskID())
+
+	// higher priority tasks read later are dispatched first
+	require.True(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+	assert.Equal(t, int64(2), nextTaskID())
+	require.True(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+	assert.Equal(t, int64(1), nextTaskID())
+	assert.Equal(t, int64(4), nextTaskID())
+	assert.Equal(t, int64(5), nextTaskID())
+	assert.Equal(t, 0, buffer.Len())
+	assert.Equal(t, 0, len(taskBuffer))
+
+	// waits for a task when the buffer is empty and stops on shutdown
+	cancel()
+	assert.False(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+}
+
+func TestFillPriorityTaskBuffer_PriorityBacklogs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := &taskListConfig{
+		GetTasksBatchSize:      func() int { return 2 },
+		EnableTaskPriority:     func() bool { return true },
+		DefaultTaskPriority:    func() int { return 3 },
+		TaskPriorityWeights:    func(...dynamicconfig.FilterOption) map[string]interface{} { return nil },
+		EnableTaskFairness:     func() bool { return false },
+		TaskFairnessKeyWeights: func(...dynamicconfig.FilterOption) map[string]interface{} { return nil },
+	}
+	newReader := func(taskBuffer chan *persistence.TaskInfo) *taskReader {
+		return &taskReader{
+			taskBuffers:     map[string]chan *persistence.TaskInfo{defaultTaskBufferIsolationGroup: taskBuffer},
+			dispatchNotifyC: map[string]chan struct{}{defaultTaskBufferIsolationGroup: make(chan struct{}, 1)},
+			taskListID:      &taskListID{taskType: persistence.TaskListTypeActivity},
+			config:          config,
+			cancelCtx:       ctx,
+			logger:          log.NewNoop(),
+			scope:           metrics.NoopScope(metrics.Matching),
+		}
+	}
+	defaultTaskBuffer := make(chan *persistence.TaskInfo, 10)
+	priorityTaskBuffer := make(chan *persistence.TaskInfo, 10)
+	tr := newReader(defaultTaskBuffer)
+	priorityReader := newReader(priorityTaskBuffer)
+	priorityReader.dispatchNotifyC = tr.dispatchNotifyC
+	var priorityReaders []*taskReader
+	tr.priorityBacklogReaders = func() []*taskReader { return priorityReaders }
+	buffer := newBacklogTaskBuffer(newPriorityTaskBuffer(&priorityTaskBufferOptions{
+		PriorityWeights:    tr.getTaskPriorityWeights,
+		DefaultPriority:    tr.config.DefaultTaskPriority,
+		Priority:           tr.getTaskPriority,
+		FairnessKey:        tr.getTaskFairnessKey,
+		FairnessKeyWeights: tr.getTaskFairnessKeyWeights,
+	}))
+	for i := 0; i < 5; i++ {
+		defaultTaskBuffer <- newPriorityTask(int64(i), "3")
+	}
+
+	// the buffer is filled with the tasks of the default backlog
+	require.True(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+	assert.Equal(t, 2, buffer.Len())
+
+	// a priority backlog is opened and buffers its tasks
+	reader, task := buffer.Next()
+	assert.Equal(t, tr, reader)
+	assert.Equal(t, int64(0), task.TaskID)
+	priorityReaders = []*taskReader{priorityReader}
+	for i := 0; i < 3; i++ {
+		priorityTaskBuffer <- newPriorityTask(int64(10+i), "1")
+	}
+	priorityReader.notifyDispatcher(defaultTaskBufferIsolationGroup)
+
+	// a batch of each backlog is buffered even though the default backlog has more tasks, and
+	// the tasks are dispatched by the reader of their backlog
+	require.True(t, tr.fillPriorityTaskBuffer(defaultTaskBufferIsolationGroup, buffer))
+	assert.Equal(t, 4, buffer.Len())
+	assert.Equal(t, 2, buffer.Count(tr))
+	assert.Equal(t, 2, buffer.Count(priorityReader))
+	reader, task = buffer.Next()
+	assert.Equal(t, priorityReader, reader)
+	assert.Equal(t, int64(10), task.TaskID)
+	reader, task = buffer.Next()
+	assert.Equal(t, priorityReader, reader)
+	assert.Equal(t, int64(11), task.TaskID)
+	reader, task = buffer.Next()
+	assert.Equal(t, tr, reader)
+	assert.Equal(t, int64(1), task.TaskID)
+	assert.Equal(t, 0, buffer.Count(priorityReader))
+}
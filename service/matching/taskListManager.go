@@ -32,6 +32,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/backoff"
 	"github.com/uber/cadence/common/cache"
@@ -91,6 +93,8 @@ type (
 		String() string
 		GetTaskListKind() types.TaskListKind
 		TaskListID() *taskListID
+		// NumPartitions returns the number of partitions of the task list
+		NumPartitions() int
 	}
 
 	outstandingPollerInfo struct {
@@ -108,16 +112,24 @@ type (
 		db              *taskListDB
 		taskWriter      *taskWriter
 		taskReader      *taskReader // reads tasks from db and async matches it with poller
-		liveness        *liveness
-		taskGC          *taskGC
-		taskAckManager  messaging.AckManager // tracks ackLevel for delivered messages
-		matcher         *TaskMatcher         // for matching a task producer with a poller
-		clusterMetadata cluster.Metadata
-		domainCache     cache.DomainCache
-		partitioner     partition.Partitioner
-		logger          log.Logger
-		scope           metrics.Scope
-		domainName      string
+		// priorityBacklogs holds the backlogs of the tasks with a priority other than the default one, it's
+		// only set for normal task lists
+		priorityBacklogs *priorityBacklogs
+		liveness         *liveness
+		taskGC           *taskGC
+		activityLimiter  *activityTypeRateLimiter // shared by the task lists of the host, only set for activity task lists
+		// throttledActivityTasks counts the throttled tasks of each activity type
+		throttledActivityTasksLock sync.Mutex
+		throttledActivityTasks     map[string]int64
+		adaptiveScaler             *adaptiveScaler      // only set for the root partition of normal task lists
+		taskAckManager             messaging.AckManager // tracks ackLevel for delivered messages
+		matcher                    *TaskMatcher         // for matching a task producer with a poller
+		clusterMetadata            cluster.Metadata
+		domainCache                cache.DomainCache
+		partitioner                partition.Partitioner
+		logger                     log.Logger
+		scope                      metrics.Scope
+		domainName                 string
 		// pollerHistory stores poller which poll from this tasklist in last few minutes
 		pollerHistory *pollerHistory
 		// outstandingPollsMap is needed to keep track of all outstanding pollers for a
@@ -204,6 +216,24 @@ func newTaskListManager(
 	tlMgr.matcher = newTaskMatcher(taskListConfig, fwdr, tlMgr.scope, isolationGroups, tlMgr.logger)
 	tlMgr.taskWriter = newTaskWriter(tlMgr)
 	tlMgr.taskReader = newTaskReader(tlMgr, isolationGroups)
+	if *taskListKind == types.TaskListKindNormal {
+		tlMgr.priorityBacklogs = newPriorityBacklogs(tlMgr, isolationGroups)
+	}
+	if taskList.taskType == persistence.TaskListTypeActivity {
+		tlMgr.activityLimiter = e.activityLimiter
+		tlMgr.throttledActivityTasks = make(map[string]int64)
+	}
+	if taskList.IsRoot() && *taskListKind == types.TaskListKindNormal {
+		tlMgr.adaptiveScaler = newAdaptiveScaler(
+			taskList,
+			taskListConfig,
+			clock.NewRealTimeSource(),
+			tlMgr.logger,
+			taskListTypeMetricScope,
+			newPartitionBacklogFn(e.taskManager, e.matchingClient, taskList, domainName, tlMgr.priorityBacklogs.priorities),
+			tlMgr.backlogCount,
+		)
+	}
 	tlMgr.startWG.Add(1)
 	return tlMgr, nil
 }
@@ -218,7 +248,16 @@ func (c *taskListManagerImpl) Start() error {
 		c.Stop()
 		return err
 	}
+	if c.priorityBacklogs != nil {
+		if err := c.priorityBacklogs.Start(); err != nil {
+			c.Stop()
+			return err
+		}
+	}
 	c.taskReader.Start()
+	if c.adaptiveScaler != nil {
+		c.adaptiveScaler.Start()
+	}
 
 	return nil
 }
@@ -231,7 +270,15 @@ func (c *taskListManagerImpl) Stop() {
 	c.closeCallback(c)
 	c.liveness.Stop()
 	c.taskWriter.Stop()
+	// the dispatchers of the task reader dispatch the tasks of the priority backlogs with the context of their
+	// reader, so the priority backlogs are stopped first to unblock them
+	if c.priorityBacklogs != nil {
+		c.priorityBacklogs.Stop()
+	}
 	c.taskReader.Stop()
+	if c.adaptiveScaler != nil {
+		c.adaptiveScaler.Stop()
+	}
 	c.logger.Info("Task list manager state changed", tag.LifeCycleStopped)
 }
 
@@ -262,8 +309,12 @@ func (c *taskListManagerImpl) AddTask(ctx context.Context, params addTaskParams)
 	if params.forwardedFrom == "" {
 		// request sent by history service
 		c.liveness.markAlive(time.Now())
+		if c.adaptiveScaler != nil {
+			c.adaptiveScaler.recordAdd()
+		}
 	}
 	var syncMatch bool
+	var reader *taskReader
 	_, err := c.executeWithRetry(func() (interface{}, error) {
 		if err := ctx.Err(); err != nil {
 			return nil, err
@@ -283,7 +334,8 @@ func (c *taskListManagerImpl) AddTask(ctx context.Context, params addTaskParams)
 				return &persistence.CreateTasksResponse{}, errRemoteSyncMatchFailed
 			}
 
-			r, err := c.taskWriter.appendTask(params.execution, params.taskInfo)
+			r, backlogReader, err := c.appendTask(params)
+			reader = backlogReader
 			return r, err
 		}
 
@@ -306,21 +358,113 @@ func (c *taskListManagerImpl) AddTask(ctx context.Context, params addTaskParams)
 			return &persistence.CreateTasksResponse{}, errRemoteSyncMatchFailed
 		}
 
-		return c.taskWriter.appendTask(params.execution, params.taskInfo)
+		r, backlogReader, err := c.appendTask(params)
+		reader = backlogReader
+		return r, err
 	})
 
 	if err == nil && !syncMatch {
-		c.taskReader.Signal()
+		reader.Signal()
 	}
 
 	return syncMatch, err
 }
 
+// appendTask writes the task to the backlog of its priority, and returns the reader of the backlog
+func (c *taskListManagerImpl) appendTask(params addTaskParams) (*persistence.CreateTasksResponse, *taskReader, error) {
+	taskWriter, taskReader := c.taskWriter, c.taskReader
+	if priority, ok := c.getBacklogPriority(params.taskInfo); ok {
+		backlog, err := c.priorityBacklogs.Get(priority)
+		if err != nil {
+			return nil, nil, err
+		}
+		taskWriter, taskReader = backlog.taskWriter, backlog.taskReader
+	}
+	r, err := taskWriter.appendTask(params.execution, params.taskInfo)
+	return r, taskReader, err
+}
+
+// getBacklogPriority returns the priority of the task if it's written to a priority backlog, or false if it's
+// written to the default backlog
+func (c *taskListManagerImpl) getBacklogPriority(task *persistence.TaskInfo) (int, bool) {
+	if c.priorityBacklogs == nil || !c.config.EnableTaskPriority() {
+		return 0, false
+	}
+	priority, ok := getTaskPriority(task)
+	if !ok || priority == c.config.DefaultTaskPriority() {
+		return 0, false
+	}
+	if _, ok := c.taskReader.getTaskPriorityWeights()[priority]; !ok {
+		// tasks of a priority without weight are dispatched with the default priority
+		return 0, false
+	}
+	return priority, true
+}
+
+// priorityBacklogReaders returns the readers of the opened priority backlogs
+func (c *taskListManagerImpl) priorityBacklogReaders() []*taskReader {
+	if c.priorityBacklogs == nil {
+		return nil
+	}
+	return c.priorityBacklogs.Readers()
+}
+
+// backlogCount returns the number of tasks in the default and priority backlogs
+func (c *taskListManagerImpl) backlogCount() int64 {
+	count := c.taskAckManager.GetBacklogCount()
+	if c.priorityBacklogs != nil {
+		for _, priorityCount := range c.priorityBacklogs.BacklogCount() {
+			count += priorityCount
+		}
+	}
+	return count
+}
+
 // DispatchTask dispatches a task to a poller. When there are no pollers to pick
 // up the task or if rate limit is exceeded, this method will return error. Task
 // *will not* be persisted to db
 func (c *taskListManagerImpl) DispatchTask(ctx context.Context, task *InternalTask) error {
-	return c.matcher.MustOffer(ctx, task)
+	// the activity type limit is checked without blocking, so that the task reader can dispatch the tasks of the
+	// other activity types while the activity type of this task is throttled
+	rsv, err := c.reserveActivityType(task)
+	if err != nil {
+		return err
+	}
+	err = c.matcher.MustOffer(ctx, task)
+	if err != nil && rsv != nil {
+		// return the token since the task isn't dispatched
+		rsv.Cancel()
+	}
+	return err
+}
+
+// reserveActivityType reserves a token of the activity type rate limit for the task, it returns an
+// activityTypeThrottledError if the activity type of the task is throttled
+func (c *taskListManagerImpl) reserveActivityType(task *InternalTask) (*rate.Reservation, error) {
+	if c.activityLimiter == nil {
+		return nil, nil
+	}
+	rsv, err := c.activityLimiter.TryReserve(c.taskListID.domainID, c.domainName, task)
+	var throttledErr *activityTypeThrottledError
+	if errors.As(err, &throttledErr) {
+		c.scope.Tagged(getTaskListTypeTag(c.taskListID.taskType), metrics.ActivityTypeTag(throttledErr.activityType)).
+			IncCounter(metrics.ActivityTypeThrottlePerTaskListCounter)
+		c.throttledActivityTasksLock.Lock()
+		c.throttledActivityTasks[throttledErr.activityType]++
+		c.throttledActivityTasksLock.Unlock()
+	}
+	return rsv, err
+}
+
+// throttledActivityTaskCounts returns the number of times the tasks of each activity type were throttled
+func (c *taskListManagerImpl) throttledActivityTaskCounts() map[string]int64 {
+	c.throttledActivityTasksLock.Lock()
+	defer c.throttledActivityTasksLock.Unlock()
+	counts := make(map[string]int64, len(c.throttledActivityTasks))
+	for activityType, count := range c.throttledActivityTasks {
+		counts[activityType] = count
+	}
+	return counts
 }
 
 // DispatchQueryTask will dispatch query to local or remote poller. If forwarded then result or error is returned,
@@ -353,7 +497,10 @@ func (c *taskListManagerImpl) GetTask(
 		return nil, fmt.Errorf("couldn't get task: %w", err)
 	}
 	task.domainName = c.domainName
-	task.backlogCountHint = c.taskAckManager.GetBacklogCount()
+	task.backlogCountHint = c.backlogCount()
+	if c.adaptiveScaler != nil && !task.isQuery() && !task.isForwarded() {
+		c.adaptiveScaler.recordDispatch()
+	}
 	return task, nil
 }
 
@@ -442,7 +589,8 @@ func (c *taskListManagerImpl) CancelPoller(pollerID string) {
 
 // DescribeTaskList returns information about the target tasklist, right now this API returns the
 // pollers which polled this tasklist in last few minutes and status of tasklist's ackManager
-// (readLevel, ackLevel, backlogCountHint and taskIDBlock).
+// (readLevel, ackLevel, backlogCountHint and taskIDBlock). The backlog count includes the
+// priority backlogs, the other fields are the ones of the default backlog.
 func (c *taskListManagerImpl) DescribeTaskList(includeTaskListStatus bool) *types.DescribeTaskListResponse {
 	response := &types.DescribeTaskListResponse{Pollers: c.GetAllPollerInfo()}
 	if !includeTaskListStatus {
@@ -455,6 +603,15 @@ func (c *taskListManagerImpl) DescribeTaskList(includeTaskListStatus bool) *type
 		// fallback to im-memory backlog, if failed to get count from db
 		backlogCount = c.taskAckManager.GetBacklogCount()
 	}
+	if c.priorityBacklogs != nil {
+		priorityBacklogCounts, err := c.priorityBacklogs.GetTaskListSize()
+		if err != nil {
+			priorityBacklogCounts = c.priorityBacklogs.BacklogCount()
+		}
+		for _, count := range priorityBacklogCounts {
+			backlogCount += count
+		}
+	}
 	response.TaskListStatus = &types.TaskListStatus{
 		ReadLevel:        c.taskAckManager.GetReadLevel(),
 		AckLevel:         c.taskAckManager.GetAckLevel(),
@@ -482,6 +639,26 @@ func (c *taskListManagerImpl) String() string {
 	fmt.Fprintf(buf, "TaskIDBlock=%+v\n", rangeIDToTaskIDBlock(rangeID, c.config.RangeSize))
 	fmt.Fprintf(buf, "AckLevel=%v\n", c.taskAckManager.GetAckLevel())
 	fmt.Fprintf(buf, "MaxReadLevel=%v\n", c.taskAckManager.GetReadLevel())
+	if c.priorityBacklogs != nil {
+		counts := c.priorityBacklogs.BacklogCount()
+		priorities := make([]int, 0, len(counts))
+		for priority := range counts {
+			priorities = append(priorities, priority)
+		}
+		sort.Ints(priorities)
+		for _, priority := range priorities {
+			fmt.Fprintf(buf, "Priority%vBacklogCount=%v\n", priority, counts[priority])
+		}
+	}
+	throttled := c.throttledActivityTaskCounts()
+	activityTypes := make([]string, 0, len(throttled))
+	for activityType := range throttled {
+		activityTypes = append(activityTypes, activityType)
+	}
+	sort.Strings(activityTypes)
+	for _, activityType := range activityTypes {
+		fmt.Fprintf(buf, "ActivityType%vThrottledCount=%v\n", activityType, throttled[activityType])
+	}
 
 	return buf.String()
 }
@@ -494,6 +671,13 @@ func (c *taskListManagerImpl) TaskListID() *taskListID {
 	return c.taskListID
 }
 
+func (c *taskListManagerImpl) NumPartitions() int {
+	if c.adaptiveScaler != nil && c.config.EnableAdaptiveScaler() {
+		return c.adaptiveScaler.NumPartitions()
+	}
+	return c.config.NumWritePartitions()
+}
+
 // Retry operation on transient error. On rangeID update by another process calls c.Stop().
 func (c *taskListManagerImpl) executeWithRetry(
 	operation func() (interface{}, error),
@@ -514,6 +698,14 @@ func (c *taskListManagerImpl) executeWithRetry(
 
 func (c *taskListManagerImpl) trySyncMatch(ctx context.Context, params addTaskParams, isolationGroup string) (bool, error) {
 	task := newInternalTask(params.taskInfo, nil, params.source, params.forwardedFrom, true, params.activityTaskDispatchInfo, isolationGroup)
+	var rsv *rate.Reservation
+	if !task.isForwarded() {
+		var err error
+		if rsv, err = c.reserveActivityType(task); err != nil {
+			// the activity type is throttled, the task will be dispatched from the backlog
+			return false, nil
+		}
+	}
 	childCtx := ctx
 	cancel := func() {}
 	waitTime := maxSyncMatchWaitTime
@@ -533,6 +725,10 @@ func (c *taskListManagerImpl) trySyncMatch(ctx context.Context, params addTaskPa
 		matched, err = c.matcher.Offer(childCtx, task)
 	}
 	cancel()
+	if !matched && rsv != nil {
+		// return the token since the task isn't dispatched
+		rsv.Cancel()
+	}
 	return matched, err
 }
 
@@ -600,6 +796,10 @@ func (c *taskListManagerImpl) getIsolationGroupForTask(ctx context.Context, task
 			if err == partition.ErrNoIsolationGroupsAvailable && c.taskListKind == types.TaskListKindSticky {
 				return "", _stickyPollerUnavailableError
 			}
+			// the workflow was started without an isolation group, e.g. with a task priority only
+			if err == partition.ErrInvalidPartitionConfig && taskInfo.PartitionConfig[partition.IsolationGroupKey] == "" {
+				return defaultTaskBufferIsolationGroup, nil
+			}
 			// if we're unable to get the isolation group, log the error and fallback to no isolation
 			c.logger.Error("Failed to get isolation group from partition library", tag.WorkflowID(taskInfo.WorkflowID), tag.WorkflowRunID(taskInfo.RunID), tag.TaskID(taskInfo.TaskID), tag.Error(err))
 			return defaultTaskBufferIsolationGroup, nil
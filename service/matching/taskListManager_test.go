@@ -140,7 +140,10 @@ func createTestTaskListManager(logger log.Logger, controller *gomock.Controller)
 }
 
 func createTestTaskListManagerWithConfig(logger log.Logger, controller *gomock.Controller, cfg *Config) *taskListManagerImpl {
-	tm := newTestTaskManager(logger)
+	return createTestTaskListManagerWithTaskManager(logger, controller, cfg, newTestTaskManager(logger))
+}
+
+func createTestTaskListManagerWithTaskManager(logger log.Logger, controller *gomock.Controller, cfg *Config, tm *testTaskManager) *taskListManagerImpl {
 	mockPartitioner := partition.NewMockPartitioner(controller)
 	mockPartitioner.EXPECT().GetIsolationGroupByDomainID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).AnyTimes()
 	mockDomainCache := cache.NewMockDomainCache(controller)
@@ -220,6 +223,71 @@ func TestDescribeTaskList(t *testing.T) {
 	require.Zero(t, taskListStatus.GetBacklogCountHint())
 }
 
+func TestPriorityBacklogs(t *testing.T) {
+	controller := gomock.NewController(t)
+	logger := testlogger.New(t)
+	tm := newTestTaskManager(logger)
+
+	newTask := func(priority string) addTaskParams {
+		workflowID := uuid.New()
+		runID := uuid.New()
+		taskInfo := &persistence.TaskInfo{
+			DomainID:               "domain",
+			WorkflowID:             workflowID,
+			RunID:                  runID,
+			ScheduleID:             2,
+			ScheduleToStartTimeout: 5,
+			CreatedTime:            time.Now(),
+		}
+		if priority != "" {
+			taskInfo.PartitionConfig = map[string]string{partition.PriorityKey: priority}
+		}
+		return addTaskParams{
+			execution: &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+			taskInfo:  taskInfo,
+		}
+	}
+	defaultBacklog := newTestPersistedTaskListID("domain", "tl", persistence.TaskListTypeActivity)
+	priorityBacklog := newTestPersistedTaskListID("domain", priorityBacklogName("tl", 1), persistence.TaskListTypeActivity)
+
+	// the tasks are written to the backlog of their priority
+	cfg := NewConfig(dynamicconfig.NewNopCollection(), "some random hostname")
+	cfg.EnableTaskPriority = dynamicconfig.GetBoolPropertyFnFilteredByTaskListInfo(true)
+	tlm := createTestTaskListManagerWithTaskManager(logger, controller, cfg, tm)
+	require.NoError(t, tlm.Start())
+	require.Empty(t, tlm.priorityBacklogReaders())
+	for _, priority := range []string{"", "3", "1", "1", "42"} {
+		syncMatch, err := tlm.AddTask(context.Background(), newTask(priority))
+		require.NoError(t, err)
+		require.False(t, syncMatch)
+	}
+	assert.Equal(t, 3, tm.getTaskCount(defaultBacklog))
+	assert.Equal(t, 2, tm.getTaskCount(priorityBacklog))
+	assert.Len(t, tlm.priorityBacklogReaders(), 1)
+	assert.Equal(t, int64(5), tlm.DescribeTaskList(true).GetTaskListStatus().GetBacklogCountHint())
+	tlm.Stop()
+
+	// the priority backlogs with tasks are opened and drained when the task list is loaded,
+	// even if task priority is disabled
+	cfg.EnableTaskPriority = dynamicconfig.GetBoolPropertyFnFilteredByTaskListInfo(false)
+	tlm = createTestTaskListManagerWithTaskManager(logger, controller, cfg, tm)
+	require.NoError(t, tlm.Start())
+	defer tlm.Stop()
+	require.Len(t, tlm.priorityBacklogReaders(), 1)
+	backlog, err := tlm.priorityBacklogs.Get(1)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		task, err := tlm.GetTask(ctx, nil)
+		cancel()
+		require.NoError(t, err)
+		task.finish(nil)
+	}
+	assert.Equal(t, int64(0), backlog.taskAckManager.GetBacklogCount())
+	assert.Equal(t, int64(2), backlog.taskAckManager.GetAckLevel())
+	assert.Equal(t, int64(0), tlm.backlogCount())
+}
+
 func TestCheckIdleTaskList(t *testing.T) {
 	cfg := NewConfig(dynamicconfig.NewNopCollection(), "some random hostname")
 	cfg.IdleTasklistCheckInterval = dynamicconfig.GetDurationPropertyFnFilteredByTaskListInfo(10 * time.Millisecond)
@@ -416,6 +484,36 @@ func TestRateLimitErrorsFromTasklistDispatch(t *testing.T) {
 	assert.False(t, breakRetryLoop)
 }
 
+// a task whose activity type is throttled is put back in the buffer once the activity type is no longer
+// throttled, the dispatcher moves on to the next tasks in the meantime
+func TestActivityTypeThrottledTaskIsDeferred(t *testing.T) {
+	controller := gomock.NewController(t)
+	logger := testlogger.New(t)
+
+	tlm := createTestTaskListManager(logger, controller)
+	tlm.taskReader.dispatchTask = func(ctx context.Context, task *InternalTask) error {
+		return &activityTypeThrottledError{activityType: "foo", delay: 10 * time.Millisecond}
+	}
+	tlm.taskReader.getIsolationGroupForTask = func(ctx context.Context, info *persistence.TaskInfo) (string, error) {
+		return defaultTaskBufferIsolationGroup, nil
+	}
+
+	task := &persistence.TaskInfo{TaskID: 1}
+	breakDispatcher, breakRetryLoop := tlm.taskReader.dispatchSingleTaskFromBuffer(defaultTaskBufferIsolationGroup, task)
+	assert.False(t, breakDispatcher)
+	assert.True(t, breakRetryLoop)
+
+	select {
+	case deferred := <-tlm.taskReader.taskBuffers[defaultTaskBufferIsolationGroup]:
+		assert.Equal(t, task, deferred)
+	case <-time.After(time.Second):
+		t.Fatal("the throttled task wasn't put back in the buffer")
+	}
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&tlm.taskReader.deferredTasks) == 0
+	}, time.Second, time.Millisecond)
+}
+
 // The intent of this test: SingleTaskDispatch is one of two places where tasks are written to
 // the taskreader.taskBuffers channels. As such, it needs to take care to not accidentally
 // hit the channel when it's full, as it'll block, causing a deadlock (due to both this dispatch
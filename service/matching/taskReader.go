@@ -30,6 +30,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/backoff"
 	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/cluster"
@@ -54,8 +55,14 @@ type (
 		// that are enqueued for pollers to pickup. It's written to by
 		// - getTasksPump - the primary means of loading async matching tasks
 		// - task dispatch redirection - when a task is redirected from another isolation group
-		taskBuffers     map[string]chan *persistence.TaskInfo
-		notifyC         chan struct{} // Used as signal to notify pump of new tasks
+		taskBuffers map[string]chan *persistence.TaskInfo
+		notifyC     chan struct{} // Used as signal to notify pump of new tasks
+		// dispatchNotifyC is used as signal to notify the dispatcher of an isolation group of new buffered tasks,
+		// it's shared by the readers of all the backlogs of the task list
+		dispatchNotifyC map[string]chan struct{}
+		// pumpOnly is set for the readers of the priority backlogs, whose buffered tasks are dispatched by the
+		// dispatchers of the reader of the default backlog
+		pumpOnly        bool
 		tlMgr           *taskListManagerImpl
 		taskListID      *taskListID
 		config          *taskListConfig
@@ -73,6 +80,7 @@ type (
 		cancelCtx                context.Context
 		cancelFunc               context.CancelFunc
 		stopped                  int64 // set to 1 if the reader is stopped or is shutting down
+		deferredTasks            int64 // number of tasks waiting for their activity type to no longer be throttled
 		logger                   log.Logger
 		scope                    metrics.Scope
 		throttleRetry            *backoff.ThrottleRetry
@@ -81,6 +89,7 @@ type (
 		dispatchTask             func(context.Context, *InternalTask) error
 		getIsolationGroupForTask func(context.Context, *persistence.TaskInfo) (string, error)
 		ratePerSecond            func() float64
+		priorityBacklogReaders   func() []*taskReader
 
 		// stopWg is used to wait for all dispatchers to stop.
 		stopWg sync.WaitGroup
@@ -88,6 +97,27 @@ type (
 )
 
 func newTaskReader(tlMgr *taskListManagerImpl, isolationGroups []string) *taskReader {
+	dispatchNotifyC := make(map[string]chan struct{})
+	dispatchNotifyC[defaultTaskBufferIsolationGroup] = make(chan struct{}, 1)
+	for _, g := range isolationGroups {
+		dispatchNotifyC[g] = make(chan struct{}, 1)
+	}
+	tr := newBacklogTaskReader(tlMgr, tlMgr.db, tlMgr.taskWriter, tlMgr.taskGC, tlMgr.taskAckManager, tlMgr.scope, isolationGroups, dispatchNotifyC)
+	tr.priorityBacklogReaders = tlMgr.priorityBacklogReaders
+	return tr
+}
+
+// newBacklogTaskReader returns a reader of the given backlog of the task list, see priorityBacklog
+func newBacklogTaskReader(
+	tlMgr *taskListManagerImpl,
+	db *taskListDB,
+	taskWriter *taskWriter,
+	taskGC *taskGC,
+	taskAckManager messaging.AckManager,
+	scope metrics.Scope,
+	isolationGroups []string,
+	dispatchNotifyC map[string]chan struct{},
+) *taskReader {
 	ctx, cancel := context.WithCancel(context.Background())
 	taskBuffers := make(map[string]chan *persistence.TaskInfo)
 	taskBuffers[defaultTaskBufferIsolationGroup] = make(chan *persistence.TaskInfo, tlMgr.config.GetTasksBatchSize()-1)
@@ -98,20 +128,21 @@ func newTaskReader(tlMgr *taskListManagerImpl, isolationGroups []string) *taskRe
 		tlMgr:          tlMgr,
 		taskListID:     tlMgr.taskListID,
 		config:         tlMgr.config,
-		db:             tlMgr.db,
-		taskWriter:     tlMgr.taskWriter,
-		taskGC:         tlMgr.taskGC,
-		taskAckManager: tlMgr.taskAckManager,
+		db:             db,
+		taskWriter:     taskWriter,
+		taskGC:         taskGC,
+		taskAckManager: taskAckManager,
 		cancelCtx:      ctx,
 		cancelFunc:     cancel,
 		notifyC:        make(chan struct{}, 1),
 		// we always dequeue the head of the buffer and try to dispatch it to a poller
 		// so allocate one less than desired target buffer size
 		taskBuffers:              taskBuffers,
+		dispatchNotifyC:          dispatchNotifyC,
 		domainCache:              tlMgr.domainCache,
 		clusterMetadata:          tlMgr.clusterMetadata,
 		logger:                   tlMgr.logger,
-		scope:                    tlMgr.scope,
+		scope:                    scope,
 		handleErr:                tlMgr.handleErr,
 		onFatalErr:               tlMgr.Stop,
 		dispatchTask:             tlMgr.DispatchTask,
@@ -127,6 +158,9 @@ func newTaskReader(tlMgr *taskListManagerImpl, isolationGroups []string) *taskRe
 func (tr *taskReader) Start() {
 	tr.Signal()
 	for g := range tr.taskBuffers {
+		if tr.pumpOnly {
+			break
+		}
 		g := g
 		tr.stopWg.Add(1)
 		go func() {
@@ -163,8 +197,32 @@ func (tr *taskReader) Signal() {
 }
 
 func (tr *taskReader) dispatchBufferedTasks(isolationGroup string) {
+	priorityBuffer := newBacklogTaskBuffer(newPriorityTaskBuffer(&priorityTaskBufferOptions{
+		PriorityWeights:    tr.getTaskPriorityWeights,
+		DefaultPriority:    tr.config.DefaultTaskPriority,
+		Priority:           tr.getTaskPriority,
+		FairnessKey:        tr.getTaskFairnessKey,
+		FairnessKeyWeights: tr.getTaskFairnessKeyWeights,
+	}))
 dispatchLoop:
 	for {
+		// when task priority or fairness is enabled, or there are priority backlogs to drain, the tasks are moved
+		// from the channels to the priority buffer and dispatched by priority and fairness key. If both are disabled
+		// afterwards, the tasks left in the priority buffer are dispatched first.
+		enableBuffer := tr.config.EnableTaskPriority() || tr.config.EnableTaskFairness() || len(tr.getPriorityBacklogReaders()) > 0
+		if enableBuffer || priorityBuffer.Len() > 0 {
+			if enableBuffer && !tr.fillPriorityTaskBuffer(isolationGroup, priorityBuffer) {
+				break dispatchLoop
+			}
+			// the task is dispatched by the reader of its backlog, so it's completed in the right backlog
+			reader, taskInfo := priorityBuffer.Next()
+			breakDispatchLoop := reader.dispatchSingleTaskFromBufferWithRetries(isolationGroup, taskInfo)
+			if breakDispatchLoop {
+				// shutting down
+				break dispatchLoop
+			}
+			continue dispatchLoop
+		}
 		select {
 		case taskInfo, ok := <-tr.taskBuffers[isolationGroup]:
 			if !ok { // Task list getTasks pump is shutdown
@@ -181,6 +239,120 @@ dispatchLoop:
 	}
 }
 
+// fillPriorityTaskBuffer moves the tasks of the isolation group from the channels of the default and priority
+// backlogs to the priority buffer until the buffer holds a batch of tasks of each backlog or the channels are
+// empty, and waits for a task if the buffer is empty.
+// It returns false if the task reader is shutting down.
+func (tr *taskReader) fillPriorityTaskBuffer(isolationGroup string, priorityBuffer *backlogTaskBuffer) bool {
+	readers := append([]*taskReader{tr}, tr.getPriorityBacklogReaders()...)
+	for {
+		for _, reader := range readers {
+			if !reader.moveBufferedTasks(isolationGroup, priorityBuffer) {
+				return false
+			}
+		}
+		if priorityBuffer.Len() > 0 {
+			break
+		}
+		select {
+		case taskInfo, ok := <-tr.taskBuffers[isolationGroup]:
+			if !ok {
+				return false
+			}
+			priorityBuffer.Add(tr, taskInfo)
+		case <-tr.dispatchNotifyC[isolationGroup]:
+			// a priority backlog buffered a task, or a new priority backlog was opened
+			readers = append([]*taskReader{tr}, tr.getPriorityBacklogReaders()...)
+		case <-tr.cancelCtx.Done():
+			return false
+		}
+	}
+	scope := tr.scope.Tagged(getTaskListTypeTag(tr.taskListID.taskType))
+	for priority, count := range priorityBuffer.Backlog() {
+		scope.Tagged(metrics.TaskPriorityTag(priority)).UpdateGauge(metrics.BufferedTasksPerPriorityGauge, float64(count))
+	}
+	scope.UpdateGauge(metrics.BufferedFairnessKeysPerTaskListGauge, float64(priorityBuffer.FairnessKeys()))
+	return true
+}
+
+// moveBufferedTasks moves the tasks of the isolation group from the channel of the reader to the priority buffer
+// until the buffer holds a batch of tasks of the reader or the channel is empty.
+// It returns false if the task reader is shutting down.
+func (tr *taskReader) moveBufferedTasks(isolationGroup string, priorityBuffer *backlogTaskBuffer) bool {
+	for priorityBuffer.Count(tr) < tr.config.GetTasksBatchSize() {
+		select {
+		case taskInfo, ok := <-tr.taskBuffers[isolationGroup]:
+			if !ok {
+				return false
+			}
+			priorityBuffer.Add(tr, taskInfo)
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+func (tr *taskReader) getPriorityBacklogReaders() []*taskReader {
+	if tr.priorityBacklogReaders == nil {
+		return nil
+	}
+	return tr.priorityBacklogReaders()
+}
+
+// notifyDispatcher signals the dispatcher of the isolation group that a task was buffered
+func (tr *taskReader) notifyDispatcher(isolationGroup string) {
+	var event struct{}
+	select {
+	case tr.dispatchNotifyC[isolationGroup] <- event:
+	default: // channel already has an event, don't block
+	}
+}
+
+func (tr *taskReader) getTaskPriority(task *persistence.TaskInfo) (int, bool) {
+	if !tr.config.EnableTaskPriority() {
+		return 0, false
+	}
+	return getTaskPriority(task)
+}
+
+func (tr *taskReader) getTaskFairnessKey(task *persistence.TaskInfo) string {
+	if !tr.config.EnableTaskFairness() {
+		// all the tasks of a priority are dispatched in order
+		return ""
+	}
+	return getTaskFairnessKey(task, tr.config.FairnessKeyWorkflowIDDelimiter())
+}
+
+func (tr *taskReader) getTaskFairnessKeyWeights() map[string]int {
+	weights := make(map[string]int)
+	for key, value := range tr.config.TaskFairnessKeyWeights() {
+		switch value := value.(type) {
+		case float64:
+			weights[key] = int(value)
+		case int:
+			weights[key] = value
+		default:
+			tr.logger.Error("Invalid task fairness key weight, using the default one",
+				tag.Dynamic("fairness-key", key),
+				tag.Value(value))
+		}
+	}
+	return weights
+}
+
+func (tr *taskReader) getTaskPriorityWeights() map[int]int {
+	weights, err := common.ConvertDynamicConfigMapPropertyToIntMap(tr.config.TaskPriorityWeights())
+	if err != nil {
+		tr.logger.Error("Failed to parse task priority weights, using the default ones", tag.Error(err))
+		return defaultTaskPriorityWeights
+	}
+	if len(weights) == 0 {
+		return defaultTaskPriorityWeights
+	}
+	return weights
+}
+
 func (tr *taskReader) getTasksPump() {
 	updateAckTimer := time.NewTimer(tr.config.UpdateAckInterval())
 	defer updateAckTimer.Stop()
@@ -321,6 +493,7 @@ func (tr *taskReader) addSingleTaskToBuffer(task *persistence.TaskInfo) bool {
 	}
 	select {
 	case tr.taskBuffers[isolationGroup] <- task:
+		tr.notifyDispatcher(isolationGroup)
 		return true
 	case <-tr.cancelCtx.Done():
 		return false
@@ -397,6 +570,33 @@ func (tr *taskReader) newDispatchContext(isolationGroup string) (context.Context
 	return tr.cancelCtx, func() {}
 }
 
+// deferThrottledTask puts the task back in the buffer of the isolation group after the delay, when its activity type
+// is no longer throttled, so that the tasks of the other activity types are dispatched in the meantime.
+// It returns false if a batch of tasks is deferred already, to bound the number of tasks read ahead.
+func (tr *taskReader) deferThrottledTask(isolationGroup string, taskInfo *persistence.TaskInfo, delay time.Duration) bool {
+	if atomic.AddInt64(&tr.deferredTasks, 1) > int64(tr.config.GetTasksBatchSize()) {
+		atomic.AddInt64(&tr.deferredTasks, -1)
+		return false
+	}
+	go func() {
+		defer atomic.AddInt64(&tr.deferredTasks, -1)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-tr.cancelCtx.Done():
+			// the task is read again from persistence by the next owner of the task list
+			return
+		}
+		select {
+		case tr.taskBuffers[isolationGroup] <- taskInfo:
+			tr.notifyDispatcher(isolationGroup)
+		case <-tr.cancelCtx.Done():
+		}
+	}()
+	return true
+}
+
 func (tr *taskReader) dispatchSingleTaskFromBufferWithRetries(isolationGroup string, taskInfo *persistence.TaskInfo) (breakDispatchLoop bool) {
 	// retry loop for dispatching a single task
 	for {
@@ -486,6 +686,7 @@ func (tr *taskReader) dispatchSingleTaskFromBuffer(isolationGroup string, taskIn
 				return true, true
 			case tr.taskBuffers[defaultTaskBufferIsolationGroup] <- taskInfo:
 				// task successfully rerouted to default tasklist
+				tr.notifyDispatcher(defaultTaskBufferIsolationGroup)
 				return false, true
 			default:
 				// couldn't redirect, loop and try again
@@ -502,6 +703,7 @@ func (tr *taskReader) dispatchSingleTaskFromBuffer(isolationGroup string, taskIn
 			return true, true
 		case tr.taskBuffers[group] <- taskInfo:
 			// successful redirect
+			tr.notifyDispatcher(group)
 			tr.scope.IncCounter(metrics.BufferIsolationGroupRedirectCounter)
 			tr.logger.Warn("some tasks were redirected to another isolation group.",
 				tag.Dynamic("redirection-from-isolation-group", isolationGroup),
@@ -527,6 +729,23 @@ func (tr *taskReader) dispatchSingleTaskFromBuffer(isolationGroup string, taskIn
 		}
 	}
 
+	var throttledErr *activityTypeThrottledError
+	if errors.As(err, &throttledErr) {
+		if tr.deferThrottledTask(isolationGroup, taskInfo, throttledErr.delay) {
+			// dispatch the next tasks in the meantime
+			return false, true
+		}
+		// too many tasks are deferred already, wait for the activity type of this one
+		timer := time.NewTimer(throttledErr.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return false, false
+		case <-tr.cancelCtx.Done():
+			return true, true
+		}
+	}
+
 	if errors.Is(err, ErrTasklistThrottled) {
 		tr.scope.IncCounter(metrics.BufferThrottlePerTaskListCounter)
 		runtime.Gosched()
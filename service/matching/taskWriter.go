@@ -76,11 +76,16 @@ type (
 var errShutdown = errors.New("task list shutting down")
 
 func newTaskWriter(tlMgr *taskListManagerImpl) *taskWriter {
+	return newBacklogTaskWriter(tlMgr, tlMgr.db, tlMgr.taskAckManager)
+}
+
+// newBacklogTaskWriter returns a writer of the given backlog of the task list, see priorityBacklog
+func newBacklogTaskWriter(tlMgr *taskListManagerImpl, db *taskListDB, taskAckManager messaging.AckManager) *taskWriter {
 	return &taskWriter{
-		db:             tlMgr.db,
+		db:             db,
 		config:         tlMgr.config,
 		taskListID:     tlMgr.taskListID,
-		taskAckManager: tlMgr.taskAckManager,
+		taskAckManager: taskAckManager,
 		stopCh:         make(chan struct{}),
 		appendCh:       make(chan *writeTaskRequest, tlMgr.config.OutstandingTaskAppendsThreshold()),
 		logger:         tlMgr.logger,
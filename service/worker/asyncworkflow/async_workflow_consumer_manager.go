@@ -35,6 +35,7 @@ import (
 	"github.com/uber/cadence/common/clock"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
 )
@@ -63,22 +64,26 @@ func NewConsumerManager(
 	metricsClient metrics.Client,
 	domainCache cache.DomainCache,
 	queueProvider queue.Provider,
+	queueManager provider.QueueManagerFn,
 	frontendClient frontend.Client,
+	membershipResolver membership.Resolver,
 	options ...ConsumerManagerOptions,
 ) *ConsumerManager {
 	ctx, cancel := context.WithCancel(context.Background())
 	cm := &ConsumerManager{
-		logger:          logger.WithTags(tag.ComponentAsyncWFConsumptionManager),
-		metricsClient:   metricsClient,
-		domainCache:     domainCache,
-		queueProvider:   queueProvider,
-		frontendClient:  frontendClient,
-		refreshInterval: defaultRefreshInterval,
-		shutdownTimeout: defaultShutdownTimeout,
-		ctx:             ctx,
-		cancelFn:        cancel,
-		activeConsumers: make(map[string]provider.Consumer),
-		timeSrc:         clock.NewRealTimeSource(),
+		logger:             logger.WithTags(tag.ComponentAsyncWFConsumptionManager),
+		metricsClient:      metricsClient,
+		domainCache:        domainCache,
+		queueProvider:      queueProvider,
+		queueManager:       queueManager,
+		frontendClient:     frontendClient,
+		membershipResolver: membershipResolver,
+		refreshInterval:    defaultRefreshInterval,
+		shutdownTimeout:    defaultShutdownTimeout,
+		ctx:                ctx,
+		cancelFn:           cancel,
+		activeConsumers:    make(map[string]provider.Consumer),
+		timeSrc:            clock.NewRealTimeSource(),
 	}
 
 	for _, opt := range options {
@@ -88,18 +93,20 @@ func NewConsumerManager(
 }
 
 type ConsumerManager struct {
-	logger          log.Logger
-	metricsClient   metrics.Client
-	timeSrc         clock.TimeSource
-	domainCache     cache.DomainCache
-	queueProvider   queue.Provider
-	frontendClient  frontend.Client
-	refreshInterval time.Duration
-	shutdownTimeout time.Duration
-	ctx             context.Context
-	cancelFn        context.CancelFunc
-	wg              sync.WaitGroup
-	activeConsumers map[string]provider.Consumer
+	logger             log.Logger
+	metricsClient      metrics.Client
+	timeSrc            clock.TimeSource
+	domainCache        cache.DomainCache
+	queueProvider      queue.Provider
+	queueManager       provider.QueueManagerFn
+	frontendClient     frontend.Client
+	membershipResolver membership.Resolver
+	refreshInterval    time.Duration
+	shutdownTimeout    time.Duration
+	ctx                context.Context
+	cancelFn           context.CancelFunc
+	wg                 sync.WaitGroup
+	activeConsumers    map[string]provider.Consumer
 }
 
 func (c *ConsumerManager) Start() {
@@ -186,9 +193,11 @@ func (c *ConsumerManager) refreshConsumers() {
 
 		c.logger.Info("Starting consumer", tag.WorkflowDomainName(domain.GetInfo().Name), tag.AsyncWFQueueID(queue.ID()))
 		consumer, err := queue.CreateConsumer(&provider.Params{
-			Logger:         c.logger,
-			MetricsClient:  c.metricsClient,
-			FrontendClient: c.frontendClient,
+			Logger:             c.logger,
+			MetricsClient:      c.metricsClient,
+			FrontendClient:     c.frontendClient,
+			QueueManagerFn:     c.queueManager,
+			MembershipResolver: c.membershipResolver,
 		})
 		if err != nil {
 			c.logger.Error("Failed to create consumer", tag.Error(err), tag.WorkflowDomainName(domain.GetInfo().Name), tag.AsyncWFQueueID(queue.ID()))
@@ -293,6 +293,8 @@ func TestConsumerManager(t *testing.T) {
 				mockDomainCache,
 				mockQueueProvider,
 				nil,
+				nil,
+				nil,
 				WithTimeSource(mockTimeSrc),
 			)
 
@@ -0,0 +1,161 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pborman/uuid"
+	"go.uber.org/cadence"
+
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	startWorkflowActivityParams struct {
+		DomainName    string
+		ScheduleID    string
+		ScheduledTime time.Time
+		Action        StartWorkflowAction
+	}
+
+	workflowActivityParams struct {
+		DomainName string
+		Run        RunInfo
+		// Terminate and Reason are only used by stopWorkflowActivity
+		Terminate bool
+		Reason    string
+	}
+)
+
+// getRunWorkflowID returns the ID of the workflow started for a scheduled time, it is deterministic
+// so that the retries of startWorkflowActivity don't start the same workflow twice
+func getRunWorkflowID(scheduleID string, scheduledTime time.Time) string {
+	return fmt.Sprintf("%v-%v", scheduleID, scheduledTime.UTC().Format(time.RFC3339))
+}
+
+func startWorkflowActivity(ctx context.Context, params startWorkflowActivityParams) (*RunInfo, error) {
+	workflowID := getRunWorkflowID(params.ScheduleID, params.ScheduledTime)
+	resp, err := getClient(ctx).StartWorkflowExecution(ctx, &types.StartWorkflowExecutionRequest{
+		Domain:                              params.DomainName,
+		WorkflowID:                          workflowID,
+		WorkflowType:                        &types.WorkflowType{Name: params.Action.WorkflowType},
+		TaskList:                            &types.TaskList{Name: params.Action.TaskList},
+		Input:                               params.Action.Input,
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(params.Action.ExecutionStartToCloseTimeoutSeconds),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(params.Action.TaskStartToCloseTimeoutSeconds),
+		Identity:                            WorkflowTypeName,
+		RequestID:                           uuid.New(),
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyRejectDuplicate.Ptr(),
+	})
+	var runID string
+	switch e := err.(type) {
+	case nil:
+		runID = resp.GetRunID()
+	case *types.WorkflowExecutionAlreadyStartedError:
+		// started by a previous attempt of this activity
+		runID = e.RunID
+	case *types.BadRequestError, *types.EntityNotExistsError:
+		return nil, cadence.NewCustomError(errReasonNonRetriable, err.Error())
+	default:
+		return nil, err
+	}
+	return &RunInfo{
+		WorkflowID:    workflowID,
+		RunID:         runID,
+		ScheduledTime: params.ScheduledTime,
+	}, nil
+}
+
+func isWorkflowRunningActivity(ctx context.Context, params workflowActivityParams) (bool, error) {
+	resp, err := getClient(ctx).DescribeWorkflowExecution(ctx, &types.DescribeWorkflowExecutionRequest{
+		Domain: params.DomainName,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: params.Run.WorkflowID,
+			RunID:      params.Run.RunID,
+		},
+	})
+	switch err.(type) {
+	case nil:
+		return resp.GetWorkflowExecutionInfo().CloseStatus == nil, nil
+	case *types.EntityNotExistsError:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func stopWorkflowActivity(ctx context.Context, params workflowActivityParams) error {
+	execution := &types.WorkflowExecution{
+		WorkflowID: params.Run.WorkflowID,
+		RunID:      params.Run.RunID,
+	}
+	var err error
+	if params.Terminate {
+		err = getClient(ctx).TerminateWorkflowExecution(ctx, &types.TerminateWorkflowExecutionRequest{
+			Domain:            params.DomainName,
+			WorkflowExecution: execution,
+			Reason:            params.Reason,
+			Identity:          WorkflowTypeName,
+		})
+	} else {
+		err = getClient(ctx).RequestCancelWorkflowExecution(ctx, &types.RequestCancelWorkflowExecutionRequest{
+			Domain:            params.DomainName,
+			WorkflowExecution: execution,
+			Identity:          WorkflowTypeName,
+			RequestID:         uuid.New(),
+			Cause:             params.Reason,
+		})
+	}
+	switch err.(type) {
+	case *types.EntityNotExistsError, *types.WorkflowExecutionAlreadyCompletedError:
+		return nil
+	default:
+		return err
+	}
+}
+
+func recordScheduleActivity(ctx context.Context, record ScheduleRecord) error {
+	return getScheduler(ctx).store.upsert(ctx, &record)
+}
+
+func scheduleExistsActivity(ctx context.Context, record ScheduleRecord) (bool, error) {
+	stored, err := getScheduler(ctx).store.get(ctx, record.DomainName, record.ScheduleID)
+	return stored != nil, err
+}
+
+func deleteScheduleActivity(ctx context.Context, record ScheduleRecord) error {
+	return getScheduler(ctx).store.delete(ctx, record.DomainName, record.ScheduleID)
+}
+
+func getClient(ctx context.Context) frontend.Client {
+	return getScheduler(ctx).clientBean.GetFrontendClient()
+}
+
+func getScheduler(ctx context.Context) *Scheduler {
+	return ctx.Value(schedulerContextKey).(*Scheduler)
+}
@@ -0,0 +1,273 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/worker"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/common/types"
+)
+
+type activitiesTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	activityEnv     *testsuite.TestActivityEnvironment
+	mockResource    *resource.Test
+	mockConfigStore *persistence.MockConfigStoreManager
+	scheduler       *Scheduler
+}
+
+func TestActivitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(activitiesTestSuite))
+}
+
+func (s *activitiesTestSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockResource = resource.NewTest(s.T(), controller, metrics.Worker)
+	s.mockConfigStore = persistence.NewMockConfigStoreManager(controller)
+	s.scheduler = &Scheduler{
+		clientBean: s.mockResource.ClientBean,
+		store:      newStore(s.mockConfigStore),
+		logger:     s.mockResource.Logger,
+	}
+
+	s.activityEnv = s.NewTestActivityEnvironment()
+	s.activityEnv.SetTestTimeout(time.Second * 5)
+	s.activityEnv.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: context.WithValue(context.Background(), schedulerContextKey, s.scheduler),
+	})
+	s.activityEnv.RegisterActivityWithOptions(startWorkflowActivity, activity.RegisterOptions{Name: startWorkflowActivityName})
+	s.activityEnv.RegisterActivityWithOptions(isWorkflowRunningActivity, activity.RegisterOptions{Name: isWorkflowRunningActivityName})
+	s.activityEnv.RegisterActivityWithOptions(stopWorkflowActivity, activity.RegisterOptions{Name: stopWorkflowActivityName})
+	s.activityEnv.RegisterActivityWithOptions(recordScheduleActivity, activity.RegisterOptions{Name: recordScheduleActivityName})
+	s.activityEnv.RegisterActivityWithOptions(scheduleExistsActivity, activity.RegisterOptions{Name: scheduleExistsActivityName})
+	s.activityEnv.RegisterActivityWithOptions(deleteScheduleActivity, activity.RegisterOptions{Name: deleteScheduleActivityName})
+}
+
+func (s *activitiesTestSuite) TearDownTest() {
+	s.mockResource.Finish(s.T())
+}
+
+func (s *activitiesTestSuite) TestStartWorkflowActivity() {
+	scheduledTime := time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)
+	params := startWorkflowActivityParams{
+		DomainName:    "test-domain",
+		ScheduleID:    "test-schedule",
+		ScheduledTime: scheduledTime,
+		Action:        testSpec(OverlapPolicySkip).Action,
+	}
+	s.mockResource.FrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.StartWorkflowExecutionRequest, opts ...interface{}) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal("test-domain", request.Domain)
+			s.Equal("test-schedule-2024-01-01T00:10:00Z", request.WorkflowID)
+			s.Equal("test-workflow", request.WorkflowType.Name)
+			s.Equal(types.WorkflowIDReusePolicyRejectDuplicate, request.GetWorkflowIDReusePolicy())
+			return &types.StartWorkflowExecutionResponse{RunID: "run-id"}, nil
+		},
+	)
+	value, err := s.activityEnv.ExecuteActivity(startWorkflowActivityName, params)
+	s.NoError(err)
+	var run RunInfo
+	s.NoError(value.Get(&run))
+	s.Equal(RunInfo{WorkflowID: "test-schedule-2024-01-01T00:10:00Z", RunID: "run-id", ScheduledTime: scheduledTime}, run)
+
+	// the workflow started by a previous attempt is returned
+	s.mockResource.FrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).
+		Return(nil, &types.WorkflowExecutionAlreadyStartedError{RunID: "previous-run-id"})
+	value, err = s.activityEnv.ExecuteActivity(startWorkflowActivityName, params)
+	s.NoError(err)
+	s.NoError(value.Get(&run))
+	s.Equal("previous-run-id", run.RunID)
+
+	s.mockResource.FrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).
+		Return(nil, &types.EntityNotExistsError{Message: "domain does not exist"})
+	_, err = s.activityEnv.ExecuteActivity(startWorkflowActivityName, params)
+	s.Error(err)
+	s.Contains(err.Error(), errReasonNonRetriable)
+}
+
+func (s *activitiesTestSuite) TestIsWorkflowRunningActivity() {
+	params := workflowActivityParams{
+		DomainName: "test-domain",
+		Run:        RunInfo{WorkflowID: "wid", RunID: "rid"},
+	}
+	s.mockResource.FrontendClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &types.WorkflowExecutionInfo{},
+	}, nil)
+	s.assertRunning(params, true)
+
+	s.mockResource.FrontendClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &types.WorkflowExecutionInfo{
+			CloseStatus: types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+		},
+	}, nil)
+	s.assertRunning(params, false)
+
+	s.mockResource.FrontendClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil, &types.EntityNotExistsError{})
+	s.assertRunning(params, false)
+}
+
+func (s *activitiesTestSuite) TestStopWorkflowActivity() {
+	params := workflowActivityParams{
+		DomainName: "test-domain",
+		Run:        RunInfo{WorkflowID: "wid", RunID: "rid"},
+		Reason:     "reason",
+	}
+	s.mockResource.FrontendClient.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.WorkflowExecutionAlreadyCompletedError{})
+	_, err := s.activityEnv.ExecuteActivity(stopWorkflowActivityName, params)
+	s.NoError(err)
+
+	params.Terminate = true
+	s.mockResource.FrontendClient.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil)
+	_, err = s.activityEnv.ExecuteActivity(stopWorkflowActivityName, params)
+	s.NoError(err)
+}
+
+func (s *activitiesTestSuite) assertRunning(params workflowActivityParams, expected bool) {
+	value, err := s.activityEnv.ExecuteActivity(isWorkflowRunningActivityName, params)
+	s.NoError(err)
+	var running bool
+	s.NoError(value.Get(&running))
+	s.Equal(expected, running)
+}
+
+func (s *activitiesTestSuite) TestScheduleRecordActivities() {
+	record := ScheduleRecord{
+		DomainName: "test-domain",
+		ScheduleID: "test-schedule",
+		Spec:       *testSpec(OverlapPolicySkip),
+	}
+	otherRecord := ScheduleRecord{
+		DomainName: "test-domain",
+		ScheduleID: "other-schedule",
+		Spec:       *testSpec(OverlapPolicyConcurrent),
+	}
+	snapshot := s.recordSnapshot(1, otherRecord)
+
+	// the record is added to the snapshot, and the update is retried on version conflict
+	s.mockConfigStore.EXPECT().FetchDynamicConfig(gomock.Any(), persistence.ScheduleConfig).Return(snapshot, nil).Times(2)
+	s.mockConfigStore.EXPECT().UpdateDynamicConfig(gomock.Any(), gomock.Any(), persistence.ScheduleConfig).
+		Return(&persistence.ConditionFailedError{})
+	s.mockConfigStore.EXPECT().UpdateDynamicConfig(gomock.Any(), gomock.Any(), persistence.ScheduleConfig).DoAndReturn(
+		func(ctx context.Context, request *persistence.UpdateDynamicConfigRequest, cfgType persistence.ConfigType) error {
+			s.Equal(int64(2), request.Snapshot.Version)
+			s.Len(request.Snapshot.Values.Entries, 2)
+			return nil
+		},
+	)
+	_, err := s.activityEnv.ExecuteActivity(recordScheduleActivityName, record)
+	s.Error(err)
+	_, err = s.activityEnv.ExecuteActivity(recordScheduleActivityName, record)
+	s.NoError(err)
+
+	s.mockConfigStore.EXPECT().FetchDynamicConfig(gomock.Any(), persistence.ScheduleConfig).Return(s.recordSnapshot(2, otherRecord, record), nil)
+	value, err := s.activityEnv.ExecuteActivity(scheduleExistsActivityName, record)
+	s.NoError(err)
+	var exists bool
+	s.NoError(value.Get(&exists))
+	s.True(exists)
+
+	s.mockConfigStore.EXPECT().FetchDynamicConfig(gomock.Any(), persistence.ScheduleConfig).Return(nil, nil)
+	value, err = s.activityEnv.ExecuteActivity(scheduleExistsActivityName, record)
+	s.NoError(err)
+	s.NoError(value.Get(&exists))
+	s.False(exists)
+
+	s.mockConfigStore.EXPECT().FetchDynamicConfig(gomock.Any(), persistence.ScheduleConfig).Return(s.recordSnapshot(2, otherRecord, record), nil)
+	s.mockConfigStore.EXPECT().UpdateDynamicConfig(gomock.Any(), gomock.Any(), persistence.ScheduleConfig).DoAndReturn(
+		func(ctx context.Context, request *persistence.UpdateDynamicConfigRequest, cfgType persistence.ConfigType) error {
+			s.Equal(int64(3), request.Snapshot.Version)
+			s.Len(request.Snapshot.Values.Entries, 1)
+			s.Equal(GetWorkflowID(otherRecord.DomainName, otherRecord.ScheduleID), request.Snapshot.Values.Entries[0].Name)
+			return nil
+		},
+	)
+	_, err = s.activityEnv.ExecuteActivity(deleteScheduleActivityName, record)
+	s.NoError(err)
+}
+
+func (s *activitiesTestSuite) TestRestoreSchedules() {
+	running := ScheduleRecord{
+		DomainName: "test-domain",
+		ScheduleID: "running-schedule",
+		Spec:       *testSpec(OverlapPolicySkip),
+	}
+	gone := ScheduleRecord{
+		DomainName:  "test-domain",
+		ScheduleID:  "gone-schedule",
+		Spec:        *testSpec(OverlapPolicySkip),
+		Paused:      true,
+		PauseReason: "maintenance",
+	}
+	s.mockConfigStore.EXPECT().FetchDynamicConfig(gomock.Any(), persistence.ScheduleConfig).Return(s.recordSnapshot(1, running, gone), nil)
+	s.mockResource.FrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.StartWorkflowExecutionRequest, opts ...interface{}) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(common.SchedulerLocalDomainName, request.Domain)
+			if request.WorkflowID == "test-domain/running-schedule" {
+				return nil, &types.WorkflowExecutionAlreadyStartedError{}
+			}
+			s.Equal("test-domain/gone-schedule", request.WorkflowID)
+			var params ScheduleParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.True(params.Restored)
+			s.True(params.State.Paused)
+			s.Equal("maintenance", params.State.PauseReason)
+			return &types.StartWorkflowExecutionResponse{RunID: "run-id"}, nil
+		},
+	).Times(2)
+
+	s.scheduler.restoreSchedules()
+}
+
+func (s *activitiesTestSuite) recordSnapshot(version int64, records ...ScheduleRecord) *persistence.FetchDynamicConfigResponse {
+	entries := make([]*types.DynamicConfigEntry, 0, len(records))
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		s.NoError(err)
+		entries = append(entries, &types.DynamicConfigEntry{
+			Name: GetWorkflowID(record.DomainName, record.ScheduleID),
+			Values: []*types.DynamicConfigValue{{
+				Value: &types.DataBlob{EncodingType: types.EncodingTypeJSON.Ptr(), Data: data},
+			}},
+		})
+	}
+	return &persistence.FetchDynamicConfigResponse{
+		Snapshot: &persistence.DynamicConfigSnapshot{
+			Version: version,
+			Values:  &types.DynamicConfigBlob{Entries: entries},
+		},
+	}
+}
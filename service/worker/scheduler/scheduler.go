@@ -0,0 +1,181 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pborman/uuid"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/client"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	restoreTimeout = time.Minute
+)
+
+type (
+	// BootstrapParams contains the set of params needed to bootstrap the scheduler
+	BootstrapParams struct {
+		// ServiceClient is an instance of cadence service client
+		ServiceClient workflowserviceclient.Interface
+		// MetricsClient is an instance of metrics object for emitting stats
+		MetricsClient metrics.Client
+		Logger        log.Logger
+		// TallyScope is an instance of tally metrics scope
+		TallyScope tally.Scope
+		// ClientBean is an instance of client.Bean for a collection of clients
+		ClientBean client.Bean
+		// ConfigStoreManager persists the schedule records
+		ConfigStoreManager persistence.ConfigStoreManager
+	}
+
+	// Scheduler runs the workflows of the cron schedules. Every schedule is a long running workflow
+	// in the scheduler domain, which starts the workflows of the schedule in the target domain.
+	// The schedules are also persisted as records, and the workflows which are gone are restored from them.
+	Scheduler struct {
+		svcClient     workflowserviceclient.Interface
+		clientBean    client.Bean
+		store         *store
+		metricsClient metrics.Client
+		tallyScope    tally.Scope
+		logger        log.Logger
+		worker        worker.Worker
+	}
+)
+
+// New returns a new instance of Scheduler
+func New(params *BootstrapParams) *Scheduler {
+	return &Scheduler{
+		svcClient:     params.ServiceClient,
+		metricsClient: params.MetricsClient,
+		tallyScope:    params.TallyScope,
+		logger:        params.Logger.WithTags(tag.ComponentScheduler),
+		clientBean:    params.ClientBean,
+		store:         newStore(params.ConfigStoreManager),
+	}
+}
+
+// Start starts the worker
+func (s *Scheduler) Start() error {
+	ctx := context.WithValue(context.Background(), schedulerContextKey, s)
+	workerOpts := worker.Options{
+		MetricsScope:              s.tallyScope,
+		BackgroundActivityContext: ctx,
+		Tracer:                    opentracing.GlobalTracer(),
+	}
+	schedulerWorker := worker.New(s.svcClient, common.SchedulerLocalDomainName, TaskListName, workerOpts)
+	schedulerWorker.RegisterWorkflowWithOptions(ScheduleWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	schedulerWorker.RegisterWorkflowWithOptions(BackfillWorkflow, workflow.RegisterOptions{Name: BackfillWorkflowTypeName})
+	schedulerWorker.RegisterActivityWithOptions(startWorkflowActivity, activity.RegisterOptions{Name: startWorkflowActivityName})
+	schedulerWorker.RegisterActivityWithOptions(isWorkflowRunningActivity, activity.RegisterOptions{Name: isWorkflowRunningActivityName})
+	schedulerWorker.RegisterActivityWithOptions(stopWorkflowActivity, activity.RegisterOptions{Name: stopWorkflowActivityName})
+	schedulerWorker.RegisterActivityWithOptions(recordScheduleActivity, activity.RegisterOptions{Name: recordScheduleActivityName})
+	schedulerWorker.RegisterActivityWithOptions(scheduleExistsActivity, activity.RegisterOptions{Name: scheduleExistsActivityName})
+	schedulerWorker.RegisterActivityWithOptions(deleteScheduleActivity, activity.RegisterOptions{Name: deleteScheduleActivityName})
+	s.worker = schedulerWorker
+	if err := schedulerWorker.Start(); err != nil {
+		return err
+	}
+	go s.restoreSchedules()
+	return nil
+}
+
+// Stop stops the worker
+func (s *Scheduler) Stop() {
+	s.worker.Stop()
+}
+
+// restoreSchedules starts the workflows of the schedule records which are not running, e.g. terminated by mistake.
+// Every worker host does it when it starts, the workflows which are already running are left untouched.
+func (s *Scheduler) restoreSchedules() {
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+
+	records, err := s.store.list(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list schedules.", tag.Error(err))
+		return
+	}
+	for _, record := range records {
+		if err := s.restoreSchedule(ctx, record); err != nil {
+			s.logger.Error("Failed to restore schedule.", tag.WorkflowDomainName(record.DomainName), tag.WorkflowID(GetWorkflowID(record.DomainName, record.ScheduleID)), tag.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) restoreSchedule(ctx context.Context, record *ScheduleRecord) error {
+	input, err := json.Marshal(ScheduleParams{
+		DomainName: record.DomainName,
+		ScheduleID: record.ScheduleID,
+		Spec:       record.Spec,
+		State: ScheduleState{
+			Paused:      record.Paused,
+			PauseReason: record.PauseReason,
+		},
+		Restored: true,
+	})
+	if err != nil {
+		return err
+	}
+	customDomain, err := json.Marshal(record.DomainName)
+	if err != nil {
+		return err
+	}
+	_, err = s.clientBean.GetFrontendClient().StartWorkflowExecution(ctx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SchedulerLocalDomainName,
+		WorkflowID:                          GetWorkflowID(record.DomainName, record.ScheduleID),
+		WorkflowType:                        &types.WorkflowType{Name: WorkflowTypeName},
+		TaskList:                            &types.TaskList{Name: TaskListName},
+		Input:                               input,
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(int32(InfiniteDuration.Seconds())),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(DefaultTaskStartToCloseTimeoutSeconds),
+		Identity:                            WorkflowTypeName,
+		RequestID:                           uuid.New(),
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+		SearchAttributes: &types.SearchAttributes{
+			IndexedFields: map[string][]byte{"CustomDomain": customDomain},
+		},
+	})
+	if _, ok := err.(*types.WorkflowExecutionAlreadyStartedError); ok {
+		return nil
+	}
+	if err == nil {
+		s.logger.Info("Restored schedule.", tag.WorkflowDomainName(record.DomainName), tag.WorkflowID(GetWorkflowID(record.DomainName, record.ScheduleID)))
+	}
+	return err
+}
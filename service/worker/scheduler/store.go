@@ -0,0 +1,150 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// ScheduleRecord is the persisted entity of a schedule. The schedule workflow keeps it up to date,
+	// and the scheduler restores the workflows of the schedules whose workflows are gone.
+	ScheduleRecord struct {
+		DomainName  string
+		ScheduleID  string
+		Spec        ScheduleSpec
+		Paused      bool
+		PauseReason string
+	}
+
+	// store keeps all the schedule records in a single versioned snapshot of the config store, keyed by
+	// the workflow IDs of the schedules. Concurrent updates fail on the version, and are retried by the activities.
+	store struct {
+		manager persistence.ConfigStoreManager
+	}
+)
+
+func newStore(manager persistence.ConfigStoreManager) *store {
+	return &store{manager: manager}
+}
+
+func (s *store) list(ctx context.Context) ([]*ScheduleRecord, error) {
+	_, entries, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*ScheduleRecord, 0, len(entries))
+	for _, entry := range entries {
+		record, err := decodeRecord(entry)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// get returns nil if the schedule is not found
+func (s *store) get(ctx context.Context, domainName, scheduleID string) (*ScheduleRecord, error) {
+	_, entries, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := GetWorkflowID(domainName, scheduleID)
+	for _, entry := range entries {
+		if entry.Name == key {
+			return decodeRecord(entry)
+		}
+	}
+	return nil, nil
+}
+
+func (s *store) upsert(ctx context.Context, record *ScheduleRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	key := GetWorkflowID(record.DomainName, record.ScheduleID)
+	return s.update(ctx, key, &types.DynamicConfigEntry{
+		Name: key,
+		Values: []*types.DynamicConfigValue{{
+			Value: &types.DataBlob{
+				EncodingType: types.EncodingTypeJSON.Ptr(),
+				Data:         data,
+			},
+		}},
+	})
+}
+
+func (s *store) delete(ctx context.Context, domainName, scheduleID string) error {
+	return s.update(ctx, GetWorkflowID(domainName, scheduleID), nil)
+}
+
+// update replaces the entry of the key, or removes it if newEntry is nil
+func (s *store) update(ctx context.Context, key string, newEntry *types.DynamicConfigEntry) error {
+	version, entries, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	newEntries := make([]*types.DynamicConfigEntry, 0, len(entries)+1)
+	for _, entry := range entries {
+		if entry.Name != key {
+			newEntries = append(newEntries, entry)
+		}
+	}
+	if newEntry != nil {
+		newEntries = append(newEntries, newEntry)
+	}
+	return s.manager.UpdateDynamicConfig(ctx, &persistence.UpdateDynamicConfigRequest{
+		Snapshot: &persistence.DynamicConfigSnapshot{
+			Version: version + 1,
+			Values: &types.DynamicConfigBlob{
+				Entries: newEntries,
+			},
+		},
+	}, persistence.ScheduleConfig)
+}
+
+func (s *store) load(ctx context.Context) (int64, []*types.DynamicConfigEntry, error) {
+	resp, err := s.manager.FetchDynamicConfig(ctx, persistence.ScheduleConfig)
+	if err != nil || resp == nil {
+		return 0, nil, err
+	}
+	if resp.Snapshot.Values == nil {
+		return resp.Snapshot.Version, nil, nil
+	}
+	return resp.Snapshot.Version, resp.Snapshot.Values.Entries, nil
+}
+
+func decodeRecord(entry *types.DynamicConfigEntry) (*ScheduleRecord, error) {
+	if len(entry.Values) != 1 {
+		return nil, &types.InternalServiceError{Message: "invalid schedule record " + entry.Name}
+	}
+	var record ScheduleRecord
+	if err := json.Unmarshal(entry.Values[0].Value.GetData(), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
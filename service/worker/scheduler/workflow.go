@@ -0,0 +1,642 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
+)
+
+type (
+	contextKey string
+)
+
+const (
+	schedulerContextKey contextKey = "schedulerContext"
+	// TaskListName is the tasklist of the schedule workflows
+	TaskListName = "cadence-sys-scheduler-tasklist"
+	// WorkflowTypeName is the workflow type of the schedule workflows
+	WorkflowTypeName = "cadence-sys-schedule-workflow"
+	// BackfillWorkflowTypeName is the workflow type of the child workflows which take the actions of backfill requests
+	BackfillWorkflowTypeName = "cadence-sys-schedule-backfill-workflow"
+
+	startWorkflowActivityName     = "cadence-sys-schedule-startWorkflow-activity"
+	isWorkflowRunningActivityName = "cadence-sys-schedule-isWorkflowRunning-activity"
+	stopWorkflowActivityName      = "cadence-sys-schedule-stopWorkflow-activity"
+	recordScheduleActivityName    = "cadence-sys-schedule-recordSchedule-activity"
+	scheduleExistsActivityName    = "cadence-sys-schedule-scheduleExists-activity"
+	deleteScheduleActivityName    = "cadence-sys-schedule-deleteSchedule-activity"
+
+	// QueryType is the query type to describe a schedule
+	QueryType = "describe"
+	// UpdateSignal replaces the spec of a schedule, the payload is a ScheduleSpec
+	UpdateSignal = "update"
+	// PauseSignal pauses a schedule, the payload is the reason
+	PauseSignal = "pause"
+	// UnpauseSignal resumes a paused schedule
+	UnpauseSignal = "unpause"
+	// BackfillSignal takes the actions of a time range in the past, the payload is a BackfillRequest
+	BackfillSignal = "backfill"
+	// DeleteSignal deletes the schedule record and completes the schedule workflow, the payload is the reason
+	DeleteSignal = "delete"
+
+	// OverlapPolicySkip doesn't start a workflow if the previous one is still running
+	OverlapPolicySkip = "skip"
+	// OverlapPolicyBufferOne starts a workflow once the previous one is closed, at most one start is buffered
+	OverlapPolicyBufferOne = "buffer-one"
+	// OverlapPolicyConcurrent starts a workflow regardless of the previous one
+	OverlapPolicyConcurrent = "concurrent"
+	// OverlapPolicyCancelOther requests cancellation of the previous workflow and starts a new one
+	OverlapPolicyCancelOther = "cancel-other"
+	// OverlapPolicyTerminateOther terminates the previous workflow and starts a new one
+	OverlapPolicyTerminateOther = "terminate-other"
+
+	// InfiniteDuration is the execution timeout of the schedule workflows
+	InfiniteDuration = 20 * 365 * 24 * time.Hour
+	// DefaultTaskStartToCloseTimeoutSeconds is the decision timeout of the started workflows if it is not set
+	DefaultTaskStartToCloseTimeoutSeconds = 10
+	// MaxBackfillActions is the max number of actions taken by one backfill request
+	MaxBackfillActions = 100
+
+	backfillWorkflowIDPrefix = "backfill-"
+	// maxIterationsPerRun bounds the history size, the workflow continues as new after that many iterations
+	maxIterationsPerRun = 500
+	// bufferCheckInterval is how often the previous workflow is checked when a start is buffered
+	bufferCheckInterval = time.Minute
+
+	errMsgDomainNameIsEmpty    = "domainName is empty"
+	errMsgScheduleIDIsEmpty    = "scheduleID is empty"
+	errMsgWorkflowTypeIsEmpty  = "workflowType is empty"
+	errMsgTaskListIsEmpty      = "taskList is empty"
+	errMsgInvalidTimeout       = "executionStartToCloseTimeoutSeconds must be positive"
+	errMsgInvalidOverlapPolicy = "overlapPolicy is not valid"
+	errMsgWorkflowIDMismatch   = "workflow ID doesn't match the domain name and the schedule ID"
+	errReasonNonRetriable      = "cadence-sys-schedule-nonRetriable-error"
+)
+
+// AllOverlapPolicies is the list of all overlap policies
+var AllOverlapPolicies = []string{
+	OverlapPolicySkip,
+	OverlapPolicyBufferOne,
+	OverlapPolicyConcurrent,
+	OverlapPolicyCancelOther,
+	OverlapPolicyTerminateOther,
+}
+
+type (
+	// ScheduleSpec defines when and what a schedule starts
+	ScheduleSpec struct {
+		// CronSchedule is a standard cron expression, in UTC
+		CronSchedule string
+		// OverlapPolicy decides what to do when the previous workflow is still running, default to skip
+		OverlapPolicy string
+		// Action is the template of the started workflows
+		Action StartWorkflowAction
+	}
+
+	// StartWorkflowAction is the template of the workflows started by a schedule
+	StartWorkflowAction struct {
+		WorkflowType                        string
+		TaskList                            string
+		Input                               []byte
+		ExecutionStartToCloseTimeoutSeconds int32
+		TaskStartToCloseTimeoutSeconds      int32
+	}
+
+	// ScheduleParams is the input of the schedule workflow
+	ScheduleParams struct {
+		DomainName string
+		ScheduleID string
+		Spec       ScheduleSpec
+		// State is carried over when the workflow continues as new
+		State ScheduleState
+		// Restored is set when the scheduler restores the workflow of a schedule record, so that
+		// the workflow checks that the schedule is not deleted in the meantime
+		Restored bool
+	}
+
+	// ScheduleState is the runtime state of a schedule
+	ScheduleState struct {
+		Paused      bool
+		PauseReason string
+		// LastScheduledTime is the last time the schedule was due, whether the workflow was started or not
+		LastScheduledTime time.Time
+		// LastRun is the last workflow started by the schedule
+		LastRun *RunInfo
+		// BufferedTime is the scheduled time buffered by the buffer-one overlap policy
+		BufferedTime *time.Time
+		TotalRuns    int64
+		SkippedRuns  int64
+		FailedRuns   int64
+	}
+
+	// RunInfo identifies a workflow started by a schedule
+	RunInfo struct {
+		WorkflowID    string
+		RunID         string
+		ScheduledTime time.Time
+	}
+
+	// BackfillRequest is the payload of BackfillSignal
+	BackfillRequest struct {
+		StartTime time.Time
+		EndTime   time.Time
+		// OverlapPolicy of the backfilled workflows, default to concurrent
+		OverlapPolicy string
+	}
+
+	// BackfillParams is the input of the backfill workflow
+	BackfillParams struct {
+		DomainName     string
+		ScheduleID     string
+		Action         StartWorkflowAction
+		ScheduledTimes []time.Time
+		OverlapPolicy  string
+	}
+
+	// DescribeResult is the result of QueryType
+	DescribeResult struct {
+		DomainName  string
+		ScheduleID  string
+		Spec        ScheduleSpec
+		State       ScheduleState
+		NextRunTime time.Time
+	}
+
+	scheduleWorkflow struct {
+		params ScheduleParams
+		cron   cron.Schedule
+		logger *zap.Logger
+		// deleted is set once the schedule record is deleted, the workflow completes then
+		deleted bool
+	}
+)
+
+// getWorkflowID is replaced in the tests, where the ID of the tested workflow can't be set
+var getWorkflowID = func(ctx workflow.Context) string {
+	return workflow.GetInfo(ctx).WorkflowExecution.ID
+}
+
+// GetWorkflowID returns the ID of the workflow running the schedule
+func GetWorkflowID(domainName, scheduleID string) string {
+	return domainName + common.SchedulerWorkflowIDSeparator + scheduleID
+}
+
+func getBackfillWorkflowID(domainName, scheduleID string, startTime, endTime time.Time) string {
+	return GetWorkflowID(domainName, scheduleID) + common.SchedulerWorkflowIDSeparator + backfillWorkflowIDPrefix +
+		fmt.Sprintf("%v-%v", startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339))
+}
+
+// ValidateSpec validates the spec of a schedule and fills in the defaults
+func ValidateSpec(spec *ScheduleSpec) (cron.Schedule, error) {
+	if spec.OverlapPolicy == "" {
+		spec.OverlapPolicy = OverlapPolicySkip
+	}
+	if !IsValidOverlapPolicy(spec.OverlapPolicy) {
+		return nil, errors.New(errMsgInvalidOverlapPolicy)
+	}
+	if spec.Action.WorkflowType == "" {
+		return nil, errors.New(errMsgWorkflowTypeIsEmpty)
+	}
+	if spec.Action.TaskList == "" {
+		return nil, errors.New(errMsgTaskListIsEmpty)
+	}
+	if spec.Action.ExecutionStartToCloseTimeoutSeconds <= 0 {
+		return nil, errors.New(errMsgInvalidTimeout)
+	}
+	if spec.Action.TaskStartToCloseTimeoutSeconds <= 0 {
+		spec.Action.TaskStartToCloseTimeoutSeconds = DefaultTaskStartToCloseTimeoutSeconds
+	}
+	return backoff.ValidateSchedule(spec.CronSchedule)
+}
+
+// IsValidOverlapPolicy returns whether the overlap policy is supported
+func IsValidOverlapPolicy(policy string) bool {
+	for _, p := range AllOverlapPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleWorkflow starts a workflow every time the cron schedule of a schedule is due
+func ScheduleWorkflow(ctx workflow.Context, params ScheduleParams) error {
+	schedule, err := validateParams(&params)
+	if err != nil {
+		return err
+	}
+	// the frontend authorizes the requests of the schedule workflows in the domain of their workflow IDs,
+	// so the workflow must not act in any other domain
+	if getWorkflowID(ctx) != GetWorkflowID(params.DomainName, params.ScheduleID) {
+		return errors.New(errMsgWorkflowIDMismatch)
+	}
+	s := &scheduleWorkflow{
+		params: params,
+		cron:   schedule,
+		logger: workflow.GetLogger(ctx).With(
+			zap.String("domain", params.DomainName),
+			zap.String("scheduleID", params.ScheduleID),
+		),
+	}
+	if s.params.Restored {
+		s.params.Restored = false
+		var exists bool
+		ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+		if err := workflow.ExecuteActivity(ao, scheduleExistsActivityName, s.record()).Get(ctx, &exists); err != nil {
+			return err
+		}
+		if !exists {
+			s.logger.Info("Schedule is deleted, skip restoring it.")
+			return nil
+		}
+	}
+	if s.params.State.LastScheduledTime.IsZero() {
+		// a new schedule doesn't take the actions before it is created
+		s.params.State.LastScheduledTime = workflow.Now(ctx)
+		s.saveRecord(ctx)
+	}
+
+	err = workflow.SetQueryHandler(ctx, QueryType, func() (*DescribeResult, error) {
+		return s.describe(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < maxIterationsPerRun && !s.deleted; i++ {
+		s.takeBufferedAction(ctx)
+
+		now := workflow.Now(ctx)
+		nextRunTime := s.cron.Next(s.params.State.LastScheduledTime)
+		if !nextRunTime.After(now) {
+			s.takeScheduledAction(ctx, now)
+			continue
+		}
+
+		wait := nextRunTime.Sub(now)
+		if s.params.State.BufferedTime != nil && wait > bufferCheckInterval {
+			wait = bufferCheckInterval
+		}
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(workflow.Future) {})
+		s.addSignalHandlers(ctx, selector)
+		selector.Select(ctx)
+		cancelTimer()
+	}
+
+	// handle the pending signals before continuing as new, otherwise they are lost
+	drained := false
+	selector := workflow.NewSelector(ctx)
+	s.addSignalHandlers(ctx, selector)
+	selector.AddDefault(func() {
+		drained = true
+	})
+	for !drained && !s.deleted {
+		selector.Select(ctx)
+	}
+	if s.deleted {
+		return nil
+	}
+	return workflow.NewContinueAsNewError(ctx, WorkflowTypeName, s.params)
+}
+
+func (s *scheduleWorkflow) describe() *DescribeResult {
+	return &DescribeResult{
+		DomainName:  s.params.DomainName,
+		ScheduleID:  s.params.ScheduleID,
+		Spec:        s.params.Spec,
+		State:       s.params.State,
+		NextRunTime: s.cron.Next(s.params.State.LastScheduledTime),
+	}
+}
+
+func (s *scheduleWorkflow) addSignalHandlers(ctx workflow.Context, selector workflow.Selector) {
+	selector.AddReceive(workflow.GetSignalChannel(ctx, UpdateSignal), func(c workflow.Channel, more bool) {
+		var spec ScheduleSpec
+		c.Receive(ctx, &spec)
+		schedule, err := ValidateSpec(&spec)
+		if err != nil {
+			s.logger.Warn("Ignore invalid schedule spec.", zap.Error(err))
+			return
+		}
+		s.params.Spec = spec
+		s.cron = schedule
+		// the new spec takes effect from now on
+		s.params.State.LastScheduledTime = workflow.Now(ctx)
+		s.saveRecord(ctx)
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, PauseSignal), func(c workflow.Channel, more bool) {
+		var reason string
+		c.Receive(ctx, &reason)
+		s.params.State.Paused = true
+		s.params.State.PauseReason = reason
+		s.saveRecord(ctx)
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, UnpauseSignal), func(c workflow.Channel, more bool) {
+		c.Receive(ctx, nil)
+		s.params.State.Paused = false
+		s.params.State.PauseReason = ""
+		s.saveRecord(ctx)
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, BackfillSignal), func(c workflow.Channel, more bool) {
+		var request BackfillRequest
+		c.Receive(ctx, &request)
+		s.backfill(ctx, request)
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, DeleteSignal), func(c workflow.Channel, more bool) {
+		var reason string
+		c.Receive(ctx, &reason)
+		s.deleteRecord(ctx, reason)
+	})
+}
+
+func (s *scheduleWorkflow) record() ScheduleRecord {
+	return ScheduleRecord{
+		DomainName:  s.params.DomainName,
+		ScheduleID:  s.params.ScheduleID,
+		Spec:        s.params.Spec,
+		Paused:      s.params.State.Paused,
+		PauseReason: s.params.State.PauseReason,
+	}
+}
+
+// saveRecord persists the schedule, so that its workflow can be restored from the record
+func (s *scheduleWorkflow) saveRecord(ctx workflow.Context) {
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+	if err := workflow.ExecuteActivity(ao, recordScheduleActivityName, s.record()).Get(ctx, nil); err != nil {
+		s.logger.Error("Failed to record schedule.", zap.Error(err))
+	}
+}
+
+// deleteRecord deletes the schedule record, the workflow keeps running if it fails so that the schedule can be deleted again
+func (s *scheduleWorkflow) deleteRecord(ctx workflow.Context, reason string) {
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+	if err := workflow.ExecuteActivity(ao, deleteScheduleActivityName, s.record()).Get(ctx, nil); err != nil {
+		s.logger.Error("Failed to delete schedule.", zap.Error(err))
+		return
+	}
+	s.logger.Info("Schedule is deleted.", zap.String("reason", reason))
+	s.deleted = true
+}
+
+// takeScheduledAction takes the action of the latest due time, the earlier missed ones are skipped
+func (s *scheduleWorkflow) takeScheduledAction(ctx workflow.Context, now time.Time) {
+	state := &s.params.State
+	scheduledTime := s.cron.Next(state.LastScheduledTime)
+	for next := s.cron.Next(scheduledTime); !next.After(now); next = s.cron.Next(next) {
+		state.SkippedRuns++
+		scheduledTime = next
+	}
+	state.LastScheduledTime = scheduledTime
+	if state.Paused {
+		state.SkippedRuns++
+		return
+	}
+	s.takeAction(ctx, scheduledTime, s.params.Spec.OverlapPolicy)
+}
+
+func (s *scheduleWorkflow) takeBufferedAction(ctx workflow.Context) {
+	state := &s.params.State
+	if state.BufferedTime == nil || state.Paused || s.isLastRunRunning(ctx) {
+		return
+	}
+	scheduledTime := *state.BufferedTime
+	state.BufferedTime = nil
+	s.startWorkflow(ctx, scheduledTime)
+}
+
+func (s *scheduleWorkflow) takeAction(ctx workflow.Context, scheduledTime time.Time, overlapPolicy string) {
+	state := &s.params.State
+	if overlapPolicy != OverlapPolicyConcurrent && s.isLastRunRunning(ctx) {
+		switch overlapPolicy {
+		case OverlapPolicySkip:
+			state.SkippedRuns++
+			return
+		case OverlapPolicyBufferOne:
+			if state.BufferedTime != nil {
+				state.SkippedRuns++
+				return
+			}
+			state.BufferedTime = &scheduledTime
+			return
+		case OverlapPolicyCancelOther, OverlapPolicyTerminateOther:
+			s.stopLastRun(ctx, overlapPolicy == OverlapPolicyTerminateOther)
+		}
+	}
+	s.startWorkflow(ctx, scheduledTime)
+}
+
+func (s *scheduleWorkflow) backfill(ctx workflow.Context, request BackfillRequest) {
+	if request.OverlapPolicy == "" {
+		request.OverlapPolicy = OverlapPolicyConcurrent
+	}
+	if !IsValidOverlapPolicy(request.OverlapPolicy) {
+		s.logger.Warn("Ignore backfill request with invalid overlap policy.", zap.String("overlapPolicy", request.OverlapPolicy))
+		return
+	}
+	if now := workflow.Now(ctx); request.EndTime.After(now) {
+		request.EndTime = now
+	}
+	times := GetScheduledTimes(s.cron, request.StartTime, request.EndTime, MaxBackfillActions)
+	if len(times) == 0 {
+		return
+	}
+
+	// the actions are taken by a child workflow, so that a long backfill doesn't hold up the schedule
+	cwo := workflow.ChildWorkflowOptions{
+		WorkflowID:                   getBackfillWorkflowID(s.params.DomainName, s.params.ScheduleID, request.StartTime, request.EndTime),
+		TaskList:                     TaskListName,
+		ExecutionStartToCloseTimeout: InfiniteDuration,
+		TaskStartToCloseTimeout:      DefaultTaskStartToCloseTimeoutSeconds * time.Second,
+		ParentClosePolicy:            client.ParentClosePolicyAbandon,
+	}
+	params := BackfillParams{
+		DomainName:     s.params.DomainName,
+		ScheduleID:     s.params.ScheduleID,
+		Action:         s.params.Spec.Action,
+		ScheduledTimes: times,
+		OverlapPolicy:  request.OverlapPolicy,
+	}
+	future := workflow.ExecuteChildWorkflow(workflow.WithChildOptions(ctx, cwo), BackfillWorkflowTypeName, params)
+	if err := future.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+		s.logger.Error("Failed to start backfill workflow.", zap.Error(err))
+	}
+}
+
+// BackfillWorkflow starts the workflows of a backfill request one after another, the overlap policy applies
+// between the backfilled workflows
+func BackfillWorkflow(ctx workflow.Context, params BackfillParams) error {
+	if params.DomainName == "" {
+		return errors.New(errMsgDomainNameIsEmpty)
+	}
+	if params.ScheduleID == "" {
+		return errors.New(errMsgScheduleIDIsEmpty)
+	}
+	if !IsValidOverlapPolicy(params.OverlapPolicy) {
+		return errors.New(errMsgInvalidOverlapPolicy)
+	}
+	if !strings.HasPrefix(getWorkflowID(ctx), GetWorkflowID(params.DomainName, params.ScheduleID)+common.SchedulerWorkflowIDSeparator) {
+		return errors.New(errMsgWorkflowIDMismatch)
+	}
+	logger := workflow.GetLogger(ctx).With(
+		zap.String("domain", params.DomainName),
+		zap.String("scheduleID", params.ScheduleID),
+	)
+
+	var lastRun *RunInfo
+	for _, scheduledTime := range params.ScheduledTimes {
+		if lastRun != nil && params.OverlapPolicy != OverlapPolicyConcurrent && isWorkflowRunning(ctx, logger, params.DomainName, *lastRun) {
+			switch params.OverlapPolicy {
+			case OverlapPolicySkip:
+				continue
+			case OverlapPolicyBufferOne:
+				for running := true; running; running = isWorkflowRunning(ctx, logger, params.DomainName, *lastRun) {
+					if err := workflow.Sleep(ctx, bufferCheckInterval); err != nil {
+						return err
+					}
+				}
+			case OverlapPolicyCancelOther, OverlapPolicyTerminateOther:
+				stopWorkflow(ctx, logger, params.DomainName, params.ScheduleID, *lastRun, params.OverlapPolicy == OverlapPolicyTerminateOther)
+			}
+		}
+		if run, err := startWorkflow(ctx, params.DomainName, params.ScheduleID, params.Action, scheduledTime); err != nil {
+			logger.Error("Failed to start backfilled workflow.", zap.Time("scheduledTime", scheduledTime), zap.Error(err))
+		} else {
+			lastRun = run
+		}
+	}
+	return nil
+}
+
+// GetScheduledTimes returns at most limit times when the cron schedule is due within [startTime, endTime]
+func GetScheduledTimes(schedule cron.Schedule, startTime, endTime time.Time, limit int) []time.Time {
+	var times []time.Time
+	// cron.Next returns a time after the given one, and it works at the granularity of seconds
+	for t := schedule.Next(startTime.Add(-time.Second)); !t.After(endTime) && len(times) < limit; t = schedule.Next(t) {
+		times = append(times, t)
+	}
+	return times
+}
+
+func (s *scheduleWorkflow) startWorkflow(ctx workflow.Context, scheduledTime time.Time) {
+	state := &s.params.State
+	run, err := startWorkflow(ctx, s.params.DomainName, s.params.ScheduleID, s.params.Spec.Action, scheduledTime)
+	if err != nil {
+		s.logger.Error("Failed to start scheduled workflow.", zap.Time("scheduledTime", scheduledTime), zap.Error(err))
+		state.FailedRuns++
+		return
+	}
+	state.LastRun = run
+	state.TotalRuns++
+}
+
+func (s *scheduleWorkflow) isLastRunRunning(ctx workflow.Context) bool {
+	if s.params.State.LastRun == nil {
+		return false
+	}
+	return isWorkflowRunning(ctx, s.logger, s.params.DomainName, *s.params.State.LastRun)
+}
+
+func (s *scheduleWorkflow) stopLastRun(ctx workflow.Context, terminate bool) {
+	stopWorkflow(ctx, s.logger, s.params.DomainName, s.params.ScheduleID, *s.params.State.LastRun, terminate)
+}
+
+func startWorkflow(ctx workflow.Context, domainName, scheduleID string, action StartWorkflowAction, scheduledTime time.Time) (*RunInfo, error) {
+	params := startWorkflowActivityParams{
+		DomainName:    domainName,
+		ScheduleID:    scheduleID,
+		ScheduledTime: scheduledTime,
+		Action:        action,
+	}
+	var run RunInfo
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+	if err := workflow.ExecuteActivity(ao, startWorkflowActivityName, params).Get(ctx, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// isWorkflowRunning returns true when the state of the run is unknown, so that the overlap policy still applies
+func isWorkflowRunning(ctx workflow.Context, logger *zap.Logger, domainName string, run RunInfo) bool {
+	params := workflowActivityParams{
+		DomainName: domainName,
+		Run:        run,
+	}
+	var running bool
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+	if err := workflow.ExecuteActivity(ao, isWorkflowRunningActivityName, params).Get(ctx, &running); err != nil {
+		logger.Error("Failed to describe last scheduled workflow.", zap.Error(err))
+		return true
+	}
+	return running
+}
+
+func stopWorkflow(ctx workflow.Context, logger *zap.Logger, domainName, scheduleID string, run RunInfo, terminate bool) {
+	params := workflowActivityParams{
+		DomainName: domainName,
+		Run:        run,
+		Terminate:  terminate,
+		Reason:     fmt.Sprintf("stopped by schedule %v due to overlap policy", scheduleID),
+	}
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions())
+	if err := workflow.ExecuteActivity(ao, stopWorkflowActivityName, params).Get(ctx, nil); err != nil {
+		logger.Error("Failed to stop last scheduled workflow.", zap.Error(err))
+	}
+}
+
+func validateParams(params *ScheduleParams) (cron.Schedule, error) {
+	if params.DomainName == "" {
+		return nil, errors.New(errMsgDomainNameIsEmpty)
+	}
+	if params.ScheduleID == "" {
+		return nil, errors.New(errMsgScheduleIDIsEmpty)
+	}
+	return ValidateSpec(&params.Spec)
+}
+
+func getActivityOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    10 * time.Second,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:          time.Second,
+			BackoffCoefficient:       2,
+			MaximumInterval:          time.Minute,
+			ExpirationInterval:       10 * time.Minute,
+			NonRetriableErrorReasons: []string{errReasonNonRetriable},
+		},
+	}
+}
@@ -0,0 +1,413 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/encoded"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/backoff"
+)
+
+var testStartTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	testDomainName = "test-domain"
+	testScheduleID = "test-schedule"
+)
+
+type scheduleWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	workflowEnv *testsuite.TestWorkflowEnvironment
+	// backfilledRuns counts the started workflows scheduled before testStartTime
+	backfilledRuns int32
+}
+
+func TestScheduleWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(scheduleWorkflowTestSuite))
+}
+
+func (s *scheduleWorkflowTestSuite) SetupTest() {
+	s.backfilledRuns = 0
+	// the ID of the tested workflow can't be set, the child workflows have the IDs set by their parents
+	getWorkflowID = func(ctx workflow.Context) string {
+		if info := workflow.GetInfo(ctx); info.ParentWorkflowExecution != nil {
+			return info.WorkflowExecution.ID
+		}
+		return GetWorkflowID(testDomainName, testScheduleID)
+	}
+	s.workflowEnv = s.NewTestWorkflowEnvironment()
+	s.workflowEnv.SetStartTime(testStartTime)
+	s.workflowEnv.RegisterWorkflowWithOptions(ScheduleWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	s.workflowEnv.RegisterWorkflowWithOptions(BackfillWorkflow, workflow.RegisterOptions{Name: BackfillWorkflowTypeName})
+	s.workflowEnv.RegisterActivityWithOptions(startWorkflowActivity, activity.RegisterOptions{Name: startWorkflowActivityName})
+	s.workflowEnv.RegisterActivityWithOptions(isWorkflowRunningActivity, activity.RegisterOptions{Name: isWorkflowRunningActivityName})
+	s.workflowEnv.RegisterActivityWithOptions(stopWorkflowActivity, activity.RegisterOptions{Name: stopWorkflowActivityName})
+	s.workflowEnv.RegisterActivityWithOptions(recordScheduleActivity, activity.RegisterOptions{Name: recordScheduleActivityName})
+	s.workflowEnv.RegisterActivityWithOptions(scheduleExistsActivity, activity.RegisterOptions{Name: scheduleExistsActivityName})
+	s.workflowEnv.RegisterActivityWithOptions(deleteScheduleActivity, activity.RegisterOptions{Name: deleteScheduleActivityName})
+	s.workflowEnv.OnActivity(recordScheduleActivityName, mock.Anything, mock.Anything).Return(nil)
+	s.workflowEnv.OnActivity(startWorkflowActivityName, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params startWorkflowActivityParams) (*RunInfo, error) {
+			if params.ScheduledTime.Before(testStartTime) {
+				atomic.AddInt32(&s.backfilledRuns, 1)
+			}
+			return &RunInfo{
+				WorkflowID:    getRunWorkflowID(params.ScheduleID, params.ScheduledTime),
+				RunID:         "run-id",
+				ScheduledTime: params.ScheduledTime,
+			}, nil
+		},
+	)
+}
+
+func (s *scheduleWorkflowTestSuite) TearDownTest() {
+	getWorkflowID = func(ctx workflow.Context) string {
+		return workflow.GetInfo(ctx).WorkflowExecution.ID
+	}
+}
+
+func (s *scheduleWorkflowTestSuite) TestValidateSpec() {
+	spec := &ScheduleSpec{}
+	_, err := ValidateSpec(spec)
+	s.Error(err)
+
+	spec = testSpec(OverlapPolicySkip)
+	spec.OverlapPolicy = "invalid"
+	_, err = ValidateSpec(spec)
+	s.Error(err)
+
+	spec = testSpec(OverlapPolicySkip)
+	spec.CronSchedule = "invalid"
+	_, err = ValidateSpec(spec)
+	s.Error(err)
+
+	spec = testSpec("")
+	spec.Action.TaskStartToCloseTimeoutSeconds = 0
+	_, err = ValidateSpec(spec)
+	s.NoError(err)
+	s.Equal(OverlapPolicySkip, spec.OverlapPolicy)
+	s.Equal(int32(DefaultTaskStartToCloseTimeoutSeconds), spec.Action.TaskStartToCloseTimeoutSeconds)
+
+	_, err = validateParams(&ScheduleParams{ScheduleID: "s", Spec: *testSpec("")})
+	s.Error(err)
+	_, err = validateParams(&ScheduleParams{DomainName: "d", Spec: *testSpec("")})
+	s.Error(err)
+}
+
+func (s *scheduleWorkflowTestSuite) TestGetScheduledTimes() {
+	schedule, err := backoff.ValidateSchedule("*/10 * * * *")
+	s.NoError(err)
+
+	times := GetScheduledTimes(schedule, testStartTime, testStartTime.Add(30*time.Minute), MaxBackfillActions)
+	s.Equal([]time.Time{
+		testStartTime,
+		testStartTime.Add(10 * time.Minute),
+		testStartTime.Add(20 * time.Minute),
+		testStartTime.Add(30 * time.Minute),
+	}, times)
+
+	times = GetScheduledTimes(schedule, testStartTime.Add(time.Minute), testStartTime.Add(30*time.Minute), 2)
+	s.Equal([]time.Time{
+		testStartTime.Add(10 * time.Minute),
+		testStartTime.Add(20 * time.Minute),
+	}, times)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_Skip() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(true, nil)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal(int64(1), result.State.TotalRuns)
+		s.Equal(int64(5), result.State.SkippedRuns)
+		s.Equal(testStartTime.Add(10*time.Minute), result.State.LastRun.ScheduledTime)
+		s.Equal(testStartTime.Add(70*time.Minute), result.NextRunTime)
+	}, time.Hour+time.Second)
+
+	s.executeWorkflow(OverlapPolicySkip)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_Concurrent() {
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal(int64(6), result.State.TotalRuns)
+		s.Equal(int64(0), result.State.SkippedRuns)
+		s.Equal(testStartTime.Add(time.Hour), result.State.LastRun.ScheduledTime)
+	}, time.Hour+time.Second)
+
+	s.executeWorkflow(OverlapPolicyConcurrent)
+	s.workflowEnv.AssertNotCalled(s.T(), isWorkflowRunningActivityName, mock.Anything, mock.Anything)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_BufferOne() {
+	var running int32 = 1
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params workflowActivityParams) (bool, error) {
+			return atomic.LoadInt32(&running) == 1, nil
+		},
+	)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal(int64(1), result.State.TotalRuns)
+		s.Equal(int64(1), result.State.SkippedRuns)
+		s.Equal(testStartTime.Add(20*time.Minute), *result.State.BufferedTime)
+		atomic.StoreInt32(&running, 0)
+	}, 35*time.Minute)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal(int64(2), result.State.TotalRuns)
+		s.Nil(result.State.BufferedTime)
+		s.Equal(testStartTime.Add(20*time.Minute), result.State.LastRun.ScheduledTime)
+	}, 38*time.Minute)
+
+	s.executeWorkflow(OverlapPolicyBufferOne)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_TerminateOther() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(true, nil)
+	s.workflowEnv.OnActivity(stopWorkflowActivityName, mock.Anything, mock.MatchedBy(func(params workflowActivityParams) bool {
+		return params.Terminate
+	})).Return(nil)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal(int64(6), result.State.TotalRuns)
+		s.Equal(int64(0), result.State.SkippedRuns)
+	}, time.Hour+time.Second)
+
+	s.executeWorkflow(OverlapPolicyTerminateOther)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_PauseAndUnpause() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(false, nil)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		s.workflowEnv.SignalWorkflow(PauseSignal, "maintenance")
+	}, 5*time.Minute)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.True(result.State.Paused)
+		s.Equal("maintenance", result.State.PauseReason)
+		s.Equal(int64(0), result.State.TotalRuns)
+		s.Equal(int64(3), result.State.SkippedRuns)
+		s.workflowEnv.SignalWorkflow(UnpauseSignal, nil)
+	}, 35*time.Minute)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.False(result.State.Paused)
+		s.Equal(int64(3), result.State.TotalRuns)
+		s.Equal(int64(3), result.State.SkippedRuns)
+	}, time.Hour+time.Second)
+
+	s.executeWorkflow(OverlapPolicySkip)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_Update() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(false, nil)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		spec := testSpec(OverlapPolicyConcurrent)
+		spec.CronSchedule = "0 * * * *"
+		s.workflowEnv.SignalWorkflow(UpdateSignal, spec)
+	}, 15*time.Minute)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		result := s.describe()
+		s.Equal("0 * * * *", result.Spec.CronSchedule)
+		s.Equal(OverlapPolicyConcurrent, result.Spec.OverlapPolicy)
+		s.Equal(int64(2), result.State.TotalRuns)
+		s.Equal(testStartTime.Add(2*time.Hour), result.NextRunTime)
+	}, time.Hour+time.Second)
+
+	s.executeWorkflow(OverlapPolicySkip)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_Backfill() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(false, nil)
+	var backfillWorkflowID string
+	s.workflowEnv.SetOnChildWorkflowStartedListener(func(info *workflow.Info, ctx workflow.Context, args encoded.Values) {
+		backfillWorkflowID = info.WorkflowExecution.ID
+	})
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		s.workflowEnv.SignalWorkflow(BackfillSignal, BackfillRequest{
+			StartTime: testStartTime.Add(-time.Hour),
+			EndTime:   testStartTime.Add(-30 * time.Minute),
+		})
+	}, time.Minute)
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		// the backfilled workflows are started by the child workflow, not counted by the schedule
+		result := s.describe()
+		s.Equal(int64(0), result.State.TotalRuns)
+		s.Equal("test-domain/test-schedule/backfill-2023-12-31T23:00:00Z-2023-12-31T23:30:00Z", backfillWorkflowID)
+	}, 2*time.Minute)
+
+	s.executeWorkflow(OverlapPolicySkip)
+	s.Equal(int32(4), atomic.LoadInt32(&s.backfilledRuns))
+}
+
+func (s *scheduleWorkflowTestSuite) TestBackfillWorkflow_Skip() {
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(true, nil)
+	getWorkflowID = func(ctx workflow.Context) string {
+		return getBackfillWorkflowID(testDomainName, testScheduleID, testStartTime, testStartTime)
+	}
+
+	s.workflowEnv.ExecuteWorkflow(BackfillWorkflowTypeName, BackfillParams{
+		DomainName:     testDomainName,
+		ScheduleID:     testScheduleID,
+		Action:         testSpec(OverlapPolicySkip).Action,
+		ScheduledTimes: []time.Time{testStartTime, testStartTime.Add(10 * time.Minute), testStartTime.Add(20 * time.Minute)},
+		OverlapPolicy:  OverlapPolicySkip,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+	s.workflowEnv.AssertNumberOfCalls(s.T(), startWorkflowActivityName, 1)
+}
+
+func (s *scheduleWorkflowTestSuite) TestBackfillWorkflow_BufferOne() {
+	var running int32 = 1
+	s.workflowEnv.OnActivity(isWorkflowRunningActivityName, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params workflowActivityParams) (bool, error) {
+			// every backfilled workflow is running until it is checked twice
+			return atomic.AddInt32(&running, 1)%3 != 0, nil
+		},
+	)
+	getWorkflowID = func(ctx workflow.Context) string {
+		return getBackfillWorkflowID(testDomainName, testScheduleID, testStartTime, testStartTime)
+	}
+
+	s.workflowEnv.ExecuteWorkflow(BackfillWorkflowTypeName, BackfillParams{
+		DomainName:     testDomainName,
+		ScheduleID:     testScheduleID,
+		Action:         testSpec(OverlapPolicySkip).Action,
+		ScheduledTimes: []time.Time{testStartTime, testStartTime.Add(10 * time.Minute), testStartTime.Add(20 * time.Minute)},
+		OverlapPolicy:  OverlapPolicyBufferOne,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+	s.workflowEnv.AssertNumberOfCalls(s.T(), startWorkflowActivityName, 3)
+}
+
+func (s *scheduleWorkflowTestSuite) TestBackfillWorkflow_WorkflowIDMismatch() {
+	getWorkflowID = func(ctx workflow.Context) string {
+		return getBackfillWorkflowID("other-domain", testScheduleID, testStartTime, testStartTime)
+	}
+
+	s.workflowEnv.ExecuteWorkflow(BackfillWorkflowTypeName, BackfillParams{
+		DomainName:     testDomainName,
+		ScheduleID:     testScheduleID,
+		Action:         testSpec(OverlapPolicySkip).Action,
+		ScheduledTimes: []time.Time{testStartTime},
+		OverlapPolicy:  OverlapPolicyConcurrent,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.EqualError(s.workflowEnv.GetWorkflowError(), errMsgWorkflowIDMismatch)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_Delete() {
+	s.workflowEnv.OnActivity(deleteScheduleActivityName, mock.Anything, ScheduleRecord{
+		DomainName: testDomainName,
+		ScheduleID: testScheduleID,
+		Spec:       *testSpec(OverlapPolicyConcurrent),
+	}).Return(nil).Once()
+	s.workflowEnv.RegisterDelayedCallback(func() {
+		s.workflowEnv.SignalWorkflow(DeleteSignal, "not needed")
+	}, 15*time.Minute)
+
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, ScheduleParams{
+		DomainName: testDomainName,
+		ScheduleID: testScheduleID,
+		Spec:       *testSpec(OverlapPolicyConcurrent),
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+	s.workflowEnv.AssertNumberOfCalls(s.T(), startWorkflowActivityName, 1)
+	s.workflowEnv.AssertExpectations(s.T())
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_RestoreDeleted() {
+	s.workflowEnv.OnActivity(scheduleExistsActivityName, mock.Anything, mock.Anything).Return(false, nil).Once()
+
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, ScheduleParams{
+		DomainName: testDomainName,
+		ScheduleID: testScheduleID,
+		Spec:       *testSpec(OverlapPolicyConcurrent),
+		Restored:   true,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+	s.workflowEnv.AssertNotCalled(s.T(), startWorkflowActivityName, mock.Anything, mock.Anything)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_WorkflowIDMismatch() {
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, ScheduleParams{
+		DomainName: "other-domain",
+		ScheduleID: testScheduleID,
+		Spec:       *testSpec(OverlapPolicyConcurrent),
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.EqualError(s.workflowEnv.GetWorkflowError(), errMsgWorkflowIDMismatch)
+}
+
+func (s *scheduleWorkflowTestSuite) TestScheduleWorkflow_InvalidParams() {
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, ScheduleParams{})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.Error(s.workflowEnv.GetWorkflowError())
+}
+
+func (s *scheduleWorkflowTestSuite) executeWorkflow(overlapPolicy string) {
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, ScheduleParams{
+		DomainName: testDomainName,
+		ScheduleID: testScheduleID,
+		Spec:       *testSpec(overlapPolicy),
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	_, ok := s.workflowEnv.GetWorkflowError().(*workflow.ContinueAsNewError)
+	s.True(ok)
+}
+
+func (s *scheduleWorkflowTestSuite) describe() *DescribeResult {
+	value, err := s.workflowEnv.QueryWorkflow(QueryType)
+	s.NoError(err)
+	var result DescribeResult
+	s.NoError(value.Get(&result))
+	return &result
+}
+
+func testSpec(overlapPolicy string) *ScheduleSpec {
+	return &ScheduleSpec{
+		CronSchedule:  "*/10 * * * *",
+		OverlapPolicy: overlapPolicy,
+		Action: StartWorkflowAction{
+			WorkflowType:                        "test-workflow",
+			TaskList:                            "test-tasklist",
+			ExecutionStartToCloseTimeoutSeconds: 60,
+			TaskStartToCloseTimeoutSeconds:      10,
+		},
+	}
+}
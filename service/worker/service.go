@@ -49,6 +49,8 @@ import (
 	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 	"github.com/uber/cadence/service/worker/scanner/tasklist"
 	"github.com/uber/cadence/service/worker/scanner/timers"
+	"github.com/uber/cadence/service/worker/scheduler"
+	"github.com/uber/cadence/service/worker/visibilitybackfill"
 )
 
 type (
@@ -80,25 +82,48 @@ type (
 		EnableParentClosePolicyWorker       dynamicconfig.BoolPropertyFn
 		NumParentClosePolicySystemWorkflows dynamicconfig.IntPropertyFn
 		EnableFailoverManager               dynamicconfig.BoolPropertyFn
+		EnableScheduler                     dynamicconfig.BoolPropertyFn
 		DomainReplicationMaxRetryDuration   dynamicconfig.DurationPropertyFn
 		EnableESAnalyzer                    dynamicconfig.BoolPropertyFn
 		EnableAsyncWorkflowConsumption      dynamicconfig.BoolPropertyFn
+		EnableVisibilityBackfill            dynamicconfig.BoolPropertyFn
 		HostName                            string
+
+		// configs for visibility, only used by the visibility backfill workflows
+		EnableReadVisibilityFromES      dynamicconfig.BoolPropertyFnWithDomainFilter
+		EnableReadVisibilityFromPinot   dynamicconfig.BoolPropertyFnWithDomainFilter
+		EnableLogCustomerQueryParameter dynamicconfig.BoolPropertyFnWithDomainFilter
+		EnableReadFromClosedExecutionV2 dynamicconfig.BoolPropertyFn
+		ESIndexMaxResultWindow          dynamicconfig.IntPropertyFn
+		ValidSearchAttributes           dynamicconfig.MapPropertyFn
+		MigratedSearchAttributes        dynamicconfig.MapPropertyFn
 	}
 )
 
 // NewService builds a new cadence-worker service
 func NewService(params *resource.Params) (resource.Resource, error) {
 	serviceConfig := NewConfig(params)
+	resourceConfig := &service.Config{
+		PersistenceMaxQPS:       serviceConfig.PersistenceMaxQPS,
+		PersistenceGlobalMaxQPS: serviceConfig.PersistenceGlobalMaxQPS,
+		ThrottledLoggerMaxRPS:   serviceConfig.ThrottledLogRPS,
+		// worker service doesn't need visibility config unless the visibility backfill workflows are enabled,
+		// as it never call visibilityManager API otherwise
+	}
+	if serviceConfig.EnableVisibilityBackfill() {
+		// AdvancedVisibilityWritingMode is not set as the backfill workflows always pick the store to write to
+		resourceConfig.EnableReadVisibilityFromES = serviceConfig.EnableReadVisibilityFromES
+		resourceConfig.EnableReadVisibilityFromPinot = serviceConfig.EnableReadVisibilityFromPinot
+		resourceConfig.EnableLogCustomerQueryParameter = serviceConfig.EnableLogCustomerQueryParameter
+		resourceConfig.EnableReadDBVisibilityFromClosedExecutionV2 = serviceConfig.EnableReadFromClosedExecutionV2
+		resourceConfig.ESIndexMaxResultWindow = serviceConfig.ESIndexMaxResultWindow
+		resourceConfig.ValidSearchAttributes = serviceConfig.ValidSearchAttributes
+		resourceConfig.MigratedSearchAttributes = serviceConfig.MigratedSearchAttributes
+	}
 	serviceResource, err := resource.New(
 		params,
 		service.Worker,
-		&service.Config{
-			PersistenceMaxQPS:       serviceConfig.PersistenceMaxQPS,
-			PersistenceGlobalMaxQPS: serviceConfig.PersistenceGlobalMaxQPS,
-			ThrottledLoggerMaxRPS:   serviceConfig.ThrottledLogRPS,
-			// worker service doesn't need visibility config as it never call visibilityManager API
-		},
+		resourceConfig,
 	)
 	if err != nil {
 		return nil, err
@@ -174,12 +199,21 @@ func NewConfig(params *resource.Params) *Config {
 		NumParentClosePolicySystemWorkflows: dc.GetIntProperty(dynamicconfig.NumParentClosePolicySystemWorkflows),
 		EnableESAnalyzer:                    dc.GetBoolProperty(dynamicconfig.EnableESAnalyzer),
 		EnableFailoverManager:               dc.GetBoolProperty(dynamicconfig.EnableFailoverManager),
+		EnableScheduler:                     dc.GetBoolProperty(dynamicconfig.EnableScheduler),
 		ThrottledLogRPS:                     dc.GetIntProperty(dynamicconfig.WorkerThrottledLogRPS),
 		PersistenceGlobalMaxQPS:             dc.GetIntProperty(dynamicconfig.WorkerPersistenceGlobalMaxQPS),
 		PersistenceMaxQPS:                   dc.GetIntProperty(dynamicconfig.WorkerPersistenceMaxQPS),
 		DomainReplicationMaxRetryDuration:   dc.GetDurationProperty(dynamicconfig.WorkerReplicationTaskMaxRetryDuration),
 		EnableAsyncWorkflowConsumption:      dc.GetBoolProperty(dynamicconfig.EnableAsyncWorkflowConsumption),
+		EnableVisibilityBackfill:            dc.GetBoolProperty(dynamicconfig.EnableVisibilityBackfill),
 		HostName:                            params.HostName,
+		EnableReadVisibilityFromES:          dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableReadVisibilityFromES),
+		EnableReadVisibilityFromPinot:       dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableReadVisibilityFromPinot),
+		EnableLogCustomerQueryParameter:     dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableLogCustomerQueryParameter),
+		EnableReadFromClosedExecutionV2:     dc.GetBoolProperty(dynamicconfig.EnableReadFromClosedExecutionV2),
+		ESIndexMaxResultWindow:              dc.GetIntProperty(dynamicconfig.FrontendESIndexMaxResultWindow),
+		ValidSearchAttributes:               dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+		MigratedSearchAttributes:            dc.GetMapProperty(dynamicconfig.MigratedSearchAttributes),
 	}
 	advancedVisWritingMode := dc.GetStringProperty(
 		dynamicconfig.AdvancedVisibilityWritingMode,
@@ -234,6 +268,13 @@ func (s *Service) Start() {
 	if s.config.EnableFailoverManager() {
 		s.startFailoverManager()
 	}
+	if s.config.EnableScheduler() {
+		s.ensureDomainExists(common.SchedulerLocalDomainName)
+		s.startScheduler()
+	}
+	if s.config.EnableVisibilityBackfill() {
+		s.startVisibilityBackfill()
+	}
 
 	if s.config.EnableAsyncWorkflowConsumption() {
 		cm := s.startAsyncWorkflowConsumerManager()
@@ -309,6 +350,36 @@ func (s *Service) startBatcher() {
 	}
 }
 
+func (s *Service) startScheduler() {
+	params := &scheduler.BootstrapParams{
+		ServiceClient:      s.params.PublicClient,
+		MetricsClient:      s.GetMetricsClient(),
+		Logger:             s.GetLogger(),
+		TallyScope:         s.params.MetricScope,
+		ClientBean:         s.GetClientBean(),
+		ConfigStoreManager: s.GetPersistenceBean().GetConfigStoreManager(),
+	}
+	if err := scheduler.New(params).Start(); err != nil {
+		s.GetLogger().Fatal("error starting scheduler", tag.Error(err))
+	}
+}
+
+func (s *Service) startVisibilityBackfill() {
+	params := &visibilitybackfill.BootstrapParams{
+		ServiceClient:         s.params.PublicClient,
+		TallyScope:            s.params.MetricScope,
+		NumHistoryShards:      s.params.PersistenceConfig.NumHistoryShards,
+		ESClient:              s.params.ESClient,
+		ValidSearchAttributes: s.config.ValidSearchAttributes,
+	}
+	if s.params.ESConfig != nil {
+		params.ESIndex = s.params.ESConfig.Indices[common.VisibilityAppName]
+	}
+	if err := visibilitybackfill.New(s.Resource, params).Start(); err != nil {
+		s.GetLogger().Fatal("error starting visibility backfill", tag.Error(err))
+	}
+}
+
 func (s *Service) startScanner() {
 	params := &scanner.BootstrapParams{
 		Config:     *s.config.ScannerCfg,
@@ -405,7 +476,9 @@ func (s *Service) startAsyncWorkflowConsumerManager() common.Daemon {
 		s.GetMetricsClient(),
 		s.GetDomainCache(),
 		s.Resource.GetAsyncWorkflowQueueProvider(),
+		s.GetPersistenceBean().GetAsyncWorkflowQueueManager,
 		s.GetFrontendClient(),
+		s.GetMembershipResolver(),
 	)
 	cm.Start()
 	return cm
@@ -459,6 +532,8 @@ func getDomainID(domain string) string {
 		domainID = common.SystemDomainID
 	case common.BatcherLocalDomainName:
 		domainID = common.BatcherDomainID
+	case common.SchedulerLocalDomainName:
+		domainID = common.SchedulerDomainID
 	case common.ShadowerLocalDomainName:
 		domainID = common.ShadowerDomainID
 	}
@@ -0,0 +1,572 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package visibilitybackfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/cadence"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/definition"
+	es "github.com/uber/cadence/common/elasticsearch"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/quotas"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	secondsInDay = int64(24 * time.Hour / time.Second)
+)
+
+// backfillActivity processes one page of the current phase and returns the next checkpoint.
+// The records are processed idempotently, so a retried page doesn't corrupt the target store.
+func backfillActivity(ctx context.Context, params Params) (*Progress, error) {
+	b := getVisibilityBackfill(ctx)
+	domainEntry, err := b.resource.GetDomainCache().GetDomain(params.DomainName)
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return nil, cadence.NewCustomError(errReasonNonRetriable, err.Error())
+		}
+		return nil, err
+	}
+
+	var migration *searchAttributeMigration
+	if params.Mode == ModeMigrate {
+		if migration, err = b.getSearchAttributeMigration(params); err != nil {
+			return nil, err
+		}
+	}
+
+	progress := params.Progress
+	limiter := quotas.NewSimpleRateLimiter(params.RPS)
+	var nextPageToken []byte
+	switch {
+	case progress.Phase != PhaseOpen && progress.Phase != PhaseClosed:
+		return &progress, nil
+	case params.Mode == ModeMigrate && params.TargetStore == persistence.VisibilityStoreES:
+		nextPageToken, err = b.migrateESExecutions(ctx, params, domainEntry, migration, &progress)
+	case progress.Phase == PhaseOpen && params.Mode == ModeMigrate:
+		nextPageToken, err = b.migrateOpenExecutions(ctx, params, domainEntry, limiter, migration, &progress)
+	case progress.Phase == PhaseOpen && params.Mode == ModeBackfill:
+		nextPageToken, err = b.backfillOpenExecutions(ctx, params, domainEntry, limiter, &progress)
+	case progress.Phase == PhaseClosed && params.Mode != ModeCompare:
+		nextPageToken, err = b.backfillClosedExecutions(ctx, params, domainEntry, limiter, migration, &progress)
+	default:
+		nextPageToken, err = b.compareExecutions(ctx, params, domainEntry, limiter, &progress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	progress.PageToken = nextPageToken
+	if len(nextPageToken) != 0 {
+		return &progress, nil
+	}
+	switch progress.Phase {
+	case PhaseOpen:
+		// only backfill mode pages through the executions of each history shard
+		if params.Mode == ModeBackfill && progress.ShardID+1 < b.numHistoryShards {
+			progress.ShardID++
+		} else {
+			progress.Phase = PhaseClosed
+			progress.ShardID = 0
+		}
+	case PhaseClosed:
+		progress.Phase = PhaseDone
+	}
+	return &progress, nil
+}
+
+// backfillOpenExecutions records the running executions of the domain in a page of the executions of a history shard.
+// The records are written without a task ID, so they never override the records written by history in ES.
+func (b *VisibilityBackfill) backfillOpenExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	limiter quotas.Limiter,
+	progress *Progress,
+) ([]byte, error) {
+	executionManager, err := b.resource.GetExecutionManager(progress.ShardID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := executionManager.ListConcreteExecutions(ctx, &persistence.ListConcreteExecutionsRequest{
+		PageSize:  params.PageSize,
+		PageToken: progress.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	targetCtx := persistence.ContextWithVisibilityStoreOverride(ctx, params.TargetStore)
+	numClusters := int16(len(domainEntry.GetReplicationConfig().Clusters))
+	for _, execution := range resp.Executions {
+		info := execution.ExecutionInfo
+		if info == nil || info.DomainID != domainEntry.GetInfo().ID || !isRunning(info.State) {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		var memo *types.Memo
+		if len(info.Memo) != 0 {
+			memo = &types.Memo{Fields: info.Memo}
+		}
+		err := b.resource.GetVisibilityManager().RecordWorkflowExecutionStarted(targetCtx, &persistence.RecordWorkflowExecutionStartedRequest{
+			DomainUUID: info.DomainID,
+			Domain:     params.DomainName,
+			Execution: types.WorkflowExecution{
+				WorkflowID: info.WorkflowID,
+				RunID:      info.RunID,
+			},
+			WorkflowTypeName: info.WorkflowTypeName,
+			StartTimestamp:   info.StartTimestamp.UnixNano(),
+			// the backoff of the first decision is not in the mutable state, the start time is the closest value
+			ExecutionTimestamp: info.StartTimestamp.UnixNano(),
+			WorkflowTimeout:    int64(info.WorkflowTimeout),
+			Memo:               memo,
+			TaskList:           info.TaskList,
+			IsCron:             info.IsCron,
+			NumClusters:        numClusters,
+			UpdateTimestamp:    info.LastUpdatedTimestamp.UnixNano(),
+			SearchAttributes:   info.SearchAttributes,
+			ShardID:            int16(progress.ShardID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		progress.Processed++
+	}
+	return resp.PageToken, nil
+}
+
+// backfillClosedExecutions records a page of the closed executions of the source store in the target store,
+// in migrate mode only the records whose search attribute is migrated are recorded again
+func (b *VisibilityBackfill) backfillClosedExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	limiter quotas.Limiter,
+	migration *searchAttributeMigration,
+	progress *Progress,
+) ([]byte, error) {
+	resp, err := b.listSourceExecutions(ctx, params, domainEntry, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	targetCtx := persistence.ContextWithVisibilityStoreOverride(ctx, params.TargetStore)
+	numClusters := int16(len(domainEntry.GetReplicationConfig().Clusters))
+	for _, info := range resp.Executions {
+		searchAttributes := info.GetSearchAttributes().GetIndexedFields()
+		if migration != nil {
+			var ok bool
+			if searchAttributes, ok = migration.migrate(info.GetExecution(), searchAttributes, params, progress); !ok {
+				continue
+			}
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		workflowID := info.GetExecution().GetWorkflowID()
+		err := b.resource.GetVisibilityManager().RecordWorkflowExecutionClosed(targetCtx, &persistence.RecordWorkflowExecutionClosedRequest{
+			DomainUUID: domainEntry.GetInfo().ID,
+			Domain:     params.DomainName,
+			Execution: types.WorkflowExecution{
+				WorkflowID: workflowID,
+				RunID:      info.GetExecution().GetRunID(),
+			},
+			WorkflowTypeName:   info.GetType().GetName(),
+			StartTimestamp:     info.GetStartTime(),
+			ExecutionTimestamp: info.GetExecutionTime(),
+			CloseTimestamp:     info.GetCloseTime(),
+			Status:             info.GetCloseStatus(),
+			HistoryLength:      info.HistoryLength,
+			RetentionSeconds:   int64(domainEntry.GetRetentionDays(workflowID)) * secondsInDay,
+			Memo:               info.Memo,
+			TaskList:           info.TaskList,
+			IsCron:             info.IsCron,
+			NumClusters:        numClusters,
+			UpdateTimestamp:    info.GetUpdateTime(),
+			SearchAttributes:   searchAttributes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		progress.Processed++
+	}
+	return resp.NextPageToken, nil
+}
+
+// compareExecutions looks up a page of the executions of the source store in the target store
+func (b *VisibilityBackfill) compareExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	limiter quotas.Limiter,
+	progress *Progress,
+) ([]byte, error) {
+	resp, err := b.listSourceExecutions(ctx, params, domainEntry, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range resp.Executions {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		target, err := b.getTargetExecution(ctx, params, domainEntry, source, progress.Phase == PhaseOpen)
+		if err != nil {
+			return nil, err
+		}
+		progress.Processed++
+
+		var mismatch error
+		if target == nil {
+			mismatch = errors.New(errMsgRecordMissingInTarget)
+		} else {
+			mismatch = persistence.CompareWorkflowExecutionInfo(truncateTimestamps(source), truncateTimestamps(target))
+		}
+		if mismatch == nil {
+			continue
+		}
+		progress.Mismatched++
+		if len(progress.Mismatches) < params.MaxReportedMismatches {
+			progress.Mismatches = append(progress.Mismatches, fmt.Sprintf("%v/%v: %v",
+				source.GetExecution().GetWorkflowID(), source.GetExecution().GetRunID(), mismatch))
+		}
+	}
+	return resp.NextPageToken, nil
+}
+
+// migrateOpenExecutions records again the open executions of the target store whose search attribute is migrated.
+// The record is rebuilt from the mutable state, so that a workflow which changed since it was listed isn't reverted.
+func (b *VisibilityBackfill) migrateOpenExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	limiter quotas.Limiter,
+	migration *searchAttributeMigration,
+	progress *Progress,
+) ([]byte, error) {
+	resp, err := b.listSourceExecutions(ctx, params, domainEntry, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	targetCtx := persistence.ContextWithVisibilityStoreOverride(ctx, params.TargetStore)
+	numClusters := int16(len(domainEntry.GetReplicationConfig().Clusters))
+	for _, listed := range resp.Executions {
+		if _, ok := migration.migrate(listed.GetExecution(), listed.GetSearchAttributes().GetIndexedFields(), params, progress); !ok {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		shardID := common.WorkflowIDToHistoryShard(listed.GetExecution().GetWorkflowID(), b.numHistoryShards)
+		executionManager, err := b.resource.GetExecutionManager(shardID)
+		if err != nil {
+			return nil, err
+		}
+		state, err := executionManager.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+			DomainID:   domainEntry.GetInfo().ID,
+			Execution:  *listed.GetExecution(),
+			DomainName: params.DomainName,
+		})
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info := state.State.ExecutionInfo
+		if !isRunning(info.State) {
+			// the closed record is written by history with the migrated search attribute
+			continue
+		}
+		searchAttributes, ok := migration.migrate(listed.GetExecution(), info.SearchAttributes, params, progress)
+		if !ok {
+			continue
+		}
+		var memo *types.Memo
+		if len(info.Memo) != 0 {
+			memo = &types.Memo{Fields: info.Memo}
+		}
+		err = b.resource.GetVisibilityManager().RecordWorkflowExecutionStarted(targetCtx, &persistence.RecordWorkflowExecutionStartedRequest{
+			DomainUUID:         info.DomainID,
+			Domain:             params.DomainName,
+			Execution:          *listed.GetExecution(),
+			WorkflowTypeName:   info.WorkflowTypeName,
+			StartTimestamp:     info.StartTimestamp.UnixNano(),
+			ExecutionTimestamp: listed.GetExecutionTime(),
+			WorkflowTimeout:    int64(info.WorkflowTimeout),
+			Memo:               memo,
+			TaskList:           info.TaskList,
+			IsCron:             info.IsCron,
+			NumClusters:        numClusters,
+			UpdateTimestamp:    info.LastUpdatedTimestamp.UnixNano(),
+			SearchAttributes:   searchAttributes,
+			ShardID:            int16(shardID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		progress.Processed++
+	}
+	return resp.NextPageToken, nil
+}
+
+// migrateESExecutions copies the search attribute of a page of the executions of ElasticSearch to its new key.
+// Writing a whole record would be rejected by the external version of the document, so only the new key is
+// updated by a script, and only if it isn't set yet, e.g. by history after the migration started.
+func (b *VisibilityBackfill) migrateESExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	migration *searchAttributeMigration,
+	progress *Progress,
+) ([]byte, error) {
+	if b.esClient == nil {
+		return nil, cadence.NewCustomError(errReasonNonRetriable, errMsgESNotConfigured)
+	}
+	resp, err := b.listSourceExecutions(ctx, params, domainEntry, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	runIDs := make([]string, 0, len(resp.Executions))
+	for _, info := range resp.Executions {
+		searchAttributes, ok := migration.migrate(info.GetExecution(), info.GetSearchAttributes().GetIndexedFields(), params, progress)
+		if !ok {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(searchAttributes[migration.newKey], &value); err != nil {
+			return nil, err
+		}
+		runID := info.GetExecution().GetRunID()
+		values[runID] = value
+		runIDs = append(runIDs, runID)
+	}
+	if len(runIDs) == 0 {
+		return resp.NextPageToken, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{es.DomainID: domainEntry.GetInfo().ID}},
+					map[string]interface{}{"terms": map[string]interface{}{es.RunID: runIDs}},
+				},
+				"must_not": map[string]interface{}{
+					"exists": map[string]interface{}{"field": definition.Attr + "." + migration.newKey},
+				},
+			},
+		},
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": migrateSearchAttributeScript,
+			"params": map[string]interface{}{
+				"key":    migration.newKey,
+				"values": values,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	updateResp, err := b.esClient.UpdateByQuery(ctx, b.esIndex, string(body))
+	if err != nil {
+		return nil, err
+	}
+	if updateResp.Failures > 0 {
+		// the page is retried, the documents which are updated already don't match the query anymore
+		return nil, fmt.Errorf("failed to update %d documents", updateResp.Failures)
+	}
+	progress.Processed += updateResp.Updated
+	return resp.NextPageToken, nil
+}
+
+const migrateSearchAttributeScript = `ctx._source.Attr[params.key] = params.values[ctx._source.RunID]`
+
+type searchAttributeMigration struct {
+	key       string
+	newKey    string
+	valueType types.IndexedValueType
+}
+
+func (b *VisibilityBackfill) getSearchAttributeMigration(params Params) (*searchAttributeMigration, error) {
+	var validSearchAttributes map[string]interface{}
+	if b.validSearchAttributes != nil {
+		validSearchAttributes = b.validSearchAttributes()
+	}
+	valueType, ok := validSearchAttributes[params.NewSearchAttributeKey]
+	if !ok {
+		return nil, cadence.NewCustomError(errReasonNonRetriable, errMsgInvalidNewKey)
+	}
+	return &searchAttributeMigration{
+		key:       params.SearchAttributeKey,
+		newKey:    params.NewSearchAttributeKey,
+		valueType: common.ConvertIndexedValueTypeToInternalType(valueType, b.logger),
+	}, nil
+}
+
+// migrate returns the search attributes with the value of the migrated key copied to the new key, and whether the
+// record has to be written. It doesn't if the value is missing, already copied or can't be converted.
+func (m *searchAttributeMigration) migrate(
+	execution *types.WorkflowExecution,
+	searchAttributes map[string][]byte,
+	params Params,
+	progress *Progress,
+) (map[string][]byte, bool) {
+	value, ok := searchAttributes[m.key]
+	if !ok {
+		return nil, false
+	}
+	if _, ok := searchAttributes[m.newKey]; ok {
+		return nil, false
+	}
+	converted, err := common.ConvertSearchAttributeValue(value, m.valueType)
+	if err != nil {
+		progress.Mismatched++
+		if len(progress.Mismatches) < params.MaxReportedMismatches {
+			progress.Mismatches = append(progress.Mismatches, fmt.Sprintf("%v/%v: cannot convert %s to %v: %v",
+				execution.GetWorkflowID(), execution.GetRunID(), value, m.valueType, err))
+		}
+		return nil, false
+	}
+
+	result := make(map[string][]byte, len(searchAttributes)+1)
+	for k, v := range searchAttributes {
+		result[k] = v
+	}
+	result[m.newKey] = converted
+	return result, true
+}
+
+func (b *VisibilityBackfill) listSourceExecutions(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	progress *Progress,
+) (*persistence.ListWorkflowExecutionsResponse, error) {
+	sourceCtx := persistence.ContextWithVisibilityStoreOverride(ctx, params.SourceStore)
+	earliestTime := int64(0)
+	if !params.EarliestTime.IsZero() {
+		earliestTime = params.EarliestTime.UnixNano()
+	}
+	request := &persistence.ListWorkflowExecutionsRequest{
+		DomainUUID:    domainEntry.GetInfo().ID,
+		Domain:        params.DomainName,
+		EarliestTime:  earliestTime,
+		LatestTime:    params.LatestTime.UnixNano(),
+		PageSize:      params.PageSize,
+		NextPageToken: progress.PageToken,
+	}
+	if progress.Phase == PhaseOpen {
+		return b.resource.GetVisibilityManager().ListOpenWorkflowExecutions(sourceCtx, request)
+	}
+	return b.resource.GetVisibilityManager().ListClosedWorkflowExecutions(sourceCtx, request)
+}
+
+// getTargetExecution returns the record of the source execution in the target store, nil if it doesn't exist
+func (b *VisibilityBackfill) getTargetExecution(
+	ctx context.Context,
+	params Params,
+	domainEntry *cache.DomainCacheEntry,
+	source *types.WorkflowExecutionInfo,
+	open bool,
+) (*types.WorkflowExecutionInfo, error) {
+	targetCtx := persistence.ContextWithVisibilityStoreOverride(ctx, params.TargetStore)
+	// open executions are listed by start time and closed executions by close time,
+	// the stores keep the timestamps at different precisions so the range is widened by a second
+	timestamp := source.GetCloseTime()
+	if open {
+		timestamp = source.GetStartTime()
+	}
+	request := &persistence.ListWorkflowExecutionsByWorkflowIDRequest{
+		ListWorkflowExecutionsRequest: persistence.ListWorkflowExecutionsRequest{
+			DomainUUID:   domainEntry.GetInfo().ID,
+			Domain:       params.DomainName,
+			EarliestTime: timestamp - int64(time.Second),
+			LatestTime:   timestamp + int64(time.Second),
+			PageSize:     params.PageSize,
+		},
+		WorkflowID: source.GetExecution().GetWorkflowID(),
+	}
+	for {
+		var resp *persistence.ListWorkflowExecutionsResponse
+		var err error
+		if open {
+			resp, err = b.resource.GetVisibilityManager().ListOpenWorkflowExecutionsByWorkflowID(targetCtx, request)
+		} else {
+			resp, err = b.resource.GetVisibilityManager().ListClosedWorkflowExecutionsByWorkflowID(targetCtx, request)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range resp.Executions {
+			if target.GetExecution().GetRunID() == source.GetExecution().GetRunID() {
+				return target, nil
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			return nil, nil
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+}
+
+// truncateTimestamps returns a copy of the record with the timestamps truncated to milliseconds,
+// which is the precision of the timestamps in Cassandra
+func truncateTimestamps(info *types.WorkflowExecutionInfo) *types.WorkflowExecutionInfo {
+	truncate := func(timestamp *int64) *int64 {
+		if timestamp == nil {
+			return nil
+		}
+		truncated := *timestamp - *timestamp%int64(time.Millisecond)
+		return &truncated
+	}
+	result := *info
+	result.StartTime = truncate(info.StartTime)
+	result.CloseTime = truncate(info.CloseTime)
+	result.ExecutionTime = truncate(info.ExecutionTime)
+	result.UpdateTime = truncate(info.UpdateTime)
+	return &result
+}
+
+func isRunning(state int) bool {
+	return state == persistence.WorkflowStateCreated || state == persistence.WorkflowStateRunning
+}
+
+func getVisibilityBackfill(ctx context.Context) *VisibilityBackfill {
+	return ctx.Value(visibilityBackfillContextKey).(*VisibilityBackfill)
+}
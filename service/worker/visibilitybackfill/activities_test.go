@@ -0,0 +1,420 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package visibilitybackfill
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/worker"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	esc "github.com/uber/cadence/common/elasticsearch/client"
+	esmocks "github.com/uber/cadence/common/elasticsearch/mocks"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	testDomainID   = "test-domain-id"
+	testDomainName = "test-domain"
+)
+
+type activitiesTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	activityEnv  *testsuite.TestActivityEnvironment
+	mockResource *resource.Test
+	esClient     *esmocks.GenericClient
+}
+
+func TestActivitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(activitiesTestSuite))
+}
+
+func (s *activitiesTestSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockResource = resource.NewTest(s.T(), controller, metrics.Worker)
+	s.mockResource.DomainCache.EXPECT().GetDomain(testDomainName).Return(cache.NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{ID: testDomainID, Name: testDomainName},
+		&persistence.DomainConfig{Retention: 7},
+		"test-cluster",
+	), nil).AnyTimes()
+
+	s.esClient = &esmocks.GenericClient{}
+
+	s.activityEnv = s.NewTestActivityEnvironment()
+	s.activityEnv.SetTestTimeout(time.Second * 5)
+	s.activityEnv.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: context.WithValue(context.Background(), visibilityBackfillContextKey, &VisibilityBackfill{
+			resource:         s.mockResource,
+			numHistoryShards: 2,
+			esClient:         s.esClient,
+			esIndex:          "test-index",
+			validSearchAttributes: dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+				"OldKey": types.IndexedValueTypeKeyword,
+				"NewKey": types.IndexedValueTypeInt,
+			}),
+		}),
+	})
+	s.activityEnv.RegisterActivityWithOptions(backfillActivity, activity.RegisterOptions{Name: backfillActivityName})
+}
+
+func (s *activitiesTestSuite) TearDownTest() {
+	s.mockResource.Finish(s.T())
+	s.esClient.AssertExpectations(s.T())
+}
+
+func (s *activitiesTestSuite) TestBackfillOpenExecutions() {
+	startTime := time.Unix(1000, 0)
+	s.mockResource.ExecutionMgr.On("ListConcreteExecutions", mock.Anything, &persistence.ListConcreteExecutionsRequest{PageSize: 10}).
+		Return(&persistence.ListConcreteExecutionsResponse{
+			Executions: []*persistence.ListConcreteExecutionsEntity{
+				{ExecutionInfo: testExecutionInfo(testDomainID, "running-wid", persistence.WorkflowStateRunning, startTime)},
+				{ExecutionInfo: testExecutionInfo(testDomainID, "completed-wid", persistence.WorkflowStateCompleted, startTime)},
+				{ExecutionInfo: testExecutionInfo("other-domain-id", "other-wid", persistence.WorkflowStateRunning, startTime)},
+			},
+		}, nil).Once()
+	s.mockResource.VisibilityMgr.On("RecordWorkflowExecutionStarted", mock.Anything, &persistence.RecordWorkflowExecutionStartedRequest{
+		DomainUUID:         testDomainID,
+		Domain:             testDomainName,
+		Execution:          types.WorkflowExecution{WorkflowID: "running-wid", RunID: "running-wid-run"},
+		WorkflowTypeName:   "test-workflow-type",
+		StartTimestamp:     startTime.UnixNano(),
+		ExecutionTimestamp: startTime.UnixNano(),
+		WorkflowTimeout:    60,
+		TaskList:           "test-tasklist",
+		NumClusters:        1,
+		UpdateTimestamp:    startTime.UnixNano(),
+	}).Return(nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:  testDomainName,
+		Mode:        ModeBackfill,
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStoreES,
+		RPS:         1000,
+		PageSize:    10,
+		Progress:    Progress{Phase: PhaseOpen},
+	})
+	// the next history shard is read after the last page of the first one
+	s.Equal(Progress{Phase: PhaseOpen, ShardID: 1, Processed: 1}, progress)
+}
+
+func (s *activitiesTestSuite) TestBackfillClosedExecutions() {
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutions", mock.Anything, &persistence.ListWorkflowExecutionsRequest{
+		DomainUUID:    testDomainID,
+		Domain:        testDomainName,
+		EarliestTime:  0,
+		LatestTime:    int64(2000),
+		PageSize:      10,
+		NextPageToken: []byte("page-token"),
+	}).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions:    []*types.WorkflowExecutionInfo{testClosedInfo("closed-wid", 1000, "test-workflow-type")},
+		NextPageToken: []byte("next-page-token"),
+	}, nil).Once()
+	s.mockResource.VisibilityMgr.On("RecordWorkflowExecutionClosed", mock.Anything, &persistence.RecordWorkflowExecutionClosedRequest{
+		DomainUUID:         testDomainID,
+		Domain:             testDomainName,
+		Execution:          types.WorkflowExecution{WorkflowID: "closed-wid", RunID: "closed-wid-run"},
+		WorkflowTypeName:   "test-workflow-type",
+		StartTimestamp:     1000,
+		ExecutionTimestamp: 1000,
+		CloseTimestamp:     1500,
+		Status:             types.WorkflowExecutionCloseStatusCompleted,
+		HistoryLength:      10,
+		RetentionSeconds:   7 * secondsInDay,
+		TaskList:           "test-tasklist",
+		NumClusters:        1,
+	}).Return(nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:  testDomainName,
+		Mode:        ModeBackfill,
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStorePinot,
+		LatestTime:  time.Unix(0, 2000),
+		RPS:         1000,
+		PageSize:    10,
+		Progress:    Progress{Phase: PhaseClosed, PageToken: []byte("page-token")},
+	})
+	s.Equal(Progress{Phase: PhaseClosed, PageToken: []byte("next-page-token"), Processed: 1}, progress)
+}
+
+func (s *activitiesTestSuite) TestCompareClosedExecutions() {
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutions", mock.Anything, mock.Anything).
+		Return(&persistence.ListWorkflowExecutionsResponse{
+			Executions: []*types.WorkflowExecutionInfo{
+				testClosedInfo("same-wid", int64(time.Millisecond)+1, "test-workflow-type"),
+				testClosedInfo("missing-wid", 1000, "test-workflow-type"),
+				testClosedInfo("different-wid", 1000, "test-workflow-type"),
+			},
+		}, nil).Once()
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutionsByWorkflowID", mock.Anything, mock.MatchedBy(
+		func(request *persistence.ListWorkflowExecutionsByWorkflowIDRequest) bool {
+			return request.WorkflowID == "same-wid"
+		})).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions: []*types.WorkflowExecutionInfo{
+			// the start time in the target store is truncated to milliseconds
+			testClosedInfo("same-wid", int64(time.Millisecond), "test-workflow-type"),
+		},
+	}, nil).Once()
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutionsByWorkflowID", mock.Anything, mock.MatchedBy(
+		func(request *persistence.ListWorkflowExecutionsByWorkflowIDRequest) bool {
+			return request.WorkflowID == "missing-wid"
+		})).Return(&persistence.ListWorkflowExecutionsResponse{}, nil).Once()
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutionsByWorkflowID", mock.Anything, mock.MatchedBy(
+		func(request *persistence.ListWorkflowExecutionsByWorkflowIDRequest) bool {
+			return request.WorkflowID == "different-wid"
+		})).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions: []*types.WorkflowExecutionInfo{testClosedInfo("different-wid", 1000, "other-workflow-type")},
+	}, nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:            testDomainName,
+		Mode:                  ModeCompare,
+		SourceStore:           persistence.VisibilityStoreES,
+		TargetStore:           persistence.VisibilityStorePinot,
+		LatestTime:            time.Unix(0, 2000),
+		RPS:                   1000,
+		PageSize:              10,
+		MaxReportedMismatches: 1,
+		Progress:              Progress{Phase: PhaseClosed},
+	})
+	s.Equal(Progress{
+		Phase:      PhaseDone,
+		Processed:  3,
+		Mismatched: 2,
+		Mismatches: []string{"missing-wid/missing-wid-run: " + errMsgRecordMissingInTarget},
+	}, progress)
+}
+
+func (s *activitiesTestSuite) TestMigrateClosedExecutions() {
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutions", mock.Anything, mock.Anything).
+		Return(&persistence.ListWorkflowExecutionsResponse{
+			Executions: []*types.WorkflowExecutionInfo{
+				testMigratedInfo("migrated-wid", map[string][]byte{"OldKey": []byte(`"5"`)}),
+				testMigratedInfo("invalid-wid", map[string][]byte{"OldKey": []byte(`"five"`)}),
+				testMigratedInfo("copied-wid", map[string][]byte{"OldKey": []byte(`"5"`), "NewKey": []byte(`6`)}),
+				testMigratedInfo("missing-wid", nil),
+			},
+		}, nil).Once()
+	s.mockResource.VisibilityMgr.On("RecordWorkflowExecutionClosed", mock.Anything, mock.MatchedBy(
+		func(request *persistence.RecordWorkflowExecutionClosedRequest) bool {
+			return request.Execution.WorkflowID == "migrated-wid" &&
+				string(request.SearchAttributes["OldKey"]) == `"5"` &&
+				string(request.SearchAttributes["NewKey"]) == `5`
+		})).Return(nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:            testDomainName,
+		Mode:                  ModeMigrate,
+		SourceStore:           persistence.VisibilityStorePinot,
+		TargetStore:           persistence.VisibilityStorePinot,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "NewKey",
+		LatestTime:            time.Unix(0, 2000),
+		RPS:                   1000,
+		PageSize:              10,
+		MaxReportedMismatches: 10,
+		Progress:              Progress{Phase: PhaseClosed},
+	})
+	s.Equal(PhaseDone, progress.Phase)
+	s.Equal(int64(1), progress.Processed)
+	s.Equal(int64(1), progress.Mismatched)
+	s.Len(progress.Mismatches, 1)
+	s.Contains(progress.Mismatches[0], "invalid-wid/invalid-wid-run")
+}
+
+func (s *activitiesTestSuite) TestMigrateOpenExecutions() {
+	startTime := time.Unix(1000, 0)
+	s.mockResource.VisibilityMgr.On("ListOpenWorkflowExecutions", mock.Anything, mock.Anything).
+		Return(&persistence.ListWorkflowExecutionsResponse{
+			Executions: []*types.WorkflowExecutionInfo{
+				testMigratedInfo("running-wid", map[string][]byte{"OldKey": []byte(`"5"`)}),
+				testMigratedInfo("completed-wid", map[string][]byte{"OldKey": []byte(`"5"`)}),
+			},
+		}, nil).Once()
+	runningInfo := testExecutionInfo(testDomainID, "running-wid", persistence.WorkflowStateRunning, startTime)
+	// the search attribute changed since the execution was listed
+	runningInfo.SearchAttributes = map[string][]byte{"OldKey": []byte(`"7"`)}
+	s.mockResource.ExecutionMgr.On("GetWorkflowExecution", mock.Anything, mock.MatchedBy(
+		func(request *persistence.GetWorkflowExecutionRequest) bool {
+			return request.Execution.WorkflowID == "running-wid"
+		})).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{ExecutionInfo: runningInfo},
+	}, nil).Once()
+	s.mockResource.ExecutionMgr.On("GetWorkflowExecution", mock.Anything, mock.MatchedBy(
+		func(request *persistence.GetWorkflowExecutionRequest) bool {
+			return request.Execution.WorkflowID == "completed-wid"
+		})).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{
+			ExecutionInfo: testExecutionInfo(testDomainID, "completed-wid", persistence.WorkflowStateCompleted, startTime),
+		},
+	}, nil).Once()
+	s.mockResource.VisibilityMgr.On("RecordWorkflowExecutionStarted", mock.Anything, &persistence.RecordWorkflowExecutionStartedRequest{
+		DomainUUID:         testDomainID,
+		Domain:             testDomainName,
+		Execution:          types.WorkflowExecution{WorkflowID: "running-wid", RunID: "running-wid-run"},
+		WorkflowTypeName:   "test-workflow-type",
+		StartTimestamp:     startTime.UnixNano(),
+		ExecutionTimestamp: 1000,
+		WorkflowTimeout:    60,
+		TaskList:           "test-tasklist",
+		NumClusters:        1,
+		UpdateTimestamp:    startTime.UnixNano(),
+		SearchAttributes:   map[string][]byte{"OldKey": []byte(`"7"`), "NewKey": []byte(`7`)},
+		ShardID:            int16(common.WorkflowIDToHistoryShard("running-wid", 2)),
+	}).Return(nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:            testDomainName,
+		Mode:                  ModeMigrate,
+		SourceStore:           persistence.VisibilityStoreDB,
+		TargetStore:           persistence.VisibilityStoreDB,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "NewKey",
+		RPS:                   1000,
+		PageSize:              10,
+		Progress:              Progress{Phase: PhaseOpen},
+	})
+	s.Equal(Progress{Phase: PhaseClosed, Processed: 1}, progress)
+}
+
+func (s *activitiesTestSuite) TestMigrateESExecutions() {
+	s.mockResource.VisibilityMgr.On("ListClosedWorkflowExecutions", mock.Anything, mock.Anything).
+		Return(&persistence.ListWorkflowExecutionsResponse{
+			Executions: []*types.WorkflowExecutionInfo{
+				testMigratedInfo("migrated-wid", map[string][]byte{"OldKey": []byte(`"5"`)}),
+				testMigratedInfo("copied-wid", map[string][]byte{"OldKey": []byte(`"5"`), "NewKey": []byte(`6`)}),
+			},
+			NextPageToken: []byte("next-page-token"),
+		}, nil).Once()
+	s.esClient.On("UpdateByQuery", mock.Anything, "test-index", mock.MatchedBy(func(body string) bool {
+		var request struct {
+			Script struct {
+				Params struct {
+					Key    string
+					Values map[string]int
+				}
+			}
+		}
+		s.NoError(json.Unmarshal([]byte(body), &request))
+		return request.Script.Params.Key == "NewKey" &&
+			len(request.Script.Params.Values) == 1 &&
+			request.Script.Params.Values["migrated-wid-run"] == 5
+	})).Return(&esc.UpdateByQueryResponse{Total: 1, Updated: 1}, nil).Once()
+
+	progress := s.executeActivity(Params{
+		DomainName:            testDomainName,
+		Mode:                  ModeMigrate,
+		SourceStore:           persistence.VisibilityStoreES,
+		TargetStore:           persistence.VisibilityStoreES,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "NewKey",
+		LatestTime:            time.Unix(0, 2000),
+		RPS:                   1000,
+		PageSize:              10,
+		Progress:              Progress{Phase: PhaseClosed},
+	})
+	s.Equal(Progress{Phase: PhaseClosed, PageToken: []byte("next-page-token"), Processed: 1}, progress)
+}
+
+func (s *activitiesTestSuite) TestMigrate_InvalidNewKey() {
+	_, err := s.activityEnv.ExecuteActivity(backfillActivityName, Params{
+		DomainName:            testDomainName,
+		Mode:                  ModeMigrate,
+		SourceStore:           persistence.VisibilityStorePinot,
+		TargetStore:           persistence.VisibilityStorePinot,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "UnknownKey",
+		Progress:              Progress{Phase: PhaseOpen},
+	})
+	s.Error(err)
+	s.Contains(err.Error(), errReasonNonRetriable)
+}
+
+func (s *activitiesTestSuite) TestBackfillActivity_DomainNotExists() {
+	s.mockResource.DomainCache.EXPECT().GetDomain("not-exist-domain").
+		Return(nil, &types.EntityNotExistsError{Message: "domain does not exist"})
+	_, err := s.activityEnv.ExecuteActivity(backfillActivityName, Params{
+		DomainName:  "not-exist-domain",
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStoreES,
+		Progress:    Progress{Phase: PhaseOpen},
+	})
+	s.Error(err)
+	s.Contains(err.Error(), errReasonNonRetriable)
+}
+
+func (s *activitiesTestSuite) executeActivity(params Params) Progress {
+	value, err := s.activityEnv.ExecuteActivity(backfillActivityName, params)
+	s.NoError(err)
+	var progress Progress
+	s.NoError(value.Get(&progress))
+	return progress
+}
+
+func testExecutionInfo(domainID, workflowID string, state int, startTime time.Time) *persistence.WorkflowExecutionInfo {
+	return &persistence.WorkflowExecutionInfo{
+		DomainID:             domainID,
+		WorkflowID:           workflowID,
+		RunID:                workflowID + "-run",
+		WorkflowTypeName:     "test-workflow-type",
+		TaskList:             "test-tasklist",
+		WorkflowTimeout:      60,
+		State:                state,
+		StartTimestamp:       startTime,
+		LastUpdatedTimestamp: startTime,
+	}
+}
+
+func testClosedInfo(workflowID string, startTime int64, workflowType string) *types.WorkflowExecutionInfo {
+	return &types.WorkflowExecutionInfo{
+		Execution:     &types.WorkflowExecution{WorkflowID: workflowID, RunID: workflowID + "-run"},
+		Type:          &types.WorkflowType{Name: workflowType},
+		StartTime:     common.Int64Ptr(startTime),
+		ExecutionTime: common.Int64Ptr(startTime),
+		CloseTime:     common.Int64Ptr(1500),
+		CloseStatus:   types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+		HistoryLength: 10,
+		TaskList:      "test-tasklist",
+	}
+}
+
+func testMigratedInfo(workflowID string, searchAttributes map[string][]byte) *types.WorkflowExecutionInfo {
+	info := testClosedInfo(workflowID, 1000, "test-workflow-type")
+	info.SearchAttributes = &types.SearchAttributes{IndexedFields: searchAttributes}
+	return info
+}
@@ -0,0 +1,106 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package visibilitybackfill
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/dynamicconfig"
+	es "github.com/uber/cadence/common/elasticsearch"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/resource"
+)
+
+type (
+	// BootstrapParams contains the set of params needed to bootstrap the visibility backfill
+	BootstrapParams struct {
+		// ServiceClient is an instance of cadence service client
+		ServiceClient workflowserviceclient.Interface
+		// TallyScope is an instance of tally metrics scope
+		TallyScope tally.Scope
+		// NumHistoryShards is the number of history shards whose executions are read in backfill mode
+		NumHistoryShards int
+		// ESClient and ESIndex are used to migrate search attributes in ElasticSearch, they are nil without it
+		ESClient es.GenericClient
+		ESIndex  string
+		// ValidSearchAttributes gives the type a search attribute is converted to in migrate mode
+		ValidSearchAttributes dynamicconfig.MapPropertyFn
+	}
+
+	// VisibilityBackfill runs the workflows which copy the visibility records of a domain into a newly added
+	// visibility store, or compare the records of two visibility stores.
+	VisibilityBackfill struct {
+		resource              resource.Resource
+		svcClient             workflowserviceclient.Interface
+		tallyScope            tally.Scope
+		numHistoryShards      int
+		esClient              es.GenericClient
+		esIndex               string
+		validSearchAttributes dynamicconfig.MapPropertyFn
+		logger                log.Logger
+		worker                worker.Worker
+	}
+)
+
+// New returns a new instance of VisibilityBackfill
+func New(resource resource.Resource, params *BootstrapParams) *VisibilityBackfill {
+	return &VisibilityBackfill{
+		resource:              resource,
+		svcClient:             params.ServiceClient,
+		tallyScope:            params.TallyScope,
+		numHistoryShards:      params.NumHistoryShards,
+		esClient:              params.ESClient,
+		esIndex:               params.ESIndex,
+		validSearchAttributes: params.ValidSearchAttributes,
+		logger:                resource.GetLogger().WithTags(tag.ComponentVisibilityBackfill),
+	}
+}
+
+// Start starts the worker
+func (b *VisibilityBackfill) Start() error {
+	ctx := context.WithValue(context.Background(), visibilityBackfillContextKey, b)
+	workerOpts := worker.Options{
+		MetricsScope:              b.tallyScope,
+		BackgroundActivityContext: ctx,
+		Tracer:                    opentracing.GlobalTracer(),
+	}
+	backfillWorker := worker.New(b.svcClient, common.SystemLocalDomainName, TaskListName, workerOpts)
+	backfillWorker.RegisterWorkflowWithOptions(BackfillWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	backfillWorker.RegisterActivityWithOptions(backfillActivity, activity.RegisterOptions{Name: backfillActivityName})
+	b.worker = backfillWorker
+	return backfillWorker.Start()
+}
+
+// Stop stops the worker
+func (b *VisibilityBackfill) Stop() {
+	b.worker.Stop()
+}
@@ -0,0 +1,243 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package visibilitybackfill
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common/persistence"
+)
+
+type (
+	contextKey string
+)
+
+const (
+	visibilityBackfillContextKey contextKey = "visibilityBackfillContext"
+	// TaskListName is the tasklist of the visibility backfill workflows
+	TaskListName = "cadence-sys-visibility-backfill-tasklist"
+	// WorkflowTypeName is the workflow type of the visibility backfill workflows
+	WorkflowTypeName = "cadence-sys-visibility-backfill-workflow"
+
+	backfillActivityName = "cadence-sys-visibility-backfill-activity"
+
+	// QueryType is the query type to get the Progress of a backfill
+	QueryType = "progress"
+
+	// ModeBackfill writes the visibility records of a domain into the target store
+	ModeBackfill = "backfill"
+	// ModeCompare reports the visibility records of the source store which are missing or different in the target store
+	ModeCompare = "compare"
+	// ModeMigrate copies the values of a search attribute to a new key in the records of the target store,
+	// converting them to the type of the new key
+	ModeMigrate = "migrate"
+
+	// PhaseOpen processes the open executions
+	PhaseOpen = "open"
+	// PhaseClosed processes the closed executions
+	PhaseClosed = "closed"
+	// PhaseDone means all the executions are processed
+	PhaseDone = "done"
+
+	// DefaultRPS is the max number of records processed per second if it is not set
+	DefaultRPS = 100
+	// DefaultPageSize is the number of records read per page if it is not set
+	DefaultPageSize = 1000
+	// DefaultMaxReportedMismatches is the max number of mismatches kept in the Progress if it is not set
+	DefaultMaxReportedMismatches = 100
+
+	// maxPagesPerRun bounds the history size, the workflow continues as new after that many pages
+	maxPagesPerRun = 200
+
+	errMsgDomainNameIsEmpty     = "domainName is empty"
+	errMsgInvalidMode           = "mode is not valid"
+	errMsgInvalidSourceStore    = "sourceStore is not a valid visibility store"
+	errMsgInvalidTargetStore    = "targetStore is not a valid visibility store"
+	errMsgSameSourceAndTarget   = "sourceStore and targetStore must be different"
+	errMsgInvalidTimeRange      = "earliestTime is after latestTime"
+	errMsgInvalidMigrateKeys    = "searchAttributeKey and newSearchAttributeKey must be set and different in migrate mode"
+	errMsgInvalidNewKey         = "newSearchAttributeKey is not a valid search attribute key"
+	errMsgESNotConfigured       = "ElasticSearch is not configured"
+	errMsgInvalidPhase          = "phase of progress is not valid"
+	errReasonNonRetriable       = "cadence-sys-visibility-backfill-nonRetriable-error"
+	errMsgRecordMissingInTarget = "record is missing in target store"
+)
+
+type (
+	// Params is the input of the visibility backfill workflow
+	Params struct {
+		DomainName string
+		// Mode is one of backfill, compare or migrate, default to backfill
+		Mode string
+		// SourceStore is one of db, es or pinot. The closed executions are read from it in all modes,
+		// the open executions are read from it in compare mode. In backfill mode the open executions are read
+		// from the executions of all the history shards, so that the target store gets their latest state.
+		// In migrate mode it is the target store.
+		SourceStore string
+		// TargetStore is one of db, es or pinot, es also stands for OpenSearch
+		TargetStore string
+		// EarliestTime and LatestTime bound the close time of the closed executions and the start time of the
+		// open executions in compare mode. LatestTime defaults to the time the backfill is started.
+		EarliestTime time.Time
+		LatestTime   time.Time
+		// RPS is the max number of records processed per second, it bounds the writes in backfill mode
+		// and the reads of the target store in compare mode
+		RPS                   int
+		PageSize              int
+		MaxReportedMismatches int
+		// SearchAttributeKey is copied to NewSearchAttributeKey in migrate mode
+		SearchAttributeKey    string
+		NewSearchAttributeKey string
+		// Progress is the checkpoint of the backfill, it is carried over when the workflow continues as new
+		Progress Progress
+	}
+
+	// Progress is the checkpoint of a backfill, which is also the result of QueryType
+	Progress struct {
+		Phase string
+		// ShardID is the history shard being read in the open phase of backfill mode
+		ShardID   int
+		PageToken []byte
+		// Processed is the number of records written in backfill and migrate modes or compared in compare mode
+		Processed int64
+		// Mismatched is the number of records which are different in compare mode,
+		// or whose value can't be converted to the type of the new key in migrate mode
+		Mismatched int64
+		// Mismatches are the first MaxReportedMismatches mismatches
+		Mismatches []string
+	}
+)
+
+// BackfillWorkflow pages through the visibility records of a domain and writes them into, or compares them with,
+// the target store. The page is processed by an activity which returns the next checkpoint.
+func BackfillWorkflow(ctx workflow.Context, params Params) (*Progress, error) {
+	if err := ValidateParams(&params); err != nil {
+		return nil, err
+	}
+	if params.LatestTime.IsZero() {
+		params.LatestTime = workflow.Now(ctx)
+	}
+	logger := workflow.GetLogger(ctx).With(
+		zap.String("domain", params.DomainName),
+		zap.String("mode", params.Mode),
+		zap.String("sourceStore", params.SourceStore),
+		zap.String("targetStore", params.TargetStore),
+	)
+
+	err := workflow.SetQueryHandler(ctx, QueryType, func() (*Progress, error) {
+		return &params.Progress, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ao := workflow.WithActivityOptions(ctx, getActivityOptions(params))
+	for i := 0; i < maxPagesPerRun; i++ {
+		if params.Progress.Phase == PhaseDone {
+			logger.Info("Visibility backfill is done.",
+				zap.Int64("processed", params.Progress.Processed),
+				zap.Int64("mismatched", params.Progress.Mismatched))
+			return &params.Progress, nil
+		}
+		var progress Progress
+		if err := workflow.ExecuteActivity(ao, backfillActivityName, params).Get(ctx, &progress); err != nil {
+			logger.Error("Failed to process visibility records.",
+				zap.String("phase", params.Progress.Phase),
+				zap.Int("shardID", params.Progress.ShardID),
+				zap.Error(err))
+			return nil, err
+		}
+		params.Progress = progress
+	}
+	return nil, workflow.NewContinueAsNewError(ctx, WorkflowTypeName, params)
+}
+
+// ValidateParams validates the params of a backfill and fills in the defaults
+func ValidateParams(params *Params) error {
+	if params.DomainName == "" {
+		return errors.New(errMsgDomainNameIsEmpty)
+	}
+	if params.Mode == "" {
+		params.Mode = ModeBackfill
+	}
+	if params.Mode != ModeBackfill && params.Mode != ModeCompare && params.Mode != ModeMigrate {
+		return errors.New(errMsgInvalidMode)
+	}
+	if params.Mode == ModeMigrate {
+		if params.SearchAttributeKey == "" || params.NewSearchAttributeKey == "" ||
+			params.SearchAttributeKey == params.NewSearchAttributeKey {
+			return errors.New(errMsgInvalidMigrateKeys)
+		}
+		params.SourceStore = params.TargetStore
+	}
+	if !persistence.IsValidVisibilityStore(params.SourceStore) {
+		return errors.New(errMsgInvalidSourceStore)
+	}
+	if !persistence.IsValidVisibilityStore(params.TargetStore) {
+		return errors.New(errMsgInvalidTargetStore)
+	}
+	if params.SourceStore == params.TargetStore && params.Mode != ModeMigrate {
+		return errors.New(errMsgSameSourceAndTarget)
+	}
+	if !params.LatestTime.IsZero() && params.EarliestTime.After(params.LatestTime) {
+		return errors.New(errMsgInvalidTimeRange)
+	}
+	if params.RPS <= 0 {
+		params.RPS = DefaultRPS
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = DefaultPageSize
+	}
+	if params.MaxReportedMismatches <= 0 {
+		params.MaxReportedMismatches = DefaultMaxReportedMismatches
+	}
+	switch params.Progress.Phase {
+	case "":
+		params.Progress.Phase = PhaseOpen
+	case PhaseOpen, PhaseClosed, PhaseDone:
+	default:
+		return errors.New(errMsgInvalidPhase)
+	}
+	return nil
+}
+
+func getActivityOptions(params Params) workflow.ActivityOptions {
+	// a page takes at least PageSize/RPS seconds because of the rate limiting
+	pageDuration := time.Duration(params.PageSize/params.RPS) * time.Second
+	return workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    pageDuration + time.Minute,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:          time.Second,
+			BackoffCoefficient:       2,
+			MaximumInterval:          time.Minute,
+			ExpirationInterval:       time.Hour,
+			NonRetriableErrorReasons: []string{errReasonNonRetriable},
+		},
+	}
+}
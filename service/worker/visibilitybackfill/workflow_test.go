@@ -0,0 +1,182 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package visibilitybackfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/persistence"
+)
+
+type backfillWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	workflowEnv *testsuite.TestWorkflowEnvironment
+}
+
+func TestBackfillWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(backfillWorkflowTestSuite))
+}
+
+func (s *backfillWorkflowTestSuite) SetupTest() {
+	s.workflowEnv = s.NewTestWorkflowEnvironment()
+	s.workflowEnv.RegisterWorkflowWithOptions(BackfillWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	s.workflowEnv.RegisterActivityWithOptions(backfillActivity, activity.RegisterOptions{Name: backfillActivityName})
+}
+
+func (s *backfillWorkflowTestSuite) TestValidateParams() {
+	s.Error(ValidateParams(&Params{}))
+	s.Error(ValidateParams(&Params{DomainName: "test-domain", SourceStore: "invalid", TargetStore: persistence.VisibilityStoreES}))
+	s.Error(ValidateParams(&Params{DomainName: "test-domain", SourceStore: persistence.VisibilityStoreES, TargetStore: persistence.VisibilityStoreES}))
+	s.Error(ValidateParams(&Params{
+		DomainName:  "test-domain",
+		Mode:        "invalid",
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStoreES,
+	}))
+	s.Error(ValidateParams(&Params{
+		DomainName:   "test-domain",
+		SourceStore:  persistence.VisibilityStoreDB,
+		TargetStore:  persistence.VisibilityStoreES,
+		EarliestTime: time.Unix(100, 0),
+		LatestTime:   time.Unix(10, 0),
+	}))
+
+	params := &Params{DomainName: "test-domain", SourceStore: persistence.VisibilityStoreDB, TargetStore: persistence.VisibilityStorePinot}
+	s.NoError(ValidateParams(params))
+	s.Equal(ModeBackfill, params.Mode)
+	s.Equal(DefaultRPS, params.RPS)
+	s.Equal(DefaultPageSize, params.PageSize)
+	s.Equal(DefaultMaxReportedMismatches, params.MaxReportedMismatches)
+	s.Equal(PhaseOpen, params.Progress.Phase)
+}
+
+func (s *backfillWorkflowTestSuite) TestValidateParams_Migrate() {
+	s.Error(ValidateParams(&Params{
+		DomainName:         "test-domain",
+		Mode:               ModeMigrate,
+		TargetStore:        persistence.VisibilityStorePinot,
+		SearchAttributeKey: "OldKey",
+	}))
+	s.Error(ValidateParams(&Params{
+		DomainName:            "test-domain",
+		Mode:                  ModeMigrate,
+		TargetStore:           persistence.VisibilityStorePinot,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "OldKey",
+	}))
+
+	params := &Params{
+		DomainName:            "test-domain",
+		Mode:                  ModeMigrate,
+		TargetStore:           persistence.VisibilityStorePinot,
+		SearchAttributeKey:    "OldKey",
+		NewSearchAttributeKey: "NewKey",
+	}
+	s.NoError(ValidateParams(params))
+	// the search attribute is migrated within the target store
+	s.Equal(persistence.VisibilityStorePinot, params.SourceStore)
+}
+
+func (s *backfillWorkflowTestSuite) TestBackfillWorkflow() {
+	s.workflowEnv.OnActivity(backfillActivityName, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params Params) (*Progress, error) {
+			progress := params.Progress
+			progress.Processed += 10
+			switch progress.Phase {
+			case PhaseOpen:
+				progress.Phase = PhaseClosed
+			case PhaseClosed:
+				progress.Mismatched++
+				progress.Mismatches = append(progress.Mismatches, "test-wid/test-rid: mismatch")
+				progress.Phase = PhaseDone
+			}
+			return &progress, nil
+		},
+	).Times(2)
+
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, Params{
+		DomainName:  "test-domain",
+		Mode:        ModeCompare,
+		SourceStore: persistence.VisibilityStoreES,
+		TargetStore: persistence.VisibilityStorePinot,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+	var result Progress
+	s.NoError(s.workflowEnv.GetWorkflowResult(&result))
+	s.Equal(Progress{
+		Phase:      PhaseDone,
+		Processed:  20,
+		Mismatched: 1,
+		Mismatches: []string{"test-wid/test-rid: mismatch"},
+	}, result)
+
+	value, err := s.workflowEnv.QueryWorkflow(QueryType)
+	s.NoError(err)
+	var progress Progress
+	s.NoError(value.Get(&progress))
+	s.Equal(result, progress)
+}
+
+func (s *backfillWorkflowTestSuite) TestBackfillWorkflow_ContinueAsNew() {
+	s.workflowEnv.OnActivity(backfillActivityName, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params Params) (*Progress, error) {
+			progress := params.Progress
+			progress.Processed++
+			progress.PageToken = []byte("next-page")
+			return &progress, nil
+		},
+	).Times(maxPagesPerRun)
+
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, Params{
+		DomainName:  "test-domain",
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStoreES,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	_, ok := s.workflowEnv.GetWorkflowError().(*workflow.ContinueAsNewError)
+	s.True(ok)
+}
+
+func (s *backfillWorkflowTestSuite) TestBackfillWorkflow_ActivityFailure() {
+	s.workflowEnv.OnActivity(backfillActivityName, mock.Anything, mock.Anything).
+		Return(nil, cadence.NewCustomError(errReasonNonRetriable, "domain does not exist"))
+
+	s.workflowEnv.ExecuteWorkflow(WorkflowTypeName, Params{
+		DomainName:  "test-domain",
+		SourceStore: persistence.VisibilityStoreDB,
+		TargetStore: persistence.VisibilityStoreES,
+	})
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.Error(s.workflowEnv.GetWorkflowError())
+}
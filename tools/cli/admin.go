@@ -28,6 +28,7 @@ import (
 
 	"github.com/urfave/cli"
 
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/invariant"
 	"github.com/uber/cadence/service/worker/scanner/executions"
 )
@@ -107,6 +108,37 @@ func newAdminWorkflowCommands() []cli.Command {
 				AdminRefreshWorkflowTasks(c)
 			},
 		},
+		{
+			Name:  "pause",
+			Usage: "Pause a workflow, none of its decision and activity tasks is dispatched until it's resumed",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowIDWithAlias,
+					Usage: "WorkflowID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminPauseWorkflow(c)
+			},
+		},
+		{
+			Name:  "resume",
+			Usage: "Resume a paused workflow and dispatch its pending decision and activity tasks",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowIDWithAlias,
+					Usage: "WorkflowID",
+				},
+				cli.IntFlag{
+					Name:  FlagRefreshWaitTimeWithAlias,
+					Usage: "Optional time to wait in seconds for the dynamic config change to reach the history hosts before the tasks of the workflow are refreshed",
+					Value: defaultRefreshWaitTimeInSeconds,
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminResumeWorkflow(c)
+			},
+		},
 		{
 			Name:    "delete",
 			Aliases: []string{"del"},
@@ -468,6 +500,71 @@ func newAdminDomainCommands() []cli.Command {
 				newDomainCLI(c, false).ListDomains(c)
 			},
 		},
+		{
+			Name:        "concurrency",
+			Aliases:     []string{"cc"},
+			Usage:       "Manage concurrency limits of workflow types under a domain",
+			Subcommands: newAdminWorkflowConcurrencyCommands(),
+		},
+	}
+}
+
+func newAdminWorkflowConcurrencyCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List concurrency limits of workflow types under a domain with their open executions",
+			Action: func(c *cli.Context) {
+				AdminListWorkflowConcurrencyLimits(c)
+			},
+		},
+		{
+			Name:    "describe",
+			Aliases: []string{"desc"},
+			Usage:   "Describe concurrency limit and open executions of a workflow type under a domain",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowTypeWithAlias,
+					Usage: "Workflow type name",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDescribeWorkflowConcurrency(c)
+			},
+		},
+		{
+			Name:    "update",
+			Aliases: []string{"u"},
+			Usage:   "Set concurrency limit of a workflow type under a domain",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowTypeWithAlias,
+					Usage: "Workflow type name",
+				},
+				cli.IntFlag{
+					Name:  FlagConcurrencyLimit,
+					Usage: "Max number of open executions of the workflow type",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminUpdateWorkflowConcurrencyLimit(c)
+			},
+		},
+		{
+			Name:    "remove",
+			Aliases: []string{"rm"},
+			Usage:   "Remove concurrency limit of a workflow type under a domain",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowTypeWithAlias,
+					Usage: "Workflow type name",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminRemoveWorkflowConcurrencyLimit(c)
+			},
+		},
 	}
 }
 
@@ -695,6 +792,57 @@ func newAdminTaskListCommands() []cli.Command {
 				AdminListTaskList(c)
 			},
 		},
+		{
+			Name:        "ratelimit",
+			Aliases:     []string{"rl"},
+			Usage:       "Manage dispatch rate limits of activity types under a domain",
+			Subcommands: newAdminActivityTypeRateLimitCommands(),
+		},
+	}
+}
+
+func newAdminActivityTypeRateLimitCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List dispatch rate limits of activity types under a domain",
+			Action: func(c *cli.Context) {
+				AdminListActivityTypeRateLimits(c)
+			},
+		},
+		{
+			Name:    "update",
+			Aliases: []string{"u"},
+			Usage:   "Set dispatch rate limit of an activity type under a domain",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagActivityTypeWithAlias,
+					Usage: "Activity type name",
+				},
+				cli.IntFlag{
+					Name:  FlagRPS,
+					Usage: "Activity tasks dispatched per second across all partitions of a task list",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminUpdateActivityTypeRateLimit(c)
+			},
+		},
+		{
+			Name:    "remove",
+			Aliases: []string{"rm"},
+			Usage:   "Remove dispatch rate limit of an activity type under a domain",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagActivityTypeWithAlias,
+					Usage: "Activity type name",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminRemoveActivityTypeRateLimit(c)
+			},
+		},
 	}
 }
 
@@ -723,6 +871,78 @@ func newAdminClusterCommands() []cli.Command {
 				AdminAddSearchAttribute(c)
 			},
 		},
+		{
+			Name:    "deprecate-search-attr",
+			Aliases: []string{"dsa"},
+			Usage:   "deprecate search attribute, writes to it are still accepted but logged",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key to be deprecated",
+				},
+				cli.StringFlag{
+					Name:  FlagReasonWithAlias,
+					Usage: "Reason of the deprecation",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDeprecateSearchAttribute(c)
+			},
+		},
+		{
+			Name:    "remove-search-attr",
+			Aliases: []string{"rsa"},
+			Usage:   "remove search attribute from the whitelist and reject writes to it",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key to be removed",
+				},
+				cli.StringFlag{
+					Name:  FlagReasonWithAlias,
+					Usage: "Reason of the removal",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminRemoveSearchAttribute(c)
+			},
+		},
+		{
+			Name:    "migrate-search-attr",
+			Aliases: []string{"msa"},
+			Usage:   "migrate search attribute to a new key with a new type, the existing records are migrated by workflows and the old key is deprecated",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key to be migrated",
+				},
+				cli.StringFlag{
+					Name:  FlagSearchAttributesNewKey,
+					Usage: "Search Attribute key to migrate to",
+				},
+				cli.IntFlag{
+					Name:  FlagSearchAttributesType,
+					Value: -1,
+					Usage: "Search Attribute value type of the new key. [0:String, 1:Keyword, 2:Int, 3:Double, 4:Bool, 5:Datetime]",
+				},
+				cli.StringFlag{
+					Name:  FlagVisibilityStore,
+					Value: persistence.VisibilityStoreES,
+					Usage: "Visibility store whose records are migrated: es (ElasticSearch or OpenSearch) or pinot",
+				},
+				cli.IntFlag{
+					Name:  FlagRPS,
+					Usage: "Optional max number of records migrated per second in each domain",
+				},
+				cli.StringFlag{
+					Name:  FlagSecurityTokenWithAlias,
+					Usage: "Optional token for security check",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminMigrateSearchAttribute(c)
+			},
+		},
 		{
 			Name:    "describe",
 			Aliases: []string{"d"},
@@ -1004,6 +1224,10 @@ func newDBCommands() []cli.Command {
 					Name:  FlagInputEncodingWithAlias,
 					Usage: "Encoding of the input: [hex|base64] (Default: hex)",
 				},
+				cli.StringFlag{
+					Name:  FlagDataEncoding,
+					Usage: "Data encoding of the input, as stored next to the data in the database: [thriftrw|thriftrw+snappy|thriftrw+zstd] (Default: thriftrw)",
+				},
 			},
 			Action: func(c *cli.Context) {
 				AdminDBDataDecodeThrift(c)
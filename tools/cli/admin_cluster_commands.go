@@ -30,8 +30,12 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/service/worker/failovermanager"
+	"github.com/uber/cadence/service/worker/visibilitybackfill"
 )
 
 // An indirection for the prompt function so that it can be mocked in the unit tests
@@ -72,6 +76,182 @@ func AdminAddSearchAttribute(c *cli.Context) {
 	fmt.Println("Success. Note that for a multil-node Cadence cluster, DynamicConfig MUST be updated separately to whitelist the new attributes.")
 }
 
+// AdminDeprecateSearchAttribute marks a whitelisted search attribute as deprecated, writes to it are still accepted but logged
+func AdminDeprecateSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+	reason := c.String(FlagReason)
+
+	validAttr := getDynamicConfigMapValue(c, dynamicconfig.ValidSearchAttributes.String())
+	if _, ok := validAttr[key]; !ok {
+		ErrorAndExit(fmt.Sprintf("Search attribute key [%s] is not whitelisted.", key), nil)
+	}
+
+	// ask user for confirmation
+	promptMsg := fmt.Sprintf("Are you trying to deprecate key [%s]? Y/N", color.YellowString(key))
+	promptFn(promptMsg)
+
+	deprecateSearchAttribute(c, key, reason)
+	fmt.Println("Success.")
+}
+
+// AdminRemoveSearchAttribute removes a search attribute from the whitelist, writes to it are rejected afterwards
+func AdminRemoveSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+	reason := c.String(FlagReason)
+	if definition.IsSystemIndexedKey(key) {
+		ErrorAndExit(fmt.Sprintf("Search attribute key [%s] is reserved by system.", key), nil)
+	}
+
+	validAttr := getDynamicConfigMapValue(c, dynamicconfig.ValidSearchAttributes.String())
+	if _, ok := validAttr[key]; !ok {
+		ErrorAndExit(fmt.Sprintf("Search attribute key [%s] is not whitelisted.", key), nil)
+	}
+
+	// ask user for confirmation
+	promptMsg := fmt.Sprintf("Are you trying to remove key [%s]? Writes to the key will be rejected. Y/N", color.YellowString(key))
+	promptFn(promptMsg)
+
+	// reject writes to the key first, so that it can't be written between the two updates
+	removedAttr := getDynamicConfigMapValue(c, dynamicconfig.RemovedSearchAttributes.String())
+	removedAttr[key] = reason
+	updateDynamicConfigMapValue(c, dynamicconfig.RemovedSearchAttributes.String(), removedAttr)
+
+	delete(validAttr, key)
+	updateDynamicConfigMapValue(c, dynamicconfig.ValidSearchAttributes.String(), validAttr)
+
+	deprecatedAttr := getDynamicConfigMapValue(c, dynamicconfig.DeprecatedSearchAttributes.String())
+	if _, ok := deprecatedAttr[key]; ok {
+		delete(deprecatedAttr, key)
+		updateDynamicConfigMapValue(c, dynamicconfig.DeprecatedSearchAttributes.String(), deprecatedAttr)
+	}
+
+	// a migrated key isn't written anymore, so it doesn't need to be copied to its new key
+	migratedAttr := getDynamicConfigMapValue(c, dynamicconfig.MigratedSearchAttributes.String())
+	if _, ok := migratedAttr[key]; ok {
+		delete(migratedAttr, key)
+		updateDynamicConfigMapValue(c, dynamicconfig.MigratedSearchAttributes.String(), migratedAttr)
+	}
+	fmt.Println("Success. Note that the field is kept in the ElasticSearch mapping, since mapping fields can't be dropped without reindexing into a new index.")
+}
+
+// AdminMigrateSearchAttribute migrates a search attribute to a new key with a new type. The values written to the old key
+// are copied to the new key by the visibility managers from now on, and the existing records of all the domains are
+// migrated by visibility backfill workflows, the old key is deprecated.
+func AdminMigrateSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+	newKey := getRequiredOption(c, FlagSearchAttributesNewKey)
+	if err := validateSearchAttributeKey(newKey); err != nil {
+		ErrorAndExit("Invalid search-attribute key.", err)
+	}
+	valType := getRequiredIntOption(c, FlagSearchAttributesType)
+	if !isValueTypeValid(valType) {
+		ErrorAndExit("Unknown Search Attributes value type.", nil)
+	}
+	store := c.String(FlagVisibilityStore)
+	if store != persistence.VisibilityStoreES && store != persistence.VisibilityStorePinot {
+		ErrorAndExit(fmt.Sprintf("Visibility store must be %s or %s.", persistence.VisibilityStoreES, persistence.VisibilityStorePinot), nil)
+	}
+
+	validAttr := getDynamicConfigMapValue(c, dynamicconfig.ValidSearchAttributes.String())
+	if _, ok := validAttr[key]; !ok {
+		ErrorAndExit(fmt.Sprintf("Search attribute key [%s] is not whitelisted.", key), nil)
+	}
+	migratedAttr := getDynamicConfigMapValue(c, dynamicconfig.MigratedSearchAttributes.String())
+	// the migration is started again if the key is already migrated to the new key
+	_, isNewKeyValid := validAttr[newKey]
+	if isNewKeyValid && migratedAttr[key] != newKey {
+		ErrorAndExit(fmt.Sprintf("Search attribute key [%s] is already whitelisted.", newKey), nil)
+	}
+
+	// ask user for confirmation
+	promptMsg := fmt.Sprintf("Are you trying to migrate key [%s] to key [%s] with Type [%s]? Y/N",
+		color.YellowString(key), color.YellowString(newKey), color.YellowString(intValTypeToString(valType)))
+	promptFn(promptMsg)
+
+	if !isNewKeyValid {
+		adminClient := cFactory.ServerAdminClient(c)
+		ctx, cancel := newContext(c)
+		defer cancel()
+		err := adminClient.AddSearchAttribute(ctx, &types.AddSearchAttributeRequest{
+			SearchAttribute: map[string]types.IndexedValueType{
+				newKey: types.IndexedValueType(valType),
+			},
+			SecurityToken: c.String(FlagSecurityToken),
+		})
+		if err != nil {
+			ErrorAndExit("Add search attribute failed.", err)
+		}
+	}
+
+	// write the new key along with the old one, so that the records written during the migration are migrated too
+	if migratedAttr[key] != newKey {
+		migratedAttr[key] = newKey
+		updateDynamicConfigMapValue(c, dynamicconfig.MigratedSearchAttributes.String(), migratedAttr)
+	}
+
+	client := getCadenceClient(c)
+	for _, domain := range newDomainCLI(c, false).getAllDomains(c) {
+		domainName := domain.GetDomainInfo().GetName()
+		workflowID := fmt.Sprintf("%s-%s-%s", migrateSearchAttributeWorkflowIDPrefix, key, domainName)
+		input, err := json.Marshal(&visibilitybackfill.Params{
+			DomainName:            domainName,
+			Mode:                  visibilitybackfill.ModeMigrate,
+			TargetStore:           store,
+			RPS:                   c.Int(FlagRPS),
+			SearchAttributeKey:    key,
+			NewSearchAttributeKey: newKey,
+		})
+		if err != nil {
+			ErrorAndExit("Failed to serialize params for visibility backfill workflow", err)
+		}
+		ctx, cancel := newContext(c)
+		_, err = client.StartWorkflowExecution(ctx, &types.StartWorkflowExecutionRequest{
+			Domain:                              common.SystemLocalDomainName,
+			WorkflowID:                          workflowID,
+			RequestID:                           uuid.New(),
+			Identity:                            getCliIdentity(),
+			WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+			ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(migrateSearchAttributeTimeoutInSeconds),
+			TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(int32(defaultDecisionTimeoutInSeconds)),
+			// the dynamic config is polled by the hosts, the records are copied once they all write the new key
+			DelayStartSeconds: common.Int32Ptr(migrateSearchAttributeDelayInSeconds),
+			Input:             input,
+			TaskList: &types.TaskList{
+				Name: visibilitybackfill.TaskListName,
+			},
+			WorkflowType: &types.WorkflowType{
+				Name: visibilitybackfill.WorkflowTypeName,
+			},
+		})
+		cancel()
+		if _, ok := err.(*types.WorkflowExecutionAlreadyStartedError); ok {
+			fmt.Printf("Migration of domain %s is already running, wid: %s\n", domainName, workflowID)
+			continue
+		}
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to start the migration of domain %s.", domainName), err)
+		}
+		fmt.Printf("Migration of domain %s started, wid: %s\n", domainName, workflowID)
+	}
+
+	deprecateSearchAttribute(c, key, fmt.Sprintf("migrated to %s", newKey))
+	fmt.Printf("Success. The progress of a domain can be queried with the %s query type of its workflow in the %s domain. "+
+		"Workflows should now write [%s] instead of [%s], which can be removed once the migrations are completed.\n",
+		visibilitybackfill.QueryType, common.SystemLocalDomainName, newKey, key)
+}
+
+const (
+	migrateSearchAttributeWorkflowIDPrefix = "cadence-sys-migrate-search-attribute"
+	migrateSearchAttributeTimeoutInSeconds = 365 * 24 * 60 * 60
+	migrateSearchAttributeDelayInSeconds   = 60
+)
+
+func deprecateSearchAttribute(c *cli.Context, key string, reason string) {
+	deprecatedAttr := getDynamicConfigMapValue(c, dynamicconfig.DeprecatedSearchAttributes.String())
+	deprecatedAttr[key] = reason
+	updateDynamicConfigMapValue(c, dynamicconfig.DeprecatedSearchAttributes.String(), deprecatedAttr)
+}
+
 // AdminDescribeCluster is used to dump information about the cluster
 func AdminDescribeCluster(c *cli.Context) {
 	adminClient := cFactory.ServerAdminClient(c)
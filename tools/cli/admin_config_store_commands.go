@@ -243,6 +243,123 @@ func AdminListConfigKeys(c *cli.Context) {
 	)
 }
 
+// listDynamicConfigValues returns all values of the dynamic config parameter
+func listDynamicConfigValues(c *cli.Context, dcName string) []*types.DynamicConfigValue {
+	adminClient := cFactory.ServerAdminClient(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	response, err := adminClient.ListDynamicConfig(ctx, &types.ListDynamicConfigRequest{
+		ConfigName: dcName,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to list dynamic config value(s)", err)
+	}
+
+	var values []*types.DynamicConfigValue
+	if response != nil {
+		for _, entry := range response.Entries {
+			values = append(values, entry.Values...)
+		}
+	}
+	return values
+}
+
+// updateDynamicConfigValues replaces all values of the dynamic config parameter,
+// it's used to update a single value since the dynamic config store only supports updating all of them
+func updateDynamicConfigValues(c *cli.Context, dcName string, values []*types.DynamicConfigValue) {
+	adminClient := cFactory.ServerAdminClient(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	err := adminClient.UpdateDynamicConfig(ctx, &types.UpdateDynamicConfigRequest{
+		ConfigName:   dcName,
+		ConfigValues: values,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to update dynamic config value", err)
+	}
+}
+
+// getDynamicConfigMapValue returns the current value of a map dynamic config parameter without filters
+func getDynamicConfigMapValue(c *cli.Context, dcName string) map[string]interface{} {
+	adminClient := cFactory.ServerAdminClient(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	response, err := adminClient.GetDynamicConfig(ctx, &types.GetDynamicConfigRequest{
+		ConfigName: dcName,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to get dynamic config value", err)
+	}
+
+	value := map[string]interface{}{}
+	if response != nil && response.Value != nil && len(response.Value.Data) > 0 {
+		if err := json.Unmarshal(response.Value.Data, &value); err != nil {
+			ErrorAndExit("Failed to unmarshal dynamic config value", err)
+		}
+		if value == nil {
+			value = map[string]interface{}{}
+		}
+	}
+	return value
+}
+
+// updateDynamicConfigMapValue replaces the value of a map dynamic config parameter without filters
+func updateDynamicConfigMapValue(c *cli.Context, dcName string, value map[string]interface{}) {
+	dcValue, err := convertFromInputValue(&cliValue{Value: value})
+	if err != nil {
+		ErrorAndExit("Failed to convert dynamic config value", err)
+	}
+	updateDynamicConfigValues(c, dcName, []*types.DynamicConfigValue{dcValue})
+}
+
+// newDomainFilterValue creates a dynamic config value filtered by the domain and the given filter
+func newDomainFilterValue(value interface{}, domain string, filter dynamicconfig.Filter, filterValue string) (*types.DynamicConfigValue, error) {
+	return convertFromInputValue(&cliValue{
+		Value: value,
+		Filters: []*cliFilter{
+			{Name: dynamicconfig.DomainName.String(), Value: domain},
+			{Name: filter.String(), Value: filterValue},
+		},
+	})
+}
+
+// getDomainFilterValue returns the value of the given filter if the dynamic config value is filtered by exactly the domain and the filter
+func getDomainFilterValue(value *types.DynamicConfigValue, domain string, filter dynamicconfig.Filter) (string, bool) {
+	if len(value.Filters) != 2 {
+		return "", false
+	}
+	inputValue, err := convertToInputValue(value)
+	if err != nil {
+		return "", false
+	}
+	var valueDomain, filterValue string
+	for _, inputFilter := range inputValue.Filters {
+		inputFilterValue, _ := inputFilter.Value.(string)
+		switch dynamicconfig.ParseFilter(inputFilter.Name) {
+		case dynamicconfig.DomainName:
+			valueDomain = inputFilterValue
+		case filter:
+			filterValue = inputFilterValue
+		}
+	}
+	return filterValue, filterValue != "" && valueDomain == domain
+}
+
+// removeDomainFilterValue removes the dynamic config value filtered by exactly the domain and the filter value
+func removeDomainFilterValue(values []*types.DynamicConfigValue, domain string, filter dynamicconfig.Filter, filterValue string) []*types.DynamicConfigValue {
+	newValues := make([]*types.DynamicConfigValue, 0, len(values))
+	for _, value := range values {
+		if valueFilterValue, ok := getDomainFilterValue(value, domain, filter); ok && valueFilterValue == filterValue {
+			continue
+		}
+		newValues = append(newValues, value)
+	}
+	return newValues
+}
+
 func convertToInputEntry(dcEntry *types.DynamicConfigEntry) (*cliEntry, error) {
 	newValues := make([]*cliValue, 0, len(dcEntry.Values))
 	for _, value := range dcEntry.Values {
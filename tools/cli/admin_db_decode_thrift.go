@@ -38,7 +38,9 @@ import (
 	"github.com/uber/cadence/.gen/go/replicator"
 	"github.com/uber/cadence/.gen/go/shared"
 	"github.com/uber/cadence/.gen/go/sqlblobs"
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/persistence"
 )
 
 var decodingTypes = map[string]func() codec.ThriftObject{
@@ -82,6 +84,10 @@ func AdminDBDataDecodeThrift(c *cli.Context) {
 	if err != nil {
 		ErrorAndExit("failed to decode input", err)
 	}
+	data, err = decompressPayload(data, c.String(FlagDataEncoding))
+	if err != nil {
+		ErrorAndExit("failed to decompress input", err)
+	}
 
 	if _, err := decodeThriftPayload(data); err != nil {
 		ErrorAndExit(err.shortMsg, err.err)
@@ -132,6 +138,22 @@ func decodeThriftPayload(data []byte) (codec.ThriftObject, *decodeError) {
 	}
 }
 
+// decompressPayload returns the uncompressed ThriftRW payload of data stored with a compressed data encoding
+func decompressPayload(data []byte, dataEncoding string) ([]byte, error) {
+	switch common.EncodingType(dataEncoding) {
+	case common.EncodingTypeEmpty, common.EncodingTypeThriftRW:
+		return data, nil
+	case common.EncodingTypeThriftRWSnappy, common.EncodingTypeThriftRWZstd:
+		blob, err := persistence.DecompressDataBlob(persistence.NewDataBlob(data, common.EncodingType(dataEncoding)))
+		if err != nil {
+			return nil, err
+		}
+		return blob.GetData(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data encoding: %s", dataEncoding)
+}
+
 func decodeUserInput(input, encoding string) ([]byte, error) {
 	switch encoding {
 	case "", "hex":
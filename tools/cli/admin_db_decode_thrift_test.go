@@ -32,6 +32,8 @@ import (
 	"github.com/uber/cadence/.gen/go/sqlblobs"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
 )
 
 func TestThriftDecodeHelper(t *testing.T) {
@@ -133,6 +135,39 @@ func TestThriftDecodeHelper(t *testing.T) {
 	}
 }
 
+func TestDecompressPayload(t *testing.T) {
+	serializer := persistence.NewPayloadSerializer()
+	events := []*types.HistoryEvent{{ID: 1, Version: 1, EventType: types.EventTypeWorkflowExecutionStarted.Ptr()}}
+	thriftBlob, err := serializer.SerializeBatchEvents(events, common.EncodingTypeThriftRW)
+	if err != nil {
+		t.Fatalf("Failed to serialize events, err: %v", err)
+	}
+
+	for _, encoding := range []common.EncodingType{common.EncodingTypeThriftRW, common.EncodingTypeThriftRWSnappy, common.EncodingTypeThriftRWZstd} {
+		blob, err := serializer.SerializeBatchEvents(events, encoding)
+		if err != nil {
+			t.Fatalf("Failed to serialize events, err: %v", err)
+		}
+		data, err := decompressPayload(blob.Data, string(encoding))
+		if err != nil {
+			t.Fatalf("decompressPayload() error: %v", err)
+		}
+		if diff := cmp.Diff(thriftBlob.Data, data); diff != "" {
+			t.Fatalf("Payload mismatch for %v (-want +got):\n%s", encoding, diff)
+		}
+		if _, decodeErr := decodeThriftPayload(data); decodeErr != nil {
+			t.Fatalf("decodeThriftPayload() error: %v", decodeErr.shortMsg)
+		}
+	}
+
+	if _, err := decompressPayload(thriftBlob.Data, "thriftrw+snappy"); err == nil {
+		t.Fatalf("decompressPayload() expected error for uncompressed data")
+	}
+	if _, err := decompressPayload(thriftBlob.Data, "gzip"); err == nil {
+		t.Fatalf("decompressPayload() expected error for unknown data encoding")
+	}
+}
+
 func mustThriftEncode(t *testing.T, obj codec.ThriftObject) []byte {
 	t.Helper()
 	data, err := codec.NewThriftRWEncoder().Encode(obj)
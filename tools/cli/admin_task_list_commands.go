@@ -21,12 +21,15 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/urfave/cli"
 
+	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -43,6 +46,10 @@ type (
 		StartID   int64 `header:"Lease Start TaskID"`
 		EndID     int64 `header:"Lease End TaskID"`
 	}
+	ActivityTypeRateLimitRow struct {
+		ActivityType string `header:"Activity Type"`
+		RPS          int    `header:"RPS"`
+	}
 )
 
 // AdminDescribeTaskList displays poller and status information of task list.
@@ -75,6 +82,7 @@ func AdminDescribeTaskList(c *cli.Context) {
 	}
 	printTaskListStatus(taskListStatus)
 	fmt.Printf("\n")
+	printNumTaskListPartitions(ctx, frontendClient, domain, taskList, taskListType)
 
 	pollers := response.Pollers
 	if len(pollers) == 0 {
@@ -120,3 +128,60 @@ func printTaskListStatus(taskListStatus *types.TaskListStatus) {
 	}}
 	RenderTable(os.Stdout, table, RenderOptions{Color: true})
 }
+
+// AdminListActivityTypeRateLimits displays dispatch rate limits of activity types under a domain.
+func AdminListActivityTypeRateLimits(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+
+	table := []ActivityTypeRateLimitRow{}
+	for _, value := range listDynamicConfigValues(c, dynamicconfig.MatchingActivityTypeDispatchRPS.String()) {
+		activityType, ok := getDomainFilterValue(value, domain, dynamicconfig.ActivityType)
+		if !ok {
+			continue
+		}
+		var rps int
+		if err := json.Unmarshal(value.Value.GetData(), &rps); err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to parse rate limit of activity type %v", activityType), err)
+		}
+		table = append(table, ActivityTypeRateLimitRow{ActivityType: activityType, RPS: rps})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].ActivityType < table[j].ActivityType
+	})
+	RenderTable(os.Stdout, table, RenderOptions{Color: true, Border: true})
+}
+
+// AdminUpdateActivityTypeRateLimit sets dispatch rate limit of an activity type under a domain.
+func AdminUpdateActivityTypeRateLimit(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	activityType := getRequiredOption(c, FlagActivityType)
+	rps := getRequiredIntOption(c, FlagRPS)
+	if rps <= 0 {
+		ErrorAndExit("Option rps must be positive, use remove to remove the rate limit.", nil)
+	}
+
+	newValue, err := newDomainFilterValue(rps, domain, dynamicconfig.ActivityType, activityType)
+	if err != nil {
+		ErrorAndExit("Failed to create rate limit value", err)
+	}
+	configName := dynamicconfig.MatchingActivityTypeDispatchRPS.String()
+	values := removeDomainFilterValue(listDynamicConfigValues(c, configName), domain, dynamicconfig.ActivityType, activityType)
+	updateDynamicConfigValues(c, configName, append(values, newValue))
+	fmt.Printf("Rate limit of activity type %q under domain %q updated to %d\n", activityType, domain, rps)
+	fmt.Printf("The rate limit only applies if %s is enabled for the domain\n", dynamicconfig.HistoryEnableActivityPartitionConfig.String())
+}
+
+// AdminRemoveActivityTypeRateLimit removes dispatch rate limit of an activity type under a domain.
+func AdminRemoveActivityTypeRateLimit(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	activityType := getRequiredOption(c, FlagActivityType)
+
+	configName := dynamicconfig.MatchingActivityTypeDispatchRPS.String()
+	values := listDynamicConfigValues(c, configName)
+	newValues := removeDomainFilterValue(values, domain, dynamicconfig.ActivityType, activityType)
+	if len(newValues) == len(values) {
+		ErrorAndExit(fmt.Sprintf("No rate limit found for activity type %q under domain %q", activityType, domain), nil)
+	}
+	updateDynamicConfigValues(c, configName, newValues)
+	fmt.Printf("Rate limit of activity type %q under domain %q removed\n", activityType, domain)
+}
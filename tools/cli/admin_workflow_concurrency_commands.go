@@ -0,0 +1,181 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	WorkflowConcurrencyRow struct {
+		WorkflowType   string `header:"Workflow Type"`
+		Limit          int    `header:"Concurrency Limit"`
+		OpenExecutions int64  `header:"Open Executions"`
+	}
+)
+
+// AdminListWorkflowConcurrencyLimits displays concurrency limits of workflow types under a domain with their open executions.
+func AdminListWorkflowConcurrencyLimits(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+
+	table := []WorkflowConcurrencyRow{}
+	for workflowType, limit := range getWorkflowConcurrencyLimits(c, domain) {
+		table = append(table, WorkflowConcurrencyRow{
+			WorkflowType:   workflowType,
+			Limit:          limit,
+			OpenExecutions: countOpenWorkflowExecutions(c, domain, workflowType, limit),
+		})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].WorkflowType < table[j].WorkflowType
+	})
+	RenderTable(os.Stdout, table, RenderOptions{Color: true, Border: true})
+}
+
+// AdminDescribeWorkflowConcurrency displays concurrency limit and open executions of a workflow type under a domain.
+func AdminDescribeWorkflowConcurrency(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	workflowType := getRequiredOption(c, FlagWorkflowType)
+
+	limit := getWorkflowConcurrencyLimits(c, domain)[workflowType]
+	table := []WorkflowConcurrencyRow{{
+		WorkflowType:   workflowType,
+		Limit:          limit,
+		OpenExecutions: countOpenWorkflowExecutions(c, domain, workflowType, limit),
+	}}
+	RenderTable(os.Stdout, table, RenderOptions{Color: true, Border: true})
+}
+
+// AdminUpdateWorkflowConcurrencyLimit sets concurrency limit of a workflow type under a domain.
+func AdminUpdateWorkflowConcurrencyLimit(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	workflowType := getRequiredOption(c, FlagWorkflowType)
+	limit := getRequiredIntOption(c, FlagConcurrencyLimit)
+	if limit <= 0 {
+		ErrorAndExit("Option concurrency_limit must be positive, use remove to remove the limit.", nil)
+	}
+
+	newValue, err := newDomainFilterValue(limit, domain, dynamicconfig.WorkflowType, workflowType)
+	if err != nil {
+		ErrorAndExit("Failed to create concurrency limit value", err)
+	}
+	configName := dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String()
+	values := removeDomainFilterValue(listDynamicConfigValues(c, configName), domain, dynamicconfig.WorkflowType, workflowType)
+	updateDynamicConfigValues(c, configName, append(values, newValue))
+	fmt.Printf("Concurrency limit of workflow type %q under domain %q updated to %d\n", workflowType, domain, limit)
+}
+
+// AdminRemoveWorkflowConcurrencyLimit removes concurrency limit of a workflow type under a domain.
+func AdminRemoveWorkflowConcurrencyLimit(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	workflowType := getRequiredOption(c, FlagWorkflowType)
+
+	configName := dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String()
+	values := listDynamicConfigValues(c, configName)
+	newValues := removeDomainFilterValue(values, domain, dynamicconfig.WorkflowType, workflowType)
+	if len(newValues) == len(values) {
+		ErrorAndExit(fmt.Sprintf("No concurrency limit found for workflow type %q under domain %q", workflowType, domain), nil)
+	}
+	updateDynamicConfigValues(c, configName, newValues)
+	fmt.Printf("Concurrency limit of workflow type %q under domain %q removed\n", workflowType, domain)
+}
+
+// getWorkflowConcurrencyLimits returns the concurrency limits set for workflow types under the domain
+func getWorkflowConcurrencyLimits(c *cli.Context, domain string) map[string]int {
+	limits := make(map[string]int)
+	for _, value := range listDynamicConfigValues(c, dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String()) {
+		workflowType, ok := getDomainFilterValue(value, domain, dynamicconfig.WorkflowType)
+		if !ok {
+			continue
+		}
+		var limit int
+		if err := json.Unmarshal(value.Value.GetData(), &limit); err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to parse concurrency limit of workflow type %v", workflowType), err)
+		}
+		limits[workflowType] = limit
+	}
+	return limits
+}
+
+// countOpenWorkflowExecutions returns the number of open executions of the workflow type, the same way as the
+// frontend when it enforces the concurrency limit: they're counted with a query, or listed by type up to the limit
+// if the visibility store doesn't support it. A limit of 0 lists all of them.
+func countOpenWorkflowExecutions(c *cli.Context, domain, workflowType string, limit int) int64 {
+	frontendClient := cFactory.ServerFrontendClient(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	response, err := frontendClient.CountWorkflowExecutions(ctx, &types.CountWorkflowExecutionsRequest{
+		Domain: domain,
+		Query:  fmt.Sprintf("WorkflowType = '%s' AND CloseTime = missing", strings.ReplaceAll(workflowType, "'", "\\'")),
+	})
+	if _, ok := err.(*types.BadRequestError); ok {
+		return listOpenWorkflowExecutionsUpTo(c, domain, workflowType, limit)
+	}
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Failed to count open executions of workflow type %v", workflowType), err)
+	}
+	return response.GetCount()
+}
+
+// listOpenWorkflowExecutionsUpTo returns the number of open executions of the workflow type, it stops listing
+// them once the limit is reached
+func listOpenWorkflowExecutionsUpTo(c *cli.Context, domain, workflowType string, limit int) int64 {
+	frontendClient := cFactory.ServerFrontendClient(c)
+
+	request := &types.ListOpenWorkflowExecutionsRequest{
+		Domain:          domain,
+		MaximumPageSize: int32(defaultPageSizeForList),
+		StartTimeFilter: &types.StartTimeFilter{
+			EarliestTime: common.Int64Ptr(0),
+			LatestTime:   common.Int64Ptr(time.Now().UnixNano()),
+		},
+		TypeFilter: &types.WorkflowTypeFilter{Name: workflowType},
+	}
+	var count int64
+	for limit <= 0 || count < int64(limit) {
+		ctx, cancel := newContext(c)
+		response, err := frontendClient.ListOpenWorkflowExecutions(ctx, request)
+		cancel()
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to list open executions of workflow type %v", workflowType), err)
+		}
+		count += int64(len(response.Executions))
+		if len(response.NextPageToken) == 0 {
+			break
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+	return count
+}
@@ -0,0 +1,84 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	defaultRefreshWaitTimeInSeconds = 10
+)
+
+// AdminPauseWorkflow pauses a workflow by the dynamic config, so that none of its decision and activity tasks is dispatched.
+// The signals and the completions of the tasks which are already started are still recorded.
+func AdminPauseWorkflow(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	wid := getRequiredOption(c, FlagWorkflowID)
+
+	newValue, err := newDomainFilterValue(true, domain, dynamicconfig.WorkflowID, wid)
+	if err != nil {
+		ErrorAndExit("Failed to create paused workflow value", err)
+	}
+	configName := dynamicconfig.HistoryWorkflowPaused.String()
+	values := removeDomainFilterValue(listDynamicConfigValues(c, configName), domain, dynamicconfig.WorkflowID, wid)
+	updateDynamicConfigValues(c, configName, append(values, newValue))
+	fmt.Printf("Workflow %q under domain %q paused\n", wid, domain)
+}
+
+// AdminResumeWorkflow resumes a paused workflow. The tasks of the workflow which are dropped while it's paused are
+// generated again by refreshing its tasks, after the dynamic config change reaches the history hosts.
+func AdminResumeWorkflow(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	wid := getRequiredOption(c, FlagWorkflowID)
+
+	configName := dynamicconfig.HistoryWorkflowPaused.String()
+	values := listDynamicConfigValues(c, configName)
+	newValues := removeDomainFilterValue(values, domain, dynamicconfig.WorkflowID, wid)
+	if len(newValues) == len(values) {
+		ErrorAndExit(fmt.Sprintf("Workflow %q under domain %q is not paused", wid, domain), nil)
+	}
+	updateDynamicConfigValues(c, configName, newValues)
+
+	time.Sleep(time.Duration(c.Int(FlagRefreshWaitTime)) * time.Second)
+
+	adminClient := cFactory.ServerAdminClient(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+	err := adminClient.RefreshWorkflowTasks(ctx, &types.RefreshWorkflowTasksRequest{
+		Domain: domain,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: wid,
+		},
+	})
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Workflow %q under domain %q resumed, but failed to refresh its tasks, retry with: cadence admin workflow refresh-tasks", wid, domain), err)
+	}
+	fmt.Printf("Workflow %q under domain %q resumed\n", wid, domain)
+}
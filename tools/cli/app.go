@@ -201,6 +201,12 @@ func NewCliApp() *cli.App {
 			Usage:       "Operate cadence cluster",
 			Subcommands: newClusterCommands(),
 		},
+		{
+			Name:        "schedule",
+			Aliases:     []string{"sch"},
+			Usage:       "Operate cron schedules, which start workflows periodically",
+			Subcommands: newScheduleCommands(),
+		},
 	}
 	app.CommandNotFound = func(context *cli.Context, command string) {
 		printMessage("command not found: " + command)
@@ -21,6 +21,8 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -31,12 +33,19 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/suite"
 	"github.com/urfave/cli"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
 
 	"github.com/uber/cadence/client/admin"
 	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/scheduler"
+	"github.com/uber/cadence/service/worker/visibilitybackfill"
 )
 
 type cliAppSuite struct {
@@ -466,6 +475,82 @@ func (s *cliAppSuite) TestQueryWorkflow_Failed() {
 	s.Equal(1, errorCode)
 }
 
+func (s *cliAppSuite) TestCreateSchedule() {
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(common.SchedulerLocalDomainName, request.Domain)
+			s.Equal(scheduler.GetWorkflowID(domainName, "sid"), request.WorkflowID)
+			var params scheduler.ScheduleParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal("*/5 * * * *", params.Spec.CronSchedule)
+			s.Equal(scheduler.OverlapPolicySkip, params.Spec.OverlapPolicy)
+			return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+		})
+	err := s.app.Run([]string{"", "--do", domainName, "schedule", "create", "-sid", "sid", "--cron", "*/5 * * * *", "-tl", "testTaskList", "-wt", "testWorkflowType", "-et", "60"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestCreateSchedule_AlreadyExists() {
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil, &types.WorkflowExecutionAlreadyStartedError{})
+	errorCode := s.RunErrorExitCode([]string{"", "--do", domainName, "schedule", "create", "-sid", "sid", "--cron", "*/5 * * * *", "-tl", "testTaskList", "-wt", "testWorkflowType", "-et", "60"})
+	s.Equal(1, errorCode)
+}
+
+func (s *cliAppSuite) TestUpdateSchedule() {
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(s.describeScheduleResponse(), nil)
+	s.serverFrontendClient.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+			s.Equal(scheduler.UpdateSignal, request.SignalName)
+			var spec scheduler.ScheduleSpec
+			s.NoError(json.Unmarshal(request.Input, &spec))
+			s.Equal("0 * * * *", spec.CronSchedule)
+			s.Equal("testWorkflowType", spec.Action.WorkflowType)
+			return nil
+		})
+	err := s.app.Run([]string{"", "--do", domainName, "schedule", "update", "-sid", "sid", "--cron", "0 * * * *"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestPauseSchedule() {
+	s.serverFrontendClient.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "schedule", "pause", "-sid", "sid", "--reason", "test"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestBackfillSchedule() {
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(s.describeScheduleResponse(), nil)
+	s.serverFrontendClient.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "schedule", "backfill", "-sid", "sid", "--earliest_time", "2h", "--latest_time", "1h"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestBackfillSchedule_TooManyRuns() {
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(s.describeScheduleResponse(), nil)
+	// osExit is mocked, so the command continues after the error
+	s.serverFrontendClient.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	errorCode := s.RunErrorExitCode([]string{"", "--do", domainName, "schedule", "backfill", "-sid", "sid", "--earliest_time", "1d"})
+	s.Equal(1, errorCode)
+}
+
+func (s *cliAppSuite) describeScheduleResponse() *types.QueryWorkflowResponse {
+	result, err := json.Marshal(scheduler.DescribeResult{
+		DomainName: domainName,
+		ScheduleID: "sid",
+		Spec: scheduler.ScheduleSpec{
+			CronSchedule:  "*/5 * * * *",
+			OverlapPolicy: scheduler.OverlapPolicySkip,
+			Action: scheduler.StartWorkflowAction{
+				WorkflowType:                        "testWorkflowType",
+				TaskList:                            "testTaskList",
+				ExecutionStartToCloseTimeoutSeconds: 60,
+				TaskStartToCloseTimeoutSeconds:      10,
+			},
+		},
+	})
+	s.NoError(err)
+	return &types.QueryWorkflowResponse{QueryResult: result}
+}
+
 var (
 	closeStatus = types.WorkflowExecutionCloseStatusCompleted
 
@@ -595,6 +680,37 @@ func (s *cliAppSuite) TestCountWorkflow() {
 	s.Nil(err)
 }
 
+func (s *cliAppSuite) TestCountWorkflow_GroupBy() {
+	s.serverFrontendClient.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*types.CountWorkflowExecutionsResponse, error) {
+			s.Equal("WorkflowType = 'wt'", request.Query)
+			callOpts := make([]encoding.CallOption, 0, len(opts))
+			for _, opt := range opts {
+				callOpts = append(callOpts, encoding.CallOption(opt))
+			}
+			call := encoding.NewOutboundCall(callOpts...)
+			transportRequest := &transport.Request{}
+			_, err := call.WriteToRequest(ctx, transportRequest)
+			s.NoError(err)
+			groupBy, _ := transportRequest.Headers.Get(common.CountGroupByHeaderName)
+			s.Equal("CloseStatus", groupBy)
+			_, err = call.ReadFromResponse(ctx, &transport.Response{
+				Headers: transport.NewHeaders().With(common.CountGroupsHeaderName, `[{"value":"","count":2},{"value":"COMPLETED","count":1}]`),
+			})
+			s.NoError(err)
+			return &types.CountWorkflowExecutionsResponse{Count: 3}, nil
+		})
+	err := s.app.Run([]string{"", "--do", domainName, "workflow", "count", "-q", "WorkflowType = 'wt'", "--group-by", "CloseStatus"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestCountWorkflow_GroupBy_Unsupported() {
+	// servers without grouped counts ignore the request header and don't return the groups
+	s.serverFrontendClient.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(&types.CountWorkflowExecutionsResponse{Count: 3}, nil)
+	errorCode := s.RunErrorExitCode([]string{"", "--do", domainName, "workflow", "count", "-q", "WorkflowType = 'wt'", "--group-by", "CloseStatus"})
+	s.Equal(1, errorCode)
+}
+
 var describeTaskListResponse = &types.DescribeTaskListResponse{
 	Pollers: []*types.PollerInfo{
 		{
@@ -604,6 +720,16 @@ var describeTaskListResponse = &types.DescribeTaskListResponse{
 	},
 }
 
+var listTaskListPartitionsResponse = &types.ListTaskListPartitionsResponse{
+	DecisionTaskListPartitions: []*types.TaskListPartitionMetadata{
+		{Key: "test-taskList", OwnerHostName: "host"},
+		{Key: "/__cadence_sys/test-taskList/1", OwnerHostName: "host"},
+	},
+	ActivityTaskListPartitions: []*types.TaskListPartitionMetadata{
+		{Key: "test-taskList", OwnerHostName: "host"},
+	},
+}
+
 func (s *cliAppSuite) TestAdminDescribeWorkflow() {
 	resp := &types.AdminDescribeWorkflowExecutionResponse{
 		ShardID:                "test-shard-id",
@@ -639,6 +765,134 @@ func (s *cliAppSuite) TestAdminAddSearchAttribute() {
 	s.Nil(err)
 }
 
+func newDynamicConfigMapResponse(value map[string]interface{}) *types.GetDynamicConfigResponse {
+	data, _ := json.Marshal(value)
+	return &types.GetDynamicConfigResponse{
+		Value: &types.DataBlob{EncodingType: types.EncodingTypeJSON.Ptr(), Data: data},
+	}
+}
+
+func newDynamicConfigMapValues(value map[string]interface{}) []*types.DynamicConfigValue {
+	dcValue, _ := convertFromInputValue(&cliValue{Value: value})
+	return []*types.DynamicConfigValue{dcValue}
+}
+
+func (s *cliAppSuite) TestAdminDeprecateSearchAttribute() {
+	promptFn = func(string) {}
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.ValidSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"testKey": 1}), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.DeprecatedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"otherKey": "unused"}), nil)
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName:   dynamicconfig.DeprecatedSearchAttributes.String(),
+		ConfigValues: newDynamicConfigMapValues(map[string]interface{}{"otherKey": "unused", "testKey": "replaced"}),
+	}).Return(nil)
+
+	err := s.app.Run([]string{"", "admin", "cl", "dsa", "--search_attr_key", "testKey", "--reason", "replaced"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminRemoveSearchAttribute() {
+	var promptMsg string
+	promptFn = func(msg string) {
+		promptMsg = msg
+	}
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.ValidSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"testKey": 1, "otherKey": 2}), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.RemovedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(nil), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.DeprecatedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"testKey": "replaced"}), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.MigratedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"testKey": "newKey"}), nil)
+	gomock.InOrder(
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.RemovedSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{"testKey": "unused"}),
+		}).Return(nil),
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.ValidSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{"otherKey": 2}),
+		}).Return(nil),
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.DeprecatedSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{}),
+		}).Return(nil),
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.MigratedSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{}),
+		}).Return(nil),
+	)
+
+	err := s.app.Run([]string{"", "admin", "cl", "rsa", "--search_attr_key", "testKey", "--reason", "unused"})
+	s.Equal("Are you trying to remove key [testKey]? Writes to the key will be rejected. Y/N", promptMsg)
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminMigrateSearchAttribute() {
+	var promptMsg string
+	promptFn = func(msg string) {
+		promptMsg = msg
+	}
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.ValidSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(map[string]interface{}{"testKey": 1}), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.MigratedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(nil), nil)
+	s.serverAdminClient.EXPECT().GetDynamicConfig(gomock.Any(), &types.GetDynamicConfigRequest{
+		ConfigName: dynamicconfig.DeprecatedSearchAttributes.String(),
+	}).Return(newDynamicConfigMapResponse(nil), nil)
+	s.serverFrontendClient.EXPECT().ListDomains(gomock.Any(), gomock.Any()).Return(&types.ListDomainsResponse{
+		Domains: []*types.DescribeDomainResponse{
+			{DomainInfo: &types.DomainInfo{Name: "domain-1"}},
+			{DomainInfo: &types.DomainInfo{Name: "domain-2"}},
+		},
+	}, nil)
+	gomock.InOrder(
+		s.serverAdminClient.EXPECT().AddSearchAttribute(gomock.Any(), &types.AddSearchAttributeRequest{
+			SearchAttribute: map[string]types.IndexedValueType{"newKey": types.IndexedValueTypeInt},
+		}).Return(nil),
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.MigratedSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{"testKey": "newKey"}),
+		}).Return(nil),
+		s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+				s.Equal(common.SystemLocalDomainName, request.GetDomain())
+				s.Equal(visibilitybackfill.WorkflowTypeName, request.WorkflowType.GetName())
+				var params visibilitybackfill.Params
+				s.NoError(json.Unmarshal(request.Input, &params))
+				s.Equal(visibilitybackfill.Params{
+					DomainName:            "domain-1",
+					Mode:                  visibilitybackfill.ModeMigrate,
+					TargetStore:           persistence.VisibilityStorePinot,
+					SearchAttributeKey:    "testKey",
+					NewSearchAttributeKey: "newKey",
+				}, params)
+				return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+			}),
+		// the migration of a domain is resumed by the running workflow
+		s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, &types.WorkflowExecutionAlreadyStartedError{}),
+		s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+			ConfigName:   dynamicconfig.DeprecatedSearchAttributes.String(),
+			ConfigValues: newDynamicConfigMapValues(map[string]interface{}{"testKey": "migrated to newKey"}),
+		}).Return(nil),
+	)
+
+	err := s.app.Run([]string{"", "admin", "cl", "msa", "--search_attr_key", "testKey", "--search_attr_new_key", "newKey",
+		"--search_attr_type", "2", "--visibility_store", "pinot"})
+	s.Equal("Are you trying to migrate key [testKey] to key [newKey] with Type [Int]? Y/N", promptMsg)
+	s.Nil(err)
+}
+
 func (s *cliAppSuite) TestAdminFailover() {
 	resp := &types.StartWorkflowExecutionResponse{RunID: uuid.New()}
 	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(resp, nil)
@@ -650,6 +904,7 @@ func (s *cliAppSuite) TestAdminFailover() {
 func (s *cliAppSuite) TestDescribeTaskList() {
 	resp := describeTaskListResponse
 	s.serverFrontendClient.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any()).Return(resp, nil)
+	s.serverFrontendClient.EXPECT().ListTaskListPartitions(gomock.Any(), gomock.Any()).Return(listTaskListPartitionsResponse, nil)
 	err := s.app.Run([]string{"", "--do", domainName, "tasklist", "describe", "-tl", "test-taskList"})
 	s.Nil(err)
 }
@@ -657,10 +912,210 @@ func (s *cliAppSuite) TestDescribeTaskList() {
 func (s *cliAppSuite) TestDescribeTaskList_Activity() {
 	resp := describeTaskListResponse
 	s.serverFrontendClient.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any()).Return(resp, nil)
+	s.serverFrontendClient.EXPECT().ListTaskListPartitions(gomock.Any(), gomock.Any()).Return(listTaskListPartitionsResponse, nil)
 	err := s.app.Run([]string{"", "--do", domainName, "tasklist", "describe", "-tl", "test-taskList", "-tlt", "activity"})
 	s.Nil(err)
 }
 
+func (s *cliAppSuite) TestDescribeTaskList_ListPartitionsFailed() {
+	resp := describeTaskListResponse
+	s.serverFrontendClient.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any()).Return(resp, nil)
+	s.serverFrontendClient.EXPECT().ListTaskListPartitions(gomock.Any(), gomock.Any()).Return(nil, &types.BadRequestError{"faked error"})
+	err := s.app.Run([]string{"", "--do", domainName, "tasklist", "describe", "-tl", "test-taskList"})
+	s.Nil(err)
+}
+
+func newDomainFilteredValue(v interface{}, filters ...*cliFilter) *types.DynamicConfigValue {
+	value, _ := convertFromInputValue(&cliValue{Value: v, Filters: filters})
+	return value
+}
+
+var listActivityTypeRateLimitsResponse = &types.ListDynamicConfigResponse{
+	Entries: []*types.DynamicConfigEntry{
+		{
+			Name: dynamicconfig.MatchingActivityTypeDispatchRPS.String(),
+			Values: []*types.DynamicConfigValue{
+				newDomainFilteredValue(5, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "activityType", Value: "foo"}),
+				newDomainFilteredValue(8, &cliFilter{Name: "domainName", Value: "other-domain"}, &cliFilter{Name: "activityType", Value: "foo"}),
+				newDomainFilteredValue(10, &cliFilter{Name: "domainName", Value: domainName}),
+			},
+		},
+	},
+}
+
+func (s *cliAppSuite) TestAdminListActivityTypeRateLimits() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), &types.ListDynamicConfigRequest{
+		ConfigName: dynamicconfig.MatchingActivityTypeDispatchRPS.String(),
+	}).Return(listActivityTypeRateLimitsResponse, nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "tasklist", "ratelimit", "list"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminUpdateActivityTypeRateLimit() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listActivityTypeRateLimitsResponse, nil).Times(2)
+
+	// the existing value of the activity type is replaced
+	existing := listActivityTypeRateLimitsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName: dynamicconfig.MatchingActivityTypeDispatchRPS.String(),
+		ConfigValues: []*types.DynamicConfigValue{
+			existing[1],
+			existing[2],
+			newDomainFilteredValue(20, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "activityType", Value: "foo"}),
+		},
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "tasklist", "ratelimit", "update", "--at", "foo", "--rps", "20"})
+	s.Nil(err)
+
+	// a new value is added for a new activity type
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName: dynamicconfig.MatchingActivityTypeDispatchRPS.String(),
+		ConfigValues: append(append([]*types.DynamicConfigValue{}, existing...),
+			newDomainFilteredValue(1, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "activityType", Value: "bar"}),
+		),
+	}).Return(nil)
+	err = s.app.Run([]string{"", "--do", domainName, "admin", "tasklist", "ratelimit", "update", "--at", "bar", "--rps", "1"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminRemoveActivityTypeRateLimit() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listActivityTypeRateLimitsResponse, nil)
+
+	existing := listActivityTypeRateLimitsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName:   dynamicconfig.MatchingActivityTypeDispatchRPS.String(),
+		ConfigValues: []*types.DynamicConfigValue{existing[1], existing[2]},
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "tasklist", "ratelimit", "remove", "--at", "foo"})
+	s.Nil(err)
+}
+
+var listPausedWorkflowsResponse = &types.ListDynamicConfigResponse{
+	Entries: []*types.DynamicConfigEntry{
+		{
+			Name: dynamicconfig.HistoryWorkflowPaused.String(),
+			Values: []*types.DynamicConfigValue{
+				newDomainFilteredValue(true, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "workflowID", Value: "wid"}),
+				newDomainFilteredValue(true, &cliFilter{Name: "domainName", Value: "other-domain"}, &cliFilter{Name: "workflowID", Value: "wid"}),
+			},
+		},
+	},
+}
+
+func (s *cliAppSuite) TestAdminPauseWorkflow() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listPausedWorkflowsResponse, nil)
+
+	existing := listPausedWorkflowsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName: dynamicconfig.HistoryWorkflowPaused.String(),
+		ConfigValues: append(append([]*types.DynamicConfigValue{}, existing...),
+			newDomainFilteredValue(true, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "workflowID", Value: "wid2"}),
+		),
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "workflow", "pause", "--wid", "wid2"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminResumeWorkflow() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listPausedWorkflowsResponse, nil)
+
+	existing := listPausedWorkflowsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName:   dynamicconfig.HistoryWorkflowPaused.String(),
+		ConfigValues: []*types.DynamicConfigValue{existing[1]},
+	}).Return(nil)
+	s.serverAdminClient.EXPECT().RefreshWorkflowTasks(gomock.Any(), &types.RefreshWorkflowTasksRequest{
+		Domain:    domainName,
+		Execution: &types.WorkflowExecution{WorkflowID: "wid"},
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "workflow", "resume", "--wid", "wid", "--rwts", "0"})
+	s.Nil(err)
+}
+
+var listWorkflowConcurrencyLimitsResponse = &types.ListDynamicConfigResponse{
+	Entries: []*types.DynamicConfigEntry{
+		{
+			Name: dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String(),
+			Values: []*types.DynamicConfigValue{
+				newDomainFilteredValue(5, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "workflowType", Value: "batch"}),
+				newDomainFilteredValue(8, &cliFilter{Name: "domainName", Value: "other-domain"}, &cliFilter{Name: "workflowType", Value: "batch"}),
+			},
+		},
+	},
+}
+
+func (s *cliAppSuite) TestAdminListWorkflowConcurrencyLimits() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), &types.ListDynamicConfigRequest{
+		ConfigName: dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String(),
+	}).Return(listWorkflowConcurrencyLimitsResponse, nil)
+	s.serverFrontendClient.EXPECT().CountWorkflowExecutions(gomock.Any(), &types.CountWorkflowExecutionsRequest{
+		Domain: domainName,
+		Query:  "WorkflowType = 'batch' AND CloseTime = missing",
+	}).Return(&types.CountWorkflowExecutionsResponse{Count: 3}, nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "concurrency", "list"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDescribeWorkflowConcurrency() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listWorkflowConcurrencyLimitsResponse, nil)
+	s.serverFrontendClient.EXPECT().CountWorkflowExecutions(gomock.Any(), &types.CountWorkflowExecutionsRequest{
+		Domain: domainName,
+		Query:  "WorkflowType = 'batch' AND CloseTime = missing",
+	}).Return(&types.CountWorkflowExecutionsResponse{Count: 3}, nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "concurrency", "describe", "--wt", "batch"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDescribeWorkflowConcurrency_BasicVisibility() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listWorkflowConcurrencyLimitsResponse, nil)
+	s.serverFrontendClient.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any()).Return(nil, &types.BadRequestError{Message: "Operation is not supported. Please use ElasticSearch"})
+	s.serverFrontendClient.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*types.ListOpenWorkflowExecutionsResponse, error) {
+			s.Equal("batch", request.TypeFilter.GetName())
+			s.Nil(request.NextPageToken)
+			return &types.ListOpenWorkflowExecutionsResponse{
+				Executions:    make([]*types.WorkflowExecutionInfo, 3),
+				NextPageToken: []byte("next"),
+			}, nil
+		})
+	s.serverFrontendClient.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*types.ListOpenWorkflowExecutionsResponse, error) {
+			s.Equal([]byte("next"), request.NextPageToken)
+			return &types.ListOpenWorkflowExecutionsResponse{
+				Executions:    make([]*types.WorkflowExecutionInfo, 3),
+				NextPageToken: []byte("more"),
+			}, nil
+		})
+	// the listing stops at the limit of 5
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "concurrency", "describe", "--wt", "batch"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminUpdateWorkflowConcurrencyLimit() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listWorkflowConcurrencyLimitsResponse, nil)
+	existing := listWorkflowConcurrencyLimitsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName: dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String(),
+		ConfigValues: []*types.DynamicConfigValue{
+			existing[1],
+			newDomainFilteredValue(10, &cliFilter{Name: "domainName", Value: domainName}, &cliFilter{Name: "workflowType", Value: "batch"}),
+		},
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "concurrency", "update", "--wt", "batch", "--concurrency_limit", "10"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminRemoveWorkflowConcurrencyLimit() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), gomock.Any()).Return(listWorkflowConcurrencyLimitsResponse, nil)
+	existing := listWorkflowConcurrencyLimitsResponse.Entries[0].Values
+	s.serverAdminClient.EXPECT().UpdateDynamicConfig(gomock.Any(), &types.UpdateDynamicConfigRequest{
+		ConfigName:   dynamicconfig.FrontendWorkflowTypeConcurrencyLimit.String(),
+		ConfigValues: []*types.DynamicConfigValue{existing[1]},
+	}).Return(nil)
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "concurrency", "remove", "--wt", "batch"})
+	s.Nil(err)
+}
+
 func (s *cliAppSuite) TestObserveWorkflow() {
 	history := getWorkflowExecutionHistoryResponse
 	s.serverFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(history, nil).Times(2)
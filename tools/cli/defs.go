@@ -66,8 +66,10 @@ const (
 	defaultPageSizeForScan          = 2000
 	defaultWorkflowIDReusePolicy    = types.WorkflowIDReusePolicyAllowDuplicateFailedOnly
 
-	workflowStatusNotSet = -1
-	showErrorStackEnv    = `CADENCE_CLI_SHOW_STACKS`
+	workflowStatusNotSet    = -1
+	workflowCountGroupOpen  = "OPEN"  // group of open workflows when counting by CloseStatus
+	workflowCountGroupOther = "OTHER" // group of the workflows beyond the largest groups of a count
+	showErrorStackEnv       = `CADENCE_CLI_SHOW_STACKS`
 
 	searchAttrInputSeparator = "|"
 
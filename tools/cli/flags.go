@@ -88,6 +88,7 @@ const (
 	FlagInputFileWithAlias                = FlagInputFile + ", if"
 	FlagInputEncoding                     = "encoding"
 	FlagInputEncodingWithAlias            = FlagInputEncoding + ", enc"
+	FlagDataEncoding                      = "data_encoding"
 	FlagSignalInput                       = "signal_input"
 	FlagSignalInputWithAlias              = FlagSignalInput + ", si"
 	FlagSignalInputFile                   = "signal_input_file"
@@ -188,6 +189,11 @@ const (
 	FlagEventIDWithAlias                  = FlagEventID + ", eid"
 	FlagActivityID                        = "activity_id"
 	FlagActivityIDWithAlias               = FlagActivityID + ", aid"
+	FlagActivityType                      = "activity_type"
+	FlagActivityTypeWithAlias             = FlagActivityType + ", at"
+	FlagConcurrencyLimit                  = "concurrency_limit"
+	FlagRefreshWaitTime                   = "refresh_wait_time_second"
+	FlagRefreshWaitTimeWithAlias          = FlagRefreshWaitTime + ", rwts"
 	FlagMaxFieldLength                    = "max_field_length"
 	FlagMaxFieldLengthWithAlias           = FlagMaxFieldLength + ", maxl"
 	FlagSecurityToken                     = "security_token"
@@ -210,6 +216,8 @@ const (
 	FlagSearchAttributesKey               = "search_attr_key"
 	FlagSearchAttributesVal               = "search_attr_value"
 	FlagSearchAttributesType              = "search_attr_type"
+	FlagSearchAttributesNewKey            = "search_attr_new_key"
+	FlagVisibilityStore                   = "visibility_store"
 	FlagAddBadBinary                      = "add_bad_binary"
 	FlagRemoveBadBinary                   = "remove_bad_binary"
 	FlagResetType                         = "reset_type"
@@ -219,6 +227,7 @@ const (
 	FlagSkipSignalReapply                 = "skip_signal_reapply"
 	FlagListQuery                         = "query"
 	FlagListQueryWithAlias                = FlagListQuery + ", q"
+	FlagGroupBy                           = "group-by"
 	FlagExcludeWorkflowIDByQuery          = "exclude_query"
 	FlagBatchType                         = "batch_type"
 	FlagBatchTypeWithAlias                = FlagBatchType + ", bt"
@@ -233,6 +242,10 @@ const (
 	FlagRPSScaleUpSeconds                 = "rps_scale_up_seconds"
 	FlagJobID                             = "job_id"
 	FlagJobIDWithAlias                    = FlagJobID + ", jid"
+	FlagScheduleID                        = "schedule_id"
+	FlagScheduleIDWithAlias               = FlagScheduleID + ", sid"
+	FlagOverlapPolicy                     = "overlap_policy"
+	FlagOverlapPolicyWithAlias            = FlagOverlapPolicy + ", op"
 	FlagYes                               = "yes"
 	FlagServiceConfigDir                  = "service_config_dir"
 	FlagServiceConfigDirWithAlias         = FlagServiceConfigDir + ", scd"
@@ -698,6 +711,10 @@ func getFlagsForCount() []cli.Flag {
 			Name:  FlagListQueryWithAlias,
 			Usage: "Optional SQL like query. e.g count all open workflows 'CloseTime = missing'; 'WorkflowType=\"wtype\" and CloseTime > 0'",
 		},
+		cli.StringFlag{
+			Name:  FlagGroupBy,
+			Usage: "Optional, break down the count by WorkflowType, CloseStatus or a keyword search attribute. Experimental, requires a server returning the cadence-count-groups header",
+		},
 	}
 }
 
@@ -0,0 +1,205 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/service/worker/scheduler"
+)
+
+func newScheduleCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "create",
+			Usage: "Create a schedule which starts a workflow every time its cron schedule is due",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  FlagCronSchedule,
+					Usage: "Cron schedule of the schedule in UTC, e.g. '*/10 * * * *'",
+				},
+			}, getFlagsForScheduleSpec()...),
+			Action: func(c *cli.Context) {
+				CreateSchedule(c)
+			},
+		},
+		{
+			Name:    "describe",
+			Aliases: []string{"desc"},
+			Usage:   "Describe a schedule",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagScheduleIDWithAlias,
+					Usage: "Schedule ID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				DescribeSchedule(c)
+			},
+		},
+		{
+			Name:  "update",
+			Usage: "Update a schedule, only the specified options are changed",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  FlagCronSchedule,
+					Usage: "Cron schedule of the schedule in UTC, e.g. '*/10 * * * *'",
+				},
+			}, getFlagsForScheduleSpec()...),
+			Action: func(c *cli.Context) {
+				UpdateSchedule(c)
+			},
+		},
+		{
+			Name:  "pause",
+			Usage: "Pause a schedule, the workflows which are due while it is paused are skipped",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagScheduleIDWithAlias,
+					Usage: "Schedule ID",
+				},
+				cli.StringFlag{
+					Name:  FlagReasonWithAlias,
+					Usage: "Reason to pause the schedule",
+				},
+			},
+			Action: func(c *cli.Context) {
+				PauseSchedule(c)
+			},
+		},
+		{
+			Name:  "unpause",
+			Usage: "Resume a paused schedule",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagScheduleIDWithAlias,
+					Usage: "Schedule ID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				UnpauseSchedule(c)
+			},
+		},
+		{
+			Name:  "delete",
+			Usage: "Delete a schedule, the workflows started by it are not affected",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagScheduleIDWithAlias,
+					Usage: "Schedule ID",
+				},
+				cli.StringFlag{
+					Name:  FlagReasonWithAlias,
+					Usage: "Reason to delete the schedule",
+				},
+			},
+			Action: func(c *cli.Context) {
+				DeleteSchedule(c)
+			},
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List the schedules of a domain",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  FlagPageSizeWithAlias,
+					Value: 30,
+					Usage: "Result page size",
+				},
+			},
+			Action: func(c *cli.Context) {
+				ListSchedules(c)
+			},
+		},
+		{
+			Name:  "backfill",
+			Usage: "Start the workflows which were due in a time range in the past",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagScheduleIDWithAlias,
+					Usage: "Schedule ID",
+				},
+				cli.StringFlag{
+					Name: FlagEarliestTimeWithAlias,
+					Usage: "Start of the time range, inclusive. Supported formats are '2006-01-02T15:04:05+07:00', raw UnixNano and " +
+						"time range (N<duration>), where 0 < N < 1000000 and duration (full-notation/short-notation) can be second/s, " +
+						"minute/m, hour/h, day/d, week/w, month/M or year/y. For example, '15minute' or '15m' implies last 15 minutes.",
+				},
+				cli.StringFlag{
+					Name:  FlagLatestTimeWithAlias,
+					Usage: "End of the time range, inclusive. Default to now. Supported formats are the same as " + FlagEarliestTime,
+				},
+				cli.StringFlag{
+					Name:  FlagOverlapPolicyWithAlias,
+					Value: scheduler.OverlapPolicyConcurrent,
+					Usage: "Overlap policy of the backfilled workflows. Supported: " + strings.Join(scheduler.AllOverlapPolicies, ","),
+				},
+			},
+			Action: func(c *cli.Context) {
+				BackfillSchedule(c)
+			},
+		},
+	}
+}
+
+func getFlagsForScheduleSpec() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  FlagScheduleIDWithAlias,
+			Usage: "Schedule ID",
+		},
+		cli.StringFlag{
+			Name:  FlagOverlapPolicyWithAlias,
+			Usage: "What to do when the previous workflow is still running, default to skip. Supported: " + strings.Join(scheduler.AllOverlapPolicies, ","),
+		},
+		cli.StringFlag{
+			Name:  FlagTaskListWithAlias,
+			Usage: "TaskList of the started workflows",
+		},
+		cli.StringFlag{
+			Name:  FlagWorkflowTypeWithAlias,
+			Usage: "WorkflowTypeName of the started workflows",
+		},
+		cli.IntFlag{
+			Name:  FlagExecutionTimeoutWithAlias,
+			Usage: "Execution start to close timeout of the started workflows in seconds",
+		},
+		cli.IntFlag{
+			Name:  FlagDecisionTimeoutWithAlias,
+			Value: defaultDecisionTimeoutInSeconds,
+			Usage: "Decision task start to close timeout of the started workflows in seconds",
+		},
+		cli.StringFlag{
+			Name:  FlagInputWithAlias,
+			Usage: "Optional input of the started workflows, in JSON format. If there are multiple parameters, concatenate them and separate by space.",
+		},
+		cli.StringFlag{
+			Name: FlagInputFileWithAlias,
+			Usage: "Optional input of the started workflows from JSON file. If there are multiple JSON, concatenate them and separate by space or newline. " +
+				"Input from file will be overwrite by input from command line",
+		},
+	}
+}
@@ -0,0 +1,338 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/scheduler"
+)
+
+// CreateSchedule creates a schedule
+func CreateSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	spec := scheduler.ScheduleSpec{
+		CronSchedule:  getRequiredOption(c, FlagCronSchedule),
+		OverlapPolicy: c.String(FlagOverlapPolicy),
+		Action: scheduler.StartWorkflowAction{
+			WorkflowType:                        getRequiredOption(c, FlagWorkflowType),
+			TaskList:                            getRequiredOption(c, FlagTaskList),
+			Input:                               []byte(processJSONInput(c)),
+			ExecutionStartToCloseTimeoutSeconds: int32(getRequiredIntOption(c, FlagExecutionTimeout)),
+			TaskStartToCloseTimeoutSeconds:      int32(c.Int(FlagDecisionTimeout)),
+		},
+	}
+	if _, err := scheduler.ValidateSpec(&spec); err != nil {
+		ErrorAndExit("Invalid schedule", err)
+	}
+
+	input, err := json.Marshal(scheduler.ScheduleParams{
+		DomainName: domain,
+		ScheduleID: scheduleID,
+		Spec:       spec,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to encode schedule parameters", err)
+	}
+	searchAttributes, err := serializeSearchAttributes(map[string]interface{}{
+		"CustomDomain": domain,
+		"Operator":     getCurrentUserFromEnv(),
+	})
+	if err != nil {
+		ErrorAndExit("Failed to encode schedule search attributes", err)
+	}
+
+	svcClient := cFactory.ServerFrontendClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+
+	_, err = svcClient.StartWorkflowExecution(tcCtx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SchedulerLocalDomainName,
+		RequestID:                           uuid.New(),
+		WorkflowID:                          scheduler.GetWorkflowID(domain, scheduleID),
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(int32(scheduler.InfiniteDuration.Seconds())),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(int32(defaultDecisionTimeoutInSeconds)),
+		TaskList:                            &types.TaskList{Name: scheduler.TaskListName},
+		SearchAttributes:                    searchAttributes,
+		WorkflowType:                        &types.WorkflowType{Name: scheduler.WorkflowTypeName},
+		Input:                               input,
+		Identity:                            getCliIdentity(),
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+	})
+	if _, ok := err.(*types.WorkflowExecutionAlreadyStartedError); ok {
+		ErrorAndExit(fmt.Sprintf("Schedule %v already exists", scheduleID), nil)
+	}
+	if err != nil {
+		ErrorAndExit("Failed to create schedule", err)
+	}
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg":        "schedule is created",
+		"scheduleID": scheduleID,
+	})
+}
+
+// DescribeSchedule describes the spec and the state of a schedule
+func DescribeSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	result := describeSchedule(c, domain, scheduleID)
+
+	output := map[string]interface{}{
+		"scheduleID":       result.ScheduleID,
+		"cronSchedule":     result.Spec.CronSchedule,
+		"overlapPolicy":    result.Spec.OverlapPolicy,
+		"workflowType":     result.Spec.Action.WorkflowType,
+		"taskList":         result.Spec.Action.TaskList,
+		"input":            string(result.Spec.Action.Input),
+		"executionTimeout": result.Spec.Action.ExecutionStartToCloseTimeoutSeconds,
+		"decisionTimeout":  result.Spec.Action.TaskStartToCloseTimeoutSeconds,
+		"paused":           result.State.Paused,
+		"totalRuns":        result.State.TotalRuns,
+		"skippedRuns":      result.State.SkippedRuns,
+		"failedRuns":       result.State.FailedRuns,
+	}
+	if result.State.Paused {
+		output["pauseReason"] = result.State.PauseReason
+	} else {
+		output["nextRunTime"] = convertTime(result.NextRunTime.UnixNano(), false)
+	}
+	if result.State.LastRun != nil {
+		output["lastRun"] = result.State.LastRun
+	}
+	if result.State.BufferedTime != nil {
+		output["bufferedTime"] = convertTime(result.State.BufferedTime.UnixNano(), false)
+	}
+	prettyPrintJSONObject(output)
+}
+
+// UpdateSchedule updates the spec of a schedule
+func UpdateSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	spec := describeSchedule(c, domain, scheduleID).Spec
+
+	if c.IsSet(FlagCronSchedule) {
+		spec.CronSchedule = c.String(FlagCronSchedule)
+	}
+	if c.IsSet(FlagOverlapPolicy) {
+		spec.OverlapPolicy = c.String(FlagOverlapPolicy)
+	}
+	if c.IsSet(FlagWorkflowType) {
+		spec.Action.WorkflowType = c.String(FlagWorkflowType)
+	}
+	if c.IsSet(FlagTaskList) {
+		spec.Action.TaskList = c.String(FlagTaskList)
+	}
+	if c.IsSet(FlagInput) || c.IsSet(FlagInputFile) {
+		spec.Action.Input = []byte(processJSONInput(c))
+	}
+	if c.IsSet(FlagExecutionTimeout) {
+		spec.Action.ExecutionStartToCloseTimeoutSeconds = int32(c.Int(FlagExecutionTimeout))
+	}
+	if c.IsSet(FlagDecisionTimeout) {
+		spec.Action.TaskStartToCloseTimeoutSeconds = int32(c.Int(FlagDecisionTimeout))
+	}
+	if _, err := scheduler.ValidateSpec(&spec); err != nil {
+		ErrorAndExit("Invalid schedule", err)
+	}
+
+	signalSchedule(c, domain, scheduleID, scheduler.UpdateSignal, spec)
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg": "schedule is updated",
+	})
+}
+
+// PauseSchedule pauses a schedule
+func PauseSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	reason := getRequiredOption(c, FlagReason)
+
+	signalSchedule(c, domain, scheduleID, scheduler.PauseSignal, reason)
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg": "schedule is paused",
+	})
+}
+
+// UnpauseSchedule resumes a paused schedule
+func UnpauseSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+
+	signalSchedule(c, domain, scheduleID, scheduler.UnpauseSignal, nil)
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg": "schedule is unpaused",
+	})
+}
+
+// DeleteSchedule deletes a schedule
+func DeleteSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	reason := getRequiredOption(c, FlagReason)
+
+	signalSchedule(c, domain, scheduleID, scheduler.DeleteSignal, reason)
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg": "schedule deletion is requested",
+	})
+}
+
+// ListSchedules lists the schedules of a domain
+func ListSchedules(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	pageSize := c.Int(FlagPageSize)
+	svcClient := cFactory.ServerFrontendClient(c)
+
+	output := make([]interface{}, 0)
+	var nextPageToken []byte
+	for more := true; more; more = len(nextPageToken) > 0 {
+		tcCtx, cancel := newContext(c)
+		resp, err := svcClient.ListWorkflowExecutions(
+			tcCtx,
+			&types.ListWorkflowExecutionsRequest{
+				Domain:        common.SchedulerLocalDomainName,
+				PageSize:      int32(pageSize),
+				NextPageToken: nextPageToken,
+				Query:         fmt.Sprintf("CustomDomain = '%v'", domain),
+			},
+		)
+		cancel()
+		if err != nil {
+			ErrorAndExit("Failed to list schedules", err)
+		}
+		for _, wf := range resp.Executions {
+			// closed executions are either deleted schedules or the runs before continuing as new
+			if wf.CloseStatus != nil {
+				continue
+			}
+			output = append(output, map[string]string{
+				"scheduleID": strings.TrimPrefix(wf.Execution.GetWorkflowID(), scheduler.GetWorkflowID(domain, "")),
+				"operator":   string(wf.SearchAttributes.IndexedFields["Operator"]),
+			})
+		}
+		nextPageToken = resp.NextPageToken
+	}
+	prettyPrintJSONObject(output)
+}
+
+// BackfillSchedule starts the workflows of a schedule which were due in a time range in the past
+func BackfillSchedule(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	scheduleID := getRequiredOption(c, FlagScheduleID)
+	startTime := time.Unix(0, parseTime(getRequiredOption(c, FlagEarliestTime), 0)).UTC()
+	endTime := time.Unix(0, parseTime(c.String(FlagLatestTime), time.Now().UnixNano())).UTC()
+	overlapPolicy := c.String(FlagOverlapPolicy)
+
+	if endTime.Before(startTime) {
+		ErrorAndExit(fmt.Sprintf("Option %v must not be later than %v", FlagEarliestTime, FlagLatestTime), nil)
+	}
+	if !scheduler.IsValidOverlapPolicy(overlapPolicy) {
+		ErrorAndExit("Overlap policy is not valid, supported: "+strings.Join(scheduler.AllOverlapPolicies, ","), nil)
+	}
+
+	result := describeSchedule(c, domain, scheduleID)
+	schedule, err := backoff.ValidateSchedule(result.Spec.CronSchedule)
+	if err != nil {
+		ErrorAndExit("Invalid cron schedule", err)
+	}
+	times := scheduler.GetScheduledTimes(schedule, startTime, endTime, scheduler.MaxBackfillActions+1)
+	if len(times) == 0 {
+		ErrorAndExit("The schedule is not due in the time range", nil)
+	}
+	if len(times) > scheduler.MaxBackfillActions {
+		ErrorAndExit(fmt.Sprintf("The schedule is due more than %v times in the time range, please use a smaller one", scheduler.MaxBackfillActions), nil)
+	}
+
+	signalSchedule(c, domain, scheduleID, scheduler.BackfillSignal, scheduler.BackfillRequest{
+		StartTime:     startTime,
+		EndTime:       endTime,
+		OverlapPolicy: overlapPolicy,
+	})
+	prettyPrintJSONObject(map[string]interface{}{
+		"msg":  "schedule backfill is requested",
+		"runs": len(times),
+	})
+}
+
+func describeSchedule(c *cli.Context, domain, scheduleID string) *scheduler.DescribeResult {
+	svcClient := cFactory.ServerFrontendClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+
+	resp, err := svcClient.QueryWorkflow(tcCtx, &types.QueryWorkflowRequest{
+		Domain: common.SchedulerLocalDomainName,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: scheduler.GetWorkflowID(domain, scheduleID),
+		},
+		Query: &types.WorkflowQuery{
+			QueryType: scheduler.QueryType,
+		},
+		QueryRejectCondition: types.QueryRejectConditionNotOpen.Ptr(),
+	})
+	if err != nil {
+		ErrorAndExit("Failed to describe schedule", err)
+	}
+	if resp.GetQueryRejected() != nil {
+		ErrorAndExit(fmt.Sprintf("Schedule %v is deleted", scheduleID), nil)
+	}
+	var result scheduler.DescribeResult
+	if err := json.Unmarshal(resp.GetQueryResult(), &result); err != nil {
+		ErrorAndExit("Failed to decode schedule", err)
+	}
+	return &result
+}
+
+func signalSchedule(c *cli.Context, domain, scheduleID, signalName string, payload interface{}) {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		ErrorAndExit("Failed to encode signal input", err)
+	}
+
+	svcClient := cFactory.ServerFrontendClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+
+	err = svcClient.SignalWorkflowExecution(tcCtx, &types.SignalWorkflowExecutionRequest{
+		Domain: common.SchedulerLocalDomainName,
+		WorkflowExecution: &types.WorkflowExecution{
+			WorkflowID: scheduler.GetWorkflowID(domain, scheduleID),
+		},
+		SignalName: signalName,
+		Input:      input,
+		Identity:   getCliIdentity(),
+		RequestID:  uuid.New(),
+	})
+	if err != nil {
+		ErrorAndExit("Failed to signal schedule", err)
+	}
+}
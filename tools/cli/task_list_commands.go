@@ -21,11 +21,14 @@
 package cli
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/urfave/cli"
 
+	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -64,6 +67,8 @@ func DescribeTaskList(c *cli.Context) {
 		ErrorAndExit("Operation DescribeTaskList failed.", err)
 	}
 
+	printNumTaskListPartitions(ctx, wfClient, domain, taskList, taskListType)
+
 	pollers := response.Pollers
 	if len(pollers) == 0 {
 		ErrorAndExit(colorMagenta("No poller for tasklist: "+taskList), nil)
@@ -97,6 +102,25 @@ func ListTaskListPartitions(c *cli.Context) {
 	}
 }
 
+// printNumTaskListPartitions prints the current number of partitions of the task list,
+// which changes over time when the partitions are scaled by matching
+func printNumTaskListPartitions(ctx context.Context, client frontend.Client, domain string, taskList string, taskListType types.TaskListType) {
+	response, err := client.ListTaskListPartitions(ctx, &types.ListTaskListPartitionsRequest{
+		Domain:   domain,
+		TaskList: &types.TaskList{Name: taskList},
+	})
+	if err != nil {
+		// the partitions are only informational here
+		fmt.Printf("Failed to get task list partitions: %v\n\n", err)
+		return
+	}
+	partitions := response.DecisionTaskListPartitions
+	if taskListType == types.TaskListTypeActivity {
+		partitions = response.ActivityTaskListPartitions
+	}
+	fmt.Printf("Partitions: %v\n\n", len(partitions))
+}
+
 func printTaskListPollers(pollers []*types.PollerInfo, taskListType types.TaskListType) {
 	table := []TaskListPollerRow{}
 	for _, poller := range pollers {
@@ -40,10 +40,13 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"github.com/pborman/uuid"
 	"github.com/urfave/cli"
+	"go.uber.org/yarpc"
 
 	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/service/history/execution"
 )
@@ -722,6 +725,15 @@ func CountWorkflow(c *cli.Context) {
 
 	domain := getRequiredGlobalOption(c, FlagDomain)
 	query := c.String(FlagListQuery)
+	if groupBy := c.String(FlagGroupBy); groupBy != "" {
+		countWorkflowGroupBy(c, wfClient, domain, query, groupBy)
+		return
+	}
+
+	fmt.Println(countWorkflowExecutions(c, wfClient, domain, query))
+}
+
+func countWorkflowExecutions(c *cli.Context, wfClient frontend.Client, domain, query string) int64 {
 	request := &types.CountWorkflowExecutionsRequest{
 		Domain: domain,
 		Query:  query,
@@ -733,8 +745,63 @@ func CountWorkflow(c *cli.Context) {
 	if err != nil {
 		ErrorAndExit("Failed to count workflow.", err)
 	}
+	return response.GetCount()
+}
+
+// countWorkflowGroupBy prints the number of workflows matching the query for each value of the groupBy field.
+// The groups are counted by the visibility store, they are requested and returned through experimental headers of the
+// count call until the API has fields for them. Servers without the headers ignore the request header, so the count
+// fails instead of printing the total without groups.
+func countWorkflowGroupBy(c *cli.Context, wfClient frontend.Client, domain, query, groupBy string) {
+	groupBy = strings.TrimPrefix(groupBy, definition.Attr+".")
+	request := &types.CountWorkflowExecutionsRequest{
+		Domain: domain,
+		Query:  query,
+	}
+
+	ctx, cancel := newContextForLongPoll(c)
+	defer cancel()
+	var headers map[string]string
+	response, err := wfClient.CountWorkflowExecutions(ctx, request,
+		yarpc.WithHeader(common.CountGroupByHeaderName, groupBy), yarpc.ResponseHeaders(&headers))
+	if err != nil {
+		ErrorAndExit("Failed to count workflow.", err)
+	}
+	encodedGroups, ok := headers[common.CountGroupsHeaderName]
+	if !ok {
+		ErrorAndExit("Failed to count workflow.", fmt.Errorf("server does not support counting workflows by %s, "+
+			"it didn't return the experimental %s response header", groupBy, common.CountGroupsHeaderName))
+	}
+	var groups []*persistence.WorkflowExecutionCountGroup
+	if err := json.Unmarshal([]byte(encodedGroups), &groups); err != nil {
+		ErrorAndExit("Failed to decode workflow count groups.", err)
+	}
+
+	type WorkflowCountGroupRow struct {
+		Value string `header:"Value"`
+		Count int64  `header:"Count"`
+	}
+	rows := make([]WorkflowCountGroupRow, 0, len(groups)+1)
+	var grouped int64
+	for _, group := range groups {
+		value := group.Value
+		if groupBy == definition.CloseStatus && value == "" {
+			value = workflowCountGroupOpen
+		}
+		rows = append(rows, WorkflowCountGroupRow{Value: value, Count: group.Count})
+		grouped += group.Count
+	}
+	// only the largest groups are returned, the rest of the workflows are counted together
+	if len(groups) >= persistence.MaxWorkflowExecutionCountGroups && response.GetCount() > grouped {
+		rows = append(rows, WorkflowCountGroupRow{Value: workflowCountGroupOther, Count: response.GetCount() - grouped})
+	}
 
-	fmt.Println(response.GetCount())
+	Render(c, rows, RenderOptions{
+		DefaultTemplate: templateTable,
+		Color:           true,
+		Border:          true,
+		ColumnAlignment: []int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT},
+	})
 }
 
 // ListArchivedWorkflow lists archived workflow executions based on filters